@@ -0,0 +1,13 @@
+-- sealed_key is the vault key of the owner sealed to the public key of the grantee, NULL until access is granted
+CREATE TABLE IF NOT EXISTS emergency_access
+(
+    owner_id     TEXT    NOT NULL,
+    grantee_id   TEXT    NOT NULL,
+    status       TEXT    NOT NULL,
+    wait_days    INTEGER NOT NULL,
+    requested_at INTEGER NOT NULL DEFAULT 0,
+    sealed_key   BLOB,
+    PRIMARY KEY (owner_id, grantee_id),
+    FOREIGN KEY (owner_id) REFERENCES users (id),
+    FOREIGN KEY (grantee_id) REFERENCES users (id)
+);
@@ -0,0 +1,22 @@
+-- data is the event sealed with the key of the vault it happened in, hash chains every record to the previous one
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    seq        INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    TEXT    NOT NULL,
+    created_at INTEGER NOT NULL,
+    data       TEXT    NOT NULL,
+    prev_hash  TEXT    NOT NULL,
+    hash       TEXT    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_user_id ON audit_log (user_id, seq);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit log is append-only');
+END;
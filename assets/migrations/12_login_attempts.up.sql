@@ -0,0 +1,7 @@
+-- attempts are kept per hashed username whether a user of that name exists or not, so they reveal nothing about it
+CREATE TABLE IF NOT EXISTS login_attempts
+(
+    username_hash TEXT PRIMARY KEY,
+    failures      INTEGER NOT NULL,
+    last_failure  INTEGER NOT NULL
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS generator_profiles;
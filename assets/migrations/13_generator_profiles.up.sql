@@ -0,0 +1,20 @@
+-- symbol_set is empty for the default symbols, at most one profile of a user is active
+CREATE TABLE IF NOT EXISTS generator_profiles
+(
+    user_id           TEXT    NOT NULL,
+    name              TEXT    NOT NULL,
+    active            INTEGER NOT NULL DEFAULT 0,
+    length            INTEGER NOT NULL,
+    lower             INTEGER NOT NULL,
+    upper             INTEGER NOT NULL,
+    digits            INTEGER NOT NULL,
+    symbols           INTEGER NOT NULL,
+    min_lower         INTEGER NOT NULL DEFAULT 0,
+    min_upper         INTEGER NOT NULL DEFAULT 0,
+    min_digits        INTEGER NOT NULL DEFAULT 0,
+    min_symbols       INTEGER NOT NULL DEFAULT 0,
+    symbol_set        TEXT    NOT NULL DEFAULT '',
+    exclude_ambiguous INTEGER NOT NULL DEFAULT 0,
+    no_repeat         INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, name)
+);
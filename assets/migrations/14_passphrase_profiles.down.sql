@@ -0,0 +1,7 @@
+ALTER TABLE generator_profiles DROP COLUMN append_symbol;
+ALTER TABLE generator_profiles DROP COLUMN append_digit;
+ALTER TABLE generator_profiles DROP COLUMN capitalization;
+ALTER TABLE generator_profiles DROP COLUMN separator;
+ALTER TABLE generator_profiles DROP COLUMN wordlist;
+ALTER TABLE generator_profiles DROP COLUMN words;
+ALTER TABLE generator_profiles DROP COLUMN mode;
@@ -0,0 +1,8 @@
+-- profiles saved before passphrases generate characters, switching to passphrase mode uses the defaults below
+ALTER TABLE generator_profiles ADD COLUMN mode TEXT NOT NULL DEFAULT 'characters';
+ALTER TABLE generator_profiles ADD COLUMN words INTEGER NOT NULL DEFAULT 6;
+ALTER TABLE generator_profiles ADD COLUMN wordlist TEXT NOT NULL DEFAULT 'eff-large';
+ALTER TABLE generator_profiles ADD COLUMN separator TEXT NOT NULL DEFAULT '-';
+ALTER TABLE generator_profiles ADD COLUMN capitalization TEXT NOT NULL DEFAULT 'none';
+ALTER TABLE generator_profiles ADD COLUMN append_digit INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE generator_profiles ADD COLUMN append_symbol INTEGER NOT NULL DEFAULT 0;
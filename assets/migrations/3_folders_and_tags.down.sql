@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS password_tags;
+DROP TABLE IF EXISTS password_folders;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS folders;
+DROP INDEX IF EXISTS passwords_id_idx;
+ALTER TABLE passwords DROP COLUMN id;
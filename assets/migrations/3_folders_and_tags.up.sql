@@ -0,0 +1,38 @@
+ALTER TABLE passwords ADD COLUMN id TEXT NOT NULL DEFAULT '';
+UPDATE passwords SET id = lower(hex(randomblob(16))) WHERE id = '';
+CREATE UNIQUE INDEX IF NOT EXISTS passwords_id_idx ON passwords (id);
+
+CREATE TABLE IF NOT EXISTS folders
+(
+    id        TEXT PRIMARY KEY,
+    user_id   TEXT NOT NULL,
+    parent_id TEXT NOT NULL DEFAULT '',
+    name      TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS tags
+(
+    id      TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name    TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS password_folders
+(
+    password_id TEXT NOT NULL,
+    folder_id   TEXT NOT NULL,
+    PRIMARY KEY (password_id, folder_id),
+    FOREIGN KEY (password_id) REFERENCES passwords (id),
+    FOREIGN KEY (folder_id) REFERENCES folders (id)
+);
+
+CREATE TABLE IF NOT EXISTS password_tags
+(
+    password_id TEXT NOT NULL,
+    tag_id      TEXT NOT NULL,
+    PRIMARY KEY (password_id, tag_id),
+    FOREIGN KEY (password_id) REFERENCES passwords (id),
+    FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
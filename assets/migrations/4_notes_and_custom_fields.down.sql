@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS password_fields;
+ALTER TABLE passwords DROP COLUMN notes;
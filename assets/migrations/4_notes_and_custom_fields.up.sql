@@ -0,0 +1,12 @@
+ALTER TABLE passwords ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS password_fields
+(
+    password_id TEXT    NOT NULL,
+    position    INTEGER NOT NULL,
+    type        TEXT    NOT NULL,
+    name        TEXT    NOT NULL,
+    value       TEXT    NOT NULL,
+    PRIMARY KEY (password_id, position),
+    FOREIGN KEY (password_id) REFERENCES passwords (id)
+);
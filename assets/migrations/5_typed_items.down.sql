@@ -0,0 +1,13 @@
+-- typed items keep their fields in the encrypted payload, which logins cannot hold. Rather than
+-- deleting them, the downgrade is refused until they are exported and removed.
+CREATE TEMP TABLE typed_items_guard (id TEXT);
+CREATE TEMP TRIGGER typed_items_guard_abort BEFORE INSERT ON typed_items_guard
+BEGIN
+    SELECT RAISE(ABORT, 'cannot downgrade: the vault holds items other than logins, export and delete them first');
+END;
+INSERT INTO typed_items_guard SELECT id FROM passwords WHERE type != 'login';
+DROP TRIGGER typed_items_guard_abort;
+DROP TABLE typed_items_guard;
+
+ALTER TABLE passwords DROP COLUMN payload;
+ALTER TABLE passwords DROP COLUMN type;
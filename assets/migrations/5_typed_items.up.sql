@@ -0,0 +1,2 @@
+ALTER TABLE passwords ADD COLUMN type TEXT NOT NULL DEFAULT 'login';
+ALTER TABLE passwords ADD COLUMN payload TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS attachments_password_id;
+DROP TABLE IF EXISTS attachments;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS attachments
+(
+    id          TEXT PRIMARY KEY,
+    password_id TEXT    NOT NULL,
+    name        TEXT    NOT NULL,
+    size        INTEGER NOT NULL,
+    blob        TEXT    NOT NULL,
+    FOREIGN KEY (password_id) REFERENCES passwords (id)
+);
+
+CREATE INDEX IF NOT EXISTS attachments_password_id ON attachments (password_id);
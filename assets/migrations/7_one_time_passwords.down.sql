@@ -0,0 +1,2 @@
+ALTER TABLE passwords DROP COLUMN otp_counter;
+ALTER TABLE passwords DROP COLUMN otp;
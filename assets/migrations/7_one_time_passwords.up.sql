@@ -0,0 +1,2 @@
+ALTER TABLE passwords ADD COLUMN otp TEXT NOT NULL DEFAULT '';
+ALTER TABLE passwords ADD COLUMN otp_counter INTEGER NOT NULL DEFAULT 0;
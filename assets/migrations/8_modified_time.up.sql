@@ -0,0 +1 @@
+ALTER TABLE passwords ADD COLUMN modified_at INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,59 @@
+DELETE FROM password_fields WHERE password_id IN (SELECT id FROM passwords WHERE user_id IN (SELECT id FROM collections));
+DELETE FROM password_folders WHERE password_id IN (SELECT id FROM passwords WHERE user_id IN (SELECT id FROM collections));
+DELETE FROM password_tags WHERE password_id IN (SELECT id FROM passwords WHERE user_id IN (SELECT id FROM collections));
+DELETE FROM attachments WHERE password_id IN (SELECT id FROM passwords WHERE user_id IN (SELECT id FROM collections));
+DELETE FROM passwords WHERE user_id IN (SELECT id FROM collections);
+DELETE FROM folders WHERE user_id IN (SELECT id FROM collections);
+DELETE FROM tags WHERE user_id IN (SELECT id FROM collections);
+
+CREATE TABLE passwords_owned
+(
+    user_id     TEXT    NOT NULL,
+    title       TEXT    NOT NULL,
+    username    TEXT    NOT NULL,
+    password    TEXT    NOT NULL,
+    url         TEXT,
+    nonce       BLOB,
+    id          TEXT    NOT NULL DEFAULT '',
+    notes       TEXT    NOT NULL DEFAULT '',
+    type        TEXT    NOT NULL DEFAULT 'login',
+    payload     TEXT    NOT NULL DEFAULT '',
+    otp         TEXT    NOT NULL DEFAULT '',
+    otp_counter INTEGER NOT NULL DEFAULT 0,
+    modified_at INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, title, username),
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+INSERT INTO passwords_owned (user_id, title, username, password, url, nonce, id, notes, type, payload, otp, otp_counter, modified_at)
+SELECT user_id, title, username, password, url, nonce, id, notes, type, payload, otp, otp_counter, modified_at
+FROM passwords;
+DROP TABLE passwords;
+ALTER TABLE passwords_owned RENAME TO passwords;
+CREATE UNIQUE INDEX IF NOT EXISTS passwords_id_idx ON passwords (id);
+
+CREATE TABLE folders_owned
+(
+    id        TEXT PRIMARY KEY,
+    user_id   TEXT NOT NULL,
+    parent_id TEXT NOT NULL DEFAULT '',
+    name      TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+INSERT INTO folders_owned (id, user_id, parent_id, name) SELECT id, user_id, parent_id, name FROM folders;
+DROP TABLE folders;
+ALTER TABLE folders_owned RENAME TO folders;
+
+CREATE TABLE tags_owned
+(
+    id      TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name    TEXT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+INSERT INTO tags_owned (id, user_id, name) SELECT id, user_id, name FROM tags;
+DROP TABLE tags;
+ALTER TABLE tags_owned RENAME TO tags;
+
+DROP TABLE IF EXISTS collection_members;
+DROP TABLE IF EXISTS collections;
+DROP TABLE IF EXISTS user_keys;
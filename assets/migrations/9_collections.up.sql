@@ -0,0 +1,72 @@
+CREATE TABLE IF NOT EXISTS user_keys
+(
+    user_id     TEXT PRIMARY KEY,
+    public_key  BLOB NOT NULL,
+    private_key BLOB NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+-- Entries, folders and tags of a collection are stored with the collection ID as their user_id
+CREATE TABLE IF NOT EXISTS collections
+(
+    id   TEXT PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS collection_members
+(
+    collection_id TEXT NOT NULL,
+    user_id       TEXT NOT NULL,
+    role          TEXT NOT NULL,
+    sealed_key    BLOB NOT NULL,
+    PRIMARY KEY (collection_id, user_id),
+    FOREIGN KEY (collection_id) REFERENCES collections (id),
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+-- Entries, folders and tags belong to a user or to a collection now, user_id holds the ID of either of
+-- them. It referenced users, which entries of collections violate, so the tables are rebuilt without that key.
+CREATE TABLE passwords_owned
+(
+    user_id     TEXT    NOT NULL,
+    title       TEXT    NOT NULL,
+    username    TEXT    NOT NULL,
+    password    TEXT    NOT NULL,
+    url         TEXT,
+    nonce       BLOB,
+    id          TEXT    NOT NULL DEFAULT '',
+    notes       TEXT    NOT NULL DEFAULT '',
+    type        TEXT    NOT NULL DEFAULT 'login',
+    payload     TEXT    NOT NULL DEFAULT '',
+    otp         TEXT    NOT NULL DEFAULT '',
+    otp_counter INTEGER NOT NULL DEFAULT 0,
+    modified_at INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, title, username)
+);
+INSERT INTO passwords_owned (user_id, title, username, password, url, nonce, id, notes, type, payload, otp, otp_counter, modified_at)
+SELECT user_id, title, username, password, url, nonce, id, notes, type, payload, otp, otp_counter, modified_at
+FROM passwords;
+DROP TABLE passwords;
+ALTER TABLE passwords_owned RENAME TO passwords;
+CREATE UNIQUE INDEX IF NOT EXISTS passwords_id_idx ON passwords (id);
+
+CREATE TABLE folders_owned
+(
+    id        TEXT PRIMARY KEY,
+    user_id   TEXT NOT NULL,
+    parent_id TEXT NOT NULL DEFAULT '',
+    name      TEXT NOT NULL
+);
+INSERT INTO folders_owned (id, user_id, parent_id, name) SELECT id, user_id, parent_id, name FROM folders;
+DROP TABLE folders;
+ALTER TABLE folders_owned RENAME TO folders;
+
+CREATE TABLE tags_owned
+(
+    id      TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name    TEXT NOT NULL
+);
+INSERT INTO tags_owned (id, user_id, name) SELECT id, user_id, name FROM tags;
+DROP TABLE tags;
+ALTER TABLE tags_owned RENAME TO tags;
@@ -1,18 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"yubigo-pass/internal/app/cli"
+	"yubigo-pass/internal/app/command"
 	"yubigo-pass/internal/app/services"
+	"yubigo-pass/internal/app/vault"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/sirupsen/logrus"
 )
 
 // main is the entry point of the yubigo-pass application.
-// It sets up services, initializes the main Bubble Tea application model,
-// and runs the TUI program loop.
+// It sets up services and either runs the subcommand given on the command line,
+// or initializes the main Bubble Tea application model and runs the TUI program loop.
+// Scheduled backups are taken before a subcommand runs and in the background of the TUI.
+// A tampered audit log is reported before a subcommand runs and on every screen of the TUI.
 func main() {
 	setupLogging() // Configure logging early
 
@@ -23,8 +28,25 @@ func main() {
 		os.Exit(1)
 	}
 
+	if len(os.Args) > 1 {
+		if _, _, err := container.Backups.TakeIfDue(); err != nil {
+			logrus.Errorf("Scheduled backup failed: %v", err)
+		}
+		if err := vault.VerifyAuditLog(container.Store); err != nil {
+			logrus.Errorf("Audit log verification failed: %v", err)
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		os.Exit(command.Run(command.NewEnv(container), os.Args[1:]))
+	}
+
 	logrus.Info("Application starting...")
 
+	ctx, stopBackups := context.WithCancel(context.Background())
+	defer stopBackups()
+	go container.Backups.Schedule(ctx, func(err error) {
+		logrus.Errorf("Scheduled backup failed: %v", err)
+	})
+
 	appModel := cli.NewAppModel(container)
 	program := tea.NewProgram(appModel, tea.WithAltScreen())
 
@@ -0,0 +1,224 @@
+// Package archive reads and writes portable vault archives. An archive starts with a plaintext
+// JSON header line naming the format and the Argon2id parameters its key is derived with,
+// followed by the vault document sealed in an authenticated stream under that key. The header
+// is bound to the stream as associated data, so tampering with the parameters fails to open.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"yubigo-pass/internal/app/crypto"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Format identifies yubigo-pass archives in their header
+const Format = "yubigo-pass-archive"
+
+// Version is the version of the archive layout written
+const Version = 1
+
+// KDFArgon2id is the only key derivation archives are written with
+const KDFArgon2id = "argon2id"
+
+// CipherAESGCMStream names the chunked AES-256-GCM stream the document is sealed in
+const CipherAESGCMStream = "aes-256-gcm-stream"
+
+// maxHeaderSize bounds the header line, so a file of another format is not read whole
+const maxHeaderSize = 4096
+
+// Limits on the key derivation parameters read from an archive, so a crafted header
+// cannot make opening it exhaust the machine
+const (
+	maxIterations = 100
+	maxMemory     = 4 * 1024 * 1024
+)
+
+// Errors returned when an archive cannot be opened
+var (
+	ErrNotArchive           = errors.New("not a yubigo-pass archive")
+	ErrWrongPassphrase      = errors.New("wrong passphrase or corrupted archive")
+	ErrUnsupportedVersion   = errors.New("archive was written by a newer version of yubigo-pass")
+	ErrPassphraseRequired   = errors.New("archive passphrase must not be empty")
+	errUnsupportedParameter = errors.New("unsupported archive parameters")
+)
+
+// KDF holds the Argon2id parameters the archive key is derived with. Memory is in KiB.
+type KDF struct {
+	Algorithm   string `json:"algorithm"`
+	Iterations  uint32 `json:"iterations"`
+	Memory      uint32 `json:"memory"`
+	Parallelism uint8  `json:"parallelism"`
+	Salt        []byte `json:"salt"`
+}
+
+// DefaultKDF are the parameters archives are written with, following the second
+// recommended option of RFC 9106
+var DefaultKDF = KDF{Algorithm: KDFArgon2id, Iterations: 3, Memory: 64 * 1024, Parallelism: 4}
+
+// key derives the archive key from the passphrase
+func (k KDF) key(passphrase string) []byte {
+	return argon2.IDKey([]byte(passphrase), k.Salt, k.Iterations, k.Memory, k.Parallelism, 32)
+}
+
+// validate checks the parameters are supported and within bounds
+func (k KDF) validate() error {
+	switch {
+	case k.Algorithm != KDFArgon2id:
+		return fmt.Errorf("%w: key derivation %q", errUnsupportedParameter, k.Algorithm)
+	case k.Iterations == 0 || k.Iterations > maxIterations:
+		return fmt.Errorf("%w: %d iterations", errUnsupportedParameter, k.Iterations)
+	case k.Memory < 8*uint32(k.Parallelism) || k.Memory > maxMemory:
+		return fmt.Errorf("%w: %d KiB of memory", errUnsupportedParameter, k.Memory)
+	case k.Parallelism == 0:
+		return fmt.Errorf("%w: parallelism of 0", errUnsupportedParameter)
+	case len(k.Salt) < 16:
+		return fmt.Errorf("%w: salt shorter than 16 bytes", errUnsupportedParameter)
+	}
+	return nil
+}
+
+// Header describes how the rest of the archive is sealed
+type Header struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	KDF     KDF       `json:"kdf"`
+	Cipher  string    `json:"cipher"`
+}
+
+// Document is the sealed content of an archive, every entry of a vault in plaintext
+type Document struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is a vault entry with its custom fields, placement and attachments. Values hold
+// the fields of the item type, keyed as the vault keys them.
+type Entry struct {
+	Type        string            `json:"type"`
+	Title       string            `json:"title"`
+	Notes       string            `json:"notes,omitempty"`
+	Values      map[string]string `json:"values,omitempty"`
+	Fields      []Field           `json:"fields,omitempty"`
+	Folder      string            `json:"folder,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Attachments []Attachment      `json:"attachments,omitempty"`
+}
+
+// Field is a custom field of an entry
+type Field struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Attachment is a file attached to an entry
+type Attachment struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// Write seals the document under a key derived from the passphrase with the given parameters,
+// drawing a fresh salt, and writes the archive to w
+func Write(w io.Writer, doc Document, passphrase string, kdf KDF) error {
+	if passphrase == "" {
+		return ErrPassphraseRequired
+	}
+	kdf.Algorithm = KDFArgon2id
+	kdf.Salt = make([]byte, 16)
+	if _, err := rand.Read(kdf.Salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	if err := kdf.validate(); err != nil {
+		return err
+	}
+
+	header, err := json.Marshal(Header{
+		Format:  Format,
+		Version: Version,
+		Created: time.Now().UTC().Truncate(time.Second),
+		KDF:     kdf,
+		Cipher:  CipherAESGCMStream,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	header = append(header, '\n')
+	content, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	if _, err = w.Write(header); err != nil {
+		return err
+	}
+	_, err = crypto.NewCipher(kdf.key(passphrase)).EncryptStream(w, bytes.NewReader(content), header)
+	return err
+}
+
+// ReadHeader reads the header of an archive without opening it
+func ReadHeader(r io.Reader) (Header, error) {
+	header, _, err := readHeader(bufio.NewReader(r))
+	return header, err
+}
+
+// Read opens an archive with the passphrase it was sealed under
+func Read(r io.Reader, passphrase string) (Header, Document, error) {
+	in := bufio.NewReader(r)
+	header, raw, err := readHeader(in)
+	if err != nil {
+		return Header{}, Document{}, err
+	}
+	if passphrase == "" {
+		return Header{}, Document{}, ErrPassphraseRequired
+	}
+
+	var content bytes.Buffer
+	_, err = crypto.NewCipher(header.KDF.key(passphrase)).DecryptStream(&content, in, raw)
+	if errors.Is(err, crypto.ErrStreamCorrupted) {
+		return Header{}, Document{}, ErrWrongPassphrase
+	}
+	if err != nil {
+		return Header{}, Document{}, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	var doc Document
+	if err = json.Unmarshal(content.Bytes(), &doc); err != nil {
+		return Header{}, Document{}, fmt.Errorf("failed to read archive: %w", err)
+	}
+	return header, doc, nil
+}
+
+// readHeader reads and checks the header line, returning it parsed and as written
+func readHeader(in *bufio.Reader) (Header, []byte, error) {
+	peeked, _ := in.Peek(maxHeaderSize)
+	end := bytes.IndexByte(peeked, '\n')
+	if end < 0 {
+		return Header{}, nil, ErrNotArchive
+	}
+	raw := make([]byte, end+1)
+	if _, err := io.ReadFull(in, raw); err != nil {
+		return Header{}, nil, ErrNotArchive
+	}
+
+	var header Header
+	if err := json.Unmarshal(raw, &header); err != nil || header.Format != Format {
+		return Header{}, nil, ErrNotArchive
+	}
+	if header.Version > Version {
+		return Header{}, nil, ErrUnsupportedVersion
+	}
+	if header.Cipher != CipherAESGCMStream {
+		return Header{}, nil, fmt.Errorf("%w: cipher %q", errUnsupportedParameter, header.Cipher)
+	}
+	if err := header.KDF.validate(); err != nil {
+		return Header{}, nil, err
+	}
+	return header, raw, nil
+}
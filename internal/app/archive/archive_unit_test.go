@@ -0,0 +1,157 @@
+//go:build unit
+
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKDF keeps the key derivation cheap for tests
+var testKDF = KDF{Iterations: 1, Memory: 64, Parallelism: 1}
+
+func testDocument() Document {
+	return Document{Entries: []Entry{{
+		Type:        "login",
+		Title:       "GitHub",
+		Notes:       "personal",
+		Values:      map[string]string{"username": "octocat", "password": "hunter2"},
+		Fields:      []Field{{Type: "hidden", Name: "PIN", Value: "4242"}},
+		Folder:      "Work/Dev",
+		Tags:        []string{"code"},
+		Attachments: []Attachment{{Name: "recovery.txt", Data: []byte{0, 1, 2, 0xff}}},
+	}}}
+}
+
+func writeArchive(t *testing.T, passphrase string) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, testDocument(), passphrase, testKDF))
+	return buf.Bytes()
+}
+
+func TestWriteShouldRoundTripDocument(t *testing.T) {
+	// given
+	data := writeArchive(t, "correct horse")
+
+	// when
+	header, doc, err := Read(bytes.NewReader(data), "correct horse")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, testDocument(), doc)
+	assert.Equal(t, Format, header.Format)
+	assert.Equal(t, Version, header.Version)
+	assert.Equal(t, KDFArgon2id, header.KDF.Algorithm)
+	assert.Len(t, header.KDF.Salt, 16)
+	assert.NotContains(t, string(data), "hunter2")
+}
+
+func TestReadHeaderShouldDescribeArchiveWithoutPassphrase(t *testing.T) {
+	// given
+	data := writeArchive(t, "correct horse")
+
+	// when
+	header, err := ReadHeader(bytes.NewReader(data))
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), header.KDF.Iterations)
+	assert.Equal(t, uint32(64), header.KDF.Memory)
+	assert.Equal(t, uint8(1), header.KDF.Parallelism)
+	assert.Equal(t, CipherAESGCMStream, header.Cipher)
+}
+
+func TestReadShouldRejectWrongPassphrase(t *testing.T) {
+	// given
+	data := writeArchive(t, "correct horse")
+
+	// when
+	_, _, err := Read(bytes.NewReader(data), "battery staple")
+
+	// then
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+}
+
+func TestReadShouldRejectTamperedHeader(t *testing.T) {
+	// given
+	data := writeArchive(t, "correct horse")
+	line, rest, _ := bytes.Cut(data, []byte("\n"))
+	var header Header
+	require.NoError(t, json.Unmarshal(line, &header))
+	header.Created = header.Created.AddDate(-1, 0, 0)
+	tampered, err := json.Marshal(header)
+	require.NoError(t, err)
+
+	// when
+	_, _, err = Read(bytes.NewReader(append(append(tampered, '\n'), rest...)), "correct horse")
+
+	// then
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+}
+
+func TestReadShouldRejectTruncatedArchive(t *testing.T) {
+	// given
+	data := writeArchive(t, "correct horse")
+
+	// when
+	_, _, err := Read(bytes.NewReader(data[:len(data)-10]), "correct horse")
+
+	// then
+	assert.Error(t, err)
+}
+
+func TestReadShouldRejectOtherFiles(t *testing.T) {
+	for _, data := range []string{"", `{"encrypted": false, "items": []}`, "{\"format\":\"other\"}\nxyz", strings.Repeat("x", 10000)} {
+		// when
+		_, _, err := Read(strings.NewReader(data), "correct horse")
+
+		// then
+		assert.ErrorIs(t, err, ErrNotArchive)
+	}
+}
+
+func TestReadShouldRejectNewerVersionsAndUnsafeParameters(t *testing.T) {
+	tests := map[string]struct {
+		header Header
+		err    string
+	}{
+		"newer version": {
+			Header{Format: Format, Version: Version + 1, Cipher: CipherAESGCMStream, KDF: KDF{Algorithm: KDFArgon2id, Iterations: 1, Memory: 64, Parallelism: 1, Salt: make([]byte, 16)}},
+			ErrUnsupportedVersion.Error(),
+		},
+		"huge memory": {
+			Header{Format: Format, Version: Version, Cipher: CipherAESGCMStream, KDF: KDF{Algorithm: KDFArgon2id, Iterations: 1, Memory: 1 << 30, Parallelism: 1, Salt: make([]byte, 16)}},
+			"unsupported archive parameters: 1073741824 KiB of memory",
+		},
+		"other kdf": {
+			Header{Format: Format, Version: Version, Cipher: CipherAESGCMStream, KDF: KDF{Algorithm: "pbkdf2", Iterations: 1, Memory: 64, Parallelism: 1, Salt: make([]byte, 16)}},
+			`unsupported archive parameters: key derivation "pbkdf2"`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			line, err := json.Marshal(tt.header)
+			require.NoError(t, err)
+
+			// when
+			_, _, err = Read(bytes.NewReader(append(line, '\n')), "correct horse")
+
+			// then
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestWriteShouldRequirePassphrase(t *testing.T) {
+	// when
+	err := Write(&bytes.Buffer{}, testDocument(), "", testKDF)
+
+	// then
+	assert.ErrorIs(t, err, ErrPassphraseRequired)
+}
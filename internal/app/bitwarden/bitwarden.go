@@ -0,0 +1,183 @@
+// Package bitwarden reads and writes the JSON export format of Bitwarden, both the
+// unencrypted one and the password protected one, which wraps the unencrypted export
+// in a Bitwarden EncString under a key derived from the export password.
+package bitwarden
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ItemType discriminates the kinds of Bitwarden items
+type ItemType int
+
+// Bitwarden item types
+const (
+	ItemTypeLogin      ItemType = 1
+	ItemTypeSecureNote ItemType = 2
+	ItemTypeCard       ItemType = 3
+	ItemTypeIdentity   ItemType = 4
+	ItemTypeSSHKey     ItemType = 5
+)
+
+// FieldType discriminates the kinds of Bitwarden custom fields
+type FieldType int
+
+// Bitwarden custom field types
+const (
+	FieldTypeText    FieldType = 0
+	FieldTypeHidden  FieldType = 1
+	FieldTypeBoolean FieldType = 2
+	FieldTypeLinked  FieldType = 3
+)
+
+// Vault is the content of an unencrypted export
+type Vault struct {
+	Encrypted bool     `json:"encrypted"`
+	Folders   []Folder `json:"folders"`
+	Items     []Item   `json:"items"`
+}
+
+// Folder is a Bitwarden folder, nested folders are named by their path, e.g. "Work/Servers"
+type Folder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Item is a Bitwarden vault item. Only the part matching its type is set.
+type Item struct {
+	ID             string      `json:"id"`
+	OrganizationID *string     `json:"organizationId"`
+	FolderID       *string     `json:"folderId"`
+	Type           ItemType    `json:"type"`
+	Reprompt       int         `json:"reprompt"`
+	Name           string      `json:"name"`
+	Notes          *string     `json:"notes"`
+	Favorite       bool        `json:"favorite"`
+	Fields         []Field     `json:"fields,omitempty"`
+	Login          *Login      `json:"login,omitempty"`
+	SecureNote     *SecureNote `json:"secureNote,omitempty"`
+	Card           *Card       `json:"card,omitempty"`
+	Identity       *Identity   `json:"identity,omitempty"`
+	SSHKey         *SSHKey     `json:"sshKey,omitempty"`
+	CollectionIDs  []string    `json:"collectionIds"`
+}
+
+// Field is a custom field of an item
+type Field struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Type     FieldType `json:"type"`
+	LinkedID *int      `json:"linkedId"`
+}
+
+// Login holds the credentials of a login item. Totp is either an otpauth:// URI or a bare base32 secret.
+type Login struct {
+	URIs     []URI  `json:"uris,omitempty"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Totp     string `json:"totp"`
+}
+
+// URI is a website a login belongs to
+type URI struct {
+	Match *int   `json:"match"`
+	URI   string `json:"uri"`
+}
+
+// SecureNote marks a secure note item, the note itself is the item's notes
+type SecureNote struct {
+	Type int `json:"type"`
+}
+
+// Card holds the details of a payment card item
+type Card struct {
+	CardholderName string `json:"cardholderName"`
+	Brand          string `json:"brand"`
+	Number         string `json:"number"`
+	ExpMonth       string `json:"expMonth"`
+	ExpYear        string `json:"expYear"`
+	Code           string `json:"code"`
+}
+
+// Identity holds the details of an identity item
+type Identity struct {
+	Title          string `json:"title"`
+	FirstName      string `json:"firstName"`
+	MiddleName     string `json:"middleName"`
+	LastName       string `json:"lastName"`
+	Address1       string `json:"address1"`
+	Address2       string `json:"address2"`
+	Address3       string `json:"address3"`
+	City           string `json:"city"`
+	State          string `json:"state"`
+	PostalCode     string `json:"postalCode"`
+	Country        string `json:"country"`
+	Company        string `json:"company"`
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	SSN            string `json:"ssn"`
+	Username       string `json:"username"`
+	PassportNumber string `json:"passportNumber"`
+	LicenseNumber  string `json:"licenseNumber"`
+}
+
+// SSHKey holds an SSH key pair item
+type SSHKey struct {
+	PrivateKey     string `json:"privateKey"`
+	PublicKey      string `json:"publicKey"`
+	KeyFingerprint string `json:"keyFingerprint"`
+}
+
+// Errors returned when reading a password protected export
+var (
+	ErrPasswordRequired = errors.New("the Bitwarden export is password protected, an export password is required")
+	ErrWrongPassword    = errors.New("wrong Bitwarden export password")
+)
+
+// header holds the fields telling the kinds of exports apart
+type header struct {
+	Encrypted         bool `json:"encrypted"`
+	PasswordProtected bool `json:"passwordProtected"`
+}
+
+// Parse reads an unencrypted or a password protected export. The password is only
+// needed for the latter and ErrPasswordRequired is returned when it is missing.
+func Parse(data []byte, password string) (Vault, error) {
+	var h header
+	if err := json.Unmarshal(data, &h); err != nil {
+		return Vault{}, fmt.Errorf("failed to read Bitwarden export: %w", err)
+	}
+	if h.Encrypted && !h.PasswordProtected {
+		return Vault{}, errors.New("account restricted Bitwarden exports cannot be read, export as unencrypted or password protected JSON")
+	}
+	if h.PasswordProtected {
+		if password == "" {
+			return Vault{}, ErrPasswordRequired
+		}
+		var err error
+		data, err = unprotect(data, password)
+		if err != nil {
+			return Vault{}, err
+		}
+	}
+
+	var v Vault
+	if err := json.Unmarshal(data, &v); err != nil {
+		return Vault{}, fmt.Errorf("failed to read Bitwarden export: %w", err)
+	}
+	return v, nil
+}
+
+// Marshal writes an unencrypted export
+func Marshal(v Vault) ([]byte, error) {
+	v.Encrypted = false
+	if v.Folders == nil {
+		v.Folders = []Folder{}
+	}
+	if v.Items == nil {
+		v.Items = []Item{}
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
@@ -0,0 +1,144 @@
+//go:build unit
+
+package bitwarden
+
+import (
+	"encoding/json"
+	"testing"
+	"yubigo-pass/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShouldReadUnencryptedExport(t *testing.T) {
+	// when
+	v, err := Parse(test.BitwardenExport(t), "")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []Folder{{ID: "1c3f6a4e-0a35-4b83-9a5b-b1b0001a2b3c", Name: "Work/Dev"}}, v.Folders)
+	require.Len(t, v.Items, 5)
+
+	login := v.Items[0]
+	assert.Equal(t, ItemTypeLogin, login.Type)
+	assert.Equal(t, "GitHub", login.Name)
+	require.NotNil(t, login.FolderID)
+	assert.Equal(t, "1c3f6a4e-0a35-4b83-9a5b-b1b0001a2b3c", *login.FolderID)
+	require.NotNil(t, login.Login)
+	assert.Equal(t, "octocat", login.Login.Username)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", login.Login.Totp)
+	assert.Equal(t, []URI{{URI: "https://github.com"}, {URI: "https://gist.github.com"}}, login.Login.URIs)
+	require.Len(t, login.Fields, 4)
+	assert.Equal(t, Field{Name: "PIN", Value: "4242", Type: FieldTypeHidden}, login.Fields[0])
+
+	note := v.Items[1]
+	assert.Equal(t, ItemTypeSecureNote, note.Type)
+	assert.Nil(t, note.FolderID)
+	require.NotNil(t, note.Notes)
+	assert.Equal(t, "password on the router", *note.Notes)
+}
+
+func TestMarshalShouldRoundTrip(t *testing.T) {
+	// given
+	v, err := Parse(test.BitwardenExport(t), "")
+	require.NoError(t, err)
+
+	// when
+	data, err := Marshal(v)
+	require.NoError(t, err)
+	parsed, err := Parse(data, "")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, v, parsed)
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, false, raw["encrypted"])
+}
+
+func TestProtectShouldRoundTripWithEveryKDF(t *testing.T) {
+	kdfs := map[string]KDF{
+		"pbkdf2":   {Type: KDFTypePBKDF2, Iterations: 1000},
+		"argon2id": {Type: KDFTypeArgon2id, Iterations: 2, Memory: 8, Parallelism: 1},
+	}
+	for name, kdf := range kdfs {
+		t.Run(name, func(t *testing.T) {
+			// given
+			v, err := Parse(test.BitwardenExport(t), "")
+			require.NoError(t, err)
+
+			// when
+			data, err := Protect(v, "correct horse", kdf)
+			require.NoError(t, err)
+			parsed, err := Parse(data, "correct horse")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, v, parsed)
+			assert.NotContains(t, string(data), "hunter2")
+			var raw protected
+			require.NoError(t, json.Unmarshal(data, &raw))
+			assert.True(t, raw.Encrypted)
+			assert.True(t, raw.PasswordProtected)
+			assert.Equal(t, kdf.Type, raw.KdfType)
+			assert.Equal(t, kdf.Iterations, raw.KdfIterations)
+		})
+	}
+}
+
+func TestParseShouldRejectWrongPassword(t *testing.T) {
+	// given
+	data, err := Protect(Vault{}, "correct horse", KDF{Type: KDFTypePBKDF2, Iterations: 1000})
+	require.NoError(t, err)
+
+	// when
+	_, err = Parse(data, "battery staple")
+
+	// then
+	assert.ErrorIs(t, err, ErrWrongPassword)
+}
+
+func TestParseShouldRequirePasswordOfProtectedExport(t *testing.T) {
+	// given
+	data, err := Protect(Vault{}, "correct horse", KDF{Type: KDFTypePBKDF2, Iterations: 1000})
+	require.NoError(t, err)
+
+	// when
+	_, err = Parse(data, "")
+
+	// then
+	assert.ErrorIs(t, err, ErrPasswordRequired)
+}
+
+func TestParseShouldRejectAccountRestrictedExport(t *testing.T) {
+	// given
+	data := `{"encrypted": true, "encKeyValidation_DO_NOT_EDIT": "2.x|y|z", "folders": [], "items": []}`
+
+	// when
+	_, err := Parse([]byte(data), "")
+
+	// then
+	assert.ErrorContains(t, err, "account restricted Bitwarden exports cannot be read")
+}
+
+func TestParseShouldRejectTamperedData(t *testing.T) {
+	// given
+	data, err := Protect(Vault{}, "correct horse", KDF{Type: KDFTypePBKDF2, Iterations: 1000})
+	require.NoError(t, err)
+	var p protected
+	require.NoError(t, json.Unmarshal(data, &p))
+	encKey, macKey, err := deriveKeys("correct horse", p.Salt, KDF{Type: KDFTypePBKDF2, Iterations: 1000})
+	require.NoError(t, err)
+	p.Data, err = encrypt(encKey, []byte("another mac key of thirty two b!"), []byte(`{"items":[]}`))
+	require.NoError(t, err)
+	require.NotEqual(t, macKey, []byte("another mac key of thirty two b!"))
+	tampered, err := json.Marshal(p)
+	require.NoError(t, err)
+
+	// when
+	_, err = Parse(tampered, "correct horse")
+
+	// then
+	assert.ErrorContains(t, err, "encrypted data failed authentication")
+}
@@ -0,0 +1,228 @@
+package bitwarden
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// KDFType is the key derivation function of a password protected export
+type KDFType int
+
+// Key derivation functions supported by Bitwarden
+const (
+	KDFTypePBKDF2   KDFType = 0
+	KDFTypeArgon2id KDFType = 1
+)
+
+// KDF holds the key derivation parameters of a password protected export.
+// Memory is in MiB and, like Parallelism, only applies to Argon2id.
+type KDF struct {
+	Type        KDFType
+	Iterations  int
+	Memory      int
+	Parallelism int
+}
+
+// DefaultKDF matches the defaults of current Bitwarden clients
+var DefaultKDF = KDF{Type: KDFTypePBKDF2, Iterations: 600000}
+
+// encStringType is the Bitwarden EncString type of AES-256-CBC with an HMAC-SHA256 MAC
+const encStringType = "2"
+
+// protected is a password protected export
+type protected struct {
+	Encrypted         bool    `json:"encrypted"`
+	PasswordProtected bool    `json:"passwordProtected"`
+	Salt              string  `json:"salt"`
+	KdfType           KDFType `json:"kdfType"`
+	KdfIterations     int     `json:"kdfIterations"`
+	KdfMemory         *int    `json:"kdfMemory"`
+	KdfParallelism    *int    `json:"kdfParallelism"`
+	EncKeyValidation  string  `json:"encKeyValidation_DO_NOT_EDIT"`
+	Data              string  `json:"data"`
+}
+
+// Protect writes a password protected export, encrypting the unencrypted export of v
+// under a key derived from password with kdf
+func Protect(v Vault, password string, kdf KDF) ([]byte, error) {
+	if password == "" {
+		return nil, errors.New("export password cannot be empty")
+	}
+	plain, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	p := protected{
+		Encrypted:         true,
+		PasswordProtected: true,
+		Salt:              base64.StdEncoding.EncodeToString(salt),
+		KdfType:           kdf.Type,
+		KdfIterations:     kdf.Iterations,
+	}
+	if kdf.Type == KDFTypeArgon2id {
+		p.KdfMemory, p.KdfParallelism = &kdf.Memory, &kdf.Parallelism
+	}
+
+	encKey, macKey, err := deriveKeys(password, p.Salt, kdf)
+	if err != nil {
+		return nil, err
+	}
+	p.EncKeyValidation, err = encrypt(encKey, macKey, []byte(uuid.New().String()))
+	if err != nil {
+		return nil, err
+	}
+	p.Data, err = encrypt(encKey, macKey, plain)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// unprotect decrypts the unencrypted export wrapped in a password protected export
+func unprotect(data []byte, password string) ([]byte, error) {
+	var p protected
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to read Bitwarden export: %w", err)
+	}
+	kdf := KDF{Type: p.KdfType, Iterations: p.KdfIterations}
+	if p.KdfMemory != nil {
+		kdf.Memory = *p.KdfMemory
+	}
+	if p.KdfParallelism != nil {
+		kdf.Parallelism = *p.KdfParallelism
+	}
+
+	encKey, macKey, err := deriveKeys(password, p.Salt, kdf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = decrypt(encKey, macKey, p.EncKeyValidation); err != nil {
+		return nil, ErrWrongPassword
+	}
+	plain, err := decrypt(encKey, macKey, p.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt Bitwarden export: %w", err)
+	}
+	return plain, nil
+}
+
+// deriveKeys derives the master key from the password and stretches it with HKDF
+// into the encryption and MAC keys, as Bitwarden does for export passwords
+func deriveKeys(password, salt string, kdf KDF) ([]byte, []byte, error) {
+	var key []byte
+	switch kdf.Type {
+	case KDFTypePBKDF2:
+		if kdf.Iterations < 1 {
+			return nil, nil, errors.New("invalid Bitwarden export KDF iterations")
+		}
+		key = pbkdf2.Key([]byte(password), []byte(salt), kdf.Iterations, 32, sha256.New)
+	case KDFTypeArgon2id:
+		if kdf.Iterations < 1 || kdf.Memory < 1 || kdf.Parallelism < 1 || kdf.Parallelism > 255 {
+			return nil, nil, errors.New("invalid Bitwarden export KDF parameters")
+		}
+		saltHash := sha256.Sum256([]byte(salt))
+		key = argon2.IDKey([]byte(password), saltHash[:], uint32(kdf.Iterations), uint32(kdf.Memory*1024), uint8(kdf.Parallelism), 32)
+	default:
+		return nil, nil, fmt.Errorf("unsupported Bitwarden export KDF type %d", kdf.Type)
+	}
+
+	encKey, macKey := make([]byte, 32), make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, key, []byte("enc")), encKey); err != nil {
+		return nil, nil, fmt.Errorf("failed to derive export key: %w", err)
+	}
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, key, []byte("mac")), macKey); err != nil {
+		return nil, nil, fmt.Errorf("failed to derive export key: %w", err)
+	}
+	return encKey, macKey, nil
+}
+
+// encrypt seals plaintext into an EncString of the form 2.iv|ciphertext|mac
+func encrypt(encKey, macKey, plaintext []byte) (string, error) {
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt export: %w", err)
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err = rand.Read(iv); err != nil {
+		return "", fmt.Errorf("failed to encrypt export: %w", err)
+	}
+
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append(append([]byte(nil), plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+	return encStringType + "." + strings.Join([]string{
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(ciphertext),
+		base64.StdEncoding.EncodeToString(mac(macKey, iv, ciphertext)),
+	}, "|"), nil
+}
+
+// decrypt opens an EncString written by encrypt, checking its MAC first
+func decrypt(encKey, macKey []byte, encString string) ([]byte, error) {
+	encType, rest, ok := strings.Cut(encString, ".")
+	if !ok || encType != encStringType {
+		return nil, errors.New("unsupported encryption type")
+	}
+	parts := strings.Split(rest, "|")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed encrypted data")
+	}
+	decoded := make([][]byte, len(parts))
+	for i, part := range parts {
+		var err error
+		decoded[i], err = base64.StdEncoding.DecodeString(part)
+		if err != nil {
+			return nil, errors.New("malformed encrypted data")
+		}
+	}
+	iv, ciphertext, sum := decoded[0], decoded[1], decoded[2]
+
+	if !hmac.Equal(sum, mac(macKey, iv, ciphertext)) {
+		return nil, errors.New("encrypted data failed authentication")
+	}
+	if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, errors.New("malformed encrypted data")
+	}
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, errors.New("malformed encrypted data")
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
+
+// mac authenticates the IV and the ciphertext of an EncString
+func mac(key, iv, ciphertext []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(iv)
+	h.Write(ciphertext)
+	return h.Sum(nil)
+}
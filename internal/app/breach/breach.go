@@ -0,0 +1,98 @@
+// Package breach checks the passwords of a vault against the Pwned Passwords dataset of Have I
+// Been Pwned. The dataset is looked up offline in a compact index built from its downloaded range
+// files, or online through the k-anonymity range API, which is only ever sent the first five hex
+// digits of the SHA-1 of a password.
+package breach
+
+import (
+	"cmp"
+	"crypto/sha1" // #nosec G505 -- Pwned Passwords identifies passwords by their SHA-1
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+)
+
+// Environment variables locating the breach data checked against
+const (
+	EnvIndex = "YUBIGO_PASS_BREACH_INDEX"
+	EnvAPI   = "YUBIGO_PASS_BREACH_API"
+)
+
+// IndexFileName is the index, next to the database, looked up by default
+const IndexFileName = "pwned-passwords.idx"
+
+// ErrNoData is returned by Open when neither an index nor a range API is set up
+var ErrNoData = errors.New("no breach data, build an index with `yubigo-pass breach --build-index` or set $" + EnvAPI)
+
+// Hash is the SHA-1 of a password
+type Hash [sha1.Size]byte
+
+// HashPassword returns the SHA-1 of a password
+func HashPassword(password string) Hash {
+	return sha1.Sum([]byte(password)) // #nosec G401 -- Pwned Passwords identifies passwords by their SHA-1
+}
+
+// Checker tells how often a password was seen in breaches
+type Checker interface {
+	// Count returns how often the password of hash was seen in breaches, 0 when it never was
+	Count(hash Hash) (int, error)
+	Close() error
+}
+
+// Finding is an entry whose password was seen in breaches, Count times
+type Finding struct {
+	Entry model.Password
+	Count int
+}
+
+// Open returns the checker of the index at indexPath when there is one, of the range API at
+// apiURL otherwise, and ErrNoData when neither is set up
+func Open(indexPath, apiURL string) (Checker, error) {
+	if indexPath != "" {
+		index, err := OpenIndex(indexPath)
+		if err == nil {
+			return index, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if apiURL != "" {
+		return NewClient(apiURL), nil
+	}
+	return nil, ErrNoData
+}
+
+// Check looks the passwords of the logins of a vault up, returning the entries seen in breaches,
+// the most often seen first
+func Check(v vault.Vault, checker Checker) ([]Finding, error) {
+	entries, err := v.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, entry := range entries {
+		if entry.ItemType() != model.ItemTypeLogin {
+			continue
+		}
+		password, err := v.RevealPassword(entry)
+		if err != nil {
+			return nil, err
+		}
+		if password == "" {
+			continue
+		}
+		count, err := checker.Count(HashPassword(password))
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", entry.Title, err)
+		}
+		if count > 0 {
+			findings = append(findings, Finding{Entry: entry, Count: count})
+		}
+	}
+	slices.SortStableFunc(findings, func(a, b Finding) int { return cmp.Compare(b.Count, a.Count) })
+	return findings, nil
+}
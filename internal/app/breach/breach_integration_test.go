@@ -0,0 +1,51 @@
+//go:build integration
+
+package breach
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckShouldReportLoginsSeenInBreachesMostSeenFirst(t *testing.T) {
+	// given
+	v := vaulttest.New(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "hunter2"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Bank", Username: "me", Password: "password"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Mail", Username: "me", Password: "8fj#2kLq!0zPw"}, nil, "", nil))
+
+	var lines []string
+	for password, count := range map[string]string{"hunter2": "25592", "password": "10434004"} {
+		hash := HashPassword(password)
+		lines = append(lines, strings.ToUpper(hex.EncodeToString(hash[:]))+":"+count)
+	}
+	sort.Strings(lines)
+	source := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	require.NoError(t, os.WriteFile(source, []byte(strings.Join(lines, "\n")), 0o600))
+	path := filepath.Join(t.TempDir(), IndexFileName)
+	_, err := BuildIndex(source, path)
+	require.NoError(t, err)
+	checker, err := Open(path, "")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = checker.Close() })
+
+	// when
+	findings, err := Check(v, checker)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "Bank", findings[0].Entry.Title)
+	assert.Equal(t, 10434004, findings[0].Count)
+	assert.Equal(t, "GitHub", findings[1].Entry.Title)
+	assert.Equal(t, 25592, findings[1].Count)
+}
@@ -0,0 +1,245 @@
+//go:build unit
+
+package breach
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// breached are the passwords of the test dataset with the times they were seen
+var breached = map[string]int{
+	"password": 10434004,
+	"hunter2":  25592,
+	"123456":   37359195,
+	"letmein":  531847,
+	"qwerty":   10556095,
+}
+
+// hashHex returns the uppercase hex SHA-1 of a password, as the dataset lists it
+func hashHex(password string) string {
+	hash := HashPassword(password)
+	return strings.ToUpper(hex.EncodeToString(hash[:]))
+}
+
+// datasetLines returns the lines of the test dataset ordered by hash, along with padding hashes
+// starting with 00 and FF so lookups cross the bounds of the fan-out table
+func datasetLines() []string {
+	lines := []string{
+		"0000000000000000000000000000000000000001:3",
+		"00000000000000000000000000000000000000FF:4",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:5",
+	}
+	for password, count := range breached {
+		lines = append(lines, fmt.Sprintf("%s:%d", hashHex(password), count))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// writeRangeDir writes the test dataset as range files, as the downloader of Have I Been Pwned does
+func writeRangeDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	ranges := map[string][]string{}
+	for _, line := range datasetLines() {
+		ranges[line[:5]] = append(ranges[line[:5]], line[5:])
+	}
+	for prefix, lines := range ranges {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a range"), 0o600))
+	return dir
+}
+
+func TestBuildIndexShouldLookUpHashesOfRangeFilesAndSingleFile(t *testing.T) {
+	single := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	require.NoError(t, os.WriteFile(single, []byte(strings.Join(datasetLines(), "\n")), 0o600))
+	sources := map[string]string{"range files": writeRangeDir(t), "single file": single}
+
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			// given
+			path := filepath.Join(t.TempDir(), IndexFileName)
+
+			// when
+			indexed, err := BuildIndex(source, path)
+			require.NoError(t, err)
+			index, openErr := OpenIndex(path)
+			require.NoError(t, openErr)
+			t.Cleanup(func() { _ = index.Close() })
+
+			// then
+			assert.Equal(t, len(breached)+3, indexed)
+			assert.Equal(t, indexed, index.Len())
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, int64(headerSize+indexed*recordSize), info.Size())
+			for password, want := range breached {
+				count, err := index.Count(HashPassword(password))
+				require.NoError(t, err)
+				assert.Equal(t, want, count, password)
+			}
+			for _, line := range []string{"0000000000000000000000000000000000000001", "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"} {
+				var hash Hash
+				_, err = hex.Decode(hash[:], []byte(line))
+				require.NoError(t, err)
+				count, err := index.Count(hash)
+				require.NoError(t, err)
+				assert.Positive(t, count, line)
+			}
+			count, err := index.Count(HashPassword("correct horse battery staple"))
+			require.NoError(t, err)
+			assert.Zero(t, count)
+		})
+	}
+}
+
+func TestBuildIndexShouldRejectUnorderedDataset(t *testing.T) {
+	// given
+	lines := datasetLines()
+	lines[1], lines[2] = lines[2], lines[1]
+	source := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	require.NoError(t, os.WriteFile(source, []byte(strings.Join(lines, "\n")), 0o600))
+	path := filepath.Join(t.TempDir(), IndexFileName)
+
+	// when
+	_, err := BuildIndex(source, path)
+
+	// then
+	assert.ErrorContains(t, err, "dataset is not ordered by hash")
+	assert.NoFileExists(t, path)
+	assert.NoFileExists(t, path+".partial")
+}
+
+func TestBuildIndexShouldRejectInvalidLines(t *testing.T) {
+	// given
+	source := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	require.NoError(t, os.WriteFile(source, []byte(hashHex("password")+":12\nnot a hash\n"), 0o600))
+
+	// when
+	_, err := BuildIndex(source, filepath.Join(t.TempDir(), IndexFileName))
+
+	// then
+	assert.ErrorContains(t, err, source+":2: invalid line, expected a hash and a count")
+}
+
+func TestOpenIndexShouldRejectOtherFiles(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), IndexFileName)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(datasetLines(), "\n")), 0o600))
+
+	// when
+	_, err := OpenIndex(path)
+
+	// then
+	assert.ErrorIs(t, err, ErrInvalidIndex)
+}
+
+// rangeAPI stands in for the range API of Have I Been Pwned, answering ranges of the test dataset
+// with padding and recording the prefixes asked for
+type rangeAPI struct {
+	mu       sync.Mutex
+	prefixes []string
+}
+
+func (a *rangeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix, ok := strings.CutPrefix(r.URL.Path, "/range/")
+	if !ok || len(prefix) != 5 {
+		http.NotFound(w, r)
+		return
+	}
+	a.mu.Lock()
+	a.prefixes = append(a.prefixes, prefix)
+	a.mu.Unlock()
+	for _, line := range datasetLines() {
+		if strings.HasPrefix(line, prefix) {
+			fmt.Fprintf(w, "%s\r\n", line[5:])
+		}
+	}
+	fmt.Fprintf(w, "%s:0\r\n", strings.Repeat("0", 35))
+}
+
+func TestClientShouldLookUpPasswordsByPrefix(t *testing.T) {
+	// given
+	api := &rangeAPI{}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	client := NewClient(server.URL + "/")
+
+	// when
+	hunter2, err := client.Count(HashPassword("hunter2"))
+	require.NoError(t, err)
+	again, err := client.Count(HashPassword("hunter2"))
+	require.NoError(t, err)
+	unknown, err := client.Count(HashPassword("correct horse battery staple"))
+	require.NoError(t, err)
+
+	// then
+	assert.Equal(t, breached["hunter2"], hunter2)
+	assert.Equal(t, hunter2, again)
+	assert.Zero(t, unknown)
+	assert.Equal(t, []string{hashHex("hunter2")[:5], hashHex("correct horse battery staple")[:5]}, api.prefixes,
+		"only prefixes are sent, once each")
+}
+
+func TestClientShouldIgnorePadding(t *testing.T) {
+	// given
+	server := httptest.NewServer(&rangeAPI{})
+	t.Cleanup(server.Close)
+	// the all-zero hash is answered as padding
+	var padding Hash
+
+	// when
+	count, err := NewClient(server.URL).Count(padding)
+
+	// then
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestClientShouldReportFailingAPI(t *testing.T) {
+	// given
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(server.Close)
+
+	// when
+	_, err := NewClient(server.URL).Count(HashPassword("hunter2"))
+
+	// then
+	assert.ErrorContains(t, err, "breach API answered 429 Too Many Requests")
+}
+
+func TestOpenShouldPreferIndexOverAPI(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), IndexFileName)
+	_, err := BuildIndex(writeRangeDir(t), path)
+	require.NoError(t, err)
+	missing := filepath.Join(t.TempDir(), IndexFileName)
+
+	// when
+	fromIndex, indexErr := Open(path, DefaultAPI)
+	fromAPI, apiErr := Open(missing, DefaultAPI)
+	_, noDataErr := Open(missing, "")
+
+	// then
+	require.NoError(t, indexErr)
+	require.NoError(t, apiErr)
+	t.Cleanup(func() { _ = fromIndex.Close() })
+	assert.IsType(t, &Index{}, fromIndex)
+	assert.IsType(t, Client{}, fromAPI)
+	assert.ErrorIs(t, noDataErr, ErrNoData)
+}
@@ -0,0 +1,90 @@
+package breach
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAPI is the range API of Have I Been Pwned
+const DefaultAPI = "https://api.pwnedpasswords.com"
+
+const requestTimeout = 30 * time.Second
+
+// Client looks passwords up through a k-anonymity range API, sending only the first five hex
+// digits of their SHA-1 and matching the rest against the range answered. Ranges are remembered,
+// so passwords sharing a prefix are looked up once.
+type Client struct {
+	URL  string
+	HTTP *http.Client
+
+	mu     *sync.Mutex
+	ranges map[string]map[Hash]int
+}
+
+// NewClient returns new Client instance of the range API at baseURL, e.g. DefaultAPI
+func NewClient(baseURL string) Client {
+	return Client{
+		URL:    strings.TrimRight(baseURL, "/"),
+		HTTP:   &http.Client{Timeout: requestTimeout},
+		mu:     &sync.Mutex{},
+		ranges: map[string]map[Hash]int{},
+	}
+}
+
+// Count implements Checker
+func (c Client) Count(hash Hash) (int, error) {
+	prefix := strings.ToUpper(hex.EncodeToString(hash[:]))[:prefixChars]
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts, ok := c.ranges[prefix]
+	if !ok {
+		var err error
+		if counts, err = c.fetch(prefix); err != nil {
+			return 0, err
+		}
+		c.ranges[prefix] = counts
+	}
+	return counts[hash], nil
+}
+
+// fetch downloads the range of the hashes starting with prefix. Padding added to the range, with
+// a count of 0, is dropped.
+func (c Client) fetch(prefix string) (map[Hash]int, error) {
+	req, err := http.NewRequest(http.MethodGet, c.URL+"/range/"+prefix, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid breach API URL: %w", err)
+	}
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", "yubigo-pass")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach breach API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("breach API answered %s", resp.Status)
+	}
+	counts := map[Hash]int{}
+	err = readRanges(resp.Body, prefix, func(_ int, hash Hash, count int) error {
+		if count > 0 {
+			counts[hash] = count
+		}
+		return nil
+	}, func(line int) error {
+		return fmt.Errorf("invalid answer of breach API at line %d", line)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// Close implements Checker
+func (c Client) Close() error {
+	return nil
+}
@@ -0,0 +1,264 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The index starts with indexMagic and a fan-out table of 256 big-endian counts, the number of
+// hashes starting with a byte up to each value, followed by the hashes in ascending order. The
+// first byte of a hash is told by the fan-out table, so a record only holds the remaining 19
+// bytes and the big-endian count of the hash.
+const (
+	indexMagic  = "YGPBIDX1"
+	fanoutSize  = 256 * 4
+	headerSize  = len(indexMagic) + fanoutSize
+	suffixSize  = len(Hash{}) - 1
+	recordSize  = suffixSize + 4
+	prefixChars = 5
+)
+
+// ErrInvalidIndex is returned when opening a file that is not a breach index
+var ErrInvalidIndex = errors.New("not a breach index, rebuild it with `yubigo-pass breach --build-index`")
+
+// Index looks passwords up in the index of a Pwned Passwords dataset by binary search, reading
+// only the records it compares
+type Index struct {
+	file   *os.File
+	fanout [256]uint32
+}
+
+// OpenIndex opens the index at path
+func OpenIndex(path string) (*Index, error) {
+	file, err := os.Open(path) // #nosec G304 -- the user picks the index
+	if err != nil {
+		return nil, err
+	}
+	index := &Index{file: file}
+	if err = index.readHeader(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return index, nil
+}
+
+func (i *Index) readHeader() error {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(i.file, header); err != nil {
+		return ErrInvalidIndex
+	}
+	if string(header[:len(indexMagic)]) != indexMagic {
+		return ErrInvalidIndex
+	}
+	for b := range i.fanout {
+		i.fanout[b] = binary.BigEndian.Uint32(header[len(indexMagic)+b*4:])
+	}
+	info, err := i.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() != int64(headerSize)+int64(i.Len())*int64(recordSize) {
+		return ErrInvalidIndex
+	}
+	return nil
+}
+
+// Len returns the number of hashes of the index
+func (i *Index) Len() int {
+	return int(i.fanout[255])
+}
+
+// Count implements Checker
+func (i *Index) Count(hash Hash) (int, error) {
+	low := 0
+	if hash[0] > 0 {
+		low = int(i.fanout[hash[0]-1])
+	}
+	high := int(i.fanout[hash[0]])
+	record := make([]byte, recordSize)
+	for low < high {
+		mid := int(uint(low+high) >> 1)
+		if _, err := i.file.ReadAt(record, int64(headerSize)+int64(mid)*int64(recordSize)); err != nil {
+			return 0, fmt.Errorf("failed to read breach index: %w", err)
+		}
+		switch bytes.Compare(record[:suffixSize], hash[1:]) {
+		case 0:
+			return int(binary.BigEndian.Uint32(record[suffixSize:])), nil
+		case -1:
+			low = mid + 1
+		default:
+			high = mid
+		}
+	}
+	return 0, nil
+}
+
+// Close implements Checker
+func (i *Index) Close() error {
+	return i.file.Close()
+}
+
+// BuildIndex indexes a Pwned Passwords dataset into a new index at dst, returning the number of
+// hashes indexed. The source is either the directory of range files the downloader of Have I Been
+// Pwned writes, each named by the first five hex digits of its hashes and listing their remaining
+// digits with a count, or a single file listing whole hashes with a count, ordered by hash.
+func BuildIndex(source, dst string) (int, error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+		return 0, fmt.Errorf("failed to create index directory: %w", err)
+	}
+	partial := dst + ".partial"
+	file, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600) // #nosec G304 -- the user picks the index
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(partial)
+	}()
+
+	w := &indexWriter{out: bufio.NewWriter(file)}
+	if _, err = w.out.Write(make([]byte, headerSize)); err != nil {
+		return 0, err
+	}
+	if err = readDataset(source, w.add); err != nil {
+		return 0, err
+	}
+	if err = w.out.Flush(); err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, headerSize)
+	copy(header, indexMagic)
+	var total uint32
+	for b, count := range w.counts {
+		total += count
+		binary.BigEndian.PutUint32(header[len(indexMagic)+b*4:], total)
+	}
+	if _, err = file.WriteAt(header, 0); err != nil {
+		return 0, err
+	}
+	if err = file.Sync(); err != nil {
+		return 0, err
+	}
+	if err = file.Close(); err != nil {
+		return 0, err
+	}
+	if err = os.Rename(partial, dst); err != nil {
+		return 0, err
+	}
+	return int(total), nil
+}
+
+// indexWriter writes the records of an index, counting the hashes starting with every byte
+type indexWriter struct {
+	out    *bufio.Writer
+	counts [256]uint32
+	last   Hash
+	total  int
+}
+
+func (w *indexWriter) add(hash Hash, count int) error {
+	if w.total > 0 && bytes.Compare(hash[:], w.last[:]) <= 0 {
+		return fmt.Errorf("dataset is not ordered by hash at %X", hash)
+	}
+	if uint64(w.total) == math.MaxUint32 {
+		return errors.New("dataset holds too many hashes")
+	}
+	record := make([]byte, recordSize)
+	copy(record, hash[1:])
+	binary.BigEndian.PutUint32(record[suffixSize:], uint32(min(uint64(count), math.MaxUint32)))
+	if _, err := w.out.Write(record); err != nil {
+		return err
+	}
+	w.counts[hash[0]]++
+	w.last = hash
+	w.total++
+	return nil
+}
+
+// readDataset passes every hash of a dataset to add with its count, in the order of the dataset
+func readDataset(source string, add func(Hash, int) error) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return readRangeFile(source, "", add)
+	}
+
+	files, err := os.ReadDir(source)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, file := range files {
+		prefix := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
+		if file.IsDir() || len(prefix) != prefixChars || !isHex(prefix) {
+			continue
+		}
+		found = true
+		if err = readRangeFile(filepath.Join(source, file.Name()), prefix, add); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("no range files in %s", source)
+	}
+	return nil
+}
+
+// readRangeFile reads the lines of a range file, whose hashes start with prefix
+func readRangeFile(path, prefix string, add func(Hash, int) error) error {
+	file, err := os.Open(path) // #nosec G304 -- the user picks the dataset
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return readRanges(file, prefix, func(line int, hash Hash, count int) error {
+		if err := add(hash, count); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		return nil
+	}, func(line int) error {
+		return fmt.Errorf("%s:%d: invalid line, expected a hash and a count", path, line)
+	})
+}
+
+// readRanges parses lines of hash suffixes completing prefix, each followed by a colon and a count
+func readRanges(r io.Reader, prefix string, add func(line int, hash Hash, count int) error, invalid func(line int) error) error {
+	lines := bufio.NewScanner(r)
+	for line := 1; lines.Scan(); line++ {
+		text := strings.TrimSpace(lines.Text())
+		if text == "" {
+			continue
+		}
+		suffix, countText, ok := strings.Cut(text, ":")
+		count, err := strconv.Atoi(countText)
+		var hash Hash
+		if !ok || err != nil || count < 0 || len(prefix)+len(suffix) != hex.EncodedLen(len(hash)) {
+			return invalid(line)
+		}
+		if _, err = hex.Decode(hash[:], []byte(prefix+suffix)); err != nil {
+			return invalid(line)
+		}
+		if err = add(line, hash, count); err != nil {
+			return err
+		}
+	}
+	return lines.Err()
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s + strings.Repeat("0", len(s)%2))
+	return err == nil
+}
@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"strings"
 	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/health"
 	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/otp"
 	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
 
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/nbutton23/zxcvbn-go"
 )
 
 // sessionStateAddPassword defines the focus state within the add password view.
@@ -21,17 +24,28 @@ const (
 	addPasswordBackFocused
 )
 
+// otpInput is the index of the input holding an optional otpauth:// URI.
+const otpInput = 4
+
+// firstFieldInput is the index of the first custom field input, each custom field
+// takes two inputs: its name followed by its value.
+const firstFieldInput = 7
+
 // AddPasswordModel is a Bubble Tea model for adding a new password entry.
 // It gathers input, allows toggling password visibility, and triggers the
-// password addition process via messages.
+// password addition process via messages. Besides the fixed inputs it holds
+// a multi-line notes area and a dynamic list of typed custom fields.
 type AddPasswordModel struct {
 	state            sessionStateAddPassword
 	focusIndex       int
 	inputs           []textinput.Model
+	notes            textarea.Model
+	fieldTypes       []model.FieldType
 	showErr          bool
 	err              error
 	passwordStrength int
 	passwordVisible  bool
+	policy           model.PasswordPolicy
 
 	session utils.Session
 }
@@ -43,17 +57,36 @@ func ExtractPasswordDataFromModel(m AddPasswordModel) model.Password {
 		Username: m.inputs[1].Value(),
 		Password: m.inputs[2].Value(),
 		Url:      m.inputs[3].Value(),
+		OTP:      strings.TrimSpace(m.inputs[otpInput].Value()),
+		Notes:    m.notes.Value(),
+	}
+}
+
+// ExtractPlacementFromModel returns the folder path and the tags entered for the new password.
+func ExtractPlacementFromModel(m AddPasswordModel) (string, []string) {
+	return strings.TrimSpace(m.inputs[5].Value()), vault.ParseTags(m.inputs[6].Value())
+}
+
+// ExtractCustomFieldsFromModel returns the custom fields entered for the new password.
+func ExtractCustomFieldsFromModel(m AddPasswordModel) []model.CustomField {
+	fields := make([]model.CustomField, 0, len(m.fieldTypes))
+	for i, fieldType := range m.fieldTypes {
+		name := m.inputs[firstFieldInput+2*i].Value()
+		value := m.inputs[firstFieldInput+2*i+1].Value()
+		fields = append(fields, model.NewCustomField("", i, fieldType, strings.TrimSpace(name), value))
 	}
+	return fields
 }
 
 // NewAddPasswordModel creates a new instance of the AddPasswordModel.
 func NewAddPasswordModel(session utils.Session) AddPasswordModel {
 	m := AddPasswordModel{
 		state:            addPasswordInputsFocused,
-		inputs:           make([]textinput.Model, 4),
+		inputs:           make([]textinput.Model, firstFieldInput),
 		session:          session,
 		passwordStrength: 0,
 		passwordVisible:  false,
+		policy:           model.DefaultPasswordPolicy,
 	}
 
 	var t textinput.Model
@@ -85,23 +118,51 @@ func NewAddPasswordModel(session utils.Session) AddPasswordModel {
 			t.PromptStyle = noStyle
 			t.TextStyle = noStyle
 			t.CharLimit = 512
+		case otpInput:
+			t.Placeholder = "One-time password URI, otpauth://… (optional)"
+			t.EchoMode = textinput.EchoPassword
+			t.EchoCharacter = '•'
+			t.PromptStyle = noStyle
+			t.TextStyle = noStyle
+			t.CharLimit = 1024
+		case 5:
+			t.Placeholder = "Folder, e.g. Work/Servers (optional)"
+			t.PromptStyle = noStyle
+			t.TextStyle = noStyle
+			t.CharLimit = 256
+		case 6:
+			t.Placeholder = "Tags, comma separated (optional)"
+			t.PromptStyle = noStyle
+			t.TextStyle = noStyle
+			t.CharLimit = 256
 		}
 		m.inputs[i] = t
 	}
 	m.focusIndex = 0
 
+	m.notes = textarea.New()
+	m.notes.Placeholder = "Notes (optional)"
+	m.notes.ShowLineNumbers = false
+	m.notes.SetHeight(3)
+	m.notes.SetWidth(60)
+	m.notes.CharLimit = 0
+	m.notes.Blur()
+
 	return m
 }
 
 // Init initializes the AddPasswordModel, setting focus and clearing inputs.
 func (m AddPasswordModel) Init() tea.Cmd {
 	m.inputs[2].EchoMode = textinput.EchoPassword
+	m.inputs[otpInput].EchoMode = textinput.EchoPassword
 	for i := range m.inputs {
 		m.inputs[i].SetValue("")
 		m.inputs[i].Blur()
 		m.inputs[i].PromptStyle = noStyle
 		m.inputs[i].TextStyle = noStyle
 	}
+	m.notes.Reset()
+	m.notes.Blur()
 	m.inputs[0].Focus()
 	m.inputs[0].PromptStyle = focusedStyle
 	m.inputs[0].TextStyle = focusedStyle
@@ -109,20 +170,26 @@ func (m AddPasswordModel) Init() tea.Cmd {
 	return textinput.Blink
 }
 
+// WithPasswordPolicy returns the model generating passwords with policy on Ctrl+G.
+func (m AddPasswordModel) WithPasswordPolicy(policy model.PasswordPolicy) AddPasswordModel {
+	m.policy = policy
+	return m
+}
+
 // Update handles incoming messages and user input for the add password screen.
 func (m AddPasswordModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if m.state == addPasswordInputsFocused && m.focusIndex < len(m.inputs) {
+		if m.state == addPasswordInputsFocused && m.focusIndex <= m.notesIndex() {
 			switch msg.Type {
 			case tea.KeyRunes, tea.KeySpace, tea.KeyBackspace:
 				m.showErr = false
 				m.err = nil
 			case tea.KeyCtrlG:
 				if m.focusIndex == 2 {
-					generatedPassword, err := utils.GeneratePassword(utils.DefaultLength, true, true, true, true)
+					generatedPassword, err := utils.GeneratePasswordWithPolicy(m.policy)
 					if err != nil {
 						m.err = fmt.Errorf("password generation failed: %w", err)
 						m.showErr = true
@@ -136,15 +203,29 @@ func (m AddPasswordModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, textinput.Blink
 				}
 			case tea.KeyCtrlS:
-				if m.focusIndex == 2 {
+				if m.focusIndex == 2 || m.focusIndex == otpInput {
 					m.passwordVisible = !m.passwordVisible
+					echoMode := textinput.EchoPassword
 					if m.passwordVisible {
-						m.inputs[2].EchoMode = textinput.EchoNormal
-					} else {
-						m.inputs[2].EchoMode = textinput.EchoPassword
+						echoMode = textinput.EchoNormal
 					}
+					m.inputs[2].EchoMode = echoMode
+					m.inputs[otpInput].EchoMode = echoMode
 					return m, nil
 				}
+			case tea.KeyCtrlN:
+				m.addCustomField()
+				return m, m.updateFocus()
+			case tea.KeyCtrlT:
+				if field, ok := m.focusedCustomField(); ok {
+					m.setCustomFieldType(field, m.fieldTypes[field].Next())
+					return m, nil
+				}
+			case tea.KeyCtrlD:
+				if field, ok := m.focusedCustomField(); ok {
+					m.removeCustomField(field)
+					return m, m.updateFocus()
+				}
 			}
 		}
 
@@ -162,30 +243,39 @@ func (m AddPasswordModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case tea.KeyUp, tea.KeyDown:
 			if m.state == addPasswordInputsFocused {
+				if m.focusIndex == m.notesIndex() && !m.notesCursorAtEdge(msg.Type == tea.KeyUp) {
+					break
+				}
 				originalFocus := m.focusIndex
 				if msg.Type == tea.KeyUp {
-					m.focusIndex = (m.focusIndex - 1 + (len(m.inputs) + 1)) % (len(m.inputs) + 1)
+					m.focusIndex = (m.focusIndex - 1 + m.focusCount()) % m.focusCount()
 				} else {
-					m.focusIndex = (m.focusIndex + 1) % (len(m.inputs) + 1)
+					m.focusIndex = (m.focusIndex + 1) % m.focusCount()
 				}
 				if m.focusIndex != originalFocus {
 					cmds = append(cmds, m.updateFocus())
 				}
+				return m, tea.Batch(cmds...)
 			}
 
 		case tea.KeyEnter:
 			if m.state == addPasswordBackFocused {
 				return m, common.ChangeStateCmd(common.StateGoBack)
 			}
-			if m.state == addPasswordInputsFocused && m.focusIndex == len(m.inputs) {
+			if m.state == addPasswordInputsFocused && m.focusIndex == m.addButtonIndex() {
 				validationErr := validateAddPasswordModelInputs(m.inputs)
+				if validationErr == nil {
+					validationErr = validateCustomFields(ExtractCustomFieldsFromModel(m))
+				}
 				if validationErr != nil {
 					m.err = validationErr
 					m.showErr = true
 					return m, nil
 				}
 				passwordData := ExtractPasswordDataFromModel(m)
-				return m, common.AddPasswordCmd(passwordData)
+				fields := ExtractCustomFieldsFromModel(m)
+				folder, tags := ExtractPlacementFromModel(m)
+				return m, common.AddPasswordCmd(passwordData, fields, folder, tags)
 			} else if m.state == addPasswordInputsFocused && m.focusIndex < len(m.inputs) {
 				m.focusIndex++
 				cmds = append(cmds, m.updateFocus())
@@ -202,6 +292,10 @@ func (m AddPasswordModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.focusIndex == 2 && m.inputs[2].Value() != originalValue {
 			m.passwordStrength = calculateStrength(&m)
 		}
+	} else if m.state == addPasswordInputsFocused && m.focusIndex == m.notesIndex() {
+		var notesCmd tea.Cmd
+		m.notes, notesCmd = m.notes.Update(msg)
+		cmds = append(cmds, notesCmd)
 	}
 
 	return m, tea.Batch(cmds...)
@@ -212,7 +306,7 @@ func (m AddPasswordModel) View() string {
 	var b strings.Builder
 	b.WriteString(titleStyle.Render("ADD A NEW PASSWORD") + "\n\n")
 
-	for i := range m.inputs {
+	for i := 0; i < firstFieldInput; i++ {
 		b.WriteString(m.inputs[i].View())
 		if i == 2 && m.inputs[i].Value() != "" {
 			strengthScore := m.passwordStrength
@@ -224,10 +318,21 @@ func (m AddPasswordModel) View() string {
 		b.WriteRune('\n')
 	}
 
+	if len(m.fieldTypes) > 0 {
+		b.WriteString("\n" + blurredStyle.Render("Custom fields") + "\n")
+	}
+	for i, fieldType := range m.fieldTypes {
+		nameInput := m.inputs[firstFieldInput+2*i]
+		valueInput := m.inputs[firstFieldInput+2*i+1]
+		fmt.Fprintf(&b, "%s %s %s\n", nameInput.View(), valueInput.View(), blurredStyle.Render("["+string(fieldType)+"]"))
+	}
+
+	b.WriteString("\n" + m.notes.View() + "\n")
+
 	addBtn := blurredAddButton
 	backBtn := blurredBackButton
 
-	if m.state == addPasswordInputsFocused && m.focusIndex == len(m.inputs) {
+	if m.state == addPasswordInputsFocused && m.focusIndex == m.addButtonIndex() {
 		addBtn = focusedAddButton
 	}
 	if m.state == addPasswordBackFocused {
@@ -243,12 +348,84 @@ func (m AddPasswordModel) View() string {
 	}
 
 	help := blurredStyle.Render("\n\n(Tab/Shift+Tab: Navigate, ↑/↓: Focus, Enter: Select/Add)\n")
-	help += blurredStyle.Render("(Ctrl+G on Pwd: Generate, Ctrl+S on Pwd: Show/Hide, Esc: Quit)")
+	help += blurredStyle.Render("(Ctrl+G on Pwd: Generate, Ctrl+S on Pwd or OTP: Show/Hide, Esc: Quit)\n")
+	help += blurredStyle.Render("(Ctrl+N: New custom field, Ctrl+T on field: Change type, Ctrl+D on field: Remove)")
 	b.WriteString(help)
 
 	return b.String()
 }
 
+// notesIndex returns the focus index of the notes area, which follows all inputs.
+func (m AddPasswordModel) notesIndex() int {
+	return len(m.inputs)
+}
+
+// addButtonIndex returns the focus index of the Add button, which follows the notes area.
+func (m AddPasswordModel) addButtonIndex() int {
+	return len(m.inputs) + 1
+}
+
+// focusCount returns the number of focusable elements in the inputs pane.
+func (m AddPasswordModel) focusCount() int {
+	return len(m.inputs) + 2
+}
+
+// notesCursorAtEdge reports whether moving the cursor up (or down) would leave the notes area.
+func (m AddPasswordModel) notesCursorAtEdge(up bool) bool {
+	if up {
+		return m.notes.Line() == 0
+	}
+	return m.notes.Line() >= m.notes.LineCount()-1
+}
+
+// focusedCustomField returns the index of the custom field whose name or value input is focused.
+func (m AddPasswordModel) focusedCustomField() (int, bool) {
+	if m.focusIndex < firstFieldInput || m.focusIndex >= len(m.inputs) {
+		return 0, false
+	}
+	return (m.focusIndex - firstFieldInput) / 2, true
+}
+
+// addCustomField appends a text custom field and focuses its name input.
+func (m *AddPasswordModel) addCustomField() {
+	name := textinput.New()
+	name.Cursor.Style = cursorStyle
+	name.Placeholder = "Field name"
+	name.CharLimit = 128
+	name.Width = 24
+
+	value := textinput.New()
+	value.Cursor.Style = cursorStyle
+	value.Placeholder = "Value"
+	value.EchoCharacter = '•'
+
+	m.inputs = append(m.inputs, name, value)
+	m.fieldTypes = append(m.fieldTypes, model.FieldTypeText)
+	m.focusIndex = len(m.inputs) - 2
+}
+
+// setCustomFieldType changes the type of a custom field, masking the value of hidden fields.
+func (m *AddPasswordModel) setCustomFieldType(field int, fieldType model.FieldType) {
+	m.fieldTypes[field] = fieldType
+	value := &m.inputs[firstFieldInput+2*field+1]
+	if fieldType == model.FieldTypeHidden {
+		value.EchoMode = textinput.EchoPassword
+	} else {
+		value.EchoMode = textinput.EchoNormal
+	}
+	if fieldType == model.FieldTypeBoolean && value.Value() == "" {
+		value.SetValue("false")
+	}
+}
+
+// removeCustomField drops a custom field and moves the focus to the preceding input.
+func (m *AddPasswordModel) removeCustomField(field int) {
+	start := firstFieldInput + 2*field
+	m.inputs = append(m.inputs[:start], m.inputs[start+2:]...)
+	m.fieldTypes = append(m.fieldTypes[:field], m.fieldTypes[field+1:]...)
+	m.focusIndex = start - 1
+}
+
 // updateFocus updates the visual focus styles on inputs and returns the blink command.
 func (m *AddPasswordModel) updateFocus() tea.Cmd {
 	for i := 0; i < len(m.inputs); i++ {
@@ -262,6 +439,10 @@ func (m *AddPasswordModel) updateFocus() tea.Cmd {
 			m.inputs[i].TextStyle = noStyle
 		}
 	}
+	if m.state == addPasswordInputsFocused && m.focusIndex == m.notesIndex() {
+		return m.notes.Focus()
+	}
+	m.notes.Blur()
 	if m.state == addPasswordInputsFocused && m.focusIndex < len(m.inputs) {
 		return textinput.Blink
 	}
@@ -270,21 +451,7 @@ func (m *AddPasswordModel) updateFocus() tea.Cmd {
 
 // calculateStrength calculates the password strength score using zxcvbn.
 func calculateStrength(m *AddPasswordModel) int {
-	password := m.inputs[2].Value()
-	if password == "" {
-		return 0
-	}
-	userInputs := []string{
-		m.inputs[0].Value(),
-		m.inputs[1].Value(),
-	}
-	var filteredInputs []string
-	for _, input := range userInputs {
-		if input != "" {
-			filteredInputs = append(filteredInputs, input)
-		}
-	}
-	return zxcvbn.PasswordStrength(password, filteredInputs).Score
+	return health.Strength(m.inputs[2].Value(), m.inputs[0].Value(), m.inputs[1].Value())
 }
 
 // validateAddPasswordModelInputs checks if required fields are empty.
@@ -296,5 +463,23 @@ func validateAddPasswordModelInputs(input []textinput.Model) error {
 	if titleIsEmpty() || usernameIsEmpty() || passwordIsEmpty() {
 		return fmt.Errorf("title, username, and password fields cannot be empty")
 	}
+	if len(input) <= otpInput {
+		return nil
+	}
+	if uri := strings.TrimSpace(input[otpInput].Value()); uri != "" {
+		if _, err := otp.Parse(uri); err != nil {
+			return fmt.Errorf("one-time password URI %w", err)
+		}
+	}
+	return nil
+}
+
+// validateCustomFields checks that every custom field has a name and a value matching its type.
+func validateCustomFields(fields []model.CustomField) error {
+	for _, field := range fields {
+		if err := field.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -4,6 +4,7 @@ package cli
 
 import (
 	"testing"
+	"yubigo-pass/internal/app/model"
 	"yubigo-pass/internal/app/utils"
 	"yubigo-pass/test"
 
@@ -79,6 +80,10 @@ func TestShouldAddPassword(t *testing.T) {
 	test.TypeString(tm, examplePassword)
 	test.PressKey(tm, tea.KeyDown)
 	test.TypeString(tm, exampleUrl)
+	test.PressKey(tm, tea.KeyDown)  // One-time password
+	test.PressKey(tm, tea.KeyDown)  // Folder
+	test.PressKey(tm, tea.KeyDown)  // Tags
+	test.PressKey(tm, tea.KeyDown)  // Notes
 	test.PressKey(tm, tea.KeyDown)  // Focus Add button
 	test.PressKey(tm, tea.KeyEnter) // Submit
 
@@ -114,6 +119,10 @@ func TestShouldNotAddPasswordWithEmptyTitle(t *testing.T) {
 	test.PressKey(tm, tea.KeyDown)
 	test.TypeString(tm, examplePassword)
 	test.PressKey(tm, tea.KeyDown)  // URL
+	test.PressKey(tm, tea.KeyDown)  // One-time password
+	test.PressKey(tm, tea.KeyDown)  // Folder
+	test.PressKey(tm, tea.KeyDown)  // Tags
+	test.PressKey(tm, tea.KeyDown)  // Notes
 	test.PressKey(tm, tea.KeyDown)  // Add button
 	test.PressKey(tm, tea.KeyEnter) // Submit
 
@@ -144,6 +153,10 @@ func TestShouldNotAddPasswordWithEmptyUsername(t *testing.T) {
 	test.PressKey(tm, tea.KeyDown) // Password
 	test.TypeString(tm, examplePassword)
 	test.PressKey(tm, tea.KeyDown)  // URL
+	test.PressKey(tm, tea.KeyDown)  // One-time password
+	test.PressKey(tm, tea.KeyDown)  // Folder
+	test.PressKey(tm, tea.KeyDown)  // Tags
+	test.PressKey(tm, tea.KeyDown)  // Notes
 	test.PressKey(tm, tea.KeyDown)  // Add button
 	test.PressKey(tm, tea.KeyEnter) // Submit
 
@@ -174,6 +187,10 @@ func TestShouldNotAddPasswordWithEmptyPassword(t *testing.T) {
 	test.TypeString(tm, exampleUsername)
 	test.PressKey(tm, tea.KeyDown)  // Password (empty)
 	test.PressKey(tm, tea.KeyDown)  // URL
+	test.PressKey(tm, tea.KeyDown)  // One-time password
+	test.PressKey(tm, tea.KeyDown)  // Folder
+	test.PressKey(tm, tea.KeyDown)  // Tags
+	test.PressKey(tm, tea.KeyDown)  // Notes
 	test.PressKey(tm, tea.KeyDown)  // Add button
 	test.PressKey(tm, tea.KeyEnter) // Submit
 
@@ -200,6 +217,10 @@ func TestShouldNotAddPasswordWithEmptyFields(t *testing.T) {
 	test.PressKey(tm, tea.KeyDown)  // Username
 	test.PressKey(tm, tea.KeyDown)  // Password
 	test.PressKey(tm, tea.KeyDown)  // URL
+	test.PressKey(tm, tea.KeyDown)  // One-time password
+	test.PressKey(tm, tea.KeyDown)  // Folder
+	test.PressKey(tm, tea.KeyDown)  // Tags
+	test.PressKey(tm, tea.KeyDown)  // Notes
 	test.PressKey(tm, tea.KeyDown)  // Add button
 	test.PressKey(tm, tea.KeyEnter) // Submit
 
@@ -241,6 +262,10 @@ func TestShouldAddPasswordWithEmptyUrl(t *testing.T) {
 	test.PressKey(tm, tea.KeyDown)
 	test.TypeString(tm, examplePassword)
 	test.PressKey(tm, tea.KeyDown)  // Focus URL (empty)
+	test.PressKey(tm, tea.KeyDown)  // One-time password
+	test.PressKey(tm, tea.KeyDown)  // Folder
+	test.PressKey(tm, tea.KeyDown)  // Tags
+	test.PressKey(tm, tea.KeyDown)  // Notes
 	test.PressKey(tm, tea.KeyDown)  // Focus Add button
 	test.PressKey(tm, tea.KeyEnter) // Submit
 
@@ -331,6 +356,10 @@ func TestShouldGenerateShowAndAddPassword(t *testing.T) {
 	test.PressKey(tm, tea.KeyCtrlS) // Show password
 	test.PressKey(tm, tea.KeyDown)
 	test.TypeString(tm, exampleUrl)
+	test.PressKey(tm, tea.KeyDown)  // One-time password
+	test.PressKey(tm, tea.KeyDown)  // Folder
+	test.PressKey(tm, tea.KeyDown)  // Tags
+	test.PressKey(tm, tea.KeyDown)  // Notes
 	test.PressKey(tm, tea.KeyDown)  // Focus Add button
 	test.PressKey(tm, tea.KeyEnter) // Submit
 
@@ -380,6 +409,10 @@ func TestShouldGenerateShowThenHideAndAddPassword(t *testing.T) {
 	test.PressKey(tm, tea.KeyCtrlS) // Hide password
 	test.PressKey(tm, tea.KeyDown)
 	test.TypeString(tm, exampleUrl)
+	test.PressKey(tm, tea.KeyDown)  // One-time password
+	test.PressKey(tm, tea.KeyDown)  // Folder
+	test.PressKey(tm, tea.KeyDown)  // Tags
+	test.PressKey(tm, tea.KeyDown)  // Notes
 	test.PressKey(tm, tea.KeyDown)  // Focus Add button
 	test.PressKey(tm, tea.KeyEnter) // Submit
 
@@ -399,3 +432,179 @@ func TestShouldGenerateShowThenHideAndAddPassword(t *testing.T) {
 
 	assert.NoError(t, m.err, "Error should be nil on quit")
 }
+
+func TestShouldAddPasswordWithCustomFieldsAndNotes(t *testing.T) {
+	// given
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+
+	tm := teatest.NewTestModel(
+		t,
+		NewAddPasswordModel(session),
+		teatest.WithInitialTermSize(300, 100),
+	)
+
+	// when
+	test.TypeString(tm, "aws")
+	test.PressKey(tm, tea.KeyDown)
+	test.TypeString(tm, "root")
+	test.PressKey(tm, tea.KeyDown)
+	test.TypeString(tm, "hunter2")
+	test.PressKey(tm, tea.KeyDown) // URL
+	test.PressKey(tm, tea.KeyDown) // One-time password
+	test.PressKey(tm, tea.KeyDown) // Folder
+	test.PressKey(tm, tea.KeyDown) // Tags
+	test.PressKey(tm, tea.KeyCtrlN)
+	test.TypeString(tm, "PIN")
+	test.PressKey(tm, tea.KeyCtrlT) // Text -> Hidden
+	test.PressKey(tm, tea.KeyDown)
+	test.TypeString(tm, "4242")
+	test.PressKey(tm, tea.KeyDown) // Notes
+	test.TypeString(tm, "recovery codes")
+	test.PressKey(tm, tea.KeyDown)  // Focus Add button
+	test.PressKey(tm, tea.KeyEnter) // Submit
+
+	// then
+	err := tm.Quit()
+	require.NoError(t, err, "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(AddPasswordModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+
+	fields := ExtractCustomFieldsFromModel(m)
+	require.Len(t, fields, 1)
+	assert.Equal(t, model.FieldTypeHidden, fields[0].Type)
+	assert.Equal(t, "PIN", fields[0].Name)
+	assert.Equal(t, "4242", fields[0].Value)
+	assert.Equal(t, "recovery codes", ExtractPasswordDataFromModel(m).Notes)
+	assert.NoError(t, m.err, "Error should be nil on quit")
+}
+
+func TestShouldNotAddPasswordWithInvalidBooleanCustomField(t *testing.T) {
+	// given
+	tm := teatest.NewTestModel(
+		t,
+		NewAddPasswordModel(utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())),
+		teatest.WithInitialTermSize(300, 100),
+	)
+
+	// when
+	test.TypeString(tm, "aws")
+	test.PressKey(tm, tea.KeyDown)
+	test.TypeString(tm, "root")
+	test.PressKey(tm, tea.KeyDown)
+	test.TypeString(tm, "hunter2")
+	test.PressKey(tm, tea.KeyCtrlN)
+	test.TypeString(tm, "MFA")
+	test.PressKey(tm, tea.KeyCtrlT) // Text -> Hidden
+	test.PressKey(tm, tea.KeyCtrlT) // Hidden -> Boolean
+	test.PressKey(tm, tea.KeyDown)
+	test.PressKey(tm, tea.KeyBackspace)
+	test.PressKey(tm, tea.KeyBackspace)
+	test.PressKey(tm, tea.KeyBackspace)
+	test.PressKey(tm, tea.KeyBackspace)
+	test.PressKey(tm, tea.KeyBackspace)
+	test.TypeString(tm, "maybe")
+	test.PressKey(tm, tea.KeyDown)  // Notes
+	test.PressKey(tm, tea.KeyDown)  // Focus Add button
+	test.PressKey(tm, tea.KeyEnter) // Submit
+
+	// then
+	err := tm.Quit()
+	require.NoError(t, err, "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(AddPasswordModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	assert.EqualError(t, m.err, "custom field MFA must be true or false")
+}
+
+func TestShouldAddPasswordWithOneTimePassword(t *testing.T) {
+	// given
+	uri := "otpauth://totp/GitHub:octocat?secret=JBSWY3DPEHPK3PXP"
+	tm := teatest.NewTestModel(
+		t,
+		NewAddPasswordModel(utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())),
+		teatest.WithInitialTermSize(300, 100),
+	)
+
+	// when
+	test.TypeString(tm, "github")
+	test.PressKey(tm, tea.KeyDown)
+	test.TypeString(tm, "octocat")
+	test.PressKey(tm, tea.KeyDown)
+	test.TypeString(tm, "hunter2")
+	test.PressKey(tm, tea.KeyDown) // URL
+	test.PressKey(tm, tea.KeyDown) // One-time password
+	test.TypeString(tm, uri)
+	test.PressKey(tm, tea.KeyDown)  // Folder
+	test.PressKey(tm, tea.KeyDown)  // Tags
+	test.PressKey(tm, tea.KeyDown)  // Notes
+	test.PressKey(tm, tea.KeyDown)  // Focus Add button
+	test.PressKey(tm, tea.KeyEnter) // Submit
+
+	// then
+	err := tm.Quit()
+	require.NoError(t, err, "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(AddPasswordModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	assert.Equal(t, uri, ExtractPasswordDataFromModel(m).OTP)
+	assert.NoError(t, m.err, "Error should be nil on quit")
+}
+
+func TestShouldNotAddPasswordWithInvalidOneTimePassword(t *testing.T) {
+	// given
+	tm := teatest.NewTestModel(
+		t,
+		NewAddPasswordModel(utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())),
+		teatest.WithInitialTermSize(300, 100),
+	)
+
+	// when
+	test.TypeString(tm, "github")
+	test.PressKey(tm, tea.KeyDown)
+	test.TypeString(tm, "octocat")
+	test.PressKey(tm, tea.KeyDown)
+	test.TypeString(tm, "hunter2")
+	test.PressKey(tm, tea.KeyDown) // URL
+	test.PressKey(tm, tea.KeyDown) // One-time password
+	test.TypeString(tm, "otpauth://totp/GitHub")
+	test.PressKey(tm, tea.KeyDown)  // Folder
+	test.PressKey(tm, tea.KeyDown)  // Tags
+	test.PressKey(tm, tea.KeyDown)  // Notes
+	test.PressKey(tm, tea.KeyDown)  // Focus Add button
+	test.PressKey(tm, tea.KeyEnter) // Submit
+
+	// then
+	err := tm.Quit()
+	require.NoError(t, err, "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(AddPasswordModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	assert.EqualError(t, m.err, "one-time password URI must have a secret")
+}
+
+func TestShouldGeneratePasswordWithPolicy(t *testing.T) {
+	// given
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	policy := model.PasswordPolicy{Length: 6, Digits: true}
+	tm := teatest.NewTestModel(
+		t,
+		NewAddPasswordModel(session).WithPasswordPolicy(policy),
+		teatest.WithInitialTermSize(300, 100),
+	)
+
+	// when
+	test.PressKey(tm, tea.KeyDown)  // -> Username
+	test.PressKey(tm, tea.KeyDown)  // -> Password
+	test.PressKey(tm, tea.KeyCtrlG) // Generate password
+
+	// then
+	err := tm.Quit()
+	require.NoError(t, err, "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(AddPasswordModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+
+	generated := ExtractPasswordDataFromModel(m).Password
+	assert.Regexp(t, `^[0-9]{6}$`, generated)
+}
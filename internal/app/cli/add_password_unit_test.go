@@ -118,3 +118,46 @@ func TestAddPasswordShouldNotValidateEmptyRequiredField(t *testing.T) {
 		})
 	}
 }
+
+func TestAddPasswordShouldValidateOneTimePasswordURI(t *testing.T) {
+	testCases := []struct {
+		name        string
+		uri         string
+		expectedErr string
+	}{
+		{
+			name: "Empty URI",
+			uri:  "",
+		},
+		{
+			name: "Valid URI",
+			uri:  "otpauth://totp/GitHub:octocat?secret=JBSWY3DPEHPK3PXP",
+		},
+		{
+			name:        "Invalid URI",
+			uri:         "https://github.com",
+			expectedErr: "one-time password URI must be an otpauth:// URI",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inputs := make([]textinput.Model, otpInput+1)
+			for i := range inputs {
+				inputs[i] = newTestInput()
+			}
+			inputs[0].SetValue(test.RandomString())
+			inputs[1].SetValue(test.RandomString())
+			inputs[2].SetValue(test.RandomString())
+			inputs[otpInput].SetValue(tc.uri)
+
+			err := validateAddPasswordModelInputs(inputs)
+
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
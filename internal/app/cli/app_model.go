@@ -6,9 +6,14 @@ import (
 	"strings"
 	"yubigo-pass/internal/app/common"
 	"yubigo-pass/internal/app/crypto"
+	"yubigo-pass/internal/app/gitsync"
+	"yubigo-pass/internal/app/httpsync"
 	"yubigo-pass/internal/app/model"
 	"yubigo-pass/internal/app/services"
+	"yubigo-pass/internal/app/sharelink"
 	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/internal/database"
 
 	"github.com/charmbracelet/lipgloss"
 
@@ -23,9 +28,14 @@ type AppModel struct {
 	height      int
 	activeModel tea.Model
 	session     utils.Session
+	username    string
 	container   services.Container
+	opened      vault.Vault
+	openedFrom  common.MsgState
 	lastError   error
 	showErr     bool
+	tampered    error
+	policy      model.PasswordPolicy
 }
 
 // NewAppModel creates the initial state of the top-level application model.
@@ -38,15 +48,30 @@ func NewAppModel(container services.Container) AppModel {
 		container:   container,
 		session:     utils.NewEmptySession(),
 		activeModel: initialModel, // Start with the login model
+		policy:      model.DefaultPasswordPolicy,
 	}
 }
 
-// Init initializes the application model by initializing the currently active sub-model.
+// Init initializes the application model by initializing the currently active sub-model
+// and verifies the audit log was not tampered with.
 func (m AppModel) Init() tea.Cmd {
+	verify := verifyAuditLogCmd(m.container.Store)
 	if m.activeModel != nil {
-		return m.activeModel.Init()
+		return tea.Batch(m.activeModel.Init(), verify)
+	}
+	return verify
+}
+
+// auditLogVerifiedMsg reports whether the audit log was found tampered with.
+type auditLogVerifiedMsg struct {
+	err error
+}
+
+// verifyAuditLogCmd walks the hash chain of the audit log.
+func verifyAuditLogCmd(store database.StoreExecutor) tea.Cmd {
+	return func() tea.Msg {
+		return auditLogVerifiedMsg{err: vault.VerifyAuditLog(store)}
 	}
-	return nil
 }
 
 // Update handles incoming messages, updates the active sub-model, and manages transitions
@@ -65,6 +90,19 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.showErr = true
 		return m, nil
 
+	case auditLogVerifiedMsg:
+		m.tampered = msg.err
+		return m, nil
+
+	case syncedMsg:
+		if msg.err != nil {
+			return m, common.ErrCmd(fmt.Errorf("sync failed: %w", msg.err))
+		}
+		if msg.conflicts > 0 {
+			return m, common.ErrCmd(fmt.Errorf("%d entries were edited on another device as well, resolve the sync conflicts from the main menu", msg.conflicts))
+		}
+		return m, nil
+
 	case common.StateMsg:
 		m.lastError = nil
 		switch msg.State {
@@ -77,13 +115,113 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.activeModel = NewLoginModel(m.container.Store)
 				return m, tea.Batch(m.activeModel.Init(), tea.Batch(cmds...))
 			}
-			m.activeModel = NewAddPasswordModel(m.session)
+			m.activeModel = NewAddPasswordModel(m.session).WithPasswordPolicy(m.policy)
+			return m, m.activeModel.Init()
+		case common.StateGoToAddItem:
+			if !m.session.IsAuthenticated() {
+				cmds = append(cmds, common.ErrCmd(errors.New("cannot add item: not authenticated")))
+				m.activeModel = NewLoginModel(m.container.Store)
+				return m, tea.Batch(m.activeModel.Init(), tea.Batch(cmds...))
+			}
+			m.activeModel = NewItemTypePickerModel()
+			return m, tea.Batch(m.activeModel.Init(), m.windowSizeCmd())
+		case common.StateGoToViewPasswords:
+			if !m.session.IsAuthenticated() {
+				cmds = append(cmds, common.ErrCmd(errors.New("cannot view passwords: not authenticated")))
+				m.activeModel = NewLoginModel(m.container.Store)
+				return m, tea.Batch(m.activeModel.Init(), tea.Batch(cmds...))
+			}
+			m.activeModel = m.viewPasswordsModel(vault.New(m.container.Store, m.session))
+			return m, m.activeModel.Init()
+		case common.StateGoToImportQR:
+			if !m.session.IsAuthenticated() {
+				cmds = append(cmds, common.ErrCmd(errors.New("cannot import: not authenticated")))
+				m.activeModel = NewLoginModel(m.container.Store)
+				return m, tea.Batch(m.activeModel.Init(), tea.Batch(cmds...))
+			}
+			m.activeModel = NewImportQRModel(vault.New(m.container.Store, m.session))
+			return m, m.activeModel.Init()
+		case common.StateGoToImportCSV:
+			if !m.session.IsAuthenticated() {
+				cmds = append(cmds, common.ErrCmd(errors.New("cannot import: not authenticated")))
+				m.activeModel = NewLoginModel(m.container.Store)
+				return m, tea.Batch(m.activeModel.Init(), tea.Batch(cmds...))
+			}
+			m.activeModel = NewImportCSVModel(vault.New(m.container.Store, m.session))
+			return m, m.activeModel.Init()
+		case common.StateGoToSyncConflicts:
+			if !m.session.IsAuthenticated() {
+				cmds = append(cmds, common.ErrCmd(errors.New("cannot resolve conflicts: not authenticated")))
+				m.activeModel = NewLoginModel(m.container.Store)
+				return m, tea.Batch(m.activeModel.Init(), tea.Batch(cmds...))
+			}
+			m.activeModel = NewSyncConflictsModel(m.syncClient(), vault.New(m.container.Store, m.session))
+			return m, m.activeModel.Init()
+		case common.StateGoToCollections:
+			if !m.session.IsAuthenticated() {
+				cmds = append(cmds, common.ErrCmd(errors.New("cannot view collections: not authenticated")))
+				m.activeModel = NewLoginModel(m.container.Store)
+				return m, tea.Batch(m.activeModel.Init(), tea.Batch(cmds...))
+			}
+			m.activeModel = NewCollectionsModel(vault.New(m.container.Store, m.session))
+			return m, m.activeModel.Init()
+		case common.StateGoToEmergencyAccess:
+			if !m.session.IsAuthenticated() {
+				cmds = append(cmds, common.ErrCmd(errors.New("cannot manage emergency access: not authenticated")))
+				m.activeModel = NewLoginModel(m.container.Store)
+				return m, tea.Batch(m.activeModel.Init(), tea.Batch(cmds...))
+			}
+			m.activeModel = NewEmergencyAccessModel(vault.New(m.container.Store, m.session))
+			return m, m.activeModel.Init()
+		case common.StateGoToSecurityReport:
+			if !m.session.IsAuthenticated() {
+				cmds = append(cmds, common.ErrCmd(errors.New("cannot check passwords: not authenticated")))
+				m.activeModel = NewLoginModel(m.container.Store)
+				return m, tea.Batch(m.activeModel.Init(), tea.Batch(cmds...))
+			}
+			m.activeModel = NewSecurityReportModel(vault.New(m.container.Store, m.session)).
+				WithBreachCheck(m.container.BreachIndex, m.container.BreachAPI)
+			return m, m.activeModel.Init()
+		case common.StateGoToAuditLog:
+			if !m.session.IsAuthenticated() {
+				cmds = append(cmds, common.ErrCmd(errors.New("cannot view audit log: not authenticated")))
+				m.activeModel = NewLoginModel(m.container.Store)
+				return m, tea.Batch(m.activeModel.Init(), tea.Batch(cmds...))
+			}
+			m.activeModel = NewAuditLogModel(vault.New(m.container.Store, m.session))
+			return m, m.activeModel.Init()
+		case common.StateGoToPasswordGenerator:
+			if !m.session.IsAuthenticated() {
+				cmds = append(cmds, common.ErrCmd(errors.New("cannot configure the password generator: not authenticated")))
+				m.activeModel = NewLoginModel(m.container.Store)
+				return m, tea.Batch(m.activeModel.Init(), tea.Batch(cmds...))
+			}
+			m.activeModel = NewPasswordGeneratorModel(vault.New(m.container.Store, m.session))
 			return m, m.activeModel.Init()
 
 		case common.StateGoBack:
 			switch m.activeModel.(type) {
-			case AddPasswordModel:
-				m.activeModel = NewMainMenuModel()
+			case ViewPasswordsModel:
+				if m.opened.UserID() != "" {
+					from := m.openedFrom
+					m.opened, m.openedFrom = vault.Vault{}, 0
+					return m, common.ChangeStateCmd(from)
+				}
+				m.activeModel = m.mainMenu()
+			case AddPasswordModel, ItemTypePickerModel, ImportQRModel, ImportCSVModel, CollectionsModel, EmergencyAccessModel, SecurityReportModel, AuditLogModel:
+				m.activeModel = m.mainMenu()
+			case PasswordGeneratorModel:
+				// the entry forms generate passwords with the profile activated on the screen
+				m.policy = m.passwordPolicy()
+				m.activeModel = m.mainMenu()
+			case SyncConflictsModel:
+				m.activeModel = m.mainMenu()
+				return m, tea.Batch(m.activeModel.Init(), m.syncCmd())
+			case ItemFormModel:
+				m.activeModel = NewItemTypePickerModel()
+				return m, tea.Batch(m.activeModel.Init(), m.windowSizeCmd())
+			case PasswordDetailModel:
+				m.activeModel = m.viewPasswordsModel(m.activeVault())
 			case CreateUserModel:
 				m.activeModel = NewLoginModel(m.container.Store)
 			default:
@@ -93,6 +231,9 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case common.StateLogout:
 			m.session.Clear()
+			m.opened, m.openedFrom = vault.Vault{}, 0
+			m.username = ""
+			m.policy = model.DefaultPasswordPolicy
 			m.activeModel = NewLoginModel(m.container.Store)
 			return m, m.activeModel.Init()
 
@@ -103,20 +244,90 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.activeModel = NewLoginModel(m.container.Store)
 			return m, m.activeModel.Init()
 		case common.StatePasswordAdded:
-			m.activeModel = NewMainMenuModel()
+			m.activeModel = m.mainMenu()
 			return m, m.activeModel.Init()
 		}
 
+	case openVaultMsg:
+		m.lastError = nil
+		m.opened, m.openedFrom = msg.vault, msg.from
+		m.activeModel = m.viewPasswordsModel(msg.vault)
+		return m, m.activeModel.Init()
+
 	case common.LoginMsg:
 		m.lastError = nil
 		session, err := m.attemptLogin(msg.Username, msg.Password)
+		var throttled model.LoginThrottledError
+		if errors.As(err, &throttled) {
+			// the login screen counts down until the next attempt is allowed, below the error of the
+			// wrong attempt that locked it
+			m.activeModel, cmd = m.activeModel.Update(loginThrottledMsg{retryAt: throttled.RetryAt})
+			if errors.Is(err, vault.ErrIncorrectCredentials) {
+				return m, tea.Batch(cmd, common.ErrCmd(vault.ErrIncorrectCredentials))
+			}
+			return m, cmd
+		}
 		if err != nil {
 			return m, common.ErrCmd(err)
 		}
 		m.session = session
-		m.activeModel = NewMainMenuModel()
+		m.username = msg.Username
+		m.policy = m.passwordPolicy()
+		m.activeModel = m.mainMenu()
+		if requests, err := vault.New(m.container.Store, session).EmergencyRequests(); err == nil && len(requests) > 0 {
+			// the owner learns about requests for access to their vault while they may still reject them
+			m.activeModel = NewEmergencyAccessModel(vault.New(m.container.Store, session))
+		}
+		return m, tea.Batch(m.activeModel.Init(), m.syncCmd())
+
+	case common.ShowPasswordMsg:
+		m.lastError = nil
+		if !m.session.IsAuthenticated() {
+			m.activeModel = NewLoginModel(m.container.Store)
+			return m, tea.Batch(m.activeModel.Init(), common.ErrCmd(errors.New("cannot show password: not authenticated")))
+		}
+		v := m.activeVault()
+		detail := NewPasswordDetailModel(v, vault.NewAttachments(v, m.container.AttachmentDir), msg.Entry)
+		if m.container.ShareServer != "" {
+			detail = detail.WithShareLinks(sharelink.New(m.container.ShareServer, m.container.ShareToken))
+		}
+		m.activeModel = detail
+		return m, m.activeModel.Init()
+
+	case common.ItemTypeChosenMsg:
+		m.lastError = nil
+		if msg.Type == model.ItemTypeLogin {
+			m.activeModel = NewAddPasswordModel(m.session).WithPasswordPolicy(m.policy)
+		} else {
+			m.activeModel = NewItemFormModel(msg.Type).WithPasswordPolicy(m.policy)
+		}
+		return m, m.activeModel.Init()
+
+	case common.EditPasswordMsg:
+		m.lastError = nil
+		if !m.session.IsAuthenticated() {
+			m.activeModel = NewLoginModel(m.container.Store)
+			return m, tea.Batch(m.activeModel.Init(), common.ErrCmd(errors.New("cannot edit password: not authenticated")))
+		}
+		m.activeModel = NewEditItemFormModel(m.activeVault(), msg.Entry).WithPasswordPolicy(m.policy)
 		return m, m.activeModel.Init()
 
+	case common.ItemToAddMsg:
+		m.lastError = nil
+		err := m.addNewItem(msg.Item, msg.Folder, msg.Tags)
+		if err != nil {
+			return m, common.ErrCmd(fmt.Errorf("failed to add item: %w", err))
+		}
+		return m, tea.Batch(common.ChangeStateCmd(common.StatePasswordAdded), m.syncCmd())
+
+	case common.ItemToUpdateMsg:
+		m.lastError = nil
+		entry, err := m.updateItem(msg.Entry, msg.Item, msg.Folder, msg.Tags)
+		if err != nil {
+			return m, common.ErrCmd(fmt.Errorf("failed to update item: %w", err))
+		}
+		return m, tea.Batch(common.ShowPasswordCmd(entry), m.syncCmd())
+
 	case common.UserToCreateMsg:
 		m.lastError = nil
 		err := m.createNewUser(msg.Username, msg.Password)
@@ -127,11 +338,11 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case common.PasswordToAddMsg:
 		m.lastError = nil
-		err := m.addNewPassword(msg.Data.Title, msg.Data.Username, msg.Data.Password, msg.Data.Url)
+		err := m.addNewPassword(msg.Data, msg.Fields, msg.Folder, msg.Tags)
 		if err != nil {
 			return m, common.ErrCmd(fmt.Errorf("failed to add password: %w", err))
 		}
-		return m, common.ChangeStateCmd(common.StatePasswordAdded)
+		return m, tea.Batch(common.ChangeStateCmd(common.StatePasswordAdded), m.syncCmd())
 
 	default:
 		if m.activeModel != nil {
@@ -164,10 +375,14 @@ func (m AppModel) View() string {
 		viewBuilder.WriteString("Error: No active model to display.")
 	}
 
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
 	if m.lastError != nil && m.showErr {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
 		fmt.Fprintf(&viewBuilder, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.lastError.Error()))
 	}
+	if m.tampered != nil {
+		// the warning stays up for the whole session, whatever the screen
+		fmt.Fprintf(&viewBuilder, "\n%s %s\n", validateErrPrefix, errorStyle.Render("Warning: "+m.tampered.Error()))
+	}
 	return viewBuilder.String()
 }
 
@@ -192,57 +407,151 @@ func (m *AppModel) createNewUser(username, password string) error {
 	return nil
 }
 
-// addNewPassword handles the logic for encrypting and adding a new password entry to the database.
-func (m *AppModel) addNewPassword(title, username, password, url string) error {
+// addNewPassword handles the logic for encrypting and adding a new password entry with its custom fields
+// to the database, filing it under the given folder path and labelling it with the given tags.
+func (m *AppModel) addNewPassword(entry model.Password, fields []model.CustomField, folder string, tags []string) error {
 	if !m.session.IsAuthenticated() {
 		return errors.New("cannot add password: no active user session")
 	}
 
-	encryptionKey := crypto.DeriveAESKey(m.session.GetPassphrase(), m.session.GetSalt())
-
-	encryptedPassword, nonce, err := crypto.EncryptAES(encryptionKey, []byte(password))
+	v := vault.New(m.container.Store, m.session)
+	entry.ID = uuid.New().String()
+	err := v.AddEntry(entry, fields, folder, tags)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt password: %w", err)
+		var passExistsError *model.PasswordAlreadyExistsError
+		if errors.As(err, &passExistsError) {
+			return passExistsError
+		}
+		return fmt.Errorf("database error adding password: %w", err)
 	}
 
-	ciphertext := append(nonce, encryptedPassword...)
+	return v.Record(model.AuditCreated, model.ClientTUI, entry)
+}
 
-	addPasswordInput := model.NewPassword(
-		m.session.GetUserID(),
-		title,
-		username,
-		string(ciphertext),
-		url,
-		nonce,
-	)
+// addNewItem handles the logic for encrypting and adding a new typed item to the database.
+func (m *AppModel) addNewItem(item model.Item, folder string, tags []string) error {
+	if !m.session.IsAuthenticated() {
+		return errors.New("cannot add item: no active user session")
+	}
 
-	err = m.container.Store.AddPassword(addPasswordInput)
+	v := vault.New(m.container.Store, m.session)
+	id, err := v.AddItem(item, nil, folder, tags)
 	if err != nil {
 		var passExistsError *model.PasswordAlreadyExistsError
 		if errors.As(err, &passExistsError) {
 			return passExistsError
 		}
-		return fmt.Errorf("database error adding password: %w", err)
+		return fmt.Errorf("database error adding item: %w", err)
 	}
 
-	return nil
+	entry, _ := item.Entry()
+	entry.ID = id
+	return v.Record(model.AuditCreated, model.ClientTUI, entry)
 }
 
-// attemptLogin handles the logic for logging in a user by verifying their credentials.
-func (m *AppModel) attemptLogin(username, password string) (utils.Session, error) {
-	user, err := m.container.Store.GetUser(username)
+// updateItem handles the logic for overwriting a stored entry with an edited item,
+// returning the updated entry as stored.
+func (m *AppModel) updateItem(entry model.Password, item model.Item, folder string, tags []string) (model.Password, error) {
+	if !m.session.IsAuthenticated() {
+		return model.Password{}, errors.New("cannot update item: no active user session")
+	}
+
+	v := m.activeVault()
+	err := v.UpdateItem(entry, item, folder, tags)
 	if err != nil {
-		if errors.As(err, &model.UserNotFoundError{}) {
-			return utils.NewEmptySession(), fmt.Errorf("incorrect username or password")
-		} else {
-			return utils.NewEmptySession(), fmt.Errorf("login failed: %w", err)
-		}
+		return model.Password{}, err
+	}
+	updated, err := v.Entry(entry.ID)
+	if err != nil {
+		return model.Password{}, err
+	}
+	return updated, v.Record(model.AuditChanged, model.ClientTUI, updated)
+}
+
+// activeVault returns the vault whose entries are browsed, the shared collection or the vault of
+// another user opened through emergency access, or else the user's own vault.
+func (m *AppModel) activeVault() vault.Vault {
+	if m.opened.UserID() != "" {
+		return m.opened
+	}
+	return vault.New(m.container.Store, m.session)
+}
+
+// viewPasswordsModel returns the entry list of a vault, checking its passwords for breaches when breach
+// data is set up.
+func (m *AppModel) viewPasswordsModel(v vault.Vault) ViewPasswordsModel {
+	return NewViewPasswordsModel(v).WithBreachCheck(m.container.BreachIndex, m.container.BreachAPI)
+}
+
+// passwordPolicy returns the policy of the active generator profile of the user, the default
+// policy when it cannot be loaded.
+func (m *AppModel) passwordPolicy() model.PasswordPolicy {
+	profile, err := vault.New(m.container.Store, m.session).ActiveGeneratorProfile()
+	if err != nil {
+		return model.DefaultPasswordPolicy
 	}
+	return profile.PasswordPolicy
+}
+
+// syncedMsg reports the end of a background sync along with the conflicts it found.
+type syncedMsg struct {
+	conflicts int
+	err       error
+}
 
-	hashedPassword := crypto.HashPasswordWithSalt(password, user.Salt)
-	if hashedPassword == user.Password {
-		session := utils.NewSession(user.UserID, password, user.Salt)
-		return session, nil
+// mainMenu returns the main menu, offering to resolve sync conflicts when a sync server is set.
+func (m *AppModel) mainMenu() MainMenuModel {
+	if m.container.SyncServer != "" {
+		return NewMainMenuModel().WithSyncConflicts()
 	}
-	return utils.NewEmptySession(), fmt.Errorf("incorrect username or password")
+	return NewMainMenuModel()
+}
+
+// windowSizeCmd resends the size of the window, sizing a newly opened list to it rather than to its
+// default width, which cuts off longer titles.
+func (m *AppModel) windowSizeCmd() tea.Cmd {
+	size := tea.WindowSizeMsg{Width: m.width, Height: m.height}
+	return func() tea.Msg { return size }
+}
+
+// syncClient returns the client synchronizing the vault of the session with the sync server.
+func (m *AppModel) syncClient() httpsync.Client {
+	return httpsync.ForUser(m.container.SyncDir, m.session.GetUserID(), m.username, m.container.SyncServer, m.container.SyncToken)
+}
+
+// syncCmd synchronizes the vault of the session in the background, pulling the changes of other
+// devices and sending local ones, through the sync server or else the git remote. It is nil when
+// sync is off.
+func (m *AppModel) syncCmd() tea.Cmd {
+	if !m.session.IsAuthenticated() {
+		return nil
+	}
+	v := vault.New(m.container.Store, m.session)
+	switch {
+	case m.container.SyncServer != "":
+		client := m.syncClient()
+		return func() tea.Msg {
+			report, err := client.Sync(v)
+			if err == nil && report.Received > 0 {
+				err = v.Record(model.AuditImported, model.ClientTUI, model.Password{})
+			}
+			return syncedMsg{conflicts: report.Conflicts, err: err}
+		}
+	case m.container.SyncRemote != "":
+		syncer := gitsync.ForUser(m.container.SyncDir, v.UserID(), m.container.SyncRemote)
+		return func() tea.Msg {
+			report, err := syncer.Sync(v)
+			if err == nil && report.Received > 0 {
+				err = v.Record(model.AuditImported, model.ClientTUI, model.Password{})
+			}
+			return syncedMsg{err: err}
+		}
+	default:
+		return nil
+	}
+}
+
+// attemptLogin handles the logic for logging in a user by verifying their credentials.
+func (m *AppModel) attemptLogin(username, password string) (utils.Session, error) {
+	return vault.Unlock(m.container.Store, username, password)
 }
@@ -219,8 +219,7 @@ func TestAppModel_AddPasswordFlow_Success(t *testing.T) {
 	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool { return bytes.Contains(bts, []byte("MAIN MENU")) })
 
 	// Navigate to Add Password and select
-	test.PressKey(tm, tea.KeyDown)  // -> View Passwords
-	test.PressKey(tm, tea.KeyDown)  // -> Add Password
+	moveToMenuItem(t, tm, AddPasswordItem)
 	test.PressKey(tm, tea.KeyEnter) // Select Add Password
 
 	// Wait for Add Password screen
@@ -240,6 +239,10 @@ func TestAppModel_AddPasswordFlow_Success(t *testing.T) {
 	test.PressKey(tm, tea.KeyDown) // -> Password
 	test.TypeString(tm, newPassword)
 	test.PressKey(tm, tea.KeyDown)  // -> URL
+	test.PressKey(tm, tea.KeyDown)  // -> One-time password
+	test.PressKey(tm, tea.KeyDown)  // -> Folder
+	test.PressKey(tm, tea.KeyDown)  // -> Tags
+	test.PressKey(tm, tea.KeyDown)  // -> Notes
 	test.PressKey(tm, tea.KeyDown)  // -> Add Button
 	test.PressKey(tm, tea.KeyEnter) // Submit Add Password
 
@@ -291,8 +294,7 @@ func TestAppModel_AddPasswordFlow_PasswordAlreadyExists(t *testing.T) {
 	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool { return bytes.Contains(bts, []byte("MAIN MENU")) })
 
 	// Navigate to Add Password and select
-	test.PressKey(tm, tea.KeyDown)  // -> View Passwords
-	test.PressKey(tm, tea.KeyDown)  // -> Add Password
+	moveToMenuItem(t, tm, AddPasswordItem)
 	test.PressKey(tm, tea.KeyEnter) // Select Add Password
 
 	// Wait for Add Password screen
@@ -306,6 +308,7 @@ func TestAppModel_AddPasswordFlow_PasswordAlreadyExists(t *testing.T) {
 	newPassword := test.RandomString()
 
 	test.InsertIntoPasswords(t, db, model.Password{
+		ID:       uuid.New().String(),
 		UserID:   userID,
 		Title:    newTitle,
 		Username: newPwdUsername,
@@ -321,6 +324,10 @@ func TestAppModel_AddPasswordFlow_PasswordAlreadyExists(t *testing.T) {
 	test.PressKey(tm, tea.KeyDown) // -> Password
 	test.TypeString(tm, newPassword)
 	test.PressKey(tm, tea.KeyDown)  // -> URL
+	test.PressKey(tm, tea.KeyDown)  // -> One-time password
+	test.PressKey(tm, tea.KeyDown)  // -> Folder
+	test.PressKey(tm, tea.KeyDown)  // -> Tags
+	test.PressKey(tm, tea.KeyDown)  // -> Notes
 	test.PressKey(tm, tea.KeyDown)  // -> Add Button
 	test.PressKey(tm, tea.KeyEnter) // Submit Add Password
 
@@ -368,8 +375,7 @@ func TestAppModel_AddPasswordFlow_GoBack(t *testing.T) {
 	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool { return bytes.Contains(bts, []byte("MAIN MENU")) })
 
 	// Navigate to Add Password and select
-	test.PressKey(tm, tea.KeyDown)  // -> View Passwords
-	test.PressKey(tm, tea.KeyDown)  // -> Add Password
+	moveToMenuItem(t, tm, AddPasswordItem)
 	test.PressKey(tm, tea.KeyEnter) // Select Add Password
 
 	// Wait for Add Password screen
@@ -425,15 +431,12 @@ func TestAppModel_LogoutFlow(t *testing.T) {
 	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool { return bytes.Contains(bts, []byte("MAIN MENU")) })
 
 	// Navigate to Logout and select
-	test.PressKey(tm, tea.KeyDown)  // -> View
-	test.PressKey(tm, tea.KeyDown)  // -> Add
-	test.PressKey(tm, tea.KeyDown)  // -> Logout
+	moveToMenuItem(t, tm, LogoutItem)
 	test.PressKey(tm, tea.KeyEnter) // Select Logout
 
-	// Wait to return to Login screen
+	// Wait to return to Login screen, the output may still hold repaints of the menu
 	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
-		return bytes.Contains(bts, []byte("LOGIN")) &&
-			!bytes.Contains(bts, []byte("MAIN MENU"))
+		return bytes.Contains(bts, []byte("LOGIN")) && bytes.Contains(bts, []byte("Create new user"))
 	}, teatest.WithDuration(2*time.Second))
 
 	tm.Quit()
@@ -457,3 +460,88 @@ func TestAppModel_QuitFlow(t *testing.T) {
 	// Wait for program to finish
 	tm.WaitFinished(t, teatest.WithFinalTimeout(time.Second))
 }
+
+func TestAppModel_AddItemFlow_Success(t *testing.T) {
+	db, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed setup")
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+	container := services.Container{Store: store}
+
+	// Setup existing user for login
+	existingUsername := test.RandomString()
+	existingPassword := test.RandomString()
+	existingSalt, err := crypto.NewSalt()
+	require.NoError(t, err)
+	userID := uuid.New().String()
+	existingUser := model.User{
+		UserID:   userID,
+		Username: existingUsername,
+		Password: crypto.HashPasswordWithSalt(existingPassword, existingSalt),
+		Salt:     existingSalt,
+	}
+	test.InsertIntoUsers(t, db, existingUser)
+
+	tm := teatest.NewTestModel(t, NewAppModel(container), teatest.WithInitialTermSize(300, 100))
+
+	// Login first
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool { return bytes.Contains(bts, []byte("LOGIN")) })
+	test.TypeString(tm, existingUsername)
+	test.PressKey(tm, tea.KeyDown) // -> Password
+	test.TypeString(tm, existingPassword)
+	test.PressKey(tm, tea.KeyDown)  // -> Login Button
+	test.PressKey(tm, tea.KeyEnter) // Submit Login
+
+	// Wait for Main Menu
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool { return bytes.Contains(bts, []byte("MAIN MENU")) })
+
+	// Navigate to Add Item and select
+	moveToMenuItem(t, tm, AddItemItem)
+	test.PressKey(tm, tea.KeyEnter) // Select Add Item
+
+	// Pick the database credential type
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("WHAT DO YOU WANT TO ADD?"))
+	}, teatest.WithDuration(2*time.Second))
+	for range 6 {
+		test.PressKey(tm, tea.KeyDown)
+	}
+	test.PressKey(tm, tea.KeyEnter)
+
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("ADD A NEW DATABASE CREDENTIAL"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// Fill the form
+	test.TypeString(tm, "orders")
+	test.PressKey(tm, tea.KeyDown) // -> Username
+	test.TypeString(tm, "app")
+	test.PressKey(tm, tea.KeyDown) // -> Password
+	test.TypeString(tm, "s3cret")
+	test.PressKey(tm, tea.KeyDown) // -> Engine
+	test.TypeString(tm, "postgres")
+	test.PressKey(tm, tea.KeyDown) // -> Host
+	test.TypeString(tm, "db.internal")
+	test.PressKey(tm, tea.KeyDown) // -> Port
+	test.TypeString(tm, "5432")
+	test.PressKey(tm, tea.KeyDown) // -> Database name
+	test.PressKey(tm, tea.KeyDown) // -> Folder
+	test.PressKey(tm, tea.KeyDown) // -> Tags
+	test.PressKey(tm, tea.KeyDown) // -> Notes
+	test.PressKey(tm, tea.KeyDown) // -> Save Button
+	test.PressKey(tm, tea.KeyEnter)
+
+	// Wait to return to Main Menu screen
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("MAIN MENU")) &&
+			!bytes.Contains(bts, []byte("ADD A NEW DATABASE CREDENTIAL"))
+	}, teatest.WithDuration(3*time.Second))
+
+	// Verify item exists in DB
+	stored, dbErr := store.GetPassword(userID, "orders", "app")
+	assert.NoError(t, dbErr, "Item should exist in database after adding")
+	assert.Equal(t, model.ItemTypeDatabase, stored.Type)
+	assert.NotEmpty(t, stored.Payload)
+
+	tm.Quit()
+}
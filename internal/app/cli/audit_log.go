@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// auditLogPageSize is the number of events shown at once.
+const auditLogPageSize = 15
+
+// auditLogLoadedMsg carries the events of the audit log passing the filter.
+type auditLogLoadedMsg struct {
+	events []vault.AuditEntry
+	err    error
+}
+
+// AuditLogModel is a Bubble Tea model browsing the audit log of the vault, the latest events first.
+// Typing narrows the events down to entries whose title contains the text, Tab and Shift+Tab cycle
+// through the kinds of events and the clients they happened through.
+type AuditLogModel struct {
+	vault    vault.Vault
+	events   []vault.AuditEntry
+	input    textinput.Model
+	event    int
+	client   int
+	selected int
+	loaded   bool
+	err      error
+}
+
+// NewAuditLogModel creates a new instance of the AuditLogModel.
+func NewAuditLogModel(v vault.Vault) AuditLogModel {
+	t := textinput.New()
+	t.Cursor.Style = cursorStyle
+	t.CharLimit = 64
+	t.Placeholder = "Filter by entry title"
+	t.PromptStyle = focusedStyle
+	t.TextStyle = focusedStyle
+	t.Focus()
+
+	return AuditLogModel{
+		vault: v,
+		input: t,
+	}
+}
+
+// Init loads the audit log of the vault.
+func (m AuditLogModel) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, m.loadCmd())
+}
+
+// filter returns the filter picked on the screen, the first of the cycled values matching everything.
+func (m AuditLogModel) filter() vault.AuditFilter {
+	filter := vault.AuditFilter{Entry: strings.TrimSpace(m.input.Value())}
+	if m.event > 0 {
+		filter.Event = model.AuditEvents[m.event-1]
+	}
+	if m.client > 0 {
+		filter.Client = model.AuditClients[m.client-1]
+	}
+	return filter
+}
+
+// loadCmd decrypts the events of the audit log passing the filter.
+func (m AuditLogModel) loadCmd() tea.Cmd {
+	v, filter := m.vault, m.filter()
+	return func() tea.Msg {
+		events, err := v.AuditLog(filter)
+		return auditLogLoadedMsg{events: events, err: err}
+	}
+}
+
+// Update handles incoming messages and user input for the audit log screen.
+func (m AuditLogModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case auditLogLoadedMsg:
+		m.loaded = true
+		m.events, m.err = msg.events, msg.err
+		m.selected = max(0, min(m.selected, len(m.events)-1))
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, common.ChangeStateCmd(common.StateQuit)
+		case tea.KeyEnter:
+			return m, common.ChangeStateCmd(common.StateGoBack)
+		case tea.KeyUp:
+			m.selected = max(0, m.selected-1)
+			return m, nil
+		case tea.KeyDown:
+			m.selected = max(0, min(m.selected+1, len(m.events)-1))
+			return m, nil
+		case tea.KeyTab:
+			m.event = (m.event + 1) % (len(model.AuditEvents) + 1)
+			m.selected = 0
+			return m, m.loadCmd()
+		case tea.KeyShiftTab:
+			m.client = (m.client + 1) % (len(model.AuditClients) + 1)
+			m.selected = 0
+			return m, m.loadCmd()
+		}
+
+		previous := m.input.Value()
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		if m.input.Value() == previous {
+			return m, cmd
+		}
+		m.selected = 0
+		return m, tea.Batch(cmd, m.loadCmd())
+	}
+	return m, nil
+}
+
+// View renders the audit log screen UI.
+func (m AuditLogModel) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("AUDIT LOG") + "\n\n")
+	b.WriteString(m.input.View() + "\n")
+
+	filter := m.filter()
+	event, client := "all", "all"
+	if filter.Event != "" {
+		event = string(filter.Event)
+	}
+	if filter.Client != "" {
+		client = string(filter.Client)
+	}
+	b.WriteString(blurredStyle.Render(fmt.Sprintf("Events: %s · Client: %s", event, client)) + "\n\n")
+
+	switch {
+	case !m.loaded:
+		b.WriteString("Decrypting the audit log...\n")
+	case m.err == nil && len(m.events) == 0:
+		b.WriteString("No events recorded.\n")
+	case m.err == nil:
+		b.WriteString(m.eventsView())
+	}
+
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
+	}
+
+	help := "(Type: Filter by entry, Tab: Event, Shift+Tab: Client, ↑/↓: Scroll, Enter: Back, Esc: Quit)"
+	b.WriteString(blurredStyle.Render("\n\n" + help))
+	return b.String()
+}
+
+// eventsView renders the page of events around the selected one.
+func (m AuditLogModel) eventsView() string {
+	var b strings.Builder
+	start := max(0, min(m.selected-auditLogPageSize/2, len(m.events)-auditLogPageSize))
+	end := min(len(m.events), start+auditLogPageSize)
+	for i := start; i < end; i++ {
+		event := m.events[i]
+		title := event.Title
+		if title == "" {
+			title = "(whole vault)"
+		}
+		line := fmt.Sprintf("%s  %-8s  %-5s  %s", event.Time.Format(time.DateTime), event.Event, event.Client, title)
+		if i == m.selected {
+			b.WriteString(focusedStyle.Render("> "+line) + "\n")
+			continue
+		}
+		b.WriteString("  " + line + "\n")
+	}
+	b.WriteString(blurredStyle.Render(fmt.Sprintf("\n%d of %d events", m.selected+1, len(m.events))) + "\n")
+	return b.String()
+}
@@ -0,0 +1,111 @@
+//go:build e2e
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/services"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogShouldListAndFilterEvents(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	v := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	require.NoError(t, v.Record(model.AuditRead, model.ClientCLI, model.Password{Title: "GitHub"}))
+	require.NoError(t, v.Record(model.AuditChanged, model.ClientTUI, model.Password{Title: "Bank"}))
+	tm := teatest.NewTestModel(t, NewAuditLogModel(v), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("GitHub")) && bytes.Contains(bts, []byte("Bank"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyTab) // -> read
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Events: read")) && bytes.Contains(bts, []byte("1 of 1 events"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm, ok := tm.FinalModel(t).(AuditLogModel)
+	require.True(t, ok)
+	require.Len(t, fm.events, 1)
+	assert.Equal(t, "GitHub", fm.events[0].Title)
+}
+
+func TestAuditLogShouldFilterByTypedTitle(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	v := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	require.NoError(t, v.Record(model.AuditRead, model.ClientCLI, model.Password{Title: "GitHub"}))
+	require.NoError(t, v.Record(model.AuditRead, model.ClientCLI, model.Password{Title: "Bank"}))
+	tm := teatest.NewTestModel(t, NewAuditLogModel(v), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("1 of 2 events"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.TypeString(tm, "ban")
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("1 of 1 events"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm, ok := tm.FinalModel(t).(AuditLogModel)
+	require.True(t, ok)
+	require.Len(t, fm.events, 1)
+	assert.Equal(t, "Bank", fm.events[0].Title)
+}
+
+func TestPasswordDetailShouldRecordRevealingSecrets(t *testing.T) {
+	// given
+	v, entry := setupDetailEntry(t)
+	m := NewPasswordDetailModel(v, vault.NewAttachments(v, t.TempDir()), entry)
+
+	// when
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	require.NotNil(t, cmd)
+	updated, _ = updated.Update(cmd())
+
+	// then
+	assert.True(t, updated.(PasswordDetailModel).revealed)
+	events, err := v.AuditLog(vault.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, model.AuditRead, events[0].Event)
+	assert.Equal(t, model.ClientTUI, events[0].Client)
+	assert.Equal(t, entry.ID, events[0].EntryID)
+}
+
+func TestAppModelShouldWarnOfTamperedAuditLog(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	v := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	require.NoError(t, v.Record(model.AuditRead, model.ClientCLI, model.Password{Title: "GitHub"}))
+	_, err := db.Exec(`DROP TRIGGER audit_log_no_delete`)
+	require.NoError(t, err)
+	_, err = db.Exec(`DELETE FROM audit_log`)
+	require.NoError(t, err)
+
+	// when
+	tm := teatest.NewTestModel(t, NewAppModel(services.Container{Store: database.NewStore(db)}), teatest.WithInitialTermSize(300, 100))
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("LOGIN")) &&
+			bytes.Contains(bts, []byte("Warning: audit log was tampered with at record 1"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+}
@@ -0,0 +1,387 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// collectionsStep defines what the user is doing on the collections screen.
+type collectionsStep uint
+
+const (
+	collectionsListStep collectionsStep = iota
+	collectionsCreateStep
+	collectionsInviteStep
+	collectionsMoveStep
+)
+
+// collectionsLoadedMsg carries the collections the user is a member of along with their members.
+type collectionsLoadedMsg struct {
+	collections []model.CollectionMember
+	members     map[string][]model.CollectionMember
+	err         error
+}
+
+// ownEntriesLoadedMsg carries the entries of the user that can be moved into a collection.
+type ownEntriesLoadedMsg struct {
+	entries []model.Password
+	err     error
+}
+
+// collectionChangedMsg reports the outcome of creating a collection, inviting a member or moving an entry.
+type collectionChangedMsg struct {
+	notice string
+	err    error
+}
+
+// openVaultMsg asks the application to list the entries of a collection, or of the vault of another
+// user opened through emergency access. from is the state the application returns to afterwards.
+type openVaultMsg struct {
+	vault vault.Vault
+	from  common.MsgState
+}
+
+// CollectionsModel is a Bubble Tea model for the collections of entries shared between the users
+// of this installation. It lists the collections the user is a member of with their members, and
+// lets the user create collections, invite other local users and move their own entries into them.
+type CollectionsModel struct {
+	vault       vault.Vault
+	step        collectionsStep
+	collections []model.CollectionMember
+	members     map[string][]model.CollectionMember
+	entries     []model.Password
+	selected    int
+	cursor      int
+	input       textinput.Model
+	role        model.Role
+	loaded      bool
+	working     bool
+	notice      string
+	err         error
+}
+
+// NewCollectionsModel creates a new instance of the CollectionsModel.
+func NewCollectionsModel(v vault.Vault) CollectionsModel {
+	t := textinput.New()
+	t.Cursor.Style = cursorStyle
+	t.CharLimit = 256
+	t.PromptStyle = focusedStyle
+	t.TextStyle = focusedStyle
+
+	return CollectionsModel{
+		vault: v,
+		input: t,
+		role:  model.RoleRead,
+	}
+}
+
+// Init loads the collections of the user.
+func (m CollectionsModel) Init() tea.Cmd {
+	return loadCollectionsCmd(m.vault)
+}
+
+// Update handles incoming messages and user input for the collections screen.
+func (m CollectionsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case collectionsLoadedMsg:
+		m.loaded = true
+		m.working = false
+		m.collections, m.members, m.err = msg.collections, msg.members, msg.err
+		m.selected = max(0, min(m.selected, len(m.collections)-1))
+		return m, nil
+
+	case ownEntriesLoadedMsg:
+		m.working = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.entries = msg.entries
+		m.cursor = max(0, min(m.cursor, len(m.entries)-1))
+		switch {
+		case len(m.entries) > 0:
+			m.step = collectionsMoveStep
+		case m.step == collectionsMoveStep:
+			m.step = collectionsListStep
+		default:
+			m.err = fmt.Errorf("you have no entries of your own to move")
+		}
+		return m, nil
+
+	case collectionChangedMsg:
+		if msg.err != nil {
+			m.working = false
+			m.err = msg.err
+			return m, nil
+		}
+		m.notice = msg.notice
+		if m.step == collectionsMoveStep {
+			return m, tea.Batch(loadCollectionsCmd(m.vault), loadOwnEntriesCmd(m.vault))
+		}
+		m.step = collectionsListStep
+		m.input.Blur()
+		return m, loadCollectionsCmd(m.vault)
+
+	case tea.KeyMsg:
+		if m.working {
+			return m, nil
+		}
+		if msg.Type == tea.KeyCtrlC {
+			return m, common.ChangeStateCmd(common.StateQuit)
+		}
+		switch m.step {
+		case collectionsCreateStep, collectionsInviteStep:
+			return m.updateInput(msg)
+		case collectionsMoveStep:
+			return m.updateMove(msg)
+		default:
+			return m.updateList(msg)
+		}
+	}
+	return m, nil
+}
+
+// updateList handles user input while browsing the collections.
+func (m CollectionsModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		return m, common.ChangeStateCmd(common.StateQuit)
+	case tea.KeyEnter:
+		return m, common.ChangeStateCmd(common.StateGoBack)
+	case tea.KeyUp:
+		m.selected = max(0, m.selected-1)
+	case tea.KeyDown:
+		m.selected = max(0, min(m.selected+1, len(m.collections)-1))
+	case tea.KeyRunes:
+		m.notice, m.err = "", nil
+		switch string(msg.Runes) {
+		case "n":
+			return m.startInput(collectionsCreateStep, "Name of the new collection")
+		case "i":
+			collection, ok := m.selectedCollection()
+			if !ok {
+				return m, nil
+			}
+			if !collection.Role.CanAdmin() {
+				m.err = vault.ErrNotAdmin
+				return m, nil
+			}
+			m.role = model.RoleRead
+			return m.startInput(collectionsInviteStep, "Username of the user to invite")
+		case "m":
+			collection, ok := m.selectedCollection()
+			if !ok {
+				return m, nil
+			}
+			if !collection.Role.CanWrite() {
+				m.err = vault.ErrReadOnly
+				return m, nil
+			}
+			m.working = true
+			m.cursor = 0
+			return m, loadOwnEntriesCmd(m.vault)
+		case "o":
+			collection, ok := m.selectedCollection()
+			if !ok {
+				return m, nil
+			}
+			v := m.vault
+			return m, func() tea.Msg {
+				shared, err := v.Collection(collection.CollectionID)
+				if err != nil {
+					return common.ErrorMsg{Err: err}
+				}
+				return openVaultMsg{vault: shared, from: common.StateGoToCollections}
+			}
+		}
+	}
+	return m, nil
+}
+
+// startInput asks for the name of a collection or the user to invite.
+func (m CollectionsModel) startInput(step collectionsStep, placeholder string) (tea.Model, tea.Cmd) {
+	m.step = step
+	m.input.Reset()
+	m.input.Placeholder = placeholder
+	return m, m.input.Focus()
+}
+
+// updateInput handles user input while naming a collection or the user to invite.
+func (m CollectionsModel) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.step = collectionsListStep
+		m.input.Blur()
+		return m, nil
+	case tea.KeyTab:
+		if m.step == collectionsInviteStep {
+			m.role = m.role.Next()
+		}
+		return m, nil
+	case tea.KeyEnter:
+		value := strings.TrimSpace(m.input.Value())
+		if value == "" {
+			return m, nil
+		}
+		m.working = true
+		m.err = nil
+		v := m.vault
+		if m.step == collectionsCreateStep {
+			return m, func() tea.Msg {
+				collection, err := v.CreateCollection(value)
+				return collectionChangedMsg{notice: fmt.Sprintf("Created %s", collection.Name), err: err}
+			}
+		}
+		collection, role := m.collections[m.selected], m.role
+		return m, func() tea.Msg {
+			err := v.Invite(collection.CollectionID, value, role)
+			return collectionChangedMsg{notice: fmt.Sprintf("Invited %s to %s as %s", value, collection.Name, role), err: err}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// updateMove handles user input while picking entries to move into the selected collection.
+func (m CollectionsModel) updateMove(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.step = collectionsListStep
+		m.entries = nil
+		return m, nil
+	case tea.KeyUp:
+		m.cursor = max(0, m.cursor-1)
+	case tea.KeyDown:
+		m.cursor = max(0, min(m.cursor+1, len(m.entries)-1))
+	case tea.KeyEnter:
+		if len(m.entries) == 0 {
+			return m, nil
+		}
+		m.working = true
+		m.notice, m.err = "", nil
+		entry, collection, v := m.entries[m.cursor], m.collections[m.selected], m.vault
+		return m, func() tea.Msg {
+			shared, err := v.Collection(collection.CollectionID)
+			if err == nil {
+				err = v.MoveToCollection(entry, shared)
+			}
+			return collectionChangedMsg{notice: fmt.Sprintf("Moved %s into %s", entry.Title, collection.Name), err: err}
+		}
+	}
+	return m, nil
+}
+
+// selectedCollection returns the membership of the selected collection.
+func (m CollectionsModel) selectedCollection() (model.CollectionMember, bool) {
+	if len(m.collections) == 0 {
+		return model.CollectionMember{}, false
+	}
+	return m.collections[m.selected], true
+}
+
+// loadCollectionsCmd reads the collections of the user and their members.
+func loadCollectionsCmd(v vault.Vault) tea.Cmd {
+	return func() tea.Msg {
+		collections, err := v.Collections()
+		if err != nil {
+			return collectionsLoadedMsg{err: err}
+		}
+		members := make(map[string][]model.CollectionMember, len(collections))
+		for _, collection := range collections {
+			members[collection.CollectionID], err = v.Members(collection.CollectionID)
+			if err != nil {
+				return collectionsLoadedMsg{err: err}
+			}
+		}
+		return collectionsLoadedMsg{collections: collections, members: members}
+	}
+}
+
+// loadOwnEntriesCmd reads the entries of the user.
+func loadOwnEntriesCmd(v vault.Vault) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := v.Entries()
+		return ownEntriesLoadedMsg{entries: entries, err: err}
+	}
+}
+
+// View renders the collections screen UI.
+func (m CollectionsModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("SHARED COLLECTIONS") + "\n\n")
+	var help string
+	switch m.step {
+	case collectionsCreateStep:
+		b.WriteString("Create a collection, you become its admin.\n\n")
+		b.WriteString(m.input.View() + "\n")
+		help = "(Enter: Create, Esc: Cancel)"
+	case collectionsInviteStep:
+		fmt.Fprintf(&b, "Invite a user of this installation to %s.\n\n", m.collections[m.selected].Name)
+		b.WriteString(m.input.View() + "\n")
+		fmt.Fprintf(&b, "\nRole: %s\n", focusedStyle.Render(string(m.role)))
+		help = "(Tab: Change role, Enter: Invite, Esc: Cancel)"
+	case collectionsMoveStep:
+		fmt.Fprintf(&b, "Move your entries into %s, they are removed from your own vault.\n\n", m.collections[m.selected].Name)
+		for i, entry := range m.entries {
+			line := entry.Title
+			if entry.Username != "" {
+				line = fmt.Sprintf("%s (%s)", entry.Title, entry.Username)
+			}
+			if i == m.cursor {
+				b.WriteString(focusedStyle.Render("> "+line) + "\n")
+				continue
+			}
+			b.WriteString("  " + line + "\n")
+		}
+		help = "(↑/↓: Select, Enter: Move, Esc: Done)"
+	default:
+		b.WriteString(m.listView())
+		help = "(↑/↓: Select, o: Open, n: New, i: Invite, m: Move entries in, Enter: Back, Esc: Quit)"
+	}
+
+	if m.notice != "" {
+		okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateOk))
+		fmt.Fprintf(&b, "\n%s %s\n", validateOkPrefix, okStyle.Render(m.notice))
+	}
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
+	}
+	b.WriteString(blurredStyle.Render("\n\n" + help))
+	return b.String()
+}
+
+// listView renders the collections of the user with the members of the selected one.
+func (m CollectionsModel) listView() string {
+	switch {
+	case !m.loaded:
+		return "Loading...\n"
+	case len(m.collections) == 0:
+		return "You are not a member of any collection yet, press n to create one.\n"
+	}
+
+	var b strings.Builder
+	for i, collection := range m.collections {
+		line := fmt.Sprintf("%-32s %s", collection.Name, collection.Role)
+		if i == m.selected {
+			b.WriteString(focusedStyle.Render("> "+line) + "\n")
+			continue
+		}
+		b.WriteString("  " + line + "\n")
+	}
+	b.WriteString("\n" + blurredStyle.Render("Members") + "\n")
+	for _, member := range m.members[m.collections[m.selected].CollectionID] {
+		fmt.Fprintf(&b, "  %-32s %s\n", member.Username, member.Role)
+	}
+	return b.String()
+}
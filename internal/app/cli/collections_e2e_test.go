@@ -0,0 +1,111 @@
+//go:build e2e
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectionsShouldCreateCollection(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	tm := teatest.NewTestModel(t, NewCollectionsModel(alice), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("You are not a member of any collection yet"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.TypeString(tm, "n")
+	test.TypeString(tm, "Family")
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Created Family")) && bytes.Contains(bts, []byte("> Family"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	collections, err := alice.Collections()
+	require.NoError(t, err)
+	require.Len(t, collections, 1)
+	assert.Equal(t, "Family", collections[0].Name)
+	assert.Equal(t, model.RoleAdmin, collections[0].Role)
+}
+
+func TestCollectionsShouldInviteUserWithPickedRole(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	bob := vaulttest.NewUser(t, db, "bob", test.RandomString())
+	_, err := alice.CreateCollection("Family")
+	require.NoError(t, err)
+	tm := teatest.NewTestModel(t, NewCollectionsModel(alice), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("> Family"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.TypeString(tm, "i")
+	test.TypeString(tm, "bob")
+	test.PressKey(tm, tea.KeyTab) // -> write
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Invited bob to Family as write"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	collections, err := bob.Collections()
+	require.NoError(t, err)
+	require.Len(t, collections, 1)
+	assert.Equal(t, model.RoleWrite, collections[0].Role)
+}
+
+func TestCollectionsShouldMoveEntryIntoCollection(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	created, err := alice.CreateCollection("Family")
+	require.NoError(t, err)
+	require.NoError(t, alice.AddEntry(model.Password{Title: "Netflix", Username: "family", Password: "hunter2"}, nil, "", nil))
+	tm := teatest.NewTestModel(t, NewCollectionsModel(alice), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("> Family"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.TypeString(tm, "m")
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("> Netflix"))
+	}, teatest.WithDuration(2*time.Second))
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Moved Netflix into Family"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	own, err := alice.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, own)
+	shared, err := alice.Collection(created.ID)
+	require.NoError(t, err)
+	entry, err := shared.FindEntry("Netflix", "family")
+	require.NoError(t, err)
+	password, err := shared.RevealPassword(entry)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", password)
+}
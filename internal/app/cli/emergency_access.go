@@ -0,0 +1,356 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// emergencyWaitDays are the waiting periods cycled through when designating an emergency contact.
+var emergencyWaitDays = []int{1, 2, 7, 14, 30}
+
+// emergencyAccessLoadedMsg carries the emergency contacts of the user and the vaults they may access.
+type emergencyAccessLoadedMsg struct {
+	contacts []model.EmergencyAccess
+	grants   []model.EmergencyAccess
+	err      error
+}
+
+// emergencyAccessChangedMsg reports the outcome of changing emergency access.
+type emergencyAccessChangedMsg struct {
+	notice string
+	err    error
+}
+
+// EmergencyAccessModel is a Bubble Tea model for the emergency access between the users of this
+// installation. Users designate contacts who may request access to their vault, which is granted
+// after a waiting period unless they reject the request, and request access to the vaults of the
+// users who designated them. Pending requests are shown first, as the screen opens on login when
+// there are any.
+type EmergencyAccessModel struct {
+	vault         vault.Vault
+	contacts      []model.EmergencyAccess
+	grants        []model.EmergencyAccess
+	grantsFocused bool
+	contact       int
+	grant         int
+	adding        bool
+	input         textinput.Model
+	waitDays      int
+	loaded        bool
+	working       bool
+	notice        string
+	err           error
+}
+
+// NewEmergencyAccessModel creates a new instance of the EmergencyAccessModel.
+func NewEmergencyAccessModel(v vault.Vault) EmergencyAccessModel {
+	t := textinput.New()
+	t.Cursor.Style = cursorStyle
+	t.CharLimit = 64
+	t.Placeholder = "Username of the contact"
+	t.PromptStyle = focusedStyle
+	t.TextStyle = focusedStyle
+
+	return EmergencyAccessModel{
+		vault:    v,
+		input:    t,
+		waitDays: 7,
+	}
+}
+
+// Init loads the emergency contacts of the user and the vaults they may access.
+func (m EmergencyAccessModel) Init() tea.Cmd {
+	return loadEmergencyAccessCmd(m.vault)
+}
+
+// Update handles incoming messages and user input for the emergency access screen.
+func (m EmergencyAccessModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case emergencyAccessLoadedMsg:
+		m.loaded = true
+		m.working = false
+		m.contacts, m.grants, m.err = msg.contacts, msg.grants, msg.err
+		m.contact = max(0, min(m.contact, len(m.contacts)-1))
+		m.grant = max(0, min(m.grant, len(m.grants)-1))
+		return m, nil
+
+	case emergencyAccessChangedMsg:
+		if msg.err != nil {
+			m.working = false
+			m.err = msg.err
+			return m, nil
+		}
+		m.notice = msg.notice
+		m.adding = false
+		m.input.Blur()
+		return m, loadEmergencyAccessCmd(m.vault)
+
+	case tea.KeyMsg:
+		if m.working {
+			return m, nil
+		}
+		if msg.Type == tea.KeyCtrlC {
+			return m, common.ChangeStateCmd(common.StateQuit)
+		}
+		if m.adding {
+			return m.updateAdd(msg)
+		}
+		return m.updateList(msg)
+	}
+	return m, nil
+}
+
+// updateList handles user input while browsing emergency contacts and the vaults the user may access.
+func (m EmergencyAccessModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		return m, common.ChangeStateCmd(common.StateQuit)
+	case tea.KeyEnter:
+		return m, common.ChangeStateCmd(common.StateGoBack)
+	case tea.KeyTab:
+		m.grantsFocused = !m.grantsFocused
+	case tea.KeyUp:
+		if m.grantsFocused {
+			m.grant = max(0, m.grant-1)
+		} else {
+			m.contact = max(0, m.contact-1)
+		}
+	case tea.KeyDown:
+		if m.grantsFocused {
+			m.grant = max(0, min(m.grant+1, len(m.grants)-1))
+		} else {
+			m.contact = max(0, min(m.contact+1, len(m.contacts)-1))
+		}
+	case tea.KeyRunes:
+		m.notice, m.err = "", nil
+		if m.grantsFocused {
+			return m.updateGrant(string(msg.Runes))
+		}
+		return m.updateContact(string(msg.Runes))
+	}
+	return m, nil
+}
+
+// updateContact handles the keys changing the emergency access given to the selected contact.
+func (m EmergencyAccessModel) updateContact(key string) (tea.Model, tea.Cmd) {
+	if key == "n" {
+		m.adding = true
+		m.input.Reset()
+		return m, m.input.Focus()
+	}
+	if len(m.contacts) == 0 {
+		return m, nil
+	}
+	contact, v := m.contacts[m.contact], m.vault
+	change := func(notice string, fn func(string) error) (tea.Model, tea.Cmd) {
+		m.working = true
+		return m, func() tea.Msg {
+			return emergencyAccessChangedMsg{notice: notice, err: fn(contact.GranteeID)}
+		}
+	}
+	switch key {
+	case "a":
+		return change(fmt.Sprintf("Approved the request of %s", contact.GranteeName), v.ApproveEmergencyAccess)
+	case "x":
+		return change(fmt.Sprintf("Rejected the request of %s", contact.GranteeName), v.RejectEmergencyAccess)
+	case "d":
+		return change(fmt.Sprintf("Removed %s from your emergency contacts", contact.GranteeName), v.RemoveEmergencyContact)
+	}
+	return m, nil
+}
+
+// updateGrant handles the keys requesting and opening the vault of the selected user.
+func (m EmergencyAccessModel) updateGrant(key string) (tea.Model, tea.Cmd) {
+	if len(m.grants) == 0 {
+		return m, nil
+	}
+	grant, v := m.grants[m.grant], m.vault
+	switch key {
+	case "r":
+		m.working = true
+		return m, func() tea.Msg {
+			access, err := v.RequestEmergencyAccess(grant.OwnerID)
+			return emergencyAccessChangedMsg{
+				notice: fmt.Sprintf("Requested access to the vault of %s, granted on %s unless they reject it",
+					grant.OwnerName, access.GrantedAt().Format(time.DateTime)),
+				err: err,
+			}
+		}
+	case "o":
+		return m, func() tea.Msg {
+			owner, err := v.EmergencyVault(grant.OwnerID)
+			if err != nil {
+				return common.ErrorMsg{Err: err}
+			}
+			return openVaultMsg{vault: owner, from: common.StateGoToEmergencyAccess}
+		}
+	}
+	return m, nil
+}
+
+// updateAdd handles user input while designating an emergency contact.
+func (m EmergencyAccessModel) updateAdd(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.adding = false
+		m.input.Blur()
+		return m, nil
+	case tea.KeyTab:
+		m.waitDays = nextWaitDays(m.waitDays)
+		return m, nil
+	case tea.KeyEnter:
+		username := strings.TrimSpace(m.input.Value())
+		if username == "" {
+			return m, nil
+		}
+		m.working = true
+		m.err = nil
+		v, waitDays := m.vault, m.waitDays
+		return m, func() tea.Msg {
+			return emergencyAccessChangedMsg{
+				notice: fmt.Sprintf("%s may now request access to your vault", username),
+				err:    v.AddEmergencyContact(username, waitDays),
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// nextWaitDays returns the waiting period following days in emergencyWaitDays.
+func nextWaitDays(days int) int {
+	for _, d := range emergencyWaitDays {
+		if d > days {
+			return d
+		}
+	}
+	return emergencyWaitDays[0]
+}
+
+// loadEmergencyAccessCmd reads the emergency contacts of the user and the vaults they may access,
+// first releasing the vault key to contacts whose waiting period passed while the user was logged in.
+func loadEmergencyAccessCmd(v vault.Vault) tea.Cmd {
+	return func() tea.Msg {
+		if err := v.GrantDueEmergencyAccess(); err != nil {
+			return emergencyAccessLoadedMsg{err: err}
+		}
+		contacts, err := v.EmergencyContacts()
+		if err != nil {
+			return emergencyAccessLoadedMsg{err: err}
+		}
+		grants, err := v.EmergencyGrants()
+		return emergencyAccessLoadedMsg{contacts: contacts, grants: grants, err: err}
+	}
+}
+
+// View renders the emergency access screen UI.
+func (m EmergencyAccessModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("EMERGENCY ACCESS") + "\n\n")
+	help := "(Tab: Switch list, ↑/↓: Select, n: Add contact, a: Approve, x: Reject, d: Remove, Enter: Back, Esc: Quit)"
+	if m.grantsFocused {
+		help = "(Tab: Switch list, ↑/↓: Select, r: Request access, o: Open vault, Enter: Back, Esc: Quit)"
+	}
+	switch {
+	case !m.loaded:
+		b.WriteString("Loading...\n")
+	case m.adding:
+		b.WriteString("Designate a user of this installation who may request access to your vault.\n\n")
+		b.WriteString(m.input.View() + "\n")
+		fmt.Fprintf(&b, "\nWaiting period: %s\n", focusedStyle.Render(fmt.Sprintf("%d days", m.waitDays)))
+		help = "(Tab: Change waiting period, Enter: Add, Esc: Cancel)"
+	default:
+		b.WriteString(m.requestsView())
+		b.WriteString(m.accessView("Your emergency contacts", m.contacts, !m.grantsFocused, m.contact,
+			"Nobody may request access to your vault, press n to add a contact.",
+			func(a model.EmergencyAccess) string { return a.GranteeName }))
+		b.WriteRune('\n')
+		b.WriteString(m.accessView("Vaults you may access in an emergency", m.grants, m.grantsFocused, m.grant,
+			"Nobody made you their emergency contact.",
+			func(a model.EmergencyAccess) string { return a.OwnerName }))
+	}
+
+	if m.notice != "" {
+		okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateOk))
+		fmt.Fprintf(&b, "\n%s %s\n", validateOkPrefix, okStyle.Render(m.notice))
+	}
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
+	}
+	b.WriteString(blurredStyle.Render("\n\n" + help))
+	return b.String()
+}
+
+// requestsView renders the pending requests for access to the vault of the user.
+func (m EmergencyAccessModel) requestsView() string {
+	var b strings.Builder
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+	now := time.Now()
+	for _, contact := range m.contacts {
+		if contact.Status != model.EmergencyRequested {
+			continue
+		}
+		notice := fmt.Sprintf("%s requested access to your vault, it is granted on %s unless you reject it",
+			contact.GranteeName, contact.GrantedAt().Format(time.DateTime))
+		if contact.Granted(now) {
+			notice = fmt.Sprintf("%s has had access to your vault since %s, reject it to take it back",
+				contact.GranteeName, contact.GrantedAt().Format(time.DateTime))
+		}
+		fmt.Fprintf(&b, "%s %s\n", validateErrPrefix, errorStyle.Render(notice))
+	}
+	if b.Len() > 0 {
+		b.WriteRune('\n')
+	}
+	return b.String()
+}
+
+// accessView renders a list of emergency access, naming each by the other user.
+func (m EmergencyAccessModel) accessView(title string, list []model.EmergencyAccess, focused bool, selected int,
+	empty string, name func(model.EmergencyAccess) string) string {
+	var b strings.Builder
+	if focused {
+		b.WriteString(focusedStyle.Render(title) + "\n")
+	} else {
+		b.WriteString(blurredStyle.Render(title) + "\n")
+	}
+	if len(list) == 0 {
+		b.WriteString("  " + empty + "\n")
+		return b.String()
+	}
+	now := time.Now()
+	for i, access := range list {
+		line := fmt.Sprintf("%-24s %-10s %s", name(access), fmt.Sprintf("%d days", access.WaitDays), emergencyStatus(access, now))
+		if focused && i == selected {
+			b.WriteString(focusedStyle.Render("> "+line) + "\n")
+			continue
+		}
+		b.WriteString("  " + line + "\n")
+	}
+	return b.String()
+}
+
+// emergencyStatus describes the state of emergency access at now.
+func emergencyStatus(access model.EmergencyAccess, now time.Time) string {
+	switch {
+	case access.Granted(now) && !access.Released():
+		return "granted, opens on the owner's next login"
+	case access.Granted(now):
+		return "granted"
+	case access.Status == model.EmergencyRequested:
+		return "requested, granted on " + access.GrantedAt().Format(time.DateTime)
+	default:
+		return string(access.Status)
+	}
+}
@@ -0,0 +1,114 @@
+//go:build e2e
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/services"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmergencyAccessShouldAddContactWithPickedWaitingPeriod(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	vaulttest.NewUser(t, db, "bob", test.RandomString())
+	tm := teatest.NewTestModel(t, NewEmergencyAccessModel(alice), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Nobody may request access to your vault"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.TypeString(tm, "n")
+	test.TypeString(tm, "bob")
+	test.PressKey(tm, tea.KeyTab) // -> 14 days
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("bob may now request access to your vault")) &&
+			bytes.Contains(bts, []byte("> bob"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	contacts, err := alice.EmergencyContacts()
+	require.NoError(t, err)
+	require.Len(t, contacts, 1)
+	assert.Equal(t, 14, contacts[0].WaitDays)
+	assert.Equal(t, model.EmergencyInvited, contacts[0].Status)
+}
+
+func TestEmergencyAccessShouldRequestAccessToVault(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	bob := vaulttest.NewUser(t, db, "bob", test.RandomString())
+	require.NoError(t, alice.AddEmergencyContact("bob", 7))
+	tm := teatest.NewTestModel(t, NewEmergencyAccessModel(bob), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("alice"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyTab)
+	test.TypeString(tm, "r")
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Requested access to the vault of alice"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	requests, err := alice.EmergencyRequests()
+	require.NoError(t, err)
+	assert.Len(t, requests, 1)
+}
+
+func TestAppModelShouldNotifyOwnerOfEmergencyRequestsOnLogin(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	store := database.NewStore(db)
+	password := test.RandomString()
+	alice := vaulttest.NewUser(t, db, "alice", password)
+	bob := vaulttest.NewUser(t, db, "bob", test.RandomString())
+	require.NoError(t, alice.AddEmergencyContact("bob", 7))
+	_, err := bob.RequestEmergencyAccess(alice.UserID())
+	require.NoError(t, err)
+
+	tm := teatest.NewTestModel(t, NewAppModel(services.Container{Store: store}), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("LOGIN"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.TypeString(tm, "alice")
+	test.PressKey(tm, tea.KeyDown) // -> Password
+	test.TypeString(tm, password)
+	test.PressKey(tm, tea.KeyDown)  // -> Login Button
+	test.PressKey(tm, tea.KeyEnter) // Submit Login
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("EMERGENCY ACCESS")) &&
+			bytes.Contains(bts, []byte("bob requested access to your vault"))
+	}, teatest.WithDuration(2*time.Second))
+	test.TypeString(tm, "x")
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Rejected the request of bob"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	requests, err := alice.EmergencyRequests()
+	require.NoError(t, err)
+	assert.Empty(t, requests)
+}
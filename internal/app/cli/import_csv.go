@@ -0,0 +1,411 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/importer"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// importCSVStep defines the step of the CSV import the user is at.
+type importCSVStep uint
+
+const (
+	importCSVFileStep importCSVStep = iota
+	importCSVMappingStep
+	importCSVPreviewStep
+	importCSVDoneStep
+)
+
+// sessionStateImportCSV defines the focus state within the CSV import view.
+type sessionStateImportCSV uint
+
+const (
+	importCSVContentFocused sessionStateImportCSV = iota
+	importCSVBackFocused
+)
+
+// notMapped is the column choice of a value read from no column.
+const notMapped = -1
+
+// csvLoadedMsg carries the content of a CSV file along with the preset matching its header.
+type csvLoadedMsg struct {
+	data    []byte
+	header  []string
+	preset  string
+	mapping importer.CSVMapping
+	err     error
+}
+
+// csvImportedMsg reports the outcome of importing, or previewing the import of, a CSV file.
+type csvImportedMsg struct {
+	report vault.ImportReport
+	dryRun bool
+	err    error
+}
+
+// ImportCSVModel is a Bubble Tea model for importing the CSV export of a browser or another
+// password manager. It maps the columns of the file onto entry values, detecting the layout of
+// known exports, previews the import and then imports it.
+type ImportCSVModel struct {
+	vault      vault.Vault
+	step       importCSVStep
+	state      sessionStateImportCSV
+	pathInput  textinput.Model
+	data       []byte
+	header     []string
+	preset     string
+	columns    []int
+	duplicates importer.DuplicatePolicy
+	cursor     int
+	working    bool
+	report     *vault.ImportReport
+	err        error
+}
+
+// NewImportCSVModel creates a new instance of the ImportCSVModel.
+func NewImportCSVModel(v vault.Vault) ImportCSVModel {
+	t := textinput.New()
+	t.Cursor.Style = cursorStyle
+	t.CharLimit = 4096
+	t.Placeholder = "CSV file exported from Chrome, Firefox, Safari, LastPass or elsewhere"
+	t.Focus()
+	t.PromptStyle = focusedStyle
+	t.TextStyle = focusedStyle
+
+	return ImportCSVModel{
+		vault:     v,
+		step:      importCSVFileStep,
+		state:     importCSVContentFocused,
+		pathInput: t,
+	}
+}
+
+// Init initializes the ImportCSVModel.
+func (m ImportCSVModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles incoming messages and user input for the CSV import screen.
+func (m ImportCSVModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case csvLoadedMsg:
+		m.working = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.data, m.header, m.preset = msg.data, msg.header, msg.preset
+		m.columns = make([]int, len(importer.CSVColumns))
+		for i, column := range importer.CSVColumns {
+			m.columns[i] = notMapped
+			if index, ok := msg.mapping[column]; ok {
+				m.columns[i] = index
+			}
+		}
+		m.step = importCSVMappingStep
+		m.cursor = 0
+		m.pathInput.Blur()
+		return m, nil
+
+	case csvImportedMsg:
+		m.working = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.report = &msg.report
+		if msg.dryRun {
+			m.step = importCSVPreviewStep
+		} else {
+			m.step = importCSVDoneStep
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.working {
+			return m, nil
+		}
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, common.ChangeStateCmd(common.StateQuit)
+
+		case tea.KeyTab, tea.KeyShiftTab:
+			if m.state == importCSVContentFocused {
+				m.state = importCSVBackFocused
+			} else {
+				m.state = importCSVContentFocused
+			}
+			return m, m.updateFocus()
+
+		case tea.KeyEnter:
+			if m.state == importCSVBackFocused {
+				return m.back()
+			}
+			return m.submit()
+		}
+
+		if m.state == importCSVBackFocused {
+			return m, nil
+		}
+		switch m.step {
+		case importCSVFileStep:
+			switch msg.Type {
+			case tea.KeyRunes, tea.KeySpace, tea.KeyBackspace:
+				m.err = nil
+			}
+			var cmd tea.Cmd
+			m.pathInput, cmd = m.pathInput.Update(msg)
+			return m, cmd
+		case importCSVMappingStep:
+			m.updateMapping(msg)
+		}
+	}
+	return m, nil
+}
+
+// updateMapping moves between the mapped values with ↑/↓ and cycles the column of the
+// selected one, or the duplicate policy, with ←/→.
+func (m *ImportCSVModel) updateMapping(msg tea.KeyMsg) {
+	rows := len(importer.CSVColumns) + 1
+	switch msg.Type {
+	case tea.KeyUp:
+		m.cursor = (m.cursor - 1 + rows) % rows
+	case tea.KeyDown:
+		m.cursor = (m.cursor + 1) % rows
+	case tea.KeyLeft, tea.KeyRight:
+		step := 1
+		if msg.Type == tea.KeyLeft {
+			step = -1
+		}
+		m.err = nil
+		if m.cursor == len(importer.CSVColumns) {
+			policies := len(importer.DuplicatePolicies)
+			m.duplicates = importer.DuplicatePolicies[(int(m.duplicates)+step+policies)%policies]
+			return
+		}
+		// Choices run from notMapped through every column of the header
+		choices := len(m.header) + 1
+		m.columns[m.cursor] = (m.columns[m.cursor]+1+step+choices)%choices - 1
+	}
+}
+
+// submit moves the import on to its next step.
+func (m ImportCSVModel) submit() (tea.Model, tea.Cmd) {
+	switch m.step {
+	case importCSVFileStep:
+		path := strings.TrimSpace(m.pathInput.Value())
+		if path == "" {
+			m.err = fmt.Errorf("file path cannot be empty")
+			return m, nil
+		}
+		m.working = true
+		m.err = nil
+		return m, loadCSVCmd(expandHome(path))
+	case importCSVMappingStep:
+		mapping := m.mapping()
+		_, title := mapping[importer.CSVTitle]
+		_, url := mapping[importer.CSVURL]
+		if !title && !url {
+			m.err = fmt.Errorf("map a title or URL column to name the entries by")
+			return m, nil
+		}
+		m.working = true
+		m.err = nil
+		return m, importCSVCmd(m.vault, m.data, mapping, m.duplicates, true)
+	case importCSVPreviewStep:
+		m.working = true
+		return m, importCSVCmd(m.vault, m.data, m.mapping(), m.duplicates, false)
+	default:
+		return m, common.ChangeStateCmd(common.StateGoBack)
+	}
+}
+
+// back returns to the previous step, or leaves the screen from the first and the last one.
+func (m ImportCSVModel) back() (tea.Model, tea.Cmd) {
+	m.state = importCSVContentFocused
+	m.err = nil
+	switch m.step {
+	case importCSVMappingStep:
+		m.step = importCSVFileStep
+		return m, m.updateFocus()
+	case importCSVPreviewStep:
+		m.step = importCSVMappingStep
+		m.report = nil
+		return m, nil
+	default:
+		return m, common.ChangeStateCmd(common.StateGoBack)
+	}
+}
+
+// mapping returns the columns the values are currently read from.
+func (m ImportCSVModel) mapping() importer.CSVMapping {
+	mapping := importer.CSVMapping{}
+	for i, column := range importer.CSVColumns {
+		if m.columns[i] != notMapped {
+			mapping[column] = m.columns[i]
+		}
+	}
+	return mapping
+}
+
+// loadCSVCmd reads a CSV file and detects its layout from the header.
+func loadCSVCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		data, err := os.ReadFile(path) // #nosec G304 -- the user picks the file to import
+		if err != nil {
+			return csvLoadedMsg{err: err}
+		}
+		header, err := importer.ReadCSVHeader(bytes.NewReader(data))
+		if err != nil {
+			return csvLoadedMsg{err: err}
+		}
+		msg := csvLoadedMsg{data: data, header: header}
+		if preset, mapping, ok := importer.DetectCSVPreset(header); ok {
+			msg.preset, msg.mapping = preset.Name, mapping
+		}
+		return msg
+	}
+}
+
+// importCSVCmd imports the rows of a CSV file into the vault, or only previews the import.
+func importCSVCmd(v vault.Vault, data []byte, mapping importer.CSVMapping, duplicates importer.DuplicatePolicy, dryRun bool) tea.Cmd {
+	return func() tea.Msg {
+		batch, err := importer.ReadCSV(bytes.NewReader(data), mapping)
+		if err != nil {
+			return csvImportedMsg{err: err}
+		}
+		// CSV records carry no attachments, so no attachment directory is needed
+		report, err := importer.Import(v, batch, importer.Options{Duplicates: duplicates, DryRun: dryRun})
+		if err == nil && !dryRun {
+			err = recordImport(v, report)
+		}
+		return csvImportedMsg{report: report, dryRun: dryRun, err: err}
+	}
+}
+
+// View renders the CSV import screen UI.
+func (m ImportCSVModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("IMPORT CSV") + "\n\n")
+	switch m.step {
+	case importCSVFileStep:
+		b.WriteString(blurredStyle.Render("Reads a CSV export with a header row, the file stays on this machine.") + "\n\n")
+		b.WriteString(m.pathInput.View() + "\n")
+	case importCSVMappingStep:
+		m.viewMapping(&b)
+	case importCSVPreviewStep:
+		b.WriteString(blurredStyle.Render("Nothing has been imported yet, this is what the import would do:") + "\n")
+		m.viewReport(&b, "Would add", "Would update", "Would skip")
+	case importCSVDoneStep:
+		m.viewReport(&b, "Added", "Updated", "Skipped")
+	}
+
+	submitButton := blurredSubmitButton
+	backButton := blurredBackButton
+	if m.state == importCSVContentFocused {
+		submitButton = focusedSubmitButton
+	}
+	if m.state == importCSVBackFocused {
+		backButton = focusedBackButton
+	}
+	fmt.Fprintf(&b, "\n%s\t%s\n", submitButton, backButton)
+
+	if m.working {
+		b.WriteString("\nWorking...\n")
+	}
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
+	}
+
+	help := "\n(Tab/Shift+Tab: Navigate, Enter: Continue, Esc: Quit)"
+	if m.step == importCSVMappingStep {
+		help = "\n(↑/↓: Select, ←/→: Change, Tab/Shift+Tab: Navigate, Enter: Preview, Esc: Quit)"
+	}
+	b.WriteString(blurredStyle.Render(help))
+	return b.String()
+}
+
+// viewMapping renders the column each value is read from and the duplicate policy.
+func (m ImportCSVModel) viewMapping(b *strings.Builder) {
+	if m.preset != "" {
+		b.WriteString(blurredStyle.Render(fmt.Sprintf("Detected a %s export, adjust the columns if needed.", m.preset)) + "\n\n")
+	} else {
+		b.WriteString(blurredStyle.Render("Unknown layout, pick the column each value is read from.") + "\n\n")
+	}
+	row := func(i int, label, value string) {
+		line := fmt.Sprintf("%-12s %s", label, value)
+		if m.state == importCSVContentFocused && i == m.cursor {
+			b.WriteString(focusedStyle.Render("> "+line) + "\n")
+			return
+		}
+		b.WriteString("  " + line + "\n")
+	}
+	for i, column := range importer.CSVColumns {
+		value := "(not mapped)"
+		if index := m.columns[i]; index != notMapped {
+			value = fmt.Sprintf("← %s", m.header[index])
+		}
+		row(i, csvColumnLabel(column), value)
+	}
+	b.WriteRune('\n')
+	row(len(importer.CSVColumns), "Duplicates", m.duplicates.String())
+}
+
+// viewReport renders what the import did, or would do, with every row.
+func (m ImportCSVModel) viewReport(b *strings.Builder, added, updated, skipped string) {
+	if m.report == nil {
+		return
+	}
+	okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateOk))
+	fmt.Fprintf(b, "\n%s %s\n", validateOkPrefix, okStyle.Render(fmt.Sprintf("%d %s, %d %s, %d %s",
+		len(m.report.Added), strings.ToLower(added), len(m.report.Updated), strings.ToLower(updated),
+		len(m.report.Skipped)+len(m.report.Duplicates), strings.ToLower(skipped))))
+	for _, name := range m.report.Added {
+		fmt.Fprintf(b, "  %s %s\n", added, name)
+	}
+	for _, name := range m.report.Updated {
+		fmt.Fprintf(b, "  %s %s\n", updated, name)
+	}
+	for _, record := range m.report.Skipped {
+		fmt.Fprintf(b, "  %s %s: %s\n", skipped, record.Name, record.Reason)
+	}
+	for _, duplicate := range m.report.Duplicates {
+		fmt.Fprintf(b, "  %s %s (%s): already exists\n", skipped, duplicate.Title, duplicate.Username)
+	}
+}
+
+// csvColumnLabel returns how the mapping step names a value.
+func csvColumnLabel(column importer.CSVColumn) string {
+	switch column {
+	case importer.CSVURL:
+		return "URL"
+	case importer.CSVOTP:
+		return "OTP"
+	default:
+		return strings.ToUpper(string(column[:1])) + string(column[1:])
+	}
+}
+
+// updateFocus updates the visual focus styles on the path input and returns the blink command.
+func (m *ImportCSVModel) updateFocus() tea.Cmd {
+	if m.step == importCSVFileStep && m.state == importCSVContentFocused {
+		m.pathInput.Focus()
+		m.pathInput.PromptStyle = focusedStyle
+		m.pathInput.TextStyle = focusedStyle
+		return textinput.Blink
+	}
+	m.pathInput.Blur()
+	m.pathInput.PromptStyle = noStyle
+	m.pathInput.TextStyle = noStyle
+	return nil
+}
@@ -0,0 +1,135 @@
+//go:build e2e
+
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCSV(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "export.csv")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestImportCSVShouldPreviewAndImportDetectedExport(t *testing.T) {
+	// given
+	v := vaulttest.New(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "old"}, nil, "", nil))
+	path := writeCSV(t, "name,url,username,password\n"+
+		"GitHub,https://github.com,octocat,new\n"+
+		"GitLab,https://gitlab.com,tanuki,secret\n")
+	tm := teatest.NewTestModel(t, NewImportCSVModel(v), teatest.WithInitialTermSize(300, 100))
+
+	// when
+	test.TypeString(tm, path)
+	test.PressKey(tm, tea.KeyEnter)
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Detected a Chrome export"))
+	}, teatest.WithDuration(2*time.Second))
+	for range 7 {
+		test.PressKey(tm, tea.KeyDown) // -> Duplicates
+	}
+	test.PressKey(tm, tea.KeyRight) // -> overwrite
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Would add GitLab (tanuki)")) &&
+			bytes.Contains(bts, []byte("Would update GitHub (octocat)"))
+	}, teatest.WithDuration(2*time.Second))
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// when
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("1 added, 1 updated, 0 skipped"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	github, err := v.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	password, err := v.RevealPassword(github)
+	require.NoError(t, err)
+	assert.Equal(t, "new", password)
+	vaulttest.AssertLastAuditEvent(t, v, model.AuditImported, model.ClientTUI)
+}
+
+func TestImportCSVShouldMapColumnsOfUnknownLayout(t *testing.T) {
+	// given
+	v := vaulttest.New(t)
+	path := writeCSV(t, "Site,Login,Secret\nGitHub,octocat,hunter2\n")
+	m := NewImportCSVModel(v)
+	m.pathInput.SetValue(path)
+
+	// when
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, _ = updated.Update(cmd())
+	for i := range 3 {
+		for range i + 1 {
+			updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRight})
+		}
+		updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+
+	// then
+	view := updated.View()
+	assert.Contains(t, view, "Unknown layout")
+	assert.Contains(t, view, "Title        ← Site")
+	assert.Contains(t, view, "Username     ← Login")
+	assert.Contains(t, view, "Password     ← Secret")
+	assert.Contains(t, view, "URL          (not mapped)")
+
+	// when
+	updated, cmd = updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, _ = updated.Update(cmd())
+
+	// then
+	assert.Contains(t, updated.View(), "Would add GitHub (octocat)")
+}
+
+func TestImportCSVShouldRequireTitleOrURLColumn(t *testing.T) {
+	// given
+	m := NewImportCSVModel(vaulttest.New(t))
+	m.pathInput.SetValue(writeCSV(t, "Site,Login,Secret\nGitHub,octocat,hunter2\n"))
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, _ = updated.Update(cmd())
+
+	// when
+	updated, cmd = updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// then
+	assert.Nil(t, cmd)
+	assert.Contains(t, updated.View(), "map a title or URL column to name the entries by")
+}
+
+func TestImportCSVShouldGoBack(t *testing.T) {
+	// given
+	m := NewImportCSVModel(vaulttest.New(t))
+
+	// when
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	_, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// then
+	require.NotNil(t, cmd)
+	assert.Equal(t, common.StateMsg{State: common.StateGoBack}, cmd())
+}
@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/otp"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sessionStateImportQR defines the focus state within the QR import view.
+type sessionStateImportQR uint
+
+const (
+	importQRInputsFocused sessionStateImportQR = iota
+	importQRBackFocused
+)
+
+// Indexes of the QR import inputs.
+const (
+	importQRPathInput = iota
+	importQRFolderInput
+)
+
+// qrImportedMsg reports the outcome of importing the QR codes of an image.
+type qrImportedMsg struct {
+	report vault.ImportReport
+	err    error
+}
+
+// ImportQRModel is a Bubble Tea model for importing one-time passwords from QR code images.
+// It decodes the image locally and files a TOTP or HOTP bearing login per account found.
+type ImportQRModel struct {
+	vault      vault.Vault
+	state      sessionStateImportQR
+	focusIndex int
+	inputs     []textinput.Model
+	importing  bool
+	report     *vault.ImportReport
+	err        error
+}
+
+// NewImportQRModel creates a new instance of the ImportQRModel.
+func NewImportQRModel(v vault.Vault) ImportQRModel {
+	m := ImportQRModel{
+		vault:  v,
+		state:  importQRInputsFocused,
+		inputs: make([]textinput.Model, 2),
+	}
+
+	for i := range m.inputs {
+		t := textinput.New()
+		t.Cursor.Style = cursorStyle
+		t.CharLimit = 4096
+
+		switch i {
+		case importQRPathInput:
+			t.Placeholder = "QR code image, PNG or JPEG"
+			t.Focus()
+			t.PromptStyle = focusedStyle
+			t.TextStyle = focusedStyle
+		case importQRFolderInput:
+			t.Placeholder = "Folder, e.g. 2FA (optional)"
+			t.CharLimit = 256
+			t.PromptStyle = noStyle
+			t.TextStyle = noStyle
+		}
+		m.inputs[i] = t
+	}
+	return m
+}
+
+// Init initializes the ImportQRModel.
+func (m ImportQRModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles incoming messages and user input for the QR import screen.
+func (m ImportQRModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case qrImportedMsg:
+		m.importing = false
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.report = &msg.report
+		m.inputs[importQRPathInput].SetValue("")
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.importing {
+			return m, nil
+		}
+		if m.state == importQRInputsFocused && m.focusIndex < len(m.inputs) {
+			switch msg.Type {
+			case tea.KeyRunes, tea.KeySpace, tea.KeyBackspace:
+				m.err = nil
+			}
+		}
+
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, common.ChangeStateCmd(common.StateQuit)
+
+		case tea.KeyTab, tea.KeyShiftTab:
+			if m.state == importQRInputsFocused {
+				m.state = importQRBackFocused
+			} else {
+				m.state = importQRInputsFocused
+			}
+			cmds = append(cmds, m.updateFocus())
+
+		case tea.KeyUp, tea.KeyDown:
+			if m.state == importQRInputsFocused {
+				if msg.Type == tea.KeyUp {
+					m.focusIndex = (m.focusIndex - 1 + (len(m.inputs) + 1)) % (len(m.inputs) + 1)
+				} else {
+					m.focusIndex = (m.focusIndex + 1) % (len(m.inputs) + 1)
+				}
+				cmds = append(cmds, m.updateFocus())
+			}
+
+		case tea.KeyEnter:
+			if m.state == importQRBackFocused {
+				return m, common.ChangeStateCmd(common.StateGoBack)
+			}
+			if m.focusIndex < len(m.inputs) {
+				m.focusIndex++
+				return m, m.updateFocus()
+			}
+			path := strings.TrimSpace(m.inputs[importQRPathInput].Value())
+			if path == "" {
+				m.err = fmt.Errorf("image path cannot be empty")
+				return m, nil
+			}
+			m.importing = true
+			m.err = nil
+			m.report = nil
+			return m, importQRCmd(m.vault, expandHome(path), strings.TrimSpace(m.inputs[importQRFolderInput].Value()))
+		}
+	}
+
+	if m.state == importQRInputsFocused && m.focusIndex < len(m.inputs) {
+		var inputCmd tea.Cmd
+		m.inputs[m.focusIndex], inputCmd = m.inputs[m.focusIndex].Update(msg)
+		cmds = append(cmds, inputCmd)
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// importQRCmd decodes the QR codes of an image and imports their keys into the vault.
+func importQRCmd(v vault.Vault, path, folder string) tea.Cmd {
+	return func() tea.Msg {
+		file, err := os.Open(path) // #nosec G304 -- the user picks the image to import
+		if err != nil {
+			return qrImportedMsg{err: err}
+		}
+		defer file.Close()
+
+		keys, err := otp.ReadQR(file)
+		if err != nil {
+			return qrImportedMsg{err: err}
+		}
+		report, err := v.ImportOTPKeys(keys, folder)
+		if err == nil {
+			err = recordImport(v, report)
+		}
+		return qrImportedMsg{report: report, err: err}
+	}
+}
+
+// recordImport records in the audit log of the vault that an import stored entries in it.
+func recordImport(v vault.Vault, report vault.ImportReport) error {
+	if len(report.Added) == 0 && len(report.Updated) == 0 {
+		return nil
+	}
+	return v.Record(model.AuditImported, model.ClientTUI, model.Password{})
+}
+
+// View renders the QR import screen UI.
+func (m ImportQRModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("IMPORT ONE-TIME PASSWORDS") + "\n\n")
+	b.WriteString(blurredStyle.Render("Reads otpauth:// and Google Authenticator export QR codes, the image stays on this machine.") + "\n\n")
+
+	for i := range m.inputs {
+		b.WriteString(m.inputs[i].View())
+		b.WriteRune('\n')
+	}
+
+	submitButton := blurredSubmitButton
+	backButton := blurredBackButton
+	if m.state == importQRInputsFocused && m.focusIndex == len(m.inputs) {
+		submitButton = focusedSubmitButton
+	}
+	if m.state == importQRBackFocused {
+		backButton = focusedBackButton
+	}
+	fmt.Fprintf(&b, "\n%s\t%s\n", submitButton, backButton)
+
+	if m.importing {
+		b.WriteString("\nImporting...\n")
+	}
+	if m.report != nil {
+		okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateOk))
+		fmt.Fprintf(&b, "\n%s %s\n", validateOkPrefix, okStyle.Render(fmt.Sprintf("%d added, %d updated, %d skipped",
+			len(m.report.Added), len(m.report.Updated), len(m.report.Skipped))))
+		for _, name := range m.report.Added {
+			fmt.Fprintf(&b, "  Added %s\n", name)
+		}
+		for _, name := range m.report.Updated {
+			fmt.Fprintf(&b, "  Updated %s\n", name)
+		}
+		for _, skipped := range m.report.Skipped {
+			fmt.Fprintf(&b, "  Skipped %s: %s\n", skipped.Name, skipped.Reason)
+		}
+	}
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
+	}
+
+	help := blurredStyle.Render("\n(Tab/Shift+Tab: Navigate, ↑/↓: Cycle Focus, Enter: Select/Import, Esc: Quit)")
+	b.WriteString(help)
+
+	return b.String()
+}
+
+// updateFocus updates the visual focus styles on inputs and returns the blink command.
+func (m *ImportQRModel) updateFocus() tea.Cmd {
+	for i := range m.inputs {
+		if m.state == importQRInputsFocused && i == m.focusIndex {
+			m.inputs[i].Focus()
+			m.inputs[i].PromptStyle = focusedStyle
+			m.inputs[i].TextStyle = focusedStyle
+		} else {
+			m.inputs[i].Blur()
+			m.inputs[i].PromptStyle = noStyle
+			m.inputs[i].TextStyle = noStyle
+		}
+	}
+	if m.state == importQRInputsFocused && m.focusIndex < len(m.inputs) {
+		return textinput.Blink
+	}
+	return nil
+}
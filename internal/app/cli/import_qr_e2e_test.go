@@ -0,0 +1,102 @@
+//go:build e2e
+
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportQRShouldImportAccountsOfImage(t *testing.T) {
+	// given
+	v := vaulttest.New(t)
+	image := filepath.Join(t.TempDir(), "export.png")
+	test.WriteQRCode(t, image, test.MigrationURI(
+		test.MigrationAccount{Secret: []byte("12345678901234567890"), Name: "octocat", Issuer: "GitHub"},
+		test.MigrationAccount{Secret: []byte("12345678901234567890"), Name: "me@bank", Issuer: "Bank"},
+	))
+	tm := teatest.NewTestModel(t, NewImportQRModel(v), teatest.WithInitialTermSize(300, 100))
+
+	// when
+	test.TypeString(tm, image)
+	test.PressKey(tm, tea.KeyDown)
+	test.TypeString(tm, "2FA")
+	test.PressKey(tm, tea.KeyDown)
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("2 added, 0 updated, 0 skipped")) &&
+			bytes.Contains(bts, []byte("Added GitHub (octocat)"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	entry, err := v.FindEntry("Bank", "me@bank")
+	require.NoError(t, err)
+	details, err := v.Reveal(entry)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2FA"}, details.Folders)
+	_, err = v.OTPKey(entry)
+	assert.NoError(t, err)
+	vaulttest.AssertLastAuditEvent(t, v, model.AuditImported, model.ClientTUI)
+}
+
+func TestImportQRShouldShowErrorOfImageWithoutQRCode(t *testing.T) {
+	// given
+	v := vaulttest.New(t)
+	image := filepath.Join(t.TempDir(), "website.png")
+	test.WriteQRCode(t, image, "https://example.com")
+	tm := teatest.NewTestModel(t, NewImportQRModel(v), teatest.WithInitialTermSize(300, 100))
+
+	// when
+	test.TypeString(tm, image)
+	test.PressKey(tm, tea.KeyDown)
+	test.PressKey(tm, tea.KeyDown)
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("QR code must be an otpauth:// URI"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestImportQRShouldRequireImagePath(t *testing.T) {
+	// given
+	m := NewImportQRModel(vaulttest.New(t))
+	m.focusIndex = len(m.inputs)
+
+	// when
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// then
+	assert.Nil(t, cmd)
+	assert.Contains(t, updated.View(), "image path cannot be empty")
+}
+
+func TestImportQRShouldGoBack(t *testing.T) {
+	// given
+	m := NewImportQRModel(vaulttest.New(t))
+
+	// when
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	_, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// then
+	require.NotNil(t, cmd)
+	assert.Equal(t, common.StateMsg{State: common.StateGoBack}, cmd())
+}
@@ -0,0 +1,440 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Keys of the inputs an item form shows around the fields of the item type.
+const (
+	itemFormTitleKey  = "title"
+	itemFormFolderKey = "folder"
+	itemFormTagsKey   = "tags"
+	itemFormNotesKey  = "notes"
+)
+
+var (
+	focusedSaveButton = focusedStyle.Copy().Render("[ Save ]")
+	blurredSaveButton = fmt.Sprintf("[ %s ]", blurredStyle.Render("Save"))
+)
+
+// itemFormLoadedMsg carries the decrypted entry being edited.
+type itemFormLoadedMsg struct {
+	details vault.EntryDetails
+	err     error
+}
+
+// itemFormInput is a single input of an item form, backed by a text area for multi-line values.
+type itemFormInput struct {
+	field model.ItemField
+	line  textinput.Model
+	area  textarea.Model
+}
+
+func newItemFormInput(field model.ItemField) itemFormInput {
+	in := itemFormInput{field: field}
+	placeholder := field.Label
+	if !field.Required {
+		placeholder += " (optional)"
+	}
+
+	if field.Multiline {
+		in.area = textarea.New()
+		in.area.Placeholder = placeholder
+		in.area.ShowLineNumbers = false
+		in.area.SetHeight(4)
+		in.area.SetWidth(70)
+		in.area.CharLimit = 0
+		in.area.Blur()
+		return in
+	}
+
+	in.line = textinput.New()
+	in.line.Cursor.Style = cursorStyle
+	in.line.Placeholder = placeholder
+	in.line.CharLimit = 0
+	if field.Secret {
+		in.line.EchoMode = textinput.EchoPassword
+		in.line.EchoCharacter = '•'
+	}
+	return in
+}
+
+func (in itemFormInput) value() string {
+	if in.field.Multiline {
+		return in.area.Value()
+	}
+	return in.line.Value()
+}
+
+func (in *itemFormInput) setValue(value string) {
+	if in.field.Multiline {
+		in.area.SetValue(value)
+		return
+	}
+	in.line.SetValue(value)
+}
+
+func (in *itemFormInput) focus() tea.Cmd {
+	if in.field.Multiline {
+		return in.area.Focus()
+	}
+	in.line.PromptStyle = focusedStyle
+	in.line.TextStyle = focusedStyle
+	return in.line.Focus()
+}
+
+func (in *itemFormInput) blur() {
+	if in.field.Multiline {
+		in.area.Blur()
+		return
+	}
+	in.line.PromptStyle = noStyle
+	in.line.TextStyle = noStyle
+	in.line.Blur()
+}
+
+// atEdge reports whether moving the cursor up (or down) would leave the input.
+func (in itemFormInput) atEdge(up bool) bool {
+	if !in.field.Multiline {
+		return true
+	}
+	if up {
+		return in.area.Line() == 0
+	}
+	return in.area.Line() >= in.area.LineCount()-1
+}
+
+// ItemFormModel is a Bubble Tea model for adding or editing a typed vault item.
+// Its inputs follow the fields of the item type, surrounded by the title, placement and notes.
+type ItemFormModel struct {
+	state        sessionStateAddPassword
+	focusIndex   int
+	inputs       []itemFormInput
+	itemType     model.ItemType
+	secretsShown bool
+	editing      bool
+	loaded       bool
+	entry        model.Password
+	showErr      bool
+	err          error
+	vault        vault.Vault
+	policy       model.PasswordPolicy
+}
+
+// NewItemFormModel creates an ItemFormModel for adding a new item of the given type.
+func NewItemFormModel(itemType model.ItemType) ItemFormModel {
+	m := ItemFormModel{
+		state:    addPasswordInputsFocused,
+		itemType: itemType,
+		loaded:   true,
+		policy:   model.DefaultPasswordPolicy,
+	}
+	m.inputs = newItemFormInputs(itemType)
+	m.inputs[0].focus()
+	return m
+}
+
+// NewEditItemFormModel creates an ItemFormModel for editing a stored entry.
+func NewEditItemFormModel(v vault.Vault, entry model.Password) ItemFormModel {
+	m := NewItemFormModel(entry.ItemType())
+	m.editing = true
+	m.loaded = false
+	m.entry = entry
+	m.vault = v
+	return m
+}
+
+// WithPasswordPolicy returns the form generating secrets with policy on Ctrl+G.
+func (m ItemFormModel) WithPasswordPolicy(policy model.PasswordPolicy) ItemFormModel {
+	m.policy = policy
+	return m
+}
+
+// newItemFormInputs builds the inputs for an item type in focus order.
+func newItemFormInputs(itemType model.ItemType) []itemFormInput {
+	inputs := []itemFormInput{newItemFormInput(model.ItemField{Key: itemFormTitleKey, Label: "Title", Required: true})}
+	for _, field := range itemType.Fields() {
+		inputs = append(inputs, newItemFormInput(field))
+	}
+	inputs = append(inputs,
+		newItemFormInput(model.ItemField{Key: itemFormFolderKey, Label: "Folder, e.g. Work/Servers"}),
+		newItemFormInput(model.ItemField{Key: itemFormTagsKey, Label: "Tags, comma separated"}),
+		newItemFormInput(model.ItemField{
+			Key:       itemFormNotesKey,
+			Label:     "Notes",
+			Required:  itemType == model.ItemTypeSecureNote,
+			Multiline: true,
+		}),
+	)
+	return inputs
+}
+
+// ExtractItemFromModel returns the item, folder path and tags entered in the form.
+func ExtractItemFromModel(m ItemFormModel) (model.Item, string, []string) {
+	item := model.Item{Type: m.itemType, Values: model.ItemValues{}}
+	var folder string
+	var tags []string
+	for _, in := range m.inputs {
+		switch in.field.Key {
+		case itemFormTitleKey:
+			item.Title = strings.TrimSpace(in.value())
+		case itemFormFolderKey:
+			folder = strings.TrimSpace(in.value())
+		case itemFormTagsKey:
+			tags = vault.ParseTags(in.value())
+		case itemFormNotesKey:
+			item.Notes = in.value()
+		default:
+			value := in.value()
+			if !in.field.Multiline {
+				value = strings.TrimSpace(value)
+			}
+			item.Values[in.field.Key] = value
+		}
+	}
+	return item, folder, tags
+}
+
+// Init focuses the first input, and loads the decrypted entry when editing.
+func (m ItemFormModel) Init() tea.Cmd {
+	if !m.editing {
+		return textinput.Blink
+	}
+	v, entry := m.vault, m.entry
+	return func() tea.Msg {
+		details, err := v.Reveal(entry)
+		return itemFormLoadedMsg{details: details, err: err}
+	}
+}
+
+// Update handles incoming messages and user input for the item form.
+func (m ItemFormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case itemFormLoadedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("failed to decrypt entry: %w", msg.err)
+			m.showErr = true
+			return m, nil
+		}
+		m.fill(msg.details)
+		m.loaded = true
+		return m, m.updateFocus()
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, common.ChangeStateCmd(common.StateQuit)
+
+		case tea.KeyTab, tea.KeyShiftTab:
+			if m.state == addPasswordInputsFocused {
+				m.state = addPasswordBackFocused
+			} else {
+				m.state = addPasswordInputsFocused
+			}
+			return m, m.updateFocus()
+
+		case tea.KeyCtrlS:
+			m.secretsShown = !m.secretsShown
+			for i := range m.inputs {
+				if m.inputs[i].field.Secret && !m.inputs[i].field.Multiline {
+					if m.secretsShown {
+						m.inputs[i].line.EchoMode = textinput.EchoNormal
+					} else {
+						m.inputs[i].line.EchoMode = textinput.EchoPassword
+					}
+				}
+			}
+			return m, nil
+		}
+
+		if m.state == addPasswordBackFocused {
+			if msg.Type == tea.KeyEnter {
+				return m, m.back()
+			}
+			return m, nil
+		}
+		if !m.loaded {
+			return m, nil
+		}
+
+		switch msg.Type {
+		case tea.KeyUp, tea.KeyDown:
+			up := msg.Type == tea.KeyUp
+			if m.focusIndex == m.saveButtonIndex() || m.inputs[m.focusIndex].atEdge(up) {
+				if up {
+					m.focusIndex = (m.focusIndex - 1 + m.focusCount()) % m.focusCount()
+				} else {
+					m.focusIndex = (m.focusIndex + 1) % m.focusCount()
+				}
+				return m, m.updateFocus()
+			}
+
+		case tea.KeyCtrlG:
+			if m.focusIndex < len(m.inputs) && m.inputs[m.focusIndex].field.Generated {
+				generated, err := utils.GeneratePasswordWithPolicy(m.policy)
+				if err != nil {
+					m.err = fmt.Errorf("password generation failed: %w", err)
+					m.showErr = true
+					return m, nil
+				}
+				m.inputs[m.focusIndex].setValue(generated)
+				return m, nil
+			}
+
+		case tea.KeyEnter:
+			if m.focusIndex == m.saveButtonIndex() {
+				return m, m.submit()
+			}
+			if !m.inputs[m.focusIndex].field.Multiline {
+				m.focusIndex++
+				return m, m.updateFocus()
+			}
+
+		case tea.KeyRunes, tea.KeySpace, tea.KeyBackspace:
+			m.showErr = false
+			m.err = nil
+		}
+	}
+
+	if m.state == addPasswordInputsFocused && m.focusIndex < len(m.inputs) {
+		var cmd tea.Cmd
+		in := &m.inputs[m.focusIndex]
+		if in.field.Multiline {
+			in.area, cmd = in.area.Update(msg)
+		} else {
+			in.line, cmd = in.line.Update(msg)
+		}
+		return m, cmd
+	}
+	return m, nil
+}
+
+// View renders the item form UI.
+func (m ItemFormModel) View() string {
+	var b strings.Builder
+	if m.editing {
+		b.WriteString(titleStyle.Render("EDIT "+strings.ToUpper(m.entry.Title)) + "\n\n")
+	} else {
+		b.WriteString(titleStyle.Render("ADD A NEW "+strings.ToUpper(m.itemType.Label())) + "\n\n")
+	}
+
+	if m.loaded {
+		for i, in := range m.inputs {
+			focused := m.state == addPasswordInputsFocused && i == m.focusIndex
+			if in.field.Multiline {
+				label := blurredStyle
+				if focused {
+					label = focusedStyle
+				}
+				b.WriteString(label.Render(in.field.Label) + "\n")
+				if in.field.Secret && !m.secretsShown && !focused && in.value() != "" {
+					b.WriteString(maskedValue + "\n")
+				} else {
+					b.WriteString(in.area.View() + "\n")
+				}
+				continue
+			}
+			b.WriteString(in.line.View() + "\n")
+		}
+	} else if !m.showErr {
+		b.WriteString(blurredStyle.Render("Decrypting…") + "\n")
+	}
+
+	saveBtn := blurredSaveButton
+	backBtn := blurredBackButton
+	if m.state == addPasswordInputsFocused && m.focusIndex == m.saveButtonIndex() {
+		saveBtn = focusedSaveButton
+	}
+	if m.state == addPasswordBackFocused {
+		backBtn = focusedBackButton
+	}
+	buttonRow := lipgloss.JoinHorizontal(lipgloss.Top, saveBtn, "    ", backBtn)
+	fmt.Fprintf(&b, "\n%s", buttonRow)
+
+	if m.err != nil && m.showErr {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
+	}
+
+	help := blurredStyle.Render("\n\n(Tab/Shift+Tab: Navigate, ↑/↓: Focus, Enter: Select/Save)\n")
+	help += blurredStyle.Render("(Ctrl+S: Show/Hide secrets, Ctrl+G on Pwd: Generate, Esc: Quit)")
+	b.WriteString(help)
+
+	return b.String()
+}
+
+// fill populates the inputs with a decrypted entry.
+func (m *ItemFormModel) fill(details vault.EntryDetails) {
+	for i := range m.inputs {
+		in := &m.inputs[i]
+		switch in.field.Key {
+		case itemFormTitleKey:
+			in.setValue(details.Item.Title)
+		case itemFormFolderKey:
+			if len(details.Folders) > 0 {
+				in.setValue(details.Folders[0])
+			}
+		case itemFormTagsKey:
+			in.setValue(strings.Join(details.Tags, ", "))
+		case itemFormNotesKey:
+			in.setValue(details.Item.Notes)
+		default:
+			in.setValue(details.Item.Values[in.field.Key])
+		}
+	}
+}
+
+// submit validates the form and sends the item to be added or updated.
+func (m *ItemFormModel) submit() tea.Cmd {
+	item, folder, tags := ExtractItemFromModel(*m)
+	if err := item.Validate(); err != nil {
+		m.err = err
+		m.showErr = true
+		return nil
+	}
+	if m.editing {
+		return common.UpdateItemCmd(m.entry, item, folder, tags)
+	}
+	return common.AddItemCmd(item, folder, tags)
+}
+
+// back leaves the form, returning to the entry when editing.
+func (m ItemFormModel) back() tea.Cmd {
+	if m.editing {
+		return common.ShowPasswordCmd(m.entry)
+	}
+	return common.ChangeStateCmd(common.StateGoBack)
+}
+
+// saveButtonIndex returns the focus index of the Save button, which follows all inputs.
+func (m ItemFormModel) saveButtonIndex() int {
+	return len(m.inputs)
+}
+
+// focusCount returns the number of focusable elements in the inputs pane.
+func (m ItemFormModel) focusCount() int {
+	return len(m.inputs) + 1
+}
+
+// updateFocus focuses the input under the focus index and blurs the others.
+func (m *ItemFormModel) updateFocus() tea.Cmd {
+	var cmd tea.Cmd
+	for i := range m.inputs {
+		if m.state == addPasswordInputsFocused && i == m.focusIndex {
+			cmd = m.inputs[i].focus()
+		} else {
+			m.inputs[i].blur()
+		}
+	}
+	return cmd
+}
@@ -0,0 +1,164 @@
+//go:build e2e
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemFormShouldBuildCardItem(t *testing.T) {
+	// given
+	tm := teatest.NewTestModel(t, NewItemFormModel(model.ItemTypeCard), teatest.WithInitialTermSize(300, 100))
+
+	// when
+	test.TypeString(tm, "Visa")
+	test.PressKey(tm, tea.KeyDown) // -> Cardholder
+	test.TypeString(tm, "Jane Doe")
+	test.PressKey(tm, tea.KeyDown) // -> Card number
+	test.TypeString(tm, "4111 1111 1111 1111")
+	test.PressKey(tm, tea.KeyDown) // -> Expiry
+	test.TypeString(tm, "09/29")
+	test.PressKey(tm, tea.KeyDown) // -> CVV
+	test.TypeString(tm, "123")
+	test.PressKey(tm, tea.KeyDown) // -> Folder
+	test.TypeString(tm, "Finance")
+	test.PressKey(tm, tea.KeyDown) // -> Tags
+	test.TypeString(tm, "bank, travel")
+	test.PressKey(tm, tea.KeyDown) // -> Notes
+	test.PressKey(tm, tea.KeyDown) // -> Save button
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(ItemFormModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	assert.NoError(t, m.err)
+
+	item, folder, tags := ExtractItemFromModel(m)
+	assert.Equal(t, model.Item{
+		Type:  model.ItemTypeCard,
+		Title: "Visa",
+		Values: model.ItemValues{
+			"cardholder": "Jane Doe",
+			"number":     "4111 1111 1111 1111",
+			"expiry":     "09/29",
+			"cvv":        "123",
+		},
+	}, item)
+	assert.Equal(t, "Finance", folder)
+	assert.Equal(t, []string{"bank", "travel"}, tags)
+}
+
+func TestItemFormShouldShowValidationError(t *testing.T) {
+	// given
+	tm := teatest.NewTestModel(t, NewItemFormModel(model.ItemTypeCard), teatest.WithInitialTermSize(300, 100))
+
+	// when
+	test.TypeString(tm, "Visa")
+	test.PressKey(tm, tea.KeyDown) // -> Cardholder
+	test.TypeString(tm, "Jane Doe")
+	test.PressKey(tm, tea.KeyDown) // -> Card number
+	test.TypeString(tm, "1234")
+	test.PressKey(tm, tea.KeyUp) // -> Cardholder
+	test.PressKey(tm, tea.KeyUp) // -> Title
+	test.PressKey(tm, tea.KeyUp) // -> Save button
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("card number must have between 12 and 19 digits"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+}
+
+func TestItemFormShouldAcceptMultilineSecureNote(t *testing.T) {
+	// given
+	tm := teatest.NewTestModel(t, NewItemFormModel(model.ItemTypeSecureNote), teatest.WithInitialTermSize(300, 100))
+
+	// when
+	test.TypeString(tm, "wifi")
+	test.PressKey(tm, tea.KeyDown) // -> Folder
+	test.PressKey(tm, tea.KeyDown) // -> Tags
+	test.PressKey(tm, tea.KeyDown) // -> Notes
+	test.TypeString(tm, "ssid: home")
+	test.PressKey(tm, tea.KeyEnter)
+	test.TypeString(tm, "key: secret")
+	test.PressKey(tm, tea.KeyDown) // -> Save button
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(ItemFormModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	assert.NoError(t, m.err)
+	item, _, _ := ExtractItemFromModel(m)
+	assert.Equal(t, "ssid: home\nkey: secret", item.Notes)
+}
+
+func TestItemFormShouldEditStoredEntry(t *testing.T) {
+	// given
+	db, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed to set up test database")
+	defer test.TeardownTestDB(db)
+
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := vault.New(database.NewStore(db), session)
+	item := model.Item{Type: model.ItemTypeAPIToken, Title: "ci", Values: model.ItemValues{"key_id": "bot", "token": "old-token"}}
+	_, err = v.AddItem(item, nil, "Work", []string{"ci"})
+	require.NoError(t, err)
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	tm := teatest.NewTestModel(t, NewEditItemFormModel(v, entries[0]), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("EDIT CI")) && bytes.Contains(bts, []byte("bot"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyDown) // -> Key ID
+	test.PressKey(tm, tea.KeyDown) // -> Token
+	test.PressKey(tm, tea.KeyCtrlU)
+	test.TypeString(tm, "new-token")
+
+	// then
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(ItemFormModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	edited, folder, tags := ExtractItemFromModel(m)
+	assert.Equal(t, "new-token", edited.Values["token"])
+	assert.Equal(t, "bot", edited.Values["key_id"])
+	assert.Equal(t, "Work", folder)
+	assert.Equal(t, []string{"ci"}, tags)
+}
+
+func TestItemFormShouldReturnToEntryWhenEditingIsAborted(t *testing.T) {
+	// given
+	entry := model.Password{ID: "id", Title: "ci", Type: model.ItemTypeAPIToken}
+	m := NewEditItemFormModel(vault.Vault{}, entry)
+
+	// when
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	_, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// then
+	require.NotNil(t, cmd)
+	assert.Equal(t, common.ShowPasswordMsg{Entry: entry}, cmd())
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pickerBackItem is the last entry of the item type list, returning to the main menu.
+const pickerBackItem = "Back"
+
+// ItemTypePickerModel is a Bubble Tea model letting the user choose the type of a new item.
+type ItemTypePickerModel struct {
+	list list.Model
+}
+
+// NewItemTypePickerModel creates a new instance of the ItemTypePickerModel.
+func NewItemTypePickerModel() ItemTypePickerModel {
+	items := make([]list.Item, 0, len(model.ItemTypes)+1)
+	for _, itemType := range model.ItemTypes {
+		items = append(items, item(itemType.Label()))
+	}
+	items = append(items, item(pickerBackItem))
+
+	const defaultWidth = 40
+
+	l := list.New(items, itemDelegate{}, defaultWidth, listHeight)
+	l.Title = "WHAT DO YOU WANT TO ADD?"
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.SetShowHelp(true)
+	l.Styles.Title = titleStyle.Copy().MarginBottom(1)
+	l.Styles.PaginationStyle = paginationStyle
+	l.Styles.HelpStyle = helpStyle
+
+	return ItemTypePickerModel{
+		list: l,
+	}
+}
+
+// Init initializes the ItemTypePickerModel. Currently returns nil.
+func (m ItemTypePickerModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles incoming messages and user input for the item type picker.
+func (m ItemTypePickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := docStyle.GetFrameSize()
+		m.list.SetSize(msg.Width-h, msg.Height-v)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, common.ChangeStateCmd(common.StateQuit)
+
+		case "enter":
+			index := m.list.Index()
+			if index >= len(model.ItemTypes) {
+				return m, common.ChangeStateCmd(common.StateGoBack)
+			}
+			return m, common.ChooseItemTypeCmd(model.ItemTypes[index])
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// View renders the item type picker UI.
+func (m ItemTypePickerModel) View() string {
+	return docStyle.Render(m.list.View())
+}
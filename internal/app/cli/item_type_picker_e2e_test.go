@@ -0,0 +1,55 @@
+//go:build e2e
+
+package cli
+
+import (
+	"testing"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemTypePickerShouldChooseType(t *testing.T) {
+	for i, itemType := range model.ItemTypes {
+		t.Run(string(itemType), func(t *testing.T) {
+			// given
+			m := NewItemTypePickerModel()
+			m.list.Select(i)
+
+			// when
+			_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+			// then
+			require.NotNil(t, cmd)
+			assert.Equal(t, common.ItemTypeChosenMsg{Type: itemType}, cmd())
+		})
+	}
+}
+
+func TestItemTypePickerShouldGoBack(t *testing.T) {
+	// given
+	m := NewItemTypePickerModel()
+	m.list.Select(len(model.ItemTypes))
+
+	// when
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// then
+	require.NotNil(t, cmd)
+	assert.Equal(t, common.StateMsg{State: common.StateGoBack}, cmd())
+}
+
+func TestItemTypePickerShouldQuit(t *testing.T) {
+	// given
+	m := NewItemTypePickerModel()
+
+	// when
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	// then
+	require.NotNil(t, cmd)
+	assert.Equal(t, common.StateMsg{State: common.StateQuit}, cmd())
+}
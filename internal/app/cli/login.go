@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"strings"
+	"time"
 	"yubigo-pass/internal/app/common"
 	"yubigo-pass/internal/database"
 
@@ -27,14 +28,28 @@ var (
 	blurredCreateUserButton = fmt.Sprintf("[ %s ]", blurredStyle.Render("Create new user"))
 )
 
+// loginThrottledMsg locks the login screen after too many failed attempts until retryAt.
+type loginThrottledMsg struct {
+	retryAt time.Time
+}
+
+// loginTickMsg refreshes the countdown of a locked login screen. Ticks of an earlier lockout, told
+// apart by their generation, are dropped so that a single countdown runs at a time.
+type loginTickMsg struct {
+	generation int
+}
+
 // LoginModel is a Bubble Tea model for the user login screen.
 // It handles user input for credentials and triggers authentication logic via messages.
+// After too many failed attempts it counts down until the next login is allowed.
 type LoginModel struct {
 	state      sessionStateLogin
 	focusIndex int
 	inputs     []textinput.Model
 	showErr    bool
 	err        error
+	retryAt    time.Time
+	generation int
 
 	store database.StoreExecutor
 }
@@ -93,6 +108,22 @@ func (m LoginModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
+	case loginThrottledMsg:
+		m.retryAt = msg.retryAt
+		m.showErr = false
+		m.generation++
+		return m, loginTickCmd(m.generation)
+
+	case loginTickMsg:
+		if msg.generation != m.generation {
+			return m, nil
+		}
+		if !m.throttled() {
+			m.retryAt = time.Time{}
+			return m, nil
+		}
+		return m, loginTickCmd(m.generation)
+
 	case tea.KeyMsg:
 		if m.state == loginInputsFocused && m.focusIndex < len(m.inputs) {
 			switch msg.Type {
@@ -132,6 +163,9 @@ func (m LoginModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, common.ChangeStateCmd(common.StateGoToCreateUser)
 			}
 			if m.state == loginInputsFocused && m.focusIndex == len(m.inputs) {
+				if m.throttled() {
+					return m, nil
+				}
 				validationErr := validateLoginModelInputs(m.inputs)
 				if validationErr != nil {
 					m.err = validationErr
@@ -179,10 +213,15 @@ func (m LoginModel) View() string {
 
 	fmt.Fprintf(&b, "\n%s\t%s\n", loginButton, createUserButton)
 
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
 	if m.err != nil && m.showErr {
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
 		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
 	}
+	if m.throttled() {
+		wait := max(time.Until(m.retryAt).Round(time.Second), time.Second)
+		locked := fmt.Sprintf("Login locked after too many failed attempts, try again in %s", wait)
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(locked))
+	}
 
 	help := blurredStyle.Render("\n(Tab/Shift+Tab: Navigate, ↑/↓: Cycle Focus, Enter: Select/Login, Esc: Quit)")
 	b.WriteString(help)
@@ -190,6 +229,18 @@ func (m LoginModel) View() string {
 	return b.String()
 }
 
+// throttled reports whether logins are locked after too many failed attempts.
+func (m LoginModel) throttled() bool {
+	return time.Now().Before(m.retryAt)
+}
+
+// loginTickCmd schedules the next refresh of the login countdown of generation.
+func loginTickCmd(generation int) tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return loginTickMsg{generation: generation}
+	})
+}
+
 // updateFocus updates the visual focus styles on inputs and returns the blink command.
 func (m *LoginModel) updateFocus() tea.Cmd {
 	for i := range m.inputs {
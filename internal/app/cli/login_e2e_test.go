@@ -3,9 +3,13 @@
 package cli
 
 import (
+	"bytes"
 	"testing"
+	"time"
 	"yubigo-pass/internal/app/crypto"
 	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/services"
+	"yubigo-pass/internal/app/vault"
 	"yubigo-pass/internal/database"
 	"yubigo-pass/test"
 
@@ -252,3 +256,54 @@ func TestLoginShouldSucceedAfterEnteringCreateUserFlowAndBack(t *testing.T) {
 	assert.Equal(t, existingPassword, m.inputs[1].Value())
 	assert.NoError(t, m.err, "Error should be nil on successful login")
 }
+
+func TestAppModelShouldLockLoginAfterFailedAttempts(t *testing.T) {
+	// given
+	db, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed to set up test database")
+	defer test.TeardownTestDB(db)
+	container := services.Container{Store: database.NewStore(db)}
+
+	tm := teatest.NewTestModel(t, NewAppModel(container), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("LOGIN"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.TypeString(tm, test.RandomString())
+	test.PressKey(tm, tea.KeyDown) // -> Password
+	test.TypeString(tm, test.RandomString())
+	test.PressKey(tm, tea.KeyDown) // -> Login Button
+	for range model.DefaultLoginPolicy.FreeFailures {
+		test.PressKey(tm, tea.KeyEnter) // Submit
+	}
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		// earlier attempts show the error too, the wrong attempt locking the login shows it after the countdown
+		locked := bytes.Index(bts, []byte("Login locked after too many failed attempts, try again in 1s"))
+		return locked >= 0 && bytes.LastIndex(bts, []byte("incorrect username or password")) > locked
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm, ok := tm.FinalModel(t).(AppModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	login, ok := fm.activeModel.(LoginModel)
+	require.Truef(t, ok, "active model has wrong type: %T", fm.activeModel)
+	assert.False(t, login.retryAt.IsZero())
+	assert.ErrorIs(t, fm.lastError, vault.ErrIncorrectCredentials)
+}
+
+func TestLoginShouldRunSingleCountdownAcrossLockouts(t *testing.T) {
+	// given
+	var m tea.Model = NewLoginModel(nil)
+	m, _ = m.Update(loginThrottledMsg{retryAt: time.Now().Add(time.Minute)})
+	m, _ = m.Update(loginThrottledMsg{retryAt: time.Now().Add(2 * time.Minute)})
+
+	// when
+	_, staleCmd := m.Update(loginTickMsg{generation: 1})
+	_, currentCmd := m.Update(loginTickMsg{generation: 2})
+
+	// then
+	assert.Nil(t, staleCmd, "ticks of the first lockout should stop")
+	assert.NotNil(t, currentCmd, "ticks of the latest lockout should go on")
+}
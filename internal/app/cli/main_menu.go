@@ -19,6 +19,15 @@ const (
 	GetPasswordItem  = "Get password"
 	ViewPasswordItem = "View your passwords"
 	AddPasswordItem  = "Add a new password" // #nosec G101
+	AddItemItem      = "Add a card, identity, key or other item"
+	ImportQRItem     = "Import one-time passwords from a QR code"
+	ImportCSVItem    = "Import a CSV export"
+	SyncConflictItem = "Resolve sync conflicts"
+	CollectionsItem  = "Shared collections"
+	EmergencyItem    = "Emergency access"
+	SecurityItem     = "Security report"
+	AuditLogItem     = "Audit log"
+	GeneratorItem    = "Password generator"
 	LogoutItem       = "Logout"
 	QuitItem         = "Quit"
 )
@@ -73,6 +82,14 @@ func NewMainMenuModel() MainMenuModel {
 		item(GetPasswordItem),
 		item(ViewPasswordItem),
 		item(AddPasswordItem),
+		item(AddItemItem),
+		item(ImportQRItem),
+		item(ImportCSVItem),
+		item(CollectionsItem),
+		item(EmergencyItem),
+		item(SecurityItem),
+		item(AuditLogItem),
+		item(GeneratorItem),
 		item(LogoutItem),
 		item(QuitItem),
 	}
@@ -93,6 +110,14 @@ func NewMainMenuModel() MainMenuModel {
 	}
 }
 
+// WithSyncConflicts returns the menu offering to resolve sync conflicts, for vaults synchronized
+// through a sync server.
+func (m MainMenuModel) WithSyncConflicts() MainMenuModel {
+	items := m.list.Items()
+	m.list.InsertItem(len(items)-2, item(SyncConflictItem))
+	return m
+}
+
 // Init initializes the MainMenuModel. Currently returns nil.
 func (m MainMenuModel) Init() tea.Cmd {
 	return nil
@@ -131,6 +156,24 @@ func (m MainMenuModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, common.ChangeStateCmd(common.StateGoToViewPasswords)
 			case AddPasswordItem:
 				return m, common.ChangeStateCmd(common.StateGoToAddPassword)
+			case AddItemItem:
+				return m, common.ChangeStateCmd(common.StateGoToAddItem)
+			case ImportQRItem:
+				return m, common.ChangeStateCmd(common.StateGoToImportQR)
+			case ImportCSVItem:
+				return m, common.ChangeStateCmd(common.StateGoToImportCSV)
+			case SyncConflictItem:
+				return m, common.ChangeStateCmd(common.StateGoToSyncConflicts)
+			case CollectionsItem:
+				return m, common.ChangeStateCmd(common.StateGoToCollections)
+			case EmergencyItem:
+				return m, common.ChangeStateCmd(common.StateGoToEmergencyAccess)
+			case SecurityItem:
+				return m, common.ChangeStateCmd(common.StateGoToSecurityReport)
+			case AuditLogItem:
+				return m, common.ChangeStateCmd(common.StateGoToAuditLog)
+			case GeneratorItem:
+				return m, common.ChangeStateCmd(common.StateGoToPasswordGenerator)
 			case LogoutItem:
 				return m, common.ChangeStateCmd(common.StateLogout)
 			case QuitItem:
@@ -3,7 +3,11 @@
 package cli
 
 import (
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"yubigo-pass/internal/app/common"
 	"yubigo-pass/test"
 
 	"github.com/stretchr/testify/assert"
@@ -80,7 +84,7 @@ func TestMainMenuShouldChooseViewPasswords(t *testing.T) {
 	)
 
 	// when
-	test.PressKey(tm, tea.KeyDown) // -> View Passwords
+	moveToMenuItem(t, tm, ViewPasswordItem)
 	test.PressKey(tm, tea.KeyEnter)
 
 	// then
@@ -102,8 +106,7 @@ func TestMainMenuShouldChooseAddPasswords(t *testing.T) {
 	)
 
 	// when
-	test.PressKey(tm, tea.KeyDown) // -> View Passwords
-	test.PressKey(tm, tea.KeyDown) // -> Add Password
+	moveToMenuItem(t, tm, AddPasswordItem)
 	test.PressKey(tm, tea.KeyEnter)
 
 	// then
@@ -116,6 +119,58 @@ func TestMainMenuShouldChooseAddPasswords(t *testing.T) {
 	assert.False(t, m.quitting)
 }
 
+func TestMainMenuShouldChooseAddItem(t *testing.T) {
+	// given
+	m := NewMainMenuModel()
+	m.list.Select(3)
+
+	// when
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// then
+	require.NotNil(t, cmd)
+	assert.Equal(t, common.StateMsg{State: common.StateGoToAddItem}, cmd())
+}
+
+func TestMainMenuShouldChooseImportQR(t *testing.T) {
+	// given
+	m := NewMainMenuModel()
+	m.list.Select(4)
+
+	// when
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// then
+	require.NotNil(t, cmd)
+	assert.Equal(t, common.StateMsg{State: common.StateGoToImportQR}, cmd())
+}
+
+func TestMainMenuShouldChooseSharedCollections(t *testing.T) {
+	// given
+	m := NewMainMenuModel()
+	m.list.Select(6)
+
+	// when
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// then
+	require.NotNil(t, cmd)
+	assert.Equal(t, common.StateMsg{State: common.StateGoToCollections}, cmd())
+}
+
+func TestMainMenuShouldChooseEmergencyAccess(t *testing.T) {
+	// given
+	m := NewMainMenuModel()
+	m.list.Select(7)
+
+	// when
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// then
+	require.NotNil(t, cmd)
+	assert.Equal(t, common.StateMsg{State: common.StateGoToEmergencyAccess}, cmd())
+}
+
 func TestMainMenuShouldChooseLogout(t *testing.T) {
 	// given
 	tm := teatest.NewTestModel(
@@ -125,9 +180,7 @@ func TestMainMenuShouldChooseLogout(t *testing.T) {
 	)
 
 	// when
-	test.PressKey(tm, tea.KeyDown) // -> View Passwords
-	test.PressKey(tm, tea.KeyDown) // -> Add Password
-	test.PressKey(tm, tea.KeyDown) // -> Logout
+	moveToMenuItem(t, tm, LogoutItem)
 	test.PressKey(tm, tea.KeyEnter)
 
 	// then
@@ -149,10 +202,7 @@ func TestMainMenuShouldChooseQuit(t *testing.T) {
 	)
 
 	// when
-	test.PressKey(tm, tea.KeyDown) // -> View Passwords
-	test.PressKey(tm, tea.KeyDown) // -> Add Password
-	test.PressKey(tm, tea.KeyDown) // -> Logout
-	test.PressKey(tm, tea.KeyDown) // -> Quit
+	moveToMenuItem(t, tm, QuitItem)
 	test.PressKey(tm, tea.KeyEnter)
 
 	// then
@@ -185,3 +235,25 @@ func TestMainMenuShouldQuitByPressingQ(t *testing.T) {
 	require.Truef(t, ok, "final model has wrong type: %T", fm)
 	assert.True(t, m.quitting)
 }
+
+// selectedMenuItem matches the main menu item under the cursor with its number and title
+var selectedMenuItem = regexp.MustCompile(`> (\d+)\. ([^\n\x1b]*)`)
+
+// moveToMenuItem moves the cursor of the main menu from its first item down to the item titled title,
+// waiting for every move to render so the keys pressed next reach the menu after it.
+func moveToMenuItem(t *testing.T, tm *teatest.TestModel, title string) {
+	t.Helper()
+	current := GetPasswordItem
+	for number := 2; current != title; number++ {
+		test.PressKey(tm, tea.KeyDown)
+		teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+			for _, match := range selectedMenuItem.FindAllSubmatch(bts, -1) {
+				if string(match[1]) == strconv.Itoa(number) {
+					current = strings.TrimSpace(string(match[2]))
+					return true
+				}
+			}
+			return false
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"yubigo-pass/internal/app/merge"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// mergeResolutionLabels describe the version of a conflicting entry kept by the merge.
+var mergeResolutionLabels = map[merge.Resolution]string{
+	merge.Unresolved: "unresolved",
+	merge.KeepLocal:  "keep this vault's",
+	merge.KeepOther:  "keep the other vault's",
+	merge.KeepBoth:   "keep both",
+}
+
+// MergeConflictsModel is a Bubble Tea program for picking the version kept of each entry changed
+// differently in the vaults merged by the merge command. It shows both versions of the selected
+// entry side by side and quits once every conflict is resolved and the merge confirmed, or aborted.
+type MergeConflictsModel struct {
+	conflicts []merge.Conflict
+	selected  int
+	revealed  bool
+	confirmed bool
+	done      bool
+	err       error
+}
+
+// NewMergeConflictsModel creates a new instance of the MergeConflictsModel.
+func NewMergeConflictsModel(conflicts []merge.Conflict) MergeConflictsModel {
+	return MergeConflictsModel{
+		conflicts: slices.Clone(conflicts),
+	}
+}
+
+// Conflicts returns the conflicts with the versions picked, and whether the merge was confirmed.
+func (m MergeConflictsModel) Conflicts() ([]merge.Conflict, bool) {
+	return m.conflicts, m.confirmed
+}
+
+// Init initializes the MergeConflictsModel. Currently returns nil.
+func (m MergeConflictsModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles user input for the merge conflicts screen.
+func (m MergeConflictsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || m.done {
+		return m, nil
+	}
+
+	switch keyMsg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		m.done = true
+		return m, tea.Quit
+	case tea.KeyEnter:
+		if unresolved := m.unresolved(); unresolved >= 0 {
+			m.selected = unresolved
+			m.err = fmt.Errorf("%s is not resolved yet", m.conflicts[unresolved].Local.Name())
+			return m, nil
+		}
+		m.confirmed = true
+		m.done = true
+		return m, tea.Quit
+	case tea.KeyCtrlS:
+		m.revealed = !m.revealed
+	case tea.KeyUp:
+		m.selected = max(0, m.selected-1)
+	case tea.KeyDown:
+		m.selected = max(0, min(m.selected+1, len(m.conflicts)-1))
+	case tea.KeyRunes:
+		switch string(keyMsg.Runes) {
+		case "m":
+			return m.resolve(merge.KeepLocal), nil
+		case "o":
+			return m.resolve(merge.KeepOther), nil
+		case "b":
+			return m.resolve(merge.KeepBoth), nil
+		}
+	}
+	return m, nil
+}
+
+// resolve keeps a version of the selected entry and moves on to the next unresolved one.
+func (m MergeConflictsModel) resolve(resolution merge.Resolution) MergeConflictsModel {
+	if len(m.conflicts) == 0 {
+		return m
+	}
+	m.err = nil
+	m.conflicts = slices.Clone(m.conflicts)
+	m.conflicts[m.selected].Resolution = resolution
+	if unresolved := m.unresolved(); unresolved >= 0 {
+		m.selected = unresolved
+	}
+	return m
+}
+
+// unresolved returns the index of the first conflict left unresolved, or -1 when there is none.
+func (m MergeConflictsModel) unresolved() int {
+	return slices.IndexFunc(m.conflicts, func(c merge.Conflict) bool { return c.Resolution == merge.Unresolved })
+}
+
+// View renders the merge conflicts screen UI.
+func (m MergeConflictsModel) View() string {
+	if m.done {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("MERGE CONFLICTS") + "\n\n")
+	if len(m.conflicts) > 0 {
+		b.WriteString(blurredStyle.Render("These entries differ in both vaults, pick the version to keep of each.") + "\n\n")
+		for i, conflict := range m.conflicts {
+			line := fmt.Sprintf("%-40s %s", conflict.Local.Name(), mergeResolutionLabels[conflict.Resolution])
+			if i == m.selected {
+				b.WriteString(focusedStyle.Render("> "+line) + "\n")
+				continue
+			}
+			b.WriteString("  " + line + "\n")
+		}
+		b.WriteRune('\n')
+		conflict := m.conflicts[m.selected]
+		b.WriteString(viewVersions(
+			version{header: "THIS VAULT", entry: conflict.Local.Entry, modified: conflict.Local.Modified},
+			version{header: "OTHER VAULT", entry: conflict.Other.Entry, modified: conflict.Other.Modified},
+			m.revealed,
+		))
+	}
+
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
+	}
+
+	help := "(↑/↓: Select, m: Keep this vault's, o: Keep the other vault's, b: Keep both, Ctrl+S: Show/Hide secrets, Enter: Merge, Esc: Abort)"
+	b.WriteString(blurredStyle.Render("\n\n" + help))
+	return b.String()
+}
@@ -0,0 +1,95 @@
+//go:build e2e
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/merge"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mergeConflict returns a conflict over a login whose password differs in the merged vaults
+func mergeConflict(title, local, other string) merge.Conflict {
+	version := func(password string) merge.Version {
+		return merge.Version{
+			ID:       title,
+			Username: "octocat",
+			Entry: archive.Entry{
+				Type:   string(model.ItemTypeLogin),
+				Title:  title,
+				Values: map[string]string{"username": "octocat", "password": password},
+			},
+			Modified: time.Now(),
+		}
+	}
+	return merge.Conflict{Local: version(local), Other: version(other)}
+}
+
+func TestMergeConflictsShouldQuitWithPickedVersions(t *testing.T) {
+	// given
+	conflicts := []merge.Conflict{mergeConflict("GitHub", "mine", "theirs"), mergeConflict("GitLab", "mine", "theirs")}
+	tm := teatest.NewTestModel(t, NewMergeConflictsModel(conflicts), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("MERGE CONFLICTS")) && bytes.Contains(bts, []byte("OTHER VAULT"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.TypeString(tm, "o")
+	test.TypeString(tm, "b")
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(2*time.Second))
+	m, ok := fm.(MergeConflictsModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	resolved, confirmed := m.Conflicts()
+	assert.True(t, confirmed)
+	require.Len(t, resolved, 2)
+	assert.Equal(t, merge.KeepOther, resolved[0].Resolution)
+	assert.Equal(t, merge.KeepBoth, resolved[1].Resolution)
+}
+
+func TestMergeConflictsShouldNotConfirmUntilAllResolved(t *testing.T) {
+	// given
+	conflicts := []merge.Conflict{mergeConflict("GitHub", "mine", "theirs"), mergeConflict("GitLab", "mine", "theirs")}
+	tm := teatest.NewTestModel(t, NewMergeConflictsModel(conflicts), teatest.WithInitialTermSize(300, 100))
+
+	// when
+	test.TypeString(tm, "m")
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("GitLab (octocat) is not resolved yet"))
+	}, teatest.WithDuration(2*time.Second))
+	test.PressKey(tm, tea.KeyEsc)
+	fm := tm.FinalModel(t, teatest.WithFinalTimeout(2*time.Second))
+	_, confirmed := fm.(MergeConflictsModel).Conflicts()
+	assert.False(t, confirmed)
+	assert.Equal(t, merge.Unresolved, conflicts[0].Resolution, "conflicts passed in are left untouched")
+}
+
+func TestMergeConflictsShouldMaskSecretsUntilRevealed(t *testing.T) {
+	// given
+	m := NewMergeConflictsModel([]merge.Conflict{mergeConflict("GitHub", "mine", "theirs")})
+
+	// when
+	masked := m.View()
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	revealed := updated.View()
+
+	// then
+	assert.NotContains(t, masked, "theirs")
+	assert.Contains(t, masked, "≠ Password")
+	assert.Contains(t, revealed, "theirs")
+	assert.Contains(t, revealed, "mine")
+}
@@ -0,0 +1,644 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/otp"
+	"yubigo-pass/internal/app/sharelink"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maskedValue is shown in place of secrets until they are revealed.
+const maskedValue = "••••••••"
+
+// passwordDetailsLoadedMsg carries the decrypted details of the displayed entry.
+type passwordDetailsLoadedMsg struct {
+	details vault.EntryDetails
+	err     error
+}
+
+// attachmentsLoadedMsg carries the attachments of the displayed entry.
+type attachmentsLoadedMsg struct {
+	files []model.Attachment
+	err   error
+}
+
+// attachmentChangedMsg reports the outcome of attaching, extracting or removing a file.
+type attachmentChangedMsg struct {
+	notice string
+	err    error
+}
+
+// otpTickMsg refreshes the one-time code countdown of the detail screen with the given ID.
+type otpTickMsg struct {
+	id   int64
+	time time.Time
+}
+
+// otpCodeMsg carries a generated counter based one-time code.
+type otpCodeMsg struct {
+	code otp.Code
+	err  error
+}
+
+// shareLinkMsg carries the one-time link created for the displayed entry.
+type shareLinkMsg struct {
+	link sharelink.Link
+	err  error
+}
+
+// secretReadMsg reports whether revealing the secrets of the entry was recorded in the audit log.
+type secretReadMsg struct {
+	err error
+}
+
+// lastDetailID numbers detail screens, so a countdown keeps ticking only on the screen that started it.
+var lastDetailID int64
+
+// attachmentPrompt is the question the detail screen is waiting to be answered.
+type attachmentPrompt int
+
+const (
+	promptNone attachmentPrompt = iota
+	promptAttach
+	promptExtract
+	promptRemove
+	promptShareLink
+)
+
+// linkExpiries are the lifetimes offered for one-time links, Tab cycles through them.
+var linkExpiries = []struct {
+	label  string
+	expiry time.Duration
+}{
+	{"1 hour", time.Hour},
+	{"1 day", sharelink.DefaultExpiry},
+	{"7 days", 7 * 24 * time.Hour},
+}
+
+// defaultLinkExpiry is the index of the lifetime links are offered with first.
+const defaultLinkExpiry = 1
+
+var detailLabelStyle = blurredStyle.Copy().Width(12)
+
+// PasswordDetailModel is a Bubble Tea model displaying a single entry with the fields of its type,
+// notes, custom fields, folders, tags and attached files. Secrets stay masked until revealed.
+// With a share server, the entry can be handed over through a one-time link.
+type PasswordDetailModel struct {
+	entry       model.Password
+	details     vault.EntryDetails
+	loaded      bool
+	revealed    bool
+	showErr     bool
+	err         error
+	vault       vault.Vault
+	attachments vault.Attachments
+	files       []model.Attachment
+	selected    int
+	prompt      attachmentPrompt
+	pathInput   textinput.Model
+	notice      string
+	id          int64
+	otpKey      *otp.Key
+	otpCode     otp.Code
+	now         time.Time
+	links       sharelink.Client
+	linkExpiry  int
+}
+
+// NewPasswordDetailModel creates a new instance of the PasswordDetailModel.
+func NewPasswordDetailModel(v vault.Vault, attachments vault.Attachments, entry model.Password) PasswordDetailModel {
+	pathInput := textinput.New()
+	pathInput.Cursor.Style = cursorStyle
+	pathInput.CharLimit = 4096
+
+	return PasswordDetailModel{
+		entry:       entry,
+		vault:       v,
+		attachments: attachments,
+		pathInput:   pathInput,
+		id:          atomic.AddInt64(&lastDetailID, 1),
+	}
+}
+
+// WithShareLinks returns the screen creating one-time links of the entry on the share server of client.
+func (m PasswordDetailModel) WithShareLinks(client sharelink.Client) PasswordDetailModel {
+	m.links = client
+	return m
+}
+
+// Init decrypts the details of the entry and lists its attachments.
+func (m PasswordDetailModel) Init() tea.Cmd {
+	v, entry := m.vault, m.entry
+	return tea.Batch(func() tea.Msg {
+		details, err := v.Reveal(entry)
+		return passwordDetailsLoadedMsg{details: details, err: err}
+	}, m.loadAttachmentsCmd())
+}
+
+// loadAttachmentsCmd lists the attachments of the entry.
+func (m PasswordDetailModel) loadAttachmentsCmd() tea.Cmd {
+	attachments, entryID := m.attachments, m.entry.ID
+	return func() tea.Msg {
+		files, err := attachments.List(entryID)
+		return attachmentsLoadedMsg{files: files, err: err}
+	}
+}
+
+// Update handles incoming messages and user input for the password detail screen.
+func (m PasswordDetailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case passwordDetailsLoadedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("failed to decrypt entry: %w", msg.err)
+			m.showErr = true
+			return m, nil
+		}
+		m.details = msg.details
+		m.loaded = true
+		if m.details.Entry.OTP == "" {
+			return m, nil
+		}
+		key, err := otp.Parse(m.details.Entry.OTP)
+		if err != nil {
+			m.err = fmt.Errorf("one-time password URI %w", err)
+			m.showErr = true
+			return m, nil
+		}
+		m.otpKey = &key
+		m.now = time.Now()
+		if key.Type == otp.TypeTOTP {
+			return m, m.otpTickCmd()
+		}
+		return m, nil
+
+	case otpTickMsg:
+		if msg.id != m.id {
+			return m, nil
+		}
+		m.now = msg.time
+		return m, m.otpTickCmd()
+
+	case otpCodeMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.showErr = true
+			return m, nil
+		}
+		m.otpCode = msg.code
+		return m, nil
+
+	case secretReadMsg:
+		if msg.err != nil {
+			m.revealed = false
+			m.err = fmt.Errorf("failed to record reading secrets: %w", msg.err)
+			m.showErr = true
+		}
+		return m, nil
+
+	case attachmentsLoadedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("failed to list attachments: %w", msg.err)
+			m.showErr = true
+			return m, nil
+		}
+		m.files = msg.files
+		m.selected = max(0, min(m.selected, len(m.files)-1))
+		return m, nil
+
+	case attachmentChangedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.showErr = true
+			m.notice = ""
+			return m, nil
+		}
+		m.err = nil
+		m.showErr = false
+		m.notice = msg.notice
+		return m, m.loadAttachmentsCmd()
+
+	case shareLinkMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("failed to create link: %w", msg.err)
+			m.showErr = true
+			m.notice = ""
+			return m, nil
+		}
+		m.err = nil
+		m.showErr = false
+		views := "once"
+		if msg.link.Views > 1 {
+			views = fmt.Sprintf("%d times", msg.link.Views)
+		}
+		m.notice = fmt.Sprintf("Link opening %s until %s:\n%s", views,
+			msg.link.Expires.Format("2006-01-02 15:04"), focusedStyle.Render(msg.link.URL))
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.prompt != promptNone {
+			return m.updatePrompt(msg)
+		}
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, common.ChangeStateCmd(common.StateQuit)
+		case tea.KeyCtrlS:
+			m.revealed = !m.revealed
+			if !m.revealed {
+				return m, nil
+			}
+			return m, recordReadCmd(m.vault, m.entry)
+		case tea.KeyCtrlE:
+			return m, common.EditPasswordCmd(m.entry)
+		case tea.KeyCtrlO:
+			if m.otpKey == nil || m.otpKey.Type != otp.TypeHOTP {
+				return m, nil
+			}
+			return m, nextHOTPCmd(m.vault, m.entry)
+		case tea.KeyEnter:
+			return m, common.ChangeStateCmd(common.StateGoBack)
+		case tea.KeyUp:
+			m.selected = max(0, m.selected-1)
+			return m, nil
+		case tea.KeyDown:
+			m.selected = max(0, min(m.selected+1, len(m.files)-1))
+			return m, nil
+		case tea.KeyCtrlA:
+			return m.openPrompt(promptAttach, "Attach file: ", "")
+		case tea.KeyCtrlX:
+			if len(m.files) == 0 {
+				return m, nil
+			}
+			return m.openPrompt(promptExtract, "Save to: ", m.files[m.selected].Name)
+		case tea.KeyCtrlD:
+			if len(m.files) == 0 {
+				return m, nil
+			}
+			m.prompt = promptRemove
+			return m, nil
+		case tea.KeyCtrlL:
+			if m.links.URL == "" {
+				m.err = fmt.Errorf("no share server set, set $%s to create links", sharelink.EnvServer)
+				m.showErr = true
+				return m, nil
+			}
+			if !m.loaded {
+				return m, nil
+			}
+			m.linkExpiry = defaultLinkExpiry
+			return m.openPrompt(promptShareLink, "Views: ", "1")
+		}
+	}
+	return m, nil
+}
+
+// otpTickCmd schedules the next refresh of the one-time code countdown.
+func (m PasswordDetailModel) otpTickCmd() tea.Cmd {
+	id := m.id
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return otpTickMsg{id: id, time: t}
+	})
+}
+
+// recordReadCmd records reading the secrets of the entry in the audit log of the vault.
+func recordReadCmd(v vault.Vault, entry model.Password) tea.Cmd {
+	return func() tea.Msg {
+		return secretReadMsg{err: v.Record(model.AuditRead, model.ClientTUI, entry)}
+	}
+}
+
+// nextHOTPCmd advances the counter of the entry and generates its next code.
+func nextHOTPCmd(v vault.Vault, entry model.Password) tea.Cmd {
+	return func() tea.Msg {
+		code, err := v.OneTimeCode(entry, time.Now())
+		if err == nil {
+			err = v.Record(model.AuditRead, model.ClientTUI, entry)
+		}
+		return otpCodeMsg{code: code, err: err}
+	}
+}
+
+// openPrompt asks for a file path, starting with value.
+func (m PasswordDetailModel) openPrompt(prompt attachmentPrompt, label, value string) (tea.Model, tea.Cmd) {
+	m.prompt = prompt
+	m.notice = ""
+	m.pathInput.Prompt = label
+	m.pathInput.SetValue(value)
+	m.pathInput.CursorEnd()
+	return m, m.pathInput.Focus()
+}
+
+// updatePrompt handles input while the screen waits for a file path or a confirmation.
+func (m PasswordDetailModel) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		return m, common.ChangeStateCmd(common.StateQuit)
+	}
+
+	if m.prompt == promptRemove {
+		m.prompt = promptNone
+		if msg.String() != "y" {
+			return m, nil
+		}
+		return m, removeAttachmentCmd(m.attachments, m.entry, m.files[m.selected])
+	}
+	if m.prompt == promptShareLink {
+		return m.updateShareLinkPrompt(msg)
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.prompt = promptNone
+		m.pathInput.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		path := expandHome(strings.TrimSpace(m.pathInput.Value()))
+		if path == "" {
+			return m, nil
+		}
+		prompt := m.prompt
+		m.prompt = promptNone
+		m.pathInput.Blur()
+		if prompt == promptAttach {
+			return m, attachFileCmd(m.attachments, m.entry, path)
+		}
+		return m, extractAttachmentCmd(m.attachments, m.entry, m.files[m.selected], path)
+	}
+
+	var cmd tea.Cmd
+	m.pathInput, cmd = m.pathInput.Update(msg)
+	return m, cmd
+}
+
+// updateShareLinkPrompt handles input while the screen asks how often and how long a link opens.
+func (m PasswordDetailModel) updateShareLinkPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.prompt = promptNone
+		m.pathInput.Blur()
+		return m, nil
+	case tea.KeyTab:
+		m.linkExpiry = (m.linkExpiry + 1) % len(linkExpiries)
+		return m, nil
+	case tea.KeyEnter:
+		views, err := strconv.Atoi(strings.TrimSpace(m.pathInput.Value()))
+		if err != nil || views < 1 || views > sharelink.MaxViews {
+			m.err = fmt.Errorf("a link opens 1 to %d times", sharelink.MaxViews)
+			m.showErr = true
+			return m, nil
+		}
+		m.prompt = promptNone
+		m.pathInput.Blur()
+		m.showErr = false
+		opts := sharelink.Options{Expiry: linkExpiries[m.linkExpiry].expiry, Views: views}
+		return m, createLinkCmd(m.links, m.vault, m.entry, shareText(m.details), opts)
+	}
+
+	var cmd tea.Cmd
+	m.pathInput, cmd = m.pathInput.Update(msg)
+	return m, cmd
+}
+
+// createLinkCmd creates a one-time link of secret, the text of the entry, on the share server.
+func createLinkCmd(links sharelink.Client, v vault.Vault, entry model.Password, secret string, opts sharelink.Options) tea.Cmd {
+	return func() tea.Msg {
+		link, err := links.Create(secret, opts)
+		if err == nil {
+			err = v.Record(model.AuditCopied, model.ClientTUI, entry)
+		}
+		return shareLinkMsg{link: link, err: err}
+	}
+}
+
+// shareText lays the fields and custom fields of an entry out as the text of a link, one per line.
+func shareText(details vault.EntryDetails) string {
+	var b strings.Builder
+	b.WriteString(details.Entry.Title + "\n")
+	for _, field := range details.Item.Type.Fields() {
+		if value := details.Item.Values[field.Key]; value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", field.Label, value)
+		}
+	}
+	for _, field := range details.Fields {
+		fmt.Fprintf(&b, "%s: %s\n", field.Name, field.Value)
+	}
+	return b.String()
+}
+
+// attachFileCmd encrypts the file at path and attaches it to the entry.
+func attachFileCmd(attachments vault.Attachments, entry model.Password, path string) tea.Cmd {
+	return func() tea.Msg {
+		file, err := os.Open(path) // #nosec G304 -- the user picks the file to attach
+		if err != nil {
+			return attachmentChangedMsg{err: err}
+		}
+		defer file.Close()
+
+		attachment, err := attachments.Add(entry.ID, filepath.Base(path), file)
+		if err == nil {
+			err = attachments.Record(model.AuditChanged, model.ClientTUI, entry)
+		}
+		if err != nil {
+			return attachmentChangedMsg{err: err}
+		}
+		return attachmentChangedMsg{notice: fmt.Sprintf("Attached %s (%s)", attachment.Name, utils.FormatSize(attachment.Size))}
+	}
+}
+
+// extractAttachmentCmd decrypts an attachment to a new file at path.
+func extractAttachmentCmd(attachments vault.Attachments, entry model.Password, attachment model.Attachment, path string) tea.Cmd {
+	return func() tea.Msg {
+		err := attachments.Record(model.AuditCopied, model.ClientTUI, entry)
+		if err == nil {
+			err = attachments.ExtractToFile(attachment, path, false)
+		}
+		if err != nil {
+			return attachmentChangedMsg{err: err}
+		}
+		return attachmentChangedMsg{notice: fmt.Sprintf("Extracted %s to %s", attachment.Name, path)}
+	}
+}
+
+// removeAttachmentCmd deletes an attachment.
+func removeAttachmentCmd(attachments vault.Attachments, entry model.Password, attachment model.Attachment) tea.Cmd {
+	return func() tea.Msg {
+		err := attachments.Remove(attachment)
+		if err == nil {
+			err = attachments.Record(model.AuditChanged, model.ClientTUI, entry)
+		}
+		if err != nil {
+			return attachmentChangedMsg{err: err}
+		}
+		return attachmentChangedMsg{notice: fmt.Sprintf("Removed %s", attachment.Name)}
+	}
+}
+
+// expandHome replaces a leading ~ with the home directory of the user.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// View renders the password detail screen UI.
+func (m PasswordDetailModel) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(strings.ToUpper(m.entry.Title)) + "\n\n")
+
+	if m.loaded {
+		b.WriteString(m.detailsView())
+	} else if !m.showErr {
+		b.WriteString(blurredStyle.Render("Decrypting…") + "\n")
+	}
+
+	b.WriteString(m.attachmentsView())
+
+	fmt.Fprintf(&b, "\n%s", focusedBackButton)
+
+	if m.err != nil && m.showErr {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
+	} else if m.notice != "" {
+		fmt.Fprintf(&b, "\n%s\n", m.notice)
+	}
+
+	var help string
+	switch m.prompt {
+	case promptAttach, promptExtract:
+		help = "(Enter: Confirm, Esc: Cancel)"
+	case promptRemove:
+		help = "(y: Remove, any other key: Cancel)"
+	case promptShareLink:
+		help = "(Enter: Create link, Tab: Change expiry, Esc: Cancel)"
+	default:
+		help = "(Ctrl+S: Show/Hide secrets, Ctrl+E: Edit, Ctrl+A: Attach file, Ctrl+X: Extract, Ctrl+D: Remove file, Enter: Back, Esc: Quit)"
+		if m.otpKey != nil && m.otpKey.Type == otp.TypeHOTP {
+			help += "\n(Ctrl+O: Next one-time code)"
+		}
+		if m.links.URL != "" {
+			help += "\n(Ctrl+L: Share through a one-time link)"
+		}
+	}
+	b.WriteString(blurredStyle.Render("\n\n" + help))
+
+	return b.String()
+}
+
+// detailsView renders the decrypted entry.
+func (m PasswordDetailModel) detailsView() string {
+	var b strings.Builder
+	entry := m.details.Entry
+	item := m.details.Item
+
+	writeDetailRow(&b, "Type", item.Type.Label())
+	for _, field := range item.Type.Fields() {
+		value := item.Values[field.Key]
+		if value == "" {
+			continue
+		}
+		if field.Secret {
+			value = m.secret(value)
+		}
+		if field.Multiline && strings.Contains(value, "\n") {
+			b.WriteString(detailLabelStyle.Render(field.Label) + "\n" + value + "\n")
+			continue
+		}
+		writeDetailRow(&b, field.Label, value)
+	}
+	if m.otpKey != nil {
+		writeDetailRow(&b, "Code", m.otpView())
+	}
+	if len(m.details.Folders) > 0 {
+		writeDetailRow(&b, "Folders", strings.Join(m.details.Folders, ", "))
+	}
+	if len(m.details.Tags) > 0 {
+		writeDetailRow(&b, "Tags", "#"+strings.Join(m.details.Tags, " #"))
+	}
+
+	for _, field := range m.details.Fields {
+		value := field.Value
+		if field.Type == model.FieldTypeHidden {
+			value = m.secret(value)
+		}
+		writeDetailRow(&b, field.Name, value)
+	}
+
+	if entry.Notes != "" {
+		b.WriteString("\n" + sectionStyle.Render("Notes") + "\n")
+		b.WriteString(entry.Notes + "\n")
+	}
+	return b.String()
+}
+
+// otpView renders the current time based code with its countdown, or the last generated counter based code.
+func (m PasswordDetailModel) otpView() string {
+	if m.otpKey.Type == otp.TypeHOTP {
+		if m.otpCode.Value == "" {
+			return blurredStyle.Render("press Ctrl+O to generate")
+		}
+		return focusedStyle.Render(groupDigits(m.otpCode.Value))
+	}
+	code := m.otpKey.Generate(m.now)
+	seconds := int((code.Remaining + time.Second - 1) / time.Second)
+	return fmt.Sprintf("%s %s", focusedStyle.Render(groupDigits(code.Value)), blurredStyle.Render(fmt.Sprintf("(%ds)", seconds)))
+}
+
+// groupDigits splits a one-time code in two halves for easier reading, e.g. 123 456.
+func groupDigits(code string) string {
+	half := len(code) / 2
+	return code[:half] + " " + code[half:]
+}
+
+// attachmentsView renders the attached files with the selected one highlighted, and the open prompt.
+func (m PasswordDetailModel) attachmentsView() string {
+	var b strings.Builder
+	if len(m.files) > 0 {
+		b.WriteString("\n" + sectionStyle.Render("Attachments") + "\n")
+		for i, file := range m.files {
+			line := fmt.Sprintf("%s (%s)", file.Name, utils.FormatSize(file.Size))
+			if i == m.selected {
+				b.WriteString(focusedStyle.Render("> "+line) + "\n")
+			} else {
+				b.WriteString("  " + line + "\n")
+			}
+		}
+	}
+
+	switch m.prompt {
+	case promptAttach, promptExtract:
+		b.WriteString("\n" + m.pathInput.View() + "\n")
+	case promptRemove:
+		fmt.Fprintf(&b, "\nRemove %s? (y/n)\n", m.files[m.selected].Name)
+	case promptShareLink:
+		fmt.Fprintf(&b, "\n%s\nExpires in: %s\n", m.pathInput.View(), focusedStyle.Render(linkExpiries[m.linkExpiry].label))
+	}
+	return b.String()
+}
+
+// secret masks a value unless secrets are revealed.
+func (m PasswordDetailModel) secret(value string) string {
+	if m.revealed {
+		return value
+	}
+	return maskedValue
+}
+
+func writeDetailRow(b *strings.Builder, label, value string) {
+	fmt.Fprintf(b, "%s %s\n", detailLabelStyle.Render(label), value)
+}
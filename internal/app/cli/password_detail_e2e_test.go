@@ -0,0 +1,318 @@
+//go:build e2e
+
+package cli
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/otp"
+	"yubigo-pass/internal/app/sharelink"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupDetailEntry(t *testing.T) (vault.Vault, model.Password) {
+	t.Helper()
+	db, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed to set up test database")
+	t.Cleanup(func() { test.TeardownTestDB(db) })
+
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := vault.New(database.NewStore(db), session)
+	fields := []model.CustomField{
+		{Type: model.FieldTypeText, Name: "Key ID", Value: "AKIA123"},
+		{Type: model.FieldTypeHidden, Name: "PIN", Value: "4242"},
+	}
+	entry := model.Password{Title: "aws", Username: "root", Password: "hunter2", Notes: "recovery codes"}
+	require.NoError(t, v.AddEntry(entry, fields, "Work", []string{"cloud"}))
+
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	return v, entries[0]
+}
+
+func TestPasswordDetailShouldShowFieldsAndNotesWithMaskedSecrets(t *testing.T) {
+	// given
+	v, entry := setupDetailEntry(t)
+
+	// when
+	tm := teatest.NewTestModel(t, NewPasswordDetailModel(v, vault.NewAttachments(v, t.TempDir()), entry), teatest.WithInitialTermSize(300, 100))
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("AKIA123")) &&
+			bytes.Contains(bts, []byte("recovery codes")) &&
+			bytes.Contains(bts, []byte("#cloud"))
+	}, teatest.WithDuration(2*time.Second))
+
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(PasswordDetailModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	assert.NotContains(t, m.View(), "hunter2")
+	assert.NotContains(t, m.View(), "4242")
+}
+
+func TestPasswordDetailShouldRevealSecrets(t *testing.T) {
+	// given
+	v, entry := setupDetailEntry(t)
+	tm := teatest.NewTestModel(t, NewPasswordDetailModel(v, vault.NewAttachments(v, t.TempDir()), entry), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("AKIA123"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyCtrlS)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("hunter2")) && bytes.Contains(bts, []byte("4242"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+}
+
+func TestPasswordDetailShouldGoBack(t *testing.T) {
+	// given
+	v, entry := setupDetailEntry(t)
+	m := NewPasswordDetailModel(v, vault.NewAttachments(v, t.TempDir()), entry)
+
+	// when
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// then
+	require.NotNil(t, cmd)
+	assert.Equal(t, common.StateMsg{State: common.StateGoBack}, cmd())
+}
+
+func TestPasswordDetailShouldOpenEditor(t *testing.T) {
+	// given
+	v, entry := setupDetailEntry(t)
+	m := NewPasswordDetailModel(v, vault.NewAttachments(v, t.TempDir()), entry)
+
+	// when
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+
+	// then
+	require.NotNil(t, cmd)
+	assert.Equal(t, common.EditPasswordMsg{Entry: entry}, cmd())
+}
+
+func TestPasswordDetailShouldAttachFile(t *testing.T) {
+	// given
+	v, entry := setupDetailEntry(t)
+	attachments := vault.NewAttachments(v, t.TempDir())
+	source := filepath.Join(t.TempDir(), "license.key")
+	require.NoError(t, os.WriteFile(source, []byte("license contents"), 0600))
+	tm := teatest.NewTestModel(t, NewPasswordDetailModel(v, attachments, entry), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("AKIA123"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyCtrlA)
+	test.TypeString(tm, source)
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("license.key (16 B)"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	listed, err := attachments.List(entry.ID)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, "license.key", listed[0].Name)
+}
+
+func TestPasswordDetailShouldExtractAttachment(t *testing.T) {
+	// given
+	v, entry := setupDetailEntry(t)
+	attachments := vault.NewAttachments(v, t.TempDir())
+	_, err := attachments.Add(entry.ID, "license.key", strings.NewReader("license contents"))
+	require.NoError(t, err)
+	target := filepath.Join(t.TempDir(), "restored.key")
+	tm := teatest.NewTestModel(t, NewPasswordDetailModel(v, attachments, entry), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("license.key (16 B)"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyCtrlX)
+	test.PressKey(tm, tea.KeyCtrlU)
+	test.TypeString(tm, target)
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Extracted license.key"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "license contents", string(content))
+}
+
+func TestPasswordDetailShouldRemoveAttachmentAfterConfirmation(t *testing.T) {
+	// given
+	v, entry := setupDetailEntry(t)
+	attachments := vault.NewAttachments(v, t.TempDir())
+	_, err := attachments.Add(entry.ID, "license.key", strings.NewReader("license contents"))
+	require.NoError(t, err)
+	tm := teatest.NewTestModel(t, NewPasswordDetailModel(v, attachments, entry), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("license.key (16 B)"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyCtrlD)
+	test.TypeString(tm, "y")
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Removed license.key"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	listed, err := attachments.List(entry.ID)
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+}
+
+func setupOTPDetailEntry(t *testing.T, uri string) (vault.Vault, model.Password) {
+	t.Helper()
+	db, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed to set up test database")
+	t.Cleanup(func() { test.TeardownTestDB(db) })
+
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := vault.New(database.NewStore(db), session)
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "octocat", Password: "hunter2", OTP: uri}, nil, "", nil))
+
+	entry, err := v.FindEntry("github", "")
+	require.NoError(t, err)
+	return v, entry
+}
+
+func TestPasswordDetailShouldShowLiveTOTP(t *testing.T) {
+	// given
+	v, entry := setupOTPDetailEntry(t, "otpauth://totp/GitHub:octocat?secret="+test.OTPSecret)
+	secret, err := otp.DecodeSecret(test.OTPSecret)
+	require.NoError(t, err)
+
+	// when
+	tm := teatest.NewTestModel(t, NewPasswordDetailModel(v, vault.NewAttachments(v, t.TempDir()), entry), teatest.WithInitialTermSize(300, 100))
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		code := groupDigits(otp.TOTP(secret, time.Now(), otp.DefaultPeriod, 6, otp.AlgorithmSHA1))
+		return bytes.Contains(bts, []byte(code)) && bytes.Contains(bts, []byte("s)"))
+	}, teatest.WithDuration(3*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+}
+
+func TestPasswordDetailShouldGenerateNextHOTP(t *testing.T) {
+	// given
+	v, entry := setupOTPDetailEntry(t, "otpauth://hotp/Bank:me?secret="+test.OTPSecret+"&counter=0")
+	tm := teatest.NewTestModel(t, NewPasswordDetailModel(v, vault.NewAttachments(v, t.TempDir()), entry), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("press Ctrl+O to generate"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyCtrlO)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("755 224"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	stored, err := v.FindEntry("github", "")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), stored.OTPCounter)
+}
+
+func TestPasswordDetailShouldIgnoreCountdownOfOtherScreens(t *testing.T) {
+	// given
+	v, entry := setupOTPDetailEntry(t, "otpauth://totp/GitHub:octocat?secret="+test.OTPSecret)
+	m := NewPasswordDetailModel(v, vault.NewAttachments(v, t.TempDir()), entry)
+
+	// when
+	_, cmd := m.Update(otpTickMsg{id: m.id + 1, time: time.Now()})
+
+	// then
+	assert.Nil(t, cmd)
+}
+
+func TestPasswordDetailShouldShareEntryThroughOneTimeLink(t *testing.T) {
+	// given
+	v, entry := setupDetailEntry(t)
+	server := httptest.NewServer(sharelink.NewServer(t.TempDir(), "s3cret"))
+	t.Cleanup(server.Close)
+	links := sharelink.New(server.URL, "s3cret")
+	detail := NewPasswordDetailModel(v, vault.NewAttachments(v, t.TempDir()), entry).WithShareLinks(links)
+	tm := teatest.NewTestModel(t, detail, teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("AKIA123"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyCtrlL)
+	test.PressKey(tm, tea.KeyBackspace)
+	test.TypeString(tm, "2")
+	test.PressKey(tm, tea.KeyTab) // -> 7 days
+	test.PressKey(tm, tea.KeyEnter)
+
+	// then
+	linkPattern := regexp.MustCompile(regexp.QuoteMeta(server.URL) + `/s/[A-Za-z0-9_-]+#[A-Za-z0-9_-]+`)
+	var link string
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		link = string(linkPattern.Find(bts))
+		return bytes.Contains(bts, []byte("Link opening 2 times until")) && link != ""
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	secret, err := links.Open(link)
+	require.NoError(t, err)
+	assert.Contains(t, secret, "Password: hunter2")
+	assert.Contains(t, secret, "PIN: 4242")
+	_, err = links.Open(link)
+	assert.NoError(t, err, "the link opens twice")
+}
+
+func TestPasswordDetailShouldAskForShareServer(t *testing.T) {
+	// given
+	v, entry := setupDetailEntry(t)
+	tm := teatest.NewTestModel(t, NewPasswordDetailModel(v, vault.NewAttachments(v, t.TempDir()), entry), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("AKIA123"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyCtrlL)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("no share server set, set $"+sharelink.EnvServer))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+}
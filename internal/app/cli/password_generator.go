@@ -0,0 +1,454 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Rows of the password generator screen, those of character mode in focus order.
+const (
+	generatorNameRow = iota
+	generatorModeRow
+	generatorLengthRow
+	generatorLowerRow
+	generatorUpperRow
+	generatorDigitsRow
+	generatorSymbolsRow
+	generatorSymbolSetRow
+	generatorAmbiguousRow
+	generatorNoRepeatRow
+	generatorWordsRow
+	generatorWordlistRow
+	generatorSeparatorRow
+	generatorCapitalizationRow
+	generatorAppendDigitRow
+	generatorAppendSymbolRow
+)
+
+// generatorCharacterRows and generatorPassphraseRows are the rows shown in each mode in focus order
+var (
+	generatorCharacterRows = []int{
+		generatorNameRow, generatorModeRow, generatorLengthRow, generatorLowerRow, generatorUpperRow, generatorDigitsRow,
+		generatorSymbolsRow, generatorSymbolSetRow, generatorAmbiguousRow, generatorNoRepeatRow,
+	}
+	generatorPassphraseRows = []int{
+		generatorNameRow, generatorModeRow, generatorWordsRow, generatorWordlistRow, generatorSeparatorRow,
+		generatorCapitalizationRow, generatorAppendDigitRow, generatorAppendSymbolRow, generatorSymbolSetRow, generatorAmbiguousRow,
+	}
+)
+
+// generatorProfilesLoadedMsg carries the generator profiles of the user.
+type generatorProfilesLoadedMsg struct {
+	profiles []model.GeneratorProfile
+	err      error
+}
+
+// generatorProfileChangedMsg reports a generator profile saved or deleted, along with the notice shown.
+type generatorProfileChangedMsg struct {
+	notice string
+	err    error
+}
+
+// PasswordGeneratorModel is a Bubble Tea model editing the password generator profiles of the user.
+// It shows the policy of a profile with a password or passphrase generated by it and its entropy,
+// which Ctrl+G in the entry forms and the generate command use once the profile is saved as the active one.
+type PasswordGeneratorModel struct {
+	vault     vault.Vault
+	profiles  []model.GeneratorProfile
+	profile   int
+	policy    model.PasswordPolicy
+	name      textinput.Model
+	symbolSet textinput.Model
+	separator textinput.Model
+	row       int
+	preview   string
+	entropy   float64
+	invalid   error
+	loaded    bool
+	notice    string
+	err       error
+}
+
+// NewPasswordGeneratorModel creates a new instance of the PasswordGeneratorModel.
+func NewPasswordGeneratorModel(v vault.Vault) PasswordGeneratorModel {
+	name := textinput.New()
+	name.Cursor.Style = cursorStyle
+	name.CharLimit = 64
+	name.Placeholder = "Profile name"
+
+	symbolSet := textinput.New()
+	symbolSet.Cursor.Style = cursorStyle
+	symbolSet.CharLimit = 64
+	symbolSet.Placeholder = "Default symbols"
+
+	separator := textinput.New()
+	separator.Cursor.Style = cursorStyle
+	separator.CharLimit = utils.MaxSeparatorLength
+	separator.Placeholder = "None"
+
+	m := PasswordGeneratorModel{
+		vault:     v,
+		name:      name,
+		symbolSet: symbolSet,
+		separator: separator,
+	}
+	m.setPolicy(model.DefaultGeneratorProfile(v.UserID()))
+	m.updateFocus()
+	return m
+}
+
+// Init loads the generator profiles of the user.
+func (m PasswordGeneratorModel) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, m.loadCmd())
+}
+
+// loadCmd fetches the generator profiles of the user.
+func (m PasswordGeneratorModel) loadCmd() tea.Cmd {
+	v := m.vault
+	return func() tea.Msg {
+		profiles, err := v.GeneratorProfiles()
+		return generatorProfilesLoadedMsg{profiles: profiles, err: err}
+	}
+}
+
+// Update handles incoming messages and user input for the password generator screen.
+func (m PasswordGeneratorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case generatorProfilesLoadedMsg:
+		m.loaded = true
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.profiles = msg.profiles
+		for i, profile := range m.profiles {
+			if profile.Active {
+				m.profile = i
+				m.setPolicy(profile)
+			}
+		}
+		// the active profile may be a passphrase one, focusing another input on the row
+		return m, m.updateFocus()
+
+	case generatorProfileChangedMsg:
+		m.notice, m.err = msg.notice, msg.err
+		if msg.err != nil {
+			m.notice = ""
+			return m, nil
+		}
+		return m, m.loadCmd()
+
+	case tea.KeyMsg:
+		m.notice, m.err = "", nil
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, common.ChangeStateCmd(common.StateQuit)
+		case tea.KeyEnter:
+			return m, common.ChangeStateCmd(common.StateGoBack)
+		case tea.KeyUp:
+			m.row = (m.row - 1 + len(m.rows())) % len(m.rows())
+			return m, m.updateFocus()
+		case tea.KeyDown:
+			m.row = (m.row + 1) % len(m.rows())
+			return m, m.updateFocus()
+		case tea.KeyTab:
+			if len(m.profiles) > 0 {
+				m.profile = (m.profile + 1) % len(m.profiles)
+				m.setPolicy(m.profiles[m.profile])
+			}
+			return m, m.updateFocus()
+		case tea.KeyLeft, tea.KeyRight:
+			if m.adjust(msg.Type == tea.KeyRight) {
+				m.generate()
+				return m, nil
+			}
+		case tea.KeySpace:
+			if m.toggle() {
+				m.generate()
+				return m, nil
+			}
+		case tea.KeyCtrlG:
+			m.generate()
+			return m, nil
+		case tea.KeyCtrlS:
+			return m, m.saveCmd()
+		case tea.KeyCtrlD:
+			return m, m.deleteCmd()
+		}
+
+		var cmd tea.Cmd
+		switch m.focused() {
+		case generatorNameRow:
+			m.name, cmd = m.name.Update(msg)
+		case generatorSymbolSetRow:
+			previous := m.symbolSet.Value()
+			m.symbolSet, cmd = m.symbolSet.Update(msg)
+			if m.symbolSet.Value() != previous {
+				m.policy.SymbolSet = m.symbolSet.Value()
+				m.generate()
+			}
+		case generatorSeparatorRow:
+			previous := m.separator.Value()
+			m.separator, cmd = m.separator.Update(msg)
+			if m.separator.Value() != previous {
+				m.policy.Separator = m.separator.Value()
+				m.generate()
+			}
+		}
+		return m, cmd
+	}
+	return m, nil
+}
+
+// rows returns the rows shown in the mode of the policy in focus order.
+func (m PasswordGeneratorModel) rows() []int {
+	if m.policy.IsPassphrase() {
+		return generatorPassphraseRows
+	}
+	return generatorCharacterRows
+}
+
+// focused returns the focused row.
+func (m PasswordGeneratorModel) focused() int {
+	return m.rows()[m.row]
+}
+
+// setPolicy fills the screen with a profile and generates a password with it.
+func (m *PasswordGeneratorModel) setPolicy(profile model.GeneratorProfile) {
+	m.policy = profile.PasswordPolicy
+	m.name.SetValue(profile.Name)
+	m.name.CursorEnd()
+	m.symbolSet.SetValue(profile.SymbolSet)
+	m.symbolSet.CursorEnd()
+	m.separator.SetValue(profile.Separator)
+	m.separator.CursorEnd()
+	m.generate()
+}
+
+// generate refreshes the preview and its entropy, or keeps why the policy cannot generate passwords.
+func (m *PasswordGeneratorModel) generate() {
+	m.preview, m.invalid = utils.GeneratePasswordWithPolicy(m.policy)
+	if m.invalid == nil {
+		m.entropy, m.invalid = utils.PolicyEntropy(m.policy)
+	}
+}
+
+// adjust lengthens or shortens the password on the length row, changes the minimum count of the
+// character class on its row and the number of passphrase words, and cycles through the modes,
+// wordlists and capitalizations, reporting whether the focused row holds one of them.
+func (m *PasswordGeneratorModel) adjust(up bool) bool {
+	step := -1
+	if up {
+		step = 1
+	}
+	switch m.focused() {
+	case generatorModeRow:
+		m.switchMode()
+	case generatorWordsRow:
+		m.policy.Words = max(utils.MinWords, min(utils.MaxWords, m.policy.Words+step))
+	case generatorWordlistRow:
+		m.policy.Wordlist = cycle(model.Wordlists, m.policy.Wordlist, step)
+	case generatorCapitalizationRow:
+		m.policy.Capitalization = cycle(model.Capitalizations, m.policy.Capitalization, step)
+	case generatorLengthRow:
+		m.policy.Length = max(utils.MinLength, min(utils.MaxLength, m.policy.Length+step))
+	case generatorLowerRow:
+		m.policy.MinLower = max(0, m.policy.MinLower+step)
+	case generatorUpperRow:
+		m.policy.MinUpper = max(0, m.policy.MinUpper+step)
+	case generatorDigitsRow:
+		m.policy.MinDigits = max(0, m.policy.MinDigits+step)
+	case generatorSymbolsRow:
+		m.policy.MinSymbols = max(0, m.policy.MinSymbols+step)
+	default:
+		return false
+	}
+	return true
+}
+
+// toggle switches the option or the mode on the focused row, reporting whether the row holds one.
+func (m *PasswordGeneratorModel) toggle() bool {
+	switch m.focused() {
+	case generatorModeRow:
+		m.switchMode()
+	case generatorAppendDigitRow:
+		m.policy.AppendDigit = !m.policy.AppendDigit
+	case generatorAppendSymbolRow:
+		m.policy.AppendSymbol = !m.policy.AppendSymbol
+	case generatorLowerRow:
+		m.policy.Lower = !m.policy.Lower
+	case generatorUpperRow:
+		m.policy.Upper = !m.policy.Upper
+	case generatorDigitsRow:
+		m.policy.Digits = !m.policy.Digits
+	case generatorSymbolsRow:
+		m.policy.Symbols = !m.policy.Symbols
+	case generatorAmbiguousRow:
+		m.policy.ExcludeAmbiguous = !m.policy.ExcludeAmbiguous
+	case generatorNoRepeatRow:
+		m.policy.NoRepeat = !m.policy.NoRepeat
+	default:
+		return false
+	}
+	return true
+}
+
+// switchMode switches between generating characters and passphrases, filling in the default passphrase
+// settings for profiles saved before passphrases could be generated.
+func (m *PasswordGeneratorModel) switchMode() {
+	if m.policy.IsPassphrase() {
+		m.policy.Mode = model.GeneratorModeCharacters
+		return
+	}
+	m.policy.Mode = model.GeneratorModePassphrase
+	if m.policy.Words == 0 {
+		m.policy.Words = model.DefaultPasswordPolicy.Words
+	}
+	if m.policy.Wordlist == "" {
+		m.policy.Wordlist = model.DefaultPasswordPolicy.Wordlist
+	}
+	if m.policy.Capitalization == "" {
+		m.policy.Capitalization = model.DefaultPasswordPolicy.Capitalization
+	}
+}
+
+// cycle returns the value next to current in values, the first one when current is not among them.
+func cycle[T comparable](values []T, current T, step int) T {
+	i := slices.Index(values, current)
+	if i < 0 {
+		return values[0]
+	}
+	return values[(i+step+len(values))%len(values)]
+}
+
+// saveCmd saves the policy under the profile name and makes it the active profile.
+func (m PasswordGeneratorModel) saveCmd() tea.Cmd {
+	v, name, policy := m.vault, strings.TrimSpace(m.name.Value()), m.policy
+	return func() tea.Msg {
+		if err := v.SaveGeneratorProfile(name, policy); err != nil {
+			return generatorProfileChangedMsg{err: err}
+		}
+		if err := v.ActivateGeneratorProfile(name); err != nil {
+			return generatorProfileChangedMsg{err: err}
+		}
+		return generatorProfileChangedMsg{notice: fmt.Sprintf("Saved %s, passwords are now generated with it", name)}
+	}
+}
+
+// deleteCmd deletes the profile with the name on the screen.
+func (m PasswordGeneratorModel) deleteCmd() tea.Cmd {
+	v, name := m.vault, strings.TrimSpace(m.name.Value())
+	return func() tea.Msg {
+		if err := v.DeleteGeneratorProfile(name); err != nil {
+			return generatorProfileChangedMsg{err: err}
+		}
+		return generatorProfileChangedMsg{notice: fmt.Sprintf("Deleted %s", name)}
+	}
+}
+
+// updateFocus focuses the text input on the focused row and returns the blink command.
+func (m *PasswordGeneratorModel) updateFocus() tea.Cmd {
+	inputs := map[int]*textinput.Model{generatorNameRow: &m.name, generatorSymbolSetRow: &m.symbolSet, generatorSeparatorRow: &m.separator}
+	var cmd tea.Cmd
+	for row, input := range inputs {
+		if row == m.focused() {
+			input.PromptStyle = focusedStyle
+			input.TextStyle = focusedStyle
+			cmd = input.Focus()
+			continue
+		}
+		input.PromptStyle = noStyle
+		input.TextStyle = noStyle
+		input.Blur()
+	}
+	return cmd
+}
+
+// View renders the password generator screen UI.
+func (m PasswordGeneratorModel) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("PASSWORD GENERATOR") + "\n\n")
+
+	if !m.loaded {
+		b.WriteString("Loading generator profiles...\n")
+	}
+	names := make([]string, 0, len(m.profiles))
+	for _, profile := range m.profiles {
+		name := profile.Name
+		if profile.Active {
+			name += " (active)"
+		}
+		names = append(names, name)
+	}
+	if len(names) > 0 {
+		b.WriteString(blurredStyle.Render("Profiles: "+strings.Join(names, " · ")) + "\n\n")
+	}
+
+	check := func(on bool) string {
+		if on {
+			return "[x]"
+		}
+		return "[ ]"
+	}
+	mode := "Characters"
+	if m.policy.IsPassphrase() {
+		mode = "Passphrase"
+	}
+	views := map[int]string{
+		generatorNameRow:           m.name.View(),
+		generatorModeRow:           fmt.Sprintf("Mode: < %s >", mode),
+		generatorLengthRow:         fmt.Sprintf("Length: < %d >", m.policy.Length),
+		generatorLowerRow:          fmt.Sprintf("%s Lowercase letters, at least < %d >", check(m.policy.Lower), m.policy.MinLower),
+		generatorUpperRow:          fmt.Sprintf("%s Uppercase letters, at least < %d >", check(m.policy.Upper), m.policy.MinUpper),
+		generatorDigitsRow:         fmt.Sprintf("%s Digits, at least < %d >", check(m.policy.Digits), m.policy.MinDigits),
+		generatorSymbolsRow:        fmt.Sprintf("%s Symbols, at least < %d >", check(m.policy.Symbols), m.policy.MinSymbols),
+		generatorSymbolSetRow:      "Symbol set: " + m.symbolSet.View(),
+		generatorAmbiguousRow:      check(m.policy.ExcludeAmbiguous) + " Exclude ambiguous characters",
+		generatorNoRepeatRow:       check(m.policy.NoRepeat) + " No repeated characters",
+		generatorWordsRow:          fmt.Sprintf("Words: < %d >", m.policy.Words),
+		generatorWordlistRow:       fmt.Sprintf("Wordlist: < %s >", m.policy.Wordlist),
+		generatorSeparatorRow:      "Separator: " + m.separator.View(),
+		generatorCapitalizationRow: fmt.Sprintf("Capitalize: < %s >", m.policy.Capitalization),
+		generatorAppendDigitRow:    check(m.policy.AppendDigit) + " Append a digit",
+		generatorAppendSymbolRow:   check(m.policy.AppendSymbol) + " Append a symbol",
+	}
+	for i, row := range m.rows() {
+		switch {
+		case row == generatorNameRow || row == generatorSymbolSetRow || row == generatorSeparatorRow:
+			b.WriteString(views[row] + "\n")
+		case i == m.row:
+			b.WriteString(focusedStyle.Render("> "+views[row]) + "\n")
+		default:
+			b.WriteString("  " + views[row] + "\n")
+		}
+	}
+
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+	if m.invalid != nil {
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.invalid.Error()))
+	} else {
+		fmt.Fprintf(&b, "\nPreview: %s\n", focusedStyle.Render(m.preview))
+		b.WriteString(blurredStyle.Render(fmt.Sprintf("Entropy: %.0f bits", m.entropy)) + "\n")
+	}
+	if m.notice != "" {
+		okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateOk))
+		fmt.Fprintf(&b, "\n%s %s\n", validateOkPrefix, okStyle.Render(m.notice))
+	}
+	if m.err != nil {
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
+	}
+
+	help := "(↑/↓: Move, Space: Toggle, ←/→: Adjust, Tab: Next profile, Ctrl+G: Regenerate, " +
+		"Ctrl+S: Save as active, Ctrl+D: Delete, Enter: Back, Esc: Quit)"
+	b.WriteString(blurredStyle.Render("\n\n" + help))
+	return b.String()
+}
@@ -0,0 +1,134 @@
+//go:build e2e
+
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordGeneratorShouldSaveAndActivateProfile(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	v := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	tm := teatest.NewTestModel(t, NewPasswordGeneratorModel(v), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("default (active)"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	for range len(model.DefaultGeneratorProfileName) {
+		test.PressKey(tm, tea.KeyBackspace)
+	}
+	test.TypeString(tm, "long")
+	test.PressKey(tm, tea.KeyDown) // -> Mode
+	test.PressKey(tm, tea.KeyDown) // -> Length
+	for range 4 {
+		test.PressKey(tm, tea.KeyRight)
+	}
+	test.PressKey(tm, tea.KeyDown)  // -> Lowercase letters
+	test.PressKey(tm, tea.KeySpace) // Leave out lowercase letters
+	test.PressKey(tm, tea.KeyCtrlS)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Saved long, passwords are now generated with it")) &&
+			bytes.Contains(bts, []byte("long (active)"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm, ok := tm.FinalModel(t).(PasswordGeneratorModel)
+	require.True(t, ok)
+	assert.Len(t, fm.preview, 24)
+	assert.False(t, containsLower(fm.preview))
+
+	active, err := v.ActiveGeneratorProfile()
+	require.NoError(t, err)
+	assert.Equal(t, "long", active.Name)
+	assert.Equal(t, 24, active.Length)
+	assert.False(t, active.Lower)
+}
+
+func TestPasswordGeneratorShouldSwitchToPassphraseMode(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	v := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	tm := teatest.NewTestModel(t, NewPasswordGeneratorModel(v), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("default (active)"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyDown)  // -> Mode
+	test.PressKey(tm, tea.KeySpace) // Passphrase
+	test.PressKey(tm, tea.KeyDown)  // -> Words
+	test.PressKey(tm, tea.KeyLeft)
+	test.PressKey(tm, tea.KeyLeft)
+	test.PressKey(tm, tea.KeyDown) // -> Wordlist
+	test.PressKey(tm, tea.KeyDown) // -> Separator
+	test.PressKey(tm, tea.KeyBackspace)
+	test.TypeString(tm, ".")
+	test.PressKey(tm, tea.KeyCtrlS)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Saved default, passwords are now generated with it")) &&
+			bytes.Contains(bts, []byte("Entropy: 52 bits"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm, ok := tm.FinalModel(t).(PasswordGeneratorModel)
+	require.True(t, ok)
+	assert.Len(t, strings.Split(fm.preview, "."), 4)
+
+	active, err := v.ActiveGeneratorProfile()
+	require.NoError(t, err)
+	assert.True(t, active.IsPassphrase())
+	assert.Equal(t, 4, active.Words)
+	assert.Equal(t, ".", active.Separator)
+}
+
+func TestPasswordGeneratorShouldShowWhyPolicyCannotGeneratePasswords(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	v := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	tm := teatest.NewTestModel(t, NewPasswordGeneratorModel(v), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("default (active)"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	for range generatorDigitsRow {
+		test.PressKey(tm, tea.KeyDown)
+	}
+	for range model.DefaultPasswordPolicy.Length {
+		test.PressKey(tm, tea.KeyRight)
+	}
+	test.PressKey(tm, tea.KeyCtrlS)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("minimum counts add up to 24 characters, more than the length of 20")) &&
+			bytes.Contains(bts, []byte("invalid generator profile default"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm, ok := tm.FinalModel(t).(PasswordGeneratorModel)
+	require.True(t, ok)
+	assert.Empty(t, fm.preview)
+
+	profiles, err := v.GeneratorProfiles()
+	require.NoError(t, err)
+	assert.Equal(t, []model.GeneratorProfile{model.DefaultGeneratorProfile(v.UserID())}, profiles)
+}
+
+func containsLower(s string) bool {
+	return bytes.ContainsAny([]byte(s), "abcdefghijklmnopqrstuvwxyz")
+}
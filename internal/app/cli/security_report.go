@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"yubigo-pass/internal/app/breach"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/health"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// goodSecurityScore is the score from which the vault is shown as healthy.
+const goodSecurityScore = 80
+
+// securityReportLoadedMsg carries the security report of the vault.
+type securityReportLoadedMsg struct {
+	report health.Report
+	err    error
+}
+
+// SecurityReportModel is a Bubble Tea model showing the security report of the vault: its overall
+// score and the entries with weak, reused, old or breached passwords or insecure URLs. The selected
+// entry opens straight in the editor to fix it.
+type SecurityReportModel struct {
+	vault       vault.Vault
+	breachIndex string
+	breachAPI   string
+	report      health.Report
+	selected    int
+	loaded      bool
+	err         error
+}
+
+// NewSecurityReportModel creates a new instance of the SecurityReportModel.
+func NewSecurityReportModel(v vault.Vault) SecurityReportModel {
+	return SecurityReportModel{vault: v}
+}
+
+// WithBreachCheck returns the screen also checking passwords against the breach index at indexPath,
+// or the range API at apiURL without one.
+func (m SecurityReportModel) WithBreachCheck(indexPath, apiURL string) SecurityReportModel {
+	m.breachIndex = indexPath
+	m.breachAPI = apiURL
+	return m
+}
+
+// Init decrypts the entries of the vault and checks them.
+func (m SecurityReportModel) Init() tea.Cmd {
+	return securityReportCmd(m.vault, m.breachIndex, m.breachAPI)
+}
+
+// Update handles incoming messages and user input for the security report screen.
+func (m SecurityReportModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case securityReportLoadedMsg:
+		m.loaded = true
+		m.report = msg.report
+		m.err = msg.err
+		m.selected = max(0, min(m.selected, len(m.report.Findings)-1))
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, common.ChangeStateCmd(common.StateQuit)
+		case tea.KeyEnter:
+			return m, common.ChangeStateCmd(common.StateGoBack)
+		case tea.KeyUp:
+			m.selected = max(0, m.selected-1)
+			return m, nil
+		case tea.KeyDown:
+			m.selected = max(0, min(m.selected+1, len(m.report.Findings)-1))
+			return m, nil
+		case tea.KeyCtrlE:
+			if len(m.report.Findings) == 0 {
+				return m, nil
+			}
+			return m, common.EditPasswordCmd(m.report.Findings[m.selected].Entry)
+		}
+	}
+	return m, nil
+}
+
+// securityReportCmd checks the entries of the vault, against the breach data too when there is some.
+func securityReportCmd(v vault.Vault, indexPath, apiURL string) tea.Cmd {
+	return func() tea.Msg {
+		opts := health.DefaultOptions()
+		if indexPath != "" || apiURL != "" {
+			checker, err := breach.Open(indexPath, apiURL)
+			switch {
+			case err == nil:
+				defer checker.Close()
+				opts.Breaches = checker
+			case !errors.Is(err, breach.ErrNoData):
+				return securityReportLoadedMsg{err: err}
+			}
+		}
+		report, err := health.Check(v, opts)
+		if err == nil {
+			// the report is made of every password of the vault
+			err = v.Record(model.AuditRead, model.ClientTUI, model.Password{})
+		}
+		return securityReportLoadedMsg{report: report, err: err}
+	}
+}
+
+// View renders the security report screen UI.
+func (m SecurityReportModel) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("SECURITY REPORT") + "\n\n")
+
+	switch {
+	case !m.loaded:
+		b.WriteString("Checking your passwords...\n")
+	case m.err == nil && m.report.Checked == 0:
+		b.WriteString("No logins to check yet.\n")
+	case m.err == nil:
+		b.WriteString(m.reportView())
+	}
+
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
+	}
+
+	help := "(↑/↓: Select, Ctrl+E: Edit entry, Enter: Back, Esc: Quit)"
+	b.WriteString(blurredStyle.Render("\n\n" + help))
+	return b.String()
+}
+
+// reportView renders the score, the count of every issue and the entries with issues, with the
+// issues of the selected one.
+func (m SecurityReportModel) reportView() string {
+	var b strings.Builder
+	scoreColor := colorValidateErr
+	if m.report.Score >= goodSecurityScore {
+		scoreColor = colorValidateOk
+	}
+	scoreStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(scoreColor)).Bold(true)
+	fmt.Fprintf(&b, "Security score: %s\n", scoreStyle.Render(fmt.Sprintf("%d/100", m.report.Score)))
+
+	var counts []string
+	for _, issue := range []health.Issue{health.IssueBreached, health.IssueWeak, health.IssueReused, health.IssueOld, health.IssueInsecureURL} {
+		counts = append(counts, fmt.Sprintf("%d %s", m.report.Count(issue), issue))
+	}
+	b.WriteString(blurredStyle.Render(fmt.Sprintf("%d logins checked: %s", m.report.Checked, strings.Join(counts, " · "))) + "\n\n")
+
+	if len(m.report.Findings) == 0 {
+		okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateOk))
+		fmt.Fprintf(&b, "%s %s\n", validateOkPrefix, okStyle.Render("No issues found, every password is in good shape."))
+		return b.String()
+	}
+
+	for i, finding := range m.report.Findings {
+		line := finding.Entry.Title
+		if finding.Entry.Username != "" {
+			line += fmt.Sprintf(" (%s)", finding.Entry.Username)
+		}
+		issues := make([]string, 0, len(finding.Issues))
+		for _, issue := range finding.Issues {
+			issues = append(issues, string(issue))
+		}
+		line += " " + breachWarningStyle.Render(strings.Join(issues, ", "))
+		if i == m.selected {
+			b.WriteString(focusedStyle.Render("> ") + line + "\n")
+			continue
+		}
+		b.WriteString("  " + line + "\n")
+	}
+
+	b.WriteString("\n" + sectionStyle.Render("Issues of "+m.report.Findings[m.selected].Entry.Title) + "\n")
+	for _, description := range m.report.Findings[m.selected].Describe() {
+		b.WriteString("  • " + description + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+//go:build e2e
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityReportShouldShowScoreAndIssuesOfSelectedEntry(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	v := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	require.NoError(t, v.AddEntry(model.Password{Title: "Bank", Username: "me", Password: "password"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Shop", Username: "me", Password: "Vq7#mZ2!pLx9@wRt", Url: "http://shop.example"}, nil, "", nil))
+	tm := teatest.NewTestModel(t, NewSecurityReportModel(v), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Security score:")) && bytes.Contains(bts, []byte("73/100"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyDown)
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Issues of Shop")) && bytes.Contains(bts, []byte("insecure URL http://shop.example"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	vaulttest.AssertLastAuditEvent(t, v, model.AuditRead, model.ClientTUI)
+}
+
+func TestSecurityReportShouldOpenOffendingEntryInEditor(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	v := vaulttest.NewUser(t, db, "alice", test.RandomString())
+	require.NoError(t, v.AddEntry(model.Password{Title: "Bank", Username: "me", Password: "password"}, nil, "", nil))
+	m := NewSecurityReportModel(v)
+	loaded, _ := m.Update(m.Init()())
+
+	// when
+	_, cmd := loaded.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+
+	// then
+	require.NotNil(t, cmd)
+	msg, ok := cmd().(common.EditPasswordMsg)
+	require.True(t, ok)
+	assert.Equal(t, "Bank", msg.Entry.Title)
+}
+
+func TestSecurityReportShouldGoBack(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	m := NewSecurityReportModel(vaulttest.NewUser(t, db, "alice", test.RandomString()))
+
+	// when
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	// then
+	require.NotNil(t, cmd)
+	assert.Equal(t, common.StateMsg{State: common.StateGoBack}, cmd())
+}
@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/httpsync"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// conflictsLoadedMsg carries the entries in conflict with the version of another device.
+type conflictsLoadedMsg struct {
+	conflicts []httpsync.Conflict
+	err       error
+}
+
+// conflictResolvedMsg reports the outcome of resolving a conflict.
+type conflictResolvedMsg struct {
+	title string
+	err   error
+}
+
+// SyncConflictsModel is a Bubble Tea model for resolving the entries a sync found edited on this
+// device and another one concurrently. It shows both versions of the selected entry side by side
+// and keeps the one the user picks.
+type SyncConflictsModel struct {
+	client    httpsync.Client
+	vault     vault.Vault
+	conflicts []httpsync.Conflict
+	selected  int
+	revealed  bool
+	loaded    bool
+	working   bool
+	notice    string
+	err       error
+}
+
+// NewSyncConflictsModel creates a new instance of the SyncConflictsModel.
+func NewSyncConflictsModel(client httpsync.Client, v vault.Vault) SyncConflictsModel {
+	return SyncConflictsModel{
+		client: client,
+		vault:  v,
+	}
+}
+
+// Init loads the conflicts left by the last syncs.
+func (m SyncConflictsModel) Init() tea.Cmd {
+	return loadConflictsCmd(m.client, m.vault)
+}
+
+// Update handles incoming messages and user input for the sync conflicts screen.
+func (m SyncConflictsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case conflictsLoadedMsg:
+		m.loaded = true
+		m.working = false
+		m.conflicts = msg.conflicts
+		m.err = msg.err
+		m.selected = max(0, min(m.selected, len(m.conflicts)-1))
+		return m, nil
+
+	case conflictResolvedMsg:
+		if msg.err != nil {
+			m.working = false
+			m.err = msg.err
+			return m, nil
+		}
+		m.notice = fmt.Sprintf("Resolved %s", msg.title)
+		return m, loadConflictsCmd(m.client, m.vault)
+
+	case tea.KeyMsg:
+		if m.working {
+			return m, nil
+		}
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, common.ChangeStateCmd(common.StateQuit)
+		case tea.KeyEnter:
+			return m, common.ChangeStateCmd(common.StateGoBack)
+		case tea.KeyCtrlS:
+			m.revealed = !m.revealed
+			return m, nil
+		case tea.KeyUp:
+			m.selected = max(0, m.selected-1)
+			return m, nil
+		case tea.KeyDown:
+			m.selected = max(0, min(m.selected+1, len(m.conflicts)-1))
+			return m, nil
+		case tea.KeyRunes:
+			switch string(msg.Runes) {
+			case "m":
+				return m.resolve(httpsync.KeepLocal)
+			case "t":
+				return m.resolve(httpsync.KeepRemote)
+			}
+		}
+	}
+	return m, nil
+}
+
+// resolve keeps one version of the selected entry.
+func (m SyncConflictsModel) resolve(resolution httpsync.Resolution) (tea.Model, tea.Cmd) {
+	if len(m.conflicts) == 0 {
+		return m, nil
+	}
+	m.working = true
+	m.notice = ""
+	m.err = nil
+	conflict := m.conflicts[m.selected]
+	client, v := m.client, m.vault
+	return m, func() tea.Msg {
+		return conflictResolvedMsg{title: conflict.Local.Title, err: client.Resolve(v, conflict.ID, resolution)}
+	}
+}
+
+// loadConflictsCmd reads the conflicts left by the last syncs.
+func loadConflictsCmd(client httpsync.Client, v vault.Vault) tea.Cmd {
+	return func() tea.Msg {
+		conflicts, err := client.Conflicts(v)
+		return conflictsLoadedMsg{conflicts: conflicts, err: err}
+	}
+}
+
+// View renders the sync conflicts screen UI.
+func (m SyncConflictsModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("SYNC CONFLICTS") + "\n\n")
+	switch {
+	case !m.loaded:
+		b.WriteString("Loading...\n")
+	case len(m.conflicts) == 0 && m.err == nil:
+		b.WriteString("No conflicts, every entry is in sync.\n")
+	case len(m.conflicts) > 0:
+		b.WriteString(blurredStyle.Render("These entries were edited on this device and another one, keep one version of each.") + "\n\n")
+		for i, conflict := range m.conflicts {
+			line := conflict.Local.Title
+			if i == m.selected {
+				b.WriteString(focusedStyle.Render("> "+line) + "\n")
+				continue
+			}
+			b.WriteString("  " + line + "\n")
+		}
+		b.WriteRune('\n')
+		conflict := m.conflicts[m.selected]
+		b.WriteString(viewVersions(
+			version{header: "THIS DEVICE", entry: conflict.Local, modified: conflict.LocalModified},
+			version{header: "OTHER DEVICE", entry: conflict.Remote, modified: conflict.RemoteModified},
+			m.revealed,
+		))
+	}
+
+	if m.notice != "" {
+		okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateOk))
+		fmt.Fprintf(&b, "\n%s %s\n", validateOkPrefix, okStyle.Render(m.notice))
+	}
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
+	}
+
+	help := "(↑/↓: Select, m: Keep this device's, t: Keep the other device's, Ctrl+S: Show/Hide secrets, Enter: Back, Esc: Quit)"
+	b.WriteString(blurredStyle.Render("\n\n" + help))
+	return b.String()
+}
+
+// version is one side of a conflicting entry shown by viewVersions.
+type version struct {
+	header   string
+	entry    archive.Entry
+	modified time.Time
+}
+
+// viewVersions renders both versions of a conflicting entry side by side, marking the values that differ.
+func viewVersions(ours, theirs version, revealed bool) string {
+	type row struct {
+		label, local, remote string
+		secret, differs      bool
+	}
+	value := func(label, local, remote string) row {
+		return row{label: label, local: local, remote: remote, differs: local != remote}
+	}
+	rows := []row{value("Title", ours.entry.Title, theirs.entry.Title)}
+	for _, field := range model.ItemType(ours.entry.Type).Fields() {
+		r := value(field.Label, ours.entry.Values[field.Key], theirs.entry.Values[field.Key])
+		r.secret = field.Secret
+		rows = append(rows, r)
+	}
+	fields := value("Custom fields", fieldNames(ours.entry.Fields), fieldNames(theirs.entry.Fields))
+	// values of custom fields may be secret, only their names are shown
+	fields.differs = !slices.Equal(ours.entry.Fields, theirs.entry.Fields)
+	rows = append(rows,
+		value("Notes", ours.entry.Notes, theirs.entry.Notes),
+		fields,
+		value("Folder", ours.entry.Folder, theirs.entry.Folder),
+		value("Tags", strings.Join(ours.entry.Tags, ", "), strings.Join(theirs.entry.Tags, ", ")),
+		row{label: "Edited", local: ours.modified.Local().Format(time.DateTime), remote: theirs.modified.Local().Format(time.DateTime)},
+	)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "  %-22s %-32s %s\n", "", ours.header, theirs.header)
+	for _, r := range rows {
+		if r.local == "" && r.remote == "" && !r.differs {
+			continue
+		}
+		local, remote := oneLine(r.local), oneLine(r.remote)
+		if r.secret && !revealed {
+			local, remote = maskSecret(r.local), maskSecret(r.remote)
+		}
+		line := fmt.Sprintf("%-22s %-32s %s", r.label, local, remote)
+		if r.differs {
+			b.WriteString(focusedStyle.Render("≠ "+line) + "\n")
+			continue
+		}
+		b.WriteString("  " + line + "\n")
+	}
+	return b.String()
+}
+
+// fieldNames lists the names of custom fields.
+func fieldNames(fields []archive.Field) string {
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// oneLine shortens a value to fit a column of the side by side view.
+func oneLine(value string) string {
+	value = strings.ReplaceAll(value, "\n", " ")
+	if len([]rune(value)) > 30 {
+		return string([]rune(value)[:29]) + "…"
+	}
+	return value
+}
+
+// maskSecret hides a secret value, keeping whether there is one.
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "••••••••"
+}
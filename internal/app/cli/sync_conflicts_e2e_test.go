@@ -0,0 +1,136 @@
+//go:build e2e
+
+package cli
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/httpsync"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/services"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSyncConflict syncs two devices through an in-process sync server after editing an entry on
+// both, returning the client and vault of the device left with the conflict
+func setupSyncConflict(t *testing.T) (httpsync.Client, vault.Vault) {
+	t.Helper()
+	server := httptest.NewServer(httpsync.NewServer(t.TempDir(), ""))
+	t.Cleanup(server.Close)
+
+	device := func() (httpsync.Client, vault.Vault) {
+		db, err := test.SetupTestDB()
+		require.NoError(t, err, "Failed to set up test database")
+		t.Cleanup(func() { test.TeardownTestDB(db) })
+		session := utils.NewSession(uuid.New().String(), "master", test.RandomString())
+		client := httpsync.ForUser(t.TempDir(), session.GetUserID(), "alice", server.URL, "")
+		return client, vault.New(database.NewStore(db), session)
+	}
+	edit := func(v vault.Vault, password string) {
+		time.Sleep(2 * time.Millisecond)
+		entry, err := v.FindEntry("GitHub", "octocat")
+		require.NoError(t, err)
+		details, err := v.Reveal(entry)
+		require.NoError(t, err)
+		details.Item.Values["password"] = password
+		require.NoError(t, v.UpdateItem(details.Entry, details.Item, "", nil))
+	}
+
+	laptopClient, laptop := device()
+	desktopClient, desktop := device()
+	require.NoError(t, laptop.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "hunter2"}, nil, "", nil))
+	for _, sync := range []func() (httpsync.Report, error){
+		func() (httpsync.Report, error) { return laptopClient.Sync(laptop) },
+		func() (httpsync.Report, error) { return desktopClient.Sync(desktop) },
+		func() (httpsync.Report, error) { edit(laptop, "from laptop"); return laptopClient.Sync(laptop) },
+		func() (httpsync.Report, error) { edit(desktop, "from desktop"); return desktopClient.Sync(desktop) },
+	} {
+		_, err := sync()
+		require.NoError(t, err)
+	}
+	return desktopClient, desktop
+}
+
+func TestSyncConflictsShouldShowBothVersionsAndKeepPickedOne(t *testing.T) {
+	// given
+	client, v := setupSyncConflict(t)
+	tm := teatest.NewTestModel(t, NewSyncConflictsModel(client, v), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("SYNC CONFLICTS")) &&
+			bytes.Contains(bts, []byte("> GitHub")) &&
+			bytes.Contains(bts, []byte("OTHER DEVICE"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.TypeString(tm, "t")
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Resolved GitHub")) &&
+			bytes.Contains(bts, []byte("No conflicts, every entry is in sync."))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+
+	entry, err := v.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	password, err := v.RevealPassword(entry)
+	require.NoError(t, err)
+	assert.Equal(t, "from laptop", password)
+}
+
+func TestSyncConflictsShouldMaskSecretsUntilRevealed(t *testing.T) {
+	// given
+	client, v := setupSyncConflict(t)
+	m := NewSyncConflictsModel(client, v)
+	updated, _ := m.Update(loadConflictsCmd(client, v)())
+	m = updated.(SyncConflictsModel)
+
+	// when
+	masked := m.View()
+	m.revealed = true
+	revealed := m.View()
+
+	// then
+	assert.NotContains(t, masked, "from desktop")
+	assert.Contains(t, masked, "≠ Password")
+	assert.Contains(t, revealed, "from desktop")
+	assert.Contains(t, revealed, "from laptop")
+}
+
+func TestAppModelSyncShouldRecordReceivedEntries(t *testing.T) {
+	// given
+	server := httptest.NewServer(httpsync.NewServer(t.TempDir(), ""))
+	defer server.Close()
+	laptopDB, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed to set up test database")
+	defer test.TeardownTestDB(laptopDB)
+	desktopDB, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed to set up test database")
+	defer test.TeardownTestDB(desktopDB)
+	laptopSession := utils.NewSession(uuid.New().String(), "master", test.RandomString())
+	laptop := vault.New(database.NewStore(laptopDB), laptopSession)
+	require.NoError(t, laptop.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "hunter2"}, nil, "", nil))
+	_, err = httpsync.ForUser(t.TempDir(), laptopSession.GetUserID(), "alice", server.URL, "").Sync(laptop)
+	require.NoError(t, err)
+	m := NewAppModel(services.Container{Store: database.NewStore(desktopDB), SyncDir: t.TempDir(), SyncServer: server.URL})
+	m.session = utils.NewSession(uuid.New().String(), "master", test.RandomString())
+	m.username = "alice"
+
+	// when
+	msg := m.syncCmd()()
+
+	// then
+	assert.Equal(t, syncedMsg{}, msg)
+	vaulttest.AssertLastAuditEvent(t, vault.New(m.container.Store, m.session), model.AuditImported, model.ClientTUI)
+}
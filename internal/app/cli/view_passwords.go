@@ -0,0 +1,488 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"yubigo-pass/internal/app/breach"
+	"yubigo-pass/internal/app/common"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/search"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sessionStateViewPasswords defines the focus state within the view passwords screen.
+type sessionStateViewPasswords uint
+
+const (
+	viewPasswordsSidebarFocused sessionStateViewPasswords = iota
+	viewPasswordsEntriesFocused
+	viewPasswordsBackFocused
+)
+
+// sidebarNodeKind tells what a sidebar row filters the entries by.
+type sidebarNodeKind uint
+
+const (
+	sidebarAllEntries sidebarNodeKind = iota
+	sidebarFolder
+	sidebarTag
+)
+
+// sidebarNode is a single selectable row of the folder and tag sidebar.
+type sidebarNode struct {
+	kind  sidebarNodeKind
+	id    string
+	label string
+	depth int
+}
+
+// sidebarLoadedMsg carries the decrypted folders and tags of the vault.
+type sidebarLoadedMsg struct {
+	nodes []sidebarNode
+	err   error
+}
+
+// searchIndexLoadedMsg carries the search index built from the decrypted entries.
+type searchIndexLoadedMsg struct {
+	index search.Searcher
+	err   error
+}
+
+// entriesLoadedMsg carries the entries matching the selected sidebar row.
+type entriesLoadedMsg struct {
+	node    sidebarNode
+	entries []model.Password
+	err     error
+}
+
+// breachesCheckedMsg carries how often the passwords of the vault entries were seen in breaches, by entry ID.
+type breachesCheckedMsg struct {
+	counts map[string]int
+	err    error
+}
+
+var (
+	sidebarStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("240")).
+			Padding(0, 1).
+			Width(32)
+	entriesStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("240")).
+			Padding(0, 1).
+			Width(64)
+	focusedPaneBorderColor = lipgloss.Color("205")
+	sectionStyle           = blurredStyle.Copy().Bold(true)
+	breachWarningStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+)
+
+// ViewPasswordsModel is a Bubble Tea model for browsing the user's passwords.
+// It shows a sidebar with the folder tree and tags, and lists the entries of the selected row.
+// A search box narrows the listed entries down to the best matches of a query.
+// With breach data set up, entries whose password was seen in breaches are flagged.
+type ViewPasswordsModel struct {
+	state        sessionStateViewPasswords
+	nodes        []sidebarNode
+	nodeIndex    int
+	entries      []model.Password
+	matches      []model.Password
+	entryIndex   int
+	searchInput  textinput.Model
+	searching    bool
+	index        search.Searcher
+	showErr      bool
+	err          error
+	vault        vault.Vault
+	selectedNode sidebarNode
+	breachIndex  string
+	breachAPI    string
+	breached     map[string]int
+}
+
+// NewViewPasswordsModel creates a new instance of the ViewPasswordsModel.
+func NewViewPasswordsModel(v vault.Vault) ViewPasswordsModel {
+	searchInput := textinput.New()
+	searchInput.Cursor.Style = cursorStyle
+	searchInput.Placeholder = "press / to search titles, usernames, URLs, tags and notes"
+	searchInput.Prompt = "Search: "
+	searchInput.CharLimit = 128
+
+	return ViewPasswordsModel{
+		state:       viewPasswordsSidebarFocused,
+		nodes:       []sidebarNode{{kind: sidebarAllEntries, label: "All entries"}},
+		searchInput: searchInput,
+		vault:       v,
+	}
+}
+
+// WithBreachCheck returns the screen checking the passwords of the entries against the breach index at
+// indexPath, or the range API at apiURL without one.
+func (m ViewPasswordsModel) WithBreachCheck(indexPath, apiURL string) ViewPasswordsModel {
+	m.breachIndex = indexPath
+	m.breachAPI = apiURL
+	return m
+}
+
+// Init loads the sidebar, the entries of the vault and the search index, and checks the passwords for breaches.
+func (m ViewPasswordsModel) Init() tea.Cmd {
+	cmds := []tea.Cmd{loadSidebarCmd(m.vault), loadEntriesCmd(m.vault, m.nodes[0]), loadSearchIndexCmd(m.vault)}
+	if m.breachIndex != "" || m.breachAPI != "" {
+		cmds = append(cmds, checkBreachesCmd(m.vault, m.breachIndex, m.breachAPI))
+	}
+	return tea.Batch(cmds...)
+}
+
+// Update handles incoming messages and user input for the view passwords screen.
+func (m ViewPasswordsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case sidebarLoadedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("failed to load folders and tags: %w", msg.err)
+			m.showErr = true
+			return m, nil
+		}
+		m.nodes = msg.nodes
+		if m.nodeIndex >= len(m.nodes) {
+			m.nodeIndex = 0
+		}
+		return m, nil
+
+	case entriesLoadedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("failed to load passwords: %w", msg.err)
+			m.showErr = true
+			return m, nil
+		}
+		m.selectedNode = msg.node
+		m.entries = msg.entries
+		m.entryIndex = 0
+		m.applySearch()
+		return m, nil
+
+	case searchIndexLoadedMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("failed to build search index: %w", msg.err)
+			m.showErr = true
+			return m, nil
+		}
+		m.index = msg.index
+		m.applySearch()
+		return m, nil
+
+	case breachesCheckedMsg:
+		if errors.Is(msg.err, breach.ErrNoData) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.err = fmt.Errorf("failed to check passwords for breaches: %w", msg.err)
+			m.showErr = true
+			return m, nil
+		}
+		m.breached = msg.counts
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		if msg.String() == "/" {
+			m.searching = true
+			return m, m.searchInput.Focus()
+		}
+
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, common.ChangeStateCmd(common.StateQuit)
+
+		case tea.KeyTab:
+			m.state = (m.state + 1) % 3
+			return m, nil
+
+		case tea.KeyShiftTab:
+			m.state = (m.state + 2) % 3
+			return m, nil
+
+		case tea.KeyUp, tea.KeyDown:
+			return m.moveCursor(msg.Type == tea.KeyUp)
+
+		case tea.KeyEnter:
+			switch m.state {
+			case viewPasswordsBackFocused:
+				return m, common.ChangeStateCmd(common.StateGoBack)
+			case viewPasswordsSidebarFocused:
+				m.state = viewPasswordsEntriesFocused
+			case viewPasswordsEntriesFocused:
+				visible := m.visibleEntries()
+				if m.entryIndex < len(visible) {
+					return m, common.ShowPasswordCmd(visible[m.entryIndex])
+				}
+			}
+			return m, nil
+		}
+	}
+
+	return m, nil
+}
+
+// updateSearch handles input while the search box is focused. Enter moves to the matching
+// entries, Esc clears the query and leaves the search box.
+func (m ViewPasswordsModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, common.ChangeStateCmd(common.StateQuit)
+	case tea.KeyEsc:
+		m.searchInput.Reset()
+		m.searchInput.Blur()
+		m.searching = false
+		m.applySearch()
+		return m, nil
+	case tea.KeyEnter, tea.KeyDown, tea.KeyTab:
+		m.searchInput.Blur()
+		m.searching = false
+		m.state = viewPasswordsEntriesFocused
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	m.applySearch()
+	return m, cmd
+}
+
+// applySearch ranks the entries of the selected sidebar row by the current query.
+func (m *ViewPasswordsModel) applySearch() {
+	m.entryIndex = 0
+	query := strings.TrimSpace(m.searchInput.Value())
+	if query == "" || m.index == nil {
+		m.matches = nil
+		return
+	}
+	m.matches = search.Rank(m.entries, m.index.Search(query, 0))
+}
+
+// visibleEntries returns the entries listed in the entries pane, narrowed down by the search query.
+func (m ViewPasswordsModel) visibleEntries() []model.Password {
+	if m.matches != nil {
+		return m.matches
+	}
+	return m.entries
+}
+
+// moveCursor moves the cursor of the focused pane, reloading entries when the sidebar selection changes.
+func (m ViewPasswordsModel) moveCursor(up bool) (tea.Model, tea.Cmd) {
+	switch m.state {
+	case viewPasswordsSidebarFocused:
+		if len(m.nodes) == 0 {
+			return m, nil
+		}
+		if up {
+			m.nodeIndex = (m.nodeIndex - 1 + len(m.nodes)) % len(m.nodes)
+		} else {
+			m.nodeIndex = (m.nodeIndex + 1) % len(m.nodes)
+		}
+		return m, loadEntriesCmd(m.vault, m.nodes[m.nodeIndex])
+
+	case viewPasswordsEntriesFocused:
+		count := len(m.visibleEntries())
+		if count == 0 {
+			return m, nil
+		}
+		if up {
+			m.entryIndex = (m.entryIndex - 1 + count) % count
+		} else {
+			m.entryIndex = (m.entryIndex + 1) % count
+		}
+	}
+	return m, nil
+}
+
+// View renders the view passwords screen UI.
+func (m ViewPasswordsModel) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("YOUR PASSWORDS") + "\n\n")
+	b.WriteString(m.searchInput.View() + "\n")
+
+	sidebar := sidebarStyle
+	entries := entriesStyle
+	switch m.state {
+	case viewPasswordsSidebarFocused:
+		sidebar = sidebar.Copy().BorderForeground(focusedPaneBorderColor)
+	case viewPasswordsEntriesFocused:
+		entries = entries.Copy().BorderForeground(focusedPaneBorderColor)
+	}
+
+	panes := lipgloss.JoinHorizontal(lipgloss.Top,
+		sidebar.Render(m.sidebarView()),
+		" ",
+		entries.Render(m.entriesView()),
+	)
+	b.WriteString(panes)
+
+	backBtn := blurredBackButton
+	if m.state == viewPasswordsBackFocused {
+		backBtn = focusedBackButton
+	}
+	if len(m.breached) > 0 {
+		fmt.Fprintf(&b, "\n%s", breachWarningStyle.Render(fmt.Sprintf("⚠ %d of your passwords were seen in breaches, change them", len(m.breached))))
+	}
+	fmt.Fprintf(&b, "\n\n%s", backBtn)
+
+	if m.err != nil && m.showErr {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorValidateErr))
+		fmt.Fprintf(&b, "\n%s %s\n", validateErrPrefix, errorStyle.Render(m.err.Error()))
+	}
+
+	help := blurredStyle.Render("\n\n(Tab/Shift+Tab: Switch pane, ↑/↓: Move, Enter: Select, /: Search, Esc: Quit)")
+	b.WriteString(help)
+
+	return b.String()
+}
+
+// sidebarView renders the folder tree and the tags.
+func (m ViewPasswordsModel) sidebarView() string {
+	var b strings.Builder
+	lastKind := sidebarAllEntries
+	for i, node := range m.nodes {
+		if node.kind != lastKind {
+			switch node.kind {
+			case sidebarFolder:
+				b.WriteString("\n" + sectionStyle.Render("Folders") + "\n")
+			case sidebarTag:
+				b.WriteString("\n" + sectionStyle.Render("Tags") + "\n")
+			}
+			lastKind = node.kind
+		}
+
+		label := node.label
+		switch node.kind {
+		case sidebarFolder:
+			label = strings.Repeat("  ", node.depth) + "▸ " + label
+		case sidebarTag:
+			label = "#" + label
+		}
+
+		if i == m.nodeIndex {
+			b.WriteString(selectedItemStyle.Copy().PaddingLeft(0).Render("> " + label))
+		} else {
+			b.WriteString("  " + label)
+		}
+		b.WriteRune('\n')
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// entriesView renders the entries of the selected sidebar row.
+func (m ViewPasswordsModel) entriesView() string {
+	visible := m.visibleEntries()
+	if len(visible) == 0 {
+		if m.matches != nil {
+			return blurredStyle.Render("No matches.")
+		}
+		return blurredStyle.Render("No passwords here yet.")
+	}
+
+	var b strings.Builder
+	for i, entry := range visible {
+		line := entry.Title
+		if entry.Username != "" {
+			line += fmt.Sprintf(" (%s)", entry.Username)
+		}
+		if entry.ItemType() != model.ItemTypeLogin {
+			line += " " + blurredStyle.Render("["+entry.ItemType().Label()+"]")
+		}
+		if entry.Url != "" {
+			line += " " + blurredStyle.Render(entry.Url)
+		}
+		if m.breached[entry.ID] > 0 {
+			line += " " + breachWarningStyle.Render("⚠ breached")
+		}
+		if i == m.entryIndex && m.state == viewPasswordsEntriesFocused {
+			b.WriteString(selectedItemStyle.Copy().PaddingLeft(0).Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteRune('\n')
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// buildSidebarNodes flattens the folder tree and the tags into sidebar rows.
+func buildSidebarNodes(tree vault.FolderTree, tags []vault.Tag) []sidebarNode {
+	nodes := []sidebarNode{{kind: sidebarAllEntries, label: "All entries"}}
+	tree.Walk(func(f vault.Folder, depth int) {
+		nodes = append(nodes, sidebarNode{kind: sidebarFolder, id: f.ID, label: f.Name, depth: depth})
+	})
+	for _, tag := range tags {
+		nodes = append(nodes, sidebarNode{kind: sidebarTag, id: tag.ID, label: tag.Name})
+	}
+	return nodes
+}
+
+// loadSidebarCmd returns a command that decrypts the folders and tags of the vault.
+func loadSidebarCmd(v vault.Vault) tea.Cmd {
+	return func() tea.Msg {
+		tree, err := v.Folders()
+		if err != nil {
+			return sidebarLoadedMsg{err: err}
+		}
+		tags, err := v.Tags()
+		if err != nil {
+			return sidebarLoadedMsg{err: err}
+		}
+		return sidebarLoadedMsg{nodes: buildSidebarNodes(tree, tags)}
+	}
+}
+
+// loadSearchIndexCmd returns a command that builds the search index from the decrypted entries.
+func loadSearchIndexCmd(v vault.Vault) tea.Cmd {
+	return func() tea.Msg {
+		index, err := search.BuildIndex(v)
+		if err != nil {
+			return searchIndexLoadedMsg{err: err}
+		}
+		return searchIndexLoadedMsg{index: index}
+	}
+}
+
+// loadEntriesCmd returns a command that fetches the entries matching a sidebar row.
+func loadEntriesCmd(v vault.Vault, node sidebarNode) tea.Cmd {
+	return func() tea.Msg {
+		var entries []model.Password
+		var err error
+		switch node.kind {
+		case sidebarFolder:
+			entries, err = v.EntriesInFolder(node.id)
+		case sidebarTag:
+			entries, err = v.EntriesWithTag(node.id)
+		default:
+			entries, err = v.Entries()
+		}
+		return entriesLoadedMsg{node: node, entries: entries, err: err}
+	}
+}
+
+// checkBreachesCmd returns a command that looks the passwords of the vault up in the breach data.
+func checkBreachesCmd(v vault.Vault, indexPath, apiURL string) tea.Cmd {
+	return func() tea.Msg {
+		checker, err := breach.Open(indexPath, apiURL)
+		if err != nil {
+			return breachesCheckedMsg{err: err}
+		}
+		defer checker.Close()
+		findings, err := breach.Check(v, checker)
+		if err != nil {
+			return breachesCheckedMsg{err: err}
+		}
+		counts := make(map[string]int, len(findings))
+		for _, finding := range findings {
+			counts[finding.Entry.ID] = finding.Count
+		}
+		return breachesCheckedMsg{counts: counts}
+	}
+}
@@ -0,0 +1,195 @@
+//go:build e2e
+
+package cli
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/breach"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/search"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewPasswordsShouldShowFoldersTagsAndEntries(t *testing.T) {
+	// given
+	db, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed to set up test database")
+	defer test.TeardownTestDB(db)
+
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := vault.New(database.NewStore(db), session)
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "octocat", Password: "x"}, nil, "Work/Code", []string{"dev"}))
+	require.NoError(t, v.AddEntry(model.Password{Title: "bank", Username: "me", Password: "x"}, nil, "", nil))
+
+	// when
+	tm := teatest.NewTestModel(t, NewViewPasswordsModel(v), teatest.WithInitialTermSize(300, 100))
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("YOUR PASSWORDS")) &&
+			bytes.Contains(bts, []byte("Code")) &&
+			bytes.Contains(bts, []byte("#dev")) &&
+			bytes.Contains(bts, []byte("bank"))
+	}, teatest.WithDuration(2*time.Second))
+
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+}
+
+func TestViewPasswordsShouldFilterEntriesBySelectedFolder(t *testing.T) {
+	// given
+	db, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed to set up test database")
+	defer test.TeardownTestDB(db)
+
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := vault.New(database.NewStore(db), session)
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "octocat", Password: "x"}, nil, "Work", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "bank", Username: "me", Password: "x"}, nil, "", nil))
+
+	tm := teatest.NewTestModel(t, NewViewPasswordsModel(v), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("Work"))
+	}, teatest.WithDuration(2*time.Second))
+
+	// when
+	test.PressKey(tm, tea.KeyDown) // -> Work folder
+	time.Sleep(100 * time.Millisecond)
+
+	// then
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(ViewPasswordsModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	require.Len(t, m.entries, 1)
+	assert.Equal(t, "github", m.entries[0].Title)
+	assert.Equal(t, sidebarFolder, m.selectedNode.kind)
+}
+
+func TestViewPasswordsShouldGoBack(t *testing.T) {
+	// given
+	tm := teatest.NewTestModel(
+		t,
+		NewViewPasswordsModel(vault.New(test.NewStoreExecutorMock(), utils.NewEmptySession())),
+		teatest.WithInitialTermSize(300, 100),
+	)
+
+	// when
+	test.PressKey(tm, tea.KeyTab)   // -> Entries
+	test.PressKey(tm, tea.KeyTab)   // -> Back button
+	test.PressKey(tm, tea.KeyEnter) // Go back
+
+	// then
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(ViewPasswordsModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	assert.Equal(t, viewPasswordsBackFocused, m.state)
+	assert.NoError(t, m.err)
+}
+
+func TestViewPasswordsShouldSearchEntries(t *testing.T) {
+	// given
+	db, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed to set up test database")
+	defer test.TeardownTestDB(db)
+
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := vault.New(database.NewStore(db), session)
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "octocat", Password: "x"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "router", Username: "admin", Password: "x", Notes: "wpa2 passphrase"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "bank", Username: "me", Password: "x"}, nil, "", nil))
+
+	tm := teatest.NewTestModel(t, NewViewPasswordsModel(v), teatest.WithInitialTermSize(300, 100))
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("bank"))
+	}, teatest.WithDuration(2*time.Second))
+	time.Sleep(100 * time.Millisecond)
+
+	// when
+	test.TypeString(tm, "/")
+	test.TypeString(tm, "pasphrase")
+	test.PressKey(tm, tea.KeyEnter)
+	time.Sleep(100 * time.Millisecond)
+
+	// then
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(ViewPasswordsModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	visible := m.visibleEntries()
+	require.Len(t, visible, 1)
+	assert.Equal(t, "router", visible[0].Title)
+	assert.Equal(t, viewPasswordsEntriesFocused, m.state)
+}
+
+func TestViewPasswordsShouldClearSearchOnEscape(t *testing.T) {
+	// given
+	m := NewViewPasswordsModel(vault.Vault{})
+	m.entries = []model.Password{{ID: "1", Title: "github"}, {ID: "2", Title: "bank"}}
+	updated, _ := m.Update(searchIndexLoadedMsg{index: search.NewIndex([]search.Document{{ID: "1", Title: "github"}, {ID: "2", Title: "bank"}})})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("bank")})
+	require.Len(t, updated.(ViewPasswordsModel).visibleEntries(), 1)
+
+	// when
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	// then
+	assert.Nil(t, cmd)
+	m = updated.(ViewPasswordsModel)
+	assert.False(t, m.searching)
+	assert.Empty(t, m.searchInput.Value())
+	assert.Len(t, m.visibleEntries(), 2)
+}
+
+func TestViewPasswordsShouldFlagEntriesSeenInBreaches(t *testing.T) {
+	// given
+	db, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed to set up test database")
+	defer test.TeardownTestDB(db)
+
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := vault.New(database.NewStore(db), session)
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "octocat", Password: "hunter2"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "bank", Username: "me", Password: "8fj#2kLq!0zPw"}, nil, "", nil))
+	hash := breach.HashPassword("hunter2")
+	breached := strings.ToUpper(hex.EncodeToString(hash[:]))
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/range/"+breached[:5] {
+			fmt.Fprintf(w, "%s:25592\r\n", breached[5:])
+		}
+	}))
+	defer api.Close()
+	missingIndex := filepath.Join(t.TempDir(), breach.IndexFileName)
+
+	// when
+	tm := teatest.NewTestModel(t, NewViewPasswordsModel(v).WithBreachCheck(missingIndex, api.URL), teatest.WithInitialTermSize(300, 100))
+
+	// then
+	teatest.WaitFor(t, tm.Output(), func(bts []byte) bool {
+		return bytes.Contains(bts, []byte("1 of your passwords were seen in breaches"))
+	}, teatest.WithDuration(2*time.Second))
+	require.NoError(t, tm.Quit(), "Failed to quit the model")
+	fm := tm.FinalModel(t)
+	m, ok := fm.(ViewPasswordsModel)
+	require.Truef(t, ok, "final model has wrong type: %T", fm)
+	assert.Contains(t, m.View(), "github (octocat) "+breachWarningStyle.Render("⚠ breached"))
+	assert.NotContains(t, m.View(), "bank (me) "+breachWarningStyle.Render("⚠ breached"))
+}
@@ -0,0 +1,32 @@
+//go:build unit
+
+package cli
+
+import (
+	"testing"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSidebarNodesShouldListFoldersDepthFirstAndThenTags(t *testing.T) {
+	// given
+	tree := vault.NewFolderTree([]vault.Folder{
+		{ID: "work", Name: "Work"},
+		{ID: "servers", ParentID: "work", Name: "Servers"},
+		{ID: "banking", Name: "Banking"},
+	})
+	tags := []vault.Tag{{ID: "dev", Name: "dev"}}
+
+	// when
+	nodes := buildSidebarNodes(tree, tags)
+
+	// then
+	assert.Equal(t, []sidebarNode{
+		{kind: sidebarAllEntries, label: "All entries"},
+		{kind: sidebarFolder, id: "banking", label: "Banking"},
+		{kind: sidebarFolder, id: "work", label: "Work"},
+		{kind: sidebarFolder, id: "servers", label: "Servers", depth: 1},
+		{kind: sidebarTag, id: "dev", label: "dev"},
+	}, nodes)
+}
@@ -0,0 +1,214 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
+)
+
+// stdioPath reads a file from stdin or writes it to stdout in place of a path
+const stdioPath = "-"
+
+// attachCommand encrypts a file and attaches it to an entry
+func attachCommand() Command {
+	return Command{
+		Name:    "attach",
+		Summary: "Attach an encrypted file to an entry",
+		Run:     runAttach,
+	}
+}
+
+// attachmentsCommand lists the files attached to an entry
+func attachmentsCommand() Command {
+	return Command{
+		Name:    "attachments",
+		Summary: "List files attached to an entry",
+		Run:     runAttachments,
+	}
+}
+
+// extractCommand decrypts an attached file
+func extractCommand() Command {
+	return Command{
+		Name:    "extract",
+		Summary: "Decrypt a file attached to an entry",
+		Run:     runExtract,
+	}
+}
+
+// detachCommand removes an attached file
+func detachCommand() Command {
+	return Command{
+		Name:    "detach",
+		Summary: "Remove a file attached to an entry",
+		Run:     runDetach,
+	}
+}
+
+func runAttach(env Env, args []string) error {
+	fs, username := newFlagSet(env, "attach")
+	entryUsername := fs.String("username", "", "username of the entry, when several entries share the title")
+	name := fs.String("name", "", "name to store the file under, defaults to the file name")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass attach <title> <file|-> [flags]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	path := positional[1]
+	if path == stdioPath && *name == "" {
+		fmt.Fprintln(fs.Output(), "--name is required when reading from stdin")
+		fs.Usage()
+		return errUsage
+	}
+
+	attachments, entry, err := openAttachments(env, *username, positional[0], *entryUsername)
+	if err != nil {
+		return err
+	}
+
+	src := env.Stdin
+	if path != stdioPath {
+		file, err := os.Open(path) // #nosec G304 -- the user picks the file to attach
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		src = file
+	}
+	if *name == "" {
+		*name = filepath.Base(path)
+	}
+
+	attachment, err := attachments.Add(entry.ID, *name, src)
+	if err != nil {
+		return err
+	}
+	if err = attachments.Record(model.AuditChanged, model.ClientCLI, entry); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.Stdout, "Attached %s (%s) to %s\n", attachment.Name, utils.FormatSize(attachment.Size), entry.Title)
+	return nil
+}
+
+func runAttachments(env Env, args []string) error {
+	fs, username := newFlagSet(env, "attachments")
+	entryUsername := fs.String("username", "", "username of the entry, when several entries share the title")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass attachments <title> [flags]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	attachments, entry, err := openAttachments(env, *username, positional[0], *entryUsername)
+	if err != nil {
+		return err
+	}
+	list, err := attachments.List(entry.ID)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Fprintf(env.Stderr, "%s has no attachments\n", entry.Title)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE")
+	for _, attachment := range list {
+		fmt.Fprintf(w, "%s\t%s\n", attachment.Name, utils.FormatSize(attachment.Size))
+	}
+	return w.Flush()
+}
+
+func runExtract(env Env, args []string) error {
+	fs, username := newFlagSet(env, "extract")
+	entryUsername := fs.String("username", "", "username of the entry, when several entries share the title")
+	out := fs.String("out", "", "file to write to, defaults to the attachment name, - for stdout")
+	force := fs.Bool("force", false, "overwrite an existing file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass extract <title> <name> [flags]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	attachments, entry, err := openAttachments(env, *username, positional[0], *entryUsername)
+	if err != nil {
+		return err
+	}
+	attachment, err := attachments.Find(entry.ID, positional[1])
+	if err != nil {
+		return err
+	}
+
+	path := *out
+	if path == "" {
+		path = attachment.Name
+	}
+	if err = attachments.Record(model.AuditCopied, model.ClientCLI, entry); err != nil {
+		return err
+	}
+	if path == stdioPath {
+		return attachments.Extract(attachment, env.Stdout)
+	}
+	if err = attachments.ExtractToFile(attachment, path, *force); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.Stderr, "Extracted %s to %s\n", attachment.Name, path)
+	return nil
+}
+
+func runDetach(env Env, args []string) error {
+	fs, username := newFlagSet(env, "detach")
+	entryUsername := fs.String("username", "", "username of the entry, when several entries share the title")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass detach <title> <name> [flags]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+
+	attachments, entry, err := openAttachments(env, *username, positional[0], *entryUsername)
+	if err != nil {
+		return err
+	}
+	attachment, err := attachments.Find(entry.ID, positional[1])
+	if err != nil {
+		return err
+	}
+	if err = attachments.Remove(attachment); err != nil {
+		return err
+	}
+	if err = attachments.Record(model.AuditChanged, model.ClientCLI, entry); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.Stdout, "Removed %s from %s\n", attachment.Name, entry.Title)
+	return nil
+}
+
+// openAttachments unlocks the vault and looks up the entry the attachments belong to
+func openAttachments(env Env, username, title, entryUsername string) (vault.Attachments, model.Password, error) {
+	v, err := unlock(env, username)
+	if err != nil {
+		return vault.Attachments{}, model.Password{}, err
+	}
+	entry, err := v.FindEntry(title, entryUsername)
+	if err != nil {
+		return vault.Attachments{}, model.Password{}, err
+	}
+	return vault.NewAttachments(v, env.Container.AttachmentDir), entry, nil
+}
@@ -0,0 +1,145 @@
+//go:build integration
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachShouldEncryptFileAndExtractRestoresIt(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "github", Username: "me", Password: "a"}, nil, "", nil))
+	dir := t.TempDir()
+	source := filepath.Join(dir, "recovery-codes.txt")
+	require.NoError(t, os.WriteFile(source, []byte("1111-2222\n3333-4444\n"), 0600))
+	target := filepath.Join(dir, "restored.txt")
+
+	// when
+	code := Run(e.env, []string{"attach", "github", source})
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	code = Run(e.env, []string{"extract", "github", "recovery-codes.txt", "--out", target})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stdout.String(), "Attached recovery-codes.txt (20 B) to github")
+	restored, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "1111-2222\n3333-4444\n", string(restored))
+}
+
+func TestAttachShouldReadStdinWithName(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "github", Username: "me", Password: "a"}, nil, "", nil))
+	e.env.Stdin = strings.NewReader("license contents")
+
+	// when
+	code := Run(e.env, []string{"attach", "github", "-", "--name", "license.key"})
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	e.stdout.Reset()
+	code = Run(e.env, []string{"extract", "github", "license.key", "--out", "-"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "license contents", e.stdout.String())
+}
+
+func TestAttachShouldRequireNameForStdin(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"attach", "github", "-"})
+
+	// then
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, e.stderr.String(), "--name is required when reading from stdin")
+}
+
+func TestAttachmentsShouldListAttachedFiles(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "me", Password: "a"}, nil, "", nil))
+	entry, err := v.FindEntry("github", "")
+	require.NoError(t, err)
+	attachments := vault.NewAttachments(v, e.env.Container.AttachmentDir)
+	_, err = attachments.Add(entry.ID, "license.key", strings.NewReader(strings.Repeat("x", 2048)))
+	require.NoError(t, err)
+
+	// when
+	code := Run(e.env, []string{"attachments", "github"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stdout.String(), "NAME")
+	assert.Contains(t, e.stdout.String(), "license.key")
+	assert.Contains(t, e.stdout.String(), "2.0 KiB")
+}
+
+func TestExtractShouldNotOverwriteExistingFile(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "me", Password: "a"}, nil, "", nil))
+	entry, err := v.FindEntry("github", "")
+	require.NoError(t, err)
+	_, err = vault.NewAttachments(v, e.env.Container.AttachmentDir).Add(entry.ID, "license.key", strings.NewReader("new"))
+	require.NoError(t, err)
+	target := filepath.Join(t.TempDir(), "license.key")
+	require.NoError(t, os.WriteFile(target, []byte("old"), 0600))
+
+	// when
+	code := Run(e.env, []string{"extract", "github", "license.key", "--out", target})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "already exists")
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(content))
+}
+
+func TestDetachShouldRemoveAttachment(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "me", Password: "a"}, nil, "", nil))
+	entry, err := v.FindEntry("github", "")
+	require.NoError(t, err)
+	attachments := vault.NewAttachments(v, e.env.Container.AttachmentDir)
+	_, err = attachments.Add(entry.ID, "license.key", strings.NewReader("contents"))
+	require.NoError(t, err)
+
+	// when
+	code := Run(e.env, []string{"detach", "github", "license.key"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stdout.String(), "Removed license.key from github")
+	listed, err := attachments.List(entry.ID)
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+}
+
+func TestDetachShouldFailForUnknownAttachment(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "github", Username: "me", Password: "a"}, nil, "", nil))
+
+	// when
+	code := Run(e.env, []string{"detach", "github", "missing.pdf"})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "attachment not found: missing.pdf")
+}
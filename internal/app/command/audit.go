@@ -0,0 +1,81 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yubigo-pass/internal/app/breach"
+	"yubigo-pass/internal/app/health"
+	"yubigo-pass/internal/app/model"
+)
+
+// auditCommand reports weak, reused, old and breached passwords and insecure URLs of the vault
+func auditCommand() Command {
+	return Command{
+		Name:    "audit",
+		Summary: "Report weak, reused, old and breached passwords with an overall security score",
+		Run:     runAudit,
+	}
+}
+
+func runAudit(env Env, args []string) error {
+	fs, username := newFlagSet(env, "audit")
+	minStrength := fs.Int("min-strength", health.DefaultMinStrength, fmt.Sprintf("zxcvbn strength from 0 to %d below which passwords are weak", health.MaxStrength))
+	maxAge := fs.Int("max-age", int(health.DefaultMaxAge/(24*time.Hour)), "days after which unchanged passwords are old, 0 to never flag them")
+	skipBreaches := fs.Bool("skip-breaches", false, "do not look passwords up in the breach index or range API")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass audit [flags]")
+		fmt.Fprintln(fs.Output(), "Passwords are checked for breaches when `yubigo-pass breach` has an index or range API to check against.")
+		fs.PrintDefaults()
+	}
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *minStrength < 0 || *minStrength > health.MaxStrength || *maxAge < 0 {
+		fmt.Fprintf(fs.Output(), "--min-strength must be 0 to %d and --max-age at least 0\n", health.MaxStrength)
+		fs.Usage()
+		return errUsage
+	}
+
+	opts := health.DefaultOptions()
+	opts.MinStrength = *minStrength
+	opts.MaxAge = time.Duration(*maxAge) * 24 * time.Hour
+	if !*skipBreaches {
+		checker, err := breach.Open(env.Container.BreachIndex, env.Container.BreachAPI)
+		switch {
+		case err == nil:
+			defer checker.Close()
+			opts.Breaches = checker
+		case !errors.Is(err, breach.ErrNoData):
+			return err
+		}
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+	report, err := health.Check(v, opts)
+	if err != nil {
+		return err
+	}
+	// the report is made of every password of the vault
+	if err = v.Record(model.AuditRead, model.ClientCLI, model.Password{}); err != nil {
+		return err
+	}
+	printHealthReport(env, report)
+	return nil
+}
+
+// printHealthReport prints the score of the vault and the issues of every entry with some, one per line
+func printHealthReport(env Env, report health.Report) {
+	fmt.Fprintf(env.Stdout, "Security score: %d/100, %d of %d logins with issues\n", report.Score, len(report.Findings), report.Checked)
+	for _, finding := range report.Findings {
+		name := finding.Entry.Title
+		if finding.Entry.Username != "" {
+			name += " (" + finding.Entry.Username + ")"
+		}
+		fmt.Fprintf(env.Stdout, "%s: %s\n", name, strings.Join(finding.Describe(), "; "))
+	}
+}
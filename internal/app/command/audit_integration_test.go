@@ -0,0 +1,65 @@
+//go:build integration
+
+package command
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditShouldReportScoreAndIssuesOfEntries(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	e.env.Container.BreachIndex = filepath.Join(t.TempDir(), "pwned-passwords.idx")
+	v := e.vault(t)
+	old := time.Now().Add(-100 * 24 * time.Hour).UnixMilli()
+	require.NoError(t, v.AddEntry(model.Password{Title: "Bank", Username: "me", Password: "password"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Forum", Password: "8fj#2kLq!0zPw&yU", Url: "http://forum.example", ModifiedAt: old}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Mail", Username: "me", Password: "Tr5$eW9^nB3*kJ1x"}, nil, "", nil))
+
+	// when
+	code := Run(e.env, []string{"audit", "--max-age", "90"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stdout.String(), "Security score: 77/100, 2 of 3 logins with issues")
+	assert.Contains(t, e.stdout.String(), "Bank (me): weak password (strength 0/4)")
+	assert.Contains(t, e.stdout.String(), "Forum: unchanged for 100 days; insecure URL http://forum.example")
+	assert.NotContains(t, e.stdout.String(), "Mail")
+	vaulttest.AssertLastAuditEvent(t, v, model.AuditRead, model.ClientCLI)
+}
+
+func TestAuditShouldFlagBreachedPasswords(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	e.env.Container.BreachIndex = filepath.Join(t.TempDir(), "pwned-passwords.idx")
+	v := e.vault(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "Vq7#mZ2!pLx9@wRt"}, nil, "", nil))
+	require.Equal(t, ExitOK, Run(e.env, []string{"breach", "--build-index", writeRangeFiles(t, "Vq7#mZ2!pLx9@wRt", 3)}), e.stderr.String())
+
+	// when
+	code := Run(e.env, []string{"audit"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stdout.String(), "Security score: 50/100")
+	assert.Contains(t, e.stdout.String(), "GitHub (octocat): password seen 3 times in breaches")
+}
+
+func TestAuditShouldRejectStrengthOutOfRange(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"audit", "--min-strength", "5"})
+
+	// then
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, e.stderr.String(), "--min-strength must be 0 to 4")
+}
@@ -0,0 +1,94 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+	"yubigo-pass/internal/database/backup"
+)
+
+// backupCommand snapshots the database into the backup rotation, or lists the snapshots kept
+func backupCommand() Command {
+	return Command{
+		Name:    "backup",
+		Summary: "Back up the database now, or --list the backups kept",
+		Run:     runBackup,
+	}
+}
+
+// restoreCommand replaces the database with a backup after checking its integrity
+func restoreCommand() Command {
+	return Command{
+		Name:    "restore",
+		Summary: "Restore the database from a backup",
+		Run:     runRestore,
+	}
+}
+
+func runBackup(env Env, args []string) error {
+	fs := newDatabaseFlagSet(env, "backup")
+	list := fs.Bool("list", false, "list the backups kept instead of taking one")
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	backups := env.Container.Backups
+	if *list {
+		snapshots, err := backups.Rotation.List()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "TAKEN\tREASON\tFILE")
+		for _, snapshot := range snapshots {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", snapshot.Taken.Local().Format(time.DateTime), snapshot.Reason, filepath.Base(snapshot.Path))
+		}
+		return w.Flush()
+	}
+
+	snapshot, err := backups.Take(backup.ReasonManual)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.Stdout, "Backed up to %s\n", snapshot.Path)
+	return nil
+}
+
+func runRestore(env Env, args []string) error {
+	fs := newDatabaseFlagSet(env, "restore")
+	positional, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	backups := env.Container.Backups
+	path := snapshotPath(backups.Rotation, positional[0])
+	undo, err := backups.Restore(path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.Stdout, "Restored %s\nThe replaced database was backed up to %s\n", path, undo.Path)
+	return nil
+}
+
+// newDatabaseFlagSet returns a flag set for a command acting on the whole database, which has no --user flag
+func newDatabaseFlagSet(env Env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	return fs
+}
+
+// snapshotPath resolves a bare file name listed by `backup --list` to the backup directory,
+// returning any other argument as a path
+func snapshotPath(rotation backup.Rotation, arg string) string {
+	if filepath.Base(arg) != arg {
+		return arg
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return arg
+	}
+	return filepath.Join(rotation.Dir, arg)
+}
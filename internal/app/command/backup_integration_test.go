@@ -0,0 +1,88 @@
+//go:build integration
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"yubigo-pass/internal/app/model"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupShouldSnapshotDatabaseAndListIt(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"backup"})
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	e.stdout.Reset()
+	code = Run(e.env, []string{"backup", "--list"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	snapshots, err := e.env.Container.Backups.Rotation.List()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Contains(t, e.stdout.String(), "manual")
+	assert.Contains(t, e.stdout.String(), filepath.Base(snapshots[0].Path))
+}
+
+func TestRestoreShouldReplaceDatabaseWithListedBackup(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "me", Password: "a"}, nil, "", nil))
+	require.Equal(t, ExitOK, Run(e.env, []string{"backup"}), e.stderr.String())
+	snapshots, err := e.env.Container.Backups.Rotation.List()
+	require.NoError(t, err)
+	require.NoError(t, v.AddEntry(model.Password{Title: "gitlab", Username: "me", Password: "b"}, nil, "", nil))
+
+	// when
+	code := Run(e.env, []string{"restore", filepath.Base(snapshots[0].Path)})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stdout.String(), "Restored "+snapshots[0].Path)
+	assert.Contains(t, e.stdout.String(), "pre-restore")
+
+	restored, err := sqlx.Connect("sqlite3", filepath.Join(filepath.Dir(e.env.Container.Backups.Rotation.Dir), "yubigo-pass.db"))
+	require.NoError(t, err)
+	defer restored.Close()
+	var titles []string
+	require.NoError(t, restored.Select(&titles, "SELECT title FROM passwords"))
+	assert.Equal(t, []string{"github"}, titles)
+}
+
+func TestRestoreShouldRejectFileFailingIntegrityCheck(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	path := filepath.Join(t.TempDir(), "backup.db")
+	require.NoError(t, os.WriteFile(path, []byte("definitely not an SQLite database, only some text"), 0o600))
+
+	// when
+	code := Run(e.env, []string{"restore", path})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "not a yubigo-pass database")
+	assert.NoError(t, e.db.Ping())
+	snapshots, err := e.env.Container.Backups.Rotation.List()
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+func TestRestoreShouldRequireBackup(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"restore"})
+
+	// then
+	assert.Equal(t, ExitUsage, code)
+}
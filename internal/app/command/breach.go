@@ -0,0 +1,72 @@
+package command
+
+import (
+	"fmt"
+	"yubigo-pass/internal/app/breach"
+)
+
+// breachCommand reports the entries whose password was seen in breaches, or builds the index of
+// breached passwords they are checked against
+func breachCommand() Command {
+	return Command{
+		Name:    "breach",
+		Summary: "Report entries whose password was seen in breaches of Have I Been Pwned",
+		Run:     runBreach,
+	}
+}
+
+func runBreach(env Env, args []string) error {
+	fs, username := newFlagSet(env, "breach")
+	index := fs.String("index", env.Container.BreachIndex, "index of breached passwords, defaults to $"+breach.EnvIndex)
+	api := fs.String("api", env.Container.BreachAPI, "range API checked without an index, e.g. "+breach.DefaultAPI+", defaults to $"+breach.EnvAPI)
+	build := fs.String("build-index", "", "build the index from a directory of downloaded Pwned Passwords range files, or a single file of hashes ordered by hash, instead")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass breach [flags]")
+		fmt.Fprintln(fs.Output(), "Passwords are looked up offline in the index. Without one, only the first five hex digits of their SHA-1 are sent to the range API.")
+		fs.PrintDefaults()
+	}
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	if *build != "" {
+		indexed, err := breach.BuildIndex(*build, *index)
+		if err != nil {
+			return fmt.Errorf("failed to build breach index: %w", err)
+		}
+		fmt.Fprintf(env.Stdout, "Indexed %d breached passwords of %s in %s\n", indexed, *build, *index)
+		return nil
+	}
+
+	checker, err := breach.Open(*index, *api)
+	if err != nil {
+		return err
+	}
+	defer checker.Close()
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+	findings, err := breach.Check(v, checker)
+	if err != nil {
+		return err
+	}
+	printBreachFindings(env, findings)
+	return nil
+}
+
+// printBreachFindings lists the entries seen in breaches, one per line
+func printBreachFindings(env Env, findings []breach.Finding) {
+	if len(findings) == 0 {
+		fmt.Fprintln(env.Stdout, "No passwords seen in breaches")
+		return
+	}
+	for _, finding := range findings {
+		name := finding.Entry.Title
+		if finding.Entry.Username != "" {
+			name += " (" + finding.Entry.Username + ")"
+		}
+		fmt.Fprintf(env.Stdout, "%s: password seen %d times in breaches\n", name, finding.Count)
+	}
+	fmt.Fprintf(env.Stderr, "%d of your passwords were seen in breaches, change them\n", len(findings))
+}
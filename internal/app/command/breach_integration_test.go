@@ -0,0 +1,59 @@
+//go:build integration
+
+package command
+
+import (
+	"crypto/sha1" // #nosec G505 -- Pwned Passwords identifies passwords by their SHA-1
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"yubigo-pass/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRangeFiles writes the range file of a breached password, as the downloader of Have I Been Pwned does
+func writeRangeFiles(t *testing.T, password string, count int) string {
+	t.Helper()
+	dir := t.TempDir()
+	hash := fmt.Sprintf("%X", sha1.Sum([]byte(password))) // #nosec G401
+	require.NoError(t, os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(fmt.Sprintf("%s:%d\r\n", hash[5:], count)), 0o600))
+	return dir
+}
+
+func TestBreachShouldReportEntriesSeenInBreachesOfBuiltIndex(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	e.env.Container.BreachIndex = filepath.Join(t.TempDir(), "pwned-passwords.idx")
+	v := e.vault(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "hunter2"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Mail", Username: "me", Password: "8fj#2kLq!0zPw"}, nil, "", nil))
+	source := writeRangeFiles(t, "hunter2", 25592)
+
+	// when
+	buildCode := Run(e.env, []string{"breach", "--build-index", source})
+	code := Run(e.env, []string{"breach"})
+
+	// then
+	require.Equal(t, ExitOK, buildCode, e.stderr.String())
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stdout.String(), "Indexed 1 breached passwords of "+source)
+	assert.Contains(t, e.stdout.String(), "GitHub (octocat): password seen 25592 times in breaches")
+	assert.NotContains(t, e.stdout.String(), "Mail")
+	assert.Contains(t, e.stderr.String(), "1 of your passwords were seen in breaches")
+}
+
+func TestBreachShouldAskForBreachData(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	e.env.Container.BreachIndex = filepath.Join(t.TempDir(), "pwned-passwords.idx")
+
+	// when
+	code := Run(e.env, []string{"breach"})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "no breach data, build an index")
+}
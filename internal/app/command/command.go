@@ -0,0 +1,246 @@
+// Package command implements the non-interactive subcommands of yubigo-pass,
+// e.g. `yubigo-pass list --folder Work`. Running the binary without a subcommand starts the TUI.
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"yubigo-pass/internal/app/services"
+	"yubigo-pass/internal/app/vault"
+
+	"golang.org/x/term"
+)
+
+// Environment variables read when credentials are not passed on the command line
+const (
+	EnvUsername = "YUBIGO_PASS_USER"
+	EnvPassword = "YUBIGO_PASS_PASSWORD" // #nosec G101
+	// EnvExportPassword holds the password of password protected exports, read by import and export
+	EnvExportPassword = "YUBIGO_PASS_EXPORT_PASSWORD" // #nosec G101
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+// Env holds the services and the standard streams a command runs with
+type Env struct {
+	Container services.Container
+	Stdin     io.Reader
+	Stdout    io.Writer
+	Stderr    io.Writer
+}
+
+// NewEnv returns new Env instance bound to the process standard streams
+func NewEnv(container services.Container) Env {
+	return Env{
+		Container: container,
+		Stdin:     os.Stdin,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}
+}
+
+// Command is a single non-interactive subcommand
+type Command struct {
+	Name    string
+	Summary string
+	Run     func(env Env, args []string) error
+}
+
+// errUsage signals that a command was invoked with invalid arguments
+var errUsage = errors.New("invalid usage")
+
+// Commands returns all available subcommands
+func Commands() []Command {
+	return []Command{
+		listCommand(),
+		showCommand(),
+		searchCommand(),
+		otpCommand(),
+		importQRCommand(),
+		importCommand(),
+		exportCommand(),
+		attachCommand(),
+		attachmentsCommand(),
+		extractCommand(),
+		detachCommand(),
+		backupCommand(),
+		restoreCommand(),
+		syncCommand(),
+		serveSyncCommand(),
+		mergeCommand(),
+		shareCommand(),
+		receiveCommand(),
+		shareServerCommand(),
+		breachCommand(),
+		auditCommand(),
+		logCommand(),
+		generateCommand(),
+	}
+}
+
+// Run executes the subcommand named by args[0] and returns the process exit code
+func Run(env Env, args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(env.Stderr)
+		return ExitUsage
+	}
+
+	for _, c := range Commands() {
+		if c.Name != args[0] {
+			continue
+		}
+		err := c.Run(env, args[1:])
+		switch {
+		case err == nil:
+			return ExitOK
+		case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+			return ExitUsage
+		default:
+			fmt.Fprintf(env.Stderr, "Error: %v\n", err)
+			return ExitError
+		}
+	}
+
+	fmt.Fprintf(env.Stderr, "Error: unknown command %q\n\n", args[0])
+	printUsage(env.Stderr)
+	return ExitUsage
+}
+
+// printUsage lists the available subcommands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: yubigo-pass [command] [flags]")
+	fmt.Fprintln(w, "\nRun without a command to start the interactive interface.\n\nCommands:")
+	for _, c := range Commands() {
+		fmt.Fprintf(w, "  %-12s %s\n", c.Name, c.Summary)
+	}
+}
+
+// newFlagSet returns a flag set for a command with the shared --user flag registered
+func newFlagSet(env Env, name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	username := fs.String("user", "", "vault username (defaults to $"+EnvUsername+")")
+	return fs, username
+}
+
+// parseFlags parses command arguments allowing flags before and after positional arguments.
+// It returns the positional arguments, expecting between minArgs and maxArgs of them,
+// or at least minArgs when maxArgs is negative.
+func parseFlags(fs *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if maxArgs >= 0 && len(positional) > maxArgs {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %s\n", strings.Join(positional[maxArgs:], " "))
+		fs.Usage()
+		return nil, errUsage
+	}
+	if len(positional) < minArgs {
+		fmt.Fprintln(fs.Output(), "missing arguments")
+		fs.Usage()
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+// unlock authenticates the user and opens their vault. The master password is taken from
+// $YUBIGO_PASS_PASSWORD or prompted for on the terminal.
+func unlock(env Env, username string) (vault.Vault, error) {
+	username, password, err := credentials(env, username)
+	if err != nil {
+		return vault.Vault{}, err
+	}
+
+	session, err := vault.Unlock(env.Container.Store, username, password)
+	if err != nil {
+		return vault.Vault{}, err
+	}
+	return vault.New(env.Container.Store, session), nil
+}
+
+// credentials resolves the username and reads the master password the user unlocks with
+func credentials(env Env, username string) (string, string, error) {
+	username = resolveUsername(username)
+	if username == "" {
+		return "", "", fmt.Errorf("no username given, use --user or $%s", EnvUsername)
+	}
+
+	password, err := readSecret(env, EnvPassword, "Master password: ")
+	if err != nil {
+		return "", "", err
+	}
+	return username, password, nil
+}
+
+// resolveUsername returns the username given with --user, or $YUBIGO_PASS_USER without one
+func resolveUsername(username string) string {
+	if username == "" {
+		return os.Getenv(EnvUsername)
+	}
+	return username
+}
+
+// readSecret reads a secret from the environment variable envVar, the terminal without echo,
+// or a line of stdin
+func readSecret(env Env, envVar, prompt string) (string, error) {
+	if secret, ok := os.LookupEnv(envVar); ok {
+		return secret, nil
+	}
+
+	fmt.Fprint(env.Stderr, prompt)
+	if f, ok := env.Stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		secret, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(env.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return string(secret), nil
+	}
+
+	line, err := readLine(env.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return line, nil
+}
+
+// readLine reads stdin up to the next newline a byte at a time, leaving the following lines
+// for later prompts
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimRight(string(line), "\r"), nil
+}
@@ -0,0 +1,149 @@
+//go:build integration
+
+package command
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/services"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/internal/database/backup"
+	"yubigo-pass/test"
+	"yubigo-pass/test/usertest"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCommandEnv holds a test database with a single user and the buffers a command writes to
+type testCommandEnv struct {
+	env      Env
+	db       *sqlx.DB
+	user     model.User
+	password string
+	stdout   *bytes.Buffer
+	stderr   *bytes.Buffer
+}
+
+func setupCommandEnv(t *testing.T) testCommandEnv {
+	db, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed to set up test database")
+	t.Cleanup(func() { test.TeardownTestDB(db) })
+
+	username, password := test.RandomString(), test.RandomString()
+	usertest.Insert(t, db, username, password)
+	user := test.GetUser(t, db, username)
+
+	t.Setenv(EnvUsername, user.Username)
+	t.Setenv(EnvPassword, password)
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	dbPath := filepath.Join(t.TempDir(), "yubigo-pass.db")
+	backups := backup.NewManager(db, dbPath, backup.Rotation{Dir: backup.DirFor(dbPath), Keep: 3}, 0)
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	return testCommandEnv{
+		env: Env{
+			Container: services.Container{
+				Store:         database.NewStore(db),
+				AttachmentDir: t.TempDir(),
+				Backups:       backups,
+				SyncDir:       t.TempDir(),
+				MigrationPath: "file://" + filepath.Join(cwd, "../../../assets/migrations"),
+			},
+			Stdin:  strings.NewReader(""),
+			Stdout: stdout,
+			Stderr: stderr,
+		},
+		db:       db,
+		user:     user,
+		password: password,
+		stdout:   stdout,
+		stderr:   stderr,
+	}
+}
+
+func (e testCommandEnv) vault(t *testing.T) vault.Vault {
+	session, err := vault.Unlock(e.env.Container.Store, e.user.Username, e.password)
+	require.NoError(t, err)
+	return vault.New(e.env.Container.Store, session)
+}
+
+func TestRunShouldRejectUnknownCommand(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"frobnicate"})
+
+	// then
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, e.stderr.String(), `unknown command "frobnicate"`)
+	assert.Contains(t, e.stderr.String(), "list")
+}
+
+func TestRunShouldPrintUsageWithoutCommand(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"help"})
+
+	// then
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, e.stderr.String(), "Usage: yubigo-pass")
+}
+
+func TestRunShouldFailWithWrongMasterPassword(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	t.Setenv(EnvPassword, test.RandomString())
+
+	// when
+	code := Run(e.env, []string{"list"})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "incorrect username or password")
+}
+
+func TestRunShouldThrottleRepeatedWrongMasterPasswords(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	password := e.password
+	t.Setenv(EnvPassword, test.RandomString())
+	for range model.DefaultLoginPolicy.FreeFailures {
+		require.Equal(t, ExitError, Run(e.env, []string{"list"}))
+	}
+	t.Setenv(EnvPassword, password)
+	e.stderr.Reset()
+
+	// when
+	code := Run(e.env, []string{"list"})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "too many failed login attempts, try again in")
+}
+
+func TestRunShouldReadMasterPasswordFromStdin(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	t.Setenv(EnvPassword, "")
+	e.env.Stdin = strings.NewReader(e.password + "\n")
+	require.NoError(t, os.Unsetenv(EnvPassword))
+
+	// when
+	code := Run(e.env, []string{"list"})
+
+	// then
+	assert.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stderr.String(), "Master password:")
+}
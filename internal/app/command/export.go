@@ -0,0 +1,135 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/exporter"
+	"yubigo-pass/internal/app/keepass"
+	"yubigo-pass/internal/app/model"
+)
+
+// exportCommand writes every entry in the format of another password manager
+func exportCommand() Command {
+	return Command{
+		Name:    "export",
+		Summary: "Export entries for another password manager",
+		Run:     runExport,
+	}
+}
+
+func runExport(env Env, args []string) error {
+	fs, username := newFlagSet(env, "export")
+	format := fs.String("format", formatBitwarden, "format of the export, one of: "+formats)
+	encrypted := fs.Bool("encrypted", false, "write a yubigo-pass archive of every entry, field and attachment, sealed under a passphrase read from $"+EnvExportPassword+" or a prompt")
+	protected := fs.Bool("password-protected", false, "encrypt the export with a password read from $"+EnvExportPassword+" or a prompt, KeePass databases always are")
+	keyFile := fs.String("keyfile", "", "key file that, with or instead of the password, locks a KeePass database")
+	out := fs.String("out", stdioPath, "file to write to, - for stdout")
+	force := fs.Bool("force", false, "overwrite an existing file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass export [flags]")
+		fmt.Fprintln(fs.Output(), "Without --password-protected a Bitwarden export holds every secret in plaintext.")
+		fmt.Fprintln(fs.Output(), "With --encrypted the archive restores with import into any install, whatever its master password.")
+		fs.PrintDefaults()
+	}
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *format != formatBitwarden && *format != formatKeePass {
+		fmt.Fprintf(fs.Output(), "unsupported format %q\n", *format)
+		fs.Usage()
+		return errUsage
+	}
+	if *encrypted {
+		conflicting := false
+		fs.Visit(func(f *flag.Flag) {
+			conflicting = conflicting || f.Name == "format" || f.Name == "password-protected" || f.Name == "keyfile"
+		})
+		if conflicting {
+			fmt.Fprintln(fs.Output(), "--encrypted cannot be combined with --format, --password-protected or --keyfile")
+			fs.Usage()
+			return errUsage
+		}
+		*format = formatArchive
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+	var write func(w io.Writer) error
+	switch *format {
+	case formatArchive:
+		passphrase, err := readSecret(env, EnvExportPassword, "Archive passphrase: ")
+		if err != nil {
+			return err
+		}
+		if passphrase == "" {
+			return archive.ErrPassphraseRequired
+		}
+		write = func(w io.Writer) error {
+			return exporter.WriteArchive(w, v, env.Container.AttachmentDir, passphrase, archive.DefaultKDF)
+		}
+	case formatKeePass:
+		credentials, err := keepassCredentials(env, *keyFile)
+		if err != nil {
+			return err
+		}
+		write = func(w io.Writer) error {
+			return exporter.WriteKeePass(w, v, env.Container.AttachmentDir, credentials, keepass.DefaultOptions)
+		}
+	default:
+		var password string
+		if *protected {
+			password, err = readSecret(env, EnvExportPassword, "Export password: ")
+			if err != nil {
+				return err
+			}
+			if password == "" {
+				return errors.New("export password must not be empty")
+			}
+		}
+		write = func(w io.Writer) error {
+			return exporter.WriteBitwarden(w, v, password)
+		}
+	}
+
+	if err = writeOutput(env, *out, *force, write); err != nil {
+		return err
+	}
+	if err = v.Record(model.AuditExported, model.ClientCLI, model.Password{}); err != nil {
+		return err
+	}
+	if *out != stdioPath {
+		fmt.Fprintf(env.Stderr, "Exported to %s\n", *out)
+	}
+	return nil
+}
+
+// writeOutput writes to the file at path, or stdout in place of the path -. An existing file is only
+// overwritten with force, and a file left half written by a failed write is removed.
+func writeOutput(env Env, path string, force bool, write func(w io.Writer) error) error {
+	if path == stdioPath {
+		return write(env.Stdout)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0o600) // #nosec G304 -- the user picks where to write to
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists", path)
+	}
+	if err != nil {
+		return err
+	}
+	if err = write(file); err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,161 @@
+//go:build integration
+
+package command
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/bitwarden"
+	"yubigo-pass/internal/app/keepass"
+	"yubigo-pass/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportShouldWriteBitwardenJSONToStdout(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "hunter2"}, nil, "Work", nil))
+
+	// when
+	code := Run(e.env, []string{"export", "--format", "bitwarden"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	exported, err := bitwarden.Parse(e.stdout.Bytes(), "")
+	require.NoError(t, err)
+	require.Len(t, exported.Items, 1)
+	assert.Equal(t, "hunter2", exported.Items[0].Login.Password)
+	require.Len(t, exported.Folders, 1)
+	assert.Equal(t, "Work", exported.Folders[0].Name)
+}
+
+func TestExportShouldWritePasswordProtectedFile(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "hunter2"}, nil, "", nil))
+	t.Setenv(EnvExportPassword, "correct horse")
+	path := filepath.Join(t.TempDir(), "export.json")
+
+	// when
+	code := Run(e.env, []string{"export", "--password-protected", "--out", path})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Empty(t, e.stdout.String())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+	exported, err := bitwarden.Parse(data, "correct horse")
+	require.NoError(t, err)
+	require.Len(t, exported.Items, 1)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestExportShouldNotOverwriteExistingFile(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	path := filepath.Join(t.TempDir(), "export.json")
+	require.NoError(t, os.WriteFile(path, []byte("keep"), 0o600))
+
+	// when
+	code := Run(e.env, []string{"export", "--out", path})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), path+" already exists")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "keep", string(data))
+}
+
+func TestExportShouldWriteKeePassDatabaseLockedWithKeyFile(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "hunter2"}, nil, "Work", nil))
+	t.Setenv(EnvExportPassword, "")
+	keyFile := writeExport(t, "my key file")
+	path := filepath.Join(t.TempDir(), "export.kdbx")
+
+	// when
+	code := Run(e.env, []string{"export", "--format", "keepass", "--keyfile", keyFile, "--out", path})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	db, err := keepass.Parse(data, keepass.Credentials{KeyFile: []byte("my key file")})
+	require.NoError(t, err)
+	require.Len(t, db.Root.Groups, 1)
+	assert.Equal(t, "Work", db.Root.Groups[0].Name)
+	require.Len(t, db.Root.Groups[0].Entries, 1)
+	assert.Equal(t, "hunter2", db.Root.Groups[0].Entries[0].Get(keepass.KeyPassword))
+}
+
+func TestExportShouldRequireKeePassPasswordOrKeyFile(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	t.Setenv(EnvExportPassword, "")
+	path := filepath.Join(t.TempDir(), "export.kdbx")
+
+	// when
+	code := Run(e.env, []string{"export", "--format", "keepass", "--out", path})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), keepass.ErrNoCredentials.Error())
+	assert.NoFileExists(t, path)
+}
+
+func TestExportShouldWriteEncryptedArchiveRestorableElsewhere(t *testing.T) {
+	// given
+	source := setupCommandEnv(t)
+	require.NoError(t, source.vault(t).AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "hunter2"}, nil, "Work", []string{"code"}))
+	t.Setenv(EnvExportPassword, "correct horse")
+	path := filepath.Join(t.TempDir(), "vault.ygp")
+
+	// when
+	code := Run(source.env, []string{"export", "--encrypted", "--out", path})
+
+	// then
+	require.Equal(t, ExitOK, code, source.stderr.String())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+	header, err := archive.ReadHeader(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, archive.DefaultKDF.Memory, header.KDF.Memory)
+
+	// when
+	target := setupCommandEnv(t)
+	code = Run(target.env, []string{"import", path})
+
+	// then
+	require.Equal(t, ExitOK, code, target.stderr.String())
+	assert.Equal(t, "Added GitHub (octocat)\n", target.stdout.String())
+	github, err := target.vault(t).FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	details, err := target.vault(t).Reveal(github)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", details.Entry.Password)
+	assert.Equal(t, []string{"Work"}, details.Folders)
+	assert.Equal(t, []string{"code"}, details.Tags)
+}
+
+func TestExportShouldRejectEncryptedWithFormat(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"export", "--encrypted", "--format", "keepass"})
+
+	// then
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, e.stderr.String(), "--encrypted cannot be combined with --format")
+}
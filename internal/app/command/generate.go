@@ -0,0 +1,279 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
+)
+
+// generateCommand prints passwords or passphrases generated with the active or a named generator profile,
+// adjusted by flags, and reports their entropy on stderr. The adjusted policy can be saved as a profile of its own.
+func generateCommand() Command {
+	return Command{
+		Name:    "generate",
+		Summary: "Generate passwords and manage password generator profiles",
+		Run:     runGenerate,
+	}
+}
+
+func runGenerate(env Env, args []string) error {
+	fs, username := newFlagSet(env, "generate")
+	profileName := fs.String("profile", "", "generate with this saved profile instead of the active one")
+	count := fs.Int("count", 1, "number of passwords to generate")
+	list := fs.Bool("list", false, "list the saved profiles instead of generating")
+	save := fs.String("save", "", "save the policy under this profile name")
+	activate := fs.Bool("activate", false, "make the saved or given profile the active one")
+	deleteName := fs.String("delete", "", "delete the profile with this name instead of generating")
+
+	var policy model.PasswordPolicy
+	fs.IntVar(&policy.Length, "length", 0, "password length")
+	fs.BoolVar(&policy.Lower, "lower", false, "use lowercase letters, e.g. --lower=false")
+	fs.BoolVar(&policy.Upper, "upper", false, "use uppercase letters")
+	fs.BoolVar(&policy.Digits, "digits", false, "use digits")
+	fs.BoolVar(&policy.Symbols, "symbols", false, "use symbols")
+	fs.IntVar(&policy.MinLower, "min-lower", 0, "use at least this many lowercase letters")
+	fs.IntVar(&policy.MinUpper, "min-upper", 0, "use at least this many uppercase letters")
+	fs.IntVar(&policy.MinDigits, "min-digits", 0, "use at least this many digits")
+	fs.IntVar(&policy.MinSymbols, "min-symbols", 0, "use at least this many symbols")
+	fs.StringVar(&policy.SymbolSet, "symbol-set", "", "symbols to choose from instead of the default ones")
+	fs.BoolVar(&policy.ExcludeAmbiguous, "exclude-ambiguous", false, "leave out characters easily mistaken for one another")
+	fs.BoolVar(&policy.NoRepeat, "no-repeat", false, "use every character at most once")
+	fs.BoolFunc("passphrase", "generate passphrases of words instead of characters, e.g. --passphrase=false", func(value string) error {
+		on, err := strconv.ParseBool(value)
+		policy.Mode = model.GeneratorModeCharacters
+		if on {
+			policy.Mode = model.GeneratorModePassphrase
+		}
+		return err
+	})
+	fs.IntVar(&policy.Words, "words", 0, "number of passphrase words")
+	fs.StringVar(&policy.Wordlist, "wordlist", "", "wordlist passphrase words are drawn from: "+strings.Join(model.Wordlists, ", "))
+	fs.StringVar(&policy.Separator, "separator", "", "separator between passphrase words")
+	fs.Func("capitalize", "capitalization of passphrase words: "+capitalizations(), func(value string) error {
+		policy.Capitalization = model.Capitalization(value)
+		return nil
+	})
+	fs.BoolVar(&policy.AppendDigit, "append-digit", false, "append a digit to passphrases")
+	fs.BoolVar(&policy.AppendSymbol, "append-symbol", false, "append a symbol of the symbol set to passphrases")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass generate [flags]")
+		fmt.Fprintln(fs.Output(), "Policy flags adjust the active profile, or the one given with --profile.")
+		fs.PrintDefaults()
+	}
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *count < 1 {
+		fmt.Fprintln(fs.Output(), "--count must be at least 1")
+		fs.Usage()
+		return errUsage
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *list:
+		return printGeneratorProfiles(env, v)
+	case *deleteName != "":
+		if err = v.DeleteGeneratorProfile(*deleteName); err != nil {
+			return err
+		}
+		fmt.Fprintf(env.Stderr, "Deleted generator profile %s\n", *deleteName)
+		return nil
+	}
+
+	profile, err := v.ActiveGeneratorProfile()
+	if err != nil {
+		return err
+	}
+	if *profileName != "" {
+		if profile, err = findGeneratorProfile(v, *profileName); err != nil {
+			return err
+		}
+	}
+	policy = overridePolicy(fs, profile.PasswordPolicy, policy)
+
+	if *save != "" {
+		if err = v.SaveGeneratorProfile(*save, policy); err != nil {
+			return err
+		}
+		fmt.Fprintf(env.Stderr, "Saved generator profile %s\n", *save)
+		profile.Name = *save
+	}
+	if *activate {
+		if err = v.ActivateGeneratorProfile(profile.Name); err != nil {
+			return err
+		}
+		fmt.Fprintf(env.Stderr, "Activated generator profile %s\n", profile.Name)
+	}
+
+	for range *count {
+		password, err := utils.GeneratePasswordWithPolicy(policy)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(env.Stdout, password)
+	}
+	bits, err := utils.PolicyEntropy(policy)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.Stderr, "Entropy: %.1f bits\n", bits)
+	return nil
+}
+
+// capitalizations lists the capitalizations of passphrase words for the usage message
+func capitalizations() string {
+	names := make([]string, len(model.Capitalizations))
+	for i, capitalization := range model.Capitalizations {
+		names[i] = string(capitalization)
+	}
+	return strings.Join(names, ", ")
+}
+
+// overridePolicy returns base with the policy flags given on the command line taken from flags
+func overridePolicy(fs *flag.FlagSet, base, flags model.PasswordPolicy) model.PasswordPolicy {
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "length":
+			base.Length = flags.Length
+		case "lower":
+			base.Lower = flags.Lower
+		case "upper":
+			base.Upper = flags.Upper
+		case "digits":
+			base.Digits = flags.Digits
+		case "symbols":
+			base.Symbols = flags.Symbols
+		case "min-lower":
+			base.MinLower = flags.MinLower
+		case "min-upper":
+			base.MinUpper = flags.MinUpper
+		case "min-digits":
+			base.MinDigits = flags.MinDigits
+		case "min-symbols":
+			base.MinSymbols = flags.MinSymbols
+		case "symbol-set":
+			base.SymbolSet = flags.SymbolSet
+		case "exclude-ambiguous":
+			base.ExcludeAmbiguous = flags.ExcludeAmbiguous
+		case "no-repeat":
+			base.NoRepeat = flags.NoRepeat
+		case "passphrase":
+			base.Mode = flags.Mode
+		case "words":
+			base.Words = flags.Words
+		case "wordlist":
+			base.Wordlist = flags.Wordlist
+		case "separator":
+			base.Separator = flags.Separator
+		case "capitalize":
+			base.Capitalization = flags.Capitalization
+		case "append-digit":
+			base.AppendDigit = flags.AppendDigit
+		case "append-symbol":
+			base.AppendSymbol = flags.AppendSymbol
+		}
+	})
+	return base
+}
+
+// findGeneratorProfile returns the generator profile named name
+func findGeneratorProfile(v vault.Vault, name string) (model.GeneratorProfile, error) {
+	all, err := v.GeneratorProfiles()
+	if err != nil {
+		return model.GeneratorProfile{}, err
+	}
+	for _, profile := range all {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return model.GeneratorProfile{}, model.NewGeneratorProfileNotFoundError(v.UserID(), name)
+}
+
+// printGeneratorProfiles lists the generator profiles with the entropy of their passwords, marking the active one
+func printGeneratorProfiles(env Env, v vault.Vault) error {
+	all, err := v.GeneratorProfiles()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tACTIVE\tLENGTH\tCHARACTERS\tRULES\tENTROPY")
+	for _, profile := range all {
+		active := ""
+		if profile.Active {
+			active = "*"
+		}
+		length := strconv.Itoa(profile.Length)
+		if profile.IsPassphrase() {
+			length = fmt.Sprintf("%d words", profile.Words)
+		}
+		entropy := "-"
+		if bits, err := utils.PolicyEntropy(profile.PasswordPolicy); err == nil {
+			entropy = fmt.Sprintf("%.0f bits", bits)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", profile.Name, active, length,
+			policyCharacters(profile.PasswordPolicy), policyRules(profile.PasswordPolicy), entropy)
+	}
+	return w.Flush()
+}
+
+// policyCharacters describes the character classes of a policy with their minimum counts, or the
+// wordlist of a passphrase policy with what is appended to it
+func policyCharacters(policy model.PasswordPolicy) string {
+	if policy.IsPassphrase() {
+		parts := []string{policy.Wordlist}
+		if policy.AppendDigit {
+			parts = append(parts, "+digit")
+		}
+		if policy.AppendSymbol {
+			parts = append(parts, "+symbol")
+		}
+		return strings.Join(parts, " ")
+	}
+
+	var classes []string
+	add := func(include bool, name string, minCount int) {
+		if include {
+			classes = append(classes, fmt.Sprintf("%s>=%d", name, minCount))
+		}
+	}
+	add(policy.Lower, "lower", policy.MinLower)
+	add(policy.Upper, "upper", policy.MinUpper)
+	add(policy.Digits, "digits", policy.MinDigits)
+	add(policy.Symbols, "symbols", policy.MinSymbols)
+	return strings.Join(classes, " ")
+}
+
+// policyRules describes the symbol set and the exclusion rules of a policy, and the separator and
+// capitalization of a passphrase policy
+func policyRules(policy model.PasswordPolicy) string {
+	var rules []string
+	if policy.IsPassphrase() {
+		rules = append(rules, fmt.Sprintf("separator %q", policy.Separator))
+		if policy.Capitalization != "" && policy.Capitalization != model.CapitalizeNone {
+			rules = append(rules, "capitalize "+string(policy.Capitalization))
+		}
+	}
+	if policy.SymbolSet != "" && (!policy.IsPassphrase() || policy.AppendSymbol) {
+		rules = append(rules, "symbols "+policy.SymbolSet)
+	}
+	if policy.ExcludeAmbiguous {
+		rules = append(rules, "no ambiguous")
+	}
+	if policy.NoRepeat && !policy.IsPassphrase() {
+		rules = append(rules, "no repeats")
+	}
+	if len(rules) == 0 {
+		return "-"
+	}
+	return strings.Join(rules, ", ")
+}
@@ -0,0 +1,121 @@
+//go:build integration
+
+package command
+
+import (
+	"strings"
+	"testing"
+	"yubigo-pass/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateShouldPrintPasswordsOfActiveProfile(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"generate", "--count", "3"})
+
+	// then
+	assert.Equal(t, ExitOK, code, e.stderr.String())
+	passwords := strings.Fields(e.stdout.String())
+	require.Len(t, passwords, 3)
+	for _, password := range passwords {
+		assert.Len(t, password, model.DefaultPasswordPolicy.Length)
+	}
+}
+
+func TestGenerateShouldSaveAndActivateAdjustedProfile(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"generate", "--length", "8", "--lower=false", "--upper=false", "--symbols=false",
+		"--min-digits", "8", "--save", "pin", "--activate"})
+	stdout := e.stdout.String()
+	e.stdout.Reset()
+	listCode := Run(e.env, []string{"generate", "--list"})
+
+	// then
+	assert.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, ExitOK, listCode, e.stderr.String())
+	password := strings.TrimSpace(stdout)
+	assert.Len(t, password, 8)
+	assert.Empty(t, strings.Trim(password, "0123456789"))
+	assert.Contains(t, e.stderr.String(), "Saved generator profile pin")
+	assert.Contains(t, e.stderr.String(), "Activated generator profile pin")
+
+	active, err := e.vault(t).ActiveGeneratorProfile()
+	require.NoError(t, err)
+	assert.Equal(t, "pin", active.Name)
+	expected := model.DefaultPasswordPolicy
+	expected.Length, expected.Lower, expected.Upper, expected.Symbols, expected.MinDigits = 8, false, false, false, 8
+	assert.Equal(t, expected, active.PasswordPolicy)
+	lines := strings.Split(strings.TrimSpace(e.stdout.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "NAME")
+	assert.Regexp(t, `^default\s+20\s+lower>=1 upper>=1 digits>=1 symbols>=1\s+-\s+130 bits$`, lines[1])
+	assert.Regexp(t, `^pin\s+\*\s+8\s+digits>=8\s+-\s+27 bits$`, lines[2])
+}
+
+func TestGenerateShouldPrintPassphrasesWithEntropy(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"generate", "--passphrase", "--words", "4", "--separator", " ", "--capitalize", "all",
+		"--append-digit", "--save", "words"})
+	stdout := e.stdout.String()
+	e.stdout.Reset()
+	listCode := Run(e.env, []string{"generate", "--list"})
+
+	// then
+	assert.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, ExitOK, listCode, e.stderr.String())
+	assert.Regexp(t, `^[A-Z-]+ [A-Z-]+ [A-Z-]+ [A-Z-]+[0-9]\n$`, stdout)
+	assert.Contains(t, e.stderr.String(), "Entropy: 55.0 bits")
+	lines := strings.Split(strings.TrimSpace(e.stdout.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^words\s+4 words\s+eff-large \+digit\s+separator " ", capitalize all\s+55 bits$`, lines[2])
+}
+
+func TestGenerateShouldUseNamedProfileAndRejectInvalidPolicy(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	require.NoError(t, e.vault(t).SaveGeneratorProfile("symbols", model.PasswordPolicy{Length: 10, Symbols: true, SymbolSet: "#"}))
+
+	// when
+	code := Run(e.env, []string{"generate", "--profile", "symbols"})
+	stdout := e.stdout.String()
+	invalidCode := Run(e.env, []string{"generate", "--profile", "symbols", "--no-repeat"})
+	missingCode := Run(e.env, []string{"generate", "--profile", "missing"})
+
+	// then
+	assert.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "##########\n", stdout)
+	assert.Equal(t, ExitError, invalidCode)
+	assert.Contains(t, e.stderr.String(), "only 1 distinct characters are allowed, too few for 10 characters without repeats")
+	assert.Equal(t, ExitError, missingCode)
+	assert.Contains(t, e.stderr.String(), "generator profile not found: missing")
+}
+
+func TestGenerateShouldDeleteProfile(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	require.NoError(t, v.SaveGeneratorProfile("pin", model.PasswordPolicy{Length: 6, Digits: true}))
+
+	// when
+	code := Run(e.env, []string{"generate", "--delete", "pin"})
+	missingCode := Run(e.env, []string{"generate", "--delete", "pin"})
+
+	// then
+	assert.Equal(t, ExitOK, code)
+	assert.Equal(t, ExitError, missingCode)
+	profiles, err := v.GeneratorProfiles()
+	require.NoError(t, err)
+	require.Len(t, profiles, 1)
+	assert.Equal(t, model.DefaultGeneratorProfileName, profiles[0].Name)
+}
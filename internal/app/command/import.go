@@ -0,0 +1,237 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/bitwarden"
+	"yubigo-pass/internal/app/importer"
+	"yubigo-pass/internal/app/keepass"
+)
+
+// Formats of other password managers read by import and written by export
+const (
+	formatBitwarden = "bitwarden"
+	formatKeePass   = "keepass"
+	formatCSV       = "csv"
+	formatArchive   = "archive"
+	formats         = formatBitwarden + ", " + formatKeePass
+)
+
+// importCommand imports the export of another password manager
+func importCommand() Command {
+	return Command{
+		Name:    "import",
+		Summary: "Import entries exported from another password manager",
+		Run:     runImport,
+	}
+}
+
+func runImport(env Env, args []string) error {
+	fs, username := newFlagSet(env, "import")
+	format := fs.String("format", formatBitwarden, "format of the export, one of: "+formats+", "+formatCSV+", "+formatArchive+", yubigo-pass archives are recognized without it")
+	keyFile := fs.String("keyfile", "", "key file that, with or instead of the password, unlocks a KeePass database")
+	preset := fs.String("preset", "", "CSV layout, one of: "+csvPresetNames()+", detected from the header when left out")
+	columns := fs.String("map", "", "CSV columns to read values from, e.g. title=Name,username=Login, on top of the preset")
+	duplicates := fs.String("duplicates", importer.DuplicateSkip.String(), "what to do with entries that already exist: skip, overwrite or rename")
+	dryRun := fs.Bool("dry-run", false, "show what would be added, updated or skipped without importing anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass import <file|-> [flags]")
+		fmt.Fprintln(fs.Output(), "Password protected exports are opened with $"+EnvExportPassword+" or a prompt.")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *format != formatBitwarden && *format != formatKeePass && *format != formatCSV && *format != formatArchive {
+		fmt.Fprintf(fs.Output(), "unsupported format %q\n", *format)
+		fs.Usage()
+		return errUsage
+	}
+	policy, err := importer.ParseDuplicatePolicy(*duplicates)
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return errUsage
+	}
+
+	data, err := readInput(env, positional[0])
+	if err != nil {
+		return err
+	}
+	if _, err = archive.ReadHeader(bytes.NewReader(data)); !errors.Is(err, archive.ErrNotArchive) {
+		*format = formatArchive
+	}
+	// The export is read before unlocking, so an unreadable file imports nothing
+	var batch importer.Batch
+	switch *format {
+	case formatArchive:
+		var passphrase string
+		passphrase, err = readSecret(env, EnvExportPassword, "Archive passphrase: ")
+		if err != nil {
+			return err
+		}
+		batch, err = importer.ReadArchive(bytes.NewReader(data), passphrase)
+	case formatKeePass:
+		var credentials keepass.Credentials
+		credentials, err = keepassCredentials(env, *keyFile)
+		if err != nil {
+			return err
+		}
+		batch, err = importer.ReadKeePass(bytes.NewReader(data), credentials)
+	case formatCSV:
+		var mapping importer.CSVMapping
+		mapping, err = csvMapping(data, *preset, *columns)
+		if err != nil {
+			return err
+		}
+		batch, err = importer.ReadCSV(bytes.NewReader(data), mapping)
+	default:
+		batch, err = importer.ReadBitwarden(bytes.NewReader(data), "")
+		if errors.Is(err, bitwarden.ErrPasswordRequired) {
+			var password string
+			password, err = readSecret(env, EnvExportPassword, "Export password: ")
+			if err != nil {
+				return err
+			}
+			batch, err = importer.ReadBitwarden(bytes.NewReader(data), password)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+	report, err := importer.Import(v, batch, importer.Options{
+		AttachmentDir: env.Container.AttachmentDir,
+		Duplicates:    policy,
+		DryRun:        *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		printImportPreview(env, report)
+		return nil
+	}
+	if err = recordImport(v, report); err != nil {
+		return err
+	}
+	printImportReport(env, report)
+	return nil
+}
+
+// csvMapping picks the columns of a CSV export to read values from, by the named preset or
+// the one matching the header, with columns mapped by hand in spec taking precedence
+func csvMapping(data []byte, presetName, spec string) (importer.CSVMapping, error) {
+	header, err := importer.ReadCSVHeader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := importer.CSVMapping{}
+	switch {
+	case presetName != "":
+		var preset importer.CSVPreset
+		for _, p := range importer.CSVPresets {
+			if strings.EqualFold(p.Name, presetName) {
+				preset = p
+			}
+		}
+		if preset.Name == "" {
+			return nil, fmt.Errorf("unknown CSV preset %q, expected one of: %s", presetName, csvPresetNames())
+		}
+		var ok bool
+		if mapping, ok = preset.Mapping(header); !ok {
+			return nil, fmt.Errorf("CSV header does not match the %s preset: %s", preset.Name, strings.Join(header, ", "))
+		}
+	default:
+		if _, detected, ok := importer.DetectCSVPreset(header); ok {
+			mapping = detected
+		} else if spec == "" {
+			return nil, fmt.Errorf("unknown CSV layout, pick a --preset or map columns with --map: %s", strings.Join(header, ", "))
+		}
+	}
+
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		column, name, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid column mapping %q, expected value=header", pair)
+		}
+		target := importer.CSVColumn(strings.ToLower(strings.TrimSpace(column)))
+		if !slices.Contains(importer.CSVColumns, target) {
+			return nil, fmt.Errorf("unknown value %q, expected one of: %s", column, csvColumnNames())
+		}
+		index := slices.IndexFunc(header, func(h string) bool { return strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(name)) })
+		if index < 0 {
+			return nil, fmt.Errorf("CSV header has no column %q", name)
+		}
+		mapping[target] = index
+	}
+	if _, ok := mapping[importer.CSVTitle]; !ok {
+		if _, ok = mapping[importer.CSVURL]; !ok {
+			return nil, errors.New("map a title or url column to name the entries by")
+		}
+	}
+	return mapping, nil
+}
+
+// csvPresetNames lists the names of the CSV presets
+func csvPresetNames() string {
+	names := make([]string, 0, len(importer.CSVPresets))
+	for _, preset := range importer.CSVPresets {
+		names = append(names, strings.ToLower(preset.Name))
+	}
+	return strings.Join(names, ", ")
+}
+
+// csvColumnNames lists the values CSV columns can be mapped onto
+func csvColumnNames() string {
+	names := make([]string, 0, len(importer.CSVColumns))
+	for _, column := range importer.CSVColumns {
+		names = append(names, string(column))
+	}
+	return strings.Join(names, ", ")
+}
+
+// readInput reads a whole file, or stdin in place of the path -
+func readInput(env Env, path string) ([]byte, error) {
+	if path == stdioPath {
+		return io.ReadAll(env.Stdin)
+	}
+	return os.ReadFile(path) // #nosec G304 -- the user picks the file to import
+}
+
+// keepassCredentials reads the password of a KeePass database, which may be left empty
+// when a key file is given, and the key file itself
+func keepassCredentials(env Env, keyFile string) (keepass.Credentials, error) {
+	var credentials keepass.Credentials
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile) // #nosec G304 -- the user picks the key file
+		if err != nil {
+			return keepass.Credentials{}, err
+		}
+		credentials.KeyFile = data
+	}
+	password, err := readSecret(env, EnvExportPassword, "KeePass password: ")
+	if err != nil {
+		return keepass.Credentials{}, err
+	}
+	credentials.Password = password
+	if credentials.Password == "" && len(credentials.KeyFile) == 0 {
+		return keepass.Credentials{}, keepass.ErrNoCredentials
+	}
+	return credentials, nil
+}
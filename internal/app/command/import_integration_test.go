@@ -0,0 +1,268 @@
+//go:build integration
+
+package command
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/bitwarden"
+	"yubigo-pass/internal/app/keepass"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBitwardenExport is an unencrypted Bitwarden export with a login filed in a folder,
+// a secure note and an item of a type without a counterpart
+const testBitwardenExport = `{
+  "encrypted": false,
+  "folders": [{"id": "f1", "name": "Work"}],
+  "items": [
+    {"id": "i1", "folderId": "f1", "type": 1, "name": "GitHub", "notes": null,
+     "fields": [{"name": "PIN", "value": "4242", "type": 1, "linkedId": null}],
+     "login": {"uris": [{"match": null, "uri": "https://github.com"}], "username": "octocat", "password": "hunter2", "totp": null}},
+    {"id": "i2", "folderId": null, "type": 2, "name": "Wi-Fi", "notes": "password on the router", "secureNote": {"type": 0}},
+    {"id": "i3", "folderId": null, "type": 9, "name": "Passkey"}
+  ]
+}`
+
+func writeExport(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "export.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestImportShouldCreateEntriesFromBitwardenExport(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	path := writeExport(t, testBitwardenExport)
+
+	// when
+	code := Run(e.env, []string{"import", path, "--format", "bitwarden"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Added GitHub (octocat)\nAdded Wi-Fi ()\n", e.stdout.String())
+	assert.Contains(t, e.stderr.String(), "Skipped Passkey: unsupported Bitwarden item type 9")
+	assert.Contains(t, e.stderr.String(), "2 added, 0 updated, 1 skipped")
+
+	v := e.vault(t)
+	github, err := v.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	details, err := v.Reveal(github)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", details.Entry.Password)
+	assert.Equal(t, []string{"Work"}, details.Folders)
+	require.Len(t, details.Fields, 1)
+	assert.Equal(t, "PIN", details.Fields[0].Name)
+	vaulttest.AssertLastAuditEvent(t, v, model.AuditImported, model.ClientCLI)
+}
+
+func TestImportShouldReportDuplicateEntries(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "a"}, nil, "", nil))
+	path := writeExport(t, testBitwardenExport)
+
+	// when
+	code := Run(e.env, []string{"import", path})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Added Wi-Fi ()\n", e.stdout.String())
+	assert.Contains(t, e.stderr.String(), "Skipped GitHub (octocat): already exists")
+	assert.Contains(t, e.stderr.String(), "1 added, 0 updated, 2 skipped")
+}
+
+func TestImportShouldOpenPasswordProtectedExport(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	exported, err := bitwarden.Parse([]byte(testBitwardenExport), "")
+	require.NoError(t, err)
+	data, err := bitwarden.Protect(exported, "correct horse", bitwarden.KDF{Type: bitwarden.KDFTypePBKDF2, Iterations: 1000})
+	require.NoError(t, err)
+	e.env.Stdin = strings.NewReader("correct horse\n")
+
+	// when
+	code := Run(e.env, []string{"import", writeExport(t, string(data))})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stderr.String(), "Export password: ")
+	assert.Contains(t, e.stderr.String(), "2 added, 0 updated, 1 skipped")
+}
+
+func TestImportShouldFailOnWrongExportPassword(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	data, err := bitwarden.Protect(bitwarden.Vault{}, "correct horse", bitwarden.KDF{Type: bitwarden.KDFTypePBKDF2, Iterations: 1000})
+	require.NoError(t, err)
+	t.Setenv(EnvExportPassword, "battery staple")
+
+	// when
+	code := Run(e.env, []string{"import", writeExport(t, string(data))})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), bitwarden.ErrWrongPassword.Error())
+}
+
+func TestImportShouldRejectUnsupportedFormat(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"import", "export.csv", "--format", "1password"})
+
+	// then
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, e.stderr.String(), `unsupported format "1password"`)
+}
+
+func TestImportShouldCreateEntriesFromKeePassDatabase(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	t.Setenv(EnvExportPassword, "correct horse")
+	entry := keepass.Entry{Strings: []keepass.String{
+		{Key: keepass.KeyTitle, Value: keepass.Value{Content: "GitHub"}},
+		{Key: keepass.KeyUserName, Value: keepass.Value{Content: "octocat"}},
+		{Key: keepass.KeyPassword, Value: keepass.Value{Content: "hunter2", Protected: true}},
+	}, Binaries: []keepass.BinaryRef{keepass.NewBinaryRef("recovery.txt", 0)}}
+	db := keepass.Database{
+		Root:     keepass.Group{Name: "Root", Groups: []keepass.Group{{Name: "Work", Entries: []keepass.Entry{entry}}}},
+		Binaries: []keepass.Binary{{Data: []byte("codes")}},
+	}
+	data, err := keepass.Marshal(db, keepass.Credentials{Password: "correct horse"}, test.KeePassOptions)
+	require.NoError(t, err)
+	path := writeExport(t, string(data))
+
+	// when
+	code := Run(e.env, []string{"import", path, "--format", "keepass"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Added GitHub (octocat)\n", e.stdout.String())
+
+	v := e.vault(t)
+	github, err := v.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	details, err := v.Reveal(github)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", details.Entry.Password)
+	assert.Equal(t, []string{"Work"}, details.Folders)
+	files, err := vault.NewAttachments(v, e.env.Container.AttachmentDir).List(github.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "recovery.txt", files[0].Name)
+}
+
+func TestImportShouldRejectWrongKeePassPassword(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	t.Setenv(EnvExportPassword, "wrong")
+	data, err := keepass.Marshal(keepass.Database{Root: keepass.Group{Name: "Root"}}, keepass.Credentials{Password: "correct horse"}, test.KeePassOptions)
+	require.NoError(t, err)
+	path := writeExport(t, string(data))
+
+	// when
+	code := Run(e.env, []string{"import", path, "--format", "keepass"})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), keepass.ErrWrongCredentials.Error())
+}
+
+func TestImportShouldReadCSVExportOfDetectedPreset(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	path := writeExport(t, "url,username,password,totp,extra,name,grouping,fav\n"+
+		"https://github.com,octocat,hunter2,,,GitHub,Work,0\n")
+
+	// when
+	code := Run(e.env, []string{"import", path, "--format", "csv"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Added GitHub (octocat)\n", e.stdout.String())
+	github, err := e.vault(t).FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	details, err := e.vault(t).Reveal(github)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Work"}, details.Folders)
+}
+
+func TestImportShouldMapCSVColumnsByHand(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	path := writeExport(t, "Site,Login,Secret\nGitHub,octocat,hunter2\n")
+
+	// when
+	code := Run(e.env, []string{"import", path, "--format", "csv", "--map", "title=site,username=Login,password=Secret"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Added GitHub (octocat)\n", e.stdout.String())
+}
+
+func TestImportShouldRejectUnknownCSVLayout(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	path := writeExport(t, "Site,Login,Secret\nGitHub,octocat,hunter2\n")
+
+	// when
+	code := Run(e.env, []string{"import", path, "--format", "csv"})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "unknown CSV layout, pick a --preset or map columns with --map: Site, Login, Secret")
+}
+
+func TestImportShouldPreviewAndRenameDuplicates(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "old"}, nil, "", nil))
+	path := writeExport(t, "name,url,username,password\nGitHub,https://github.com,octocat,new\n")
+
+	// when
+	code := Run(e.env, []string{"import", path, "--format", "csv", "--duplicates", "rename", "--dry-run"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Would add GitHub (2) (octocat)\n", e.stdout.String())
+	assert.Contains(t, e.stderr.String(), "Dry run: 1 to add, 0 to update, 0 to skip, nothing was imported")
+	entries, err := e.vault(t).Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// when
+	code = Run(e.env, []string{"import", path, "--format", "csv", "--duplicates", "rename"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	_, err = e.vault(t).FindEntry("GitHub (2)", "octocat")
+	assert.NoError(t, err)
+}
+
+func TestImportShouldRejectArchiveWithWrongPassphrase(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	var sealed bytes.Buffer
+	require.NoError(t, archive.Write(&sealed, archive.Document{}, "correct horse", archive.KDF{Iterations: 1, Memory: 64, Parallelism: 1}))
+	path := writeExport(t, sealed.String())
+	t.Setenv(EnvExportPassword, "battery staple")
+
+	// when
+	code := Run(e.env, []string{"import", path})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), archive.ErrWrongPassphrase.Error())
+}
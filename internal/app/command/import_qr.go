@@ -0,0 +1,114 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/otp"
+	"yubigo-pass/internal/app/vault"
+)
+
+// importQRCommand imports one-time password keys from QR code images
+func importQRCommand() Command {
+	return Command{
+		Name:    "import-qr",
+		Summary: "Import one-time passwords from QR code images",
+		Run:     runImportQR,
+	}
+}
+
+func runImportQR(env Env, args []string) error {
+	fs, username := newFlagSet(env, "import-qr")
+	folder := fs.String("folder", "", "folder to file new entries under")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass import-qr <image>... [flags]")
+		fmt.Fprintln(fs.Output(), "Reads otpauth:// codes and Google Authenticator otpauth-migration:// exports from PNG or JPEG files.")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	// Every image is decoded before unlocking, so an unreadable file imports nothing
+	var keys []otp.Key
+	for _, path := range positional {
+		read, err := readQRFile(path)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, read...)
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+	report, err := v.ImportOTPKeys(keys, *folder)
+	if err != nil {
+		return err
+	}
+	if err = recordImport(v, report); err != nil {
+		return err
+	}
+	printImportReport(env, report)
+	return nil
+}
+
+// readQRFile returns the one-time password keys of the QR codes in an image file
+func readQRFile(path string) ([]otp.Key, error) {
+	file, err := os.Open(path) // #nosec G304 -- the user picks the image to import
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	keys, err := otp.ReadQR(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// recordImport records in the audit log of the vault that an import stored entries in it
+func recordImport(v vault.Vault, report vault.ImportReport) error {
+	if len(report.Added) == 0 && len(report.Updated) == 0 {
+		return nil
+	}
+	return v.Record(model.AuditImported, model.ClientCLI, model.Password{})
+}
+
+// printImportReport lists the imported entries on stdout and the skipped and duplicate records on stderr
+func printImportReport(env Env, report vault.ImportReport) {
+	printNames(env.Stdout, "Added", report.Added)
+	printNames(env.Stdout, "Updated", report.Updated)
+	for _, skipped := range report.Skipped {
+		fmt.Fprintf(env.Stderr, "Skipped %s: %s\n", skipped.Name, skipped.Reason)
+	}
+	for _, duplicate := range report.Duplicates {
+		fmt.Fprintf(env.Stderr, "Skipped %s (%s): already exists\n", duplicate.Title, duplicate.Username)
+	}
+	fmt.Fprintf(env.Stderr, "%d added, %d updated, %d skipped\n", len(report.Added), len(report.Updated), len(report.Skipped)+len(report.Duplicates))
+}
+
+// printImportPreview lists what a dry run of an import would do, on stdout what would be
+// stored and on stderr what would be skipped
+func printImportPreview(env Env, report vault.ImportReport) {
+	printNames(env.Stdout, "Would add", report.Added)
+	printNames(env.Stdout, "Would update", report.Updated)
+	for _, skipped := range report.Skipped {
+		fmt.Fprintf(env.Stderr, "Would skip %s: %s\n", skipped.Name, skipped.Reason)
+	}
+	for _, duplicate := range report.Duplicates {
+		fmt.Fprintf(env.Stderr, "Would skip %s (%s): already exists\n", duplicate.Title, duplicate.Username)
+	}
+	fmt.Fprintf(env.Stderr, "Dry run: %d to add, %d to update, %d to skip, nothing was imported\n",
+		len(report.Added), len(report.Updated), len(report.Skipped)+len(report.Duplicates))
+}
+
+// printNames prints a line per name prefixed with what happened to it
+func printNames(w io.Writer, action string, names []string) {
+	for _, name := range names {
+		fmt.Fprintf(w, "%s %s\n", action, name)
+	}
+}
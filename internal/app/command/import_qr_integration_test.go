@@ -0,0 +1,97 @@
+//go:build integration
+
+package command
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportQRShouldCreateEntriesFromImages(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	single := filepath.Join(t.TempDir(), "github.png")
+	test.WriteQRCode(t, single, "otpauth://totp/GitHub:octocat?secret="+test.OTPSecret+"&digits=8")
+	batch := filepath.Join(t.TempDir(), "export.png")
+	test.WriteQRCode(t, batch, test.MigrationURI(
+		test.MigrationAccount{Secret: []byte("12345678901234567890"), Name: "me@bank", Issuer: "Bank", HOTP: true, Counter: 1},
+		test.MigrationAccount{Secret: []byte("12345678901234567890"), Name: "Mail:me@mail"},
+	))
+
+	// when
+	code := Run(e.env, []string{"import-qr", single, batch, "--folder", "2FA"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Added GitHub (octocat)\nAdded Bank (me@bank)\nAdded Mail (me@mail)\n", e.stdout.String())
+	assert.Contains(t, e.stderr.String(), "3 added, 0 updated, 0 skipped")
+
+	v := e.vault(t)
+	github, err := v.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	otpCode, err := v.OneTimeCode(github, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "94287082", otpCode.Value)
+	bank, err := v.FindEntry("Bank", "me@bank")
+	require.NoError(t, err)
+	otpCode, err = v.OneTimeCode(bank, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "287082", otpCode.Value)
+	vaulttest.AssertLastAuditEvent(t, v, model.AuditImported, model.ClientCLI)
+}
+
+func TestImportQRShouldReportEntriesAlreadyHoldingOneTimePassword(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	uri := "otpauth://totp/GitHub:octocat?secret=" + test.OTPSecret
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "a", OTP: uri}, nil, "", nil))
+	image := filepath.Join(t.TempDir(), "github.png")
+	test.WriteQRCode(t, image, uri)
+
+	// when
+	code := Run(e.env, []string{"import-qr", image})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Empty(t, e.stdout.String())
+	assert.Contains(t, e.stderr.String(), "Skipped GitHub (octocat): already has a one-time password")
+	assert.Contains(t, e.stderr.String(), "0 added, 0 updated, 1 skipped")
+}
+
+func TestImportQRShouldFailWithoutImportingOnUnreadableImage(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	valid := filepath.Join(t.TempDir(), "github.png")
+	test.WriteQRCode(t, valid, "otpauth://totp/GitHub:octocat?secret="+test.OTPSecret)
+	invalid := filepath.Join(t.TempDir(), "website.png")
+	test.WriteQRCode(t, invalid, "https://example.com")
+
+	// when
+	code := Run(e.env, []string{"import-qr", valid, invalid})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), invalid+": QR code must be an otpauth:// URI")
+	entries, err := e.vault(t).Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestImportQRShouldRequireImage(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"import-qr"})
+
+	// then
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, e.stderr.String(), "missing arguments")
+}
@@ -0,0 +1,142 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+)
+
+// listCommand prints the user's entries, optionally filtered by folder, tag and item type
+func listCommand() Command {
+	return Command{
+		Name:    "list",
+		Summary: "List entries, filtered with --folder, --tag and --type",
+		Run:     runList,
+	}
+}
+
+func runList(env Env, args []string) error {
+	fs, username := newFlagSet(env, "list")
+	folderPath := fs.String("folder", "", "only list entries filed in this folder, e.g. Work/Servers")
+	tagName := fs.String("tag", "", "only list entries labelled with this tag")
+	typeName := fs.String("type", "", "only list items of this type: "+itemTypeNames())
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	var itemType model.ItemType
+	if *typeName != "" {
+		parsed, err := model.ParseItemType(*typeName)
+		if err != nil {
+			return err
+		}
+		itemType = parsed
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+
+	tree, err := v.Folders()
+	if err != nil {
+		return err
+	}
+
+	entries, err := filterEntries(v, tree, *folderPath, *tagName)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TITLE\tTYPE\tUSERNAME\tURL\tFOLDERS\tTAGS")
+	for _, entry := range entries {
+		if itemType != "" && entry.ItemType() != itemType {
+			continue
+		}
+		folders, err := v.EntryFolders(entry.ID)
+		if err != nil {
+			return err
+		}
+		tags, err := v.EntryTags(entry.ID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.Title, entry.ItemType(), entry.Username, entry.Url, folderPaths(tree, folders), tagNames(tags))
+	}
+	return w.Flush()
+}
+
+// filterEntries returns the entries matching both the folder and the tag filter, empty filters match everything
+func filterEntries(v vault.Vault, tree vault.FolderTree, folderPath, tagName string) ([]model.Password, error) {
+	var entries []model.Password
+	var err error
+
+	if folderPath != "" {
+		folder, ok := tree.Find(folderPath)
+		if !ok {
+			return nil, fmt.Errorf("folder not found: %s", folderPath)
+		}
+		entries, err = v.EntriesInFolder(folder.ID)
+	} else {
+		entries, err = v.Entries()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if tagName == "" {
+		return entries, nil
+	}
+
+	tag, ok, err := v.FindTag(strings.TrimSpace(tagName))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("tag not found: %s", tagName)
+	}
+	tagged, err := v.EntriesWithTag(tag.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	taggedIDs := make(map[string]bool, len(tagged))
+	for _, entry := range tagged {
+		taggedIDs[entry.ID] = true
+	}
+	var filtered []model.Password
+	for _, entry := range entries {
+		if taggedIDs[entry.ID] {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+func itemTypeNames() string {
+	names := make([]string, 0, len(model.ItemTypes))
+	for _, t := range model.ItemTypes {
+		names = append(names, string(t))
+	}
+	return strings.Join(names, ", ")
+}
+
+func folderPaths(tree vault.FolderTree, folders []vault.Folder) string {
+	paths := make([]string, 0, len(folders))
+	for _, f := range folders {
+		paths = append(paths, tree.Path(f.ID))
+	}
+	return strings.Join(paths, ",")
+}
+
+func tagNames(tags []vault.Tag) string {
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+	return strings.Join(names, ",")
+}
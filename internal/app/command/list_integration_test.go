@@ -0,0 +1,116 @@
+//go:build integration
+
+package command
+
+import (
+	"testing"
+	"yubigo-pass/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListShouldPrintAllEntries(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "octocat", Password: "secret"}, nil, "Work/Code", []string{"dev"}))
+	require.NoError(t, v.AddEntry(model.Password{Title: "bank", Username: "me", Password: "secret"}, nil, "", nil))
+
+	// when
+	code := Run(e.env, []string{"list"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	out := e.stdout.String()
+	assert.Contains(t, out, "github")
+	assert.Contains(t, out, "Work/Code")
+	assert.Contains(t, out, "dev")
+	assert.Contains(t, out, "bank")
+	assert.NotContains(t, out, "secret")
+}
+
+func TestListShouldFilterByFolderAndTag(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "octocat", Password: "x"}, nil, "Work", []string{"dev"}))
+	require.NoError(t, v.AddEntry(model.Password{Title: "jira", Username: "octocat", Password: "x"}, nil, "Work", []string{"pm"}))
+	require.NoError(t, v.AddEntry(model.Password{Title: "gitlab", Username: "octocat", Password: "x"}, nil, "Home", []string{"dev"}))
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected []string
+		excluded []string
+	}{
+		{name: "folder", args: []string{"--folder", "Work"}, expected: []string{"github", "jira"}, excluded: []string{"gitlab"}},
+		{name: "tag", args: []string{"--tag", "dev"}, expected: []string{"github", "gitlab"}, excluded: []string{"jira"}},
+		{name: "folder and tag", args: []string{"--folder", "Work", "--tag", "dev"}, expected: []string{"github"}, excluded: []string{"jira", "gitlab"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e.stdout.Reset()
+
+			// when
+			code := Run(e.env, append([]string{"list"}, tc.args...))
+
+			// then
+			require.Equal(t, ExitOK, code, e.stderr.String())
+			for _, title := range tc.expected {
+				assert.Contains(t, e.stdout.String(), title)
+			}
+			for _, title := range tc.excluded {
+				assert.NotContains(t, e.stdout.String(), title)
+			}
+		})
+	}
+}
+
+func TestListShouldFailForUnknownFolder(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"list", "--folder", "Nowhere"})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "folder not found: Nowhere")
+}
+
+func TestListShouldFilterByType(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "octocat", Password: "x"}, nil, "", nil))
+	card := model.Item{
+		Type:   model.ItemTypeCard,
+		Title:  "visa",
+		Values: model.ItemValues{"cardholder": "Jane Doe", "number": "4111111111111111", "expiry": "09/29"},
+	}
+	_, err := v.AddItem(card, nil, "", nil)
+	require.NoError(t, err)
+
+	// when
+	code := Run(e.env, []string{"list", "--type", "card"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stdout.String(), "visa")
+	assert.Contains(t, e.stdout.String(), "card")
+	assert.NotContains(t, e.stdout.String(), "github")
+}
+
+func TestListShouldRejectUnknownType(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"list", "--type", "wallet"})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "unknown item type wallet")
+}
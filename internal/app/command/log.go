@@ -0,0 +1,104 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+)
+
+// logCommand prints the audit log of the vault, optionally filtered by event, client, entry and date
+func logCommand() Command {
+	return Command{
+		Name:    "log",
+		Summary: "Show when secrets were read, copied, changed or exported",
+		Run:     runLog,
+	}
+}
+
+func runLog(env Env, args []string) error {
+	fs, username := newFlagSet(env, "log")
+	eventName := fs.String("event", "", "only show events of this kind: "+auditEventNames())
+	clientName := fs.String("client", "", "only show events through this client: "+auditClientNames())
+	entry := fs.String("entry", "", "only show events about entries whose title contains this text")
+	since := fs.String("since", "", "only show events from this date on, e.g. 2024-01-31")
+	limit := fs.Int("limit", 0, "show at most this many of the latest events, 0 for all")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass log [flags]")
+		fmt.Fprintln(fs.Output(), "Events are listed the latest first.")
+		fs.PrintDefaults()
+	}
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	filter := vault.AuditFilter{Entry: *entry}
+	var err error
+	if *eventName != "" {
+		if filter.Event, err = model.ParseAuditEvent(*eventName); err != nil {
+			return err
+		}
+	}
+	if *clientName != "" {
+		if filter.Client, err = model.ParseAuditClient(*clientName); err != nil {
+			return err
+		}
+	}
+	if *since != "" {
+		if filter.Since, err = time.ParseInLocation(time.DateOnly, *since, time.Local); err != nil {
+			return fmt.Errorf("invalid date %s, expected e.g. 2024-01-31", *since)
+		}
+	}
+	if *limit < 0 {
+		fmt.Fprintln(fs.Output(), "--limit must be at least 0")
+		fs.Usage()
+		return errUsage
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+	events, err := v.AuditLog(filter)
+	if err != nil {
+		return err
+	}
+	if *limit > 0 && len(events) > *limit {
+		events = events[:*limit]
+	}
+	if len(events) == 0 {
+		fmt.Fprintln(env.Stderr, "No events recorded")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tEVENT\tCLIENT\tENTRY")
+	for _, event := range events {
+		title := event.Title
+		if title == "" {
+			title = "(whole vault)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", event.Time.Format(time.DateTime), event.Event, event.Client, title)
+	}
+	return w.Flush()
+}
+
+// auditEventNames lists the names of the audit events for flag usage
+func auditEventNames() string {
+	names := make([]string, 0, len(model.AuditEvents))
+	for _, event := range model.AuditEvents {
+		names = append(names, string(event))
+	}
+	return strings.Join(names, ", ")
+}
+
+// auditClientNames lists the names of the audit clients for flag usage
+func auditClientNames() string {
+	names := make([]string, 0, len(model.AuditClients))
+	for _, client := range model.AuditClients {
+		names = append(names, string(client))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,67 @@
+//go:build integration
+
+package command
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"yubigo-pass/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogShouldListSecretsReadAndExported(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "hunter2"}, nil, "", nil))
+	require.Equal(t, ExitOK, Run(e.env, []string{"show", "GitHub"}), e.stderr.String())
+	require.Equal(t, ExitOK, Run(e.env, []string{"show", "GitHub", "--reveal"}), e.stderr.String())
+	out := filepath.Join(t.TempDir(), "export.json")
+	require.Equal(t, ExitOK, Run(e.env, []string{"export", "--out", out}), e.stderr.String())
+	e.stdout.Reset()
+
+	// when
+	code := Run(e.env, []string{"log"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	lines := strings.Split(strings.TrimSpace(e.stdout.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^TIME\s+EVENT\s+CLIENT\s+ENTRY$`, lines[0])
+	assert.Regexp(t, `exported\s+cli\s+\(whole vault\)$`, lines[1])
+	assert.Regexp(t, `read\s+cli\s+GitHub$`, lines[2])
+}
+
+func TestLogShouldFilterByEventAndEntry(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	require.NoError(t, v.Record(model.AuditRead, model.ClientTUI, model.Password{Title: "GitHub"}))
+	require.NoError(t, v.Record(model.AuditChanged, model.ClientTUI, model.Password{Title: "GitHub"}))
+	require.NoError(t, v.Record(model.AuditRead, model.ClientTUI, model.Password{Title: "Bank"}))
+	require.NoError(t, v.Record(model.AuditRead, model.ClientCLI, model.Password{Title: "GitHub"}))
+
+	// when
+	code := Run(e.env, []string{"log", "--event", "read", "--entry", "git", "--limit", "1"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Regexp(t, `read\s+cli\s+GitHub\n$`, e.stdout.String())
+	assert.NotContains(t, e.stdout.String(), "tui")
+	assert.NotContains(t, e.stdout.String(), "Bank")
+}
+
+func TestLogShouldRejectUnknownEvent(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"log", "--event", "deleted"})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "unknown audit event deleted")
+}
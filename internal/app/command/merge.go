@@ -0,0 +1,202 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"yubigo-pass/internal/app/cli"
+	"yubigo-pass/internal/app/merge"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/internal/database"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+)
+
+// EnvOtherPassword holds the master password of the vault merged by merge
+const EnvOtherPassword = "YUBIGO_PASS_OTHER_PASSWORD" // #nosec G101
+
+// Versions kept of conflicting entries with --prefer
+var preferences = map[string]merge.Resolution{
+	"mine":  merge.KeepLocal,
+	"other": merge.KeepOther,
+	"both":  merge.KeepBoth,
+}
+
+// mergeCommand merges the vault of another database file into the user's vault
+func mergeCommand() Command {
+	return Command{
+		Name:    "merge",
+		Summary: "Merge the vault of another database file into yours",
+		Run:     runMerge,
+	}
+}
+
+func runMerge(env Env, args []string) error {
+	fs, username := newFlagSet(env, "merge")
+	otherUser := fs.String("other-user", "", "username of the vault in the other file (defaults to --user)")
+	basePath := fs.String("base", "", "database file from before the vaults diverged, e.g. a backup, taking changes made on one side only")
+	prefer := fs.String("prefer", "", "version kept of conflicting entries without asking, one of: mine, other, both")
+	dryRun := fs.Bool("dry-run", false, "show what would be added, updated and in conflict without merging anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass merge <other.db> [flags]")
+		fmt.Fprintln(fs.Output(), "The other vault is unlocked with $"+EnvOtherPassword+" or a prompt, the base with your credentials.")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	preference, ok := preferences[*prefer]
+	if *prefer != "" && !ok {
+		fmt.Fprintf(fs.Output(), "unsupported preference %q\n", *prefer)
+		fs.Usage()
+		return errUsage
+	}
+
+	user, localPassword, err := credentials(env, *username)
+	if err != nil {
+		return err
+	}
+	session, err := vault.Unlock(env.Container.Store, user, localPassword)
+	if err != nil {
+		return err
+	}
+	local := vault.New(env.Container.Store, session)
+	if *otherUser == "" {
+		*otherUser = user
+	}
+
+	tempDir, err := os.MkdirTemp("", "yubigo-pass-merge-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	otherPath := positional[0]
+	password, err := readSecret(env, EnvOtherPassword, fmt.Sprintf("Master password of %s: ", otherPath))
+	if err != nil {
+		return err
+	}
+	other, closeOther, err := openVaultFile(env, otherPath, filepath.Join(tempDir, "other.db"), *otherUser, password)
+	if err != nil {
+		return err
+	}
+	defer closeOther()
+
+	var base *vault.Vault
+	if *basePath != "" {
+		baseVault, closeBase, err := openVaultFile(env, *basePath, filepath.Join(tempDir, "base.db"), user, localPassword)
+		if err != nil {
+			return err
+		}
+		defer closeBase()
+		base = &baseVault
+	}
+
+	plan, err := merge.Build(local, other, base)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		printMergePreview(env, plan)
+		return nil
+	}
+
+	switch {
+	case len(plan.Conflicts) == 0:
+	case *prefer != "":
+		plan.Resolve(preference)
+	case isTerminal(env):
+		confirmed, err := resolveConflicts(env, &plan)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Fprintln(env.Stderr, "Merge aborted, nothing was merged")
+			return nil
+		}
+	default:
+		printNames(env.Stderr, "Conflict", conflictNames(plan.Conflicts))
+		return fmt.Errorf("%d entries differ in both vaults, pick the versions kept on a terminal or with --prefer", len(plan.Conflicts))
+	}
+
+	report, err := merge.Apply(local, plan)
+	if err != nil {
+		return err
+	}
+	if len(report.Added) > 0 || len(report.Updated) > 0 || len(report.Copied) > 0 {
+		if err = local.Record(model.AuditImported, model.ClientCLI, model.Password{}); err != nil {
+			return err
+		}
+	}
+	printNames(env.Stdout, "Added", report.Added)
+	printNames(env.Stdout, "Updated", report.Updated)
+	printNames(env.Stdout, "Copied", report.Copied)
+	printNames(env.Stdout, "Kept", report.Kept)
+	fmt.Fprintf(env.Stderr, "%d added, %d updated, %d copied, %d kept, %d unchanged\n",
+		len(report.Added), len(report.Updated), len(report.Copied), len(report.Kept), plan.Unchanged)
+	return nil
+}
+
+// openVaultFile opens a copy of another database file and unlocks the vault of username in it.
+// The returned function closes the copy.
+func openVaultFile(env Env, path, copyPath, username, password string) (vault.Vault, func(), error) {
+	db, err := database.OpenCopy(path, copyPath, env.Container.MigrationPath)
+	if err != nil {
+		return vault.Vault{}, nil, err
+	}
+	store := database.NewStore(db)
+	session, err := vault.Unlock(store, username, password)
+	if err != nil {
+		_ = db.Close()
+		return vault.Vault{}, nil, fmt.Errorf("failed to unlock %s: %w", path, err)
+	}
+	return vault.New(store, session), func() { _ = db.Close() }, nil
+}
+
+// resolveConflicts lets the user pick the version kept of each conflicting entry on the terminal,
+// reporting whether the merge was confirmed
+func resolveConflicts(env Env, plan *merge.Plan) (bool, error) {
+	program := tea.NewProgram(cli.NewMergeConflictsModel(plan.Conflicts),
+		tea.WithInput(env.Stdin), tea.WithOutput(env.Stderr), tea.WithAltScreen())
+	final, err := program.Run()
+	if err != nil {
+		return false, err
+	}
+	model, ok := final.(cli.MergeConflictsModel)
+	if !ok {
+		return false, errors.New("unexpected merge conflicts model")
+	}
+	conflicts, confirmed := model.Conflicts()
+	plan.Conflicts = conflicts
+	return confirmed, nil
+}
+
+// isTerminal reports whether the standard input of the command is a terminal
+func isTerminal(env Env) bool {
+	f, ok := env.Stdin.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// printMergePreview lists what a dry run of a merge would do
+func printMergePreview(env Env, plan merge.Plan) {
+	for _, added := range plan.Added {
+		fmt.Fprintf(env.Stdout, "Would add %s\n", added.Name())
+	}
+	printNames(env.Stdout, "Would update", conflictNames(plan.Updated))
+	printNames(env.Stdout, "Conflict", conflictNames(plan.Conflicts))
+	fmt.Fprintf(env.Stderr, "Dry run: %d to add, %d to update, %d in conflict, %d unchanged, nothing was merged\n",
+		len(plan.Added), len(plan.Updated), len(plan.Conflicts), plan.Unchanged)
+}
+
+// conflictNames names the local entries of conflicts
+func conflictNames(conflicts []merge.Conflict) []string {
+	names := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		names = append(names, conflict.Local.Name())
+	}
+	return names
+}
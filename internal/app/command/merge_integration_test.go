@@ -0,0 +1,182 @@
+//go:build integration
+
+package command
+
+import (
+	"path/filepath"
+	"testing"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDivergedVaults writes a base copy of the test database and another copy that took its own
+// changes, then changes the test database itself: GitHub is changed in the other copy only, GitLab
+// in the test database only and Mail is added to the other copy. It returns the paths of both copies.
+func setupDivergedVaults(t *testing.T, e testCommandEnv) (string, string) {
+	t.Helper()
+	v := e.vault(t)
+	snapshot := func(name string) string {
+		path := filepath.Join(t.TempDir(), name)
+		_, err := e.db.Exec("VACUUM INTO ?", path)
+		require.NoError(t, err)
+		return path
+	}
+	setPassword := func(title, password string) {
+		entry, err := v.FindEntry(title, "octocat")
+		require.NoError(t, err)
+		details, err := v.Reveal(entry)
+		require.NoError(t, err)
+		details.Item.Values["password"] = password
+		require.NoError(t, v.UpdateItem(details.Entry, details.Item, "", nil))
+	}
+
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "original"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitLab", Username: "octocat", Password: "original"}, nil, "", nil))
+	base := snapshot("base.db")
+	setPassword("GitHub", "changed there")
+	require.NoError(t, v.AddEntry(model.Password{Title: "Mail", Username: "octocat", Password: "new"}, nil, "", nil))
+	other := snapshot("other.db")
+	// the app has no way to delete entries yet
+	_, err := e.db.Exec("DELETE FROM passwords WHERE id = $1", mustFind(t, v, "Mail").ID)
+	require.NoError(t, err)
+	setPassword("GitHub", "original")
+	setPassword("GitLab", "changed here")
+	t.Setenv(EnvOtherPassword, e.password)
+	return base, other
+}
+
+func mustFind(t *testing.T, v vault.Vault, title string) model.Password {
+	t.Helper()
+	entry, err := v.FindEntry(title, "octocat")
+	require.NoError(t, err)
+	return entry
+}
+
+func revealPassword(t *testing.T, v vault.Vault, title string) string {
+	t.Helper()
+	password, err := v.RevealPassword(mustFind(t, v, title))
+	require.NoError(t, err)
+	return password
+}
+
+func TestMergeShouldTakeChangesMadeOnOneSideSinceBase(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	base, other := setupDivergedVaults(t, e)
+
+	// when
+	code := Run(e.env, []string{"merge", "--base", base, other})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Added Mail (octocat)\nUpdated GitHub (octocat)\n", e.stdout.String())
+	assert.Contains(t, e.stderr.String(), "1 added, 1 updated, 0 copied, 0 kept, 1 unchanged")
+	v := e.vault(t)
+	assert.Equal(t, "new", revealPassword(t, v, "Mail"))
+	assert.Equal(t, "changed there", revealPassword(t, v, "GitHub"))
+	assert.Equal(t, "changed here", revealPassword(t, v, "GitLab"))
+	vaulttest.AssertLastAuditEvent(t, v, model.AuditImported, model.ClientCLI)
+}
+
+func TestMergeShouldRefuseConflictsWithoutTerminalOrPreference(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	_, other := setupDivergedVaults(t, e)
+
+	// when
+	code := Run(e.env, []string{"merge", other})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "Conflict GitHub (octocat)\nConflict GitLab (octocat)\n")
+	assert.Contains(t, e.stderr.String(), "2 entries differ in both vaults")
+	_, err := e.vault(t).FindEntry("Mail", "octocat")
+	assert.Error(t, err)
+}
+
+func TestMergeShouldKeepPreferredVersionOfConflicts(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	_, other := setupDivergedVaults(t, e)
+
+	// when
+	code := Run(e.env, []string{"merge", "--prefer", "both", other})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stdout.String(), "Added Mail (octocat)\n")
+	assert.Contains(t, e.stderr.String(), "1 added, 0 updated, 2 copied, 0 kept, 0 unchanged")
+	entries, err := e.vault(t).Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 5)
+	assert.Equal(t, "changed here", revealPassword(t, e.vault(t), "GitLab"))
+}
+
+func TestMergeShouldPreviewWithDryRun(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	base, other := setupDivergedVaults(t, e)
+
+	// when
+	code := Run(e.env, []string{"merge", "--dry-run", "--base", base, other})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Would add Mail (octocat)\nWould update GitHub (octocat)\n", e.stdout.String())
+	assert.Contains(t, e.stderr.String(), "Dry run: 1 to add, 1 to update, 0 in conflict, 1 unchanged, nothing was merged")
+	assert.Equal(t, "original", revealPassword(t, e.vault(t), "GitHub"))
+}
+
+func TestMergeShouldRejectWrongPasswordOfOtherVault(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	_, other := setupDivergedVaults(t, e)
+	t.Setenv(EnvOtherPassword, "wrong")
+
+	// when
+	code := Run(e.env, []string{"merge", "--prefer", "mine", other})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "failed to unlock "+other)
+}
+
+func TestMergeShouldRejectUnknownPreference(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"merge", "--prefer", "newest", "other.db"})
+
+	// then
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, e.stderr.String(), `unsupported preference "newest"`)
+}
+
+func TestMergeShouldAddEntriesOfOtherUserFromCopyOfSameDatabase(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	bobPassword := test.RandomString()
+	bob := vaulttest.NewUser(t, e.db, "bob", bobPassword)
+	require.NoError(t, bob.AddEntry(model.Password{Title: "Mail", Username: "octocat", Password: "bob's"}, nil, "", nil))
+	other := filepath.Join(t.TempDir(), "other.db")
+	_, err := e.db.Exec("VACUUM INTO ?", other)
+	require.NoError(t, err)
+	t.Setenv(EnvOtherPassword, bobPassword)
+
+	// when
+	code := Run(e.env, []string{"merge", "--other-user", "bob", other})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Added Mail (octocat)\n", e.stdout.String())
+	added := mustFind(t, e.vault(t), "Mail")
+	assert.NotEqual(t, mustFind(t, bob, "Mail").ID, added.ID)
+	assert.Equal(t, "bob's", revealPassword(t, e.vault(t), "Mail"))
+	assert.Equal(t, "bob's", revealPassword(t, bob, "Mail"))
+}
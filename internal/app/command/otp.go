@@ -0,0 +1,52 @@
+package command
+
+import (
+	"fmt"
+	"time"
+	"yubigo-pass/internal/app/model"
+)
+
+// otpCommand prints the current one-time password of an entry
+func otpCommand() Command {
+	return Command{
+		Name:    "otp",
+		Summary: "Print the current one-time password of an entry",
+		Run:     runOTP,
+	}
+}
+
+func runOTP(env Env, args []string) error {
+	fs, username := newFlagSet(env, "otp")
+	entryUsername := fs.String("username", "", "username of the entry, when several entries share the title")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass otp <title> [flags]")
+		fmt.Fprintln(fs.Output(), "Counter based (HOTP) codes advance the counter every time they are printed.")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+	entry, err := v.FindEntry(positional[0], *entryUsername)
+	if err != nil {
+		return err
+	}
+
+	code, err := v.OneTimeCode(entry, time.Now())
+	if err != nil {
+		return err
+	}
+	if err = v.Record(model.AuditRead, model.ClientCLI, entry); err != nil {
+		return err
+	}
+	fmt.Fprintln(env.Stdout, code.Value)
+	if code.Remaining > 0 {
+		fmt.Fprintf(env.Stderr, "valid for %ds\n", int(code.Remaining.Round(time.Second)/time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+//go:build integration
+
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/otp"
+	"yubigo-pass/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTPShouldPrintCurrentTOTP(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	uri := "otpauth://totp/GitHub:octocat?secret=" + test.OTPSecret
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "github", Username: "octocat", Password: "a", OTP: uri}, nil, "", nil))
+	secret, err := otp.DecodeSecret(test.OTPSecret)
+	require.NoError(t, err)
+
+	// when
+	before := time.Now()
+	code := Run(e.env, []string{"otp", "github"})
+	after := time.Now()
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	printed := strings.TrimSpace(e.stdout.String())
+	assert.Contains(t, []string{
+		otp.TOTP(secret, before, otp.DefaultPeriod, 6, otp.AlgorithmSHA1),
+		otp.TOTP(secret, after, otp.DefaultPeriod, 6, otp.AlgorithmSHA1),
+	}, printed)
+	assert.Contains(t, e.stderr.String(), "valid for")
+}
+
+func TestOTPShouldPrintNextHOTPEveryTime(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	uri := "otpauth://hotp/Bank:me?secret=" + test.OTPSecret + "&counter=0"
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "bank", Username: "me", Password: "a", OTP: uri}, nil, "", nil))
+
+	// when
+	first := Run(e.env, []string{"otp", "bank"})
+	second := Run(e.env, []string{"otp", "bank"})
+
+	// then
+	require.Equal(t, ExitOK, first, e.stderr.String())
+	require.Equal(t, ExitOK, second, e.stderr.String())
+	assert.Equal(t, "755224\n287082\n", e.stdout.String())
+}
+
+func TestOTPShouldFailForEntryWithoutOneTimePassword(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "mail", Username: "me", Password: "a"}, nil, "", nil))
+
+	// when
+	code := Run(e.env, []string{"otp", "mail"})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "mail has no one-time password")
+}
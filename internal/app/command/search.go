@@ -0,0 +1,58 @@
+package command
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+	"yubigo-pass/internal/app/search"
+)
+
+// searchCommand prints the entries best matching a free-text query
+func searchCommand() Command {
+	return Command{
+		Name:    "search",
+		Summary: "Search entries by title, username, URL, tags and notes",
+		Run:     runSearch,
+	}
+}
+
+func runSearch(env Env, args []string) error {
+	fs, username := newFlagSet(env, "search")
+	limit := fs.Int("limit", 20, "maximum number of results, 0 for all")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass search <query> [flags]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args, 1, math.MaxInt)
+	if err != nil {
+		return err
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+
+	index, err := search.BuildIndex(v)
+	if err != nil {
+		return err
+	}
+	entries, err := v.Entries()
+	if err != nil {
+		return err
+	}
+
+	query := strings.Join(positional, " ")
+	matches := search.Rank(entries, index.Search(query, *limit))
+	if len(matches) == 0 {
+		return fmt.Errorf("no entries match %q", query)
+	}
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TITLE\tTYPE\tUSERNAME\tURL")
+	for _, entry := range matches {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Title, entry.ItemType(), entry.Username, entry.Url)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,59 @@
+//go:build integration
+
+package command
+
+import (
+	"strings"
+	"testing"
+	"yubigo-pass/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchShouldPrintRankedMatches(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "router", Username: "admin", Password: "x", Notes: "github mirror"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "octocat", Password: "x"}, nil, "", []string{"dev"}))
+	require.NoError(t, v.AddEntry(model.Password{Title: "bank", Username: "me", Password: "x"}, nil, "", nil))
+
+	// when
+	code := Run(e.env, []string{"search", "githb"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	lines := strings.Split(strings.TrimSpace(e.stdout.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[1], "github"))
+	assert.True(t, strings.HasPrefix(lines[2], "router"))
+}
+
+func TestSearchShouldJoinQueryWordsAndLimitResults(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "work mail", Username: "jane", Password: "x"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "home mail", Username: "jane", Password: "x"}, nil, "", nil))
+
+	// when
+	code := Run(e.env, []string{"search", "--limit", "1", "mail", "work"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stdout.String(), "work mail")
+	assert.NotContains(t, e.stdout.String(), "home mail")
+}
+
+func TestSearchShouldFailWithoutMatches(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"search", "paypal"})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), `no entries match "paypal"`)
+}
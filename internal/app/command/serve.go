@@ -0,0 +1,40 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// serve runs handler on the listen address until interrupted, then shuts it down gracefully.
+// Without a token it first warns that anyone reaching the address can do what exposed tells,
+// started is printed as the server starts.
+func serve(env Env, listen string, handler http.Handler, token, exposed, started string) error {
+	server := &http.Server{
+		Addr:              listen,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	if token == "" {
+		fmt.Fprintf(env.Stderr, "Warning: no token set, anyone reaching %s can %s\n", listen, exposed)
+	}
+	fmt.Fprintln(env.Stdout, started)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build integration
+
+package command
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeShouldWarnWithoutTokenAndFailOnTakenAddress(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	taken, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer taken.Close()
+
+	// when
+	err = serve(e.env, taken.Addr().String(), http.NotFoundHandler(), "", "do anything", "Serving")
+
+	// then
+	assert.ErrorContains(t, err, "address already in use")
+	assert.Equal(t, "Warning: no token set, anyone reaching "+taken.Addr().String()+" can do anything\n", e.stderr.String())
+	assert.Equal(t, "Serving\n", e.stdout.String())
+}
@@ -0,0 +1,220 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/exporter"
+	"yubigo-pass/internal/app/importer"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/share"
+	"yubigo-pass/internal/app/vault"
+
+	"filippo.io/age"
+)
+
+// EnvSharePassphrase holds the passphrase entries are shared under by share and received with by receive
+const EnvSharePassphrase = "YUBIGO_PASS_SHARE_PASSPHRASE" // #nosec G101
+
+// shareCommand encrypts a single entry for someone outside the installation
+func shareCommand() Command {
+	return Command{
+		Name:    "share",
+		Summary: "Share an entry in a file encrypted with age",
+		Run:     runShare,
+	}
+}
+
+func runShare(env Env, args []string) error {
+	fs, username := newFlagSet(env, "share")
+	entryUsername := fs.String("username", "", "username of the entry, when several entries share the title")
+	var to []string
+	fs.Func("to", "age recipient to share the entry with, e.g. age1..., may be repeated", func(value string) error {
+		to = append(to, value)
+		return nil
+	})
+	withPassphrase := fs.Bool("passphrase", false, "share the entry under a passphrase read from $"+EnvSharePassphrase+" or a prompt instead")
+	armored := fs.Bool("armor", false, "write ASCII armored text instead of a binary file")
+	out := fs.String("out", stdioPath, "file to write to, - for stdout")
+	force := fs.Bool("force", false, "overwrite an existing file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass share <title> (--to <age-recipient>... | --passphrase) [flags]")
+		fmt.Fprintln(fs.Output(), "The file opens with `yubigo-pass receive` or `age --decrypt`, and holds the entry with its attachments as JSON.")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if (len(to) == 0) == !*withPassphrase {
+		fmt.Fprintln(fs.Output(), "share the entry either --to age recipients or under a --passphrase")
+		fs.Usage()
+		return errUsage
+	}
+	recipients, err := share.ParseRecipients(to)
+	if err != nil {
+		return err
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+	entry, err := v.FindEntry(positional[0], *entryUsername)
+	if err != nil {
+		return err
+	}
+	shared, err := sharedEntry(v, vault.NewAttachments(v, env.Container.AttachmentDir), entry)
+	if err != nil {
+		return err
+	}
+	if *withPassphrase {
+		passphrase, err := readSecret(env, EnvSharePassphrase, "Share passphrase: ")
+		if err != nil {
+			return err
+		}
+		if passphrase == "" {
+			return errors.New("share passphrase must not be empty")
+		}
+		recipient, err := share.PassphraseRecipient(passphrase)
+		if err != nil {
+			return err
+		}
+		recipients = []age.Recipient{recipient}
+	}
+
+	err = writeOutput(env, *out, *force, func(w io.Writer) error {
+		return share.Write(w, shared, *armored, recipients...)
+	})
+	if err != nil {
+		return err
+	}
+	if err = v.Record(model.AuditCopied, model.ClientCLI, entry); err != nil {
+		return err
+	}
+	if *out != stdioPath {
+		fmt.Fprintf(env.Stderr, "Shared %s in %s\n", shared.Title, *out)
+	}
+	return nil
+}
+
+// sharedEntry lays an entry out with its attachments as the archive entry shared
+func sharedEntry(v vault.Vault, attachments vault.Attachments, entry model.Password) (archive.Entry, error) {
+	details, err := v.Reveal(entry)
+	if err != nil {
+		return archive.Entry{}, err
+	}
+	shared := exporter.ArchiveEntry(details)
+
+	files, err := attachments.List(entry.ID)
+	if err != nil {
+		return archive.Entry{}, err
+	}
+	for _, file := range files {
+		var content bytes.Buffer
+		if err = attachments.Extract(file, &content); err != nil {
+			return archive.Entry{}, err
+		}
+		shared.Attachments = append(shared.Attachments, archive.Attachment{Name: file.Name, Data: content.Bytes()})
+	}
+	return shared, nil
+}
+
+// receiveCommand imports an entry shared with share
+func receiveCommand() Command {
+	return Command{
+		Name:    "receive",
+		Summary: "Import an entry shared in a file encrypted with age",
+		Run:     runReceive,
+	}
+}
+
+func runReceive(env Env, args []string) error {
+	fs, username := newFlagSet(env, "receive")
+	identityFile := fs.String("identity", "", "age identity file of the recipient the entry was shared with")
+	duplicates := fs.String("duplicates", importer.DuplicateSkip.String(), "what to do when the entry already exists: skip, overwrite or rename")
+	dryRun := fs.Bool("dry-run", false, "show what would be added, updated or skipped without importing anything")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass receive <file|-> [flags]")
+		fmt.Fprintln(fs.Output(), "Entries shared under a passphrase are opened with $"+EnvSharePassphrase+" or a prompt.")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	policy, err := importer.ParseDuplicatePolicy(*duplicates)
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return errUsage
+	}
+
+	data, err := readInput(env, positional[0])
+	if err != nil {
+		return err
+	}
+	// The entry is decrypted before unlocking, so an unreadable file imports nothing
+	var identities []age.Identity
+	switch {
+	case share.NeedsPassphrase(data):
+		passphrase, err := readSecret(env, EnvSharePassphrase, "Share passphrase: ")
+		if err != nil {
+			return err
+		}
+		identity, err := share.PassphraseIdentity(passphrase)
+		if err != nil {
+			return err
+		}
+		identities = []age.Identity{identity}
+	case *identityFile != "":
+		identities, err = readIdentities(*identityFile)
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.New("the entry was shared with an age recipient, pass the identity file with --identity")
+	}
+	entry, err := share.Read(data, identities...)
+	if err != nil {
+		return err
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+	report, err := importer.Import(v, importer.Batch{Records: []importer.Record{importer.ArchiveRecord(entry)}}, importer.Options{
+		AttachmentDir: env.Container.AttachmentDir,
+		Duplicates:    policy,
+		DryRun:        *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		printImportPreview(env, report)
+		return nil
+	}
+	if err = recordImport(v, report); err != nil {
+		return err
+	}
+	printImportReport(env, report)
+	return nil
+}
+
+// readIdentities reads the age identities of an identity file, as written by age-keygen
+func readIdentities(path string) ([]age.Identity, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- the user picks the identity file
+	if err != nil {
+		return nil, err
+	}
+	identities, err := age.ParseIdentities(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity file %s: %w", path, err)
+	}
+	return identities, nil
+}
@@ -0,0 +1,106 @@
+//go:build integration
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test/vaulttest"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareShouldEncryptEntryToRecipientForReceive(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	v := e.vault(t)
+	fields := []model.CustomField{{Type: model.FieldTypeHidden, Name: "PIN", Value: "4242"}}
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "hunter2"}, fields, "Work", nil))
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityPath := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(identityPath, []byte(identity.String()+"\n"), 0o600))
+	sharedPath := filepath.Join(t.TempDir(), "github.age")
+
+	// when
+	code := Run(e.env, []string{"share", "GitHub", "--to", identity.Recipient().String(), "--out", sharedPath})
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	// the app has no way to delete entries yet
+	_, err = e.db.Exec("DELETE FROM passwords WHERE id = $1", mustFind(t, v, "GitHub").ID)
+	require.NoError(t, err)
+	receiveCode := Run(e.env, []string{"receive", sharedPath, "--identity", identityPath})
+
+	// then
+	require.Equal(t, ExitOK, receiveCode, e.stderr.String())
+	data, err := os.ReadFile(sharedPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+	assert.Contains(t, e.stderr.String(), "Shared GitHub in "+sharedPath)
+	assert.Contains(t, e.stdout.String(), "Added GitHub")
+	details, err := v.Reveal(mustFind(t, v, "GitHub"))
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", details.Item.Values["password"])
+	require.Len(t, details.Fields, 1)
+	assert.Equal(t, "4242", details.Fields[0].Value)
+	assert.Equal(t, []string{"Work"}, details.Folders)
+	vaulttest.AssertLastAuditEvent(t, v, model.AuditImported, model.ClientCLI)
+}
+
+func TestShareShouldEncryptEntryUnderPassphrase(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "hunter2"}, nil, "", nil))
+	t.Setenv(EnvSharePassphrase, "correct horse")
+	require.Equal(t, ExitOK, Run(e.env, []string{"share", "GitHub", "--passphrase", "--armor"}), e.stderr.String())
+	sharedPath := filepath.Join(t.TempDir(), "github.age")
+	require.NoError(t, os.WriteFile(sharedPath, e.stdout.Bytes(), 0o600))
+
+	// when
+	t.Setenv(EnvSharePassphrase, "wrong horse")
+	wrongCode := Run(e.env, []string{"receive", sharedPath})
+	t.Setenv(EnvSharePassphrase, "correct horse")
+	e.stdout.Reset()
+	code := Run(e.env, []string{"receive", sharedPath, "--duplicates", "rename"})
+
+	// then
+	assert.Equal(t, ExitError, wrongCode)
+	assert.Contains(t, e.stderr.String(), "the entry was not shared with this identity or passphrase")
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Contains(t, e.stdout.String(), "Added GitHub")
+	entries, err := e.vault(t).Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestShareShouldRequireRecipientsOrPassphrase(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"share", "GitHub"})
+
+	// then
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, e.stderr.String(), "share the entry either --to age recipients or under a --passphrase")
+}
+
+func TestReceiveShouldAskForIdentityOfRecipientFiles(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "hunter2"}, nil, "", nil))
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	sharedPath := filepath.Join(t.TempDir(), "github.age")
+	require.Equal(t, ExitOK, Run(e.env, []string{"share", "GitHub", "--to", identity.Recipient().String(), "--out", sharedPath}))
+
+	// when
+	code := Run(e.env, []string{"receive", sharedPath})
+
+	// then
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, e.stderr.String(), "pass the identity file with --identity")
+}
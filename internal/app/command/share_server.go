@@ -0,0 +1,35 @@
+package command
+
+import (
+	"fmt"
+	"yubigo-pass/internal/app/sharelink"
+)
+
+// shareServerCommand runs a share server hosting one-time links of secrets
+func shareServerCommand() Command {
+	return Command{
+		Name:    "share-server",
+		Summary: "Run a share server hosting one-time, expiring links of secrets",
+		Run:     runShareServer,
+	}
+}
+
+func runShareServer(env Env, args []string) error {
+	fs := newDatabaseFlagSet(env, "share-server")
+	listen := fs.String("listen", "localhost:8421", "address to listen on")
+	dir := fs.String("dir", env.Container.ShareDir, "directory to keep the links in")
+	token := fs.String("token", env.Container.ShareToken, "token required to create links, defaults to $"+sharelink.EnvToken)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass share-server [flags]")
+		fmt.Fprintln(fs.Output(), "The server only ever stores sealed secrets, their keys travel in the fragment of the links. Entries create links on it with $"+sharelink.EnvServer+" set to its URL.")
+		fmt.Fprintln(fs.Output(), "Serve it behind TLS when links are opened over a network, browsers only decrypt secrets over HTTPS or on localhost.")
+		fs.PrintDefaults()
+	}
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	return serve(env, *listen, sharelink.NewServer(*dir, *token), *token,
+		"create links",
+		fmt.Sprintf("Serving links on %s, keeping them in %s", *listen, *dir))
+}
@@ -0,0 +1,105 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"yubigo-pass/internal/app/model"
+)
+
+// maskedValue is printed in place of secrets unless --reveal is given
+const maskedValue = "********"
+
+// showCommand prints a single entry with the fields of its type, notes and custom fields
+func showCommand() Command {
+	return Command{
+		Name:    "show",
+		Summary: "Show an entry with its notes and custom fields",
+		Run:     runShow,
+	}
+}
+
+func runShow(env Env, args []string) error {
+	fs, username := newFlagSet(env, "show")
+	entryUsername := fs.String("username", "", "username of the entry, when several entries share the title")
+	reveal := fs.Bool("reveal", false, "print the password and hidden fields in plaintext")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass show <title> [flags]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseFlags(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+
+	entry, err := v.FindEntry(positional[0], *entryUsername)
+	if err != nil {
+		return err
+	}
+	details, err := v.Reveal(entry)
+	if err != nil {
+		return err
+	}
+	if *reveal {
+		if err = v.Record(model.AuditRead, model.ClientCLI, entry); err != nil {
+			return err
+		}
+	}
+
+	secret := func(value string) string {
+		if *reveal {
+			return value
+		}
+		return maskedValue
+	}
+
+	item := details.Item
+	var multiline []string
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Title:\t%s\n", item.Title)
+	fmt.Fprintf(w, "Type:\t%s\n", item.Type.Label())
+	for _, field := range item.Type.Fields() {
+		value := item.Values[field.Key]
+		if value == "" {
+			continue
+		}
+		if field.Secret {
+			value = secret(value)
+		}
+		if field.Multiline && strings.Contains(value, "\n") {
+			multiline = append(multiline, fmt.Sprintf("\n%s:\n%s\n", field.Label, value))
+			continue
+		}
+		fmt.Fprintf(w, "%s:\t%s\n", field.Label, value)
+	}
+	if len(details.Folders) > 0 {
+		fmt.Fprintf(w, "Folders:\t%s\n", strings.Join(details.Folders, ", "))
+	}
+	if len(details.Tags) > 0 {
+		fmt.Fprintf(w, "Tags:\t%s\n", strings.Join(details.Tags, ", "))
+	}
+	for _, field := range details.Fields {
+		value := field.Value
+		if field.Type == model.FieldTypeHidden {
+			value = secret(value)
+		}
+		fmt.Fprintf(w, "%s:\t%s\n", field.Name, value)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, block := range multiline {
+		fmt.Fprint(env.Stdout, block)
+	}
+	if details.Entry.Notes != "" {
+		fmt.Fprintf(env.Stdout, "\nNotes:\n%s\n", details.Entry.Notes)
+	}
+	return nil
+}
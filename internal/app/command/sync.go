@@ -0,0 +1,112 @@
+package command
+
+import (
+	"fmt"
+	"path/filepath"
+	"yubigo-pass/internal/app/gitsync"
+	"yubigo-pass/internal/app/httpsync"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+)
+
+// syncCommand synchronizes the vault with the other devices of the user, through a sync server or a git remote
+func syncCommand() Command {
+	return Command{
+		Name:    "sync",
+		Summary: "Synchronize entries with other devices through a sync server or a git remote",
+		Run:     runSync,
+	}
+}
+
+// serveSyncCommand runs a sync server storing the sealed entries of the vaults synchronized with it
+func serveSyncCommand() Command {
+	return Command{
+		Name:    "serve-sync",
+		Summary: "Run a sync server for the devices of one or more users",
+		Run:     runServeSync,
+	}
+}
+
+func runSync(env Env, args []string) error {
+	fs, username := newFlagSet(env, "sync")
+	server := fs.String("server", env.Container.SyncServer, "URL of the sync server to synchronize with, defaults to $"+httpsync.EnvServer)
+	token := fs.String("token", env.Container.SyncToken, "token the sync server expects, defaults to $"+httpsync.EnvToken)
+	remote := fs.String("remote", env.Container.SyncRemote, "git remote to synchronize with instead of a sync server, defaults to $"+gitsync.EnvRemote)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass sync [flags]")
+		fmt.Fprintln(fs.Output(), "Every device of a user syncing with the same server or remote must share the master password.")
+		fs.PrintDefaults()
+	}
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *server == "" && *remote == "" {
+		fmt.Fprintf(fs.Output(), "no sync server or remote configured, pass --server or --remote or set $%s or $%s\n", httpsync.EnvServer, gitsync.EnvRemote)
+		fs.Usage()
+		return errUsage
+	}
+
+	v, err := unlock(env, *username)
+	if err != nil {
+		return err
+	}
+	if *server == "" {
+		report, err := gitsync.ForUser(env.Container.SyncDir, v.UserID(), *remote).Sync(v)
+		if err != nil {
+			return err
+		}
+		if err = recordSync(v, report.Received); err != nil {
+			return err
+		}
+		fmt.Fprintf(env.Stdout, "Sent %d, received %d entries, %d conflict copies\n", report.Sent, report.Received, report.Conflicts)
+		return nil
+	}
+
+	client := httpsync.ForUser(env.Container.SyncDir, v.UserID(), resolveUsername(*username), *server, *token)
+	report, err := client.Sync(v)
+	if err != nil {
+		return err
+	}
+	if err = recordSync(v, report.Received); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.Stdout, "Sent %d, received %d entries\n", report.Sent, report.Received)
+	conflicts, err := client.Conflicts(v)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		fmt.Fprintf(env.Stdout, "%d entries were edited on another device as well, resolve the conflicts in the interactive interface:\n", len(conflicts))
+		for _, conflict := range conflicts {
+			fmt.Fprintf(env.Stdout, "  %s\n", conflict.Local.Title)
+		}
+	}
+	return nil
+}
+
+// recordSync records in the audit log of the vault that a sync stored the received entries in it
+func recordSync(v vault.Vault, received int) error {
+	if received == 0 {
+		return nil
+	}
+	return v.Record(model.AuditImported, model.ClientCLI, model.Password{})
+}
+
+func runServeSync(env Env, args []string) error {
+	fs := newDatabaseFlagSet(env, "serve-sync")
+	listen := fs.String("listen", "localhost:8420", "address to listen on")
+	dir := fs.String("dir", filepath.Join(env.Container.SyncDir, httpsync.ServerDirName), "directory to keep the vaults in")
+	token := fs.String("token", env.Container.SyncToken, "token clients must present, defaults to $"+httpsync.EnvToken)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: yubigo-pass serve-sync [flags]")
+		fmt.Fprintln(fs.Output(), "The server only ever stores sealed entries. Serve it behind TLS when clients reach it over a network.")
+		fs.PrintDefaults()
+	}
+	if _, err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	return serve(env, *listen, httpsync.NewServer(*dir, *token), *token,
+		"read and overwrite the sealed entries",
+		fmt.Sprintf("Serving sync on %s, keeping vaults in %s", *listen, *dir))
+}
@@ -0,0 +1,131 @@
+//go:build integration
+
+package command
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"yubigo-pass/internal/app/httpsync"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// otherDevice sets up another installation of the user of e, with a database and sync state of its own
+func otherDevice(t *testing.T, e testCommandEnv) testCommandEnv {
+	t.Helper()
+	db, err := test.SetupTestDB()
+	require.NoError(t, err, "Failed to set up test database")
+	t.Cleanup(func() { test.TeardownTestDB(db) })
+	test.InsertIntoUsers(t, db, e.user)
+
+	other := e
+	other.db = db
+	other.env.Container.Store = database.NewStore(db)
+	other.env.Container.SyncDir = t.TempDir()
+	other.stdout, other.stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	other.env.Stdout, other.env.Stderr = other.stdout, other.stderr
+	return other
+}
+
+func TestSyncShouldPushEntriesToRemote(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	remote := filepath.Join(t.TempDir(), "vault.git")
+	out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "github", Username: "me", Password: "a"}, nil, "", nil))
+
+	// when
+	code := Run(e.env, []string{"sync", "--remote", remote})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Sent 1, received 0 entries, 0 conflict copies\n", e.stdout.String())
+	out, err = exec.Command("git", "-C", remote, "log", "--oneline", "main").CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.NotEmpty(t, out)
+}
+
+func TestSyncShouldRequireRemote(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+
+	// when
+	code := Run(e.env, []string{"sync"})
+
+	// then
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, e.stderr.String(), "no sync server or remote configured")
+}
+
+func TestSyncShouldExchangeEntriesThroughSyncServer(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	server := httptest.NewServer(httpsync.NewServer(t.TempDir(), "token"))
+	defer server.Close()
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "github", Username: "me", Password: "a"}, nil, "", nil))
+
+	// when
+	code := Run(e.env, []string{"sync", "--server", server.URL, "--token", "token"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Sent 1, received 0 entries\n", e.stdout.String())
+
+	// when
+	e.stdout.Reset()
+	code = Run(e.env, []string{"sync", "--server", server.URL, "--token", "token"})
+
+	// then
+	require.Equal(t, ExitOK, code, e.stderr.String())
+	assert.Equal(t, "Sent 0, received 0 entries\n", e.stdout.String())
+}
+
+func TestSyncShouldRecordEntriesReceivedFromRemote(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	other := otherDevice(t, e)
+	remote := filepath.Join(t.TempDir(), "vault.git")
+	out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "github", Username: "me", Password: "a"}, nil, "", nil))
+	require.Equal(t, ExitOK, Run(e.env, []string{"sync", "--remote", remote}), e.stderr.String())
+
+	// when
+	code := Run(other.env, []string{"sync", "--remote", remote})
+
+	// then
+	require.Equal(t, ExitOK, code, other.stderr.String())
+	assert.Equal(t, "Sent 0, received 1 entries, 0 conflict copies\n", other.stdout.String())
+	vaulttest.AssertLastAuditEvent(t, other.vault(t), model.AuditImported, model.ClientCLI)
+	events, err := e.vault(t).AuditLog(vault.AuditFilter{Event: model.AuditImported})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestSyncShouldRecordEntriesReceivedFromSyncServer(t *testing.T) {
+	// given
+	e := setupCommandEnv(t)
+	other := otherDevice(t, e)
+	server := httptest.NewServer(httpsync.NewServer(t.TempDir(), "token"))
+	defer server.Close()
+	require.NoError(t, e.vault(t).AddEntry(model.Password{Title: "github", Username: "me", Password: "a"}, nil, "", nil))
+	require.Equal(t, ExitOK, Run(e.env, []string{"sync", "--server", server.URL, "--token", "token"}), e.stderr.String())
+
+	// when
+	code := Run(other.env, []string{"sync", "--server", server.URL, "--token", "token"})
+
+	// then
+	require.Equal(t, ExitOK, code, other.stderr.String())
+	assert.Equal(t, "Sent 0, received 1 entries\n", other.stdout.String())
+	vaulttest.AssertLastAuditEvent(t, other.vault(t), model.AuditImported, model.ClientCLI)
+}
@@ -17,8 +17,17 @@ const (
 	StateUserCreated
 	StateGoToMainMenu
 	StateGoToAddPassword
+	StateGoToAddItem
 	StateGoToViewPasswords
 	StateGoToGetPassword
+	StateGoToImportQR
+	StateGoToImportCSV
+	StateGoToSyncConflicts
+	StateGoToCollections
+	StateGoToEmergencyAccess
+	StateGoToSecurityReport
+	StateGoToAuditLog
+	StateGoToPasswordGenerator
 	StatePasswordAdded
 	StateGoBack
 	StateLogout
@@ -48,8 +57,42 @@ type UserToCreateMsg struct {
 }
 
 // PasswordToAddMsg carries the necessary data for initiating the password creation process.
+// Fields are the custom fields, Folder is a folder path like "Work/Servers", Tags are free-form tag names.
 type PasswordToAddMsg struct {
-	Data model.Password
+	Data   model.Password
+	Fields []model.CustomField
+	Folder string
+	Tags   []string
+}
+
+// ShowPasswordMsg carries the entry selected for displaying its details.
+type ShowPasswordMsg struct {
+	Entry model.Password
+}
+
+// ItemTypeChosenMsg carries the type of the item the user chose to add.
+type ItemTypeChosenMsg struct {
+	Type model.ItemType
+}
+
+// ItemToAddMsg carries a typed item for initiating the item creation process.
+type ItemToAddMsg struct {
+	Item   model.Item
+	Folder string
+	Tags   []string
+}
+
+// EditPasswordMsg carries the entry selected for editing.
+type EditPasswordMsg struct {
+	Entry model.Password
+}
+
+// ItemToUpdateMsg carries the stored entry and its edited values for initiating the update process.
+type ItemToUpdateMsg struct {
+	Entry  model.Password
+	Item   model.Item
+	Folder string
+	Tags   []string
 }
 
 // LoginCmd returns a command that sends a LoginMsg.
@@ -67,9 +110,44 @@ func CreateUserCmd(username, password string) tea.Cmd {
 }
 
 // AddPasswordCmd returns a command that sends a PasswordToAddMsg.
-func AddPasswordCmd(data model.Password) tea.Cmd {
+func AddPasswordCmd(data model.Password, fields []model.CustomField, folder string, tags []string) tea.Cmd {
+	return func() tea.Msg {
+		return PasswordToAddMsg{Data: data, Fields: fields, Folder: folder, Tags: tags}
+	}
+}
+
+// ShowPasswordCmd returns a command that sends a ShowPasswordMsg.
+func ShowPasswordCmd(entry model.Password) tea.Cmd {
+	return func() tea.Msg {
+		return ShowPasswordMsg{Entry: entry}
+	}
+}
+
+// ChooseItemTypeCmd returns a command that sends an ItemTypeChosenMsg.
+func ChooseItemTypeCmd(itemType model.ItemType) tea.Cmd {
+	return func() tea.Msg {
+		return ItemTypeChosenMsg{Type: itemType}
+	}
+}
+
+// AddItemCmd returns a command that sends an ItemToAddMsg.
+func AddItemCmd(item model.Item, folder string, tags []string) tea.Cmd {
+	return func() tea.Msg {
+		return ItemToAddMsg{Item: item, Folder: folder, Tags: tags}
+	}
+}
+
+// EditPasswordCmd returns a command that sends an EditPasswordMsg.
+func EditPasswordCmd(entry model.Password) tea.Cmd {
+	return func() tea.Msg {
+		return EditPasswordMsg{Entry: entry}
+	}
+}
+
+// UpdateItemCmd returns a command that sends an ItemToUpdateMsg.
+func UpdateItemCmd(entry model.Password, item model.Item, folder string, tags []string) tea.Cmd {
 	return func() tea.Msg {
-		return PasswordToAddMsg{Data: data}
+		return ItemToUpdateMsg{Entry: entry, Item: item, Folder: folder, Tags: tags}
 	}
 }
 
@@ -55,7 +55,11 @@ func TestAddPasswordCmd(t *testing.T) {
 		Nonce:    []byte("nonce"),
 	}
 
-	cmd := AddPasswordCmd(expectedData)
+	expectedFields := []model.CustomField{{Type: model.FieldTypeHidden, Name: "PIN", Value: "1234"}}
+	expectedFolder := "Work/Servers"
+	expectedTags := []string{"prod", "ssh"}
+
+	cmd := AddPasswordCmd(expectedData, expectedFields, expectedFolder, expectedTags)
 	require.NotNil(t, cmd, "Command should not be nil")
 
 	msg := cmd()
@@ -63,6 +67,80 @@ func TestAddPasswordCmd(t *testing.T) {
 	require.True(t, ok, "Message should be of type PasswordToAddMsg")
 
 	assert.Equal(t, expectedData, resultMsg.Data)
+	assert.Equal(t, expectedFields, resultMsg.Fields)
+	assert.Equal(t, expectedFolder, resultMsg.Folder)
+	assert.Equal(t, expectedTags, resultMsg.Tags)
+}
+
+// TestShowPasswordCmd verifies that ShowPasswordCmd creates the correct ShowPasswordMsg.
+func TestShowPasswordCmd(t *testing.T) {
+	expectedEntry := model.Password{ID: "id", Title: "Test Title", Username: "pwduser"}
+
+	cmd := ShowPasswordCmd(expectedEntry)
+	require.NotNil(t, cmd, "Command should not be nil")
+
+	msg := cmd()
+	resultMsg, ok := msg.(ShowPasswordMsg)
+	require.True(t, ok, "Message should be of type ShowPasswordMsg")
+
+	assert.Equal(t, expectedEntry, resultMsg.Entry)
+}
+
+func TestChooseItemTypeCmd(t *testing.T) {
+	cmd := ChooseItemTypeCmd(model.ItemTypeCard)
+	require.NotNil(t, cmd, "Command should not be nil")
+
+	msg := cmd()
+	resultMsg, ok := msg.(ItemTypeChosenMsg)
+	require.True(t, ok, "Message should be of type ItemTypeChosenMsg")
+
+	assert.Equal(t, model.ItemTypeCard, resultMsg.Type)
+}
+
+func TestAddItemCmd(t *testing.T) {
+	expectedItem := model.Item{Type: model.ItemTypeSecureNote, Title: "wifi", Notes: "router"}
+	expectedTags := []string{"home"}
+
+	cmd := AddItemCmd(expectedItem, "Home", expectedTags)
+	require.NotNil(t, cmd, "Command should not be nil")
+
+	msg := cmd()
+	resultMsg, ok := msg.(ItemToAddMsg)
+	require.True(t, ok, "Message should be of type ItemToAddMsg")
+
+	assert.Equal(t, expectedItem, resultMsg.Item)
+	assert.Equal(t, "Home", resultMsg.Folder)
+	assert.Equal(t, expectedTags, resultMsg.Tags)
+}
+
+func TestEditPasswordCmd(t *testing.T) {
+	expectedEntry := model.Password{ID: "id", Title: "Test Title", Username: "pwduser"}
+
+	cmd := EditPasswordCmd(expectedEntry)
+	require.NotNil(t, cmd, "Command should not be nil")
+
+	msg := cmd()
+	resultMsg, ok := msg.(EditPasswordMsg)
+	require.True(t, ok, "Message should be of type EditPasswordMsg")
+
+	assert.Equal(t, expectedEntry, resultMsg.Entry)
+}
+
+func TestUpdateItemCmd(t *testing.T) {
+	expectedEntry := model.Password{ID: "id", Title: "wifi"}
+	expectedItem := model.Item{Type: model.ItemTypeSecureNote, Title: "wifi", Notes: "router"}
+
+	cmd := UpdateItemCmd(expectedEntry, expectedItem, "", nil)
+	require.NotNil(t, cmd, "Command should not be nil")
+
+	msg := cmd()
+	resultMsg, ok := msg.(ItemToUpdateMsg)
+	require.True(t, ok, "Message should be of type ItemToUpdateMsg")
+
+	assert.Equal(t, expectedEntry, resultMsg.Entry)
+	assert.Equal(t, expectedItem, resultMsg.Item)
+	assert.Empty(t, resultMsg.Folder)
+	assert.Nil(t, resultMsg.Tags)
 }
 
 // TestChangeStateCmd verifies that ChangeStateCmd creates the correct StateMsg.
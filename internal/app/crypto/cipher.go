@@ -0,0 +1,101 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+)
+
+// macLabel tells the key authenticating data apart from the key encrypting it
+const macLabel = "yubigo-pass mac"
+
+// Cipher encrypts and decrypts vault data with a fixed AES-256 key.
+// Sealed values carry their nonce as a prefix, the same layout used for stored passwords.
+type Cipher struct {
+	key []byte
+}
+
+// NewCipher returns new Cipher instance
+func NewCipher(key []byte) Cipher {
+	return Cipher{key: key}
+}
+
+// NewCipherFromPassphrase returns new Cipher instance with a key derived from a passphrase and a salt
+func NewCipherFromPassphrase(passphrase, salt string) Cipher {
+	return NewCipher(DeriveAESKey(passphrase, salt))
+}
+
+// Seal encrypts plaintext and returns the nonce-prefixed ciphertext together with the nonce
+func (c Cipher) Seal(plaintext []byte) ([]byte, []byte, error) {
+	ciphertext, nonce, err := EncryptAES(c.key, plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	sealed := make([]byte, 0, len(nonce)+len(ciphertext))
+	sealed = append(sealed, nonce...)
+	sealed = append(sealed, ciphertext...)
+	return sealed, nonce, nil
+}
+
+// Open decrypts a nonce-prefixed ciphertext produced by Seal
+func (c Cipher) Open(sealed []byte) ([]byte, error) {
+	return DecryptAES(c.key, sealed)
+}
+
+// SealWithAssociatedData works like Seal and binds the ciphertext to associatedData, which must be
+// passed again to open it. A ciphertext moved to where other associated data is expected fails to open.
+func (c Cipher) SealWithAssociatedData(plaintext, associatedData []byte) ([]byte, error) {
+	gcm, err := newGCM(c.key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+// OpenWithAssociatedData decrypts a ciphertext produced by SealWithAssociatedData with the same
+// associated data
+func (c Cipher) OpenWithAssociatedData(sealed, associatedData []byte) ([]byte, error) {
+	gcm, err := newGCM(c.key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, associatedData)
+}
+
+// MAC authenticates data with a key derived from the key of the cipher, so only holders of the key
+// compute a matching one
+func (c Cipher) MAC(data []byte) []byte {
+	derive := hmac.New(sha256.New, c.key)
+	derive.Write([]byte(macLabel))
+	mac := hmac.New(sha256.New, derive.Sum(nil))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// EncryptString seals a string for storage in a text column
+func (c Cipher) EncryptString(plaintext string) (string, error) {
+	sealed, _, err := c.Seal([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return string(sealed), nil
+}
+
+// DecryptString opens a string sealed by EncryptString
+func (c Cipher) DecryptString(sealed string) (string, error) {
+	plaintext, err := c.Open([]byte(sealed))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,103 @@
+//go:build unit
+
+package crypto
+
+import (
+	"testing"
+	"yubigo-pass/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCipherShouldSealAndOpen(t *testing.T) {
+	// given
+	c := NewCipherFromPassphrase(test.RandomString(), test.RandomString())
+	plaintext := test.RandomString()
+
+	// when
+	sealed, nonce, err := c.Seal([]byte(plaintext))
+	require.NoError(t, err)
+	opened, err := c.Open(sealed)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, string(opened))
+	assert.Equal(t, nonce, sealed[:len(nonce)])
+}
+
+func TestCipherShouldEncryptAndDecryptString(t *testing.T) {
+	// given
+	c := NewCipherFromPassphrase(test.RandomString(), test.RandomString())
+	plaintext := test.RandomString()
+
+	// when
+	sealed, err := c.EncryptString(plaintext)
+	require.NoError(t, err)
+	decrypted, err := c.DecryptString(sealed)
+
+	// then
+	require.NoError(t, err)
+	assert.NotEqual(t, plaintext, sealed)
+	assert.Equal(t, plaintext, decrypted)
+}
+
+func TestCipherShouldNotDecryptWithDifferentKey(t *testing.T) {
+	// given
+	c := NewCipherFromPassphrase(test.RandomString(), test.RandomString())
+	other := NewCipherFromPassphrase(test.RandomString(), test.RandomString())
+	sealed, err := c.EncryptString(test.RandomString())
+	require.NoError(t, err)
+
+	// when
+	decrypted, err := other.DecryptString(sealed)
+
+	// then
+	assert.EqualError(t, err, "cipher: message authentication failed")
+	assert.Empty(t, decrypted)
+}
+
+func TestCipherShouldSealAndOpenWithAssociatedData(t *testing.T) {
+	// given
+	c := NewCipherFromPassphrase(test.RandomString(), test.RandomString())
+	plaintext := test.RandomString()
+	sealed, err := c.SealWithAssociatedData([]byte(plaintext), []byte("entry-1"))
+	require.NoError(t, err)
+
+	// when
+	opened, err := c.OpenWithAssociatedData(sealed, []byte("entry-1"))
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, string(opened))
+}
+
+func TestCipherShouldNotOpenWithDifferentAssociatedData(t *testing.T) {
+	// given
+	c := NewCipherFromPassphrase(test.RandomString(), test.RandomString())
+	sealed, err := c.SealWithAssociatedData([]byte(test.RandomString()), []byte("entry-1"))
+	require.NoError(t, err)
+
+	// when
+	opened, err := c.OpenWithAssociatedData(sealed, []byte("entry-2"))
+
+	// then
+	assert.EqualError(t, err, "cipher: message authentication failed")
+	assert.Empty(t, opened)
+}
+
+func TestCipherMACShouldDependOnKeyAndData(t *testing.T) {
+	// given
+	passphrase, salt := test.RandomString(), test.RandomString()
+	c := NewCipherFromPassphrase(passphrase, salt)
+	data := []byte(test.RandomString())
+
+	// when
+	mac := c.MAC(data)
+
+	// then
+	assert.Len(t, mac, 32)
+	assert.Equal(t, mac, NewCipherFromPassphrase(passphrase, salt).MAC(data))
+	assert.NotEqual(t, mac, NewCipherFromPassphrase(test.RandomString(), salt).MAC(data))
+	assert.NotEqual(t, mac, c.MAC([]byte(test.RandomString())))
+}
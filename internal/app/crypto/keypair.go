@@ -0,0 +1,60 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// KeySize is the length of X25519 public and private keys
+const KeySize = 32
+
+// GenerateKeyPair generates a new X25519 key pair, returning the public and the private key
+func GenerateKeyPair() ([]byte, []byte, error) {
+	public, private, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key pair: %w", err)
+	}
+	return public[:], private[:], nil
+}
+
+// SealToPublicKey encrypts plaintext so only the holder of the private key matching publicKey
+// can open it. Every call uses a new ephemeral key pair, the sender stays anonymous.
+func SealToPublicKey(publicKey, plaintext []byte) ([]byte, error) {
+	public, err := keyArray(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := box.SealAnonymous(nil, plaintext, public, rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal to public key: %w", err)
+	}
+	return sealed, nil
+}
+
+// OpenWithPrivateKey decrypts a value sealed by SealToPublicKey with the key pair it was sealed to
+func OpenWithPrivateKey(publicKey, privateKey, sealed []byte) ([]byte, error) {
+	public, err := keyArray(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	private, err := keyArray(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, ok := box.OpenAnonymous(nil, sealed, public, private)
+	if !ok {
+		return nil, errors.New("failed to open sealed value: wrong key or corrupted data")
+	}
+	return plaintext, nil
+}
+
+// keyArray checks the length of an X25519 key
+func keyArray(key []byte) (*[KeySize]byte, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key length %d, expected %d", len(key), KeySize)
+	}
+	return (*[KeySize]byte)(key), nil
+}
@@ -0,0 +1,52 @@
+//go:build unit
+
+package crypto
+
+import (
+	"testing"
+	"yubigo-pass/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldSealToPublicKeyAndOpenWithPrivateKey(t *testing.T) {
+	// given
+	public, private, err := GenerateKeyPair()
+	require.NoError(t, err)
+	plaintext := []byte(test.RandomString())
+
+	// when
+	sealed, err := SealToPublicKey(public, plaintext)
+	require.NoError(t, err)
+	opened, err := OpenWithPrivateKey(public, private, sealed)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+	assert.NotContains(t, string(sealed), string(plaintext))
+}
+
+func TestShouldNotOpenWithAnotherPrivateKey(t *testing.T) {
+	// given
+	public, _, err := GenerateKeyPair()
+	require.NoError(t, err)
+	otherPublic, otherPrivate, err := GenerateKeyPair()
+	require.NoError(t, err)
+	sealed, err := SealToPublicKey(public, []byte(test.RandomString()))
+	require.NoError(t, err)
+
+	// when
+	_, err = OpenWithPrivateKey(otherPublic, otherPrivate, sealed)
+
+	// then
+	assert.Error(t, err)
+}
+
+func TestShouldRejectKeysOfWrongLength(t *testing.T) {
+	// when
+	_, err := SealToPublicKey([]byte("short"), []byte(test.RandomString()))
+
+	// then
+	assert.ErrorContains(t, err, "invalid key length 5")
+}
@@ -0,0 +1,162 @@
+package crypto
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// StreamChunkSize is the size of the plaintext chunks a stream is sealed in
+const StreamChunkSize = 64 * 1024
+
+// streamMagic identifies the version of the stream format
+var streamMagic = []byte("YGPS\x01")
+
+const (
+	streamPrefixSize = 7
+	streamHeaderSize = 5 + streamPrefixSize
+)
+
+// ErrStreamCorrupted is returned when a sealed stream was modified, truncated,
+// or encrypted with a different key
+var ErrStreamCorrupted = errors.New("encrypted stream is corrupted or the key is wrong")
+
+// EncryptStream reads src until EOF and writes it to dst sealed in chunks of StreamChunkSize,
+// so large files never have to fit in memory. Every chunk is sealed with AES-256-GCM under
+// a nonce made of a random prefix, the chunk counter and a flag marking the last chunk,
+// which makes reordered, dropped or truncated chunks fail to open. associatedData binds the
+// stream to its owner, e.g. an attachment ID. It returns the number of plaintext bytes read.
+func (c Cipher) EncryptStream(dst io.Writer, src io.Reader, associatedData []byte) (int64, error) {
+	gcm, err := newGCM(c.key)
+	if err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, streamHeaderSize)
+	copy(header, streamMagic)
+	prefix := header[len(streamMagic):]
+	if _, err = io.ReadFull(rand.Reader, prefix); err != nil {
+		return 0, err
+	}
+	if _, err = dst.Write(header); err != nil {
+		return 0, err
+	}
+
+	in := bufio.NewReaderSize(src, StreamChunkSize)
+	chunk := make([]byte, StreamChunkSize)
+	sealed := make([]byte, 0, StreamChunkSize+gcm.Overhead())
+	var total int64
+	for counter := uint32(0); ; counter++ {
+		n, last, err := readChunk(in, chunk)
+		if err != nil {
+			return total, err
+		}
+		total += int64(n)
+
+		sealed = gcm.Seal(sealed[:0], chunkNonce(prefix, counter, last), chunk[:n], associatedData)
+		if _, err = dst.Write(sealed); err != nil {
+			return total, err
+		}
+		if last {
+			return total, nil
+		}
+		if counter == math.MaxUint32 {
+			return total, errors.New("stream is too long to encrypt")
+		}
+	}
+}
+
+// DecryptStream opens a stream sealed by EncryptStream with the same associatedData and
+// writes the plaintext to dst chunk by chunk. On error dst may already hold a part of
+// the plaintext, which callers should discard. It returns the number of bytes written.
+func (c Cipher) DecryptStream(dst io.Writer, src io.Reader, associatedData []byte) (int64, error) {
+	gcm, err := newGCM(c.key)
+	if err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, streamHeaderSize)
+	if _, err = io.ReadFull(src, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, ErrStreamCorrupted
+		}
+		return 0, err
+	}
+	if !bytes.Equal(header[:len(streamMagic)], streamMagic) {
+		return 0, fmt.Errorf("%w: unknown format", ErrStreamCorrupted)
+	}
+	prefix := header[len(streamMagic):]
+
+	in := bufio.NewReaderSize(src, StreamChunkSize+gcm.Overhead())
+	chunk := make([]byte, StreamChunkSize+gcm.Overhead())
+	plaintext := make([]byte, 0, StreamChunkSize)
+	var total int64
+	for counter := uint32(0); ; counter++ {
+		n, last, err := readChunk(in, chunk)
+		if err != nil {
+			return total, err
+		}
+
+		plaintext, err = gcm.Open(plaintext[:0], chunkNonce(prefix, counter, last), chunk[:n], associatedData)
+		if err != nil {
+			return total, ErrStreamCorrupted
+		}
+		if _, err = dst.Write(plaintext); err != nil {
+			return total, err
+		}
+		total += int64(len(plaintext))
+		if last {
+			return total, nil
+		}
+		if counter == math.MaxUint32 {
+			return total, ErrStreamCorrupted
+		}
+	}
+}
+
+// readChunk fills buf from in and reports whether the chunk is the last one of the stream
+func readChunk(in *bufio.Reader, buf []byte) (int, bool, error) {
+	n, err := io.ReadFull(in, buf)
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return n, true, nil
+	case err != nil:
+		return n, false, err
+	}
+
+	// A full chunk is the last one when nothing follows it
+	if _, err = in.Peek(1); err != nil {
+		if errors.Is(err, io.EOF) {
+			return n, true, nil
+		}
+		return n, false, err
+	}
+	return n, false, nil
+}
+
+// chunkNonce builds the GCM nonce of a chunk from the stream prefix, the chunk counter and the last chunk flag
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 0, streamPrefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// newGCM returns an AES-256-GCM AEAD for key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,125 @@
+//go:build unit
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStreamTestCipher(t *testing.T) Cipher {
+	key, err := GenerateAESKey()
+	require.NoError(t, err)
+	return NewCipher(key)
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+	return b
+}
+
+func TestCipherShouldEncryptAndDecryptStream(t *testing.T) {
+	sizes := []int{0, 1, StreamChunkSize - 1, StreamChunkSize, StreamChunkSize + 1, 3*StreamChunkSize + 17}
+	for _, size := range sizes {
+		t.Run(fmt.Sprintf("%d bytes", size), func(t *testing.T) {
+			// given
+			c := newStreamTestCipher(t)
+			plaintext := randomBytes(t, size)
+			var sealed, opened bytes.Buffer
+
+			// when
+			written, err := c.EncryptStream(&sealed, bytes.NewReader(plaintext), []byte("attachment"))
+			require.NoError(t, err)
+			read, err := c.DecryptStream(&opened, bytes.NewReader(sealed.Bytes()), []byte("attachment"))
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, int64(size), written)
+			assert.Equal(t, int64(size), read)
+			assert.True(t, bytes.Equal(plaintext, opened.Bytes()))
+			// shorter plaintexts may turn up in random ciphertext by chance
+			if size >= 32 {
+				assert.NotContains(t, sealed.String(), string(plaintext[:32]))
+			}
+		})
+	}
+}
+
+func TestCipherShouldNotDecryptTruncatedStream(t *testing.T) {
+	// given
+	c := newStreamTestCipher(t)
+	var sealed bytes.Buffer
+	_, err := c.EncryptStream(&sealed, bytes.NewReader(randomBytes(t, 2*StreamChunkSize+5)), nil)
+	require.NoError(t, err)
+	// drop the last chunk, so the stream ends on a chunk that is not marked as the last one
+	truncated := sealed.Bytes()[:streamHeaderSize+2*(StreamChunkSize+16)]
+
+	// when
+	_, err = c.DecryptStream(&bytes.Buffer{}, bytes.NewReader(truncated), nil)
+
+	// then
+	assert.ErrorIs(t, err, ErrStreamCorrupted)
+}
+
+func TestCipherShouldNotDecryptModifiedStream(t *testing.T) {
+	// given
+	c := newStreamTestCipher(t)
+	var sealed bytes.Buffer
+	_, err := c.EncryptStream(&sealed, bytes.NewReader(randomBytes(t, 100)), nil)
+	require.NoError(t, err)
+	modified := sealed.Bytes()
+	modified[streamHeaderSize+10] ^= 0xff
+
+	// when
+	_, err = c.DecryptStream(&bytes.Buffer{}, bytes.NewReader(modified), nil)
+
+	// then
+	assert.ErrorIs(t, err, ErrStreamCorrupted)
+}
+
+func TestCipherShouldNotDecryptStreamWithDifferentAssociatedData(t *testing.T) {
+	// given
+	c := newStreamTestCipher(t)
+	var sealed bytes.Buffer
+	_, err := c.EncryptStream(&sealed, bytes.NewReader(randomBytes(t, 100)), []byte("first"))
+	require.NoError(t, err)
+
+	// when
+	_, err = c.DecryptStream(&bytes.Buffer{}, bytes.NewReader(sealed.Bytes()), []byte("second"))
+
+	// then
+	assert.ErrorIs(t, err, ErrStreamCorrupted)
+}
+
+func TestCipherShouldNotDecryptStreamWithDifferentKey(t *testing.T) {
+	// given
+	c := newStreamTestCipher(t)
+	other := newStreamTestCipher(t)
+	var sealed bytes.Buffer
+	_, err := c.EncryptStream(&sealed, bytes.NewReader(randomBytes(t, 100)), nil)
+	require.NoError(t, err)
+
+	// when
+	_, err = other.DecryptStream(&bytes.Buffer{}, bytes.NewReader(sealed.Bytes()), nil)
+
+	// then
+	assert.ErrorIs(t, err, ErrStreamCorrupted)
+}
+
+func TestCipherShouldNotDecryptUnknownStreamFormat(t *testing.T) {
+	// given
+	c := newStreamTestCipher(t)
+
+	// when
+	_, err := c.DecryptStream(&bytes.Buffer{}, bytes.NewReader([]byte("not an encrypted stream at all")), nil)
+
+	// then
+	assert.EqualError(t, err, "encrypted stream is corrupted or the key is wrong: unknown format")
+}
@@ -0,0 +1,69 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/vault"
+)
+
+// WriteArchive writes every entry of the vault, with its custom fields, placement and the
+// attachments read from attachmentDir, as an archive sealed under the passphrase
+func WriteArchive(w io.Writer, v vault.Vault, attachmentDir, passphrase string, kdf archive.KDF) error {
+	doc, err := ArchiveDocument(v, vault.NewAttachments(v, attachmentDir))
+	if err != nil {
+		return err
+	}
+	return archive.Write(w, doc, passphrase, kdf)
+}
+
+// ArchiveDocument lays the entries of the vault out as an archive document. The keys of
+// the archive stay out of it, so it opens under any master password.
+func ArchiveDocument(v vault.Vault, attachments vault.Attachments) (archive.Document, error) {
+	entries, err := v.Entries()
+	if err != nil {
+		return archive.Document{}, err
+	}
+
+	doc := archive.Document{Entries: make([]archive.Entry, 0, len(entries))}
+	for _, entry := range entries {
+		details, err := v.Reveal(entry)
+		if err != nil {
+			return archive.Document{}, fmt.Errorf("failed to export %s: %w", entry.Title, err)
+		}
+		exported := ArchiveEntry(details)
+
+		files, err := attachments.List(entry.ID)
+		if err != nil {
+			return archive.Document{}, err
+		}
+		for _, file := range files {
+			var content bytes.Buffer
+			if err = attachments.Extract(file, &content); err != nil {
+				return archive.Document{}, err
+			}
+			exported.Attachments = append(exported.Attachments, archive.Attachment{Name: file.Name, Data: content.Bytes()})
+		}
+		doc.Entries = append(doc.Entries, exported)
+	}
+	return doc, nil
+}
+
+// ArchiveEntry lays a revealed entry out as an archive entry, without its attachments
+func ArchiveEntry(details vault.EntryDetails) archive.Entry {
+	exported := archive.Entry{
+		Type:   string(details.Item.Type),
+		Title:  details.Item.Title,
+		Notes:  details.Item.Notes,
+		Values: details.Item.Values,
+		Tags:   details.Tags,
+	}
+	if len(details.Folders) > 0 {
+		exported.Folder = details.Folders[0]
+	}
+	for _, field := range details.Fields {
+		exported.Fields = append(exported.Fields, archive.Field{Type: string(field.Type), Name: field.Name, Value: field.Value})
+	}
+	return exported
+}
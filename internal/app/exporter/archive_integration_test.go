@@ -0,0 +1,93 @@
+//go:build integration
+
+package exporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/importer"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testArchiveKDF keeps the key derivation cheap for tests
+var testArchiveKDF = archive.KDF{Iterations: 1, Memory: 64, Parallelism: 1}
+
+func TestShouldRestoreVaultFromArchiveUnderOtherMasterPassword(t *testing.T) {
+	// setup
+	source := vaulttest.New(t)
+	target := vaulttest.New(t)
+	sourceDir, targetDir := t.TempDir(), t.TempDir()
+
+	// given
+	for _, tt := range testItems {
+		fields := []model.CustomField{{Type: model.FieldTypeHidden, Name: "PIN", Value: "4242"}}
+		_, err := source.AddItem(tt.item, fields, tt.folder, []string{"work"})
+		require.NoError(t, err)
+	}
+	hotp := model.Item{Type: model.ItemTypeLogin, Title: "Bank", Values: model.ItemValues{
+		"username": "me", "otp": "otpauth://hotp/Bank:me?counter=7&secret=JBSWY3DPEHPK3PXP",
+	}}
+	_, err := source.AddItem(hotp, nil, "", nil)
+	require.NoError(t, err)
+	github, err := source.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	_, err = vault.NewAttachments(source, sourceDir).Add(github.ID, "recovery.txt", strings.NewReader("codes"))
+	require.NoError(t, err)
+
+	// when
+	var sealed bytes.Buffer
+	require.NoError(t, WriteArchive(&sealed, source, sourceDir, "correct horse", testArchiveKDF))
+	batch, err := importer.ReadArchive(&sealed, "correct horse")
+	require.NoError(t, err)
+	report, err := importer.Import(target, batch, importer.Options{AttachmentDir: targetDir})
+
+	// then
+	require.NoError(t, err)
+	assert.Len(t, report.Added, len(testItems)+1)
+	assert.Empty(t, report.Skipped)
+	for _, tt := range testItems {
+		entry, _ := tt.item.Entry()
+		stored, err := target.FindEntry(entry.Title, entry.Username)
+		require.NoError(t, err, tt.item.Title)
+		details, err := target.Reveal(stored)
+		require.NoError(t, err)
+
+		assert.Equal(t, tt.item.Type, details.Item.Type)
+		assert.Equal(t, tt.item.Notes, details.Item.Notes)
+		for key, value := range tt.item.Values {
+			assert.Equal(t, value, details.Item.Values[key], "%s %s", tt.item.Title, key)
+		}
+		assert.Equal(t, []string{"work"}, details.Tags)
+		if tt.folder != "" {
+			assert.Equal(t, []string{tt.folder}, details.Folders)
+		}
+		require.Len(t, details.Fields, 1)
+		assert.Equal(t, "4242", details.Fields[0].Value)
+	}
+
+	bank, err := target.FindEntry("Bank", "me")
+	require.NoError(t, err)
+	key, err := target.OTPKey(bank)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), key.Counter)
+	_, err = target.OneTimeCode(bank, time.Now())
+	require.NoError(t, err)
+
+	restored, err := target.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	attachments := vault.NewAttachments(target, targetDir)
+	files, err := attachments.List(restored.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	var content bytes.Buffer
+	require.NoError(t, attachments.Extract(files[0], &content))
+	assert.Equal(t, "codes", content.String())
+}
@@ -0,0 +1,184 @@
+// Package exporter writes the entries of a vault in the formats of other password managers
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"yubigo-pass/internal/app/bitwarden"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/google/uuid"
+)
+
+// bitwardenNativeKeys lists the item fields with a place of their own in Bitwarden items,
+// the values of other fields are exported as custom fields named by their label
+var bitwardenNativeKeys = map[model.ItemType][]string{
+	model.ItemTypeLogin:    {"username", "password", "url", "otp"},
+	model.ItemTypeCard:     {"cardholder", "number", "expiry", "cvv"},
+	model.ItemTypeIdentity: {"full_name", "document", "email", "phone", "address"},
+	model.ItemTypeSSHKey:   {"private_key", "public_key"},
+	model.ItemTypeAPIToken: {"key_id", "token", "endpoint"},
+	model.ItemTypeDatabase: {"username", "password"},
+}
+
+// WriteBitwarden writes every entry of the vault as a Bitwarden JSON export, password
+// protected with the default Bitwarden key derivation unless password is empty
+func WriteBitwarden(w io.Writer, v vault.Vault, password string) error {
+	exported, err := BitwardenVault(v)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if password == "" {
+		data, err = bitwarden.Marshal(exported)
+	} else {
+		data, err = bitwarden.Protect(exported, password, bitwarden.DefaultKDF)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// BitwardenVault lays the entries of the vault out as Bitwarden items. Item types Bitwarden
+// lacks become logins, entries are filed under their first folder and tags are left out.
+func BitwardenVault(v vault.Vault) (bitwarden.Vault, error) {
+	entries, err := v.Entries()
+	if err != nil {
+		return bitwarden.Vault{}, err
+	}
+
+	exported := bitwarden.Vault{Folders: []bitwarden.Folder{}, Items: make([]bitwarden.Item, 0, len(entries))}
+	folderIDs := map[string]string{}
+	for _, entry := range entries {
+		details, err := v.Reveal(entry)
+		if err != nil {
+			return bitwarden.Vault{}, fmt.Errorf("failed to export %s: %w", entry.Title, err)
+		}
+		item := bitwardenItem(details)
+		if len(details.Folders) > 0 {
+			path := details.Folders[0]
+			id, ok := folderIDs[path]
+			if !ok {
+				id = uuid.New().String()
+				folderIDs[path] = id
+				exported.Folders = append(exported.Folders, bitwarden.Folder{ID: id, Name: path})
+			}
+			item.FolderID = &id
+		}
+		exported.Items = append(exported.Items, item)
+	}
+	sort.Slice(exported.Folders, func(i, j int) bool {
+		return exported.Folders[i].Name < exported.Folders[j].Name
+	})
+	return exported, nil
+}
+
+// bitwardenItem maps a revealed entry onto a Bitwarden item of the closest type
+func bitwardenItem(details vault.EntryDetails) bitwarden.Item {
+	values := details.Item.Values
+	item := bitwarden.Item{
+		ID:   details.Entry.ID,
+		Name: details.Item.Title,
+	}
+	if details.Item.Notes != "" {
+		notes := details.Item.Notes
+		item.Notes = &notes
+	}
+
+	switch details.Item.Type {
+	case model.ItemTypeSecureNote:
+		item.Type = bitwarden.ItemTypeSecureNote
+		item.SecureNote = &bitwarden.SecureNote{}
+
+	case model.ItemTypeCard:
+		item.Type = bitwarden.ItemTypeCard
+		item.Card = &bitwarden.Card{
+			CardholderName: values["cardholder"],
+			Number:         values["number"],
+			Code:           values["cvv"],
+		}
+		if month, year, ok := strings.Cut(values["expiry"], "/"); ok {
+			if m, err := strconv.Atoi(month); err == nil {
+				item.Card.ExpMonth = strconv.Itoa(m)
+			}
+			item.Card.ExpYear = "20" + year
+		}
+
+	case model.ItemTypeIdentity:
+		item.Type = bitwarden.ItemTypeIdentity
+		item.Identity = &bitwarden.Identity{
+			PassportNumber: values["document"],
+			Email:          values["email"],
+			Phone:          values["phone"],
+		}
+		name := strings.Fields(values["full_name"])
+		if len(name) > 1 {
+			item.Identity.FirstName = strings.Join(name[:len(name)-1], " ")
+			item.Identity.LastName = name[len(name)-1]
+		} else {
+			item.Identity.FirstName = values["full_name"]
+		}
+		lines := []*string{&item.Identity.Address1, &item.Identity.Address2, &item.Identity.Address3}
+		for i, line := range strings.SplitN(values["address"], "\n", len(lines)) {
+			*lines[i] = strings.ReplaceAll(line, "\n", ", ")
+		}
+
+	case model.ItemTypeSSHKey:
+		item.Type = bitwarden.ItemTypeSSHKey
+		item.SSHKey = &bitwarden.SSHKey{PrivateKey: values["private_key"], PublicKey: values["public_key"]}
+
+	default:
+		entry, _ := details.Item.Entry()
+		item.Type = bitwarden.ItemTypeLogin
+		item.Login = &bitwarden.Login{Username: entry.Username, Password: entry.Password, Totp: entry.OTP}
+		if entry.Url != "" {
+			item.Login.URIs = []bitwarden.URI{{URI: entry.Url}}
+		}
+	}
+
+	item.Fields = append(bitwardenExtraFields(details.Item), bitwardenFields(details.Fields)...)
+	return item
+}
+
+// bitwardenExtraFields exports the item values without a place in Bitwarden items as custom fields
+func bitwardenExtraFields(item model.Item) []bitwarden.Field {
+	native := map[string]bool{}
+	for _, key := range bitwardenNativeKeys[item.Type] {
+		native[key] = true
+	}
+	var fields []bitwarden.Field
+	for _, field := range item.Type.Fields() {
+		value := item.Values[field.Key]
+		if native[field.Key] || value == "" {
+			continue
+		}
+		fieldType := bitwarden.FieldTypeText
+		if field.Secret {
+			fieldType = bitwarden.FieldTypeHidden
+		}
+		fields = append(fields, bitwarden.Field{Name: field.Label, Value: value, Type: fieldType})
+	}
+	return fields
+}
+
+// bitwardenFields maps custom fields onto Bitwarden custom fields
+func bitwardenFields(fields []model.CustomField) []bitwarden.Field {
+	mapped := make([]bitwarden.Field, 0, len(fields))
+	for _, field := range fields {
+		fieldType := bitwarden.FieldTypeText
+		switch field.Type {
+		case model.FieldTypeHidden:
+			fieldType = bitwarden.FieldTypeHidden
+		case model.FieldTypeBoolean:
+			fieldType = bitwarden.FieldTypeBoolean
+		}
+		mapped = append(mapped, bitwarden.Field{Name: field.Name, Value: field.Value, Type: fieldType})
+	}
+	return mapped
+}
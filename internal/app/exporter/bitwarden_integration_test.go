@@ -0,0 +1,110 @@
+//go:build integration
+
+package exporter
+
+import (
+	"bytes"
+	"testing"
+	"yubigo-pass/internal/app/bitwarden"
+	"yubigo-pass/internal/app/importer"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testItems holds an item of every type along with the folder it is filed in
+var testItems = []struct {
+	item   model.Item
+	folder string
+}{
+	{model.Item{Type: model.ItemTypeLogin, Title: "GitHub", Notes: "personal", Values: model.ItemValues{
+		"username": "octocat", "password": "hunter2", "url": "https://github.com",
+		"otp": "otpauth://totp/GitHub:octocat?secret=JBSWY3DPEHPK3PXP",
+	}}, "Work/Dev"},
+	{model.Item{Type: model.ItemTypeCard, Title: "Visa", Values: model.ItemValues{
+		"cardholder": "Jane Doe", "number": "4111111111111111", "expiry": "03/30", "cvv": "123",
+	}}, "Finance"},
+	{model.Item{Type: model.ItemTypeIdentity, Title: "Me", Values: model.ItemValues{
+		"full_name": "Jane Q Doe", "document": "X1234567", "email": "jane@example.com", "address": "1 Main St\nSpringfield",
+	}}, ""},
+	{model.Item{Type: model.ItemTypeSecureNote, Title: "Wi-Fi", Notes: "password on the router"}, "Work/Dev"},
+	{model.Item{Type: model.ItemTypeDatabase, Title: "Orders", Values: model.ItemValues{
+		"username": "admin", "password": "s3cret", "engine": "postgres", "host": "db.local",
+	}}, ""},
+}
+
+func TestShouldRoundTripVaultThroughBitwardenExport(t *testing.T) {
+	// setup
+	source := vaulttest.New(t)
+	target := vaulttest.New(t)
+
+	// given
+	for _, tt := range testItems {
+		fields := []model.CustomField{{Type: model.FieldTypeHidden, Name: "PIN", Value: "4242"}}
+		_, err := source.AddItem(tt.item, fields, tt.folder, nil)
+		require.NoError(t, err)
+	}
+
+	// when
+	var exported bytes.Buffer
+	require.NoError(t, WriteBitwarden(&exported, source, ""))
+	batch, err := importer.ReadBitwarden(&exported, "")
+	require.NoError(t, err)
+	report, err := importer.Import(target, batch, importer.Options{AttachmentDir: t.TempDir()})
+
+	// then
+	require.NoError(t, err)
+	assert.Len(t, report.Added, len(testItems))
+	assert.Empty(t, report.Skipped)
+	assert.Empty(t, report.Duplicates)
+	for _, tt := range testItems {
+		entry, _ := tt.item.Entry()
+		stored, err := target.FindEntry(entry.Title, entry.Username)
+		require.NoError(t, err, tt.item.Title)
+		details, err := target.Reveal(stored)
+		require.NoError(t, err)
+
+		if tt.item.Type == model.ItemTypeDatabase {
+			assert.Equal(t, model.ItemTypeLogin, details.Item.Type)
+			assert.Equal(t, model.NewCustomField(stored.ID, 0, model.FieldTypeText, "Engine, e.g. postgres", "postgres"), details.Fields[0])
+			continue
+		}
+		assert.Equal(t, tt.item.Type, details.Item.Type)
+		assert.Equal(t, tt.item.Notes, details.Item.Notes)
+		for key, value := range tt.item.Values {
+			assert.Equal(t, value, details.Item.Values[key], "%s %s", tt.item.Title, key)
+		}
+		if tt.folder == "" {
+			assert.Empty(t, details.Folders)
+		} else {
+			assert.Equal(t, []string{tt.folder}, details.Folders)
+		}
+		require.NotEmpty(t, details.Fields)
+		assert.Equal(t, "PIN", details.Fields[len(details.Fields)-1].Name)
+	}
+}
+
+func TestShouldWritePasswordProtectedBitwardenExport(t *testing.T) {
+	// setup
+	v := vaulttest.New(t)
+
+	// given
+	_, err := v.AddItem(testItems[0].item, nil, "", nil)
+	require.NoError(t, err)
+	exported, err := BitwardenVault(v)
+	require.NoError(t, err)
+
+	// when
+	data, err := bitwarden.Protect(exported, "correct horse", bitwarden.KDF{Type: bitwarden.KDFTypePBKDF2, Iterations: 1000})
+	require.NoError(t, err)
+	parsed, err := bitwarden.Parse(data, "correct horse")
+
+	// then
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+	require.Len(t, parsed.Items, 1)
+	assert.Equal(t, "hunter2", parsed.Items[0].Login.Password)
+	assert.Equal(t, []bitwarden.URI{{URI: "https://github.com"}}, parsed.Items[0].Login.URIs)
+}
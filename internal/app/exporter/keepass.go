@@ -0,0 +1,169 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"yubigo-pass/internal/app/keepass"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+)
+
+// keepassRootName is the name of the root group, as KeePassXC names it
+const keepassRootName = "Root"
+
+// WriteKeePass writes every entry of the vault, with its attachments read from attachmentDir,
+// as a KDBX 4 database locked with the given credentials
+func WriteKeePass(w io.Writer, v vault.Vault, attachmentDir string, credentials keepass.Credentials, opts keepass.Options) error {
+	db, err := KeePassDatabase(v, vault.NewAttachments(v, attachmentDir))
+	if err != nil {
+		return err
+	}
+	data, err := keepass.Marshal(db, credentials, opts)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// KeePassDatabase lays the entries of the vault out as a KeePass database. Folders become
+// groups, with entries filed under their first folder, and item values without a standard
+// string of their own are kept as custom strings named by their label.
+func KeePassDatabase(v vault.Vault, attachments vault.Attachments) (keepass.Database, error) {
+	entries, err := v.Entries()
+	if err != nil {
+		return keepass.Database{}, err
+	}
+	now := keepass.NewTimes(time.Now())
+	root, err := keepassGroup(keepassRootName, now)
+	if err != nil {
+		return keepass.Database{}, err
+	}
+	db := keepass.Database{Meta: keepass.Meta{Generator: "yubigo-pass", DatabaseName: "yubigo-pass"}}
+
+	for _, entry := range entries {
+		details, err := v.Reveal(entry)
+		if err != nil {
+			return keepass.Database{}, fmt.Errorf("failed to export %s: %w", entry.Title, err)
+		}
+		exported, err := keepassEntry(details, now)
+		if err != nil {
+			return keepass.Database{}, err
+		}
+
+		files, err := attachments.List(entry.ID)
+		if err != nil {
+			return keepass.Database{}, err
+		}
+		for _, file := range files {
+			var content bytes.Buffer
+			if err = attachments.Extract(file, &content); err != nil {
+				return keepass.Database{}, err
+			}
+			exported.Binaries = append(exported.Binaries, keepass.NewBinaryRef(file.Name, len(db.Binaries)))
+			db.Binaries = append(db.Binaries, keepass.Binary{Data: content.Bytes()})
+		}
+
+		var path []string
+		if len(details.Folders) > 0 {
+			path = vault.SplitFolderPath(details.Folders[0])
+		}
+		group, err := keepassSubgroup(&root, path, now)
+		if err != nil {
+			return keepass.Database{}, err
+		}
+		group.Entries = append(group.Entries, exported)
+	}
+	db.Root = root
+	return db, nil
+}
+
+// keepassGroup returns a new empty group
+func keepassGroup(name string, times keepass.Times) (keepass.Group, error) {
+	id, err := keepass.NewUUID()
+	if err != nil {
+		return keepass.Group{}, err
+	}
+	return keepass.Group{UUID: id, Name: name, Times: times}, nil
+}
+
+// keepassSubgroup returns the group at path below root, creating the missing groups along it
+func keepassSubgroup(root *keepass.Group, path []string, times keepass.Times) (*keepass.Group, error) {
+	group := root
+	for _, name := range path {
+		index := -1
+		for i, child := range group.Groups {
+			if child.Name == name {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			child, err := keepassGroup(name, times)
+			if err != nil {
+				return nil, err
+			}
+			group.Groups = append(group.Groups, child)
+			index = len(group.Groups) - 1
+		}
+		group = &group.Groups[index]
+	}
+	return group, nil
+}
+
+// keepassEntry maps a revealed entry onto a KeePass entry
+func keepassEntry(details vault.EntryDetails, times keepass.Times) (keepass.Entry, error) {
+	id, err := keepass.NewUUID()
+	if err != nil {
+		return keepass.Entry{}, err
+	}
+	entry, _ := details.Item.Entry()
+	exported := keepass.Entry{UUID: id, Times: times, Tags: strings.Join(details.Tags, ";")}
+	add := func(key, value string, protected bool) {
+		if value == "" {
+			return
+		}
+		exported.Strings = append(exported.Strings, keepass.String{
+			Key:   uniqueKey(exported, key),
+			Value: keepass.Value{Content: value, Protected: keepass.Bool(protected)},
+		})
+	}
+
+	add(keepass.KeyTitle, entry.Title, false)
+	add(keepass.KeyUserName, entry.Username, false)
+	add(keepass.KeyPassword, entry.Password, true)
+	add(keepass.KeyURL, entry.Url, false)
+	add(keepass.KeyNotes, entry.Notes, false)
+	add("otp", entry.OTP, true)
+	for _, field := range details.Item.Type.Fields() {
+		if field.Column != model.ColumnPayload {
+			continue
+		}
+		add(field.Label, details.Item.Values[field.Key], field.Secret)
+	}
+	for _, field := range details.Fields {
+		add(field.Name, field.Value, field.Type == model.FieldTypeHidden)
+	}
+	return exported, nil
+}
+
+// uniqueKey returns key, numbered when the entry already has a string of that name,
+// as KeePass requires string keys to be unique within an entry
+func uniqueKey(entry keepass.Entry, key string) string {
+	taken := func(candidate string) bool {
+		for _, s := range entry.Strings {
+			if s.Key == candidate {
+				return true
+			}
+		}
+		return false
+	}
+	candidate := key
+	for n := 2; taken(candidate); n++ {
+		candidate = fmt.Sprintf("%s (%d)", key, n)
+	}
+	return candidate
+}
@@ -0,0 +1,117 @@
+//go:build integration
+
+package exporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"yubigo-pass/internal/app/importer"
+	"yubigo-pass/internal/app/keepass"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldRoundTripVaultThroughKeePassDatabase(t *testing.T) {
+	// setup
+	source := vaulttest.New(t)
+	target := vaulttest.New(t)
+	sourceDir, targetDir := t.TempDir(), t.TempDir()
+	credentials := keepass.Credentials{Password: "correct horse"}
+
+	// given
+	for _, tt := range testItems {
+		fields := []model.CustomField{{Type: model.FieldTypeHidden, Name: "PIN", Value: "4242"}}
+		_, err := source.AddItem(tt.item, fields, tt.folder, []string{"work"})
+		require.NoError(t, err)
+	}
+	github, err := source.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	_, err = vault.NewAttachments(source, sourceDir).Add(github.ID, "recovery.txt", strings.NewReader("codes"))
+	require.NoError(t, err)
+
+	// when
+	var exported bytes.Buffer
+	require.NoError(t, WriteKeePass(&exported, source, sourceDir, credentials, test.KeePassOptions))
+	batch, err := importer.ReadKeePass(&exported, credentials)
+	require.NoError(t, err)
+	report, err := importer.Import(target, batch, importer.Options{AttachmentDir: targetDir})
+
+	// then
+	require.NoError(t, err)
+	assert.Len(t, report.Added, len(testItems))
+	assert.Empty(t, report.Skipped)
+	for _, tt := range testItems {
+		entry, _ := tt.item.Entry()
+		stored, err := target.FindEntry(entry.Title, entry.Username)
+		require.NoError(t, err, tt.item.Title)
+		details, err := target.Reveal(stored)
+		require.NoError(t, err)
+
+		assert.Equal(t, tt.item.Notes, details.Item.Notes)
+		assert.Equal(t, []string{"work"}, details.Tags)
+		if tt.folder == "" {
+			assert.Empty(t, details.Folders)
+		} else {
+			assert.Equal(t, []string{tt.folder}, details.Folders)
+		}
+		pin := details.Fields[len(details.Fields)-1]
+		assert.Equal(t, model.FieldTypeHidden, pin.Type)
+		assert.Equal(t, "4242", pin.Value)
+	}
+
+	imported, err := target.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	details, err := target.Reveal(imported)
+	require.NoError(t, err)
+	assert.Equal(t, testItems[0].item.Values, details.Item.Values)
+	attachments := vault.NewAttachments(target, targetDir)
+	files, err := attachments.List(imported.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "recovery.txt", files[0].Name)
+}
+
+func TestKeePassDatabaseShouldNestGroupsAndKeepStringKeysUnique(t *testing.T) {
+	// setup
+	v := vaulttest.New(t)
+
+	// given
+	card := testItems[1].item
+	fields := []model.CustomField{
+		{Type: model.FieldTypeText, Name: "Title", Value: "shadowed"},
+		{Type: model.FieldTypeText, Name: "Title", Value: "again"},
+	}
+	_, err := v.AddItem(card, fields, "Finance/Cards", nil)
+	require.NoError(t, err)
+	_, err = v.AddItem(model.Item{Type: model.ItemTypeSecureNote, Title: "Note", Notes: "x"}, nil, "Finance", nil)
+	require.NoError(t, err)
+
+	// when
+	db, err := KeePassDatabase(v, vault.NewAttachments(v, t.TempDir()))
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, db.Root.Groups, 1)
+	finance := db.Root.Groups[0]
+	assert.Equal(t, "Finance", finance.Name)
+	require.Len(t, finance.Entries, 1)
+	assert.Equal(t, "Note", finance.Entries[0].Get(keepass.KeyTitle))
+	require.Len(t, finance.Groups, 1)
+	require.Len(t, finance.Groups[0].Entries, 1)
+
+	exported := finance.Groups[0].Entries[0]
+	assert.Equal(t, "Visa", exported.Get(keepass.KeyTitle))
+	assert.Equal(t, "shadowed", exported.Get("Title (2)"))
+	assert.Equal(t, "again", exported.Get("Title (3)"))
+	for _, s := range exported.Strings {
+		if s.Value.Content == "123" {
+			assert.True(t, bool(s.Value.Protected), "security code should be protected")
+		}
+	}
+}
@@ -0,0 +1,534 @@
+// Package gitsync synchronizes a vault through a git repository, so the devices of a user share
+// their entries over any git remote. Every entry is sealed into a file of its own under entries/,
+// keeping diffs and merges per entry. Entries are sealed with a key derived from the master password
+// and a salt kept in the repository manifest, so every device of the owner opens them whatever the
+// salt of its own vault. Concurrent edits of an entry are resolved by the last writer winning, the
+// losing version is kept as a conflict copy. Attachments are not synchronized.
+package gitsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/crypto"
+	"yubigo-pass/internal/app/exporter"
+	"yubigo-pass/internal/app/syncutil"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/google/uuid"
+)
+
+// Format identifies yubigo-pass sync repositories in their manifest
+const Format = "yubigo-pass-sync"
+
+// Version is the version of the repository layout written
+const Version = 1
+
+// Branch is the branch entries are synchronized on
+const Branch = "main"
+
+// EnvRemote holds the URL of the git remote to synchronize with
+const EnvRemote = "YUBIGO_PASS_SYNC_REMOTE"
+
+// DirName is the name of the directory next to the database holding the working trees of its users
+const DirName = "sync"
+
+const (
+	manifestFile = "yubigo-pass-sync.json"
+	entriesDir   = "entries"
+	// maxRounds bounds the retries when another device pushes while a sync is running
+	maxRounds = 3
+)
+
+// Errors returned when a repository cannot be synchronized with
+var (
+	ErrNotSyncRepository  = errors.New("not a yubigo-pass sync repository")
+	ErrUnsupportedVersion = errors.New("sync repository was written by a newer version of yubigo-pass")
+	// errRetry asks for another round after the remote moved during this one
+	errRetry = errors.New("remote changed during sync")
+)
+
+// running serializes syncs, which share the working tree and the vault
+var running sync.Mutex
+
+// entryFile is the content of an entry file, the time of the last change of the entry in Unix
+// milliseconds and the entry sealed as an archive entry
+type entryFile struct {
+	Modified int64  `json:"modified"`
+	Sealed   string `json:"sealed"`
+}
+
+// Report tells what a sync exchanged. Sent counts entries written to the repository, Received
+// entries applied to the vault and Conflicts the conflict copies made of concurrent edits.
+type Report struct {
+	Sent      int
+	Received  int
+	Conflicts int
+}
+
+// Syncer synchronizes vaults through a git working tree cloned from the remote
+type Syncer struct {
+	Dir    string
+	Remote string
+	// Device names the commits of this device
+	Device string
+}
+
+// ForUser returns a Syncer keeping the working tree of a user under baseDir
+func ForUser(baseDir, userID, remote string) Syncer {
+	return New(filepath.Join(baseDir, userID), remote)
+}
+
+// New returns new Syncer instance keeping its working tree in dir
+func New(dir, remote string) Syncer {
+	device, err := os.Hostname()
+	if err != nil || device == "" {
+		device = "unknown"
+	}
+	return Syncer{
+		Dir:    dir,
+		Remote: remote,
+		Device: device,
+	}
+}
+
+// Sync pulls the entries changed on other devices into the vault and pushes the entries changed
+// in it. Local changes are committed first, then merged with the remote, resolving entries edited
+// on both sides by keeping the latest edit and a conflict copy of the other one.
+func (s Syncer) Sync(v vault.Vault) (Report, error) {
+	running.Lock()
+	defer running.Unlock()
+
+	if err := s.open(); err != nil {
+		return Report{}, err
+	}
+	var report Report
+	for round := 1; ; round++ {
+		r, err := s.round(v)
+		report.Sent += r.Sent
+		report.Received += r.Received
+		report.Conflicts += r.Conflicts
+		if !errors.Is(err, errRetry) || round == maxRounds {
+			return report, err
+		}
+	}
+}
+
+// open prepares the working tree, creating it on the first sync
+func (s Syncer) open() error {
+	if _, err := os.Stat(filepath.Join(s.Dir, ".git")); err == nil {
+		_, err = s.git("remote", "set-url", "origin", s.Remote)
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create sync directory: %w", err)
+	}
+	if _, err := s.git("init", "-q", "-b", Branch); err != nil {
+		return err
+	}
+	_, err := s.git("remote", "add", "origin", s.Remote)
+	return err
+}
+
+// round runs a single exchange with the remote
+func (s Syncer) round(v vault.Vault) (Report, error) {
+	committed := s.hasCommits()
+	if committed {
+		// the working tree only holds data derived from the vault and the remote, leftovers of an
+		// interrupted sync are dropped
+		if _, err := s.git("reset", "-q", "--hard"); err != nil {
+			return Report{}, err
+		}
+	}
+	if _, err := s.git("clean", "-q", "-f", "-d"); err != nil {
+		return Report{}, err
+	}
+
+	if _, err := s.git("fetch", "-q", "origin"); err != nil {
+		return Report{}, err
+	}
+	remote := s.hasRemoteBranch()
+	if remote && !committed {
+		if _, err := s.git("reset", "-q", "--hard", "origin/"+Branch); err != nil {
+			return Report{}, err
+		}
+	}
+
+	cipher, err := s.manifest(v)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var report Report
+	if report.Sent, err = s.export(v, cipher); err != nil {
+		return report, err
+	}
+	message := fmt.Sprintf("Update %d entries from %s", report.Sent, s.Device)
+	if report.Sent == 0 {
+		message = "Set up sync repository from " + s.Device
+	}
+	if err = s.commit(message); err != nil {
+		return report, err
+	}
+	if remote {
+		if report.Conflicts, err = s.merge(cipher); err != nil {
+			return report, err
+		}
+	}
+
+	var renamed int
+	if report.Received, renamed, err = s.apply(v, cipher); err != nil {
+		return report, err
+	}
+	if err = s.commit(fmt.Sprintf("Rename %d entries clashing with entries of %s", renamed, s.Device)); err != nil {
+		return report, err
+	}
+	return report, s.push()
+}
+
+// manifest reads the manifest of the repository, writing one with a fresh salt into a new repository,
+// and returns the cipher of the entries once the master password of the vault is verified to open them
+func (s Syncer) manifest(v vault.Vault) (crypto.Cipher, error) {
+	data, err := os.ReadFile(filepath.Join(s.Dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		manifest, cipher, err := syncutil.NewManifest(v, Format, Version)
+		if err != nil {
+			return crypto.Cipher{}, err
+		}
+		return cipher, s.writeJSON(manifestFile, manifest)
+	}
+	if err != nil {
+		return crypto.Cipher{}, fmt.Errorf("failed to read sync manifest: %w", err)
+	}
+
+	var manifest syncutil.Manifest
+	if err = json.Unmarshal(data, &manifest); err != nil || !manifest.Valid(Format) {
+		return crypto.Cipher{}, ErrNotSyncRepository
+	}
+	if manifest.Version > Version {
+		return crypto.Cipher{}, ErrUnsupportedVersion
+	}
+	return manifest.Cipher(v)
+}
+
+// export writes the entries of the vault changed since they were last written to the working tree
+func (s Syncer) export(v vault.Vault, cipher crypto.Cipher) (int, error) {
+	entries, err := v.Entries()
+	if err != nil {
+		return 0, err
+	}
+	if err = os.MkdirAll(filepath.Join(s.Dir, entriesDir), 0o700); err != nil {
+		return 0, fmt.Errorf("failed to create entries directory: %w", err)
+	}
+
+	sent := 0
+	for _, entry := range entries {
+		written, found, err := s.readEntry(entry.ID)
+		if err != nil {
+			return sent, err
+		}
+		if found && written.Modified >= entry.ModifiedAt {
+			continue
+		}
+		details, err := v.Reveal(entry)
+		if err != nil {
+			return sent, fmt.Errorf("failed to sync %s: %w", entry.Title, err)
+		}
+		if err = s.writeEntry(entry.ID, entry.ModifiedAt, exporter.ArchiveEntry(details), cipher); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// merge merges the remote branch, resolving entries changed on both sides. It returns the number
+// of conflict copies made.
+func (s Syncer) merge(cipher crypto.Cipher) (int, error) {
+	_, mergeErr := s.git("merge", "-q", "--no-edit", "origin/"+Branch)
+	if mergeErr == nil {
+		return 0, nil
+	}
+	if strings.Contains(mergeErr.Error(), "unrelated histories") {
+		// another device set the repository up while this one did; start over from the remote,
+		// the entries of this device are written again from the vault
+		if _, err := s.git("reset", "-q", "--hard", "origin/"+Branch); err != nil {
+			return 0, err
+		}
+		return 0, errRetry
+	}
+
+	unmerged, err := s.git("diff", "--name-only", "--diff-filter=U")
+	if err != nil || strings.TrimSpace(unmerged) == "" {
+		return 0, mergeErr
+	}
+	conflicts := 0
+	for _, path := range strings.Fields(unmerged) {
+		id, ok := entryID(path)
+		if !ok {
+			_, _ = s.git("merge", "--abort")
+			return 0, fmt.Errorf("failed to merge %s: %w", path, mergeErr)
+		}
+		copied, err := s.resolve(id, cipher)
+		if err != nil {
+			_, _ = s.git("merge", "--abort")
+			return 0, err
+		}
+		if copied {
+			conflicts++
+		}
+	}
+	if _, err = s.git("add", "-A"); err != nil {
+		return 0, err
+	}
+	if _, err = s.git("commit", "-q", "--no-edit"); err != nil {
+		return 0, err
+	}
+	return conflicts, nil
+}
+
+// resolve settles an entry changed on both sides by keeping the latest version, and the other
+// one as a conflict copy under a new ID when they differ. It reports whether a copy was made.
+func (s Syncer) resolve(id string, cipher crypto.Cipher) (bool, error) {
+	path := filepath.Join(entriesDir, id+".json")
+	ours, oursErr := s.stagedEntry(2, path)
+	theirs, theirsErr := s.stagedEntry(3, path)
+	switch {
+	case oursErr != nil && theirsErr != nil:
+		return false, oursErr
+	case oursErr != nil:
+		return false, s.writeJSON(path, theirs)
+	case theirsErr != nil:
+		return false, s.writeJSON(path, ours)
+	}
+
+	winner, loser := ours, theirs
+	if theirs.Modified > ours.Modified || theirs.Modified == ours.Modified && theirs.Sealed > ours.Sealed {
+		winner, loser = theirs, ours
+	}
+	if err := s.writeJSON(path, winner); err != nil {
+		return false, err
+	}
+
+	kept, err := unseal(id, winner, cipher)
+	if err != nil {
+		return false, err
+	}
+	lost, err := unseal(id, loser, cipher)
+	if err != nil {
+		return false, err
+	}
+	if reflect.DeepEqual(kept, lost) {
+		return false, nil
+	}
+	lost.Title = syncutil.ConflictTitle(lost.Title, time.UnixMilli(loser.Modified))
+	return true, s.writeEntry(uuid.New().String(), loser.Modified, lost, cipher)
+}
+
+// apply stores the entries of the working tree newer than their vault copy. Entries clashing with
+// another entry of the vault by title and username are renamed as conflict copies and written back,
+// unless the clash goes away once the other entries are applied, which may rename the other entry.
+// It returns the number of entries applied and renamed.
+func (s Syncer) apply(v vault.Vault, cipher crypto.Cipher) (int, int, error) {
+	entries, err := v.Entries()
+	if err != nil {
+		return 0, 0, err
+	}
+	modified := make(map[string]int64, len(entries))
+	for _, entry := range entries {
+		modified[entry.ID] = entry.ModifiedAt
+	}
+	dirEntries, err := os.ReadDir(filepath.Join(s.Dir, entriesDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read entries: %w", err)
+	}
+
+	var pending []syncutil.Change
+	for _, dirEntry := range dirEntries {
+		id, ok := entryID(filepath.Join(entriesDir, dirEntry.Name()))
+		if !ok {
+			continue
+		}
+		file, _, err := s.readEntry(id)
+		if err != nil {
+			return 0, 0, err
+		}
+		if stored, found := modified[id]; found && stored >= file.Modified {
+			continue
+		}
+		entry, err := unseal(id, file, cipher)
+		if err != nil {
+			return 0, 0, err
+		}
+		pending = append(pending, syncutil.Change{ID: id, Modified: time.UnixMilli(file.Modified), Entry: entry})
+	}
+
+	received := len(pending)
+	clashing, err := syncutil.Apply(v, pending)
+	if err != nil {
+		return 0, 0, err
+	}
+	now := time.Now()
+	for _, change := range clashing {
+		change.Entry.Title = syncutil.ConflictTitle(change.Entry.Title, change.Modified)
+		change.Modified = now
+		if err = syncutil.Put(v, change); err != nil {
+			return 0, 0, err
+		}
+		if err = s.writeEntry(change.ID, now.UnixMilli(), change.Entry, cipher); err != nil {
+			return 0, 0, err
+		}
+	}
+	return received, len(clashing), nil
+}
+
+// push publishes the local commits, asking for another round when the remote moved meanwhile
+func (s Syncer) push() error {
+	if !s.hasCommits() {
+		return nil
+	}
+	_, err := s.git("push", "-q", "origin", "HEAD:refs/heads/"+Branch)
+	if err != nil && (strings.Contains(err.Error(), "rejected") || strings.Contains(err.Error(), "fetch first")) {
+		return errRetry
+	}
+	return err
+}
+
+// commit commits every change of the working tree, if there is any
+func (s Syncer) commit(message string) error {
+	if _, err := s.git("add", "-A"); err != nil {
+		return err
+	}
+	if _, err := s.git("diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+	_, err := s.git("commit", "-q", "-m", message)
+	return err
+}
+
+// readEntry reads the file of an entry from the working tree, reporting whether there is one
+func (s Syncer) readEntry(id string) (entryFile, bool, error) {
+	data, err := os.ReadFile(filepath.Join(s.Dir, entriesDir, id+".json")) // #nosec G304 -- ID of a vault entry or a file of the entries directory
+	if errors.Is(err, os.ErrNotExist) {
+		return entryFile{}, false, nil
+	}
+	if err != nil {
+		return entryFile{}, false, fmt.Errorf("failed to read entry %s: %w", id, err)
+	}
+	file, err := parseEntry(data)
+	if err != nil {
+		return entryFile{}, false, fmt.Errorf("failed to read entry %s: %w", id, err)
+	}
+	return file, true, nil
+}
+
+// stagedEntry reads a version of a conflicted entry file, 2 for ours and 3 for theirs
+func (s Syncer) stagedEntry(stage int, path string) (entryFile, error) {
+	data, err := s.git("show", fmt.Sprintf(":%d:%s", stage, filepath.ToSlash(path)))
+	if err != nil {
+		return entryFile{}, err
+	}
+	file, err := parseEntry([]byte(data))
+	if err != nil {
+		return entryFile{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// writeEntry seals an entry and writes it to its file in the working tree
+func (s Syncer) writeEntry(id string, modified int64, entry archive.Entry, cipher crypto.Cipher) error {
+	sealed, err := syncutil.Seal(entry, associatedData(id, modified), cipher)
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(filepath.Join(entriesDir, id+".json"), entryFile{Modified: modified, Sealed: sealed})
+}
+
+// writeJSON writes an indented JSON document to a file of the working tree
+func (s Syncer) writeJSON(path string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err = os.WriteFile(filepath.Join(s.Dir, path), append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// hasCommits reports whether the current branch has any commit yet
+func (s Syncer) hasCommits() bool {
+	_, err := s.git("rev-parse", "-q", "--verify", "HEAD")
+	return err == nil
+}
+
+// hasRemoteBranch reports whether the remote has the sync branch yet
+func (s Syncer) hasRemoteBranch() bool {
+	_, err := s.git("rev-parse", "-q", "--verify", "refs/remotes/origin/"+Branch)
+	return err == nil
+}
+
+// git runs a git command in the working tree, committing as yubigo-pass on this device
+func (s Syncer) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-c", "commit.gpgsign=false"}, args...)...) // #nosec G204 -- fixed git subcommands
+	cmd.Dir = s.Dir
+	identity := "yubigo-pass@" + s.Device
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		// failures are told apart by their message, which must not be translated
+		"LC_ALL=C",
+		"GIT_AUTHOR_NAME=yubigo-pass", "GIT_AUTHOR_EMAIL="+identity,
+		"GIT_COMMITTER_NAME=yubigo-pass", "GIT_COMMITTER_EMAIL="+identity,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String() + " " + stdout.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], message)
+	}
+	return stdout.String(), nil
+}
+
+// parseEntry reads the content of an entry file
+func parseEntry(data []byte) (entryFile, error) {
+	var file entryFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Sealed == "" {
+		return entryFile{}, ErrNotSyncRepository
+	}
+	return file, nil
+}
+
+// unseal decrypts the file of an entry
+func unseal(id string, file entryFile, cipher crypto.Cipher) (archive.Entry, error) {
+	return syncutil.Unseal(file.Sealed, associatedData(id, file.Modified), cipher)
+}
+
+// entryID returns the ID of the entry a path of the working tree holds
+func entryID(path string) (string, bool) {
+	dir, name := filepath.Split(filepath.ToSlash(path))
+	id, ok := strings.CutSuffix(name, ".json")
+	return id, ok && dir == entriesDir+"/" && id != ""
+}
+
+// associatedData binds a sealed entry to its file and the time of the change the file holds, so
+// entries cannot be swapped between files or given another time without failing to open
+func associatedData(id string, modified int64) []byte {
+	return syncutil.AssociatedData(Format, id, strconv.FormatInt(modified, 10))
+}
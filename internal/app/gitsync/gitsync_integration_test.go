@@ -0,0 +1,276 @@
+//go:build integration
+
+package gitsync
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/syncutil"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// device is a vault of its own synchronized through a working tree of its own
+type device struct {
+	vault  vault.Vault
+	syncer Syncer
+}
+
+func setupRemote(t *testing.T) string {
+	t.Helper()
+	remote := filepath.Join(t.TempDir(), "vault.git")
+	out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput()
+	require.NoError(t, err, string(out))
+	return remote
+}
+
+func setupDevice(t *testing.T, remote, name, password string) device {
+	t.Helper()
+	syncer := New(filepath.Join(t.TempDir(), "sync"), remote)
+	syncer.Device = name
+	return device{
+		vault:  vaulttest.NewUser(t, test.NewDB(t), "alice", password),
+		syncer: syncer,
+	}
+}
+
+func (d device) sync(t *testing.T) Report {
+	t.Helper()
+	report, err := d.syncer.Sync(d.vault)
+	require.NoError(t, err)
+	return report
+}
+
+func (d device) reveal(t *testing.T, title string) vault.EntryDetails {
+	t.Helper()
+	entry, err := d.vault.FindEntry(title, "octocat")
+	require.NoError(t, err)
+	details, err := d.vault.Reveal(entry)
+	require.NoError(t, err)
+	return details
+}
+
+func (d device) edit(t *testing.T, title, password string) {
+	t.Helper()
+	// entries edited within the same millisecond could not be told apart
+	time.Sleep(2 * time.Millisecond)
+	details := d.reveal(t, title)
+	details.Item.Values["password"] = password
+	require.NoError(t, d.vault.UpdateItem(details.Entry, details.Item, "", nil))
+}
+
+func titles(t *testing.T, v vault.Vault) []string {
+	t.Helper()
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Title)
+	}
+	return names
+}
+
+func addGitHub(t *testing.T, v vault.Vault, password string) {
+	t.Helper()
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: password, Notes: "personal"},
+		[]model.CustomField{{Type: model.FieldTypeHidden, Name: "PIN", Value: "4242"}}, "Work/Dev", []string{"code"}))
+}
+
+func TestSyncShouldShareEntriesBetweenDevices(t *testing.T) {
+	// given
+	remote := setupRemote(t)
+	laptop := setupDevice(t, remote, "laptop", "master")
+	desktop := setupDevice(t, remote, "desktop", "master")
+	addGitHub(t, laptop.vault, "hunter2")
+
+	// when
+	sent := laptop.sync(t)
+	received := desktop.sync(t)
+
+	// then
+	assert.Equal(t, Report{Sent: 1}, sent)
+	assert.Equal(t, Report{Received: 1}, received)
+	original := laptop.reveal(t, "GitHub")
+	synced := desktop.reveal(t, "GitHub")
+	assert.Equal(t, original.Entry.ID, synced.Entry.ID)
+	assert.Equal(t, original.Entry.ModifiedAt, synced.Entry.ModifiedAt)
+	assert.Equal(t, "hunter2", synced.Item.Values["password"])
+	assert.Equal(t, "personal", synced.Item.Notes)
+	assert.Equal(t, []string{"Work/Dev"}, synced.Folders)
+	assert.Equal(t, []string{"code"}, synced.Tags)
+	require.Len(t, synced.Fields, 1)
+	assert.Equal(t, "4242", synced.Fields[0].Value)
+
+	// and the entry is sealed in a file of its own
+	data, err := os.ReadFile(filepath.Join(desktop.syncer.Dir, entriesDir, original.Entry.ID+".json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "GitHub")
+	assert.NotContains(t, string(data), "hunter2")
+
+	// and syncing again exchanges nothing
+	assert.Equal(t, Report{}, laptop.sync(t))
+	assert.Equal(t, Report{}, desktop.sync(t))
+}
+
+func TestSyncShouldApplyEditOfOtherDevice(t *testing.T) {
+	// given
+	remote := setupRemote(t)
+	laptop := setupDevice(t, remote, "laptop", "master")
+	desktop := setupDevice(t, remote, "desktop", "master")
+	addGitHub(t, laptop.vault, "hunter2")
+	laptop.sync(t)
+	desktop.sync(t)
+	desktop.edit(t, "GitHub", "correct horse")
+
+	// when
+	sent := desktop.sync(t)
+	received := laptop.sync(t)
+
+	// then
+	assert.Equal(t, Report{Sent: 1}, sent)
+	assert.Equal(t, Report{Received: 1}, received)
+	assert.Equal(t, "correct horse", laptop.reveal(t, "GitHub").Item.Values["password"])
+	assert.Equal(t, []string{"GitHub"}, titles(t, laptop.vault))
+}
+
+func TestSyncShouldKeepLatestOfConcurrentEditsAndConflictCopy(t *testing.T) {
+	// given
+	remote := setupRemote(t)
+	laptop := setupDevice(t, remote, "laptop", "master")
+	desktop := setupDevice(t, remote, "desktop", "master")
+	addGitHub(t, laptop.vault, "hunter2")
+	laptop.sync(t)
+	desktop.sync(t)
+	laptop.edit(t, "GitHub", "from laptop")
+	desktop.edit(t, "GitHub", "from desktop")
+
+	// when
+	laptop.sync(t)
+	report := desktop.sync(t)
+
+	// then
+	assert.Equal(t, 1, report.Conflicts)
+	assert.Equal(t, "from desktop", desktop.reveal(t, "GitHub").Item.Values["password"])
+	var copyTitle string
+	for _, title := range titles(t, desktop.vault) {
+		if strings.HasPrefix(title, "GitHub (conflict copy ") {
+			copyTitle = title
+		}
+	}
+	require.NotEmpty(t, copyTitle)
+	assert.Equal(t, "from laptop", desktop.reveal(t, copyTitle).Item.Values["password"])
+
+	// and the laptop converges on the same entries
+	laptop.sync(t)
+	assert.Equal(t, "from desktop", laptop.reveal(t, "GitHub").Item.Values["password"])
+	assert.ElementsMatch(t, titles(t, desktop.vault), titles(t, laptop.vault))
+}
+
+func TestSyncShouldRenameEntryClashingWithEntryOfOtherDevice(t *testing.T) {
+	// given
+	remote := setupRemote(t)
+	laptop := setupDevice(t, remote, "laptop", "master")
+	desktop := setupDevice(t, remote, "desktop", "master")
+	addGitHub(t, laptop.vault, "from laptop")
+	addGitHub(t, desktop.vault, "from desktop")
+
+	// when
+	laptop.sync(t)
+	desktop.sync(t)
+	laptop.sync(t)
+
+	// then
+	assert.Len(t, titles(t, desktop.vault), 2)
+	assert.ElementsMatch(t, titles(t, desktop.vault), titles(t, laptop.vault))
+	assert.Equal(t, "from desktop", desktop.reveal(t, "GitHub").Item.Values["password"])
+}
+
+func TestSyncShouldRejectOtherMasterPassword(t *testing.T) {
+	// given
+	remote := setupRemote(t)
+	laptop := setupDevice(t, remote, "laptop", "master")
+	intruder := setupDevice(t, remote, "intruder", "guess")
+	addGitHub(t, laptop.vault, "hunter2")
+	laptop.sync(t)
+
+	// when
+	_, err := intruder.syncer.Sync(intruder.vault)
+
+	// then
+	assert.ErrorIs(t, err, syncutil.ErrWrongPassword)
+	assert.Empty(t, titles(t, intruder.vault))
+}
+
+func TestSyncShouldNotPushEntriesSealedUnderOtherMasterPassword(t *testing.T) {
+	// given
+	remote := setupRemote(t)
+	laptop := setupDevice(t, remote, "laptop", "master")
+	intruder := setupDevice(t, remote, "intruder", "guess")
+	laptop.sync(t)
+	addGitHub(t, intruder.vault, "hunter2")
+
+	// when
+	_, err := intruder.syncer.Sync(intruder.vault)
+
+	// then
+	assert.ErrorIs(t, err, syncutil.ErrWrongPassword)
+	assert.Equal(t, Report{}, laptop.sync(t))
+	out, err := exec.Command("git", "--git-dir", remote, "ls-tree", "-r", "--name-only", Branch).CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Equal(t, manifestFile, strings.TrimSpace(string(out)))
+}
+
+func TestSyncShouldRejectEntryMovedToFileOfOtherEntry(t *testing.T) {
+	// given
+	remote := setupRemote(t)
+	laptop := setupDevice(t, remote, "laptop", "master")
+	desktop := setupDevice(t, remote, "desktop", "master")
+	addGitHub(t, laptop.vault, "hunter2")
+	laptop.sync(t)
+	github := laptop.reveal(t, "GitHub")
+	moved := filepath.Join(entriesDir, uuid.New().String()+".json")
+	require.NoError(t, os.Rename(filepath.Join(laptop.syncer.Dir, entriesDir, github.Entry.ID+".json"), filepath.Join(laptop.syncer.Dir, moved)))
+	require.NoError(t, laptop.syncer.commit("Move entry"))
+	_, err := laptop.syncer.git("push", "-q", "origin", "HEAD:refs/heads/"+Branch)
+	require.NoError(t, err)
+
+	// when
+	_, err = desktop.syncer.Sync(desktop.vault)
+
+	// then
+	assert.ErrorIs(t, err, syncutil.ErrCorruptedEntry)
+	assert.Empty(t, titles(t, desktop.vault))
+}
+
+func TestSyncShouldRetryWhenOtherDeviceSetRepositoryUpMeanwhile(t *testing.T) {
+	// given
+	remote := setupRemote(t)
+	laptop := setupDevice(t, remote, "laptop", "master")
+	desktop := setupDevice(t, remote, "desktop", "master")
+	addGitHub(t, laptop.vault, "hunter2")
+	require.NoError(t, desktop.vault.AddEntry(model.Password{Title: "GitLab", Username: "tanuki", Password: "x"}, nil, "", nil))
+	// the desktop committed a repository of its own before the laptop pushed
+	require.NoError(t, desktop.syncer.open())
+	_, err := desktop.syncer.manifest(desktop.vault)
+	require.NoError(t, err)
+	require.NoError(t, desktop.syncer.commit("Set up sync repository from desktop"))
+	laptop.sync(t)
+
+	// when
+	desktop.sync(t)
+	laptop.sync(t)
+
+	// then
+	assert.ElementsMatch(t, []string{"GitHub", "GitLab"}, titles(t, desktop.vault))
+	assert.ElementsMatch(t, []string{"GitHub", "GitLab"}, titles(t, laptop.vault))
+}
@@ -0,0 +1,233 @@
+// Package health reports on the security of the passwords of a vault. It decrypts every login
+// and flags weak passwords, passwords reused across entries, passwords left unchanged for long,
+// passwords seen in breaches and sites reached over plain http://, and scores the vault overall.
+package health
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"yubigo-pass/internal/app/breach"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+// Defaults of the options of a report
+const (
+	DefaultMinStrength = 3
+	DefaultMaxAge      = 365 * 24 * time.Hour
+	// MaxStrength is the score of the strongest passwords
+	MaxStrength = 4
+)
+
+// Issue is a weakness of an entry
+type Issue string
+
+// Issues found in entries, ordered by how much they lower the score
+const (
+	IssueBreached    Issue = "breached"
+	IssueWeak        Issue = "weak"
+	IssueReused      Issue = "reused"
+	IssueOld         Issue = "old"
+	IssueInsecureURL Issue = "insecure URL"
+)
+
+// maxScore is the score of entries without issues
+const maxScore = 100
+
+// penalties are the points an entry loses for each issue
+var penalties = map[Issue]int{
+	IssueBreached:    50,
+	IssueWeak:        40,
+	IssueReused:      30,
+	IssueOld:         15,
+	IssueInsecureURL: 15,
+}
+
+// Options tune what counts as an issue. Passwords scoring below MinStrength out of MaxStrength
+// are weak and entries left unchanged for more than MaxAge are old, a zero MaxAge never flagging
+// them. Breaches is consulted for passwords seen in breaches when not nil.
+type Options struct {
+	MinStrength int
+	MaxAge      time.Duration
+	Breaches    breach.Checker
+	Now         time.Time
+}
+
+// DefaultOptions returns the options reports are made with unless told otherwise
+func DefaultOptions() Options {
+	return Options{MinStrength: DefaultMinStrength, MaxAge: DefaultMaxAge, Now: time.Now()}
+}
+
+// Finding lists the issues of an entry. Strength is the zxcvbn score of its password, ReusedBy
+// the titles of the other entries with the same password, Age the time since it was last changed
+// and Breaches how often its password was seen in breaches.
+type Finding struct {
+	Entry    model.Password
+	Issues   []Issue
+	Strength int
+	ReusedBy []string
+	Age      time.Duration
+	Breaches int
+	Score    int
+}
+
+// Has reports whether the entry has issue
+func (f Finding) Has(issue Issue) bool {
+	return slices.Contains(f.Issues, issue)
+}
+
+// Describe explains every issue of the entry, in the order of Issues
+func (f Finding) Describe() []string {
+	descriptions := make([]string, 0, len(f.Issues))
+	for _, issue := range f.Issues {
+		switch issue {
+		case IssueBreached:
+			descriptions = append(descriptions, fmt.Sprintf("password seen %d times in breaches", f.Breaches))
+		case IssueWeak:
+			descriptions = append(descriptions, fmt.Sprintf("weak password (strength %d/%d)", f.Strength, MaxStrength))
+		case IssueReused:
+			descriptions = append(descriptions, "password reused by "+strings.Join(f.ReusedBy, ", "))
+		case IssueOld:
+			descriptions = append(descriptions, fmt.Sprintf("unchanged for %d days", int(f.Age.Hours()/24)))
+		case IssueInsecureURL:
+			descriptions = append(descriptions, "insecure URL "+f.Entry.Url)
+		}
+	}
+	return descriptions
+}
+
+// Report is the outcome of checking a vault. Checked counts the logins checked, Score is their
+// average score out of 100, an entry losing points for each of its issues, and Findings lists the
+// entries with issues, the lowest scoring first.
+type Report struct {
+	Checked  int
+	Score    int
+	Findings []Finding
+}
+
+// Count returns the number of entries with issue
+func (r Report) Count(issue Issue) int {
+	count := 0
+	for _, finding := range r.Findings {
+		if finding.Has(issue) {
+			count++
+		}
+	}
+	return count
+}
+
+// Strength returns the zxcvbn score of a password from 0 to MaxStrength, guessing it with the
+// help of userInputs such as the title and username of the entry
+func Strength(password string, userInputs ...string) int {
+	if password == "" {
+		return 0
+	}
+	var inputs []string
+	for _, input := range userInputs {
+		if input != "" {
+			inputs = append(inputs, input)
+		}
+	}
+	return zxcvbn.PasswordStrength(password, inputs).Score
+}
+
+// Check decrypts the logins of a vault and reports their issues
+func Check(v vault.Vault, opts Options) (Report, error) {
+	entries, err := v.Entries()
+	if err != nil {
+		return Report{}, err
+	}
+	type login struct {
+		entry    model.Password
+		password string
+	}
+	var logins []login
+	byPassword := map[string][]model.Password{}
+	for _, entry := range entries {
+		if entry.ItemType() != model.ItemTypeLogin {
+			continue
+		}
+		password, err := v.RevealPassword(entry)
+		if err != nil {
+			return Report{}, err
+		}
+		logins = append(logins, login{entry: entry, password: password})
+		if password != "" {
+			byPassword[password] = append(byPassword[password], entry)
+		}
+	}
+
+	report := Report{Checked: len(logins), Score: maxScore}
+	total := 0
+	for _, l := range logins {
+		finding, err := check(l.entry, l.password, byPassword[l.password], opts)
+		if err != nil {
+			return Report{}, err
+		}
+		total += finding.Score
+		if len(finding.Issues) > 0 {
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+	if len(logins) > 0 {
+		report.Score = (total + len(logins)/2) / len(logins)
+	}
+	slices.SortStableFunc(report.Findings, func(a, b Finding) int {
+		return cmp.Or(cmp.Compare(a.Score, b.Score), strings.Compare(strings.ToLower(a.Entry.Title), strings.ToLower(b.Entry.Title)))
+	})
+	return report, nil
+}
+
+// check looks an entry up for issues, sharing is the entries with the same password including itself
+func check(entry model.Password, password string, sharing []model.Password, opts Options) (Finding, error) {
+	finding := Finding{Entry: entry, Strength: Strength(password, entry.Title, entry.Username)}
+	if opts.Breaches != nil && password != "" {
+		count, err := opts.Breaches.Count(breach.HashPassword(password))
+		if err != nil {
+			return Finding{}, fmt.Errorf("failed to check %s for breaches: %w", entry.Title, err)
+		}
+		if count > 0 {
+			finding.Breaches = count
+			finding.Issues = append(finding.Issues, IssueBreached)
+		}
+	}
+	if password != "" && finding.Strength < opts.MinStrength {
+		finding.Issues = append(finding.Issues, IssueWeak)
+	}
+	for _, other := range sharing {
+		if other.ID != entry.ID {
+			finding.ReusedBy = append(finding.ReusedBy, other.Title)
+		}
+	}
+	if len(finding.ReusedBy) > 0 {
+		finding.Issues = append(finding.Issues, IssueReused)
+	}
+	if entry.ModifiedAt > 0 {
+		finding.Age = opts.Now.Sub(time.UnixMilli(entry.ModifiedAt))
+		if opts.MaxAge > 0 && finding.Age > opts.MaxAge {
+			finding.Issues = append(finding.Issues, IssueOld)
+		}
+	}
+	if insecureURL(entry.Url) {
+		finding.Issues = append(finding.Issues, IssueInsecureURL)
+	}
+
+	finding.Score = maxScore
+	for _, issue := range finding.Issues {
+		finding.Score -= penalties[issue]
+	}
+	finding.Score = max(finding.Score, 0)
+	return finding, nil
+}
+
+// insecureURL reports whether a URL is reached over plain http://
+func insecureURL(raw string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	return err == nil && strings.EqualFold(parsed.Scheme, "http")
+}
@@ -0,0 +1,85 @@
+//go:build integration
+
+package health
+
+import (
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckShouldFlagWeakReusedOldAndInsecureEntries(t *testing.T) {
+	// given
+	v := vaulttest.New(t)
+	now := time.Now()
+	old := now.Add(-400 * 24 * time.Hour).UnixMilli()
+	require.NoError(t, v.AddEntry(model.Password{Title: "Bank", Username: "me", Password: "password"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "Vq7#mZ2!pLx9@wRt", Url: "http://github.com"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Gitea", Username: "octocat", Password: "Vq7#mZ2!pLx9@wRt", Url: "https://gitea.com"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Forum", Username: "me", Password: "8fj#2kLq!0zPw&yU", ModifiedAt: old}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Mail", Username: "me", Password: "Tr5$eW9^nB3*kJ1x"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Wifi", Username: "guest", Password: ""}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Diary", Type: model.ItemTypeSecureNote, Notes: "1234"}, nil, "", nil))
+	opts := DefaultOptions()
+	opts.Now = now
+
+	// when
+	report, err := Check(v, opts)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 6, report.Checked)
+	require.Len(t, report.Findings, 4)
+	findings := map[string]Finding{}
+	for _, finding := range report.Findings {
+		findings[finding.Entry.Title] = finding
+	}
+	assert.Equal(t, []Issue{IssueWeak}, findings["Bank"].Issues)
+	assert.Equal(t, []Issue{IssueReused, IssueInsecureURL}, findings["GitHub"].Issues)
+	assert.Equal(t, []string{"Gitea"}, findings["GitHub"].ReusedBy)
+	assert.Equal(t, []Issue{IssueReused}, findings["Gitea"].Issues)
+	assert.Equal(t, []Issue{IssueOld}, findings["Forum"].Issues)
+	assert.Contains(t, findings["Forum"].Describe(), "unchanged for 400 days")
+	assert.NotContains(t, findings, "Mail")
+	assert.NotContains(t, findings, "Wifi")
+	assert.Equal(t, 1, report.Count(IssueWeak))
+	assert.Equal(t, 2, report.Count(IssueReused))
+}
+
+func TestCheckShouldScoreVaultAndSortLowestScoringFirst(t *testing.T) {
+	// given
+	v := vaulttest.New(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "Shop", Username: "me", Password: "Vq7#mZ2!pLx9@wRt", Url: "http://shop.example"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Bank", Username: "me", Password: "password"}, nil, "", nil))
+	require.NoError(t, v.AddEntry(model.Password{Title: "Mail", Username: "me", Password: "Tr5$eW9^nB3*kJ1x"}, nil, "", nil))
+
+	// when
+	report, err := Check(v, DefaultOptions())
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, report.Findings, 2)
+	assert.Equal(t, "Bank", report.Findings[0].Entry.Title)
+	assert.Equal(t, 60, report.Findings[0].Score)
+	assert.Equal(t, "Shop", report.Findings[1].Entry.Title)
+	assert.Equal(t, 85, report.Findings[1].Score)
+	assert.Equal(t, (60+85+100+1)/3, report.Score)
+}
+
+func TestCheckShouldScoreEmptyVaultFully(t *testing.T) {
+	// given
+	v := vaulttest.New(t)
+
+	// when
+	report, err := Check(v, DefaultOptions())
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Checked)
+	assert.Equal(t, 100, report.Score)
+	assert.Empty(t, report.Findings)
+}
@@ -0,0 +1,559 @@
+// Package httpsync synchronizes a vault through a yubigo-pass sync server, which
+// `yubigo-pass serve-sync` runs. The server only stores entries sealed under a key derived from
+// the master password and a salt kept in the manifest of the vault, so it never sees a secret.
+// Every entry carries a vector clock of the revisions the devices of the owner made of it: a
+// device sends the entries it changed since its last sync and pulls the entries stored after the
+// last revision of the server it saw. Versions made concurrently on two devices are not merged,
+// they are kept as conflicts for the owner to resolve. Attachments are not synchronized.
+package httpsync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/crypto"
+	"yubigo-pass/internal/app/exporter"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/syncutil"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/google/uuid"
+)
+
+// Format identifies the manifests of vaults kept by a sync server
+const Format = "yubigo-pass-sync-server"
+
+// Version is the version of the protocol spoken
+const Version = 1
+
+// Environment variables holding the sync server to synchronize with
+const (
+	EnvServer = "YUBIGO_PASS_SYNC_SERVER"
+	EnvToken  = "YUBIGO_PASS_SYNC_TOKEN" // #nosec G101
+)
+
+// ServerDirName is the directory, in the sync directory, serve-sync keeps vaults in by default
+const ServerDirName = "served"
+
+const (
+	manifestFile = "manifest.json"
+	entriesDir   = "entries"
+	// clientsDir holds the sync state of every user, in the sync directory
+	clientsDir     = "clients"
+	requestTimeout = 30 * time.Second
+)
+
+// Errors returned when a vault cannot be synchronized
+var (
+	ErrUnsupportedVersion = errors.New("sync server vault was set up by a newer version of yubigo-pass")
+	ErrUnauthorized       = errors.New("sync server rejected the token")
+	ErrNoConflict         = errors.New("entry has no sync conflict")
+)
+
+// running serializes syncs, which share the state file and the vault
+var running sync.Mutex
+
+// Record is a version of an entry as the server keeps it. Revision is the revision of the vault
+// the server stored it at, Modified the time of the change in Unix milliseconds and Sealed the
+// entry sealed as an archive entry.
+type Record struct {
+	ID       string `json:"id"`
+	Revision int64  `json:"revision"`
+	Clock    Clock  `json:"clock"`
+	Modified int64  `json:"modified"`
+	Sealed   string `json:"sealed"`
+}
+
+// Changes lists the entries stored after a revision along with the latest revision of the vault
+type Changes struct {
+	Revision int64    `json:"revision"`
+	Entries  []Record `json:"entries"`
+}
+
+// errorBody is the body of a failed request
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// Report tells what a sync exchanged. Sent counts entries stored on the server, Received entries
+// applied to the vault and Conflicts the entries found edited concurrently on another device.
+type Report struct {
+	Sent      int
+	Received  int
+	Conflicts int
+}
+
+// Conflict is an entry edited on this device and another one without either knowing of the other
+// edit, with the version of both devices
+type Conflict struct {
+	ID             string
+	Local          archive.Entry
+	LocalModified  time.Time
+	Remote         archive.Entry
+	RemoteModified time.Time
+}
+
+// Resolution is the version of a conflicting entry kept
+type Resolution int
+
+// Resolutions of a conflict
+const (
+	KeepLocal Resolution = iota
+	KeepRemote
+)
+
+// state is what a device remembers of the last sync with the server
+type state struct {
+	URL     string `json:"url"`
+	Account string `json:"account"`
+	// Device names the revision counter of this device in entry clocks
+	Device string `json:"device"`
+	Salt   string `json:"salt"`
+	// Cursor is the latest revision of the vault pulled
+	Cursor    int64                 `json:"cursor"`
+	Entries   map[string]entryState `json:"entries"`
+	Conflicts map[string]Record     `json:"conflicts"`
+}
+
+// entryState is the clock of an entry when it was last exchanged and the time of its last change
+// in the vault then, later changes are sent on the next sync
+type entryState struct {
+	Clock    Clock `json:"clock"`
+	Modified int64 `json:"modified"`
+}
+
+// Client synchronizes vaults with a sync server
+type Client struct {
+	URL   string
+	Token string
+	// Account names the vault on the server, every device of a user syncs with the same one
+	Account string
+	// StatePath is the file remembering what was last exchanged with the server
+	StatePath string
+	HTTP      *http.Client
+}
+
+// ForUser returns a Client remembering the syncs of a user under baseDir, the sync directory
+func ForUser(baseDir, userID, account, serverURL, token string) Client {
+	return New(serverURL, token, account, filepath.Join(baseDir, clientsDir, userID+".json"))
+}
+
+// New returns new Client instance
+func New(serverURL, token, account, statePath string) Client {
+	return Client{
+		URL:       strings.TrimRight(serverURL, "/"),
+		Token:     token,
+		Account:   account,
+		StatePath: statePath,
+		HTTP:      &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Sync pulls the entries changed on other devices into the vault and sends the entries changed in
+// it. Entries changed on both sides are left untouched and reported as conflicts, see Conflicts.
+func (c Client) Sync(v vault.Vault) (Report, error) {
+	running.Lock()
+	defer running.Unlock()
+
+	st, err := c.loadState()
+	if err != nil {
+		return Report{}, err
+	}
+	manifest, err := c.manifest(v)
+	if err != nil {
+		return Report{}, err
+	}
+	cipher, err := manifest.Cipher(v)
+	if err != nil {
+		return Report{}, err
+	}
+	if st.Salt != manifest.Salt {
+		// the vault was set up again on the server, everything is exchanged anew
+		st.Salt, st.Cursor, st.Entries = manifest.Salt, 0, map[string]entryState{}
+	}
+
+	var report Report
+	err = c.pull(v, cipher, &st, &report)
+	if err == nil {
+		err = c.push(v, cipher, &st, &report)
+	}
+	return report, errors.Join(err, c.saveState(st))
+}
+
+// pull applies the entries stored on the server since the last sync
+func (c Client) pull(v vault.Vault, cipher crypto.Cipher, st *state, report *Report) error {
+	var changes Changes
+	if _, err := c.do(http.MethodGet, "/entries?since="+fmt.Sprint(st.Cursor), nil, &changes, http.StatusOK); err != nil {
+		return err
+	}
+	entries, err := v.Entries()
+	if err != nil {
+		return err
+	}
+
+	var pending []syncutil.Change
+	clocks := map[string]Clock{}
+	for _, record := range changes.Entries {
+		known := st.Entries[record.ID]
+		if known.Clock.Descends(record.Clock) {
+			continue
+		}
+		remote, err := unseal(record, cipher)
+		if err != nil {
+			return err
+		}
+
+		i := slices.IndexFunc(entries, func(e model.Password) bool { return e.ID == record.ID })
+		if i >= 0 && entries[i].ModifiedAt > known.Modified {
+			// changed here as well, unless both devices made the same change it is a conflict
+			same, err := sameEntry(v, entries[i], remote)
+			if err != nil {
+				return err
+			}
+			if same {
+				st.Entries[record.ID] = entryState{Clock: known.Clock.Merge(record.Clock), Modified: entries[i].ModifiedAt}
+				delete(st.Conflicts, record.ID)
+				continue
+			}
+			if _, waiting := st.Conflicts[record.ID]; !waiting {
+				report.Conflicts++
+			}
+			st.Conflicts[record.ID] = record
+			continue
+		}
+
+		pending = append(pending, syncutil.Change{ID: record.ID, Entry: remote})
+		clocks[record.ID] = record.Clock
+	}
+
+	modified, renamed, err := apply(v, pending)
+	if err != nil {
+		return err
+	}
+	for _, change := range pending {
+		st.Entries[change.ID] = entryState{Clock: clocks[change.ID], Modified: modified}
+		if renamed[change.ID] {
+			// changed here by the rename, the new title is sent
+			st.Entries[change.ID] = entryState{Clock: clocks[change.ID]}
+		}
+	}
+	report.Received += len(pending)
+	st.Cursor = changes.Revision
+	return nil
+}
+
+// push sends the entries changed in the vault since the last sync, except for conflicting ones
+func (c Client) push(v vault.Vault, cipher crypto.Cipher, st *state, report *Report) error {
+	entries, err := v.Entries()
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		known, found := st.Entries[entry.ID]
+		if found && entry.ModifiedAt <= known.Modified {
+			continue
+		}
+		if _, pending := st.Conflicts[entry.ID]; pending {
+			continue
+		}
+		details, err := v.Reveal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to sync %s: %w", entry.Title, err)
+		}
+		record := Record{ID: entry.ID, Clock: known.Clock.Increment(st.Device), Modified: entry.ModifiedAt}
+		if record.Sealed, err = seal(exporter.ArchiveEntry(details), record, cipher); err != nil {
+			return err
+		}
+
+		var stored Record
+		status, err := c.do(http.MethodPut, "/entries/"+url.PathEscape(entry.ID), record, &stored, http.StatusOK, http.StatusConflict)
+		if err != nil {
+			return err
+		}
+		if status == http.StatusConflict {
+			// another device sent its version since the pull
+			st.Conflicts[entry.ID] = stored
+			report.Conflicts++
+			continue
+		}
+		st.Entries[entry.ID] = entryState{Clock: record.Clock, Modified: entry.ModifiedAt}
+		report.Sent++
+	}
+	return nil
+}
+
+// Conflicts returns the entries the last syncs found edited concurrently on another device,
+// ordered by title
+func (c Client) Conflicts(v vault.Vault) ([]Conflict, error) {
+	st, err := c.loadState()
+	if err != nil {
+		return nil, err
+	}
+	if len(st.Conflicts) == 0 {
+		return nil, nil
+	}
+	cipher, err := v.DeriveCipher(st.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []Conflict
+	for id, record := range st.Conflicts {
+		entry, err := v.Entry(id)
+		if err != nil {
+			return nil, err
+		}
+		details, err := v.Reveal(entry)
+		if err != nil {
+			return nil, err
+		}
+		remote, err := unseal(record, cipher)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, Conflict{
+			ID:             id,
+			Local:          exporter.ArchiveEntry(details),
+			LocalModified:  time.UnixMilli(entry.ModifiedAt),
+			Remote:         remote,
+			RemoteModified: time.UnixMilli(record.Modified),
+		})
+	}
+	slices.SortFunc(conflicts, func(a, b Conflict) int {
+		return strings.Compare(strings.ToLower(a.Local.Title), strings.ToLower(b.Local.Title))
+	})
+	return conflicts, nil
+}
+
+// Resolve settles a conflicting entry by keeping the version of one side. The version of this
+// device is sent to the server on the next sync, superseding the other one; the version of the
+// other device is stored in the vault right away.
+func (c Client) Resolve(v vault.Vault, id string, resolution Resolution) error {
+	running.Lock()
+	defer running.Unlock()
+
+	st, err := c.loadState()
+	if err != nil {
+		return err
+	}
+	record, ok := st.Conflicts[id]
+	if !ok {
+		return ErrNoConflict
+	}
+	known := st.Entries[id]
+	// the kept version is made knowing both, superseding the version of the other device
+	known.Clock = known.Clock.Merge(record.Clock)
+
+	if resolution == KeepRemote {
+		cipher, err := v.DeriveCipher(st.Salt)
+		if err != nil {
+			return err
+		}
+		remote, err := unseal(record, cipher)
+		if err != nil {
+			return err
+		}
+		modified, renamed, err := apply(v, []syncutil.Change{{ID: id, Entry: remote}})
+		if err != nil {
+			return err
+		}
+		known.Modified = modified
+		if renamed[id] {
+			known.Modified = 0
+		}
+	}
+	st.Entries[id] = known
+	delete(st.Conflicts, id)
+	return c.saveState(st)
+}
+
+// manifest reads the manifest of the vault, setting the vault up on the server when it is not yet
+func (c Client) manifest(v vault.Vault) (syncutil.Manifest, error) {
+	var manifest syncutil.Manifest
+	status, err := c.do(http.MethodGet, "/manifest", nil, &manifest, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return syncutil.Manifest{}, err
+	}
+	if status == http.StatusNotFound {
+		if manifest, _, err = syncutil.NewManifest(v, Format, Version); err != nil {
+			return syncutil.Manifest{}, err
+		}
+		// another device may have set the vault up meanwhile, its manifest is returned then
+		if _, err = c.do(http.MethodPut, "/manifest", manifest, &manifest, http.StatusOK, http.StatusCreated); err != nil {
+			return syncutil.Manifest{}, err
+		}
+	}
+	if manifest.Version > Version {
+		return syncutil.Manifest{}, ErrUnsupportedVersion
+	}
+	return manifest, nil
+}
+
+// do sends a request about the vault of the account and decodes the answer into out, expecting
+// one of the given statuses. It returns the status answered.
+func (c Client) do(method, path string, body, out any, expected ...int) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.URL+"/v1/vaults/"+vaultID(c.Account)+path, reader)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sync server URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach sync server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return resp.StatusCode, ErrUnauthorized
+	}
+	if !slices.Contains(expected, resp.StatusCode) {
+		var failure errorBody
+		_ = json.NewDecoder(resp.Body).Decode(&failure)
+		return resp.StatusCode, fmt.Errorf("sync server answered %s: %s", resp.Status, failure.Error)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return resp.StatusCode, nil
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("invalid answer of sync server: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// loadState reads the state of the last sync, starting afresh when there was none with the
+// server and account of the client
+func (c Client) loadState() (state, error) {
+	var st state
+	data, err := os.ReadFile(c.StatePath)
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(data, &st); err != nil {
+			return state{}, fmt.Errorf("failed to read sync state: %w", err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return state{}, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	if st.Device == "" {
+		st.Device = uuid.New().String()
+	}
+	if st.URL != c.URL || st.Account != c.Account {
+		st = state{URL: c.URL, Account: c.Account, Device: st.Device}
+	}
+	if st.Entries == nil {
+		st.Entries = map[string]entryState{}
+	}
+	if st.Conflicts == nil {
+		st.Conflicts = map[string]Record{}
+	}
+	return st, nil
+}
+
+// saveState writes the state of the sync
+func (c Client) saveState(st state) error {
+	if err := os.MkdirAll(filepath.Dir(c.StatePath), 0o700); err != nil {
+		return fmt.Errorf("failed to create sync directory: %w", err)
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	if err = os.WriteFile(c.StatePath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+// apply stores entries changed on other devices in the vault as changed now, returning the time of
+// the change stored. Entries still clashing with another entry of the vault by title and username
+// once the others are stored are renamed, and reported so the new title is sent.
+func apply(v vault.Vault, pending []syncutil.Change) (int64, map[string]bool, error) {
+	modified := time.Now()
+	for i := range pending {
+		pending[i].Modified = modified
+	}
+	clashing, err := syncutil.Apply(v, pending)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	renamed := make(map[string]bool, len(clashing))
+	for _, change := range clashing {
+		change.Entry.Title = syncutil.ConflictTitle(change.Entry.Title, modified)
+		if err = syncutil.Put(v, change); err != nil {
+			return 0, nil, err
+		}
+		renamed[change.ID] = true
+	}
+	return modified.UnixMilli(), renamed, nil
+}
+
+// sameEntry reports whether an entry of the vault holds the same as an entry of another device
+func sameEntry(v vault.Vault, entry model.Password, other archive.Entry) (bool, error) {
+	details, err := v.Reveal(entry)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(exporter.ArchiveEntry(details), other), nil
+}
+
+// seal encrypts an entry for the server as the version record tells
+func seal(entry archive.Entry, record Record, cipher crypto.Cipher) (string, error) {
+	ad, err := associatedData(record)
+	if err != nil {
+		return "", err
+	}
+	return syncutil.Seal(entry, ad, cipher)
+}
+
+// unseal decrypts a version of an entry stored on the server
+func unseal(record Record, cipher crypto.Cipher) (archive.Entry, error) {
+	ad, err := associatedData(record)
+	if err != nil {
+		return archive.Entry{}, err
+	}
+	return syncutil.Unseal(record.Sealed, ad, cipher)
+}
+
+// associatedData binds a sealed entry to the ID, clock and time of change of its version, so the
+// server cannot pass it off as another entry or version without it failing to open
+func associatedData(record Record) ([]byte, error) {
+	// the keys of a clock are encoded sorted, every device encodes it the same
+	clock, err := json.Marshal(record.Clock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode entry clock: %w", err)
+	}
+	return syncutil.AssociatedData(Format, record.ID, string(clock), strconv.FormatInt(record.Modified, 10)), nil
+}
+
+// vaultID names the vault of an account on the server without revealing the account
+func vaultID(account string) string {
+	sum := sha256.Sum256([]byte(Format + ":" + account))
+	return hex.EncodeToString(sum[:])
+}
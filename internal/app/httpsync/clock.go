@@ -0,0 +1,70 @@
+package httpsync
+
+// Clock is the vector clock of an entry, the revision counter every device reached editing it.
+// A device increments its own counter whenever it sends an edit, so the clocks of two versions
+// tell whether one was made knowing the other or both were made concurrently.
+type Clock map[string]uint64
+
+// Order is how the clocks of two versions of an entry relate
+type Order int
+
+// Orders of two clocks
+const (
+	Equal Order = iota
+	// Before means the version was made before the other one, which supersedes it
+	Before
+	// After means the version was made knowing the other one, which it supersedes
+	After
+	// Concurrent means the versions were made without knowing of each other, they conflict
+	Concurrent
+)
+
+// Compare tells how the clock relates to other
+func (c Clock) Compare(other Clock) Order {
+	behind, ahead := false, false
+	for device, counter := range c {
+		if counter > other[device] {
+			ahead = true
+		}
+	}
+	for device, counter := range other {
+		if counter > c[device] {
+			behind = true
+		}
+	}
+	switch {
+	case behind && ahead:
+		return Concurrent
+	case behind:
+		return Before
+	case ahead:
+		return After
+	default:
+		return Equal
+	}
+}
+
+// Descends reports whether the clock is equal to or supersedes other
+func (c Clock) Descends(other Clock) bool {
+	order := c.Compare(other)
+	return order == Equal || order == After
+}
+
+// Merge returns the least clock descending from both clocks
+func (c Clock) Merge(other Clock) Clock {
+	merged := make(Clock, len(c)+len(other))
+	for device, counter := range c {
+		merged[device] = counter
+	}
+	for device, counter := range other {
+		merged[device] = max(merged[device], counter)
+	}
+	return merged
+}
+
+// Increment returns a copy of the clock with the counter of device advanced by one
+func (c Clock) Increment(device string) Clock {
+	next := c.Merge(nil)
+	next[device]++
+	return next
+}
@@ -0,0 +1,55 @@
+//go:build unit
+
+package httpsync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClockCompare(t *testing.T) {
+	tests := []struct {
+		name  string
+		clock Clock
+		other Clock
+		want  Order
+	}{
+		{name: "both empty", clock: nil, other: Clock{}, want: Equal},
+		{name: "same counters", clock: Clock{"a": 2, "b": 1}, other: Clock{"a": 2, "b": 1}, want: Equal},
+		{name: "empty before any", clock: nil, other: Clock{"a": 1}, want: Before},
+		{name: "behind on one device", clock: Clock{"a": 1, "b": 1}, other: Clock{"a": 2, "b": 1}, want: Before},
+		{name: "ahead on new device", clock: Clock{"a": 2, "b": 1}, other: Clock{"a": 2}, want: After},
+		{name: "ahead and behind", clock: Clock{"a": 2, "b": 1}, other: Clock{"a": 1, "b": 2}, want: Concurrent},
+		{name: "disjoint devices", clock: Clock{"a": 1}, other: Clock{"b": 1}, want: Concurrent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.clock.Compare(tt.other))
+		})
+	}
+}
+
+func TestClockDescends(t *testing.T) {
+	assert.True(t, Clock{"a": 1}.Descends(Clock{"a": 1}))
+	assert.True(t, Clock{"a": 2}.Descends(Clock{"a": 1}))
+	assert.True(t, Clock{"a": 1}.Descends(nil))
+	assert.False(t, Clock(nil).Descends(Clock{"a": 1}))
+	assert.False(t, Clock{"a": 1}.Descends(Clock{"b": 1}))
+}
+
+func TestClockMergeAndIncrement(t *testing.T) {
+	// given
+	clock := Clock{"a": 3, "b": 1}
+
+	// when
+	merged := clock.Merge(Clock{"b": 4, "c": 2})
+	incremented := merged.Increment("a")
+
+	// then
+	assert.Equal(t, Clock{"a": 3, "b": 4, "c": 2}, merged)
+	assert.Equal(t, Clock{"a": 4, "b": 4, "c": 2}, incremented)
+	assert.Equal(t, Clock{"a": 3, "b": 1}, clock, "the clock itself is left untouched")
+	assert.Equal(t, Clock{"x": 1}, Clock(nil).Increment("x"))
+	assert.Equal(t, After, incremented.Compare(merged))
+}
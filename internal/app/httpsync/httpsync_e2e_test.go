@@ -0,0 +1,364 @@
+//go:build e2e
+
+package httpsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/syncutil"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "s3cret"
+
+// device is a vault of its own synchronized through a client of its own
+type device struct {
+	vault  vault.Vault
+	client Client
+}
+
+func setupServer(t *testing.T, dir string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(NewServer(dir, testToken))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func setupDevice(t *testing.T, serverURL, password string) device {
+	t.Helper()
+	v := vaulttest.NewUser(t, test.NewDB(t), "alice", password)
+	return device{
+		vault:  v,
+		client: ForUser(t.TempDir(), v.UserID(), "alice", serverURL, testToken),
+	}
+}
+
+func (d device) sync(t *testing.T) Report {
+	t.Helper()
+	report, err := d.client.Sync(d.vault)
+	require.NoError(t, err)
+	return report
+}
+
+func (d device) password(t *testing.T, title string) string {
+	t.Helper()
+	entry, err := d.vault.FindEntry(title, "octocat")
+	require.NoError(t, err)
+	password, err := d.vault.RevealPassword(entry)
+	require.NoError(t, err)
+	return password
+}
+
+func (d device) edit(t *testing.T, title, password string) {
+	t.Helper()
+	// entries edited within the same millisecond as they were synced could not be told apart
+	time.Sleep(2 * time.Millisecond)
+	entry, err := d.vault.FindEntry(title, "octocat")
+	require.NoError(t, err)
+	details, err := d.vault.Reveal(entry)
+	require.NoError(t, err)
+	details.Item.Values["password"] = password
+	require.NoError(t, d.vault.UpdateItem(details.Entry, details.Item, "", nil))
+}
+
+func titles(t *testing.T, v vault.Vault) []string {
+	t.Helper()
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Title)
+	}
+	return names
+}
+
+func addGitHub(t *testing.T, v vault.Vault, password string) {
+	t.Helper()
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: password, Notes: "personal"},
+		[]model.CustomField{{Type: model.FieldTypeHidden, Name: "PIN", Value: "4242"}}, "Work/Dev", []string{"code"}))
+}
+
+// conflicted syncs both devices after a concurrent edit, leaving the desktop with a conflict
+func conflicted(t *testing.T) (device, device) {
+	t.Helper()
+	server := setupServer(t, t.TempDir())
+	laptop := setupDevice(t, server.URL, "master")
+	desktop := setupDevice(t, server.URL, "master")
+	addGitHub(t, laptop.vault, "hunter2")
+	laptop.sync(t)
+	desktop.sync(t)
+	laptop.edit(t, "GitHub", "from laptop")
+	desktop.edit(t, "GitHub", "from desktop")
+	laptop.sync(t)
+	require.Equal(t, Report{Conflicts: 1}, desktop.sync(t))
+	return laptop, desktop
+}
+
+func TestSyncShouldShareEntriesThroughServer(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	server := setupServer(t, dir)
+	laptop := setupDevice(t, server.URL, "master")
+	desktop := setupDevice(t, server.URL, "master")
+	addGitHub(t, laptop.vault, "hunter2")
+
+	// when
+	sent := laptop.sync(t)
+	received := desktop.sync(t)
+
+	// then
+	assert.Equal(t, Report{Sent: 1}, sent)
+	assert.Equal(t, Report{Received: 1}, received)
+	entry, err := desktop.vault.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	details, err := desktop.vault.Reveal(entry)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", details.Item.Values["password"])
+	assert.Equal(t, "personal", details.Item.Notes)
+	assert.Equal(t, []string{"Work/Dev"}, details.Folders)
+	assert.Equal(t, []string{"code"}, details.Tags)
+	require.Len(t, details.Fields, 1)
+	assert.Equal(t, "4242", details.Fields[0].Value)
+
+	// and syncing again exchanges nothing
+	assert.Equal(t, Report{}, laptop.sync(t))
+	assert.Equal(t, Report{}, desktop.sync(t))
+
+	// and the server only stores sealed entries
+	files, err := filepath.Glob(filepath.Join(dir, vaultID("alice"), entriesDir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "GitHub")
+	assert.NotContains(t, string(data), "hunter2")
+}
+
+func TestSyncShouldPullOnlyEntriesChangedSinceLastSync(t *testing.T) {
+	// given
+	server := setupServer(t, t.TempDir())
+	laptop := setupDevice(t, server.URL, "master")
+	desktop := setupDevice(t, server.URL, "master")
+	addGitHub(t, laptop.vault, "hunter2")
+	require.NoError(t, laptop.vault.AddEntry(model.Password{Title: "GitLab", Username: "tanuki", Password: "x"}, nil, "", nil))
+	laptop.sync(t)
+	desktop.sync(t)
+	desktop.edit(t, "GitHub", "correct horse")
+
+	// when
+	sent := desktop.sync(t)
+	received := laptop.sync(t)
+
+	// then
+	assert.Equal(t, Report{Sent: 1}, sent)
+	assert.Equal(t, Report{Received: 1}, received)
+	assert.Equal(t, "correct horse", laptop.password(t, "GitHub"))
+
+	// and the edit of the laptop made knowing it is taken by the desktop
+	laptop.edit(t, "GitHub", "battery staple")
+	assert.Equal(t, Report{Sent: 1}, laptop.sync(t))
+	assert.Equal(t, Report{Received: 1}, desktop.sync(t))
+	assert.Equal(t, "battery staple", desktop.password(t, "GitHub"))
+}
+
+func TestSyncShouldReportConcurrentEditsAsConflict(t *testing.T) {
+	// given
+	_, desktop := conflicted(t)
+
+	// when
+	conflicts, err := desktop.client.Conflicts(desktop.vault)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "from desktop", conflicts[0].Local.Values["password"])
+	assert.Equal(t, "from laptop", conflicts[0].Remote.Values["password"])
+	assert.Equal(t, "from desktop", desktop.password(t, "GitHub"))
+
+	// and the conflict is neither sent nor reported again
+	assert.Equal(t, Report{}, desktop.sync(t))
+}
+
+func TestResolveShouldSendLocalVersionKept(t *testing.T) {
+	// given
+	laptop, desktop := conflicted(t)
+	conflicts, err := desktop.client.Conflicts(desktop.vault)
+	require.NoError(t, err)
+
+	// when
+	err = desktop.client.Resolve(desktop.vault, conflicts[0].ID, KeepLocal)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, Report{Sent: 1}, desktop.sync(t))
+	assert.Equal(t, Report{Received: 1}, laptop.sync(t))
+	assert.Equal(t, "from desktop", laptop.password(t, "GitHub"))
+	conflicts, err = desktop.client.Conflicts(desktop.vault)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+}
+
+func TestResolveShouldStoreRemoteVersionKept(t *testing.T) {
+	// given
+	laptop, desktop := conflicted(t)
+	conflicts, err := desktop.client.Conflicts(desktop.vault)
+	require.NoError(t, err)
+
+	// when
+	err = desktop.client.Resolve(desktop.vault, conflicts[0].ID, KeepRemote)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "from laptop", desktop.password(t, "GitHub"))
+	assert.Equal(t, Report{}, desktop.sync(t))
+	assert.Equal(t, Report{}, laptop.sync(t))
+	assert.ErrorIs(t, desktop.client.Resolve(desktop.vault, conflicts[0].ID, KeepRemote), ErrNoConflict)
+}
+
+func TestSyncShouldRenameEntryClashingWithEntryOfOtherDevice(t *testing.T) {
+	// given
+	server := setupServer(t, t.TempDir())
+	laptop := setupDevice(t, server.URL, "master")
+	desktop := setupDevice(t, server.URL, "master")
+	addGitHub(t, laptop.vault, "from laptop")
+	addGitHub(t, desktop.vault, "from desktop")
+
+	// when
+	laptop.sync(t)
+	desktop.sync(t)
+	laptop.sync(t)
+
+	// then
+	assert.Len(t, titles(t, desktop.vault), 2)
+	assert.ElementsMatch(t, titles(t, desktop.vault), titles(t, laptop.vault))
+	assert.Equal(t, "from desktop", desktop.password(t, "GitHub"))
+	assert.Equal(t, "from desktop", laptop.password(t, "GitHub"))
+}
+
+func TestSyncShouldRejectOtherMasterPassword(t *testing.T) {
+	// given
+	server := setupServer(t, t.TempDir())
+	laptop := setupDevice(t, server.URL, "master")
+	intruder := setupDevice(t, server.URL, "guess")
+	laptop.sync(t)
+
+	// when
+	_, err := intruder.client.Sync(intruder.vault)
+
+	// then
+	assert.ErrorIs(t, err, syncutil.ErrWrongPassword)
+}
+
+func TestSyncShouldRejectWrongToken(t *testing.T) {
+	// given
+	server := setupServer(t, t.TempDir())
+	laptop := setupDevice(t, server.URL, "master")
+	laptop.client.Token = "guess"
+
+	// when
+	_, err := laptop.client.Sync(laptop.vault)
+
+	// then
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestServerShouldKeepEntriesAcrossRestarts(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	first := setupServer(t, dir)
+	laptop := setupDevice(t, first.URL, "master")
+	addGitHub(t, laptop.vault, "hunter2")
+	laptop.sync(t)
+	first.Close()
+	second := setupServer(t, dir)
+	desktop := setupDevice(t, second.URL, "master")
+
+	// when
+	report := desktop.sync(t)
+
+	// then
+	assert.Equal(t, Report{Received: 1}, report)
+	assert.Equal(t, "hunter2", desktop.password(t, "GitHub"))
+}
+
+func TestSyncShouldRejectEntriesSwappedOnServer(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	first := setupServer(t, dir)
+	laptop := setupDevice(t, first.URL, "master")
+	addGitHub(t, laptop.vault, "hunter2")
+	require.NoError(t, laptop.vault.AddEntry(model.Password{Title: "GitLab", Username: "octocat", Password: "tanuki"}, nil, "", nil))
+	laptop.sync(t)
+	first.Close()
+	paths, err := filepath.Glob(filepath.Join(dir, vaultID("alice"), entriesDir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, paths, 2)
+	records := make([]Record, len(paths))
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &records[i]))
+	}
+	records[0].Sealed, records[1].Sealed = records[1].Sealed, records[0].Sealed
+	for i, path := range paths {
+		data, err := json.Marshal(records[i])
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+	}
+	second := setupServer(t, dir)
+	desktop := setupDevice(t, second.URL, "master")
+
+	// when
+	_, err = desktop.client.Sync(desktop.vault)
+
+	// then
+	assert.ErrorIs(t, err, syncutil.ErrCorruptedEntry)
+	assert.Empty(t, titles(t, desktop.vault))
+}
+
+func TestServerShouldRejectVersionNotDescendingStoredOne(t *testing.T) {
+	// given
+	server := setupServer(t, t.TempDir())
+	put := func(path string, body any) (int, Record) {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/v1/vaults/vault-1"+path, bytes.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var record Record
+		_ = json.NewDecoder(resp.Body).Decode(&record)
+		return resp.StatusCode, record
+	}
+	status, _ := put("/manifest", syncutil.Manifest{Format: Format, Version: Version, Salt: "salt", Check: "check"})
+	require.Equal(t, http.StatusCreated, status)
+	status, stored := put("/entries/entry-1", Record{Clock: Clock{"a": 1, "b": 1}, Sealed: "first"})
+	require.Equal(t, http.StatusOK, status)
+
+	// when
+	stale, rejected := put("/entries/entry-1", Record{Clock: Clock{"a": 2}, Sealed: "second"})
+	newer, accepted := put("/entries/entry-1", Record{Clock: Clock{"a": 2, "b": 1}, Sealed: "third"})
+	invalid, _ := put("/entries/..", Record{Clock: Clock{"a": 3, "b": 1}, Sealed: "fourth"})
+
+	// then
+	assert.Equal(t, http.StatusConflict, stale)
+	assert.Equal(t, stored, rejected)
+	assert.Equal(t, http.StatusOK, newer)
+	assert.Equal(t, "third", accepted.Sealed)
+	assert.Equal(t, stored.Revision+1, accepted.Revision)
+	assert.NotEqual(t, http.StatusOK, invalid)
+}
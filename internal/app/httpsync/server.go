@@ -0,0 +1,283 @@
+package httpsync
+
+import (
+	"cmp"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"yubigo-pass/internal/app/syncutil"
+)
+
+// maxRecordSize bounds the body of a request storing an entry or a manifest
+const maxRecordSize = 1 << 20
+
+// validID matches the vault and entry IDs the server accepts, which name its files
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// Server is the HTTP handler of a sync server. It keeps the manifest and the sealed entries of
+// every vault in files under Dir, never seeing a key. Every stored entry is given the next
+// revision of its vault, clients pull the entries stored after the last revision they saw.
+type Server struct {
+	dir   string
+	token string
+	mux   *http.ServeMux
+
+	mu     sync.Mutex
+	vaults map[string]*storedVault
+}
+
+// storedVault is the content of a vault loaded from the files of the server
+type storedVault struct {
+	manifest *syncutil.Manifest
+	records  map[string]Record
+	revision int64
+}
+
+// NewServer returns new Server instance storing vaults in dir. When token is not empty, requests
+// must carry it as a bearer token.
+func NewServer(dir, token string) *Server {
+	s := &Server{
+		dir:    dir,
+		token:  token,
+		mux:    http.NewServeMux(),
+		vaults: map[string]*storedVault{},
+	}
+	s.mux.HandleFunc("GET /v1/vaults/{vault}/manifest", s.getManifest)
+	s.mux.HandleFunc("PUT /v1/vaults/{vault}/manifest", s.putManifest)
+	s.mux.HandleFunc("GET /v1/vaults/{vault}/entries", s.getEntries)
+	s.mux.HandleFunc("PUT /v1/vaults/{vault}/entries/{id}", s.putEntry)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" {
+		given, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or wrong token")
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) getManifest(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.load(w, r)
+	if !ok {
+		return
+	}
+	if v.manifest == nil {
+		writeError(w, http.StatusNotFound, "vault not set up")
+		return
+	}
+	writeJSON(w, http.StatusOK, v.manifest)
+}
+
+// putManifest sets a vault up, answering with the manifest stored before when another device
+// set it up first
+func (s *Server) putManifest(w http.ResponseWriter, r *http.Request) {
+	var manifest syncutil.Manifest
+	if !readJSON(w, r, &manifest) {
+		return
+	}
+	if !manifest.Valid(Format) {
+		writeError(w, http.StatusBadRequest, "invalid manifest")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.load(w, r)
+	if !ok {
+		return
+	}
+	if v.manifest != nil {
+		writeJSON(w, http.StatusOK, v.manifest)
+		return
+	}
+	if err := s.write(r.PathValue("vault"), manifestFile, manifest); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	v.manifest = &manifest
+	writeJSON(w, http.StatusCreated, manifest)
+}
+
+func (s *Server) getEntries(w http.ResponseWriter, r *http.Request) {
+	since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+	if err != nil && r.URL.Query().Has("since") {
+		writeError(w, http.StatusBadRequest, "invalid since")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.load(w, r)
+	if !ok {
+		return
+	}
+	if v.manifest == nil {
+		writeError(w, http.StatusNotFound, "vault not set up")
+		return
+	}
+	changes := Changes{Revision: v.revision, Entries: []Record{}}
+	for _, record := range v.records {
+		if record.Revision > since {
+			changes.Entries = append(changes.Entries, record)
+		}
+	}
+	slices.SortFunc(changes.Entries, func(a, b Record) int { return cmp.Compare(a.Revision, b.Revision) })
+	writeJSON(w, http.StatusOK, changes)
+}
+
+// putEntry stores a version of an entry made knowing the stored one, answering with the stored
+// version and 409 Conflict otherwise
+func (s *Server) putEntry(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !validID.MatchString(id) {
+		writeError(w, http.StatusBadRequest, "invalid entry ID")
+		return
+	}
+	var record Record
+	if !readJSON(w, r, &record) {
+		return
+	}
+	if record.Sealed == "" || len(record.Clock) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid entry")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.load(w, r)
+	if !ok {
+		return
+	}
+	if v.manifest == nil {
+		writeError(w, http.StatusNotFound, "vault not set up")
+		return
+	}
+	stored, found := v.records[id]
+	if found && !record.Clock.Descends(stored.Clock) {
+		writeJSON(w, http.StatusConflict, stored)
+		return
+	}
+	if found && record.Clock.Compare(stored.Clock) == Equal {
+		writeJSON(w, http.StatusOK, stored)
+		return
+	}
+
+	record.ID = id
+	record.Revision = v.revision + 1
+	if err := s.write(r.PathValue("vault"), filepath.Join(entriesDir, id+".json"), record); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	v.records[id] = record
+	v.revision = record.Revision
+	writeJSON(w, http.StatusOK, record)
+}
+
+// load returns the vault a request is for, reading it from its files on first use. It answers
+// the request itself when the vault cannot be loaded.
+func (s *Server) load(w http.ResponseWriter, r *http.Request) (*storedVault, bool) {
+	id := r.PathValue("vault")
+	if !validID.MatchString(id) {
+		writeError(w, http.StatusBadRequest, "invalid vault ID")
+		return nil, false
+	}
+	if v, ok := s.vaults[id]; ok {
+		return v, true
+	}
+
+	v := &storedVault{records: map[string]Record{}}
+	dir := filepath.Join(s.dir, id)
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile)) // #nosec G304 -- vault IDs are validated
+	switch {
+	case err == nil:
+		var manifest syncutil.Manifest
+		if err = json.Unmarshal(data, &manifest); err != nil {
+			writeError(w, http.StatusInternalServerError, "corrupted manifest")
+			return nil, false
+		}
+		v.manifest = &manifest
+	case !errors.Is(err, os.ErrNotExist):
+		writeError(w, http.StatusInternalServerError, "failed to read manifest")
+		return nil, false
+	}
+
+	files, err := os.ReadDir(filepath.Join(dir, entriesDir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		writeError(w, http.StatusInternalServerError, "failed to read entries")
+		return nil, false
+	}
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entriesDir, file.Name())) // #nosec G304 -- files of the entries directory
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to read entries")
+			return nil, false
+		}
+		var record Record
+		if err = json.Unmarshal(data, &record); err != nil {
+			writeError(w, http.StatusInternalServerError, "corrupted entry "+file.Name())
+			return nil, false
+		}
+		v.records[record.ID] = record
+		v.revision = max(v.revision, record.Revision)
+	}
+	s.vaults[id] = v
+	return v, true
+}
+
+// write stores a document in a file of a vault, replacing the previous one atomically
+func (s *Server) write(vaultID, name string, value any) error {
+	path := filepath.Join(s.dir, vaultID, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create vault directory: %w", err)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	partial := path + ".partial"
+	if err = os.WriteFile(partial, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err = os.Rename(partial, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// readJSON decodes the body of a request, answering it with 400 Bad Request when it cannot
+func readJSON(w http.ResponseWriter, r *http.Request, value any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRecordSize)).Decode(value); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorBody{Error: message})
+}
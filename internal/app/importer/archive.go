@@ -0,0 +1,46 @@
+package importer
+
+import (
+	"io"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/model"
+)
+
+// ReadArchive opens a yubigo-pass archive with the passphrase it was sealed under and
+// reads its entries, along with their custom fields, placement and attachments
+func ReadArchive(r io.Reader, passphrase string) (Batch, error) {
+	_, doc, err := archive.Read(r, passphrase)
+	if err != nil {
+		return Batch{}, err
+	}
+
+	batch := Batch{Records: make([]Record, 0, len(doc.Entries))}
+	for _, entry := range doc.Entries {
+		batch.Records = append(batch.Records, ArchiveRecord(entry))
+	}
+	return batch, nil
+}
+
+// ArchiveRecord reads an archive entry as an import record
+func ArchiveRecord(entry archive.Entry) Record {
+	record := Record{
+		Item: model.Item{
+			Type:   model.ItemType(entry.Type),
+			Title:  entry.Title,
+			Notes:  entry.Notes,
+			Values: model.ItemValues(entry.Values),
+		},
+		Folder: entry.Folder,
+		Tags:   entry.Tags,
+	}
+	if record.Item.Values == nil {
+		record.Item.Values = model.ItemValues{}
+	}
+	for _, field := range entry.Fields {
+		record.Fields = append(record.Fields, model.CustomField{Type: model.FieldType(field.Type), Name: field.Name, Value: field.Value})
+	}
+	for _, attachment := range entry.Attachments {
+		record.Attachments = append(record.Attachments, Attachment{Name: attachment.Name, Data: attachment.Data})
+	}
+	return record
+}
@@ -0,0 +1,199 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"yubigo-pass/internal/app/bitwarden"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/otp"
+	"yubigo-pass/internal/app/vault"
+)
+
+// ReadBitwarden reads an unencrypted or a password protected Bitwarden JSON export. Logins,
+// secure notes, cards, identities and SSH keys map onto the matching item types, with folders,
+// URIs, notes, custom fields and TOTP secrets carried over. Items of other types are skipped.
+func ReadBitwarden(r io.Reader, password string) (Batch, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Batch{}, fmt.Errorf("failed to read Bitwarden export: %w", err)
+	}
+	exported, err := bitwarden.Parse(data, password)
+	if err != nil {
+		return Batch{}, err
+	}
+
+	folders := make(map[string]string, len(exported.Folders))
+	for _, folder := range exported.Folders {
+		folders[folder.ID] = folder.Name
+	}
+
+	var batch Batch
+	for _, item := range exported.Items {
+		record, err := bitwardenRecord(item)
+		if err != nil {
+			batch.Skipped = append(batch.Skipped, vault.SkippedRecord{Name: item.Name, Reason: err.Error()})
+			continue
+		}
+		if item.FolderID != nil {
+			record.Folder = folders[*item.FolderID]
+		}
+		batch.Records = append(batch.Records, record)
+	}
+	return batch, nil
+}
+
+// bitwardenRecord maps a Bitwarden item onto a record of the closest item type
+func bitwardenRecord(item bitwarden.Item) (Record, error) {
+	record := Record{
+		Item:   model.Item{Title: item.Name, Values: model.ItemValues{}},
+		Fields: bitwardenFields(item.Fields),
+	}
+	if item.Notes != nil {
+		record.Item.Notes = *item.Notes
+	}
+
+	switch {
+	case item.Type == bitwarden.ItemTypeLogin && item.Login != nil:
+		record.Item.Type = model.ItemTypeLogin
+		login := item.Login
+		record.Item.Values["username"] = login.Username
+		record.Item.Values["password"] = login.Password
+		for i, uri := range login.URIs {
+			if i == 0 {
+				record.Item.Values["url"] = uri.URI
+				continue
+			}
+			record.Fields = append(record.Fields, uriField(fmt.Sprintf("URL %d", i+1), uri.URI))
+		}
+		if login.Totp != "" {
+			uri, ok := totpURI(login.Totp, item.Name, login.Username)
+			if ok {
+				record.Item.Values["otp"] = uri
+			} else {
+				record.Fields = append(record.Fields, model.CustomField{Type: model.FieldTypeHidden, Name: "TOTP", Value: login.Totp})
+			}
+		}
+
+	case item.Type == bitwarden.ItemTypeSecureNote:
+		record.Item.Type = model.ItemTypeSecureNote
+
+	case item.Type == bitwarden.ItemTypeCard && item.Card != nil:
+		record.Item.Type = model.ItemTypeCard
+		card := item.Card
+		record.Item.Values["cardholder"] = card.CardholderName
+		record.Item.Values["number"] = card.Number
+		record.Item.Values["cvv"] = card.Code
+		if card.ExpMonth != "" && card.ExpYear != "" {
+			year := card.ExpYear
+			if len(year) > 2 {
+				year = year[len(year)-2:]
+			}
+			record.Item.Values["expiry"] = fmt.Sprintf("%02s/%s", card.ExpMonth, year)
+		}
+		record.Fields = appendTextField(record.Fields, "Brand", card.Brand, model.FieldTypeText)
+
+	case item.Type == bitwarden.ItemTypeIdentity && item.Identity != nil:
+		record.Item.Type = model.ItemTypeIdentity
+		identity := item.Identity
+		record.Item.Values["full_name"] = joinNonEmpty(" ", identity.Title, identity.FirstName, identity.MiddleName, identity.LastName)
+		record.Item.Values["document"] = identity.PassportNumber
+		record.Item.Values["email"] = identity.Email
+		record.Item.Values["phone"] = identity.Phone
+		record.Item.Values["address"] = joinNonEmpty("\n",
+			identity.Address1, identity.Address2, identity.Address3,
+			joinNonEmpty(" ", identity.PostalCode, identity.City, identity.State), identity.Country)
+		record.Fields = appendTextField(record.Fields, "Company", identity.Company, model.FieldTypeText)
+		record.Fields = appendTextField(record.Fields, "Username", identity.Username, model.FieldTypeText)
+		record.Fields = appendTextField(record.Fields, "License number", identity.LicenseNumber, model.FieldTypeHidden)
+		record.Fields = appendTextField(record.Fields, "SSN", identity.SSN, model.FieldTypeHidden)
+
+	case item.Type == bitwarden.ItemTypeSSHKey && item.SSHKey != nil:
+		record.Item.Type = model.ItemTypeSSHKey
+		record.Item.Values["private_key"] = item.SSHKey.PrivateKey
+		record.Item.Values["public_key"] = item.SSHKey.PublicKey
+		record.Fields = appendTextField(record.Fields, "Fingerprint", item.SSHKey.KeyFingerprint, model.FieldTypeText)
+
+	default:
+		return Record{}, fmt.Errorf("unsupported Bitwarden item type %d", item.Type)
+	}
+	return record, nil
+}
+
+// bitwardenFields maps Bitwarden custom fields onto custom fields. Linked fields only point
+// at other values of their item, so there is nothing to carry over.
+func bitwardenFields(fields []bitwarden.Field) []model.CustomField {
+	mapped := make([]model.CustomField, 0, len(fields))
+	for i, field := range fields {
+		name := field.Name
+		if strings.TrimSpace(name) == "" {
+			name = fmt.Sprintf("Field %d", i+1)
+		}
+		switch field.Type {
+		case bitwarden.FieldTypeHidden:
+			mapped = append(mapped, model.CustomField{Type: model.FieldTypeHidden, Name: name, Value: field.Value})
+		case bitwarden.FieldTypeBoolean:
+			fieldType := model.FieldTypeBoolean
+			if field.Value != "true" && field.Value != "false" {
+				fieldType = model.FieldTypeText
+			}
+			mapped = append(mapped, model.CustomField{Type: fieldType, Name: name, Value: field.Value})
+		case bitwarden.FieldTypeLinked:
+			continue
+		default:
+			mapped = append(mapped, model.CustomField{Type: model.FieldTypeText, Name: name, Value: field.Value})
+		}
+	}
+	return mapped
+}
+
+// totpURI turns the TOTP value of a Bitwarden login into an otpauth:// URI. Bare base32 secrets
+// become TOTP keys with default parameters, labelled by the item name and username.
+func totpURI(totp, issuer, account string) (string, bool) {
+	if _, err := otp.Parse(totp); err == nil {
+		return strings.TrimSpace(totp), true
+	}
+	secret, err := otp.DecodeSecret(totp)
+	if err != nil {
+		return "", false
+	}
+	key := otp.Key{
+		Type:      otp.TypeTOTP,
+		Issuer:    issuer,
+		Account:   account,
+		Secret:    secret,
+		Algorithm: otp.DefaultAlgorithm,
+		Digits:    otp.DefaultDigits,
+		Period:    otp.DefaultPeriod,
+	}
+	return key.URI(), true
+}
+
+// uriField returns a URL custom field, or a text one for URIs without a host such as app links
+func uriField(name, uri string) model.CustomField {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return model.CustomField{Type: model.FieldTypeText, Name: name, Value: uri}
+	}
+	return model.CustomField{Type: model.FieldTypeURL, Name: name, Value: uri}
+}
+
+// appendTextField appends a custom field holding value unless value is empty
+func appendTextField(fields []model.CustomField, name, value string, fieldType model.FieldType) []model.CustomField {
+	if strings.TrimSpace(value) == "" {
+		return fields
+	}
+	return append(fields, model.CustomField{Type: fieldType, Name: name, Value: value})
+}
+
+// joinNonEmpty joins the non-empty values with sep
+func joinNonEmpty(sep string, values ...string) string {
+	var parts []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,105 @@
+//go:build unit
+
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"yubigo-pass/internal/app/bitwarden"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBitwardenShouldMapItems(t *testing.T) {
+	// when
+	batch, err := ReadBitwarden(bytes.NewReader(test.BitwardenExport(t)), "")
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, batch.Records, 4)
+	assert.Equal(t, []string{"Passkey"}, skippedNames(batch))
+	assert.Equal(t, "unsupported Bitwarden item type 9", batch.Skipped[0].Reason)
+
+	login := batch.Records[0]
+	assert.Equal(t, "Work/Dev", login.Folder)
+	assert.Equal(t, model.Item{
+		Type:  model.ItemTypeLogin,
+		Title: "GitHub",
+		Notes: "personal account",
+		Values: model.ItemValues{
+			"username": "octocat",
+			"password": "hunter2",
+			"url":      "https://github.com",
+			"otp":      "otpauth://totp/GitHub:octocat?issuer=GitHub&secret=JBSWY3DPEHPK3PXP",
+		},
+	}, login.Item)
+	assert.Equal(t, []model.CustomField{
+		{Type: model.FieldTypeHidden, Name: "PIN", Value: "4242"},
+		{Type: model.FieldTypeText, Name: "Field 2", Value: "blue"},
+		{Type: model.FieldTypeBoolean, Name: "Admin", Value: "true"},
+		{Type: model.FieldTypeURL, Name: "URL 2", Value: "https://gist.github.com"},
+	}, login.Fields)
+	assert.NoError(t, login.Validate())
+
+	note := batch.Records[1]
+	assert.Equal(t, "Wi-Fi", note.Item.Title)
+	assert.Equal(t, "password on the router", note.Item.Notes)
+	assert.NoError(t, note.Validate())
+
+	card := batch.Records[2]
+	assert.Equal(t, model.ItemValues{"cardholder": "Jane Doe", "number": "4111111111111111", "expiry": "03/30", "cvv": "123"}, card.Item.Values)
+	assert.Equal(t, []model.CustomField{{Type: model.FieldTypeText, Name: "Brand", Value: "Visa"}}, card.Fields)
+	assert.NoError(t, card.Validate())
+
+	identity := batch.Records[3]
+	assert.Equal(t, "Jane Doe", identity.Item.Values["full_name"])
+	assert.Equal(t, "1 Main St\n12345 Springfield\nUS", identity.Item.Values["address"])
+	assert.Equal(t, []model.CustomField{{Type: model.FieldTypeHidden, Name: "SSN", Value: "078-05-1120"}}, identity.Fields)
+	assert.NoError(t, identity.Validate())
+}
+
+func TestReadBitwardenShouldKeepUnreadableTOTPAsField(t *testing.T) {
+	// given
+	data, err := bitwarden.Marshal(bitwarden.Vault{Items: []bitwarden.Item{{
+		Type:  bitwarden.ItemTypeLogin,
+		Name:  "Steam",
+		Login: &bitwarden.Login{Username: "gabe", Password: "x", Totp: "steam://not-base32!"},
+	}}})
+	require.NoError(t, err)
+
+	// when
+	batch, err := ReadBitwarden(strings.NewReader(string(data)), "")
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, batch.Records, 1)
+	assert.Empty(t, batch.Records[0].Item.Values["otp"])
+	assert.Equal(t, []model.CustomField{{Type: model.FieldTypeHidden, Name: "TOTP", Value: "steam://not-base32!"}}, batch.Records[0].Fields)
+}
+
+func TestReadBitwardenShouldReadPasswordProtectedExport(t *testing.T) {
+	// given
+	exported, err := bitwarden.Parse(test.BitwardenExport(t), "")
+	require.NoError(t, err)
+	data, err := bitwarden.Protect(exported, "correct horse", bitwarden.KDF{Type: bitwarden.KDFTypePBKDF2, Iterations: 1000})
+	require.NoError(t, err)
+
+	// when
+	batch, err := ReadBitwarden(strings.NewReader(string(data)), "correct horse")
+
+	// then
+	require.NoError(t, err)
+	assert.Len(t, batch.Records, 4)
+}
+
+func skippedNames(batch Batch) []string {
+	names := make([]string, 0, len(batch.Skipped))
+	for _, skipped := range batch.Skipped {
+		names = append(names, skipped.Name)
+	}
+	return names
+}
@@ -0,0 +1,229 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+)
+
+// CSVColumn is a value of a record that a column of a CSV export can be mapped onto
+type CSVColumn string
+
+// Values of a record read from CSV columns
+const (
+	CSVTitle    CSVColumn = "title"
+	CSVUsername CSVColumn = "username"
+	CSVPassword CSVColumn = "password"
+	CSVURL      CSVColumn = "url"
+	CSVNotes    CSVColumn = "notes"
+	CSVOTP      CSVColumn = "otp"
+	CSVFolder   CSVColumn = "folder"
+)
+
+// CSVColumns lists the values of a record in the order they are offered for mapping
+var CSVColumns = []CSVColumn{CSVTitle, CSVUsername, CSVPassword, CSVURL, CSVNotes, CSVOTP, CSVFolder}
+
+// lastPassSecureNoteURL marks the rows of a LastPass export holding a secure note in its extra column
+const lastPassSecureNoteURL = "http://sn"
+
+// CSVMapping maps values of a record onto the index of the column they are read from
+type CSVMapping map[CSVColumn]int
+
+// CSVPreset names the headers a browser or password manager writes for each value.
+// Optional headers may be missing, e.g. from exports of older versions.
+type CSVPreset struct {
+	Name     string
+	Headers  map[CSVColumn]string
+	Optional []CSVColumn
+}
+
+// CSVPresets are the CSV layouts known without mapping columns by hand
+var CSVPresets = []CSVPreset{
+	{
+		Name: "Chrome",
+		Headers: map[CSVColumn]string{
+			CSVTitle: "name", CSVURL: "url", CSVUsername: "username", CSVPassword: "password", CSVNotes: "note",
+		},
+		Optional: []CSVColumn{CSVNotes},
+	},
+	{
+		Name: "Firefox",
+		Headers: map[CSVColumn]string{
+			CSVURL: "url", CSVUsername: "username", CSVPassword: "password",
+		},
+	},
+	{
+		Name: "Safari",
+		Headers: map[CSVColumn]string{
+			CSVTitle: "Title", CSVURL: "URL", CSVUsername: "Username", CSVPassword: "Password", CSVNotes: "Notes", CSVOTP: "OTPAuth",
+		},
+		Optional: []CSVColumn{CSVNotes, CSVOTP},
+	},
+	{
+		Name: "LastPass",
+		Headers: map[CSVColumn]string{
+			CSVURL: "url", CSVUsername: "username", CSVPassword: "password", CSVOTP: "totp",
+			CSVNotes: "extra", CSVTitle: "name", CSVFolder: "grouping",
+		},
+		Optional: []CSVColumn{CSVOTP},
+	},
+}
+
+// Mapping maps the values of the preset onto the columns of header, telling whether every
+// required header of the preset is there. Headers are compared case insensitively.
+func (p CSVPreset) Mapping(header []string) (CSVMapping, bool) {
+	mapping := CSVMapping{}
+	for column, name := range p.Headers {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				mapping[column] = i
+				break
+			}
+		}
+		if _, found := mapping[column]; !found && !p.isOptional(column) {
+			return nil, false
+		}
+	}
+	return mapping, true
+}
+
+// isOptional tells whether the preset tolerates a missing header for column
+func (p CSVPreset) isOptional(column CSVColumn) bool {
+	for _, optional := range p.Optional {
+		if optional == column {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectCSVPreset returns the preset matching header. When several match, the one mapping
+// the most columns wins, so a LastPass export is not taken for a Chrome one.
+func DetectCSVPreset(header []string) (CSVPreset, CSVMapping, bool) {
+	var best CSVPreset
+	var bestMapping CSVMapping
+	for _, preset := range CSVPresets {
+		mapping, ok := preset.Mapping(header)
+		if ok && len(mapping) > len(bestMapping) {
+			best, bestMapping = preset, mapping
+		}
+	}
+	return best, bestMapping, bestMapping != nil
+}
+
+// ReadCSVHeader returns the header row of a CSV export
+func ReadCSVHeader(r io.Reader) ([]string, error) {
+	header, err := newCSVReader(r).Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+	return header, nil
+}
+
+// ReadCSV reads the rows of a CSV export below its header into logins, taking each value
+// from the column it is mapped onto. Rows without a title are named by the host of their URL,
+// bare base32 one-time password secrets become TOTP keys and LastPass secure notes become
+// secure notes. Unmapped columns are left out.
+func ReadCSV(r io.Reader, mapping CSVMapping) (Batch, error) {
+	reader := newCSVReader(r)
+	if _, err := reader.Read(); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Batch{}, errors.New("CSV file is empty")
+		}
+		return Batch{}, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+
+	var batch Batch
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Batch{}, fmt.Errorf("failed to read CSV file: %w", err)
+		}
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		value := func(column CSVColumn) string {
+			i, ok := mapping[column]
+			if !ok || i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		record, err := csvRecord(value)
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			batch.Skipped = append(batch.Skipped, vault.SkippedRecord{Name: fmt.Sprintf("line %d", line), Reason: err.Error()})
+			continue
+		}
+		batch.Records = append(batch.Records, record)
+	}
+	return batch, nil
+}
+
+// csvRecord maps the values of a row onto a record
+func csvRecord(value func(CSVColumn) string) (Record, error) {
+	title := value(CSVTitle)
+	if title == "" {
+		title = urlHost(value(CSVURL))
+	}
+	if title == "" {
+		return Record{}, errors.New("has neither title nor URL")
+	}
+	record := Record{
+		Item: model.Item{Type: model.ItemTypeLogin, Title: title, Notes: value(CSVNotes), Values: model.ItemValues{}},
+		// LastPass separates nested folders by backslashes
+		Folder: strings.ReplaceAll(value(CSVFolder), `\`, vault.FolderSeparator),
+	}
+	if value(CSVURL) == lastPassSecureNoteURL {
+		record.Item.Type = model.ItemTypeSecureNote
+		return record, nil
+	}
+
+	values := record.Item.Values
+	values["username"] = value(CSVUsername)
+	values["password"] = value(CSVPassword)
+	values["url"] = value(CSVURL)
+	if totp := value(CSVOTP); totp != "" {
+		uri, ok := totpURI(totp, title, values["username"])
+		if !ok {
+			return Record{}, errors.New("has an unreadable one-time password")
+		}
+		values["otp"] = uri
+	}
+	return record, nil
+}
+
+// urlHost returns the host of a URL without its www. prefix, or an empty string
+func urlHost(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// newCSVReader returns a CSV reader tolerating rows of varying length and the UTF-8 byte
+// order mark some spreadsheet tools write at the start of a file
+func newCSVReader(r io.Reader) *csv.Reader {
+	buffered := bufio.NewReader(r)
+	if bom, err := buffered.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		_, _ = buffered.Discard(3)
+	}
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return reader
+}
@@ -0,0 +1,171 @@
+//go:build unit
+
+package importer
+
+import (
+	"strings"
+	"testing"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Exports as written by each source, down to the header casing
+const (
+	testChromeCSV = "name,url,username,password,note\n" +
+		"github.com,https://github.com/login,octocat,hunter2,personal\n"
+	testFirefoxCSV = `"url","username","password","httpRealm","formActionOrigin","guid","timeCreated","timeLastUsed","timePasswordChanged"` + "\n" +
+		`"https://www.github.com","octocat","hunter2",,"https://github.com","{5f0b}","1700000000000","1700000000000","1700000000000"` + "\n"
+	testSafariCSV = "Title,URL,Username,Password,Notes,OTPAuth\n" +
+		"GitHub,https://github.com/,octocat,hunter2,personal,otpauth://totp/GitHub:octocat?secret=JBSWY3DPEHPK3PXP\n"
+	testLastPassCSV = "url,username,password,totp,extra,name,grouping,fav\n" +
+		"https://github.com,octocat,hunter2,JBSWY3DPEHPK3PXP,personal,GitHub,Work\\Dev,0\n" +
+		"http://sn,,,,\"router is in the hall\",Wi-Fi,,0\n"
+)
+
+func TestDetectCSVPresetShouldRecognizeEveryExport(t *testing.T) {
+	tests := []struct {
+		export string
+		preset string
+	}{
+		{testChromeCSV, "Chrome"},
+		{"name,url,username,password\n", "Chrome"},
+		{testFirefoxCSV, "Firefox"},
+		{testSafariCSV, "Safari"},
+		{testLastPassCSV, "LastPass"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			// given
+			header, err := ReadCSVHeader(strings.NewReader(tt.export))
+			require.NoError(t, err)
+
+			// when
+			preset, _, ok := DetectCSVPreset(header)
+
+			// then
+			assert.True(t, ok)
+			assert.Equal(t, tt.preset, preset.Name)
+		})
+	}
+}
+
+func TestDetectCSVPresetShouldRejectUnknownLayout(t *testing.T) {
+	// when
+	_, _, ok := DetectCSVPreset([]string{"Site", "Login", "Secret"})
+
+	// then
+	assert.False(t, ok)
+}
+
+func TestReadCSVShouldReadEveryPreset(t *testing.T) {
+	login := func(title, notes, otp string) model.Item {
+		values := model.ItemValues{"username": "octocat", "password": "hunter2"}
+		if otp != "" {
+			values["otp"] = otp
+		}
+		return model.Item{Type: model.ItemTypeLogin, Title: title, Notes: notes, Values: values}
+	}
+	tests := []struct {
+		name   string
+		export string
+		want   model.Item
+	}{
+		{"Chrome", testChromeCSV, login("github.com", "personal", "")},
+		{"Firefox", testFirefoxCSV, login("github.com", "", "")},
+		{"Safari", testSafariCSV, login("GitHub", "personal", "otpauth://totp/GitHub:octocat?secret=JBSWY3DPEHPK3PXP")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			header, err := ReadCSVHeader(strings.NewReader(tt.export))
+			require.NoError(t, err)
+			_, mapping, ok := DetectCSVPreset(header)
+			require.True(t, ok)
+
+			// when
+			batch, err := ReadCSV(strings.NewReader(tt.export), mapping)
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, batch.Skipped)
+			require.Len(t, batch.Records, 1)
+			item := batch.Records[0].Item
+			assert.Equal(t, tt.want.Type, item.Type)
+			assert.Equal(t, tt.want.Title, item.Title)
+			assert.Equal(t, tt.want.Notes, item.Notes)
+			for key, value := range tt.want.Values {
+				assert.Equal(t, value, item.Values[key], key)
+			}
+			assert.NotEmpty(t, item.Values["url"])
+		})
+	}
+}
+
+func TestReadCSVShouldReadLastPassFoldersSecretsAndSecureNotes(t *testing.T) {
+	// given
+	header, err := ReadCSVHeader(strings.NewReader(testLastPassCSV))
+	require.NoError(t, err)
+	_, mapping, ok := DetectCSVPreset(header)
+	require.True(t, ok)
+
+	// when
+	batch, err := ReadCSV(strings.NewReader(testLastPassCSV), mapping)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, batch.Records, 2)
+	github := batch.Records[0]
+	assert.Equal(t, "Work/Dev", github.Folder)
+	assert.Equal(t, "otpauth://totp/GitHub:octocat?issuer=GitHub&secret=JBSWY3DPEHPK3PXP", github.Item.Values["otp"])
+	note := batch.Records[1]
+	assert.Equal(t, model.ItemTypeSecureNote, note.Item.Type)
+	assert.Equal(t, "Wi-Fi", note.Item.Title)
+	assert.Equal(t, "router is in the hall", note.Item.Notes)
+}
+
+func TestReadCSVShouldUseCustomMappingAndSkipUnreadableRows(t *testing.T) {
+	// given
+	export := "\xef\xbb\xbfSite,Login,Secret,Code\n" +
+		"GitHub,octocat,hunter2,\n" +
+		",,,\n" +
+		",nobody,x,\n" +
+		"Bank,me,x,not a secret!\n"
+	mapping := CSVMapping{CSVTitle: 0, CSVUsername: 1, CSVPassword: 2, CSVOTP: 3}
+
+	// when
+	batch, err := ReadCSV(strings.NewReader(export), mapping)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, batch.Records, 1)
+	assert.Equal(t, "GitHub", batch.Records[0].Item.Title)
+	assert.Equal(t, "hunter2", batch.Records[0].Item.Values["password"])
+	assert.Equal(t, []vault.SkippedRecord{
+		{Name: "line 4", Reason: "has neither title nor URL"},
+		{Name: "line 5", Reason: "has an unreadable one-time password"},
+	}, batch.Skipped)
+}
+
+func TestReadCSVShouldRejectEmptyFile(t *testing.T) {
+	// when
+	_, err := ReadCSV(strings.NewReader(""), CSVMapping{})
+
+	// then
+	assert.EqualError(t, err, "CSV file is empty")
+}
+
+func TestParseDuplicatePolicyShouldRoundTripNames(t *testing.T) {
+	for _, policy := range DuplicatePolicies {
+		// when
+		parsed, err := ParseDuplicatePolicy(policy.String())
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, policy, parsed)
+	}
+	_, err := ParseDuplicatePolicy("merge")
+	assert.Error(t, err)
+}
@@ -0,0 +1,223 @@
+// Package importer reads the exports of other password managers into records and stores
+// them as entries of a vault, reporting what was added, skipped or already there.
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+)
+
+// Record is an entry read from an export, in plaintext, with the folder path and tags to file it under
+type Record struct {
+	Item        model.Item
+	Fields      []model.CustomField
+	Folder      string
+	Tags        []string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to a record
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// Name returns how reports refer to the record, its title followed by its username
+func (r Record) Name() string {
+	entry, _ := r.Item.Entry()
+	return fmt.Sprintf("%s (%s)", entry.Title, entry.Username)
+}
+
+// Validate checks the item and the custom fields of the record
+func (r Record) Validate() error {
+	if err := r.Item.Validate(); err != nil {
+		return err
+	}
+	for _, field := range r.Fields {
+		if err := field.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Batch is the content of an export, the records to import along with the ones
+// that could not be read into entries
+type Batch struct {
+	Records []Record
+	Skipped []vault.SkippedRecord
+}
+
+// DuplicatePolicy decides what an import does with a record clashing with an existing
+// entry of the same title and username
+type DuplicatePolicy int
+
+const (
+	// DuplicateSkip leaves the existing entry alone and reports the record as a duplicate
+	DuplicateSkip DuplicatePolicy = iota
+	// DuplicateOverwrite replaces the existing entry with the record
+	DuplicateOverwrite
+	// DuplicateRename adds the record under a numbered title, e.g. "GitHub (2)"
+	DuplicateRename
+)
+
+// DuplicatePolicies lists the policies in the order they are offered
+var DuplicatePolicies = []DuplicatePolicy{DuplicateSkip, DuplicateOverwrite, DuplicateRename}
+
+// String returns the name of the policy
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case DuplicateOverwrite:
+		return "overwrite"
+	case DuplicateRename:
+		return "rename"
+	default:
+		return "skip"
+	}
+}
+
+// ParseDuplicatePolicy returns the policy with the given name
+func ParseDuplicatePolicy(name string) (DuplicatePolicy, error) {
+	for _, p := range DuplicatePolicies {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return DuplicateSkip, fmt.Errorf("unknown duplicate policy %q, expected skip, overwrite or rename", name)
+}
+
+// Options tune how a batch is imported
+type Options struct {
+	// AttachmentDir is where the attachments of the records are encrypted into
+	AttachmentDir string
+	// Duplicates decides what happens to records clashing with an existing entry
+	Duplicates DuplicatePolicy
+	// DryRun reports what the import would do and then rolls it back, leaving attachments unwritten
+	DryRun bool
+}
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// Import stores the records of a batch as entries in a single transaction, with their
+// attachments encrypted into the attachment directory. Invalid records and attachments over
+// the size limit are skipped and records clashing with an existing entry are handled by the
+// duplicate policy, while any other failure rolls the whole import back.
+func Import(v vault.Vault, batch Batch, opts Options) (vault.ImportReport, error) {
+	report := vault.ImportReport{Skipped: append([]vault.SkippedRecord(nil), batch.Skipped...)}
+	var stored []model.Attachment
+	err := v.Transaction(func(tx vault.Vault) error {
+		attachments := vault.NewAttachments(tx, opts.AttachmentDir)
+		for _, record := range batch.Records {
+			if err := record.Validate(); err != nil {
+				report.Skipped = append(report.Skipped, vault.SkippedRecord{Name: record.Name(), Reason: err.Error()})
+				continue
+			}
+
+			updated, err := importRecord(tx, &record, opts.Duplicates)
+			var duplicate model.PasswordAlreadyExistsError
+			switch {
+			case errors.As(err, &duplicate):
+				report.Duplicates = append(report.Duplicates, duplicate)
+				continue
+			case err != nil:
+				return fmt.Errorf("failed to import %s: %w", record.Name(), err)
+			case updated:
+				report.Updated = append(report.Updated, record.Name())
+			default:
+				report.Added = append(report.Added, record.Name())
+			}
+			if opts.DryRun || len(record.Attachments) == 0 {
+				continue
+			}
+
+			entry, _ := record.Item.Entry()
+			entry, err = storedEntry(tx, entry.Title, entry.Username)
+			if err != nil {
+				return fmt.Errorf("failed to import %s: %w", record.Name(), err)
+			}
+			for _, file := range record.Attachments {
+				name := fmt.Sprintf("%s attachment %s", record.Name(), file.Name)
+				if _, err := attachments.Find(entry.ID, file.Name); err == nil {
+					report.Skipped = append(report.Skipped, vault.SkippedRecord{Name: name, Reason: "already exists"})
+					continue
+				}
+				attachment, err := attachments.Add(entry.ID, file.Name, bytes.NewReader(file.Data))
+				var tooLarge model.AttachmentTooLargeError
+				switch {
+				case errors.As(err, &tooLarge):
+					report.Skipped = append(report.Skipped, vault.SkippedRecord{Name: name, Reason: err.Error()})
+				case err != nil:
+					return fmt.Errorf("failed to import %s: %w", record.Name(), err)
+				default:
+					stored = append(stored, attachment)
+				}
+			}
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		return report, nil
+	}
+	if err != nil {
+		// The rolled back rows are gone, only the blobs written along the way are left to clean up
+		attachments := vault.NewAttachments(v, opts.AttachmentDir)
+		for _, attachment := range stored {
+			attachments.RemoveBlob(attachment)
+		}
+		return vault.ImportReport{}, err
+	}
+	return report, nil
+}
+
+// importRecord adds a record as a new entry, resolving a clash with an existing entry by the
+// policy. It tells whether an existing entry was overwritten and renames the record in place
+// when it is added under a numbered title.
+func importRecord(tx vault.Vault, record *Record, policy DuplicatePolicy) (bool, error) {
+	_, err := tx.AddItem(record.Item, record.Fields, record.Folder, record.Tags)
+	var duplicate model.PasswordAlreadyExistsError
+	if !errors.As(err, &duplicate) {
+		return false, err
+	}
+
+	switch policy {
+	case DuplicateOverwrite:
+		stored, err := storedEntry(tx, duplicate.Title, duplicate.Username)
+		if err != nil {
+			return false, err
+		}
+		if err = tx.UpdateItem(stored, record.Item, record.Folder, record.Tags); err != nil {
+			return false, err
+		}
+		return true, tx.SetFields(stored.ID, record.Fields)
+	case DuplicateRename:
+		title := record.Item.Title
+		for n := 2; errors.As(err, &duplicate); n++ {
+			record.Item.Title = fmt.Sprintf("%s (%d)", title, n)
+			_, err = tx.AddItem(record.Item, record.Fields, record.Folder, record.Tags)
+		}
+		return false, err
+	default:
+		return false, err
+	}
+}
+
+// storedEntry returns the entry with exactly the given title and username
+func storedEntry(v vault.Vault, title, username string) (model.Password, error) {
+	entries, err := v.Entries()
+	if err != nil {
+		return model.Password{}, err
+	}
+	for _, entry := range entries {
+		if entry.Title == title && entry.Username == username {
+			return entry, nil
+		}
+	}
+	return model.Password{}, model.NewPasswordNotFoundError(v.UserID(), title, username)
+}
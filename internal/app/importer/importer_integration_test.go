@@ -0,0 +1,220 @@
+//go:build integration
+
+package importer
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/test"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loginRecord(title, username string) Record {
+	return Record{Item: model.Item{
+		Type:   model.ItemTypeLogin,
+		Title:  title,
+		Values: model.ItemValues{"username": username, "password": test.RandomString()},
+	}}
+}
+
+func TestShouldImportRecordsWithFoldersFieldsAndOTP(t *testing.T) {
+	// setup
+	v := vaulttest.New(t)
+
+	// given
+	login := loginRecord("GitHub", "octocat")
+	login.Item.Values["otp"] = "otpauth://totp/GitHub:octocat?secret=JBSWY3DPEHPK3PXP"
+	login.Folder = "Work/Dev"
+	login.Tags = []string{"code"}
+	login.Fields = []model.CustomField{{Type: model.FieldTypeHidden, Name: "PIN", Value: "4242"}}
+	batch := Batch{
+		Records: []Record{login},
+		Skipped: []vault.SkippedRecord{{Name: "Passkey", Reason: "unsupported Bitwarden item type 9"}},
+	}
+
+	// when
+	report, err := Import(v, batch, Options{AttachmentDir: t.TempDir()})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GitHub (octocat)"}, report.Added)
+	assert.Equal(t, batch.Skipped, report.Skipped)
+	assert.Empty(t, report.Duplicates)
+
+	stored, err := v.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	details, err := v.Reveal(stored)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Work/Dev"}, details.Folders)
+	assert.Equal(t, []string{"code"}, details.Tags)
+	require.Len(t, details.Fields, 1)
+	assert.Equal(t, "PIN", details.Fields[0].Name)
+	assert.Equal(t, "4242", details.Fields[0].Value)
+	code, err := v.OneTimeCode(stored, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Len(t, code.Value, 6)
+}
+
+func TestShouldReportDuplicatesAndSkipInvalidRecords(t *testing.T) {
+	// setup
+	v := vaulttest.New(t)
+
+	// given
+	_, err := v.AddItem(loginRecord("GitHub", "octocat").Item, nil, "", nil)
+	require.NoError(t, err)
+	invalid := loginRecord("Bank", "")
+	batch := Batch{Records: []Record{loginRecord("GitHub", "octocat"), invalid, loginRecord("GitLab", "octocat")}}
+
+	// when
+	report, err := Import(v, batch, Options{AttachmentDir: t.TempDir()})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GitLab (octocat)"}, report.Added)
+	assert.Equal(t, []model.PasswordAlreadyExistsError{model.NewPasswordAlreadyExistsError(v.UserID(), "GitHub", "octocat")}, report.Duplicates)
+	require.Len(t, report.Skipped, 1)
+	assert.Equal(t, "Bank ()", report.Skipped[0].Name)
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestShouldRollImportBackWhenRecordCannotBeStored(t *testing.T) {
+	// setup
+	v, db := vaulttest.NewWithDB(t)
+
+	// given
+	_, err := v.AddItem(loginRecord("Existing", "me").Item, nil, "", nil)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TRIGGER reject_broken BEFORE INSERT ON passwords WHEN NEW.title = 'Broken'
+		BEGIN SELECT RAISE(ABORT, 'broken entry'); END`)
+	require.NoError(t, err)
+	batch := Batch{Records: []Record{loginRecord("GitHub", "octocat"), loginRecord("Broken", "octocat")}}
+
+	// when
+	_, err = Import(v, batch, Options{AttachmentDir: t.TempDir()})
+
+	// then
+	assert.ErrorContains(t, err, "failed to import Broken (octocat)")
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Existing", entries[0].Title)
+}
+
+func TestShouldImportAttachments(t *testing.T) {
+	// setup
+	v := vaulttest.New(t)
+	dir := t.TempDir()
+
+	// given
+	login := loginRecord("GitHub", "octocat")
+	login.Attachments = []Attachment{{Name: "recovery.txt", Data: []byte("codes")}}
+
+	// when
+	report, err := Import(v, Batch{Records: []Record{login}}, Options{AttachmentDir: dir})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GitHub (octocat)"}, report.Added)
+	assert.Empty(t, report.Skipped)
+
+	stored, err := v.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	attachments := vault.NewAttachments(v, dir)
+	files, err := attachments.List(stored.ID)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	var content bytes.Buffer
+	require.NoError(t, attachments.Extract(files[0], &content))
+	assert.Equal(t, "codes", content.String())
+}
+
+func TestShouldResolveDuplicatesByPolicy(t *testing.T) {
+	tests := []struct {
+		policy    DuplicatePolicy
+		added     []string
+		updated   []string
+		password  string
+		remaining int
+	}{
+		{DuplicateSkip, nil, nil, "old", 1},
+		{DuplicateOverwrite, nil, []string{"GitHub (octocat)"}, "new", 1},
+		{DuplicateRename, []string{"GitHub (2) (octocat)"}, nil, "old", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			// setup
+			v := vaulttest.New(t)
+
+			// given
+			existing := loginRecord("GitHub", "octocat")
+			existing.Item.Values["password"] = "old"
+			_, err := v.AddItem(existing.Item, []model.CustomField{{Type: model.FieldTypeText, Name: "Old", Value: "x"}}, "", nil)
+			require.NoError(t, err)
+			record := loginRecord("GitHub", "octocat")
+			record.Item.Values["password"] = "new"
+			record.Fields = []model.CustomField{{Type: model.FieldTypeText, Name: "New", Value: "y"}}
+			record.Folder = "Imported"
+
+			// when
+			report, err := Import(v, Batch{Records: []Record{record}}, Options{Duplicates: tt.policy})
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, tt.added, report.Added)
+			assert.Equal(t, tt.updated, report.Updated)
+			entries, err := v.Entries()
+			require.NoError(t, err)
+			assert.Len(t, entries, tt.remaining)
+			stored, err := v.FindEntry("GitHub", "octocat")
+			require.NoError(t, err)
+			details, err := v.Reveal(stored)
+			require.NoError(t, err)
+			assert.Equal(t, tt.password, details.Entry.Password)
+			if tt.policy == DuplicateOverwrite {
+				assert.Equal(t, []string{"Imported"}, details.Folders)
+				require.Len(t, details.Fields, 1)
+				assert.Equal(t, "New", details.Fields[0].Name)
+			}
+			if tt.policy == DuplicateSkip {
+				assert.Len(t, report.Duplicates, 1)
+			}
+		})
+	}
+}
+
+func TestShouldPreviewImportWithoutStoringAnything(t *testing.T) {
+	// setup
+	v := vaulttest.New(t)
+	dir := t.TempDir()
+
+	// given
+	_, err := v.AddItem(loginRecord("GitHub", "octocat").Item, nil, "", nil)
+	require.NoError(t, err)
+	added := loginRecord("GitLab", "octocat")
+	added.Attachments = []Attachment{{Name: "recovery.txt", Data: []byte("codes")}}
+	batch := Batch{Records: []Record{loginRecord("GitHub", "octocat"), added, loginRecord("Bank", "")}}
+
+	// when
+	report, err := Import(v, batch, Options{AttachmentDir: dir, Duplicates: DuplicateOverwrite, DryRun: true})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GitLab (octocat)"}, report.Added)
+	assert.Equal(t, []string{"GitHub (octocat)"}, report.Updated)
+	require.Len(t, report.Skipped, 1)
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	blobs, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, blobs)
+}
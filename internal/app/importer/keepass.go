@@ -0,0 +1,178 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"yubigo-pass/internal/app/keepass"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/otp"
+	"yubigo-pass/internal/app/vault"
+)
+
+// keepassOTPKey is the string KeePassXC keeps otpauth:// URIs in
+const keepassOTPKey = "otp"
+
+// Strings KeePass 2.47+ keeps its own TOTP settings in
+const (
+	keepassTOTPSecret    = "TimeOtp-Secret-Base32"
+	keepassTOTPLength    = "TimeOtp-Length"
+	keepassTOTPPeriod    = "TimeOtp-Period"
+	keepassTOTPAlgorithm = "TimeOtp-Algorithm"
+)
+
+// ReadKeePass reads a KDBX 4 database. Groups map onto folders and entries onto logins,
+// or secure notes when they hold neither username nor password. Custom strings become custom
+// fields, previous passwords kept in the entry history become hidden fields and attachments
+// are carried over. Entries in the recycle bin are skipped.
+func ReadKeePass(r io.Reader, credentials keepass.Credentials) (Batch, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Batch{}, fmt.Errorf("failed to read KeePass database: %w", err)
+	}
+	db, err := keepass.Parse(data, credentials)
+	if err != nil {
+		return Batch{}, err
+	}
+
+	var batch Batch
+	var walk func(group keepass.Group, path []string, recycled bool)
+	walk = func(group keepass.Group, path []string, recycled bool) {
+		recycled = recycled || bool(db.Meta.RecycleBinEnabled) && group.UUID == db.Meta.RecycleBinUUID
+		for _, entry := range group.Entries {
+			if recycled {
+				batch.Skipped = append(batch.Skipped, vault.SkippedRecord{Name: entry.Get(keepass.KeyTitle), Reason: "is in the recycle bin"})
+				continue
+			}
+			record, err := keepassRecord(entry, db.Binaries)
+			if err != nil {
+				batch.Skipped = append(batch.Skipped, vault.SkippedRecord{Name: entry.Get(keepass.KeyTitle), Reason: err.Error()})
+				continue
+			}
+			record.Folder = strings.Join(path, vault.FolderSeparator)
+			batch.Records = append(batch.Records, record)
+		}
+		for _, child := range group.Groups {
+			walk(child, append(append([]string(nil), path...), child.Name), recycled)
+		}
+	}
+	walk(db.Root, nil, false)
+	return batch, nil
+}
+
+// keepassRecord maps a KeePass entry onto a record
+func keepassRecord(entry keepass.Entry, binaries []keepass.Binary) (Record, error) {
+	record := Record{
+		Item: model.Item{
+			Type:   model.ItemTypeLogin,
+			Title:  entry.Get(keepass.KeyTitle),
+			Notes:  entry.Get(keepass.KeyNotes),
+			Values: model.ItemValues{},
+		},
+		Tags: entry.TagList(),
+	}
+	values := record.Item.Values
+	values["username"] = entry.Get(keepass.KeyUserName)
+	values["password"] = entry.Get(keepass.KeyPassword)
+	values["url"] = entry.Get(keepass.KeyURL)
+
+	consumed := map[string]bool{
+		keepass.KeyTitle: true, keepass.KeyUserName: true, keepass.KeyPassword: true,
+		keepass.KeyURL: true, keepass.KeyNotes: true,
+	}
+	if uri := entry.Get(keepassOTPKey); uri != "" {
+		if _, err := otp.Parse(uri); err == nil {
+			values["otp"] = strings.TrimSpace(uri)
+			consumed[keepassOTPKey] = true
+		}
+	}
+	if entry.Get(keepassTOTPSecret) != "" && values["otp"] == "" {
+		if uri, ok := keepassTOTP(entry, record.Item.Title, values["username"]); ok {
+			values["otp"] = uri
+			for _, key := range []string{keepassTOTPSecret, keepassTOTPLength, keepassTOTPPeriod, keepassTOTPAlgorithm} {
+				consumed[key] = true
+			}
+		}
+	}
+	if values["username"] == "" && values["password"] == "" && values["otp"] == "" {
+		record.Item.Type = model.ItemTypeSecureNote
+	}
+
+	for _, s := range entry.Strings {
+		if consumed[s.Key] {
+			continue
+		}
+		fieldType := model.FieldTypeText
+		if s.Value.Protected {
+			fieldType = model.FieldTypeHidden
+		}
+		record.Fields = appendTextField(record.Fields, s.Key, s.Value.Content, fieldType)
+	}
+	record.Fields = append(record.Fields, keepassPasswordHistory(entry)...)
+
+	for _, ref := range entry.Binaries {
+		if ref.Value.Ref < 0 || ref.Value.Ref >= len(binaries) {
+			return Record{}, fmt.Errorf("refers to missing attachment %s", ref.Key)
+		}
+		record.Attachments = append(record.Attachments, Attachment{Name: ref.Key, Data: binaries[ref.Value.Ref].Data})
+	}
+	return record, nil
+}
+
+// keepassPasswordHistory turns the distinct previous passwords of an entry, newest first,
+// into hidden fields named by the date they were set
+func keepassPasswordHistory(entry keepass.Entry) []model.CustomField {
+	seen := map[string]bool{entry.Get(keepass.KeyPassword): true}
+	var fields []model.CustomField
+	for i := len(entry.History) - 1; i >= 0; i-- {
+		previous := entry.History[i]
+		password := previous.Get(keepass.KeyPassword)
+		if password == "" || seen[password] {
+			continue
+		}
+		seen[password] = true
+		set := time.Time(previous.Times.LastModificationTime).Format(time.DateOnly)
+		fields = append(fields, model.CustomField{
+			Type:  model.FieldTypeHidden,
+			Name:  fmt.Sprintf("Previous password (%s)", set),
+			Value: password,
+		})
+	}
+	return fields
+}
+
+// keepassTOTP builds an otpauth:// URI from the TOTP settings KeePass keeps in entry strings
+func keepassTOTP(entry keepass.Entry, issuer, account string) (string, bool) {
+	secret, err := otp.DecodeSecret(entry.Get(keepassTOTPSecret))
+	if err != nil {
+		return "", false
+	}
+	key := otp.Key{
+		Type:      otp.TypeTOTP,
+		Issuer:    issuer,
+		Account:   account,
+		Secret:    secret,
+		Algorithm: otp.DefaultAlgorithm,
+		Digits:    otp.DefaultDigits,
+		Period:    otp.DefaultPeriod,
+	}
+	if digits, err := strconv.Atoi(entry.Get(keepassTOTPLength)); err == nil {
+		key.Digits = digits
+	}
+	if period, err := strconv.Atoi(entry.Get(keepassTOTPPeriod)); err == nil && period > 0 {
+		key.Period = time.Duration(period) * time.Second
+	}
+	switch entry.Get(keepassTOTPAlgorithm) {
+	case "HMAC-SHA-256":
+		key.Algorithm = otp.AlgorithmSHA256
+	case "HMAC-SHA-512":
+		key.Algorithm = otp.AlgorithmSHA512
+	}
+	uri := key.URI()
+	if _, err = otp.Parse(uri); err != nil {
+		return "", false
+	}
+	return uri, true
+}
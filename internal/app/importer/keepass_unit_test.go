@@ -0,0 +1,150 @@
+//go:build unit
+
+package importer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/keepass"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func keepassEntry(modified time.Time, strings ...keepass.String) keepass.Entry {
+	return keepass.Entry{Times: keepass.NewTimes(modified), Strings: strings}
+}
+
+func keepassString(key, value string, protected bool) keepass.String {
+	return keepass.String{Key: key, Value: keepass.Value{Content: value, Protected: keepass.Bool(protected)}}
+}
+
+func writeKeePass(t *testing.T, db keepass.Database) *bytes.Reader {
+	data, err := keepass.Marshal(db, keepass.Credentials{Password: "correct horse"}, test.KeePassOptions)
+	require.NoError(t, err)
+	return bytes.NewReader(data)
+}
+
+func TestReadKeePassShouldMapGroupsStringsHistoryAndAttachments(t *testing.T) {
+	// given
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	github := keepassEntry(now,
+		keepassString(keepass.KeyTitle, "GitHub", false),
+		keepassString(keepass.KeyUserName, "octocat", false),
+		keepassString(keepass.KeyPassword, "hunter3", true),
+		keepassString(keepass.KeyURL, "https://github.com", false),
+		keepassString(keepass.KeyNotes, "personal", false),
+		keepassString("otp", "otpauth://totp/GitHub:octocat?secret=JBSWY3DPEHPK3PXP", true),
+		keepassString("PIN", "4242", true),
+		keepassString("Team", "core", false),
+	)
+	github.Tags = "code;work"
+	github.Binaries = []keepass.BinaryRef{keepass.NewBinaryRef("recovery.txt", 0)}
+	github.History = []keepass.Entry{
+		keepassEntry(now.AddDate(0, 0, -20), keepassString(keepass.KeyPassword, "hunter1", true)),
+		keepassEntry(now.AddDate(0, 0, -10), keepassString(keepass.KeyPassword, "hunter2", true)),
+		keepassEntry(now.AddDate(0, 0, -5), keepassString(keepass.KeyPassword, "hunter2", true)),
+	}
+	note := keepassEntry(now, keepassString(keepass.KeyTitle, "Wi-Fi", false), keepassString(keepass.KeyNotes, "on the router", false))
+	db := keepass.Database{
+		Root: keepass.Group{Name: "Root", Entries: []keepass.Entry{note}, Groups: []keepass.Group{
+			{Name: "Work", Groups: []keepass.Group{{Name: "Dev", Entries: []keepass.Entry{github}}}},
+		}},
+		Binaries: []keepass.Binary{{Data: []byte("codes")}},
+	}
+
+	// when
+	batch, err := ReadKeePass(writeKeePass(t, db), keepass.Credentials{Password: "correct horse"})
+
+	// then
+	require.NoError(t, err)
+	assert.Empty(t, batch.Skipped)
+	require.Len(t, batch.Records, 2)
+
+	assert.Equal(t, model.ItemTypeSecureNote, batch.Records[0].Item.Type)
+	assert.Equal(t, "on the router", batch.Records[0].Item.Notes)
+	assert.Empty(t, batch.Records[0].Folder)
+
+	record := batch.Records[1]
+	assert.Equal(t, model.ItemTypeLogin, record.Item.Type)
+	assert.Equal(t, "Work/Dev", record.Folder)
+	assert.Equal(t, []string{"code", "work"}, record.Tags)
+	assert.Equal(t, "personal", record.Item.Notes)
+	assert.Equal(t, model.ItemValues{
+		"username": "octocat",
+		"password": "hunter3",
+		"url":      "https://github.com",
+		"otp":      "otpauth://totp/GitHub:octocat?secret=JBSWY3DPEHPK3PXP",
+	}, record.Item.Values)
+	assert.Equal(t, []model.CustomField{
+		{Type: model.FieldTypeHidden, Name: "PIN", Value: "4242"},
+		{Type: model.FieldTypeText, Name: "Team", Value: "core"},
+		{Type: model.FieldTypeHidden, Name: "Previous password (2024-02-25)", Value: "hunter2"},
+		{Type: model.FieldTypeHidden, Name: "Previous password (2024-02-10)", Value: "hunter1"},
+	}, record.Fields)
+	assert.Equal(t, []Attachment{{Name: "recovery.txt", Data: []byte("codes")}}, record.Attachments)
+}
+
+func TestReadKeePassShouldConvertKeePassTOTPSettings(t *testing.T) {
+	// given
+	entry := keepassEntry(time.Now(),
+		keepassString(keepass.KeyTitle, "GitLab", false),
+		keepassString(keepass.KeyUserName, "tanuki", false),
+		keepassString("TimeOtp-Secret-Base32", "JBSWY3DPEHPK3PXP", true),
+		keepassString("TimeOtp-Length", "8", false),
+		keepassString("TimeOtp-Period", "60", false),
+		keepassString("TimeOtp-Algorithm", "HMAC-SHA-256", false),
+	)
+	db := keepass.Database{Root: keepass.Group{Name: "Root", Entries: []keepass.Entry{entry}}}
+
+	// when
+	batch, err := ReadKeePass(writeKeePass(t, db), keepass.Credentials{Password: "correct horse"})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, batch.Records, 1)
+	assert.Equal(t, "otpauth://totp/GitLab:tanuki?algorithm=SHA256&digits=8&issuer=GitLab&period=60&secret=JBSWY3DPEHPK3PXP",
+		batch.Records[0].Item.Values["otp"])
+	assert.Empty(t, batch.Records[0].Fields)
+}
+
+func TestReadKeePassShouldSkipRecycleBinAndBrokenAttachments(t *testing.T) {
+	// given
+	binID, err := keepass.NewUUID()
+	require.NoError(t, err)
+	deleted := keepassEntry(time.Now(), keepassString(keepass.KeyTitle, "Old", false))
+	broken := keepassEntry(time.Now(), keepassString(keepass.KeyTitle, "Broken", false))
+	broken.Binaries = []keepass.BinaryRef{keepass.NewBinaryRef("missing.bin", 3)}
+	db := keepass.Database{
+		Meta: keepass.Meta{RecycleBinEnabled: true, RecycleBinUUID: binID},
+		Root: keepass.Group{Name: "Root", Entries: []keepass.Entry{broken}, Groups: []keepass.Group{
+			{UUID: binID, Name: "Recycle Bin", Groups: []keepass.Group{{Name: "Nested", Entries: []keepass.Entry{deleted}}}},
+		}},
+	}
+
+	// when
+	batch, err := ReadKeePass(writeKeePass(t, db), keepass.Credentials{Password: "correct horse"})
+
+	// then
+	require.NoError(t, err)
+	assert.Empty(t, batch.Records)
+	assert.Equal(t, []vault.SkippedRecord{
+		{Name: "Broken", Reason: "refers to missing attachment missing.bin"},
+		{Name: "Old", Reason: "is in the recycle bin"},
+	}, batch.Skipped)
+}
+
+func TestReadKeePassShouldRejectWrongPassword(t *testing.T) {
+	// given
+	db := keepass.Database{Root: keepass.Group{Name: "Root"}}
+
+	// when
+	_, err := ReadKeePass(writeKeePass(t, db), keepass.Credentials{Password: "wrong"})
+
+	// then
+	assert.ErrorIs(t, err, keepass.ErrWrongCredentials)
+}
@@ -0,0 +1,234 @@
+package keepass
+
+import (
+	"encoding/binary"
+	"hash"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Argon2d is not offered by golang.org/x/crypto/argon2, which only exposes the data
+// independent variants, yet it is the KeePass default. This is a plain implementation
+// of Argon2d version 1.3 following RFC 9106.
+
+const (
+	argon2Version    = 0x13
+	argon2TypeD      = 0
+	argon2BlockWords = 128
+	argon2SyncPoints = 4
+)
+
+type argon2Block [argon2BlockWords]uint64
+
+// argon2dKey derives a key of keyLen bytes with Argon2d, memory given in KiB
+func argon2dKey(password, salt, secret, data []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+	lanes := uint32(threads)
+	h0 := argon2InitHash(password, salt, secret, data, time, memory, lanes, keyLen)
+
+	memory = memory / (argon2SyncPoints * lanes) * (argon2SyncPoints * lanes)
+	if memory < 2*argon2SyncPoints*lanes {
+		memory = 2 * argon2SyncPoints * lanes
+	}
+	blocks := argon2InitBlocks(h0, memory, lanes)
+	argon2FillBlocks(blocks, time, memory, lanes)
+	return argon2ExtractKey(blocks, memory, lanes, keyLen)
+}
+
+// argon2InitHash computes H0 over the parameters and inputs, leaving room for the block and lane indexes
+func argon2InitHash(password, salt, secret, data []byte, time, memory, lanes, keyLen uint32) [blake2b.Size + 8]byte {
+	var h0 [blake2b.Size + 8]byte
+	b2, _ := blake2b.New512(nil)
+	for _, v := range []uint32{lanes, keyLen, memory, time, argon2Version, argon2TypeD} {
+		_ = binary.Write(b2, binary.LittleEndian, v)
+	}
+	for _, input := range [][]byte{password, salt, secret, data} {
+		_ = binary.Write(b2, binary.LittleEndian, uint32(len(input)))
+		b2.Write(input)
+	}
+	b2.Sum(h0[:0])
+	return h0
+}
+
+// argon2InitBlocks fills the first two blocks of every lane from H0
+func argon2InitBlocks(h0 [blake2b.Size + 8]byte, memory, lanes uint32) []argon2Block {
+	blocks := make([]argon2Block, memory)
+	var buf [1024]byte
+	for lane := uint32(0); lane < lanes; lane++ {
+		start := lane * (memory / lanes)
+		binary.LittleEndian.PutUint32(h0[blake2b.Size+4:], lane)
+		for i := uint32(0); i < 2; i++ {
+			binary.LittleEndian.PutUint32(h0[blake2b.Size:], i)
+			argon2Hash(buf[:], h0[:])
+			for w := range blocks[start+i] {
+				blocks[start+i][w] = binary.LittleEndian.Uint64(buf[w*8:])
+			}
+		}
+	}
+	return blocks
+}
+
+// argon2FillBlocks runs the passes over memory, the lanes of a slice in parallel
+func argon2FillBlocks(blocks []argon2Block, time, memory, lanes uint32) {
+	laneLength := memory / lanes
+	segmentLength := laneLength / argon2SyncPoints
+
+	fillSegment := func(pass, slice, lane uint32) {
+		index := uint32(0)
+		if pass == 0 && slice == 0 {
+			index = 2
+		}
+		offset := lane*laneLength + slice*segmentLength + index
+		for ; index < segmentLength; index, offset = index+1, offset+1 {
+			prev := offset - 1
+			if index == 0 && slice == 0 {
+				prev += laneLength
+			}
+			ref := argon2RefIndex(blocks[prev][0], pass, slice, lane, index, lanes, laneLength, segmentLength)
+			argon2Compress(&blocks[offset], &blocks[prev], &blocks[ref])
+		}
+	}
+
+	for pass := uint32(0); pass < time; pass++ {
+		for slice := uint32(0); slice < argon2SyncPoints; slice++ {
+			var wg sync.WaitGroup
+			for lane := uint32(0); lane < lanes; lane++ {
+				wg.Add(1)
+				go func(lane uint32) {
+					defer wg.Done()
+					fillSegment(pass, slice, lane)
+				}(lane)
+			}
+			wg.Wait()
+		}
+	}
+}
+
+// argon2RefIndex maps the pseudo-random value taken from the previous block onto the
+// index of the reference block, among the blocks finished so far
+func argon2RefIndex(rand uint64, pass, slice, lane, index, lanes, laneLength, segmentLength uint32) uint32 {
+	refLane := uint32(rand>>32) % lanes
+	if pass == 0 && slice == 0 {
+		refLane = lane
+	}
+
+	var area, start uint32
+	if pass == 0 {
+		area = slice * segmentLength
+		if slice == 0 || refLane == lane {
+			area += index
+		}
+	} else {
+		area = 3 * segmentLength
+		start = ((slice + 1) % argon2SyncPoints) * segmentLength
+		if refLane == lane {
+			area += index
+		}
+	}
+	if index == 0 || refLane == lane {
+		area--
+	}
+
+	x := rand & 0xFFFFFFFF
+	x = (x * x) >> 32
+	x = (x * uint64(area)) >> 32
+	return refLane*laneLength + uint32((uint64(start)+uint64(area)-(x+1))%uint64(laneLength))
+}
+
+// argon2Compress XORs G(prev, ref) into out, as version 1.3 does on every pass
+func argon2Compress(out, prev, ref *argon2Block) {
+	var r, z argon2Block
+	for i := range r {
+		r[i] = prev[i] ^ ref[i]
+	}
+	z = r
+	for row := 0; row < 8; row++ {
+		i := row * 16
+		argon2Permute(&z, i, i+1, i+2, i+3, i+4, i+5, i+6, i+7, i+8, i+9, i+10, i+11, i+12, i+13, i+14, i+15)
+	}
+	for col := 0; col < 8; col++ {
+		i := col * 2
+		argon2Permute(&z, i, i+1, i+16, i+17, i+32, i+33, i+48, i+49, i+64, i+65, i+80, i+81, i+96, i+97, i+112, i+113)
+	}
+	for i := range out {
+		out[i] ^= r[i] ^ z[i]
+	}
+}
+
+// argon2Permute applies the BlaMka round P to sixteen words of a block
+func argon2Permute(b *argon2Block, i ...int) {
+	g := func(a, c, d, e int) {
+		b[i[a]], b[i[c]], b[i[d]], b[i[e]] = argon2G(b[i[a]], b[i[c]], b[i[d]], b[i[e]])
+	}
+	g(0, 4, 8, 12)
+	g(1, 5, 9, 13)
+	g(2, 6, 10, 14)
+	g(3, 7, 11, 15)
+	g(0, 5, 10, 15)
+	g(1, 6, 11, 12)
+	g(2, 7, 8, 13)
+	g(3, 4, 9, 14)
+}
+
+// argon2G is the BLAKE2b mixing function with the multiplications Argon2 adds
+func argon2G(a, b, c, d uint64) (uint64, uint64, uint64, uint64) {
+	mul := func(x, y uint64) uint64 { return 2 * uint64(uint32(x)) * uint64(uint32(y)) }
+	a += b + mul(a, b)
+	d = rotr(d^a, 32)
+	c += d + mul(c, d)
+	b = rotr(b^c, 24)
+	a += b + mul(a, b)
+	d = rotr(d^a, 16)
+	c += d + mul(c, d)
+	b = rotr(b^c, 63)
+	return a, b, c, d
+}
+
+func rotr(x uint64, n uint) uint64 {
+	return x>>n | x<<(64-n)
+}
+
+// argon2ExtractKey XORs the last blocks of all lanes and hashes them into the key
+func argon2ExtractKey(blocks []argon2Block, memory, lanes, keyLen uint32) []byte {
+	laneLength := memory / lanes
+	final := blocks[laneLength-1]
+	for lane := uint32(1); lane < lanes; lane++ {
+		for i, v := range blocks[lane*laneLength+laneLength-1] {
+			final[i] ^= v
+		}
+	}
+	var buf [1024]byte
+	for i, v := range final {
+		binary.LittleEndian.PutUint64(buf[i*8:], v)
+	}
+	key := make([]byte, keyLen)
+	argon2Hash(key, buf[:])
+	return key
+}
+
+// argon2Hash is the variable length hash H' of Argon2, filling out
+func argon2Hash(out, in []byte) {
+	var b2 hash.Hash
+	if len(out) < blake2b.Size {
+		b2, _ = blake2b.New(len(out), nil)
+	} else {
+		b2, _ = blake2b.New512(nil)
+	}
+	_ = binary.Write(b2, binary.LittleEndian, uint32(len(out)))
+	b2.Write(in)
+	if len(out) <= blake2b.Size {
+		b2.Sum(out[:0])
+		return
+	}
+
+	var v [blake2b.Size]byte
+	b2.Sum(v[:0])
+	written := copy(out, v[:32])
+	for len(out)-written > blake2b.Size {
+		v = blake2b.Sum512(v[:])
+		written += copy(out[written:], v[:32])
+	}
+	last, _ := blake2b.New(len(out)-written, nil)
+	last.Write(v[:])
+	last.Sum(out[written:written])
+}
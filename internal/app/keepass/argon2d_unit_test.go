@@ -0,0 +1,25 @@
+//go:build unit
+
+package keepass
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgon2dShouldMatchRFC9106TestVector(t *testing.T) {
+	// given
+	password := bytes.Repeat([]byte{0x01}, 32)
+	salt := bytes.Repeat([]byte{0x02}, 16)
+	secret := bytes.Repeat([]byte{0x03}, 8)
+	data := bytes.Repeat([]byte{0x04}, 12)
+
+	// when
+	key := argon2dKey(password, salt, secret, data, 3, 32, 4, 32)
+
+	// then
+	assert.Equal(t, "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb", hex.EncodeToString(key))
+}
@@ -0,0 +1,485 @@
+package keepass
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20"
+)
+
+// File signature and version of KDBX 4
+const (
+	signature1   uint32 = 0x9AA2D903
+	signature2   uint32 = 0xB54BFB67
+	versionMajor uint16 = 4
+)
+
+// Outer header field identifiers
+const (
+	fieldEndOfHeader   byte = 0
+	fieldCipherID      byte = 2
+	fieldCompression   byte = 3
+	fieldMasterSeed    byte = 4
+	fieldEncryptionIV  byte = 7
+	fieldKdfParameters byte = 11
+)
+
+// Compression flags of the payload
+const (
+	compressionNone = 0
+	compressionGzip = 1
+)
+
+// blockSize is the payload size of the blocks written to the HMAC block stream
+const blockSize = 1 << 20
+
+// Cipher is the outer encryption of a database
+type Cipher int
+
+// Supported outer ciphers
+const (
+	CipherAES256 Cipher = iota
+	CipherChaCha20
+)
+
+// KDFType is the key derivation turning the composite key into the encryption keys
+type KDFType int
+
+// Supported key derivations
+const (
+	KDFTypeAES KDFType = iota
+	KDFTypeArgon2d
+	KDFTypeArgon2id
+)
+
+// KDF holds the key derivation parameters. Rounds applies to AES-KDF, Iterations, Memory
+// in KiB and Parallelism to Argon2.
+type KDF struct {
+	Type        KDFType
+	Rounds      uint64
+	Iterations  uint64
+	Memory      uint64
+	Parallelism uint32
+}
+
+// Options choose how Marshal encrypts a database
+type Options struct {
+	Cipher Cipher
+	KDF    KDF
+}
+
+// DefaultOptions are the settings KeePassXC picks for new databases
+var DefaultOptions = Options{
+	Cipher: CipherAES256,
+	KDF:    KDF{Type: KDFTypeArgon2id, Iterations: 10, Memory: 64 * 1024, Parallelism: 2},
+}
+
+var (
+	cipherUUIDs = map[Cipher][]byte{
+		CipherAES256:   {0x31, 0xC1, 0xF2, 0xE6, 0xBF, 0x71, 0x43, 0x50, 0xBE, 0x58, 0x05, 0x21, 0x6A, 0xFC, 0x5A, 0xFF},
+		CipherChaCha20: {0xD6, 0x03, 0x8A, 0x2B, 0x8B, 0x6F, 0x4C, 0xB5, 0xA5, 0x24, 0x33, 0x9A, 0x31, 0xDB, 0xB5, 0x9A},
+	}
+	kdfUUIDs = map[KDFType][]byte{
+		KDFTypeAES:      {0xC9, 0xD9, 0xF3, 0x9A, 0x62, 0x8A, 0x44, 0x60, 0xBF, 0x74, 0x0D, 0x08, 0xC1, 0x8A, 0x4F, 0xEA},
+		KDFTypeArgon2d:  {0xEF, 0x63, 0x6D, 0xDF, 0x8C, 0x29, 0x44, 0x4B, 0x91, 0xF7, 0xA9, 0xA4, 0x03, 0xE3, 0x0A, 0x0C},
+		KDFTypeArgon2id: {0x9E, 0x29, 0x8B, 0x19, 0x56, 0xDB, 0x47, 0x73, 0xB2, 0x3D, 0xFC, 0x3E, 0xC6, 0xF0, 0xA1, 0xE6},
+	}
+)
+
+// header is the plaintext outer header of a KDBX 4 file along with its raw bytes,
+// which are authenticated as a whole
+type header struct {
+	raw         []byte
+	cipher      Cipher
+	compression uint32
+	masterSeed  []byte
+	iv          []byte
+	kdf         KDF
+	kdfSalt     []byte
+}
+
+// newHeader returns a header with fresh random seeds for the given options
+func newHeader(opts Options) (header, error) {
+	if _, ok := cipherUUIDs[opts.Cipher]; !ok {
+		return header{}, fmt.Errorf("unsupported cipher %d", opts.Cipher)
+	}
+	if _, ok := kdfUUIDs[opts.KDF.Type]; !ok {
+		return header{}, fmt.Errorf("unsupported key derivation %d", opts.KDF.Type)
+	}
+	ivSize := aes.BlockSize
+	if opts.Cipher == CipherChaCha20 {
+		ivSize = chacha20.NonceSize
+	}
+	h := header{
+		cipher:      opts.Cipher,
+		compression: compressionGzip,
+		masterSeed:  make([]byte, 32),
+		iv:          make([]byte, ivSize),
+		kdf:         opts.KDF,
+		kdfSalt:     make([]byte, 32),
+	}
+	for _, b := range [][]byte{h.masterSeed, h.iv, h.kdfSalt} {
+		if _, err := rand.Read(b); err != nil {
+			return header{}, fmt.Errorf("failed to generate seed: %w", err)
+		}
+	}
+	return h, nil
+}
+
+// readHeader parses the outer header and checks its hash, returning the rest of the file
+func readHeader(data []byte) (header, []byte, error) {
+	if len(data) < 12 || binary.LittleEndian.Uint32(data[0:]) != signature1 || binary.LittleEndian.Uint32(data[4:]) != signature2 {
+		return header{}, nil, ErrNotKeePass
+	}
+	minor, major := binary.LittleEndian.Uint16(data[8:]), binary.LittleEndian.Uint16(data[10:])
+	if major != versionMajor {
+		return header{}, nil, fmt.Errorf("KeePass database version %d.%d is not supported, save it as KDBX 4", major, minor)
+	}
+
+	h := header{cipher: -1}
+	pos := 12
+	for {
+		if pos+5 > len(data) {
+			return header{}, nil, errors.New("KeePass database header is truncated")
+		}
+		id, size := data[pos], int(binary.LittleEndian.Uint32(data[pos+1:]))
+		pos += 5
+		if size < 0 || pos+size > len(data) {
+			return header{}, nil, errors.New("KeePass database header is truncated")
+		}
+		value := data[pos : pos+size]
+		pos += size
+
+		switch id {
+		case fieldEndOfHeader:
+			h.raw = data[:pos]
+			if len(data) < pos+64 {
+				return header{}, nil, errors.New("KeePass database header is truncated")
+			}
+			sum := sha256.Sum256(h.raw)
+			if !hmac.Equal(sum[:], data[pos:pos+32]) {
+				return header{}, nil, errors.New("KeePass database header is corrupted")
+			}
+			return h, data[pos+32:], h.validate()
+		case fieldCipherID:
+			h.cipher = -1
+			for c, uuid := range cipherUUIDs {
+				if bytes.Equal(uuid, value) {
+					h.cipher = c
+				}
+			}
+		case fieldCompression:
+			if len(value) != 4 {
+				return header{}, nil, errors.New("KeePass database header has an invalid compression flag")
+			}
+			h.compression = binary.LittleEndian.Uint32(value)
+		case fieldMasterSeed:
+			h.masterSeed = value
+		case fieldEncryptionIV:
+			h.iv = value
+		case fieldKdfParameters:
+			params, err := readVariantDictionary(value)
+			if err != nil {
+				return header{}, nil, err
+			}
+			if h.kdf, h.kdfSalt, err = kdfFromParameters(params); err != nil {
+				return header{}, nil, err
+			}
+		}
+	}
+}
+
+// validate checks that the header holds everything needed to decrypt the file
+func (h header) validate() error {
+	switch {
+	case h.cipher < 0:
+		return errors.New("KeePass database uses an unsupported cipher, only AES-256 and ChaCha20 are supported")
+	case h.compression > compressionGzip:
+		return errors.New("KeePass database uses an unsupported compression")
+	case len(h.masterSeed) != 32:
+		return errors.New("KeePass database has an invalid master seed")
+	case h.cipher == CipherAES256 && len(h.iv) != aes.BlockSize, h.cipher == CipherChaCha20 && len(h.iv) != chacha20.NonceSize:
+		return errors.New("KeePass database has an invalid encryption IV")
+	case h.kdfSalt == nil:
+		return errors.New("KeePass database has no key derivation parameters")
+	}
+	return nil
+}
+
+// marshal writes the outer header fields
+func (h header) marshal() []byte {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, signature1)
+	_ = binary.Write(&buf, binary.LittleEndian, signature2)
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(0))
+	_ = binary.Write(&buf, binary.LittleEndian, versionMajor)
+
+	compression := make([]byte, 4)
+	binary.LittleEndian.PutUint32(compression, h.compression)
+	writeField(&buf, fieldCipherID, cipherUUIDs[h.cipher])
+	writeField(&buf, fieldCompression, compression)
+	writeField(&buf, fieldMasterSeed, h.masterSeed)
+	writeField(&buf, fieldEncryptionIV, h.iv)
+	writeField(&buf, fieldKdfParameters, writeVariantDictionary(kdfParameters(h.kdf, h.kdfSalt)))
+	writeField(&buf, fieldEndOfHeader, []byte("\r\n\r\n"))
+	return buf.Bytes()
+}
+
+// writeField writes a header field as its id, its little endian length and its value
+func writeField(buf *bytes.Buffer, id byte, value []byte) {
+	buf.WriteByte(id)
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(value)))
+	buf.Write(value)
+}
+
+// keys derives the key of the outer cipher and the key authenticating the header and the blocks
+func (h header) keys(compositeKey []byte) ([]byte, []byte, error) {
+	transformed, err := h.kdf.derive(compositeKey, h.kdfSalt)
+	if err != nil {
+		return nil, nil, err
+	}
+	seeded := append(append([]byte{}, h.masterSeed...), transformed...)
+	encKey := sha256.Sum256(seeded)
+	hmacKey := sha512.Sum512(append(seeded, 1))
+	return encKey[:], hmacKey[:], nil
+}
+
+// decrypt authenticates the header and the blocks following it and returns the decrypted,
+// decompressed payload made of the inner header and the XML document
+func (h header) decrypt(body, compositeKey []byte) ([]byte, error) {
+	encKey, hmacKey, err := h.keys(compositeKey)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(headerHMAC(hmacKey, h.raw), body[:32]) {
+		return nil, ErrWrongCredentials
+	}
+	ciphertext, err := readBlocks(body[32:], hmacKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var plaintext []byte
+	switch h.cipher {
+	case CipherChaCha20:
+		stream, err := chacha20.NewUnauthenticatedCipher(encKey, h.iv)
+		if err != nil {
+			return nil, err
+		}
+		plaintext = make([]byte, len(ciphertext))
+		stream.XORKeyStream(plaintext, ciphertext)
+	default:
+		if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+			return nil, errors.New("KeePass database content is corrupted")
+		}
+		block, err := aes.NewCipher(encKey)
+		if err != nil {
+			return nil, err
+		}
+		plaintext = make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, h.iv).CryptBlocks(plaintext, ciphertext)
+		padding := int(plaintext[len(plaintext)-1])
+		if padding == 0 || padding > aes.BlockSize || padding > len(plaintext) {
+			return nil, errors.New("KeePass database content is corrupted")
+		}
+		plaintext = plaintext[:len(plaintext)-padding]
+	}
+
+	if h.compression == compressionGzip {
+		reader, err := gzip.NewReader(bytes.NewReader(plaintext))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress KeePass database: %w", err)
+		}
+		defer reader.Close()
+		if plaintext, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("failed to decompress KeePass database: %w", err)
+		}
+	}
+	return plaintext, nil
+}
+
+// encrypt compresses and encrypts the payload and writes the complete file
+func (h header) encrypt(payload, compositeKey []byte) ([]byte, error) {
+	encKey, hmacKey, err := h.keys(compositeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err = writer.Write(payload); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+	plaintext := compressed.Bytes()
+
+	var ciphertext []byte
+	switch h.cipher {
+	case CipherChaCha20:
+		stream, err := chacha20.NewUnauthenticatedCipher(encKey, h.iv)
+		if err != nil {
+			return nil, err
+		}
+		ciphertext = make([]byte, len(plaintext))
+		stream.XORKeyStream(ciphertext, plaintext)
+	default:
+		block, err := aes.NewCipher(encKey)
+		if err != nil {
+			return nil, err
+		}
+		padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+		ciphertext = append(plaintext, bytes.Repeat([]byte{byte(padding)}, padding)...)
+		cipher.NewCBCEncrypter(block, h.iv).CryptBlocks(ciphertext, ciphertext)
+	}
+
+	raw := h.marshal()
+	sum := sha256.Sum256(raw)
+	out := bytes.NewBuffer(raw)
+	out.Write(sum[:])
+	out.Write(headerHMAC(hmacKey, raw))
+	writeBlocks(out, ciphertext, hmacKey)
+	return out.Bytes(), nil
+}
+
+// headerHMAC authenticates the header with the key of the last block index
+func headerHMAC(hmacKey, raw []byte) []byte {
+	mac := hmac.New(sha256.New, blockKey(hmacKey, ^uint64(0)))
+	mac.Write(raw)
+	return mac.Sum(nil)
+}
+
+// blockHMAC authenticates a block of the HMAC block stream, its length prefixed data, along with its index
+func blockHMAC(hmacKey []byte, index uint64, block []byte) []byte {
+	mac := hmac.New(sha256.New, blockKey(hmacKey, index))
+	_ = binary.Write(mac, binary.LittleEndian, index)
+	mac.Write(block)
+	return mac.Sum(nil)
+}
+
+// blockKey derives the HMAC key of a single block
+func blockKey(hmacKey []byte, index uint64) []byte {
+	var indexBytes [8]byte
+	binary.LittleEndian.PutUint64(indexBytes[:], index)
+	key := sha512.Sum512(append(indexBytes[:], hmacKey...))
+	return key[:]
+}
+
+// readBlocks verifies and joins the blocks of the HMAC block stream
+func readBlocks(data, hmacKey []byte) ([]byte, error) {
+	var out bytes.Buffer
+	for index := uint64(0); ; index++ {
+		if len(data) < 36 {
+			return nil, errors.New("KeePass database content is truncated")
+		}
+		mac, size := data[:32], binary.LittleEndian.Uint32(data[32:36])
+		if uint64(size) > uint64(len(data)-36) {
+			return nil, errors.New("KeePass database content is truncated")
+		}
+		block := data[32 : 36+size]
+		if !hmac.Equal(mac, blockHMAC(hmacKey, index, block)) {
+			return nil, errors.New("KeePass database content is corrupted")
+		}
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+		out.Write(block[4:])
+		data = data[36+size:]
+	}
+}
+
+// writeBlocks splits data into authenticated blocks ended by an empty one
+func writeBlocks(out *bytes.Buffer, data, hmacKey []byte) {
+	for index := uint64(0); ; index++ {
+		n := min(len(data), blockSize)
+		block := make([]byte, 4+n)
+		binary.LittleEndian.PutUint32(block, uint32(n))
+		copy(block[4:], data[:n])
+		out.Write(blockHMAC(hmacKey, index, block))
+		out.Write(block)
+		if n == 0 {
+			return
+		}
+		data = data[n:]
+	}
+}
+
+// derive runs the key derivation over the composite key
+func (k KDF) derive(compositeKey, salt []byte) ([]byte, error) {
+	switch k.Type {
+	case KDFTypeAES:
+		block, err := aes.NewCipher(salt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AES-KDF seed: %w", err)
+		}
+		key := append([]byte{}, compositeKey...)
+		for range k.Rounds {
+			block.Encrypt(key[:16], key[:16])
+			block.Encrypt(key[16:], key[16:])
+		}
+		sum := sha256.Sum256(key)
+		return sum[:], nil
+	case KDFTypeArgon2d, KDFTypeArgon2id:
+		if k.Iterations == 0 || k.Iterations > 1<<32-1 || k.Parallelism == 0 || k.Parallelism > 255 || k.Memory < 8*uint64(k.Parallelism) || k.Memory > 1<<22 {
+			return nil, errors.New("KeePass database has invalid Argon2 parameters")
+		}
+		if k.Type == KDFTypeArgon2d {
+			return argon2dKey(compositeKey, salt, nil, nil, uint32(k.Iterations), uint32(k.Memory), uint8(k.Parallelism), 32), nil
+		}
+		return argon2.IDKey(compositeKey, salt, uint32(k.Iterations), uint32(k.Memory), uint8(k.Parallelism), 32), nil
+	}
+	return nil, fmt.Errorf("unsupported key derivation %d", k.Type)
+}
+
+// kdfParameters lays the key derivation out as the KDF parameters dictionary
+func kdfParameters(k KDF, salt []byte) map[string]any {
+	params := map[string]any{"$UUID": kdfUUIDs[k.Type]}
+	if k.Type == KDFTypeAES {
+		params["R"] = k.Rounds
+		params["S"] = salt
+		return params
+	}
+	params["S"] = salt
+	params["I"] = k.Iterations
+	params["M"] = k.Memory * 1024
+	params["P"] = k.Parallelism
+	params["V"] = uint32(argon2Version)
+	return params
+}
+
+// kdfFromParameters reads the key derivation and its salt from the KDF parameters dictionary
+func kdfFromParameters(params map[string]any) (KDF, []byte, error) {
+	uuid, _ := params["$UUID"].([]byte)
+	salt, _ := params["S"].([]byte)
+	for kdfType, id := range kdfUUIDs {
+		if !bytes.Equal(id, uuid) {
+			continue
+		}
+		k := KDF{Type: kdfType}
+		if kdfType == KDFTypeAES {
+			k.Rounds, _ = params["R"].(uint64)
+			return k, salt, nil
+		}
+		if version, _ := params["V"].(uint32); version != argon2Version {
+			return KDF{}, nil, fmt.Errorf("unsupported Argon2 version %#x", version)
+		}
+		memory, _ := params["M"].(uint64)
+		k.Iterations, _ = params["I"].(uint64)
+		k.Memory = memory / 1024
+		k.Parallelism, _ = params["P"].(uint32)
+		return k, salt, nil
+	}
+	return KDF{}, nil, errors.New("KeePass database uses an unsupported key derivation")
+}
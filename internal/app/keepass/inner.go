@@ -0,0 +1,262 @@
+package keepass
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// Inner header field identifiers
+const (
+	innerFieldEnd       byte = 0
+	innerFieldStreamID  byte = 1
+	innerFieldStreamKey byte = 2
+	innerFieldBinary    byte = 3
+)
+
+// innerStreamChaCha20 is the identifier of the ChaCha20 inner stream, the only one KDBX 4 writers use
+const innerStreamChaCha20 = 3
+
+// binaryProtected flags a binary of the inner header to be kept protected in memory
+const binaryProtected = 0x01
+
+// Value types of a variant dictionary
+const (
+	variantEnd       byte = 0x00
+	variantUInt32    byte = 0x04
+	variantUInt64    byte = 0x05
+	variantBool      byte = 0x08
+	variantInt32     byte = 0x0C
+	variantInt64     byte = 0x0D
+	variantString    byte = 0x18
+	variantByteArray byte = 0x42
+	variantVersion        = 0x0100
+)
+
+// newInnerStream returns a ChaCha20 inner stream with a random key
+func newInnerStream() (cipher.Stream, []byte, error) {
+	key := make([]byte, 64)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate inner stream key: %w", err)
+	}
+	stream, err := innerStream(key)
+	return stream, key, err
+}
+
+// innerStream returns the ChaCha20 stream protecting values, keyed by the SHA-512 of the inner stream key
+func innerStream(key []byte) (cipher.Stream, error) {
+	sum := sha512.Sum512(key)
+	return chacha20.NewUnauthenticatedCipher(sum[:32], sum[32:32+chacha20.NonceSize])
+}
+
+// readInnerHeader reads the binaries and the inner stream from the decrypted payload,
+// returning the XML document following them
+func readInnerHeader(data []byte) ([]Binary, cipher.Stream, []byte, error) {
+	var (
+		binaries []Binary
+		streamID uint32
+		key      []byte
+	)
+	for {
+		if len(data) < 5 {
+			return nil, nil, nil, errors.New("KeePass database inner header is truncated")
+		}
+		id, size := data[0], binary.LittleEndian.Uint32(data[1:])
+		if uint64(size) > uint64(len(data)-5) {
+			return nil, nil, nil, errors.New("KeePass database inner header is truncated")
+		}
+		value := data[5 : 5+size]
+		data = data[5+size:]
+
+		switch id {
+		case innerFieldEnd:
+			if streamID != innerStreamChaCha20 {
+				return nil, nil, nil, fmt.Errorf("KeePass database uses unsupported inner stream %d", streamID)
+			}
+			stream, err := innerStream(key)
+			return binaries, stream, data, err
+		case innerFieldStreamID:
+			if len(value) != 4 {
+				return nil, nil, nil, errors.New("KeePass database inner header has an invalid stream id")
+			}
+			streamID = binary.LittleEndian.Uint32(value)
+		case innerFieldStreamKey:
+			key = value
+		case innerFieldBinary:
+			if len(value) == 0 {
+				return nil, nil, nil, errors.New("KeePass database inner header has an invalid binary")
+			}
+			binaries = append(binaries, Binary{Protected: value[0]&binaryProtected != 0, Data: value[1:]})
+		}
+	}
+}
+
+// writeInnerHeader writes the inner stream and the binaries ahead of the XML document
+func writeInnerHeader(binaries []Binary, streamKey []byte) []byte {
+	var buf bytes.Buffer
+	streamID := make([]byte, 4)
+	binary.LittleEndian.PutUint32(streamID, innerStreamChaCha20)
+	writeField(&buf, innerFieldStreamID, streamID)
+	writeField(&buf, innerFieldStreamKey, streamKey)
+	for _, b := range binaries {
+		flags := byte(0)
+		if b.Protected {
+			flags = binaryProtected
+		}
+		writeField(&buf, innerFieldBinary, append([]byte{flags}, b.Data...))
+	}
+	writeField(&buf, innerFieldEnd, nil)
+	return buf.Bytes()
+}
+
+// readVariantDictionary reads the typed key value pairs KDBX 4 stores the KDF parameters in
+func readVariantDictionary(data []byte) (map[string]any, error) {
+	invalid := errors.New("KeePass database has invalid key derivation parameters")
+	if len(data) < 2 || binary.LittleEndian.Uint16(data)&0xFF00 != variantVersion&0xFF00 {
+		return nil, invalid
+	}
+	data = data[2:]
+	params := map[string]any{}
+	for {
+		if len(data) < 1 {
+			return nil, invalid
+		}
+		kind := data[0]
+		if kind == variantEnd {
+			return params, nil
+		}
+		if len(data) < 5 {
+			return nil, invalid
+		}
+		nameSize := binary.LittleEndian.Uint32(data[1:])
+		if uint64(nameSize)+4 > uint64(len(data)-5) {
+			return nil, invalid
+		}
+		name := string(data[5 : 5+nameSize])
+		data = data[5+nameSize:]
+		valueSize := binary.LittleEndian.Uint32(data)
+		if uint64(valueSize) > uint64(len(data)-4) {
+			return nil, invalid
+		}
+		value := data[4 : 4+valueSize]
+		data = data[4+valueSize:]
+
+		sizes := map[byte]int{variantUInt32: 4, variantUInt64: 8, variantBool: 1, variantInt32: 4, variantInt64: 8}
+		if size, ok := sizes[kind]; ok && len(value) != size {
+			return nil, invalid
+		}
+		switch kind {
+		case variantUInt32:
+			params[name] = binary.LittleEndian.Uint32(value)
+		case variantUInt64:
+			params[name] = binary.LittleEndian.Uint64(value)
+		case variantBool:
+			params[name] = value[0] != 0
+		case variantInt32:
+			params[name] = int32(binary.LittleEndian.Uint32(value))
+		case variantInt64:
+			params[name] = int64(binary.LittleEndian.Uint64(value))
+		case variantString:
+			params[name] = string(value)
+		default:
+			params[name] = append([]byte{}, value...)
+		}
+	}
+}
+
+// writeVariantDictionary writes key value pairs sorted by key, supporting the value types KDF parameters use
+func writeVariantDictionary(params map[string]any) []byte {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(variantVersion))
+	for _, name := range names {
+		var (
+			kind  byte
+			value []byte
+		)
+		switch v := params[name].(type) {
+		case uint32:
+			kind, value = variantUInt32, binary.LittleEndian.AppendUint32(nil, v)
+		case uint64:
+			kind, value = variantUInt64, binary.LittleEndian.AppendUint64(nil, v)
+		case []byte:
+			kind, value = variantByteArray, v
+		default:
+			continue
+		}
+		buf.WriteByte(kind)
+		_ = binary.Write(&buf, binary.LittleEndian, uint32(len(name)))
+		buf.WriteString(name)
+		_ = binary.Write(&buf, binary.LittleEndian, uint32(len(value)))
+		buf.Write(value)
+	}
+	buf.WriteByte(variantEnd)
+	return buf.Bytes()
+}
+
+// keyFile is the XML key file format of KeePass, version 1.0 holding the key in
+// base64 and version 2.0 in hex along with a hash prefix to catch typos
+type keyFile struct {
+	XMLName xml.Name `xml:"KeyFile"`
+	Version string   `xml:"Meta>Version"`
+	Data    struct {
+		Hash  string `xml:"Hash,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"Key>Data"`
+}
+
+// keyFileKey returns the 32 byte key a key file contributes to the composite key. XML key files
+// hold the key, a file of exactly 32 bytes or 64 hex digits is the key itself and any other
+// file is hashed.
+func keyFileKey(data []byte) ([]byte, error) {
+	var kf keyFile
+	if err := xml.Unmarshal(data, &kf); err == nil {
+		value := strings.Join(strings.Fields(kf.Data.Value), "")
+		switch {
+		case strings.HasPrefix(kf.Version, "1."):
+			key, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, errors.New("key file has invalid key data")
+			}
+			return key, nil
+		case strings.HasPrefix(kf.Version, "2."):
+			key, err := hex.DecodeString(value)
+			if err != nil || len(key) != 32 {
+				return nil, errors.New("key file has invalid key data")
+			}
+			sum := sha256.Sum256(key)
+			if kf.Data.Hash != "" && !strings.EqualFold(kf.Data.Hash, hex.EncodeToString(sum[:4])) {
+				return nil, errors.New("key file is corrupted, its key does not match its hash")
+			}
+			return key, nil
+		}
+	}
+
+	if len(data) == 32 {
+		return data, nil
+	}
+	if len(data) == 64 {
+		if key, err := hex.DecodeString(string(data)); err == nil {
+			return key, nil
+		}
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
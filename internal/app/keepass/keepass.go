@@ -0,0 +1,241 @@
+// Package keepass reads and writes KeePass KDBX 4 databases, as used by KeePass 2.35+
+// and KeePassXC, with AES-256 or ChaCha20 encryption and AES-KDF, Argon2d or Argon2id key derivation.
+package keepass
+
+import (
+	"crypto/sha256"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Keys of the standard entry strings
+const (
+	KeyTitle    = "Title"
+	KeyUserName = "UserName"
+	KeyPassword = "Password"
+	KeyURL      = "URL"
+	KeyNotes    = "Notes"
+)
+
+// Errors returned when a database cannot be opened
+var (
+	ErrNotKeePass       = errors.New("not a KeePass database")
+	ErrNoCredentials    = errors.New("a password or a key file is required")
+	ErrWrongCredentials = errors.New("wrong password or key file")
+)
+
+// Credentials make up the composite key of a database. An empty password is left out
+// of the key, like KeePassXC does, so a database may be locked by a key file alone.
+type Credentials struct {
+	Password string
+	KeyFile  []byte
+}
+
+// compositeKey hashes the password and key file into the key fed to the key derivation
+func (c Credentials) compositeKey() ([]byte, error) {
+	if c.Password == "" && len(c.KeyFile) == 0 {
+		return nil, ErrNoCredentials
+	}
+	h := sha256.New()
+	if c.Password != "" {
+		sum := sha256.Sum256([]byte(c.Password))
+		h.Write(sum[:])
+	}
+	if len(c.KeyFile) > 0 {
+		key, err := keyFileKey(c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		h.Write(key)
+	}
+	return h.Sum(nil), nil
+}
+
+// Database is the content of a KDBX file. Binaries holds the attachment contents
+// that entries refer to by index.
+type Database struct {
+	Meta     Meta
+	Root     Group
+	Binaries []Binary
+}
+
+// Meta holds the database wide settings kept in the XML document
+type Meta struct {
+	Generator         string `xml:"Generator"`
+	DatabaseName      string `xml:"DatabaseName"`
+	RecycleBinEnabled Bool   `xml:"RecycleBinEnabled"`
+	RecycleBinUUID    UUID   `xml:"RecycleBinUUID"`
+}
+
+// Group is a folder of entries and subgroups
+type Group struct {
+	UUID    UUID    `xml:"UUID"`
+	Name    string  `xml:"Name"`
+	Notes   string  `xml:"Notes"`
+	IconID  int     `xml:"IconID"`
+	Times   Times   `xml:"Times"`
+	Entries []Entry `xml:"Entry"`
+	Groups  []Group `xml:"Group"`
+}
+
+// Entry is a single record with its strings, attachments and previous versions
+type Entry struct {
+	UUID     UUID        `xml:"UUID"`
+	IconID   int         `xml:"IconID"`
+	Tags     string      `xml:"Tags"`
+	Times    Times       `xml:"Times"`
+	Strings  []String    `xml:"String"`
+	Binaries []BinaryRef `xml:"Binary"`
+	History  []Entry     `xml:"History>Entry"`
+}
+
+// Get returns the value of the string with the given key
+func (e Entry) Get(key string) string {
+	for _, s := range e.Strings {
+		if s.Key == key {
+			return s.Value.Content
+		}
+	}
+	return ""
+}
+
+// TagList splits the tags of the entry, KeePass separates them by semicolons and KeePassXC also by commas
+func (e Entry) TagList() []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(e.Tags, func(r rune) bool { return r == ';' || r == ',' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// String is a named value of an entry. Protected values are encrypted with the inner
+// stream in the file and are meant to be hidden from view.
+type String struct {
+	Key   string `xml:"Key"`
+	Value Value  `xml:"Value"`
+}
+
+// Value is the content of an entry string
+type Value struct {
+	Content   string `xml:",chardata"`
+	Protected Bool   `xml:"Protected,attr,omitempty"`
+}
+
+// BinaryRef attaches the binary at index Ref of the database to an entry under a file name
+type BinaryRef struct {
+	Key   string `xml:"Key"`
+	Value struct {
+		Ref int `xml:"Ref,attr"`
+	} `xml:"Value"`
+}
+
+// NewBinaryRef returns new BinaryRef instance
+func NewBinaryRef(name string, ref int) BinaryRef {
+	b := BinaryRef{Key: name}
+	b.Value.Ref = ref
+	return b
+}
+
+// Binary is the content of an attachment, kept in the inner header
+type Binary struct {
+	Protected bool
+	Data      []byte
+}
+
+// Times holds the timestamps of a group or an entry
+type Times struct {
+	CreationTime         Time `xml:"CreationTime"`
+	LastModificationTime Time `xml:"LastModificationTime"`
+	LastAccessTime       Time `xml:"LastAccessTime"`
+	ExpiryTime           Time `xml:"ExpiryTime"`
+	Expires              Bool `xml:"Expires"`
+	UsageCount           int  `xml:"UsageCount"`
+	LocationChanged      Time `xml:"LocationChanged"`
+}
+
+// NewTimes returns timestamps of something created at t that never expires
+func NewTimes(t time.Time) Times {
+	at := Time(t.UTC().Truncate(time.Second))
+	return Times{
+		CreationTime:         at,
+		LastModificationTime: at,
+		LastAccessTime:       at,
+		ExpiryTime:           at,
+		LocationChanged:      at,
+	}
+}
+
+// document is the XML layout of the database content
+type document struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    Meta     `xml:"Meta"`
+	Root    struct {
+		Group          Group    `xml:"Group"`
+		DeletedObjects struct{} `xml:"DeletedObjects"`
+	} `xml:"Root"`
+}
+
+// Parse decrypts and reads a KDBX 4 database
+func Parse(data []byte, credentials Credentials) (Database, error) {
+	compositeKey, err := credentials.compositeKey()
+	if err != nil {
+		return Database{}, err
+	}
+	h, body, err := readHeader(data)
+	if err != nil {
+		return Database{}, err
+	}
+	inner, err := h.decrypt(body, compositeKey)
+	if err != nil {
+		return Database{}, err
+	}
+	binaries, stream, content, err := readInnerHeader(inner)
+	if err != nil {
+		return Database{}, err
+	}
+	content, err = transformProtected(content, stream, false)
+	if err != nil {
+		return Database{}, err
+	}
+
+	var doc document
+	if err = xml.Unmarshal(content, &doc); err != nil {
+		return Database{}, fmt.Errorf("failed to read KeePass database: %w", err)
+	}
+	return Database{Meta: doc.Meta, Root: doc.Root.Group, Binaries: binaries}, nil
+}
+
+// Marshal encrypts a database into a KDBX 4 file with the given cipher and key derivation
+func Marshal(db Database, credentials Credentials, opts Options) ([]byte, error) {
+	compositeKey, err := credentials.compositeKey()
+	if err != nil {
+		return nil, err
+	}
+	stream, streamKey, err := newInnerStream()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := document{Meta: db.Meta}
+	doc.Root.Group = db.Root
+	content, err := xml.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write KeePass database: %w", err)
+	}
+	content, err = transformProtected(append([]byte(xml.Header), content...), stream, true)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := newHeader(opts)
+	if err != nil {
+		return nil, err
+	}
+	inner := writeInnerHeader(db.Binaries, streamKey)
+	return h.encrypt(append(inner, content...), compositeKey)
+}
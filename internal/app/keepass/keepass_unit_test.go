@@ -0,0 +1,226 @@
+//go:build unit
+
+package keepass
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKDFs are cheap settings of every key derivation
+var testKDFs = map[string]KDF{
+	"aes-kdf":  {Type: KDFTypeAES, Rounds: 1000},
+	"argon2d":  {Type: KDFTypeArgon2d, Iterations: 2, Memory: 64, Parallelism: 2},
+	"argon2id": {Type: KDFTypeArgon2id, Iterations: 2, Memory: 64, Parallelism: 2},
+}
+
+func testDatabase(t *testing.T) Database {
+	created := NewTimes(time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC))
+	entry := Entry{
+		UUID:  mustUUID(t),
+		Tags:  "work;code",
+		Times: created,
+		Strings: []String{
+			{Key: KeyTitle, Value: Value{Content: "GitHub"}},
+			{Key: KeyUserName, Value: Value{Content: "octocat"}},
+			{Key: KeyPassword, Value: Value{Content: "hunter2 & <friends>", Protected: true}},
+			{Key: "PIN", Value: Value{Content: "4242", Protected: true}},
+		},
+		Binaries: []BinaryRef{NewBinaryRef("recovery.txt", 0)},
+		History: []Entry{{
+			UUID:    mustUUID(t),
+			Times:   created,
+			Strings: []String{{Key: KeyPassword, Value: Value{Content: "hunter1", Protected: true}}},
+		}},
+	}
+	return Database{
+		Meta: Meta{Generator: "yubigo-pass", DatabaseName: "Passwords"},
+		Root: Group{
+			UUID:  mustUUID(t),
+			Name:  "Root",
+			Times: created,
+			Groups: []Group{{
+				UUID:    mustUUID(t),
+				Name:    "Work",
+				Times:   created,
+				Entries: []Entry{entry},
+			}},
+		},
+		Binaries: []Binary{{Protected: true, Data: []byte("1234-5678")}},
+	}
+}
+
+func mustUUID(t *testing.T) UUID {
+	u, err := NewUUID()
+	require.NoError(t, err)
+	return u
+}
+
+func TestMarshalShouldRoundTripWithEveryCipherAndKDF(t *testing.T) {
+	for _, c := range []Cipher{CipherAES256, CipherChaCha20} {
+		for name, kdf := range testKDFs {
+			t.Run(name, func(t *testing.T) {
+				// given
+				db := testDatabase(t)
+				credentials := Credentials{Password: "correct horse"}
+
+				// when
+				data, err := Marshal(db, credentials, Options{Cipher: c, KDF: kdf})
+				require.NoError(t, err)
+				parsed, err := Parse(data, credentials)
+
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, db, parsed)
+				assert.NotContains(t, string(data), "hunter2")
+			})
+		}
+	}
+}
+
+func TestParseShouldRejectWrongCredentials(t *testing.T) {
+	// given
+	data, err := Marshal(testDatabase(t), Credentials{Password: "correct horse"}, Options{KDF: testKDFs["aes-kdf"]})
+	require.NoError(t, err)
+
+	// when
+	_, err = Parse(data, Credentials{Password: "battery staple"})
+
+	// then
+	assert.ErrorIs(t, err, ErrWrongCredentials)
+}
+
+func TestParseShouldRejectTamperedContent(t *testing.T) {
+	// given
+	data, err := Marshal(testDatabase(t), Credentials{Password: "correct horse"}, Options{KDF: testKDFs["aes-kdf"]})
+	require.NoError(t, err)
+	data[len(data)-50] ^= 0xFF
+
+	// when
+	_, err = Parse(data, Credentials{Password: "correct horse"})
+
+	// then
+	assert.ErrorContains(t, err, "KeePass database content is corrupted")
+}
+
+func TestParseShouldRejectOtherFiles(t *testing.T) {
+	// when
+	_, err := Parse([]byte(`{"encrypted": false}`), Credentials{Password: "correct horse"})
+
+	// then
+	assert.ErrorIs(t, err, ErrNotKeePass)
+}
+
+func TestParseShouldRequireCredentials(t *testing.T) {
+	// when
+	_, err := Parse(nil, Credentials{})
+
+	// then
+	assert.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestParseShouldOpenDatabaseLockedWithKeyFile(t *testing.T) {
+	key := bytes.Repeat([]byte{0xA5}, 32)
+	sum := sha256.Sum256(key)
+	keyFiles := map[string][]byte{
+		"xml v2": []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<KeyFile>
+	<Meta><Version>2.0</Version></Meta>
+	<Key><Data Hash="` + hex.EncodeToString(sum[:4]) + `">` + strings.ToUpper(hex.EncodeToString(key)[:32]) + `
+		` + hex.EncodeToString(key)[32:] + `</Data></Key>
+</KeyFile>`),
+		"xml v1": []byte(`<KeyFile><Meta><Version>1.00</Version></Meta><Key><Data>` + base64.StdEncoding.EncodeToString(key) + `</Data></Key></KeyFile>`),
+		"binary": key,
+		"hex":    []byte(hex.EncodeToString(key)),
+	}
+	for name, keyFile := range keyFiles {
+		t.Run(name, func(t *testing.T) {
+			// given
+			db := testDatabase(t)
+			written, err := Marshal(db, Credentials{Password: "correct horse", KeyFile: key}, Options{KDF: testKDFs["aes-kdf"]})
+			require.NoError(t, err)
+
+			// when
+			parsed, err := Parse(written, Credentials{Password: "correct horse", KeyFile: keyFile})
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, db.Root, parsed.Root)
+			_, err = Parse(written, Credentials{Password: "correct horse"})
+			assert.ErrorIs(t, err, ErrWrongCredentials)
+		})
+	}
+}
+
+func TestParseShouldOpenDatabaseLockedWithHashedKeyFileOnly(t *testing.T) {
+	// given
+	keyFile := []byte("any file works as a key file")
+	db := testDatabase(t)
+	written, err := Marshal(db, Credentials{KeyFile: keyFile}, Options{KDF: testKDFs["aes-kdf"]})
+	require.NoError(t, err)
+
+	// when
+	parsed, err := Parse(written, Credentials{KeyFile: keyFile})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, db.Root, parsed.Root)
+}
+
+func TestKeyFileKeyShouldRejectCorruptedKeyFile(t *testing.T) {
+	// given
+	keyFile := `<KeyFile><Meta><Version>2.0</Version></Meta><Key><Data Hash="00000000">` +
+		strings.Repeat("A5", 32) + `</Data></Key></KeyFile>`
+
+	// when
+	_, err := keyFileKey([]byte(keyFile))
+
+	// then
+	assert.EqualError(t, err, "key file is corrupted, its key does not match its hash")
+}
+
+func TestTransformProtectedShouldEncryptValuesInDocumentOrder(t *testing.T) {
+	// given
+	doc := []byte(`<Root><Value Protected="True">first</Value><Value>plain</Value><Value Protected="True">second</Value></Root>`)
+	key := bytes.Repeat([]byte{1}, 64)
+	encryptStream, err := innerStream(key)
+	require.NoError(t, err)
+	decryptStream, err := innerStream(key)
+	require.NoError(t, err)
+
+	// when
+	encrypted, err := transformProtected(doc, encryptStream, true)
+	require.NoError(t, err)
+	decrypted, err := transformProtected(encrypted, decryptStream, false)
+
+	// then
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "first")
+	assert.Contains(t, string(encrypted), "<Value>plain</Value>")
+	assert.Equal(t, string(doc), string(decrypted))
+}
+
+func TestTimeShouldUseSecondsSinceYearOne(t *testing.T) {
+	// given
+	at := Time(time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC))
+
+	// when
+	text, err := at.MarshalText()
+	require.NoError(t, err)
+	var parsed, iso Time
+	require.NoError(t, parsed.UnmarshalText(text))
+	require.NoError(t, iso.UnmarshalText([]byte("2024-03-01T12:30:00Z")))
+
+	// then
+	assert.Equal(t, "SMFz3Q4AAAA=", string(text))
+	assert.Equal(t, at, parsed)
+	assert.Equal(t, at, iso)
+}
@@ -0,0 +1,175 @@
+package keepass
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Bool is an XML boolean written as True or False, as KeePass compares the values case sensitively
+type Bool bool
+
+// MarshalText implements encoding.TextMarshaler
+func (b Bool) MarshalText() ([]byte, error) {
+	if b {
+		return []byte("True"), nil
+	}
+	return []byte("False"), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (b *Bool) UnmarshalText(text []byte) error {
+	value := strings.TrimSpace(string(text))
+	*b = Bool(strings.EqualFold(value, "true") || value == "1")
+	return nil
+}
+
+// UUID identifies groups and entries, base64 encoded in the XML document
+type UUID [16]byte
+
+// NewUUID returns a random UUID
+func NewUUID() (UUID, error) {
+	var u UUID
+	if _, err := rand.Read(u[:]); err != nil {
+		return UUID{}, fmt.Errorf("failed to generate UUID: %w", err)
+	}
+	return u, nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(base64.StdEncoding.EncodeToString(u[:])), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (u *UUID) UnmarshalText(text []byte) error {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(text)))
+	if err != nil || (len(decoded) != len(u) && len(decoded) != 0) {
+		return fmt.Errorf("invalid UUID %q", text)
+	}
+	copy(u[:], decoded)
+	return nil
+}
+
+// unixEpoch is the count of seconds from year 1, the zero point of KDBX 4 timestamps, to 1970
+const unixEpoch = 62135596800
+
+// Time is a timestamp, written by KDBX 4 as the base64 encoded little endian count of
+// seconds since year 1. ISO 8601 timestamps of older files are read as well.
+type Time time.Time
+
+// MarshalText implements encoding.TextMarshaler
+func (t Time) MarshalText() ([]byte, error) {
+	seconds := time.Time(t).Unix() + unixEpoch
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(seconds))
+	return []byte(base64.StdEncoding.EncodeToString(buf[:])), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *Time) UnmarshalText(text []byte) error {
+	value := strings.TrimSpace(string(text))
+	if value == "" {
+		*t = Time{}
+		return nil
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(value); err == nil && len(decoded) == 8 {
+		seconds := int64(binary.LittleEndian.Uint64(decoded))
+		*t = Time(time.Unix(seconds-unixEpoch, 0).UTC())
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", value)
+	}
+	*t = Time(parsed.UTC())
+	return nil
+}
+
+// transformProtected runs the content of every protected value of the XML document through
+// the inner stream, in document order as the stream position depends on it. Encrypting turns
+// plaintext into base64 encoded ciphertext, decrypting does the opposite.
+func transformProtected(content []byte, stream cipher.Stream, encrypt bool) ([]byte, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	var out bytes.Buffer
+	encoder := xml.NewEncoder(&out)
+
+	var protected *bytes.Buffer
+	for {
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read KeePass database: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "Value" && isProtected(t) {
+				protected = &bytes.Buffer{}
+			}
+		case xml.CharData:
+			if protected != nil {
+				protected.Write(t)
+				continue
+			}
+		case xml.EndElement:
+			if protected != nil {
+				value, err := transformValue(protected.String(), stream, encrypt)
+				if err != nil {
+					return nil, err
+				}
+				protected = nil
+				if value != "" {
+					if err = encoder.EncodeToken(xml.CharData(value)); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		if err = encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return nil, fmt.Errorf("failed to write KeePass database: %w", err)
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// isProtected tells whether a value element is marked as protected
+func isProtected(element xml.StartElement) bool {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == "Protected" {
+			var b Bool
+			_ = b.UnmarshalText([]byte(attr.Value))
+			return bool(b)
+		}
+	}
+	return false
+}
+
+// transformValue encrypts or decrypts a single protected value
+func transformValue(value string, stream cipher.Stream, encrypt bool) (string, error) {
+	if encrypt {
+		data := []byte(value)
+		stream.XORKeyStream(data, data)
+		return base64.StdEncoding.EncodeToString(data), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return "", errors.New("failed to read KeePass database: protected value is not base64 encoded")
+	}
+	stream.XORKeyStream(data, data)
+	return string(data), nil
+}
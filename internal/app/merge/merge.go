@@ -0,0 +1,227 @@
+// Package merge merges the vault of another database file into a vault, for machines whose vaults
+// diverged with no sync set up. Entries are matched by ID, which copies of a database share, or else
+// by title and username. A matched entry changed on one side only since the base, a copy of the
+// vault from before the divergence such as a backup, takes that change; without a base, or when
+// both sides changed it, the entry is a conflict the user resolves. Attachments are not merged.
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/exporter"
+	"yubigo-pass/internal/app/importer"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnresolved is returned when applying a plan with conflicts left unresolved
+var ErrUnresolved = errors.New("merge has unresolved conflicts")
+
+// Version is an entry as stored in one of the merged vaults,
+// along with its username, the value of its type stored in the username column
+type Version struct {
+	ID       string
+	Username string
+	Entry    archive.Entry
+	Modified time.Time
+}
+
+// Name names the entry of a version by title and username, as reports list it
+func (v Version) Name() string {
+	if v.Username != "" {
+		return fmt.Sprintf("%s (%s)", v.Entry.Title, v.Username)
+	}
+	return v.Entry.Title
+}
+
+// Resolution is the version of a conflicting entry kept
+type Resolution int
+
+// Resolutions of a conflict
+const (
+	Unresolved Resolution = iota
+	KeepLocal
+	KeepOther
+	// KeepBoth keeps the local version and adds the other one as a copy
+	KeepBoth
+)
+
+// Conflict is an entry whose versions in the merged vaults differ, with the version kept
+type Conflict struct {
+	Local      Version
+	Other      Version
+	Resolution Resolution
+}
+
+// Plan is what merging a vault does. Added lists entries only the other vault holds and Updated
+// entries only the other vault changed, with the other version to store. Conflicts lists entries
+// whose versions differ otherwise and Unchanged counts the entries left as they are.
+type Plan struct {
+	Added     []Version
+	Updated   []Conflict
+	Conflicts []Conflict
+	Unchanged int
+}
+
+// Resolve resolves every conflict of the plan the same way
+func (p *Plan) Resolve(resolution Resolution) {
+	for i := range p.Conflicts {
+		p.Conflicts[i].Resolution = resolution
+	}
+}
+
+// Report tells what applying a plan did, naming the entries
+type Report struct {
+	Added   []string
+	Updated []string
+	Kept    []string
+	Copied  []string
+}
+
+// Build plans merging the other vault into the local one. The base, when given, is a copy of the
+// local vault from before the vaults diverged, telling which side changed an entry.
+func Build(local, other vault.Vault, base *vault.Vault) (Plan, error) {
+	locals, err := versions(local)
+	if err != nil {
+		return Plan{}, err
+	}
+	others, err := versions(other)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to read other vault: %w", err)
+	}
+	bases := map[string]Version{}
+	if base != nil {
+		baseVersions, err := versions(*base)
+		if err != nil {
+			return Plan{}, fmt.Errorf("failed to read base vault: %w", err)
+		}
+		for _, version := range baseVersions {
+			bases[version.ID] = version
+		}
+	}
+
+	byID := make(map[string]Version, len(locals))
+	byName := make(map[string]Version, len(locals))
+	for _, version := range locals {
+		byID[version.ID] = version
+		byName[key(version)] = version
+	}
+
+	var plan Plan
+	for _, theirs := range others {
+		ours, found := byID[theirs.ID]
+		if !found {
+			ours, found = byName[key(theirs)]
+		}
+		switch {
+		case !found:
+			plan.Added = append(plan.Added, theirs)
+		case reflect.DeepEqual(ours.Entry, theirs.Entry):
+			plan.Unchanged++
+		case ours.ID == theirs.ID && changedOnlyBy(bases, theirs, ours):
+			plan.Updated = append(plan.Updated, Conflict{Local: ours, Other: theirs, Resolution: KeepOther})
+		case ours.ID == theirs.ID && changedOnlyBy(bases, ours, theirs):
+			plan.Unchanged++
+		default:
+			plan.Conflicts = append(plan.Conflicts, Conflict{Local: ours, Other: theirs})
+		}
+	}
+	return plan, nil
+}
+
+// Apply stores the entries of the plan in the local vault in a single transaction, the whole merge
+// is rolled back when any entry fails
+func Apply(local vault.Vault, plan Plan) (Report, error) {
+	if slices.ContainsFunc(plan.Conflicts, func(c Conflict) bool { return c.Resolution == Unresolved }) {
+		return Report{}, ErrUnresolved
+	}
+
+	var report Report
+	now := time.Now()
+	err := local.Transaction(func(tx vault.Vault) error {
+		for _, added := range plan.Added {
+			err := put(tx, added.ID, added.Modified, added.Entry)
+			if errors.As(err, &model.PasswordIDTakenError{}) {
+				// the other vault may belong to another user of a copy of this database, whose entry keeps the ID
+				err = put(tx, uuid.New().String(), added.Modified, added.Entry)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to add %s: %w", added.Name(), err)
+			}
+			report.Added = append(report.Added, added.Name())
+		}
+		for _, conflict := range append(slices.Clone(plan.Updated), plan.Conflicts...) {
+			switch conflict.Resolution {
+			case KeepLocal:
+				report.Kept = append(report.Kept, conflict.Local.Name())
+			case KeepOther:
+				if err := put(tx, conflict.Local.ID, now, conflict.Other.Entry); err != nil {
+					return fmt.Errorf("failed to update %s: %w", conflict.Local.Name(), err)
+				}
+				report.Updated = append(report.Updated, conflict.Local.Name())
+			case KeepBoth:
+				copied := conflict.Other
+				copied.Entry.Title = fmt.Sprintf("%s (merged copy %s)", copied.Entry.Title, now.Format("2006-01-02 15:04"))
+				if err := put(tx, uuid.New().String(), now, copied.Entry); err != nil {
+					return fmt.Errorf("failed to copy %s: %w", conflict.Other.Name(), err)
+				}
+				report.Copied = append(report.Copied, copied.Name())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+// versions reveals every entry of a vault, ordered by title
+func versions(v vault.Vault) ([]Version, error) {
+	entries, err := v.Entries()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Version, 0, len(entries))
+	for _, entry := range entries {
+		details, err := v.Reveal(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Title, err)
+		}
+		result = append(result, Version{
+			ID:       entry.ID,
+			Username: entry.Username,
+			Entry:    exporter.ArchiveEntry(details),
+			Modified: time.UnixMilli(entry.ModifiedAt),
+		})
+	}
+	slices.SortFunc(result, func(a, b Version) int {
+		return strings.Compare(strings.ToLower(a.Entry.Title), strings.ToLower(b.Entry.Title))
+	})
+	return result, nil
+}
+
+// changedOnlyBy reports whether changed differs from the base version of the entry while unchanged
+// does not, telling the change was made on the side of changed only
+func changedOnlyBy(bases map[string]Version, changed, unchanged Version) bool {
+	base, found := bases[changed.ID]
+	return found && reflect.DeepEqual(base.Entry, unchanged.Entry) && !reflect.DeepEqual(base.Entry, changed.Entry)
+}
+
+// key identifies an entry by title and username, which are unique in a vault
+func key(version Version) string {
+	return version.Entry.Title + "\x00" + version.Username
+}
+
+// put stores a merged entry in the vault
+func put(v vault.Vault, id string, modified time.Time, entry archive.Entry) error {
+	record := importer.ArchiveRecord(entry)
+	return v.PutItem(id, modified, record.Item, record.Fields, record.Folder, record.Tags)
+}
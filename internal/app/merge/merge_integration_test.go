@@ -0,0 +1,166 @@
+//go:build integration
+
+package merge
+
+import (
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// putLogin stores a login under a known ID, as copies of a database share them
+func putLogin(t *testing.T, v vault.Vault, id, title, password string) {
+	t.Helper()
+	item := model.Item{Type: model.ItemTypeLogin, Title: title, Values: model.ItemValues{"username": "octocat", "password": password}}
+	require.NoError(t, v.PutItem(id, time.Now(), item, nil, "", nil))
+}
+
+func password(t *testing.T, v vault.Vault, title string) string {
+	t.Helper()
+	entry, err := v.FindEntry(title, "octocat")
+	require.NoError(t, err)
+	password, err := v.RevealPassword(entry)
+	require.NoError(t, err)
+	return password
+}
+
+func names(plan []Conflict) []string {
+	var result []string
+	for _, conflict := range plan {
+		result = append(result, conflict.Local.Name())
+	}
+	return result
+}
+
+func TestBuildShouldPlanAddedUnchangedAndConflictingEntries(t *testing.T) {
+	// given
+	local, other := vaulttest.New(t), vaulttest.New(t)
+	putLogin(t, local, "1", "GitHub", "hunter2")
+	putLogin(t, other, "1", "GitHub", "hunter2")
+	putLogin(t, local, "2", "GitLab", "mine")
+	putLogin(t, other, "2", "GitLab", "theirs")
+	putLogin(t, local, "3", "Bank", "same title")
+	putLogin(t, other, "4", "Bank", "other ID")
+	putLogin(t, other, "5", "Mail", "new")
+
+	// when
+	plan, err := Build(local, other, nil)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, plan.Added, 1)
+	assert.Equal(t, "Mail (octocat)", plan.Added[0].Name())
+	assert.Empty(t, plan.Updated)
+	assert.Equal(t, []string{"Bank (octocat)", "GitLab (octocat)"}, names(plan.Conflicts))
+	assert.Equal(t, "theirs", plan.Conflicts[1].Other.Entry.Values["password"])
+	assert.Equal(t, 1, plan.Unchanged)
+}
+
+func TestBuildShouldTakeChangesMadeOnOneSideSinceBase(t *testing.T) {
+	// given
+	local, other, base := vaulttest.New(t), vaulttest.New(t), vaulttest.New(t)
+	for _, v := range []vault.Vault{local, other, base} {
+		putLogin(t, v, "1", "GitHub", "original")
+		putLogin(t, v, "2", "GitLab", "original")
+		putLogin(t, v, "3", "Bank", "original")
+	}
+	putLogin(t, other, "1", "GitHub", "changed there")
+	putLogin(t, local, "2", "GitLab", "changed here")
+	putLogin(t, local, "3", "Bank", "changed here")
+	putLogin(t, other, "3", "Bank", "changed there")
+
+	// when
+	plan, err := Build(local, other, &base)
+
+	// then
+	require.NoError(t, err)
+	assert.Empty(t, plan.Added)
+	assert.Equal(t, []string{"GitHub (octocat)"}, names(plan.Updated))
+	assert.Equal(t, KeepOther, plan.Updated[0].Resolution)
+	assert.Equal(t, []string{"Bank (octocat)"}, names(plan.Conflicts))
+	assert.Equal(t, 1, plan.Unchanged)
+}
+
+func TestApplyShouldStoreResolvedVersions(t *testing.T) {
+	// given
+	local, other := vaulttest.New(t), vaulttest.New(t)
+	putLogin(t, local, "1", "GitHub", "mine")
+	putLogin(t, other, "1", "GitHub", "theirs")
+	putLogin(t, local, "2", "GitLab", "mine")
+	putLogin(t, other, "2", "GitLab", "theirs")
+	putLogin(t, local, "3", "Bank", "mine")
+	putLogin(t, other, "3", "Bank", "theirs")
+	putLogin(t, other, "4", "Mail", "new")
+	plan, err := Build(local, other, nil)
+	require.NoError(t, err)
+	plan.Conflicts[0].Resolution = KeepLocal // Bank
+	plan.Conflicts[1].Resolution = KeepOther // GitHub
+	plan.Conflicts[2].Resolution = KeepBoth  // GitLab
+
+	// when
+	report, err := Apply(local, plan)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Mail (octocat)"}, report.Added)
+	assert.Equal(t, []string{"GitHub (octocat)"}, report.Updated)
+	assert.Equal(t, []string{"Bank (octocat)"}, report.Kept)
+	require.Len(t, report.Copied, 1)
+	assert.Equal(t, "new", password(t, local, "Mail"))
+	assert.Equal(t, "theirs", password(t, local, "GitHub"))
+	assert.Equal(t, "mine", password(t, local, "Bank"))
+	assert.Equal(t, "mine", password(t, local, "GitLab"))
+	entries, err := local.Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 5)
+
+	// and merging again changes nothing
+	plan, err = Build(local, other, nil)
+	require.NoError(t, err)
+	assert.Empty(t, plan.Added)
+	assert.Equal(t, []string{"Bank (octocat)", "GitLab (octocat)"}, names(plan.Conflicts))
+}
+
+func TestApplyShouldRollBackWholeMergeOnFailure(t *testing.T) {
+	// given
+	local, other := vaulttest.New(t), vaulttest.New(t)
+	putLogin(t, local, "1", "GitHub", "mine")
+	putLogin(t, local, "2", "GitLab", "mine")
+	putLogin(t, other, "1", "GitLab", "renamed there")
+	putLogin(t, other, "3", "Mail", "new")
+	plan, err := Build(local, other, nil)
+	require.NoError(t, err)
+	plan.Resolve(KeepOther)
+
+	// when
+	_, err = Apply(local, plan)
+
+	// then
+	require.Error(t, err)
+	var duplicate model.PasswordAlreadyExistsError
+	assert.ErrorAs(t, err, &duplicate)
+	entries, err := local.Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "mine", password(t, local, "GitHub"))
+}
+
+func TestApplyShouldRejectUnresolvedConflicts(t *testing.T) {
+	// given
+	local, other := vaulttest.New(t), vaulttest.New(t)
+	putLogin(t, local, "1", "GitHub", "mine")
+	putLogin(t, other, "1", "GitHub", "theirs")
+	plan, err := Build(local, other, nil)
+	require.NoError(t, err)
+
+	// when
+	_, err = Apply(local, plan)
+
+	// then
+	assert.ErrorIs(t, err, ErrUnresolved)
+}
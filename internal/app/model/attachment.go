@@ -0,0 +1,23 @@
+package model
+
+// Attachment is the model of a file attached to a password. Name is stored encrypted and
+// Size is the plaintext size in bytes. The encrypted content lives outside the DB in a blob
+// named by the SHA-256 of its ciphertext.
+type Attachment struct {
+	ID         string `db:"id"`
+	PasswordID string `db:"password_id"`
+	Name       string `db:"name"`
+	Size       int64  `db:"size"`
+	Blob       string `db:"blob"`
+}
+
+// NewAttachment returns new Attachment instance
+func NewAttachment(id, passwordID, name string, size int64, blob string) Attachment {
+	return Attachment{
+		ID:         id,
+		PasswordID: passwordID,
+		Name:       name,
+		Size:       size,
+		Blob:       blob,
+	}
+}
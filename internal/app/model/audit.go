@@ -0,0 +1,75 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// AuditEvent is what happened to a secret of a vault
+type AuditEvent string
+
+// Audit events. Entries are read when their secret or one-time code is shown, copied when they leave
+// the vault through a share or an extracted attachment, created and changed when stored, exported
+// when the vault is written to a file and imported when entries of a file, a share, another vault
+// or another device are stored.
+const (
+	AuditRead     AuditEvent = "read"
+	AuditCopied   AuditEvent = "copied"
+	AuditCreated  AuditEvent = "created"
+	AuditChanged  AuditEvent = "changed"
+	AuditExported AuditEvent = "exported"
+	AuditImported AuditEvent = "imported"
+)
+
+// AuditEvents lists every audit event
+var AuditEvents = []AuditEvent{AuditRead, AuditCopied, AuditCreated, AuditChanged, AuditExported, AuditImported}
+
+// ParseAuditEvent returns the audit event with the given name
+func ParseAuditEvent(name string) (AuditEvent, error) {
+	event := AuditEvent(strings.TrimSpace(name))
+	if !slices.Contains(AuditEvents, event) {
+		return "", fmt.Errorf("unknown audit event %s", name)
+	}
+	return event, nil
+}
+
+// AuditClient is the interface an audit event happened through
+type AuditClient string
+
+// Audit clients
+const (
+	ClientTUI   AuditClient = "tui"
+	ClientCLI   AuditClient = "cli"
+	ClientAgent AuditClient = "agent"
+)
+
+// AuditClients lists every audit client
+var AuditClients = []AuditClient{ClientTUI, ClientCLI, ClientAgent}
+
+// ParseAuditClient returns the audit client with the given name
+func ParseAuditClient(name string) (AuditClient, error) {
+	client := AuditClient(strings.TrimSpace(name))
+	if !slices.Contains(AuditClients, client) {
+		return "", fmt.Errorf("unknown audit client %s", name)
+	}
+	return client, nil
+}
+
+// AuditRecord is a record of the append-only audit log. Data is the event sealed with the key of the
+// vault of UserID, CreatedAt is when it happened in Unix milliseconds. Every record holds the hash of
+// the previous one, the first one an empty PrevHash, so removing records breaks the chain. Hash is a
+// MAC of ChainData under the key of the vault, so altered records cannot be hashed again without it.
+type AuditRecord struct {
+	Seq       int64  `db:"seq"`
+	UserID    string `db:"user_id"`
+	CreatedAt int64  `db:"created_at"`
+	Data      string `db:"data"`
+	PrevHash  string `db:"prev_hash"`
+	Hash      string `db:"hash"`
+}
+
+// ChainData returns what Hash covers, the record chained to PrevHash at its place Seq in the log
+func (r AuditRecord) ChainData() []byte {
+	return []byte(fmt.Sprintf("%s\n%d\n%s\n%d\n%s", r.PrevHash, r.Seq, r.UserID, r.CreatedAt, r.Data))
+}
@@ -0,0 +1,47 @@
+//go:build unit
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRecordChainDataCoversEveryField(t *testing.T) {
+	// given
+	record := AuditRecord{Seq: 2, UserID: "alice", CreatedAt: 1700000000000, Data: "sealed", PrevHash: "previous"}
+	changed := []AuditRecord{
+		{Seq: 3, UserID: record.UserID, CreatedAt: record.CreatedAt, Data: record.Data, PrevHash: record.PrevHash},
+		{Seq: record.Seq, UserID: "bob", CreatedAt: record.CreatedAt, Data: record.Data, PrevHash: record.PrevHash},
+		{Seq: record.Seq, UserID: record.UserID, CreatedAt: record.CreatedAt + 1, Data: record.Data, PrevHash: record.PrevHash},
+		{Seq: record.Seq, UserID: record.UserID, CreatedAt: record.CreatedAt, Data: "resealed", PrevHash: record.PrevHash},
+		{Seq: record.Seq, UserID: record.UserID, CreatedAt: record.CreatedAt, Data: record.Data, PrevHash: ""},
+	}
+
+	// when
+	data := record.ChainData()
+
+	// then
+	assert.Equal(t, data, record.ChainData())
+	for _, other := range changed {
+		assert.NotEqual(t, data, other.ChainData())
+	}
+}
+
+func TestParseAuditEventAndClient(t *testing.T) {
+	// when
+	event, err := ParseAuditEvent("exported")
+	_, unknownEventErr := ParseAuditEvent("deleted")
+	client, clientErr := ParseAuditClient("cli")
+	_, unknownClientErr := ParseAuditClient("web")
+
+	// then
+	require.NoError(t, err)
+	require.NoError(t, clientErr)
+	assert.Equal(t, AuditExported, event)
+	assert.Equal(t, ClientCLI, client)
+	assert.EqualError(t, unknownEventErr, "unknown audit event deleted")
+	assert.EqualError(t, unknownClientErr, "unknown audit client web")
+}
@@ -0,0 +1,100 @@
+package model
+
+import "fmt"
+
+// Role is the access a member has to a shared collection
+type Role string
+
+// Supported membership roles, each allowing what the previous one does
+const (
+	RoleRead  Role = "read"
+	RoleWrite Role = "write"
+	RoleAdmin Role = "admin"
+)
+
+// Roles lists the membership roles in the order they are cycled through in the UI
+var Roles = []Role{RoleRead, RoleWrite, RoleAdmin}
+
+// ParseRole returns the role named s
+func ParseRole(s string) (Role, error) {
+	for _, role := range Roles {
+		if string(role) == s {
+			return role, nil
+		}
+	}
+	return "", fmt.Errorf("unknown role %q, expected one of: read, write, admin", s)
+}
+
+// Next returns the role following r in Roles
+func (r Role) Next() Role {
+	for i, role := range Roles {
+		if role == r {
+			return Roles[(i+1)%len(Roles)]
+		}
+	}
+	return RoleRead
+}
+
+// CanWrite reports whether members with the role may add and edit entries
+func (r Role) CanWrite() bool {
+	return r == RoleWrite || r == RoleAdmin
+}
+
+// CanAdmin reports whether members with the role may invite other users
+func (r Role) CanAdmin() bool {
+	return r == RoleAdmin
+}
+
+// UserKey is the X25519 key pair of a user, collection keys are sealed to its public key.
+// PrivateKey is stored encrypted with the vault key of the user.
+type UserKey struct {
+	UserID     string `db:"user_id"`
+	PublicKey  []byte `db:"public_key"`
+	PrivateKey []byte `db:"private_key"`
+}
+
+// NewUserKey returns new UserKey instance
+func NewUserKey(userID string, publicKey, privateKey []byte) UserKey {
+	return UserKey{
+		UserID:     userID,
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+	}
+}
+
+// Collection is the model of a collection of entries shared between users.
+// Name is stored encrypted with the collection key.
+type Collection struct {
+	ID   string `db:"id"`
+	Name string `db:"name"`
+}
+
+// NewCollection returns new Collection instance
+func NewCollection(id, name string) Collection {
+	return Collection{
+		ID:   id,
+		Name: name,
+	}
+}
+
+// CollectionMember is the membership of a user in a collection. SealedKey is the collection key
+// sealed to the public key of the user, Username and Name, the name of the collection, are read
+// along with it and not stored.
+type CollectionMember struct {
+	CollectionID string `db:"collection_id"`
+	UserID       string `db:"user_id"`
+	Role         Role   `db:"role"`
+	SealedKey    []byte `db:"sealed_key"`
+	Username     string `db:"username"`
+	Name         string `db:"name"`
+}
+
+// NewCollectionMember returns new CollectionMember instance
+func NewCollectionMember(collectionID, userID string, role Role, sealedKey []byte) CollectionMember {
+	return CollectionMember{
+		CollectionID: collectionID,
+		UserID:       userID,
+		Role:         role,
+		SealedKey:    sealedKey,
+	}
+}
@@ -0,0 +1,47 @@
+//go:build unit
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRolePermissions(t *testing.T) {
+	testCases := []struct {
+		role     Role
+		canWrite bool
+		canAdmin bool
+	}{
+		{role: RoleRead},
+		{role: RoleWrite, canWrite: true},
+		{role: RoleAdmin, canWrite: true, canAdmin: true},
+		{role: "owner"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.role), func(t *testing.T) {
+			assert.Equal(t, tc.canWrite, tc.role.CanWrite())
+			assert.Equal(t, tc.canAdmin, tc.role.CanAdmin())
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	// when
+	role, err := ParseRole("write")
+	_, unknownErr := ParseRole("owner")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, RoleWrite, role)
+	assert.ErrorContains(t, unknownErr, `unknown role "owner"`)
+}
+
+func TestRoleNextShouldCycleThroughRoles(t *testing.T) {
+	assert.Equal(t, RoleWrite, RoleRead.Next())
+	assert.Equal(t, RoleAdmin, RoleWrite.Next())
+	assert.Equal(t, RoleRead, RoleAdmin.Next())
+}
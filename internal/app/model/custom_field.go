@@ -0,0 +1,80 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// FieldType is the type of custom field value
+type FieldType string
+
+// Supported custom field types
+const (
+	FieldTypeText    FieldType = "text"
+	FieldTypeHidden  FieldType = "hidden"
+	FieldTypeBoolean FieldType = "boolean"
+	FieldTypeURL     FieldType = "url"
+)
+
+// FieldTypes lists the supported custom field types in the order they are cycled through in the UI
+var FieldTypes = []FieldType{FieldTypeText, FieldTypeHidden, FieldTypeBoolean, FieldTypeURL}
+
+// Next returns the field type following t in FieldTypes
+func (t FieldType) Next() FieldType {
+	for i, ft := range FieldTypes {
+		if ft == t {
+			return FieldTypes[(i+1)%len(FieldTypes)]
+		}
+	}
+	return FieldTypeText
+}
+
+// CustomField is the model of an extra field on a password entry, e.g. a recovery code or a PIN.
+// Name and Value are stored encrypted.
+type CustomField struct {
+	PasswordID string    `db:"password_id"`
+	Position   int       `db:"position"`
+	Type       FieldType `db:"type"`
+	Name       string    `db:"name"`
+	Value      string    `db:"value"`
+}
+
+// NewCustomField returns new CustomField instance
+func NewCustomField(passwordID string, position int, fieldType FieldType, name, value string) CustomField {
+	return CustomField{
+		PasswordID: passwordID,
+		Position:   position,
+		Type:       fieldType,
+		Name:       name,
+		Value:      value,
+	}
+}
+
+// Validate checks that the plaintext field has a name and a value matching its type
+func (f CustomField) Validate() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return fmt.Errorf("custom field name cannot be empty")
+	}
+
+	switch f.Type {
+	case FieldTypeText, FieldTypeHidden:
+		return nil
+	case FieldTypeBoolean:
+		if f.Value != "true" && f.Value != "false" {
+			return fmt.Errorf("custom field %s must be true or false", f.Name)
+		}
+		return nil
+	case FieldTypeURL:
+		if f.Value == "" {
+			return nil
+		}
+		u, err := url.Parse(f.Value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("custom field %s must be a valid URL", f.Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("custom field %s has unknown type %s", f.Name, f.Type)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build unit
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomFieldValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		field       CustomField
+		expectedErr string
+	}{
+		{name: "text", field: CustomField{Type: FieldTypeText, Name: "API key ID", Value: "abc"}},
+		{name: "hidden", field: CustomField{Type: FieldTypeHidden, Name: "PIN", Value: "1234"}},
+		{name: "boolean true", field: CustomField{Type: FieldTypeBoolean, Name: "MFA", Value: "true"}},
+		{name: "boolean false", field: CustomField{Type: FieldTypeBoolean, Name: "MFA", Value: "false"}},
+		{name: "url", field: CustomField{Type: FieldTypeURL, Name: "Console", Value: "https://example.com/login"}},
+		{name: "empty url", field: CustomField{Type: FieldTypeURL, Name: "Console", Value: ""}},
+		{
+			name:        "empty name",
+			field:       CustomField{Type: FieldTypeText, Name: "  ", Value: "abc"},
+			expectedErr: "custom field name cannot be empty",
+		},
+		{
+			name:        "invalid boolean",
+			field:       CustomField{Type: FieldTypeBoolean, Name: "MFA", Value: "yes"},
+			expectedErr: "custom field MFA must be true or false",
+		},
+		{
+			name:        "invalid url",
+			field:       CustomField{Type: FieldTypeURL, Name: "Console", Value: "example"},
+			expectedErr: "custom field Console must be a valid URL",
+		},
+		{
+			name:        "unknown type",
+			field:       CustomField{Type: "date", Name: "Expiry", Value: "2030"},
+			expectedErr: "custom field Expiry has unknown type date",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			err := tc.field.Validate()
+
+			// then
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestFieldTypeNextShouldCycle(t *testing.T) {
+	// given
+	fieldType := FieldTypeText
+	var visited []FieldType
+
+	// when
+	for range FieldTypes {
+		visited = append(visited, fieldType)
+		fieldType = fieldType.Next()
+	}
+
+	// then
+	assert.Equal(t, FieldTypes, visited)
+	assert.Equal(t, FieldTypeText, fieldType)
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// EmergencyStatus is the state of the emergency access a user gave another one to their vault
+type EmergencyStatus string
+
+// Emergency access states. Designated contacts start invited, requesting access starts the waiting
+// period after which access is granted unless the owner rejects the request first.
+const (
+	EmergencyInvited   EmergencyStatus = "invited"
+	EmergencyRequested EmergencyStatus = "requested"
+	EmergencyApproved  EmergencyStatus = "approved"
+	EmergencyRejected  EmergencyStatus = "rejected"
+)
+
+// EmergencyAccess is the emergency access a user, the owner, gave another one, the grantee, to their
+// vault. SealedKey is the vault key of the owner sealed to the public key of the grantee once access is
+// granted, empty before. RequestedAt is when the grantee last requested access in Unix milliseconds, 0
+// when there is no request. OwnerName and GranteeName are read along with it and not stored.
+type EmergencyAccess struct {
+	OwnerID     string          `db:"owner_id"`
+	GranteeID   string          `db:"grantee_id"`
+	Status      EmergencyStatus `db:"status"`
+	WaitDays    int             `db:"wait_days"`
+	RequestedAt int64           `db:"requested_at"`
+	SealedKey   []byte          `db:"sealed_key"`
+	OwnerName   string          `db:"owner_name"`
+	GranteeName string          `db:"grantee_name"`
+}
+
+// NewEmergencyAccess returns new EmergencyAccess instance of a designated contact
+func NewEmergencyAccess(ownerID, granteeID string, waitDays int) EmergencyAccess {
+	return EmergencyAccess{
+		OwnerID:   ownerID,
+		GranteeID: granteeID,
+		Status:    EmergencyInvited,
+		WaitDays:  waitDays,
+	}
+}
+
+// GrantedAt returns when a pending request is granted unless the owner rejects it
+func (a EmergencyAccess) GrantedAt() time.Time {
+	return time.UnixMilli(a.RequestedAt).AddDate(0, 0, a.WaitDays)
+}
+
+// Granted reports whether access is granted at now, after the owner approved the request or the waiting
+// period passed without the owner rejecting it. The grantee opens the vault once the key is released too.
+func (a EmergencyAccess) Granted(now time.Time) bool {
+	switch a.Status {
+	case EmergencyApproved:
+		return true
+	case EmergencyRequested:
+		return !now.Before(a.GrantedAt())
+	default:
+		return false
+	}
+}
+
+// Released reports whether the vault key of the owner was sealed to the grantee, which happens when the
+// owner approves the request or unlocks their vault after the waiting period passed
+func (a EmergencyAccess) Released() bool {
+	return len(a.SealedKey) > 0
+}
+
+// Request starts the waiting period at now, for contacts who have not requested access yet or whose
+// last request was rejected
+func (a EmergencyAccess) Request(now time.Time) (EmergencyAccess, error) {
+	switch a.Status {
+	case EmergencyInvited, EmergencyRejected:
+		a.Status = EmergencyRequested
+		a.RequestedAt = now.UnixMilli()
+		return a, nil
+	case EmergencyRequested:
+		return a, errors.New("access was already requested")
+	default:
+		return a, errors.New("access was already approved")
+	}
+}
+
+// Approve grants a pending request before the waiting period ends
+func (a EmergencyAccess) Approve() (EmergencyAccess, error) {
+	if a.Status != EmergencyRequested {
+		return a, errors.New("there is no request to approve")
+	}
+	a.Status = EmergencyApproved
+	return a, nil
+}
+
+// Reject turns down a pending request, or takes back access granted already
+func (a EmergencyAccess) Reject() (EmergencyAccess, error) {
+	if a.Status != EmergencyRequested && a.Status != EmergencyApproved {
+		return a, errors.New("there is no request to reject")
+	}
+	a.Status = EmergencyRejected
+	a.RequestedAt = 0
+	a.SealedKey = nil
+	return a, nil
+}
@@ -0,0 +1,83 @@
+//go:build unit
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmergencyAccessShouldBeGrantedAfterWaitingPeriod(t *testing.T) {
+	// given
+	requested := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	access, err := NewEmergencyAccess("owner", "grantee", 7).Request(requested)
+	require.NoError(t, err)
+
+	// when
+	beforeWait := access.Granted(requested.AddDate(0, 0, 7).Add(-time.Minute))
+	afterWait := access.Granted(requested.AddDate(0, 0, 7))
+
+	// then
+	assert.Equal(t, EmergencyRequested, access.Status)
+	assert.False(t, beforeWait)
+	assert.True(t, afterWait)
+	assert.True(t, access.GrantedAt().Equal(requested.AddDate(0, 0, 7)))
+}
+
+func TestEmergencyAccessTransitions(t *testing.T) {
+	now := time.Now()
+	invited := NewEmergencyAccess("owner", "grantee", 3)
+	requested, _ := invited.Request(now)
+	approved, _ := requested.Approve()
+	rejected, _ := requested.Reject()
+
+	testCases := []struct {
+		name       string
+		transition func() (EmergencyAccess, error)
+		expected   EmergencyStatus
+		err        string
+	}{
+		{name: "request invited", transition: func() (EmergencyAccess, error) { return invited.Request(now) }, expected: EmergencyRequested},
+		{name: "request rejected", transition: func() (EmergencyAccess, error) { return rejected.Request(now) }, expected: EmergencyRequested},
+		{name: "request twice", transition: func() (EmergencyAccess, error) { return requested.Request(now) }, err: "already requested"},
+		{name: "request approved", transition: func() (EmergencyAccess, error) { return approved.Request(now) }, err: "already approved"},
+		{name: "approve requested", transition: requested.Approve, expected: EmergencyApproved},
+		{name: "approve invited", transition: invited.Approve, err: "no request to approve"},
+		{name: "reject requested", transition: requested.Reject, expected: EmergencyRejected},
+		{name: "reject approved", transition: approved.Reject, expected: EmergencyRejected},
+		{name: "reject invited", transition: invited.Reject, err: "no request to reject"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			access, err := tc.transition()
+
+			// then
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, access.Status)
+		})
+	}
+}
+
+func TestRejectedEmergencyAccessShouldNotBeGranted(t *testing.T) {
+	// given
+	requested, err := NewEmergencyAccess("owner", "grantee", 0).Request(time.Now().AddDate(0, 0, -1))
+	require.NoError(t, err)
+
+	// when
+	rejected, err := requested.Reject()
+
+	// then
+	require.NoError(t, err)
+	assert.True(t, requested.Granted(time.Now()))
+	assert.False(t, rejected.Granted(time.Now()))
+	assert.Zero(t, rejected.RequestedAt)
+}
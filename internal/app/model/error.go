@@ -1,6 +1,9 @@
 package model
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // UserAlreadyExistsError is an error if user already exists in db
 type UserAlreadyExistsError struct {
@@ -69,3 +72,164 @@ func NewPasswordAlreadyExistsError(userID, title, username string) PasswordAlrea
 func (e PasswordAlreadyExistsError) Error() string {
 	return fmt.Sprintf("password already exists for user %s, title %s, username %s", e.UserID, e.Title, e.Username)
 }
+
+// PasswordIDTakenError is an error if another password, possibly of another user, has the ID in db
+type PasswordIDTakenError struct {
+	ID string
+}
+
+// NewPasswordIDTakenError returns new PasswordIDTakenError instance
+func NewPasswordIDTakenError(id string) PasswordIDTakenError {
+	return PasswordIDTakenError{ID: id}
+}
+
+func (e PasswordIDTakenError) Error() string {
+	return fmt.Sprintf("password ID %s is already taken", e.ID)
+}
+
+// AttachmentNotFoundError is an error if attachment is not found in db
+type AttachmentNotFoundError struct {
+	Name string
+}
+
+// NewAttachmentNotFoundError returns new AttachmentNotFoundError instance
+func NewAttachmentNotFoundError(name string) AttachmentNotFoundError {
+	return AttachmentNotFoundError{Name: name}
+}
+
+func (e AttachmentNotFoundError) Error() string {
+	return fmt.Sprintf("attachment not found: %s", e.Name)
+}
+
+// AttachmentTooLargeError is an error if a file exceeds the attachment size limit
+type AttachmentTooLargeError struct {
+	Name  string
+	Limit int64
+}
+
+// NewAttachmentTooLargeError returns new AttachmentTooLargeError instance
+func NewAttachmentTooLargeError(name string, limit int64) AttachmentTooLargeError {
+	return AttachmentTooLargeError{
+		Name:  name,
+		Limit: limit,
+	}
+}
+
+func (e AttachmentTooLargeError) Error() string {
+	return fmt.Sprintf("attachment %s exceeds the size limit of %d bytes", e.Name, e.Limit)
+}
+
+// UserKeyNotFoundError is an error if a user has no key pair in db
+type UserKeyNotFoundError struct {
+	UserID string
+}
+
+// NewUserKeyNotFoundError returns new UserKeyNotFoundError instance
+func NewUserKeyNotFoundError(userID string) UserKeyNotFoundError {
+	return UserKeyNotFoundError{UserID: userID}
+}
+
+func (e UserKeyNotFoundError) Error() string {
+	return fmt.Sprintf("key pair not found for user %s", e.UserID)
+}
+
+// MemberAlreadyExistsError is an error if a user is already a member of a collection
+type MemberAlreadyExistsError struct {
+	CollectionID string
+	UserID       string
+}
+
+// NewMemberAlreadyExistsError returns new MemberAlreadyExistsError instance
+func NewMemberAlreadyExistsError(collectionID, userID string) MemberAlreadyExistsError {
+	return MemberAlreadyExistsError{
+		CollectionID: collectionID,
+		UserID:       userID,
+	}
+}
+
+func (e MemberAlreadyExistsError) Error() string {
+	return fmt.Sprintf("user %s is already a member of collection %s", e.UserID, e.CollectionID)
+}
+
+// EmergencyAccessNotFoundError is an error if a user gave another one no emergency access to their vault
+type EmergencyAccessNotFoundError struct {
+	OwnerID   string
+	GranteeID string
+}
+
+// NewEmergencyAccessNotFoundError returns new EmergencyAccessNotFoundError instance
+func NewEmergencyAccessNotFoundError(ownerID, granteeID string) EmergencyAccessNotFoundError {
+	return EmergencyAccessNotFoundError{
+		OwnerID:   ownerID,
+		GranteeID: granteeID,
+	}
+}
+
+func (e EmergencyAccessNotFoundError) Error() string {
+	return fmt.Sprintf("user %s gave user %s no emergency access", e.OwnerID, e.GranteeID)
+}
+
+// EmergencyAccessAlreadyExistsError is an error if a user already designated another one as emergency contact
+type EmergencyAccessAlreadyExistsError struct {
+	OwnerID   string
+	GranteeID string
+}
+
+// NewEmergencyAccessAlreadyExistsError returns new EmergencyAccessAlreadyExistsError instance
+func NewEmergencyAccessAlreadyExistsError(ownerID, granteeID string) EmergencyAccessAlreadyExistsError {
+	return EmergencyAccessAlreadyExistsError{
+		OwnerID:   ownerID,
+		GranteeID: granteeID,
+	}
+}
+
+func (e EmergencyAccessAlreadyExistsError) Error() string {
+	return fmt.Sprintf("user %s is already an emergency contact of user %s", e.GranteeID, e.OwnerID)
+}
+
+// AuditChainBrokenError is an error if the audit log was tampered with from record Seq on
+type AuditChainBrokenError struct {
+	Seq int64
+}
+
+// NewAuditChainBrokenError returns new AuditChainBrokenError instance
+func NewAuditChainBrokenError(seq int64) AuditChainBrokenError {
+	return AuditChainBrokenError{Seq: seq}
+}
+
+func (e AuditChainBrokenError) Error() string {
+	return fmt.Sprintf("audit log was tampered with at record %d", e.Seq)
+}
+
+// LoginThrottledError is an error if logins under a username are throttled after failed attempts
+type LoginThrottledError struct {
+	RetryAt time.Time
+}
+
+// NewLoginThrottledError returns new LoginThrottledError instance
+func NewLoginThrottledError(retryAt time.Time) LoginThrottledError {
+	return LoginThrottledError{RetryAt: retryAt}
+}
+
+func (e LoginThrottledError) Error() string {
+	wait := max(time.Until(e.RetryAt).Round(time.Second), time.Second)
+	return fmt.Sprintf("too many failed login attempts, try again in %s", wait)
+}
+
+// GeneratorProfileNotFoundError is an error if a user saved no password generator profile under a name
+type GeneratorProfileNotFoundError struct {
+	UserID string
+	Name   string
+}
+
+// NewGeneratorProfileNotFoundError returns new GeneratorProfileNotFoundError instance
+func NewGeneratorProfileNotFoundError(userID, name string) GeneratorProfileNotFoundError {
+	return GeneratorProfileNotFoundError{
+		UserID: userID,
+		Name:   name,
+	}
+}
+
+func (e GeneratorProfileNotFoundError) Error() string {
+	return fmt.Sprintf("generator profile not found: %s", e.Name)
+}
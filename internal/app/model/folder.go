@@ -0,0 +1,20 @@
+package model
+
+// Folder is the model of the folder. Name is stored encrypted,
+// ParentID is empty for top-level folders.
+type Folder struct {
+	ID       string `db:"id"`
+	UserID   string `db:"user_id"`
+	ParentID string `db:"parent_id"`
+	Name     string `db:"name"`
+}
+
+// NewFolder returns new Folder instance
+func NewFolder(id, userID, parentID, name string) Folder {
+	return Folder{
+		ID:       id,
+		UserID:   userID,
+		ParentID: parentID,
+		Name:     name,
+	}
+}
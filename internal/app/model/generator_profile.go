@@ -0,0 +1,115 @@
+package model
+
+// DefaultGeneratorProfileName is the name of the profile passwords are generated with until the user activates another
+const DefaultGeneratorProfileName = "default"
+
+// GeneratorMode is the kind of password a policy generates
+type GeneratorMode string
+
+// Generator modes, an empty mode stands for GeneratorModeCharacters
+const (
+	GeneratorModeCharacters GeneratorMode = "characters"
+	GeneratorModePassphrase GeneratorMode = "passphrase"
+)
+
+// Capitalization is how the words of a passphrase are capitalized
+type Capitalization string
+
+// Capitalizations of passphrase words, an empty one stands for CapitalizeNone
+const (
+	CapitalizeNone   Capitalization = "none"
+	CapitalizeFirst  Capitalization = "first"
+	CapitalizeAll    Capitalization = "all"
+	CapitalizeRandom Capitalization = "random"
+)
+
+// Capitalizations lists the capitalizations in the order they are cycled through
+var Capitalizations = []Capitalization{CapitalizeNone, CapitalizeFirst, CapitalizeAll, CapitalizeRandom}
+
+// Embedded wordlists passphrases are drawn from
+const (
+	WordlistEFFLarge = "eff-large"
+	WordlistEFFShort = "eff-short"
+)
+
+// Wordlists lists the names of the embedded wordlists, the default one first
+var Wordlists = []string{WordlistEFFLarge, WordlistEFFShort}
+
+// PasswordPolicy describes the passwords the generator creates. Each enabled character class is
+// used at least its minimum count of times, minimums of disabled classes are ignored. An empty
+// SymbolSet stands for the default symbols. ExcludeAmbiguous leaves out characters that are easily
+// mistaken for one another, such as l, 1 and I, and NoRepeat uses every character at most once.
+//
+// In passphrase mode the policy instead draws Words words from Wordlist, capitalizes them and joins
+// them with Separator, then appends a digit and a symbol of the symbol set when asked to. The
+// character class settings are ignored, except for SymbolSet and ExcludeAmbiguous choosing the symbol.
+type PasswordPolicy struct {
+	Mode             GeneratorMode  `db:"mode"`
+	Length           int            `db:"length"`
+	Lower            bool           `db:"lower"`
+	Upper            bool           `db:"upper"`
+	Digits           bool           `db:"digits"`
+	Symbols          bool           `db:"symbols"`
+	MinLower         int            `db:"min_lower"`
+	MinUpper         int            `db:"min_upper"`
+	MinDigits        int            `db:"min_digits"`
+	MinSymbols       int            `db:"min_symbols"`
+	SymbolSet        string         `db:"symbol_set"`
+	ExcludeAmbiguous bool           `db:"exclude_ambiguous"`
+	NoRepeat         bool           `db:"no_repeat"`
+	Words            int            `db:"words"`
+	Wordlist         string         `db:"wordlist"`
+	Separator        string         `db:"separator"`
+	Capitalization   Capitalization `db:"capitalization"`
+	AppendDigit      bool           `db:"append_digit"`
+	AppendSymbol     bool           `db:"append_symbol"`
+}
+
+// DefaultPasswordPolicy generates 20 characters long passwords using every character class, and
+// six words long passphrases from the EFF large wordlist once switched to passphrase mode
+var DefaultPasswordPolicy = PasswordPolicy{
+	Mode:           GeneratorModeCharacters,
+	Length:         20,
+	Lower:          true,
+	Upper:          true,
+	Digits:         true,
+	Symbols:        true,
+	MinLower:       1,
+	MinUpper:       1,
+	MinDigits:      1,
+	MinSymbols:     1,
+	Words:          6,
+	Wordlist:       WordlistEFFLarge,
+	Separator:      "-",
+	Capitalization: CapitalizeNone,
+}
+
+// IsPassphrase reports whether the policy generates passphrases
+func (p PasswordPolicy) IsPassphrase() bool {
+	return p.Mode == GeneratorModePassphrase
+}
+
+// GeneratorProfile is a password policy a user saved under a name. Ctrl+G and the generate
+// command use the active profile of the user.
+type GeneratorProfile struct {
+	UserID string `db:"user_id"`
+	Name   string `db:"name"`
+	Active bool   `db:"active"`
+	PasswordPolicy
+}
+
+// NewGeneratorProfile returns new GeneratorProfile instance
+func NewGeneratorProfile(userID, name string, policy PasswordPolicy) GeneratorProfile {
+	return GeneratorProfile{
+		UserID:         userID,
+		Name:           name,
+		PasswordPolicy: policy,
+	}
+}
+
+// DefaultGeneratorProfile returns the profile of a user who has not activated any, using DefaultPasswordPolicy
+func DefaultGeneratorProfile(userID string) GeneratorProfile {
+	profile := NewGeneratorProfile(userID, DefaultGeneratorProfileName, DefaultPasswordPolicy)
+	profile.Active = true
+	return profile
+}
@@ -0,0 +1,361 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"yubigo-pass/internal/app/otp"
+)
+
+// ItemType discriminates the kinds of items a vault holds
+type ItemType string
+
+// Supported item types
+const (
+	ItemTypeLogin      ItemType = "login"
+	ItemTypeCard       ItemType = "card"
+	ItemTypeIdentity   ItemType = "identity"
+	ItemTypeSecureNote ItemType = "secure_note"
+	ItemTypeSSHKey     ItemType = "ssh_key"
+	ItemTypeAPIToken   ItemType = "api_token"
+	ItemTypeDatabase   ItemType = "database"
+)
+
+// ItemTypes lists the supported item types in the order they are offered to the user
+var ItemTypes = []ItemType{
+	ItemTypeLogin,
+	ItemTypeCard,
+	ItemTypeIdentity,
+	ItemTypeSecureNote,
+	ItemTypeSSHKey,
+	ItemTypeAPIToken,
+	ItemTypeDatabase,
+}
+
+// ItemColumn tells where the value of an item field is stored. Every type maps its display
+// name to the username column and its main secret to the password column, so listing,
+// lookup and reveal work the same for all items. One-time password URIs have their own column
+// next to the HOTP counter. Remaining values go to the encrypted payload.
+type ItemColumn int
+
+// Storage locations of item fields
+const (
+	ColumnPayload ItemColumn = iota
+	ColumnUsername
+	ColumnPassword
+	ColumnURL
+	ColumnOTP
+)
+
+// ItemField describes a single input of an item type. Generated marks secrets that
+// are usually random, so a generator can be offered for them. A required field may
+// still be left empty when the field keyed by optionalWith has a value.
+type ItemField struct {
+	Key          string
+	Label        string
+	Column       ItemColumn
+	Required     bool
+	Secret       bool
+	Multiline    bool
+	Generated    bool
+	optionalWith string
+	validate     func(value string) error
+}
+
+// ItemValues holds the plaintext values of an item keyed by ItemField.Key
+type ItemValues map[string]string
+
+// Item is a typed vault item in plaintext
+type Item struct {
+	Type   ItemType
+	Title  string
+	Values ItemValues
+	Notes  string
+}
+
+var itemFields = map[ItemType][]ItemField{
+	ItemTypeLogin: {
+		{Key: "username", Label: "Username", Column: ColumnUsername, Required: true},
+		{Key: "password", Label: "Password", Column: ColumnPassword, Required: true, Secret: true, Generated: true, optionalWith: "otp"},
+		{Key: "url", Label: "URL", Column: ColumnURL},
+		{Key: "otp", Label: "One-time password URI", Column: ColumnOTP, Secret: true, validate: validateOTP},
+	},
+	ItemTypeCard: {
+		{Key: "cardholder", Label: "Cardholder", Column: ColumnUsername, Required: true},
+		{Key: "number", Label: "Card number", Column: ColumnPassword, Required: true, Secret: true, validate: validateCardNumber},
+		{Key: "expiry", Label: "Expiry (MM/YY)", Required: true, validate: validateExpiry},
+		{Key: "cvv", Label: "CVV", Secret: true, validate: validateCVV},
+	},
+	ItemTypeIdentity: {
+		{Key: "full_name", Label: "Full name", Column: ColumnUsername, Required: true},
+		{Key: "document", Label: "ID or passport number", Column: ColumnPassword, Secret: true},
+		{Key: "email", Label: "Email", validate: validateEmail},
+		{Key: "phone", Label: "Phone", validate: validatePhone},
+		{Key: "birth_date", Label: "Birth date (YYYY-MM-DD)", validate: validateDate},
+		{Key: "address", Label: "Address", Multiline: true},
+	},
+	ItemTypeSecureNote: {},
+	ItemTypeSSHKey: {
+		{Key: "comment", Label: "Comment", Column: ColumnUsername},
+		{Key: "private_key", Label: "Private key", Column: ColumnPassword, Required: true, Secret: true, Multiline: true, validate: validatePrivateKey},
+		{Key: "public_key", Label: "Public key", validate: validatePublicKey},
+		{Key: "passphrase", Label: "Passphrase", Secret: true, Generated: true},
+	},
+	ItemTypeAPIToken: {
+		{Key: "key_id", Label: "Key ID", Column: ColumnUsername},
+		{Key: "token", Label: "Token", Column: ColumnPassword, Required: true, Secret: true},
+		{Key: "endpoint", Label: "Endpoint URL", Column: ColumnURL, validate: validateURL},
+		{Key: "expires", Label: "Expires (YYYY-MM-DD)", validate: validateDate},
+	},
+	ItemTypeDatabase: {
+		{Key: "username", Label: "Username", Column: ColumnUsername, Required: true},
+		{Key: "password", Label: "Password", Column: ColumnPassword, Required: true, Secret: true, Generated: true},
+		{Key: "engine", Label: "Engine, e.g. postgres", Required: true},
+		{Key: "host", Label: "Host", Required: true},
+		{Key: "port", Label: "Port", validate: validatePort},
+		{Key: "database", Label: "Database name"},
+	},
+}
+
+var itemLabels = map[ItemType]string{
+	ItemTypeLogin:      "Login",
+	ItemTypeCard:       "Credit card",
+	ItemTypeIdentity:   "Identity",
+	ItemTypeSecureNote: "Secure note",
+	ItemTypeSSHKey:     "SSH key",
+	ItemTypeAPIToken:   "API token",
+	ItemTypeDatabase:   "Database credential",
+}
+
+// ParseItemType returns the item type with the given name
+func ParseItemType(name string) (ItemType, error) {
+	t := ItemType(strings.TrimSpace(name))
+	if _, ok := itemFields[t]; !ok {
+		return "", fmt.Errorf("unknown item type %s", name)
+	}
+	return t, nil
+}
+
+// Label returns the human readable name of the item type
+func (t ItemType) Label() string {
+	if label, ok := itemLabels[t]; ok {
+		return label
+	}
+	return string(t)
+}
+
+// Fields returns the inputs of the item type in display order
+func (t ItemType) Fields() []ItemField {
+	return itemFields[t]
+}
+
+// Field returns the input of the item type stored in the given column
+func (t ItemType) Field(column ItemColumn) (ItemField, bool) {
+	for _, field := range itemFields[t] {
+		if field.Column == column && column != ColumnPayload {
+			return field, true
+		}
+	}
+	return ItemField{}, false
+}
+
+// Validate checks that an item has a title and values matching its type
+func (i Item) Validate() error {
+	fields, ok := itemFields[i.Type]
+	if !ok {
+		return fmt.Errorf("unknown item type %s", i.Type)
+	}
+	if strings.TrimSpace(i.Title) == "" {
+		return errors.New("title cannot be empty")
+	}
+	if i.Type == ItemTypeSecureNote && strings.TrimSpace(i.Notes) == "" {
+		return errors.New("secure note cannot be empty")
+	}
+
+	for _, field := range fields {
+		value := strings.TrimSpace(i.Values[field.Key])
+		if value == "" {
+			if field.Required && !i.hasValue(field.optionalWith) {
+				return fmt.Errorf("%s cannot be empty", strings.ToLower(field.Label))
+			}
+			continue
+		}
+		if field.validate != nil {
+			if err := field.validate(value); err != nil {
+				return fmt.Errorf("%s %w", strings.ToLower(field.Label), err)
+			}
+		}
+	}
+	return nil
+}
+
+// hasValue tells whether the field with the given key is filled in
+func (i Item) hasValue(key string) bool {
+	return key != "" && strings.TrimSpace(i.Values[key]) != ""
+}
+
+// Entry lays the item out in the shape stored in the passwords table, returning the entry
+// with its column values set and the remaining values that belong to the payload
+func (i Item) Entry() (Password, ItemValues) {
+	entry := Password{Type: i.Type, Title: i.Title, Notes: i.Notes}
+	payload := ItemValues{}
+	for _, field := range itemFields[i.Type] {
+		value := i.Values[field.Key]
+		switch field.Column {
+		case ColumnUsername:
+			entry.Username = value
+		case ColumnPassword:
+			entry.Password = value
+		case ColumnURL:
+			entry.Url = value
+		case ColumnOTP:
+			entry.OTP = value
+		default:
+			if value != "" {
+				payload[field.Key] = value
+			}
+		}
+	}
+	return entry, payload
+}
+
+// ItemFromEntry rebuilds an item from a plaintext entry and its payload values
+func ItemFromEntry(entry Password, payload ItemValues) Item {
+	item := Item{Type: entry.ItemType(), Title: entry.Title, Notes: entry.Notes, Values: ItemValues{}}
+	for _, field := range itemFields[item.Type] {
+		switch field.Column {
+		case ColumnUsername:
+			item.Values[field.Key] = entry.Username
+		case ColumnPassword:
+			item.Values[field.Key] = entry.Password
+		case ColumnURL:
+			item.Values[field.Key] = entry.Url
+		case ColumnOTP:
+			item.Values[field.Key] = entry.OTP
+		default:
+			item.Values[field.Key] = payload[field.Key]
+		}
+	}
+	return item
+}
+
+func validateURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.New("must be a valid URL")
+	}
+	return nil
+}
+
+func validateOTP(value string) error {
+	_, err := otp.Parse(value)
+	return err
+}
+
+func validateEmail(value string) error {
+	if _, err := mail.ParseAddress(value); err != nil {
+		return errors.New("must be a valid email address")
+	}
+	return nil
+}
+
+func validatePhone(value string) error {
+	digits := 0
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case strings.ContainsRune("+-() ", r):
+		default:
+			return errors.New("must contain only digits, spaces and +-()")
+		}
+	}
+	if digits < 5 {
+		return errors.New("is too short")
+	}
+	return nil
+}
+
+func validateDate(value string) error {
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		return errors.New("must be a date in YYYY-MM-DD format")
+	}
+	return nil
+}
+
+func validatePort(value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return errors.New("must be a number between 1 and 65535")
+	}
+	return nil
+}
+
+// validateCardNumber checks the length and the Luhn checksum of a card number,
+// ignoring spaces and dashes used for grouping
+func validateCardNumber(value string) error {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(value)
+	if len(digits) < 12 || len(digits) > 19 {
+		return errors.New("must have between 12 and 19 digits")
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if d < 0 || d > 9 {
+			return errors.New("must contain only digits")
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	if sum%10 != 0 {
+		return errors.New("is not valid")
+	}
+	return nil
+}
+
+func validateExpiry(value string) error {
+	if _, err := time.Parse("01/06", value); err != nil {
+		return errors.New("must be in MM/YY format")
+	}
+	return nil
+}
+
+func validateCVV(value string) error {
+	if len(value) < 3 || len(value) > 4 {
+		return errors.New("must have 3 or 4 digits")
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return errors.New("must have 3 or 4 digits")
+		}
+	}
+	return nil
+}
+
+func validatePrivateKey(value string) error {
+	if !strings.HasPrefix(value, "-----BEGIN ") || !strings.Contains(value, "PRIVATE KEY-----") {
+		return errors.New("must be a PEM encoded private key")
+	}
+	return nil
+}
+
+func validatePublicKey(value string) error {
+	for _, prefix := range []string{"ssh-", "ecdsa-", "sk-"} {
+		if strings.HasPrefix(value, prefix) {
+			return nil
+		}
+	}
+	return errors.New("must be in OpenSSH authorized_keys format")
+}
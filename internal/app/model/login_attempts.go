@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// LoginAttempts counts the failed logins in a row under a username. UsernameHash identifies the
+// username without storing it, LastFailure is the time of the last failure in Unix milliseconds.
+type LoginAttempts struct {
+	UsernameHash string `db:"username_hash"`
+	Failures     int    `db:"failures"`
+	LastFailure  int64  `db:"last_failure"`
+}
+
+// LoginPolicy throttles logins after failures. The first FreeFailures failures in a row leave the
+// next attempt open right away, every further one doubles the wait from BaseDelay up to MaxDelay,
+// and MaxFailures of them lock the username for Lockout.
+type LoginPolicy struct {
+	FreeFailures int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	MaxFailures  int
+	Lockout      time.Duration
+}
+
+// DefaultLoginPolicy is the policy logins are throttled with
+var DefaultLoginPolicy = LoginPolicy{
+	FreeFailures: 3,
+	BaseDelay:    time.Second,
+	MaxDelay:     time.Minute,
+	MaxFailures:  10,
+	Lockout:      15 * time.Minute,
+}
+
+// RetryAt returns when the next login under the username may be attempted
+func (p LoginPolicy) RetryAt(attempts LoginAttempts) time.Time {
+	last := time.UnixMilli(attempts.LastFailure)
+	switch {
+	case attempts.Failures >= p.MaxFailures:
+		return last.Add(p.Lockout)
+	case attempts.Failures < p.FreeFailures:
+		return time.Time{}
+	}
+	delay := p.BaseDelay << (attempts.Failures - p.FreeFailures)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return last.Add(delay)
+}
+
+// Fail counts a failure at now. A failure after a lockout has passed starts counting over.
+func (p LoginPolicy) Fail(attempts LoginAttempts, now time.Time) LoginAttempts {
+	if attempts.Failures >= p.MaxFailures {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailure = now.UnixMilli()
+	return attempts
+}
@@ -0,0 +1,56 @@
+//go:build unit
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginPolicyShouldBackOffExponentiallyAndLockOut(t *testing.T) {
+	// given
+	policy := DefaultLoginPolicy
+	last := time.UnixMilli(1700000000000)
+
+	testCases := []struct {
+		failures int
+		wait     time.Duration
+	}{
+		{failures: 0, wait: 0},
+		{failures: 2, wait: 0},
+		{failures: 3, wait: time.Second},
+		{failures: 4, wait: 2 * time.Second},
+		{failures: 6, wait: 8 * time.Second},
+		{failures: 9, wait: time.Minute},
+		{failures: 10, wait: 15 * time.Minute},
+		{failures: 12, wait: 15 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		// when
+		retryAt := policy.RetryAt(LoginAttempts{Failures: tc.failures, LastFailure: last.UnixMilli()})
+
+		// then
+		if tc.wait == 0 {
+			assert.True(t, retryAt.IsZero(), "%d failures", tc.failures)
+			continue
+		}
+		assert.Equal(t, last.Add(tc.wait), retryAt, "%d failures", tc.failures)
+	}
+}
+
+func TestLoginPolicyShouldCountFailuresOverAfterLockout(t *testing.T) {
+	// given
+	policy := DefaultLoginPolicy
+	now := time.UnixMilli(1700000000000)
+
+	// when
+	counted := policy.Fail(LoginAttempts{UsernameHash: "hash", Failures: 4}, now)
+	afterLockout := policy.Fail(LoginAttempts{UsernameHash: "hash", Failures: policy.MaxFailures}, now)
+
+	// then
+	assert.Equal(t, LoginAttempts{UsernameHash: "hash", Failures: 5, LastFailure: now.UnixMilli()}, counted)
+	assert.Equal(t, LoginAttempts{UsernameHash: "hash", Failures: 1, LastFailure: now.UnixMilli()}, afterLockout)
+}
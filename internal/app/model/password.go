@@ -1,23 +1,42 @@
 package model
 
-// Password is the model of the password
+// Password is the model of the password. OTP holds an otpauth:// URI and OTPCounter the
+// current counter of HOTP keys, kept apart from the encrypted URI so it can be advanced in place.
+// ModifiedAt is the time of the last change in Unix milliseconds, 0 for entries unchanged since before it was tracked.
 type Password struct {
-	UserID   string `db:"user_id"`
-	Title    string `db:"title"`
-	Username string `db:"username"`
-	Password string `db:"password"`
-	Url      string `db:"url"`
-	Nonce    []byte `db:"nonce"`
+	ID         string   `db:"id"`
+	UserID     string   `db:"user_id"`
+	Title      string   `db:"title"`
+	Username   string   `db:"username"`
+	Password   string   `db:"password"`
+	Url        string   `db:"url"`
+	Nonce      []byte   `db:"nonce"`
+	Notes      string   `db:"notes"`
+	Type       ItemType `db:"type"`
+	Payload    string   `db:"payload"`
+	OTP        string   `db:"otp"`
+	OTPCounter uint64   `db:"otp_counter"`
+	ModifiedAt int64    `db:"modified_at"`
 }
 
-// NewPassword returns new Password instance
-func NewPassword(userID, title, username, password, url string, nonce []byte) Password {
+// NewPassword returns new login Password instance
+func NewPassword(id, userID, title, username, password, url string, nonce []byte) Password {
 	return Password{
+		ID:       id,
 		UserID:   userID,
 		Title:    title,
 		Username: username,
 		Password: password,
 		Url:      url,
 		Nonce:    nonce,
+		Type:     ItemTypeLogin,
 	}
 }
+
+// ItemType returns the type of the entry, entries stored before typed items existed are logins
+func (p Password) ItemType() ItemType {
+	if p.Type == "" {
+		return ItemTypeLogin
+	}
+	return p.Type
+}
@@ -0,0 +1,17 @@
+package model
+
+// Tag is the model of the tag. Name is stored encrypted.
+type Tag struct {
+	ID     string `db:"id"`
+	UserID string `db:"user_id"`
+	Name   string `db:"name"`
+}
+
+// NewTag returns new Tag instance
+func NewTag(id, userID, name string) Tag {
+	return Tag{
+		ID:     id,
+		UserID: userID,
+		Name:   name,
+	}
+}
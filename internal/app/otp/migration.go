@@ -0,0 +1,179 @@
+package otp
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MigrationScheme is the URI scheme of Google Authenticator account exports
+const MigrationScheme = "otpauth-migration"
+
+// Field numbers and enum values of the MigrationPayload protobuf message of Google Authenticator
+const (
+	migrationOtpParameters protowire.Number = 1
+
+	parameterSecret    protowire.Number = 1
+	parameterName      protowire.Number = 2
+	parameterIssuer    protowire.Number = 3
+	parameterAlgorithm protowire.Number = 4
+	parameterDigits    protowire.Number = 5
+	parameterType      protowire.Number = 6
+	parameterCounter   protowire.Number = 7
+
+	migrationAlgorithmSHA1   = 1
+	migrationAlgorithmSHA256 = 2
+	migrationAlgorithmSHA512 = 3
+	migrationDigitsEight     = 2
+	migrationTypeHOTP        = 1
+)
+
+// ParseKeys reads the keys of an otpauth:// URI, or of an otpauth-migration:// URI which
+// batches several keys, as encoded in the QR codes of authenticator apps
+func ParseKeys(uri string) ([]Key, error) {
+	uri = strings.TrimSpace(uri)
+	if strings.HasPrefix(strings.ToLower(uri), MigrationScheme+":") {
+		return ParseMigration(uri)
+	}
+	key, err := Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	return []Key{key}, nil
+}
+
+// ParseMigration reads the keys of an otpauth-migration://offline?data=... URI exported by
+// Google Authenticator, where data is a base64 encoded MigrationPayload protobuf message
+func ParseMigration(uri string) ([]Key, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return nil, errors.New("is not a valid URI")
+	}
+	if u.Scheme != MigrationScheme {
+		return nil, fmt.Errorf("must be an %s:// URI", MigrationScheme)
+	}
+	data := strings.TrimRight(u.Query().Get("data"), "=")
+	if data == "" {
+		return nil, errors.New("must have migration data")
+	}
+	// Exports use standard base64, which survives URL decoding with its + turned into spaces
+	payload, err := base64.RawStdEncoding.DecodeString(strings.ReplaceAll(data, " ", "+"))
+	if err != nil {
+		return nil, errors.New("must have base64 encoded migration data")
+	}
+
+	var keys []Key
+	err = walkMessage(payload, func(number protowire.Number, value []byte, _ uint64) error {
+		if number != migrationOtpParameters {
+			return nil
+		}
+		key, err := parseOtpParameters(value)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("has no accounts")
+	}
+	return keys, nil
+}
+
+// parseOtpParameters decodes a single OtpParameters message into a key
+func parseOtpParameters(message []byte) (Key, error) {
+	key := Key{
+		Type:      TypeTOTP,
+		Algorithm: DefaultAlgorithm,
+		Digits:    DefaultDigits,
+		Period:    DefaultPeriod,
+	}
+	var name string
+	err := walkMessage(message, func(number protowire.Number, value []byte, varint uint64) error {
+		switch number {
+		case parameterSecret:
+			key.Secret = append([]byte(nil), value...)
+		case parameterName:
+			name = string(value)
+		case parameterIssuer:
+			key.Issuer = string(value)
+		case parameterAlgorithm:
+			switch varint {
+			case 0, migrationAlgorithmSHA1:
+				key.Algorithm = AlgorithmSHA1
+			case migrationAlgorithmSHA256:
+				key.Algorithm = AlgorithmSHA256
+			case migrationAlgorithmSHA512:
+				key.Algorithm = AlgorithmSHA512
+			default:
+				return errors.New("has an account with an unsupported algorithm")
+			}
+		case parameterDigits:
+			if varint == migrationDigitsEight {
+				key.Digits = 8
+			}
+		case parameterType:
+			if varint == migrationTypeHOTP {
+				key.Type = TypeHOTP
+			}
+		case parameterCounter:
+			key.Counter = varint
+		}
+		return nil
+	})
+	if err != nil {
+		return Key{}, err
+	}
+	if len(key.Secret) == 0 {
+		return Key{}, errors.New("has an account without a secret")
+	}
+
+	// Names carry the issuer as a prefix when the account was added from an otpauth:// URI
+	if issuer, account, ok := strings.Cut(name, ":"); ok {
+		if key.Issuer == "" {
+			key.Issuer = strings.TrimSpace(issuer)
+		}
+		name = account
+	}
+	key.Account = strings.TrimSpace(name)
+	return key, nil
+}
+
+// walkMessage calls fn with every field of a protobuf message, passing the content of
+// length-delimited fields as value and the number of varint fields as varint
+func walkMessage(message []byte, fn func(number protowire.Number, value []byte, varint uint64) error) error {
+	for len(message) > 0 {
+		number, wireType, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return errors.New("has malformed migration data")
+		}
+		message = message[n:]
+
+		var value []byte
+		var varint uint64
+		switch wireType {
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(message)
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(message)
+		default:
+			n = protowire.ConsumeFieldValue(number, wireType, message)
+		}
+		if n < 0 {
+			return errors.New("has malformed migration data")
+		}
+		message = message[n:]
+
+		if err := fn(number, value, varint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
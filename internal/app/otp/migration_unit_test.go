@@ -0,0 +1,187 @@
+//go:build unit
+
+package otp
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// migrationURI wraps raw OtpParameters messages into an otpauth-migration:// URI
+func migrationURI(parameters ...[]byte) string {
+	var payload []byte
+	for _, p := range parameters {
+		payload = protowire.AppendTag(payload, migrationOtpParameters, protowire.BytesType)
+		payload = protowire.AppendBytes(payload, p)
+	}
+	// Version and batch fields are ignored
+	payload = protowire.AppendTag(payload, 2, protowire.VarintType)
+	payload = protowire.AppendVarint(payload, 1)
+	return "otpauth-migration://offline?data=" + url.QueryEscape(base64.StdEncoding.EncodeToString(payload))
+}
+
+// otpParameters encodes an OtpParameters message, leaving out zero varints
+func otpParameters(secret []byte, name, issuer string, algorithm, digits, otpType, counter uint64) []byte {
+	var message []byte
+	message = protowire.AppendTag(message, parameterSecret, protowire.BytesType)
+	message = protowire.AppendBytes(message, secret)
+	message = protowire.AppendTag(message, parameterName, protowire.BytesType)
+	message = protowire.AppendString(message, name)
+	message = protowire.AppendTag(message, parameterIssuer, protowire.BytesType)
+	message = protowire.AppendString(message, issuer)
+	for number, value := range map[protowire.Number]uint64{
+		parameterAlgorithm: algorithm,
+		parameterDigits:    digits,
+		parameterType:      otpType,
+		parameterCounter:   counter,
+	} {
+		if value != 0 {
+			message = protowire.AppendTag(message, number, protowire.VarintType)
+			message = protowire.AppendVarint(message, value)
+		}
+	}
+	return message
+}
+
+func TestParseMigrationShouldReadAllAccounts(t *testing.T) {
+	// given
+	uri := migrationURI(
+		otpParameters([]byte("12345678901234567890"), "Example:alice@example.com", "Example", 1, 1, 2, 0),
+		otpParameters([]byte("Hello!\xde\xad\xbe\xef"), "bob", "", 3, 2, 1, 42),
+	)
+
+	// when
+	keys, err := ParseMigration(uri)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []Key{
+		{
+			Type:      TypeTOTP,
+			Issuer:    "Example",
+			Account:   "alice@example.com",
+			Secret:    []byte("12345678901234567890"),
+			Algorithm: AlgorithmSHA1,
+			Digits:    6,
+			Period:    30 * time.Second,
+		},
+		{
+			Type:      TypeHOTP,
+			Account:   "bob",
+			Secret:    []byte("Hello!\xde\xad\xbe\xef"),
+			Algorithm: AlgorithmSHA512,
+			Digits:    8,
+			Period:    30 * time.Second,
+			Counter:   42,
+		},
+	}, keys)
+}
+
+func TestParseMigrationShouldTakeIssuerFromName(t *testing.T) {
+	// given
+	uri := migrationURI(otpParameters([]byte("12345678901234567890"), "Example: alice", "", 0, 0, 0, 0))
+
+	// when
+	keys, err := ParseMigration(uri)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "Example", keys[0].Issuer)
+	assert.Equal(t, "alice", keys[0].Account)
+	assert.Equal(t, TypeTOTP, keys[0].Type)
+}
+
+func TestParseMigrationShouldAcceptUnescapedData(t *testing.T) {
+	// given
+	escaped := migrationURI(otpParameters([]byte{0xfb, 0xff, 0xfe, 0xfb, 0xef}, "alice", "", 0, 0, 0, 0))
+	unescaped, err := url.QueryUnescape(escaped)
+	require.NoError(t, err)
+
+	// when
+	keys, err := ParseMigration(unescaped)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, []byte{0xfb, 0xff, 0xfe, 0xfb, 0xef}, keys[0].Secret)
+}
+
+func TestParseMigrationShouldRejectInvalidURIs(t *testing.T) {
+	tests := []struct {
+		name        string
+		uri         string
+		expectedErr string
+	}{
+		{
+			name:        "wrong scheme",
+			uri:         "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP",
+			expectedErr: "must be an otpauth-migration:// URI",
+		},
+		{
+			name:        "missing data",
+			uri:         "otpauth-migration://offline",
+			expectedErr: "must have migration data",
+		},
+		{
+			name:        "data not base64",
+			uri:         "otpauth-migration://offline?data=%21%21%21",
+			expectedErr: "must have base64 encoded migration data",
+		},
+		{
+			name:        "malformed message",
+			uri:         "otpauth-migration://offline?data=" + base64.StdEncoding.EncodeToString([]byte{0x0a, 0x10, 0x01}),
+			expectedErr: "has malformed migration data",
+		},
+		{
+			name:        "no accounts",
+			uri:         migrationURI(),
+			expectedErr: "has no accounts",
+		},
+		{
+			name:        "account without secret",
+			uri:         migrationURI(otpParameters(nil, "alice", "", 0, 0, 0, 0)),
+			expectedErr: "has an account without a secret",
+		},
+		{
+			name:        "unsupported algorithm",
+			uri:         migrationURI(otpParameters([]byte("secret"), "alice", "", 4, 0, 0, 0)),
+			expectedErr: "has an account with an unsupported algorithm",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			_, err := ParseMigration(tt.uri)
+
+			// then
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestParseKeysShouldAcceptBothSchemes(t *testing.T) {
+	// given
+	single := "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP"
+	batch := migrationURI(
+		otpParameters([]byte("one"), "alice", "Example", 0, 0, 0, 0),
+		otpParameters([]byte("two"), "bob", "Example", 0, 0, 0, 0),
+	)
+
+	// when
+	singleKeys, singleErr := ParseKeys(single)
+	batchKeys, batchErr := ParseKeys(batch)
+
+	// then
+	require.NoError(t, singleErr)
+	require.NoError(t, batchErr)
+	assert.Len(t, singleKeys, 1)
+	assert.Len(t, batchKeys, 2)
+}
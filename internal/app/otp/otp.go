@@ -0,0 +1,114 @@
+// Package otp generates one-time passwords of two-factor authenticators, time based
+// as in RFC 6238 (TOTP) and counter based as in RFC 4226 (HOTP), from otpauth:// URIs.
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" // #nosec G505 -- SHA1 is the default algorithm of RFC 4226 and RFC 6238
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"time"
+)
+
+// Type is the kind of one-time password
+type Type string
+
+// Supported one-time password types
+const (
+	TypeTOTP Type = "totp"
+	TypeHOTP Type = "hotp"
+)
+
+// Algorithm is the HMAC hash function codes are derived with
+type Algorithm string
+
+// Supported algorithms
+const (
+	AlgorithmSHA1   Algorithm = "SHA1"
+	AlgorithmSHA256 Algorithm = "SHA256"
+	AlgorithmSHA512 Algorithm = "SHA512"
+)
+
+// Defaults applied when an otpauth:// URI leaves a parameter out
+const (
+	DefaultAlgorithm = AlgorithmSHA1
+	DefaultDigits    = 6
+	DefaultPeriod    = 30 * time.Second
+)
+
+// Key is a parsed one-time password secret with its generation parameters.
+// Period applies to TOTP keys and Counter to HOTP keys.
+type Key struct {
+	Type      Type
+	Issuer    string
+	Account   string
+	Secret    []byte
+	Algorithm Algorithm
+	Digits    int
+	Period    time.Duration
+	Counter   uint64
+}
+
+// Code is a generated one-time password. Remaining is the time the code stays valid for,
+// zero for HOTP codes which stay valid until used.
+type Code struct {
+	Value     string
+	Remaining time.Duration
+}
+
+// Generate returns the code of the key at the given moment. HOTP keys produce the code of
+// their current counter, which callers advance once the code is used.
+func (k Key) Generate(now time.Time) Code {
+	if k.Type == TypeHOTP {
+		return Code{Value: HOTP(k.Secret, k.Counter, k.Digits, k.Algorithm)}
+	}
+	return Code{Value: TOTP(k.Secret, now, k.Period, k.Digits, k.Algorithm), Remaining: Remaining(now, k.Period)}
+}
+
+// HOTP computes the RFC 4226 code of a secret for a counter value
+func HOTP(secret []byte, counter uint64, digits int, algorithm Algorithm) string {
+	mac := hmac.New(algorithm.hash(), secret)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for range digits {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
+
+// TOTP computes the RFC 6238 code of a secret at the given moment
+func TOTP(secret []byte, now time.Time, period time.Duration, digits int, algorithm Algorithm) string {
+	return HOTP(secret, timeStep(now, period), digits, algorithm)
+}
+
+// Remaining returns how long the TOTP code of the given moment stays valid
+func Remaining(now time.Time, period time.Duration) time.Duration {
+	elapsed := time.Duration(now.UnixNano()) % period
+	return period - elapsed
+}
+
+// timeStep returns the number of whole periods elapsed since the Unix epoch
+func timeStep(now time.Time, period time.Duration) uint64 {
+	return uint64(now.Unix()) / uint64(period/time.Second)
+}
+
+// hash returns the constructor of the hash function of the algorithm, SHA1 when unknown
+func (a Algorithm) hash() func() hash.Hash {
+	switch a {
+	case AlgorithmSHA256:
+		return sha256.New
+	case AlgorithmSHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
@@ -0,0 +1,94 @@
+//go:build unit
+
+package otp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test secrets of RFC 4226 appendix D and RFC 6238 appendix B
+var (
+	rfcSecretSHA1   = []byte("12345678901234567890")
+	rfcSecretSHA256 = []byte("12345678901234567890123456789012")
+	rfcSecretSHA512 = []byte("1234567890123456789012345678901234567890123456789012345678901234")
+)
+
+func TestHOTPShouldMatchRFC4226TestVectors(t *testing.T) {
+	// given
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range expected {
+		// when
+		generated := HOTP(rfcSecretSHA1, uint64(counter), 6, AlgorithmSHA1)
+
+		// then
+		assert.Equal(t, code, generated, "counter %d", counter)
+	}
+}
+
+func TestTOTPShouldMatchRFC6238TestVectors(t *testing.T) {
+	// given
+	testCases := []struct {
+		unix      int64
+		algorithm Algorithm
+		secret    []byte
+		expected  string
+	}{
+		{59, AlgorithmSHA1, rfcSecretSHA1, "94287082"},
+		{59, AlgorithmSHA256, rfcSecretSHA256, "46119246"},
+		{59, AlgorithmSHA512, rfcSecretSHA512, "90693936"},
+		{1111111109, AlgorithmSHA1, rfcSecretSHA1, "07081804"},
+		{1111111109, AlgorithmSHA256, rfcSecretSHA256, "68084774"},
+		{1111111109, AlgorithmSHA512, rfcSecretSHA512, "25091201"},
+		{1234567890, AlgorithmSHA1, rfcSecretSHA1, "89005924"},
+		{2000000000, AlgorithmSHA256, rfcSecretSHA256, "90698825"},
+		{20000000000, AlgorithmSHA512, rfcSecretSHA512, "47863826"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.algorithm)+" at "+time.Unix(tc.unix, 0).UTC().String(), func(t *testing.T) {
+			// when
+			code := TOTP(tc.secret, time.Unix(tc.unix, 0), 30*time.Second, 8, tc.algorithm)
+
+			// then
+			assert.Equal(t, tc.expected, code)
+		})
+	}
+}
+
+func TestRemainingShouldCountDownToTheEndOfThePeriod(t *testing.T) {
+	// given
+	testCases := []struct {
+		unix     int64
+		expected time.Duration
+	}{
+		{0, 30 * time.Second},
+		{59, time.Second},
+		{61, 29 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		// when
+		remaining := Remaining(time.Unix(tc.unix, 0), 30*time.Second)
+
+		// then
+		assert.Equal(t, tc.expected, remaining)
+	}
+}
+
+func TestKeyShouldGenerateCodeOfItsType(t *testing.T) {
+	// given
+	totp := Key{Type: TypeTOTP, Secret: rfcSecretSHA1, Algorithm: AlgorithmSHA1, Digits: 8, Period: 30 * time.Second}
+	hotp := Key{Type: TypeHOTP, Secret: rfcSecretSHA1, Algorithm: AlgorithmSHA1, Digits: 6, Counter: 3}
+
+	// when
+	totpCode := totp.Generate(time.Unix(59, 0))
+	hotpCode := hotp.Generate(time.Unix(59, 0))
+
+	// then
+	assert.Equal(t, Code{Value: "94287082", Remaining: time.Second}, totpCode)
+	assert.Equal(t, Code{Value: "969429"}, hotpCode)
+}
@@ -0,0 +1,58 @@
+package otp
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register the JPEG decoder for image.Decode
+	_ "image/png"  // register the PNG decoder for image.Decode
+	"io"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/multi/qrcode"
+)
+
+// ErrNoQRCode is returned when an image holds no readable QR code
+var ErrNoQRCode = errors.New("no QR code found")
+
+// DecodeQR reads the text of every QR code in a PNG or JPEG image. Decoding happens locally,
+// the image never leaves the machine.
+func DecodeQR(r io.Reader) ([]string, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	results, err := qrcode.NewQRCodeMultiReader().DecodeMultiple(bitmap, hints)
+	if err != nil || len(results) == 0 {
+		return nil, ErrNoQRCode
+	}
+	texts := make([]string, 0, len(results))
+	for _, result := range results {
+		texts = append(texts, result.GetText())
+	}
+	return texts, nil
+}
+
+// ReadQR returns the keys encoded in the QR codes of an image, accepting both otpauth://
+// and otpauth-migration:// codes
+func ReadQR(r io.Reader) ([]Key, error) {
+	texts, err := DecodeQR(r)
+	if err != nil {
+		return nil, err
+	}
+	var keys []Key
+	for _, text := range texts {
+		parsed, err := ParseKeys(text)
+		if err != nil {
+			return nil, fmt.Errorf("QR code %w", err)
+		}
+		keys = append(keys, parsed...)
+	}
+	return keys, nil
+}
@@ -0,0 +1,113 @@
+//go:build unit
+
+package otp
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// qrImage renders a QR code of every text side by side on a white canvas
+func qrImage(t *testing.T, texts ...string) image.Image {
+	const size = 300
+	canvas := image.NewGray(image.Rect(0, 0, size*len(texts), size))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for i, text := range texts {
+		code, err := qrcode.NewQRCodeWriter().Encode(text, gozxing.BarcodeFormat_QR_CODE, size, size, nil)
+		require.NoError(t, err)
+		draw.Draw(canvas, code.Bounds().Add(image.Pt(i*size, 0)), code, image.Point{}, draw.Src)
+	}
+	return canvas
+}
+
+func TestDecodeQRShouldReadPNG(t *testing.T) {
+	// given
+	uri := "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&issuer=Example"
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, qrImage(t, uri)))
+
+	// when
+	texts, err := DecodeQR(&buf)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{uri}, texts)
+}
+
+func TestDecodeQRShouldReadJPEG(t *testing.T) {
+	// given
+	uri := "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP"
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, qrImage(t, uri), &jpeg.Options{Quality: 80}))
+
+	// when
+	texts, err := DecodeQR(&buf)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{uri}, texts)
+}
+
+func TestReadQRShouldReadEveryCodeOfImage(t *testing.T) {
+	// given
+	first := "otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP"
+	second := "otpauth://hotp/Example:bob?secret=JBSWY3DPEHPK3PXP&counter=3"
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, qrImage(t, first, second)))
+
+	// when
+	keys, err := ReadQR(&buf)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	accounts := []string{keys[0].Account, keys[1].Account}
+	assert.ElementsMatch(t, []string{"alice", "bob"}, accounts)
+}
+
+func TestDecodeQRShouldFailWithoutCode(t *testing.T) {
+	// given
+	blank := image.NewGray(image.Rect(0, 0, 100, 100))
+	draw.Draw(blank, blank.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, blank))
+
+	// when
+	_, err := DecodeQR(&buf)
+
+	// then
+	assert.ErrorIs(t, err, ErrNoQRCode)
+}
+
+func TestDecodeQRShouldFailOnUnsupportedImage(t *testing.T) {
+	// given
+	content := bytes.NewReader([]byte("not an image"))
+
+	// when
+	_, err := DecodeQR(content)
+
+	// then
+	assert.ErrorContains(t, err, "failed to read image")
+}
+
+func TestReadQRShouldRejectCodeWithoutKey(t *testing.T) {
+	// given
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, qrImage(t, "https://example.com")))
+
+	// when
+	_, err := ReadQR(&buf)
+
+	// then
+	assert.EqualError(t, err, "QR code must be an otpauth:// URI")
+}
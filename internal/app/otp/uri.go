@@ -0,0 +1,131 @@
+package otp
+
+import (
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scheme is the URI scheme of authenticator keys
+const Scheme = "otpauth"
+
+// Parse reads a key from an otpauth:// URI as exported by authenticator apps, e.g.
+// otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example
+func Parse(uri string) (Key, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return Key{}, errors.New("is not a valid URI")
+	}
+	if u.Scheme != Scheme {
+		return Key{}, fmt.Errorf("must be an %s:// URI", Scheme)
+	}
+
+	key := Key{
+		Type:      Type(strings.ToLower(u.Host)),
+		Algorithm: DefaultAlgorithm,
+		Digits:    DefaultDigits,
+		Period:    DefaultPeriod,
+	}
+	if key.Type != TypeTOTP && key.Type != TypeHOTP {
+		return Key{}, fmt.Errorf("has unknown type %s, expected totp or hotp", u.Host)
+	}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		key.Issuer, key.Account = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		key.Account = strings.TrimSpace(label)
+	}
+
+	query := u.Query()
+	if issuer := query.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+
+	key.Secret, err = DecodeSecret(query.Get("secret"))
+	if err != nil {
+		return Key{}, err
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		key.Algorithm = Algorithm(strings.ToUpper(algorithm))
+		switch key.Algorithm {
+		case AlgorithmSHA1, AlgorithmSHA256, AlgorithmSHA512:
+		default:
+			return Key{}, fmt.Errorf("has unsupported algorithm %s, expected SHA1, SHA256 or SHA512", algorithm)
+		}
+	}
+
+	if digits := query.Get("digits"); digits != "" {
+		key.Digits, err = strconv.Atoi(digits)
+		if err != nil || key.Digits < 6 || key.Digits > 8 {
+			return Key{}, errors.New("must have between 6 and 8 digits")
+		}
+	}
+
+	if period := query.Get("period"); period != "" {
+		seconds, err := strconv.Atoi(period)
+		if err != nil || seconds < 1 {
+			return Key{}, errors.New("must have a period of at least one second")
+		}
+		key.Period = time.Duration(seconds) * time.Second
+	}
+
+	if key.Type == TypeHOTP {
+		counter := query.Get("counter")
+		if counter == "" {
+			return Key{}, errors.New("must have a counter for hotp")
+		}
+		key.Counter, err = strconv.ParseUint(counter, 10, 64)
+		if err != nil {
+			return Key{}, errors.New("must have a counter that is a non-negative number")
+		}
+	}
+	return key, nil
+}
+
+// DecodeSecret decodes a base32 secret, ignoring case, spaces and padding
+func DecodeSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(secret))
+	normalized = strings.TrimRight(normalized, "=")
+	if normalized == "" {
+		return nil, errors.New("must have a secret")
+	}
+	decoded, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized)
+	if err != nil {
+		return nil, errors.New("must have a base32 encoded secret")
+	}
+	return decoded, nil
+}
+
+// URI encodes the key as an otpauth:// URI, leaving out parameters with default values
+func (k Key) URI() string {
+	label := k.Account
+	if k.Issuer != "" {
+		label = k.Issuer + ":" + k.Account
+	}
+
+	query := url.Values{}
+	query.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(k.Secret))
+	if k.Issuer != "" {
+		query.Set("issuer", k.Issuer)
+	}
+	if k.Algorithm != "" && k.Algorithm != DefaultAlgorithm {
+		query.Set("algorithm", string(k.Algorithm))
+	}
+	if k.Digits != 0 && k.Digits != DefaultDigits {
+		query.Set("digits", strconv.Itoa(k.Digits))
+	}
+	if k.Type == TypeHOTP {
+		query.Set("counter", strconv.FormatUint(k.Counter, 10))
+	} else if k.Period != 0 && k.Period != DefaultPeriod {
+		query.Set("period", strconv.Itoa(int(k.Period/time.Second)))
+	}
+
+	u := url.URL{Scheme: Scheme, Host: string(k.Type), Path: "/" + label, RawQuery: query.Encode()}
+	return u.String()
+}
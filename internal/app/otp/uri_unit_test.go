@@ -0,0 +1,107 @@
+//go:build unit
+
+package otp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShouldReadTOTPURI(t *testing.T) {
+	// given
+	uri := "otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example&algorithm=SHA256&digits=8&period=60"
+
+	// when
+	key, err := Parse(uri)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, Key{
+		Type:      TypeTOTP,
+		Issuer:    "Example",
+		Account:   "alice@example.com",
+		Secret:    []byte("Hello!\xde\xad\xbe\xef"),
+		Algorithm: AlgorithmSHA256,
+		Digits:    8,
+		Period:    time.Minute,
+	}, key)
+}
+
+func TestParseShouldApplyDefaults(t *testing.T) {
+	// given
+	uri := "otpauth://totp/alice?secret=jbswy3dpehpk3pxp"
+
+	// when
+	key, err := Parse(uri)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "alice", key.Account)
+	assert.Empty(t, key.Issuer)
+	assert.Equal(t, AlgorithmSHA1, key.Algorithm)
+	assert.Equal(t, 6, key.Digits)
+	assert.Equal(t, 30*time.Second, key.Period)
+}
+
+func TestParseShouldReadHOTPCounter(t *testing.T) {
+	// given
+	uri := "otpauth://hotp/Example:alice?secret=JBSWY3DPEHPK3PXP&counter=42"
+
+	// when
+	key, err := Parse(uri)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, TypeHOTP, key.Type)
+	assert.Equal(t, uint64(42), key.Counter)
+}
+
+func TestParseShouldRejectInvalidURIs(t *testing.T) {
+	// given
+	testCases := []struct {
+		name     string
+		uri      string
+		expected string
+	}{
+		{"wrong scheme", "https://totp/alice?secret=JBSWY3DPEHPK3PXP", "must be an otpauth:// URI"},
+		{"unknown type", "otpauth://motp/alice?secret=JBSWY3DPEHPK3PXP", "has unknown type motp, expected totp or hotp"},
+		{"missing secret", "otpauth://totp/alice", "must have a secret"},
+		{"invalid secret", "otpauth://totp/alice?secret=not-base32!", "must have a base32 encoded secret"},
+		{"unsupported algorithm", "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5", "has unsupported algorithm MD5, expected SHA1, SHA256 or SHA512"},
+		{"too few digits", "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=4", "must have between 6 and 8 digits"},
+		{"zero period", "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=0", "must have a period of at least one second"},
+		{"missing counter", "otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP", "must have a counter for hotp"},
+		{"negative counter", "otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=-1", "must have a counter that is a non-negative number"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			_, err := Parse(tc.uri)
+
+			// then
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+}
+
+func TestURIShouldRoundTrip(t *testing.T) {
+	// given
+	keys := []Key{
+		{Type: TypeTOTP, Issuer: "Example Co", Account: "alice@example.com", Secret: []byte("secret key bytes"), Algorithm: AlgorithmSHA512, Digits: 8, Period: time.Minute},
+		{Type: TypeTOTP, Account: "bob", Secret: []byte("another secret"), Algorithm: AlgorithmSHA1, Digits: 6, Period: 30 * time.Second},
+		{Type: TypeHOTP, Issuer: "Bank", Account: "carol", Secret: []byte("counter secret"), Algorithm: AlgorithmSHA1, Digits: 6, Period: 30 * time.Second, Counter: 7},
+	}
+
+	for _, key := range keys {
+		// when
+		parsed, err := Parse(key.URI())
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, key, parsed)
+	}
+}
@@ -0,0 +1,260 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+)
+
+// Searcher finds entries matching a free-text query, best matches first.
+// A limit of zero or less returns all matches.
+type Searcher interface {
+	Search(query string, limit int) []Result
+}
+
+// Document is the searchable plaintext of a single entry
+type Document struct {
+	ID       string
+	Title    string
+	Username string
+	URL      string
+	Tags     []string
+	Notes    string
+}
+
+// Result is a matching entry with its relevance score
+type Result struct {
+	ID    string
+	Score float64
+}
+
+// field identifies a searchable part of a document
+type field int
+
+const (
+	fieldTitle field = iota
+	fieldUsername
+	fieldURL
+	fieldTags
+	fieldNotes
+	fieldCount
+)
+
+// fieldWeights make matches in the title count more than matches buried in the notes
+var fieldWeights = [fieldCount]float64{
+	fieldTitle:    3,
+	fieldUsername: 2,
+	fieldURL:      1.5,
+	fieldTags:     2,
+	fieldNotes:    1,
+}
+
+// Scores of a single query term matching a single token
+const (
+	exactScore     = 1.0
+	minPrefixScore = 0.6
+	maxFuzzyScore  = 0.4
+)
+
+type indexedDocument struct {
+	id     string
+	title  string
+	tokens [fieldCount][]string
+}
+
+// Index is an in-memory Searcher over plaintext documents. Entry metadata is encrypted
+// at rest, so the index is built after unlocking and never leaves memory.
+type Index struct {
+	documents []indexedDocument
+}
+
+// NewIndex tokenizes documents into a new Index
+func NewIndex(documents []Document) *Index {
+	index := &Index{documents: make([]indexedDocument, 0, len(documents))}
+	for _, doc := range documents {
+		indexed := indexedDocument{id: doc.ID, title: strings.ToLower(doc.Title)}
+		indexed.tokens[fieldTitle] = Tokenize(doc.Title)
+		indexed.tokens[fieldUsername] = Tokenize(doc.Username)
+		indexed.tokens[fieldURL] = Tokenize(doc.URL)
+		indexed.tokens[fieldTags] = Tokenize(strings.Join(doc.Tags, " "))
+		indexed.tokens[fieldNotes] = Tokenize(doc.Notes)
+		index.documents = append(index.documents, indexed)
+	}
+	return index
+}
+
+// BuildIndex decrypts the tags and notes of all entries of the vault and indexes them
+func BuildIndex(v vault.Vault) (*Index, error) {
+	entries, err := v.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make([]Document, 0, len(entries))
+	for _, entry := range entries {
+		notes, err := v.RevealNotes(entry)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := v.EntryTags(entry.ID)
+		if err != nil {
+			return nil, err
+		}
+		tagNames := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			tagNames = append(tagNames, tag.Name)
+		}
+		documents = append(documents, Document{
+			ID:       entry.ID,
+			Title:    entry.Title,
+			Username: entry.Username,
+			URL:      entry.Url,
+			Tags:     tagNames,
+			Notes:    notes,
+		})
+	}
+	return NewIndex(documents), nil
+}
+
+// Search returns the documents matching every term of the query. Terms match tokens exactly,
+// as a prefix, or within a few typos, and the results are ranked by the weighted sum of the best matches.
+func (i *Index) Search(query string, limit int) []Result {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []Result
+	titles := make(map[string]string)
+	for _, doc := range i.documents {
+		score, ok := doc.score(terms)
+		if !ok {
+			continue
+		}
+		results = append(results, Result{ID: doc.id, Score: score})
+		titles[doc.id] = doc.title
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		if results[a].Score != results[b].Score {
+			return results[a].Score > results[b].Score
+		}
+		return titles[results[a].ID] < titles[results[b].ID]
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// score sums the best weighted match of every term, failing when a term matches nothing
+func (d indexedDocument) score(terms []string) (float64, bool) {
+	total := 0.0
+	for _, term := range terms {
+		best := 0.0
+		for f := field(0); f < fieldCount; f++ {
+			for _, token := range d.tokens[f] {
+				if s := matchScore(term, token) * fieldWeights[f]; s > best {
+					best = s
+				}
+			}
+		}
+		if best == 0 {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// matchScore rates how well a query term matches a token, zero meaning no match
+func matchScore(term, token string) float64 {
+	if term == token {
+		return exactScore
+	}
+	if strings.HasPrefix(token, term) {
+		return minPrefixScore + (exactScore-minPrefixScore)*float64(len(term))/float64(len(token))
+	}
+
+	termRunes := []rune(term)
+	maxEdits := allowedEdits(len(termRunes))
+	if maxEdits == 0 {
+		return 0
+	}
+	tokenRunes := []rune(token)
+	distance := editDistance(termRunes, tokenRunes)
+	if len(tokenRunes) > len(termRunes) {
+		// Allow typos in a prefix, so partially typed words still match
+		distance = min(distance, editDistance(termRunes, tokenRunes[:len(termRunes)]))
+	}
+	if distance > maxEdits {
+		return 0
+	}
+	return maxFuzzyScore * (1 - float64(distance)/float64(maxEdits+1))
+}
+
+// allowedEdits returns how many typos a term of the given length tolerates
+func allowedEdits(length int) int {
+	switch {
+	case length < 4:
+		return 0
+	case length < 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the optimal string alignment distance between a and b,
+// counting insertions, deletions, substitutions and transpositions of adjacent runes
+func editDistance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
+
+// Tokenize lowercases text and splits it into words of letters and digits
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Rank returns the entries matching the results, in the order of the results.
+// Results without a matching entry are skipped.
+func Rank(entries []model.Password, results []Result) []model.Password {
+	byID := make(map[string]model.Password, len(entries))
+	for _, entry := range entries {
+		byID[entry.ID] = entry
+	}
+
+	ranked := make([]model.Password, 0, len(results))
+	for _, result := range results {
+		if entry, ok := byID[result.ID]; ok {
+			ranked = append(ranked, entry)
+		}
+	}
+	return ranked
+}
@@ -0,0 +1,37 @@
+//go:build integration
+
+package search
+
+import (
+	"testing"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldBuildIndexFromEncryptedTagsAndNotes(t *testing.T) {
+	// setup
+	v := vaulttest.New(t)
+
+	// given
+	require.NoError(t, v.AddEntry(model.Password{Title: "router", Username: "admin", Password: "x", Notes: "wpa2 passphrase"}, nil, "", []string{"home"}))
+	require.NoError(t, v.AddEntry(model.Password{Title: "github", Username: "octocat", Password: "x"}, nil, "", []string{"dev"}))
+
+	// when
+	index, err := BuildIndex(v)
+
+	// then
+	require.NoError(t, err)
+	entries, err := v.Entries()
+	require.NoError(t, err)
+
+	byNotes := Rank(entries, index.Search("passphrase", 0))
+	require.Len(t, byNotes, 1)
+	assert.Equal(t, "router", byNotes[0].Title)
+
+	byTag := Rank(entries, index.Search("dev", 0))
+	require.Len(t, byTag, 1)
+	assert.Equal(t, "github", byTag[0].Title)
+}
@@ -0,0 +1,123 @@
+//go:build unit
+
+package search
+
+import (
+	"testing"
+	"yubigo-pass/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testIndex() *Index {
+	return NewIndex([]Document{
+		{ID: "github", Title: "GitHub", Username: "octocat", URL: "https://github.com/login", Tags: []string{"dev"}},
+		{ID: "gitlab", Title: "GitLab", Username: "jane", URL: "https://gitlab.com", Tags: []string{"dev", "work"}},
+		{ID: "bank", Title: "Bank", Username: "jane", Notes: "security question: first pet"},
+		{ID: "mail", Title: "Mail", Username: "jane@example.com", Tags: []string{"personal"}},
+	})
+}
+
+func resultIDs(results []Result) []string {
+	ids := make([]string, 0, len(results))
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestIndexSearch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "exact title", query: "github", expected: []string{"github"}},
+		{name: "case insensitive", query: "BANK", expected: []string{"bank"}},
+		{name: "prefix ranks shorter token first", query: "git", expected: []string{"github", "gitlab"}},
+		{name: "fuzzy typo", query: "githib", expected: []string{"github"}},
+		{name: "fuzzy transposition", query: "gtihub", expected: []string{"github"}},
+		{name: "username", query: "octocat", expected: []string{"github"}},
+		{name: "url", query: "gitlab.com", expected: []string{"gitlab"}},
+		{name: "tag", query: "work", expected: []string{"gitlab"}},
+		{name: "notes", query: "pet", expected: []string{"bank"}},
+		{name: "all terms must match", query: "jane dev", expected: []string{"gitlab"}},
+		{name: "title outranks username", query: "mail", expected: []string{"mail"}},
+		{name: "no match", query: "paypal", expected: []string{}},
+		{name: "short terms are not fuzzy", query: "gx", expected: []string{}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// when
+			results := testIndex().Search(testCase.query, 0)
+
+			// then
+			assert.Equal(t, testCase.expected, resultIDs(results))
+		})
+	}
+}
+
+func TestIndexSearchShouldRankTitleAboveOtherFields(t *testing.T) {
+	// given
+	index := NewIndex([]Document{
+		{ID: "notes", Title: "Router", Notes: "vpn settings"},
+		{ID: "title", Title: "VPN"},
+	})
+
+	// when
+	results := index.Search("vpn", 0)
+
+	// then
+	assert.Equal(t, []string{"title", "notes"}, resultIDs(results))
+	assert.Greater(t, results[0].Score, results[1].Score)
+}
+
+func TestIndexSearchShouldRespectLimit(t *testing.T) {
+	// when
+	results := testIndex().Search("jane", 2)
+
+	// then
+	assert.Len(t, results, 2)
+}
+
+func TestIndexSearchShouldIgnoreEmptyQuery(t *testing.T) {
+	assert.Empty(t, testIndex().Search("  ", 0))
+}
+
+func TestEditDistance(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "", b: "abc", expected: 3},
+		{a: "abc", b: "abc", expected: 0},
+		{a: "abc", b: "abd", expected: 1},
+		{a: "abc", b: "acb", expected: 1},
+		{a: "kitten", b: "sitting", expected: 3},
+		{a: "zażółć", b: "zazółć", expected: 1},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.a+"/"+testCase.b, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, editDistance([]rune(testCase.a), []rune(testCase.b)))
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"https", "github", "com", "login"}, Tokenize("https://GitHub.com/login"))
+	assert.Empty(t, Tokenize(" -- "))
+}
+
+func TestRank(t *testing.T) {
+	// given
+	entries := []model.Password{{ID: "a", Title: "A"}, {ID: "b", Title: "B"}, {ID: "c", Title: "C"}}
+	results := []Result{{ID: "c"}, {ID: "missing"}, {ID: "a"}}
+
+	// when
+	ranked := Rank(entries, results)
+
+	// then
+	assert.Equal(t, []model.Password{{ID: "c", Title: "C"}, {ID: "a", Title: "A"}}, ranked)
+}
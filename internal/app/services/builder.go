@@ -1,15 +1,29 @@
 package services
 
 import (
+	"cmp"
 	"fmt"
+	"os"
+	"path/filepath"
+	"yubigo-pass/internal/app/breach"
+	"yubigo-pass/internal/app/gitsync"
+	"yubigo-pass/internal/app/httpsync"
+	"yubigo-pass/internal/app/sharelink"
 	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
 	"yubigo-pass/internal/database"
+	"yubigo-pass/internal/database/backup"
 )
 
 // Build initializes and wires up foundational application dependencies.
-// It now only focuses on services like the database store.
+// It now only focuses on services like the database store, the attachment directory next to it, its backups, sync, share and breach check settings.
 func Build() (Container, error) {
-	db, err := database.CreateDB(utils.CreatePathForDB(), database.MigrationPath)
+	dbPath := utils.CreatePathForDB()
+	rotation, interval, err := backup.FromEnv(dbPath)
+	if err != nil {
+		return Container{}, fmt.Errorf("error reading backup settings: %w", err)
+	}
+	db, err := database.CreateDB(dbPath, database.MigrationPath, rotation)
 	if err != nil {
 		return Container{}, fmt.Errorf("error initializing database: %w", err)
 	}
@@ -17,6 +31,18 @@ func Build() (Container, error) {
 	store := database.NewStore(db)
 
 	return Container{
-		Store: store,
+		Store:         store,
+		AttachmentDir: filepath.Join(filepath.Dir(dbPath), vault.AttachmentDirName),
+		Backups:       backup.NewManager(db, dbPath, rotation, interval),
+		SyncDir:       filepath.Join(filepath.Dir(dbPath), gitsync.DirName),
+		SyncRemote:    os.Getenv(gitsync.EnvRemote),
+		SyncServer:    os.Getenv(httpsync.EnvServer),
+		SyncToken:     os.Getenv(httpsync.EnvToken),
+		ShareDir:      filepath.Join(filepath.Dir(dbPath), sharelink.DirName),
+		ShareServer:   os.Getenv(sharelink.EnvServer),
+		ShareToken:    os.Getenv(sharelink.EnvToken),
+		BreachIndex:   cmp.Or(os.Getenv(breach.EnvIndex), filepath.Join(filepath.Dir(dbPath), breach.IndexFileName)),
+		BreachAPI:     os.Getenv(breach.EnvAPI),
+		MigrationPath: database.MigrationPath,
 	}, nil
 }
@@ -2,9 +2,32 @@ package services
 
 import (
 	"yubigo-pass/internal/database"
+	"yubigo-pass/internal/database/backup"
 )
 
-// Container is a struct holding all app services
+// Container is a struct holding all app services.
+// AttachmentDir is the directory holding encrypted attachment blobs.
+// Backups snapshots the database behind Store.
+// SyncDir holds the state of vault synchronization, SyncRemote the git remote vaults are synchronized
+// with and SyncServer the sync server, along with the token it expects; a sync server takes precedence
+// over a git remote and sync is off without either.
+// ShareDir holds the links share-server keeps by default, ShareServer the share server one-time links of
+// entries are created on along with the token it expects; entries cannot be shared through links without one.
+// BreachIndex is the index of breached passwords entries are checked against, BreachAPI the range API
+// they are checked through when there is no index; entries are not checked without either.
+// MigrationPath holds the migrations applied to other database files opened, e.g. to merge them.
 type Container struct {
-	Store database.StoreExecutor
+	Store         database.StoreExecutor
+	AttachmentDir string
+	Backups       backup.Manager
+	SyncDir       string
+	SyncRemote    string
+	SyncServer    string
+	SyncToken     string
+	ShareDir      string
+	ShareServer   string
+	ShareToken    string
+	BreachIndex   string
+	BreachAPI     string
+	MigrationPath string
 }
@@ -0,0 +1,147 @@
+// Package share hands a single entry over to someone outside the installation. The entry is
+// laid out as a JSON document and encrypted with age, to the X25519 recipients of the people
+// receiving it or under a passphrase, so it opens with the age tool as well as with yubigo-pass.
+package share
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"yubigo-pass/internal/app/archive"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// Format identifies shared entries in their document
+const Format = "yubigo-pass-share"
+
+// Version is the version of the document layout written
+const Version = 1
+
+// maxHeaderLines bounds the age header read looking for a passphrase stanza
+const maxHeaderLines = 64
+
+// Errors returned when a shared entry cannot be opened
+var (
+	ErrNotShared          = errors.New("not an entry shared by yubigo-pass")
+	ErrWrongIdentity      = errors.New("the entry was not shared with this identity or passphrase")
+	ErrUnsupportedVersion = errors.New("entry was shared by a newer version of yubigo-pass")
+)
+
+// Document is the plaintext of a shared entry
+type Document struct {
+	Format  string        `json:"format"`
+	Version int           `json:"version"`
+	Shared  time.Time     `json:"shared"`
+	Entry   archive.Entry `json:"entry"`
+}
+
+// ParseRecipients parses age X25519 recipients, e.g. age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+func ParseRecipients(values []string) ([]age.Recipient, error) {
+	recipients := make([]age.Recipient, 0, len(values))
+	for _, value := range values {
+		recipient, err := age.ParseX25519Recipient(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %w", value, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// Write encrypts the entry to the recipients, as ASCII armored text when armored
+func Write(w io.Writer, entry archive.Entry, armored bool, recipients ...age.Recipient) error {
+	if len(recipients) == 0 {
+		return errors.New("share the entry with at least one recipient or a passphrase")
+	}
+	plaintext, err := json.Marshal(Document{Format: Format, Version: Version, Shared: time.Now().UTC(), Entry: entry})
+	if err != nil {
+		return fmt.Errorf("failed to encode entry: %w", err)
+	}
+
+	dst := w
+	var armorWriter io.WriteCloser
+	if armored {
+		armorWriter = armor.NewWriter(w)
+		dst = armorWriter
+	}
+	encrypted, err := age.Encrypt(dst, recipients...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt entry: %w", err)
+	}
+	if _, err = encrypted.Write(plaintext); err != nil {
+		return fmt.Errorf("failed to encrypt entry: %w", err)
+	}
+	if err = encrypted.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt entry: %w", err)
+	}
+	if armorWriter != nil {
+		return armorWriter.Close()
+	}
+	return nil
+}
+
+// NeedsPassphrase reports whether the entry was shared under a passphrase rather than to recipients
+func NeedsPassphrase(data []byte) bool {
+	lines := bufio.NewScanner(dearmor(data))
+	for i := 0; i < maxHeaderLines && lines.Scan(); i++ {
+		line := lines.Text()
+		if strings.HasPrefix(line, "---") {
+			break
+		}
+		if strings.HasPrefix(line, "-> scrypt ") {
+			return true
+		}
+	}
+	return false
+}
+
+// Read decrypts a shared entry with the identities it was shared with, see PassphraseIdentity
+// for entries shared under a passphrase
+func Read(data []byte, identities ...age.Identity) (archive.Entry, error) {
+	decrypted, err := age.Decrypt(dearmor(data), identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return archive.Entry{}, ErrWrongIdentity
+		}
+		return archive.Entry{}, fmt.Errorf("%w: %v", ErrNotShared, err)
+	}
+	plaintext, err := io.ReadAll(decrypted)
+	if err != nil {
+		return archive.Entry{}, fmt.Errorf("failed to decrypt entry: %w", err)
+	}
+
+	var doc Document
+	if err = json.Unmarshal(plaintext, &doc); err != nil || doc.Format != Format {
+		return archive.Entry{}, ErrNotShared
+	}
+	if doc.Version > Version {
+		return archive.Entry{}, ErrUnsupportedVersion
+	}
+	return doc.Entry, nil
+}
+
+// PassphraseIdentity returns the identity opening entries shared under the passphrase
+func PassphraseIdentity(passphrase string) (age.Identity, error) {
+	return age.NewScryptIdentity(passphrase)
+}
+
+// PassphraseRecipient returns the recipient sharing entries under the passphrase
+func PassphraseRecipient(passphrase string) (age.Recipient, error) {
+	return age.NewScryptRecipient(passphrase)
+}
+
+// dearmor reads ASCII armored data as the binary age file it encodes
+func dearmor(data []byte) io.Reader {
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); bytes.HasPrefix(trimmed, []byte(armor.Header)) {
+		return armor.NewReader(bytes.NewReader(trimmed))
+	}
+	return bytes.NewReader(data)
+}
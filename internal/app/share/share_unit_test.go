@@ -0,0 +1,110 @@
+//go:build unit
+
+package share
+
+import (
+	"bytes"
+	"testing"
+	"yubigo-pass/internal/app/archive"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEntry() archive.Entry {
+	return archive.Entry{
+		Type:   "login",
+		Title:  "GitHub",
+		Values: map[string]string{"username": "octocat", "password": "hunter2"},
+		Fields: []archive.Field{{Type: "hidden", Name: "PIN", Value: "4242"}},
+		Tags:   []string{"code"},
+	}
+}
+
+// testPassphraseRecipient keeps the passphrase key derivation cheap for tests
+func testPassphraseRecipient(t *testing.T, passphrase string) age.Recipient {
+	recipient, err := age.NewScryptRecipient(passphrase)
+	require.NoError(t, err)
+	recipient.SetWorkFactor(10)
+	return recipient
+}
+
+func TestWriteShouldShareEntryWithRecipients(t *testing.T) {
+	for _, armored := range []bool{false, true} {
+		t.Run(map[bool]string{false: "binary", true: "armored"}[armored], func(t *testing.T) {
+			// given
+			identity, err := age.GenerateX25519Identity()
+			require.NoError(t, err)
+			recipients, err := ParseRecipients([]string{identity.Recipient().String()})
+			require.NoError(t, err)
+			var buf bytes.Buffer
+
+			// when
+			err = Write(&buf, testEntry(), armored, recipients...)
+			require.NoError(t, err)
+			entry, readErr := Read(buf.Bytes(), identity)
+
+			// then
+			require.NoError(t, readErr)
+			assert.Equal(t, testEntry(), entry)
+			assert.False(t, NeedsPassphrase(buf.Bytes()))
+			assert.NotContains(t, buf.String(), "hunter2")
+			assert.Equal(t, armored, bytes.HasPrefix(buf.Bytes(), []byte("-----BEGIN AGE ENCRYPTED FILE-----")))
+		})
+	}
+}
+
+func TestWriteShouldShareEntryUnderPassphrase(t *testing.T) {
+	// given
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, testEntry(), true, testPassphraseRecipient(t, "correct horse")))
+	identity, err := PassphraseIdentity("correct horse")
+	require.NoError(t, err)
+	wrongIdentity, err := PassphraseIdentity("wrong horse")
+	require.NoError(t, err)
+
+	// when
+	entry, err := Read(buf.Bytes(), identity)
+	_, wrongErr := Read(buf.Bytes(), wrongIdentity)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, testEntry(), entry)
+	assert.True(t, NeedsPassphrase(buf.Bytes()))
+	assert.ErrorIs(t, wrongErr, ErrWrongIdentity)
+}
+
+func TestReadShouldRejectOtherIdentitiesAndFiles(t *testing.T) {
+	// given
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	var shared bytes.Buffer
+	require.NoError(t, Write(&shared, testEntry(), false, identity.Recipient()))
+	var plain bytes.Buffer
+	encrypted, err := age.Encrypt(&plain, identity.Recipient())
+	require.NoError(t, err)
+	_, err = encrypted.Write([]byte(`{"format":"something-else"}`))
+	require.NoError(t, err)
+	require.NoError(t, encrypted.Close())
+
+	// when
+	_, otherErr := Read(shared.Bytes(), other)
+	_, notAgeErr := Read([]byte("hello"), identity)
+	_, notSharedErr := Read(plain.Bytes(), identity)
+
+	// then
+	assert.ErrorIs(t, otherErr, ErrWrongIdentity)
+	assert.ErrorIs(t, notAgeErr, ErrNotShared)
+	assert.ErrorIs(t, notSharedErr, ErrNotShared)
+}
+
+func TestParseRecipientsShouldRejectInvalidRecipients(t *testing.T) {
+	// when
+	_, err := ParseRecipients([]string{"age1notarecipient"})
+
+	// then
+	assert.ErrorContains(t, err, `invalid age recipient "age1notarecipient"`)
+}
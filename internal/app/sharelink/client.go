@@ -0,0 +1,278 @@
+// Package sharelink hands a secret over through a one-time link of a share server, which
+// `yubigo-pass share-server` runs. The secret is sealed with a random key before it leaves the
+// device and the key only travels in the fragment of the link, which browsers never send, so the
+// server only ever stores ciphertext. A link opens a limited number of times, a single one burning
+// the secret after reading, and expires after a while.
+package sharelink
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Environment variables holding the share server links are created on
+const (
+	EnvServer = "YUBIGO_PASS_SHARE_SERVER"
+	EnvToken  = "YUBIGO_PASS_SHARE_TOKEN" // #nosec G101
+)
+
+// DirName is the directory, next to the database, share-server keeps links in by default
+const DirName = "links"
+
+// Bounds of the links created
+const (
+	DefaultExpiry = 24 * time.Hour
+	MaxExpiry     = 30 * 24 * time.Hour
+	MaxViews      = 100
+)
+
+const (
+	linkPath       = "/s/"
+	scriptPath     = "/static/open.js"
+	keySize        = 32
+	requestTimeout = 30 * time.Second
+	errGone        = "link not found, it expired or was opened as often as allowed"
+)
+
+// Errors returned when a link cannot be created or opened
+var (
+	ErrUnauthorized = errors.New("share server rejected the token")
+	ErrGone         = errors.New(errGone)
+	ErrInvalidLink  = errors.New("not a link of a share server")
+)
+
+// Options bound how long and how often a link opens. A link opening a single time burns the
+// secret after reading.
+type Options struct {
+	Expiry time.Duration
+	Views  int
+}
+
+// Link is a link created for a secret, URL carries the key of the secret in its fragment
+type Link struct {
+	URL     string
+	Expires time.Time
+	Views   int
+}
+
+// createRequest is the body of a request creating a link. Ciphertext is the sealed secret in base64.
+type createRequest struct {
+	Ciphertext string `json:"ciphertext"`
+	ExpiresIn  int64  `json:"expires_in"`
+	Views      int    `json:"views"`
+}
+
+func (r createRequest) validate() error {
+	switch {
+	case r.Ciphertext == "" || len(r.Ciphertext) > maxCiphertextSize:
+		return fmt.Errorf("secret must be sealed in 1 to %d bytes", maxCiphertextSize)
+	case r.ExpiresIn < 1 || r.ExpiresIn > int64(MaxExpiry/time.Second):
+		return fmt.Errorf("link must expire within %s", MaxExpiry)
+	case r.Views < 1 || r.Views > MaxViews:
+		return fmt.Errorf("link must open 1 to %d times", MaxViews)
+	}
+	return nil
+}
+
+// record is a link as the server keeps it, Expires in Unix milliseconds and Views the views left
+type record struct {
+	ID         string `json:"id"`
+	Ciphertext string `json:"ciphertext"`
+	Expires    int64  `json:"expires"`
+	Views      int    `json:"views"`
+}
+
+func (r record) expired(now time.Time) bool {
+	return now.UnixMilli() >= r.Expires
+}
+
+func (r record) info() info {
+	return info{ID: r.ID, Expires: r.Expires, Views: r.Views}
+}
+
+// info describes a link without its secret
+type info struct {
+	ID      string `json:"id"`
+	Expires int64  `json:"expires"`
+	Views   int    `json:"views"`
+}
+
+// opened is the answer to opening a link
+type opened struct {
+	info
+	Ciphertext string `json:"ciphertext"`
+}
+
+// errorBody is the body of a failed request
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// Client creates and opens links of a share server
+type Client struct {
+	URL   string
+	Token string
+	HTTP  *http.Client
+}
+
+// New returns new Client instance
+func New(serverURL, token string) Client {
+	return Client{
+		URL:   strings.TrimRight(serverURL, "/"),
+		Token: token,
+		HTTP:  &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Create seals the secret under a new key and stores it on the server, returning the link to
+// hand over. A zero Expiry defaults to DefaultExpiry and zero Views to a single one.
+func (c Client) Create(secret string, opts Options) (Link, error) {
+	if opts.Expiry == 0 {
+		opts.Expiry = DefaultExpiry
+	}
+	if opts.Views == 0 {
+		opts.Views = 1
+	}
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return Link{}, fmt.Errorf("failed to generate key: %w", err)
+	}
+	sealed, err := seal(key, []byte(secret))
+	if err != nil {
+		return Link{}, err
+	}
+
+	var created info
+	err = c.do(http.MethodPost, c.URL+"/v1/links", createRequest{
+		Ciphertext: base64.RawURLEncoding.EncodeToString(sealed),
+		ExpiresIn:  int64(opts.Expiry / time.Second),
+		Views:      opts.Views,
+	}, &created, http.StatusCreated)
+	if err != nil {
+		return Link{}, err
+	}
+	return Link{
+		URL:     c.URL + linkPath + created.ID + "#" + base64.RawURLEncoding.EncodeToString(key),
+		Expires: time.UnixMilli(created.Expires),
+		Views:   created.Views,
+	}, nil
+}
+
+// Open spends a view of a link and returns its secret, as the page of the link does in a browser
+func (c Client) Open(link string) (string, error) {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return "", ErrInvalidLink
+	}
+	dir, id := path.Split(parsed.Path)
+	key, err := base64.RawURLEncoding.DecodeString(parsed.Fragment)
+	if !strings.HasSuffix(dir, linkPath) || !validID.MatchString(id) || err != nil || len(key) != keySize {
+		return "", ErrInvalidLink
+	}
+	parsed.Path = strings.TrimSuffix(dir, linkPath) + "/v1/links/" + id + "/open"
+	parsed.Fragment = ""
+
+	var answer opened
+	if err = c.do(http.MethodPost, parsed.String(), nil, &answer, http.StatusOK); err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(answer.Ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("invalid answer of share server: %w", err)
+	}
+	secret, err := open(key, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// do sends a request to the share server and decodes the answer into out, expecting status
+func (c Client) do(method, target string, body, out any, expected int) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return fmt.Errorf("invalid share server URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach share server: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case expected:
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrGone
+	default:
+		var failure errorBody
+		_ = json.NewDecoder(resp.Body).Decode(&failure)
+		return fmt.Errorf("share server answered %s: %s", resp.Status, failure.Error)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid answer of share server: %w", err)
+	}
+	return nil
+}
+
+// seal encrypts a secret with AES-256-GCM as the nonce followed by the ciphertext, the layout the
+// page of a link decrypts with WebCrypto
+func seal(key, secret []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, secret, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrInvalidLink
+	}
+	secret, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrInvalidLink
+	}
+	return secret, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
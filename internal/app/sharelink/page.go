@@ -0,0 +1,101 @@
+package sharelink
+
+// pageHTML opens a link in a browser. The secret is only fetched, spending a view, once the
+// recipient asks for it.
+const pageHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>yubigo-pass shared secret</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 40rem; margin: 3rem auto; padding: 0 1rem; color: #222; }
+h1 { font-size: 1.3rem; color: #874BFD; }
+pre { white-space: pre-wrap; word-break: break-all; background: #f4f2fb; padding: 1rem; border-radius: 4px; }
+button { font-size: 1rem; padding: .5rem 1rem; }
+.muted { color: #666; }
+.error { color: #c0392b; }
+</style>
+</head>
+<body>
+<h1>Shared secret</h1>
+<p id="status" class="muted">Checking the link…</p>
+<button id="reveal" hidden>Reveal secret</button>
+<pre id="secret" hidden></pre>
+<script src="` + scriptPath + `"></script>
+</body>
+</html>
+`
+
+// pageScript checks the link, then fetches the sealed secret and decrypts it with the key in the
+// fragment, which never reaches the server
+const pageScript = `"use strict";
+(function () {
+  const id = location.pathname.split("/").pop();
+  const status = document.getElementById("status");
+  const reveal = document.getElementById("reveal");
+  const secret = document.getElementById("secret");
+
+  function fail(message) {
+    status.textContent = message;
+    status.className = "error";
+    reveal.hidden = true;
+  }
+
+  function decode(text) {
+    const base64 = text.replace(/-/g, "+").replace(/_/g, "/");
+    const binary = atob(base64 + "===".slice((base64.length + 3) % 4));
+    return Uint8Array.from(binary, (c) => c.charCodeAt(0));
+  }
+
+  function describe(link) {
+    const views = link.views === 1 ? "It opens once more and is then deleted." : "It opens " + link.views + " more times.";
+    return views + " It expires " + new Date(link.expires).toLocaleString() + ".";
+  }
+
+  async function answer(response) {
+    const body = await response.json();
+    if (!response.ok) {
+      throw new Error(body.error || response.statusText);
+    }
+    return body;
+  }
+
+  async function open() {
+    reveal.disabled = true;
+    try {
+      const link = await answer(await fetch("/v1/links/" + id + "/open", { method: "POST" }));
+      const sealed = decode(link.ciphertext);
+      const key = await crypto.subtle.importKey("raw", decode(location.hash.slice(1)), "AES-GCM", false, ["decrypt"]);
+      const plain = await crypto.subtle.decrypt({ name: "AES-GCM", iv: sealed.slice(0, 12) }, key, sealed.slice(12));
+      secret.textContent = new TextDecoder().decode(plain);
+      secret.hidden = false;
+      reveal.hidden = true;
+      status.className = "muted";
+      status.textContent = link.views === 0 ? "The secret was deleted from the server, copy it now." : describe(link);
+    } catch (err) {
+      fail(err instanceof DOMException ? "The key of the link is wrong, copy the whole link." : err.message);
+    }
+  }
+
+  async function check() {
+    if (location.hash.length < 2) {
+      return fail("The link has no key, copy the whole link including the part after #.");
+    }
+    if (!window.crypto || !crypto.subtle) {
+      return fail("Your browser cannot decrypt the secret here, open the link over HTTPS or on localhost.");
+    }
+    try {
+      const link = await answer(await fetch("/v1/links/" + id));
+      status.textContent = describe(link);
+      reveal.hidden = false;
+    } catch (err) {
+      fail(err.message);
+    }
+  }
+
+  reveal.addEventListener("click", open);
+  check();
+})();
+`
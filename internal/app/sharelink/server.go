@@ -0,0 +1,248 @@
+package sharelink
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCiphertextSize bounds the sealed secret of a link, encoded
+const maxCiphertextSize = 64 << 10
+
+// validID matches the IDs of links, which name their files
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{22}$`)
+
+// Server is the HTTP handler of a share server. It keeps the sealed secret of every link in a file
+// under its directory until the link expires or runs out of views, never seeing the key. Creating
+// links requires the token of the server when it has one, opening them only the link itself.
+type Server struct {
+	dir   string
+	token string
+	mux   *http.ServeMux
+	now   func() time.Time
+
+	mu sync.Mutex
+}
+
+// NewServer returns new Server instance storing links in dir. When token is not empty, creating a
+// link requires it as a bearer token.
+func NewServer(dir, token string) *Server {
+	s := &Server{
+		dir:   dir,
+		token: token,
+		mux:   http.NewServeMux(),
+		now:   time.Now,
+	}
+	s.mux.HandleFunc("POST /v1/links", s.createLink)
+	s.mux.HandleFunc("GET /v1/links/{id}", s.getLink)
+	s.mux.HandleFunc("POST /v1/links/{id}/open", s.openLink)
+	s.mux.HandleFunc("GET "+linkPath+"{id}", s.page)
+	s.mux.HandleFunc("GET "+scriptPath, s.script)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	s.mux.ServeHTTP(w, r)
+}
+
+// createLink stores a sealed secret, answering with the link it is opened through
+func (s *Server) createLink(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" {
+		given, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or wrong token")
+			return
+		}
+	}
+	var request createRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCiphertextSize+1024)).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := request.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	id, err := newID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	stored := record{
+		ID:         id,
+		Ciphertext: request.Ciphertext,
+		Expires:    s.now().Add(time.Duration(request.ExpiresIn) * time.Second).UnixMilli(),
+		Views:      request.Views,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purge()
+	if err = s.write(stored); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, stored.info())
+}
+
+// getLink tells whether a link can still be opened, without spending a view
+func (s *Server) getLink(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.load(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, stored.info())
+}
+
+// openLink answers with the sealed secret of a link, spending a view of it and deleting it along
+// with the secret once none is left
+func (s *Server) openLink(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.load(w, r)
+	if !ok {
+		return
+	}
+	stored.Views--
+	var err error
+	if stored.Views == 0 {
+		err = s.remove(stored.ID)
+	} else {
+		err = s.write(stored)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, opened{info: stored.info(), Ciphertext: stored.Ciphertext})
+}
+
+// page serves the page opening a link, which decrypts the secret in the browser with the key of
+// the link fragment. Showing it spends no view, so link previews do not burn the secret.
+func (s *Server) page(w http.ResponseWriter, r *http.Request) {
+	if !validID.MatchString(r.PathValue("id")) {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; script-src 'self'; connect-src 'self'; style-src 'unsafe-inline'; base-uri 'none'; form-action 'none'; frame-ancestors 'none'")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(pageHTML))
+}
+
+func (s *Server) script(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	_, _ = w.Write([]byte(pageScript))
+}
+
+// load returns the link a request is for. It answers the request itself when the link is unknown,
+// deleting it when it expired.
+func (s *Server) load(w http.ResponseWriter, r *http.Request) (record, bool) {
+	id := r.PathValue("id")
+	if !validID.MatchString(id) {
+		writeError(w, http.StatusNotFound, errGone)
+		return record{}, false
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		writeError(w, http.StatusNotFound, errGone)
+		return record{}, false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to read link: %v", err))
+		return record{}, false
+	}
+	var stored record
+	if err = json.Unmarshal(data, &stored); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to read link: %v", err))
+		return record{}, false
+	}
+	if stored.expired(s.now()) {
+		_ = s.remove(id)
+		writeError(w, http.StatusNotFound, errGone)
+		return record{}, false
+	}
+	return stored, true
+}
+
+// purge deletes the links that expired
+func (s *Server) purge() {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	now := s.now()
+	for _, file := range files {
+		data, err := os.ReadFile(file) // #nosec G304 -- files of the link directory
+		if err != nil {
+			continue
+		}
+		var stored record
+		if json.Unmarshal(data, &stored) == nil && stored.expired(now) {
+			_ = os.Remove(file)
+		}
+	}
+}
+
+// write stores a link, replacing the previous version of it atomically
+func (s *Server) write(stored record) error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create link directory: %w", err)
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to encode link: %w", err)
+	}
+	path := filepath.Join(s.dir, stored.ID+".json")
+	partial := path + ".partial"
+	if err = os.WriteFile(partial, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write link: %w", err)
+	}
+	if err = os.Rename(partial, path); err != nil {
+		return fmt.Errorf("failed to write link: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) remove(id string) error {
+	err := os.Remove(filepath.Join(s.dir, id+".json"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete link: %w", err)
+	}
+	return nil
+}
+
+// newID returns a random link ID of 128 bits
+func newID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate link ID: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(id), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorBody{Error: message})
+}
@@ -0,0 +1,156 @@
+//go:build unit
+
+package sharelink
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "s3cret"
+
+func setupServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	s := NewServer(t.TempDir(), testToken)
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func TestCreateShouldStoreOnlyCiphertextAndBurnAfterReading(t *testing.T) {
+	// given
+	s, server := setupServer(t)
+	client := New(server.URL, testToken)
+
+	// when
+	link, err := client.Create("hunter2", Options{})
+	require.NoError(t, err)
+	files, globErr := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	require.NoError(t, globErr)
+	require.Len(t, files, 1)
+	stored, readErr := os.ReadFile(files[0])
+	require.NoError(t, readErr)
+	secret, openErr := client.Open(link.URL)
+	_, againErr := client.Open(link.URL)
+
+	// then
+	require.NoError(t, openErr)
+	assert.Equal(t, "hunter2", secret)
+	assert.Equal(t, 1, link.Views)
+	assert.WithinDuration(t, time.Now().Add(DefaultExpiry), link.Expires, time.Minute)
+	parsed, err := url.Parse(link.URL)
+	require.NoError(t, err)
+	assert.NotContains(t, string(stored), "hunter2")
+	assert.NotContains(t, string(stored), parsed.Fragment, "the key stays in the link")
+	assert.ErrorIs(t, againErr, ErrGone)
+	assert.NoFileExists(t, files[0])
+}
+
+func TestOpenShouldAllowViewsOfLink(t *testing.T) {
+	// given
+	_, server := setupServer(t)
+	client := New(server.URL, testToken)
+	link, err := client.Create("hunter2", Options{Views: 2})
+	require.NoError(t, err)
+
+	// when
+	first, firstErr := client.Open(link.URL)
+	second, secondErr := client.Open(link.URL)
+	_, thirdErr := client.Open(link.URL)
+
+	// then
+	require.NoError(t, firstErr)
+	require.NoError(t, secondErr)
+	assert.Equal(t, "hunter2", first)
+	assert.Equal(t, "hunter2", second)
+	assert.ErrorIs(t, thirdErr, ErrGone)
+}
+
+func TestOpenShouldRejectExpiredLinks(t *testing.T) {
+	// given
+	s, server := setupServer(t)
+	client := New(server.URL, testToken)
+	link, err := client.Create("hunter2", Options{Expiry: time.Hour, Views: 5})
+	require.NoError(t, err)
+	s.now = func() time.Time { return time.Now().Add(time.Hour + time.Second) }
+
+	// when
+	_, openErr := client.Open(link.URL)
+
+	// then
+	assert.ErrorIs(t, openErr, ErrGone)
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	require.NoError(t, err)
+	assert.Empty(t, files, "expired links are deleted")
+}
+
+func TestOpenShouldRejectLinkWithWrongKey(t *testing.T) {
+	// given
+	_, server := setupServer(t)
+	client := New(server.URL, testToken)
+	link, err := client.Create("hunter2", Options{})
+	require.NoError(t, err)
+	other, err := client.Create("other", Options{})
+	require.NoError(t, err)
+	page, _, _ := strings.Cut(link.URL, "#")
+	_, otherKey, _ := strings.Cut(other.URL, "#")
+
+	// when
+	_, wrongKeyErr := client.Open(page + "#" + otherKey)
+	_, noKeyErr := client.Open(page)
+
+	// then
+	assert.ErrorIs(t, wrongKeyErr, ErrInvalidLink)
+	assert.ErrorIs(t, noKeyErr, ErrInvalidLink)
+}
+
+func TestCreateShouldRequireTokenAndBounds(t *testing.T) {
+	// given
+	_, server := setupServer(t)
+
+	// when
+	_, tokenErr := New(server.URL, "wrong").Create("hunter2", Options{})
+	_, viewsErr := New(server.URL, testToken).Create("hunter2", Options{Views: MaxViews + 1})
+	_, expiryErr := New(server.URL, testToken).Create("hunter2", Options{Expiry: MaxExpiry + time.Hour})
+
+	// then
+	assert.ErrorIs(t, tokenErr, ErrUnauthorized)
+	assert.ErrorContains(t, viewsErr, "link must open 1 to 100 times")
+	assert.ErrorContains(t, expiryErr, "link must expire within")
+}
+
+func TestPageShouldNotSpendViews(t *testing.T) {
+	// given
+	_, server := setupServer(t)
+	client := New(server.URL, testToken)
+	link, err := client.Create("hunter2", Options{})
+	require.NoError(t, err)
+	page, _, _ := strings.Cut(link.URL, "#")
+
+	// when
+	resp, err := http.Get(page)
+	require.NoError(t, err)
+	body, readErr := io.ReadAll(resp.Body)
+	require.NoError(t, resp.Body.Close())
+	secret, openErr := client.Open(link.URL)
+
+	// then
+	require.NoError(t, readErr)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "Reveal secret")
+	assert.NotContains(t, string(body), "hunter2")
+	assert.Contains(t, resp.Header.Get("Content-Security-Policy"), "script-src 'self'")
+	assert.Equal(t, "no-referrer", resp.Header.Get("Referrer-Policy"))
+	require.NoError(t, openErr)
+	assert.Equal(t, "hunter2", secret)
+}
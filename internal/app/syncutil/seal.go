@@ -0,0 +1,108 @@
+package syncutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/crypto"
+	"yubigo-pass/internal/app/vault"
+)
+
+// Errors returned when the entries of a sync backend cannot be opened
+var (
+	ErrWrongPassword  = errors.New("synchronized entries were sealed under another master password")
+	ErrCorruptedEntry = errors.New("synchronized entry was corrupted or swapped for another one")
+)
+
+// Manifest describes the entries a sync backend keeps for a vault. Salt is the salt the entry key
+// is derived with and Check the format sealed under that key, telling a wrong master password
+// before any entry is opened or sealed.
+type Manifest struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	Salt    string `json:"salt"`
+	Check   string `json:"check"`
+}
+
+// NewManifest returns the manifest of entries kept in format, set up with a fresh salt, along
+// with the cipher of the entries
+func NewManifest(v vault.Vault, format string, version int) (Manifest, crypto.Cipher, error) {
+	salt, err := crypto.NewSalt()
+	if err != nil {
+		return Manifest{}, crypto.Cipher{}, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	cipher, err := v.DeriveCipher(salt)
+	if err != nil {
+		return Manifest{}, crypto.Cipher{}, err
+	}
+	check, _, err := cipher.Seal([]byte(format))
+	if err != nil {
+		return Manifest{}, crypto.Cipher{}, fmt.Errorf("failed to encrypt manifest: %w", err)
+	}
+	manifest := Manifest{Format: format, Version: version, Salt: salt, Check: base64.StdEncoding.EncodeToString(check)}
+	return manifest, cipher, nil
+}
+
+// Valid reports whether the manifest is complete and describes entries kept in format
+func (m Manifest) Valid(format string) bool {
+	return m.Format == format && m.Salt != "" && m.Check != ""
+}
+
+// Cipher returns the cipher of the entries once the master password of the vault is verified to
+// open them
+func (m Manifest) Cipher(v vault.Vault) (crypto.Cipher, error) {
+	cipher, err := v.DeriveCipher(m.Salt)
+	if err != nil {
+		return crypto.Cipher{}, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(m.Check)
+	if err != nil {
+		return crypto.Cipher{}, ErrWrongPassword
+	}
+	if plaintext, err := cipher.Open(sealed); err != nil || string(plaintext) != m.Format {
+		return crypto.Cipher{}, ErrWrongPassword
+	}
+	return cipher, nil
+}
+
+// Seal encrypts an entry bound to associatedData, see AssociatedData
+func Seal(entry archive.Entry, associatedData []byte, cipher crypto.Cipher) (string, error) {
+	plaintext, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode entry: %w", err)
+	}
+	sealed, err := cipher.SealWithAssociatedData(plaintext, associatedData)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt entry: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Unseal decrypts an entry sealed by Seal with the same associatedData, under a cipher verified
+// against the manifest
+func Unseal(sealed string, associatedData []byte, cipher crypto.Cipher) (archive.Entry, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return archive.Entry{}, ErrCorruptedEntry
+	}
+	plaintext, err := cipher.OpenWithAssociatedData(data, associatedData)
+	if err != nil {
+		// the master password was verified against the manifest, the entry itself is at fault
+		return archive.Entry{}, ErrCorruptedEntry
+	}
+	var entry archive.Entry
+	if err = json.Unmarshal(plaintext, &entry); err != nil {
+		return archive.Entry{}, fmt.Errorf("failed to decode entry: %w", err)
+	}
+	return entry, nil
+}
+
+// AssociatedData binds a sealed entry to the format of its backend and the parts naming the entry
+// and its version, so a backend cannot pass it off as another entry or version without it failing
+// to open
+func AssociatedData(format string, parts ...string) []byte {
+	return []byte(strings.Join(append([]string{format}, parts...), "\n"))
+}
@@ -0,0 +1,60 @@
+// Package syncutil holds the parts of a sync every backend shares: the manifest telling a wrong
+// master password, the sealing of entries bound to their version and the storing of entries
+// received from other devices in the vault.
+package syncutil
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/importer"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+)
+
+// Change is an entry changed on another device, to be stored in the vault as changed at Modified
+type Change struct {
+	ID       string
+	Modified time.Time
+	Entry    archive.Entry
+}
+
+// Apply stores entries changed on other devices, returning those clashing with another entry of
+// the vault by title and username. Clashing entries are retried as long as applying the others
+// clears clashes, another device may have renamed the entry they clash with.
+func Apply(v vault.Vault, pending []Change) ([]Change, error) {
+	for {
+		var clashing []Change
+		for _, change := range pending {
+			err := Put(v, change)
+			var duplicate model.PasswordAlreadyExistsError
+			if errors.As(err, &duplicate) {
+				clashing = append(clashing, change)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(clashing) == len(pending) {
+			return clashing, nil
+		}
+		pending = clashing
+	}
+}
+
+// Put stores a synchronized entry in the vault
+func Put(v vault.Vault, change Change) error {
+	record := importer.ArchiveRecord(change.Entry)
+	err := v.PutItem(change.ID, change.Modified, record.Item, record.Fields, record.Folder, record.Tags)
+	if err != nil {
+		return fmt.Errorf("failed to sync %s: %w", change.Entry.Title, err)
+	}
+	return nil
+}
+
+// ConflictTitle names the copy of an entry kept from a conflict
+func ConflictTitle(title string, modified time.Time) string {
+	return fmt.Sprintf("%s (conflict copy %s)", title, modified.UTC().Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,105 @@
+//go:build integration
+
+package syncutil
+
+import (
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/archive"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test/vaulttest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func login(title string) archive.Entry {
+	return archive.Entry{Type: string(model.ItemTypeLogin), Title: title, Values: model.ItemValues{"username": "octocat", "password": "hunter2"}}
+}
+
+func TestApplyShouldRetryEntryClashingUntilOtherEntryIsRenamed(t *testing.T) {
+	// given
+	v := vaulttest.New(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "x"}, nil, "", nil))
+	existing, err := v.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	now := time.Now()
+	// the new entry comes first, it clashes until the existing one is renamed
+	pending := []Change{
+		{ID: uuid.New().String(), Modified: now, Entry: login("GitHub")},
+		{ID: existing.ID, Modified: now, Entry: login("GitHub (work)")},
+	}
+
+	// when
+	clashing, err := Apply(v, pending)
+
+	// then
+	require.NoError(t, err)
+	assert.Empty(t, clashing)
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	var titles []string
+	for _, entry := range entries {
+		titles = append(titles, entry.Title)
+	}
+	assert.ElementsMatch(t, []string{"GitHub", "GitHub (work)"}, titles)
+}
+
+func TestApplyShouldReturnEntriesStillClashing(t *testing.T) {
+	// given
+	v := vaulttest.New(t)
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: "x"}, nil, "", nil))
+	change := Change{ID: uuid.New().String(), Modified: time.Now(), Entry: login("GitHub")}
+
+	// when
+	clashing, err := Apply(v, []Change{change})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []Change{change}, clashing)
+}
+
+func TestConflictTitleShouldNameCopyAfterTimeOfChange(t *testing.T) {
+	// when
+	title := ConflictTitle("GitHub", time.Date(2024, 5, 17, 9, 30, 0, 0, time.UTC))
+
+	// then
+	assert.Equal(t, "GitHub (conflict copy 2024-05-17 09:30)", title)
+}
+
+func TestManifestShouldOpenEntriesOnlyUnderMasterPasswordItWasSetUpWith(t *testing.T) {
+	// given
+	v := vaulttest.New(t)
+	manifest, cipher, err := NewManifest(v, "yubigo-pass-test", 1)
+	require.NoError(t, err)
+
+	// when
+	opened, err := manifest.Cipher(v)
+	_, otherErr := manifest.Cipher(vaulttest.New(t))
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, cipher, opened)
+	assert.True(t, manifest.Valid("yubigo-pass-test"))
+	assert.False(t, manifest.Valid("yubigo-pass-other"))
+	assert.ErrorIs(t, otherErr, ErrWrongPassword)
+}
+
+func TestUnsealShouldRejectEntryBoundToOtherVersion(t *testing.T) {
+	// given
+	v := vaulttest.New(t)
+	_, cipher, err := NewManifest(v, "yubigo-pass-test", 1)
+	require.NoError(t, err)
+	sealed, err := Seal(login("GitHub"), AssociatedData("yubigo-pass-test", "id", "1"), cipher)
+	require.NoError(t, err)
+
+	// when
+	entry, err := Unseal(sealed, AssociatedData("yubigo-pass-test", "id", "1"), cipher)
+	_, swappedErr := Unseal(sealed, AssociatedData("yubigo-pass-test", "id", "2"), cipher)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, login("GitHub"), entry)
+	assert.ErrorIs(t, swappedErr, ErrCorruptedEntry)
+}
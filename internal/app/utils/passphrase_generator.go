@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/rand"
+	"embed"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"unicode"
+	"yubigo-pass/internal/app/model"
+)
+
+// --- Passphrase Generation Constants ---
+const (
+	MinWords           = 3  // Fewest words a passphrase policy may ask for
+	MaxWords           = 20 // Most words a passphrase policy may ask for
+	MaxSeparatorLength = 3  // Longest separator between the words of a passphrase
+)
+
+// wordlistFiles are the EFF wordlists passphrases are drawn from, in their original format of
+// dice rolls followed by a tab and the word, see https://www.eff.org/dice
+//
+//go:embed wordlists/*.txt
+var wordlistFiles embed.FS
+
+// wordlistPaths maps the names of the embedded wordlists to their files
+var wordlistPaths = map[string]string{
+	model.WordlistEFFLarge: "wordlists/eff_large_wordlist.txt",
+	model.WordlistEFFShort: "wordlists/eff_short_wordlist_2_0.txt",
+}
+
+// wordlists caches the parsed wordlists, each one is read once
+var wordlists = map[string]func() ([]string, error){}
+
+func init() {
+	for name, path := range wordlistPaths {
+		wordlists[name] = sync.OnceValues(func() ([]string, error) { return readWordlist(path) })
+	}
+}
+
+// Wordlist returns the words of the embedded wordlist called name
+func Wordlist(name string) ([]string, error) {
+	if name == "" {
+		name = model.WordlistEFFLarge
+	}
+	load, ok := wordlists[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown wordlist %q, expected one of %s", name, strings.Join(model.Wordlists, ", "))
+	}
+	return load()
+}
+
+// readWordlist parses an embedded wordlist file
+func readWordlist(path string) ([]string, error) {
+	f, err := wordlistFiles.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wordlist: %w", err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		_, word, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok || word == "" {
+			return nil, fmt.Errorf("malformed line in wordlist %s: %q", path, scanner.Text())
+		}
+		words = append(words, word)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read wordlist: %w", err)
+	}
+	return words, nil
+}
+
+// GeneratePassphrase creates a random passphrase following a passphrase mode policy, see
+// ValidatePasswordPolicy.
+func GeneratePassphrase(policy model.PasswordPolicy) (string, error) {
+	words, digits, symbols, err := passphraseParts(policy)
+	if err != nil {
+		return "", err
+	}
+
+	picked := make([]string, policy.Words)
+	for i := range picked {
+		n, err := randomIndex(len(words))
+		if err != nil {
+			return "", err
+		}
+		word := words[n]
+		switch policy.Capitalization {
+		case model.CapitalizeFirst:
+			word = capitalize(word)
+		case model.CapitalizeAll:
+			word = strings.ToUpper(word)
+		case model.CapitalizeRandom:
+			coin, err := randomIndex(2)
+			if err != nil {
+				return "", err
+			}
+			if coin == 1 {
+				word = capitalize(word)
+			}
+		}
+		picked[i] = word
+	}
+	passphrase := strings.Join(picked, policy.Separator)
+
+	for _, chars := range []string{digits, symbols} {
+		if chars == "" {
+			continue
+		}
+		n, err := randomIndex(len(chars))
+		if err != nil {
+			return "", err
+		}
+		passphrase += chars[n : n+1]
+	}
+	return passphrase, nil
+}
+
+// PolicyEntropy returns the entropy in bits of the passwords generated with a valid policy. For
+// character passwords it counts every character as drawn from all enabled classes, which slightly
+// overestimates policies asking for minimum counts.
+func PolicyEntropy(policy model.PasswordPolicy) (float64, error) {
+	if policy.IsPassphrase() {
+		words, digits, symbols, err := passphraseParts(policy)
+		if err != nil {
+			return 0, err
+		}
+		bits := float64(policy.Words) * math.Log2(float64(len(words)))
+		if policy.Capitalization == model.CapitalizeRandom {
+			bits += float64(policy.Words)
+		}
+		for _, chars := range []string{digits, symbols} {
+			if chars != "" {
+				bits += math.Log2(float64(len(chars)))
+			}
+		}
+		return bits, nil
+	}
+
+	classes, err := policyClasses(policy)
+	if err != nil {
+		return 0, err
+	}
+	distinct := 0
+	for _, class := range classes {
+		distinct += len(class.chars)
+	}
+	bits := 0.0
+	for i := range policy.Length {
+		choices := distinct
+		if policy.NoRepeat {
+			choices -= i
+		}
+		bits += math.Log2(float64(choices))
+	}
+	return bits, nil
+}
+
+// passphraseParts validates a passphrase policy and returns the words of its wordlist with the
+// digits and symbols to append one of, each empty when not appended
+func passphraseParts(policy model.PasswordPolicy) (words []string, digits, symbols string, err error) {
+	if policy.Words < MinWords || policy.Words > MaxWords {
+		return nil, "", "", fmt.Errorf("passphrase must have between %d and %d words", MinWords, MaxWords)
+	}
+	if len(policy.Separator) > MaxSeparatorLength {
+		return nil, "", "", fmt.Errorf("separator can be at most %d characters long", MaxSeparatorLength)
+	}
+	for _, r := range policy.Separator {
+		if r > unicode.MaxASCII || unicode.IsLetter(r) || unicode.IsControl(r) {
+			return nil, "", "", fmt.Errorf("separator may only contain ASCII digits, symbols and spaces, got %q", r)
+		}
+	}
+	switch policy.Capitalization {
+	case "", model.CapitalizeNone, model.CapitalizeFirst, model.CapitalizeAll, model.CapitalizeRandom:
+	default:
+		return nil, "", "", fmt.Errorf("unknown capitalization %q", policy.Capitalization)
+	}
+	if words, err = Wordlist(policy.Wordlist); err != nil {
+		return nil, "", "", err
+	}
+
+	if policy.AppendDigit {
+		if digits = uniqueChars(digitChars, policy.ExcludeAmbiguous); digits == "" {
+			return nil, "", "", errors.New("no digits left to append")
+		}
+	}
+	if policy.AppendSymbol {
+		set := symbolChars
+		if policy.SymbolSet != "" {
+			if err = validateSymbolSet(policy.SymbolSet); err != nil {
+				return nil, "", "", err
+			}
+			set = policy.SymbolSet
+		}
+		if symbols = uniqueChars(set, policy.ExcludeAmbiguous); symbols == "" {
+			return nil, "", "", errors.New("no symbols left to append")
+		}
+	}
+	return words, digits, symbols, nil
+}
+
+// capitalize upper-cases the first letter of word
+func capitalize(word string) string {
+	return strings.ToUpper(word[:1]) + word[1:]
+}
+
+// randomIndex returns a uniformly random index below n
+func randomIndex(n int) (int, error) {
+	num, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+	return int(num.Int64()), nil
+}
@@ -0,0 +1,191 @@
+//go:build unit
+
+package utils
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode"
+	"yubigo-pass/internal/app/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordlist_Embedded(t *testing.T) {
+	testCases := []struct {
+		name          string
+		wordlist      string
+		expectedCount int
+		expectedFirst string
+		expectedLast  string
+	}{
+		{"EFF Large", model.WordlistEFFLarge, 7776, "abacus", "zoom"},
+		{"EFF Short", model.WordlistEFFShort, 1296, "aardvark", "zucchini"},
+		{"Default", "", 7776, "abacus", "zoom"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			words, err := Wordlist(tc.wordlist)
+
+			// then
+			require.NoError(t, err)
+			assert.Len(t, words, tc.expectedCount)
+			assert.Equal(t, tc.expectedFirst, words[0])
+			assert.Equal(t, tc.expectedLast, words[len(words)-1])
+		})
+	}
+}
+
+func TestWordlist_Unknown(t *testing.T) {
+	// when
+	_, err := Wordlist("klingon")
+
+	// then
+	assert.ErrorContains(t, err, `unknown wordlist "klingon", expected one of eff-large, eff-short`)
+}
+
+func TestGeneratePasswordWithPolicy_Passphrase(t *testing.T) {
+	// given
+	policy := model.PasswordPolicy{
+		Mode:           model.GeneratorModePassphrase,
+		Words:          5,
+		Wordlist:       model.WordlistEFFShort,
+		Separator:      ".",
+		Capitalization: model.CapitalizeFirst,
+		AppendDigit:    true,
+		AppendSymbol:   true,
+		SymbolSet:      "#",
+	}
+	words, err := Wordlist(model.WordlistEFFShort)
+	require.NoError(t, err)
+
+	for range 20 {
+		// when
+		passphrase, err := GeneratePasswordWithPolicy(policy)
+
+		// then
+		require.NoError(t, err)
+		require.True(t, strings.HasSuffix(passphrase, "#"), "passphrase %s does not end with the symbol", passphrase)
+		body := strings.TrimSuffix(passphrase, "#")
+		require.True(t, unicode.IsDigit(rune(body[len(body)-1])), "passphrase %s does not end with a digit", passphrase)
+		picked := strings.Split(body[:len(body)-1], ".")
+		assert.Len(t, picked, policy.Words)
+		for _, word := range picked {
+			assert.True(t, unicode.IsUpper(rune(word[0])), "word %s is not capitalized", word)
+			assert.True(t, slices.Contains(words, strings.ToLower(word)), "word %s is not on the wordlist", word)
+		}
+	}
+}
+
+func TestGeneratePasswordWithPolicy_PassphraseCapitalization(t *testing.T) {
+	testCases := []struct {
+		name           string
+		capitalization model.Capitalization
+		matches        func(string) bool
+	}{
+		{"None", model.CapitalizeNone, func(s string) bool { return s == strings.ToLower(s) }},
+		{"All", model.CapitalizeAll, func(s string) bool { return s == strings.ToUpper(s) }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			policy := model.DefaultPasswordPolicy
+			policy.Mode = model.GeneratorModePassphrase
+			policy.Capitalization = tc.capitalization
+			policy.Separator = " "
+
+			// when
+			passphrase, err := GeneratePasswordWithPolicy(policy)
+
+			// then
+			require.NoError(t, err)
+			assert.Len(t, strings.Fields(passphrase), 6)
+			assert.True(t, tc.matches(passphrase), "passphrase %s is not capitalized as %s", passphrase, tc.capitalization)
+		})
+	}
+}
+
+func TestPolicyEntropy(t *testing.T) {
+	passphrase := model.DefaultPasswordPolicy
+	passphrase.Mode = model.GeneratorModePassphrase
+
+	testCases := []struct {
+		name     string
+		policy   func() model.PasswordPolicy
+		expected float64
+	}{
+		{"Characters", func() model.PasswordPolicy { return model.PasswordPolicy{Length: 10, Digits: true} }, 33.22},
+		{"Characters Without Repeats", func() model.PasswordPolicy {
+			return model.PasswordPolicy{Length: 10, Digits: true, NoRepeat: true}
+		}, 21.79},
+		{"Default Passphrase", func() model.PasswordPolicy { return passphrase }, 77.55},
+		{"Random Capitalization", func() model.PasswordPolicy {
+			p := passphrase
+			p.Capitalization = model.CapitalizeRandom
+			return p
+		}, 83.55},
+		{"Short Wordlist With Digit And Symbol", func() model.PasswordPolicy {
+			p := passphrase
+			p.Wordlist, p.Words, p.AppendDigit, p.AppendSymbol, p.SymbolSet = model.WordlistEFFShort, 4, true, true, "!@#$"
+			return p
+		}, 46.68},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			bits, err := PolicyEntropy(tc.policy())
+
+			// then
+			require.NoError(t, err)
+			assert.InDelta(t, tc.expected, bits, 0.01)
+		})
+	}
+}
+
+func TestValidatePasswordPolicy_Passphrase(t *testing.T) {
+	passphrase := func(change func(*model.PasswordPolicy)) model.PasswordPolicy {
+		policy := model.DefaultPasswordPolicy
+		policy.Mode = model.GeneratorModePassphrase
+		change(&policy)
+		return policy
+	}
+
+	testCases := []struct {
+		name          string
+		policy        model.PasswordPolicy
+		expectedError string
+	}{
+		{name: "Default", policy: passphrase(func(*model.PasswordPolicy) {})},
+		{name: "Character Settings Ignored", policy: passphrase(func(p *model.PasswordPolicy) {
+			p.Length, p.Lower, p.Upper, p.Digits, p.Symbols = 0, false, false, false, false
+		})},
+		{name: "Empty Separator", policy: passphrase(func(p *model.PasswordPolicy) { p.Separator = "" })},
+		{name: "Too Few Words", policy: passphrase(func(p *model.PasswordPolicy) { p.Words = 2 }), expectedError: "passphrase must have between 3 and 20 words"},
+		{name: "Too Many Words", policy: passphrase(func(p *model.PasswordPolicy) { p.Words = 21 }), expectedError: "passphrase must have between 3 and 20 words"},
+		{name: "Long Separator", policy: passphrase(func(p *model.PasswordPolicy) { p.Separator = " -- " }), expectedError: "separator can be at most 3 characters long"},
+		{name: "Letters In Separator", policy: passphrase(func(p *model.PasswordPolicy) { p.Separator = "x" }), expectedError: "separator may only contain ASCII digits, symbols and spaces, got 'x'"},
+		{name: "Unknown Capitalization", policy: passphrase(func(p *model.PasswordPolicy) { p.Capitalization = "camel" }), expectedError: `unknown capitalization "camel"`},
+		{name: "Unknown Wordlist", policy: passphrase(func(p *model.PasswordPolicy) { p.Wordlist = "klingon" }), expectedError: `unknown wordlist "klingon"`},
+		{name: "Only Ambiguous Symbols", policy: passphrase(func(p *model.PasswordPolicy) { p.AppendSymbol, p.SymbolSet, p.ExcludeAmbiguous = true, "|`", true }), expectedError: "no symbols left to append"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			err := ValidatePasswordPolicy(tc.policy)
+
+			// then
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
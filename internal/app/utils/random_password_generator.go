@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"unicode"
+	"yubigo-pass/internal/app/model"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -16,55 +18,85 @@ const (
 	uppercaseChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	digitChars     = "0123456789"
 	symbolChars    = "!@#$%^&*()-_=+[]{}|;:,.<>/?"
-	DefaultLength  = 20 // Default password length
+	ambiguousChars = "Il1|O0o`'\""
+	DefaultLength  = 20  // Default password length
+	MinLength      = 4   // Shortest password a policy may ask for, GeneratePassword accepts any length
+	MaxLength      = 128 // Longest password a policy may ask for, GeneratePassword accepts any length
 )
 
-// GeneratePassword creates a random password with specified criteria.
+// charClass is a character class of a password policy with the characters it may use
+type charClass struct {
+	name  string
+	chars string
+	min   int
+}
+
+// GeneratePassword creates a random password with specified criteria, using every included
+// character set at least once as far as the length allows. Unlike policies, the length is not
+// bound by MinLength and MaxLength.
 func GeneratePassword(length int, includeLower, includeUpper, includeDigits, includeSymbols bool) (string, error) {
 	if length <= 0 {
 		length = DefaultLength
 	}
-
-	var charSet string
-	if includeLower {
-		charSet += lowercaseChars
-	}
-	if includeUpper {
-		charSet += uppercaseChars
+	room := length
+	atLeastOnce := func(include bool) int {
+		if !include || room == 0 {
+			return 0
+		}
+		room--
+		return 1
 	}
-	if includeDigits {
-		charSet += digitChars
+	return generatePassword(model.PasswordPolicy{
+		Length:     length,
+		Lower:      includeLower,
+		Upper:      includeUpper,
+		Digits:     includeDigits,
+		Symbols:    includeSymbols,
+		MinLower:   atLeastOnce(includeLower),
+		MinUpper:   atLeastOnce(includeUpper),
+		MinDigits:  atLeastOnce(includeDigits),
+		MinSymbols: atLeastOnce(includeSymbols),
+	})
+}
+
+// GeneratePasswordWithPolicy creates a random password following policy, or a passphrase for a
+// passphrase mode policy, see ValidatePasswordPolicy.
+func GeneratePasswordWithPolicy(policy model.PasswordPolicy) (string, error) {
+	if policy.IsPassphrase() {
+		return GeneratePassphrase(policy)
 	}
-	if includeSymbols {
-		charSet += symbolChars
+	if err := validateLength(policy.Length); err != nil {
+		return "", err
 	}
+	return generatePassword(policy)
+}
 
-	if charSet == "" {
-		return "", errors.New("no character sets selected for password generation")
+// generatePassword creates a random password following policy whatever its length
+func generatePassword(policy model.PasswordPolicy) (string, error) {
+	classes, err := policyClasses(policy)
+	if err != nil {
+		return "", err
 	}
 
-	password := make([]byte, length)
-	max := big.NewInt(int64(len(charSet)))
-
-	for i := 0; i < length; i++ {
-		num, err := rand.Int(rand.Reader, max)
-		if err != nil {
-			return "", fmt.Errorf("failed to generate random number: %w", err)
+	password := make([]byte, 0, policy.Length)
+	used := make(map[byte]bool, policy.Length)
+	var all string
+	for _, class := range classes {
+		for range min(class.min, policy.Length) {
+			c, err := pickChar(class.chars, used, policy.NoRepeat)
+			if err != nil {
+				return "", err
+			}
+			password = append(password, c)
 		}
-		password[i] = charSet[num.Int64()]
-	}
-
-	missingChars := map[bool]string{
-		includeLower:   lowercaseChars,
-		includeUpper:   uppercaseChars,
-		includeDigits:  digitChars,
-		includeSymbols: symbolChars,
+		all += class.chars
 	}
-
-	for include, chars := range missingChars {
-		if include && !strings.ContainsAny(string(password), chars) {
-			password = addMissingCharacters(password, length, chars)
+	for len(password) < policy.Length {
+		c, err := pickChar(all, used, policy.NoRepeat)
+		if err != nil {
+			return "", err
 		}
+		password = append(password, c)
 	}
 
 	// Fisher-Yates shuffle
@@ -80,11 +112,131 @@ func GeneratePassword(length int, includeLower, includeUpper, includeDigits, inc
 	return string(password), nil
 }
 
-func addMissingCharacters(password []byte, length int, characterSet string) []byte {
-	idx, _ := rand.Int(rand.Reader, big.NewInt(int64(length)-1))
-	charIdx, _ := rand.Int(rand.Reader, big.NewInt(int64(len(characterSet))-1))
-	password[idx.Int64()] = lowercaseChars[charIdx.Int64()]
-	return password
+// ValidatePasswordPolicy checks that passwords following policy can be generated: the length is
+// within MinLength and MaxLength, at least one character class is enabled, every enabled class has
+// characters left after excluding ambiguous ones, the minimums fit the length, and without repeats
+// there are enough distinct characters. Passphrase mode policies need MinWords to MaxWords words
+// of a known wordlist, a short separator without letters and a known capitalization.
+func ValidatePasswordPolicy(policy model.PasswordPolicy) error {
+	if policy.IsPassphrase() {
+		_, _, _, err := passphraseParts(policy)
+		return err
+	}
+	if err := validateLength(policy.Length); err != nil {
+		return err
+	}
+	_, err := policyClasses(policy)
+	return err
+}
+
+// validateLength checks that a policy asks for a password within MinLength and MaxLength characters
+func validateLength(length int) error {
+	if length < MinLength || length > MaxLength {
+		return fmt.Errorf("password length must be between %d and %d", MinLength, MaxLength)
+	}
+	return nil
+}
+
+// policyClasses returns the enabled character classes of a valid policy, leaving the bounds of its
+// length to validateLength
+func policyClasses(policy model.PasswordPolicy) ([]charClass, error) {
+	symbols := symbolChars
+	if policy.SymbolSet != "" {
+		if err := validateSymbolSet(policy.SymbolSet); err != nil {
+			return nil, err
+		}
+		symbols = policy.SymbolSet
+	}
+
+	candidates := []struct {
+		include bool
+		class   charClass
+	}{
+		{policy.Lower, charClass{name: "lowercase letters", chars: lowercaseChars, min: policy.MinLower}},
+		{policy.Upper, charClass{name: "uppercase letters", chars: uppercaseChars, min: policy.MinUpper}},
+		{policy.Digits, charClass{name: "digits", chars: digitChars, min: policy.MinDigits}},
+		{policy.Symbols, charClass{name: "symbols", chars: symbols, min: policy.MinSymbols}},
+	}
+
+	var classes []charClass
+	required, distinct := 0, 0
+	for _, candidate := range candidates {
+		if !candidate.include {
+			continue
+		}
+		class := candidate.class
+		class.chars = uniqueChars(class.chars, policy.ExcludeAmbiguous)
+		if class.chars == "" {
+			return nil, fmt.Errorf("no %s left to generate passwords with", class.name)
+		}
+		if class.min < 0 {
+			return nil, fmt.Errorf("minimum count of %s cannot be negative", class.name)
+		}
+		if policy.NoRepeat && class.min > len(class.chars) {
+			return nil, fmt.Errorf("at most %d %s can be used without repeats", len(class.chars), class.name)
+		}
+		required += class.min
+		distinct += len(class.chars)
+		classes = append(classes, class)
+	}
+
+	switch {
+	case len(classes) == 0:
+		return nil, errors.New("no character sets selected for password generation")
+	case required > policy.Length:
+		return nil, fmt.Errorf("minimum counts add up to %d characters, more than the length of %d", required, policy.Length)
+	case policy.NoRepeat && policy.Length > distinct:
+		return nil, fmt.Errorf("only %d distinct characters are allowed, too few for %d characters without repeats", distinct, policy.Length)
+	}
+	return classes, nil
+}
+
+// validateSymbolSet checks that a custom symbol set only holds ASCII symbols
+func validateSymbolSet(set string) error {
+	for _, r := range set {
+		if r > unicode.MaxASCII || !(unicode.IsPunct(r) || unicode.IsSymbol(r)) {
+			return fmt.Errorf("symbol set may only contain ASCII symbols, got %q", r)
+		}
+	}
+	return nil
+}
+
+// uniqueChars returns the characters of chars without duplicates, and without ambiguous ones when excluded
+func uniqueChars(chars string, excludeAmbiguous bool) string {
+	var b strings.Builder
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		if strings.IndexByte(chars[:i], c) >= 0 || (excludeAmbiguous && strings.IndexByte(ambiguousChars, c) >= 0) {
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// pickChar picks a random character of chars, skipping the used ones when noRepeat is set
+func pickChar(chars string, used map[byte]bool, noRepeat bool) (byte, error) {
+	candidates := chars
+	if noRepeat {
+		var b strings.Builder
+		for i := 0; i < len(chars); i++ {
+			if !used[chars[i]] {
+				b.WriteByte(chars[i])
+			}
+		}
+		candidates = b.String()
+	}
+	if candidates == "" {
+		return 0, errors.New("ran out of characters to generate the password with")
+	}
+
+	num, err := rand.Int(rand.Reader, big.NewInt(int64(len(candidates))))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+	c := candidates[num.Int64()]
+	used[c] = true
+	return c, nil
 }
 
 // GetStrengthStyle returns the style for the password strength score.
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"yubigo-pass/internal/app/model"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/stretchr/testify/assert"
@@ -38,6 +39,25 @@ func TestGeneratePassword_Defaults(t *testing.T) {
 	}
 }
 
+func TestGeneratePassword_LengthsOutsidePolicyBounds(t *testing.T) {
+	for _, length := range []int{1, 2, MinLength - 1, MaxLength + 1} {
+		t.Run(fmt.Sprintf("Length %d", length), func(t *testing.T) {
+			// when
+			password, err := GeneratePassword(length, true, true, true, true)
+
+			// then
+			require.NoError(t, err)
+			assert.Len(t, password, length)
+			if length >= 4 {
+				assert.Positive(t, countChars(password, lowercaseChars))
+				assert.Positive(t, countChars(password, uppercaseChars))
+				assert.Positive(t, countChars(password, digitChars))
+				assert.Positive(t, countChars(password, symbolChars))
+			}
+		})
+	}
+}
+
 func TestGeneratePassword_NoCharsets(t *testing.T) {
 	// given
 	length := 10
@@ -293,22 +313,97 @@ func TestGetStrengthStyle(t *testing.T) {
 	}
 }
 
-func TestShouldAddMissingCharacters(t *testing.T) {
+func TestGeneratePasswordWithPolicy_MinimumCounts(t *testing.T) {
+	// given
+	policy := model.PasswordPolicy{
+		Length:     12,
+		Lower:      true,
+		Digits:     true,
+		Symbols:    true,
+		MinDigits:  5,
+		MinSymbols: 4,
+		SymbolSet:  "#!",
+	}
+
+	for range 20 {
+		// when
+		password, err := GeneratePasswordWithPolicy(policy)
+
+		// then
+		require.NoError(t, err)
+		assert.Len(t, password, policy.Length)
+		assert.GreaterOrEqual(t, countChars(password, digitChars), policy.MinDigits)
+		assert.GreaterOrEqual(t, countChars(password, "#!"), policy.MinSymbols)
+		assert.Equal(t, policy.Length, countChars(password, lowercaseChars+digitChars+"#!"))
+	}
+}
+
+func TestGeneratePasswordWithPolicy_ExcludeAmbiguousAndNoRepeat(t *testing.T) {
+	// given
+	policy := model.PasswordPolicy{
+		Length:           30,
+		Upper:            true,
+		Digits:           true,
+		ExcludeAmbiguous: true,
+		NoRepeat:         true,
+	}
+
+	for range 20 {
+		// when
+		password, err := GeneratePasswordWithPolicy(policy)
+
+		// then
+		require.NoError(t, err)
+		assert.Len(t, password, policy.Length)
+		assert.False(t, containsAny(password, ambiguousChars), "password %s contains ambiguous characters", password)
+		seen := map[rune]bool{}
+		for _, char := range password {
+			assert.False(t, seen[char], "password %s repeats '%c'", password, char)
+			seen[char] = true
+		}
+	}
+}
+
+func TestValidatePasswordPolicy(t *testing.T) {
 	testCases := []struct {
-		name         string
-		password     string
-		characterSet string
+		name          string
+		policy        model.PasswordPolicy
+		expectedError string
 	}{
-		{name: "Lowercase", password: "aaaaa", characterSet: lowercaseChars},
-		{name: "Uppercase", password: "AAAAA", characterSet: uppercaseChars},
-		{name: "Digits", password: "11111", characterSet: digitChars},
-		{name: "Symbols", password: "!!!!!", characterSet: symbolChars},
+		{name: "Default", policy: model.DefaultPasswordPolicy},
+		{name: "Too Short", policy: model.PasswordPolicy{Length: 3, Lower: true}, expectedError: "password length must be between 4 and 128"},
+		{name: "Too Long", policy: model.PasswordPolicy{Length: 129, Lower: true}, expectedError: "password length must be between 4 and 128"},
+		{name: "No Charsets", policy: model.PasswordPolicy{Length: 10}, expectedError: "no character sets selected"},
+		{name: "Minimums Over Length", policy: model.PasswordPolicy{Length: 5, Lower: true, Digits: true, MinLower: 3, MinDigits: 3}, expectedError: "minimum counts add up to 6 characters, more than the length of 5"},
+		{name: "Minimum Of Disabled Class Ignored", policy: model.PasswordPolicy{Length: 5, Lower: true, MinDigits: 10}},
+		{name: "Negative Minimum", policy: model.PasswordPolicy{Length: 5, Lower: true, MinLower: -1}, expectedError: "minimum count of lowercase letters cannot be negative"},
+		{name: "Letters In Symbol Set", policy: model.PasswordPolicy{Length: 5, Symbols: true, SymbolSet: "#a"}, expectedError: "symbol set may only contain ASCII symbols, got 'a'"},
+		{name: "Only Ambiguous Symbols", policy: model.PasswordPolicy{Length: 5, Symbols: true, SymbolSet: "|`", ExcludeAmbiguous: true}, expectedError: "no symbols left to generate passwords with"},
+		{name: "Too Few Distinct Characters", policy: model.PasswordPolicy{Length: 11, Digits: true, NoRepeat: true}, expectedError: "only 10 distinct characters are allowed, too few for 11 characters without repeats"},
+		{name: "Minimum Over Distinct Characters", policy: model.PasswordPolicy{Length: 40, Lower: true, Digits: true, MinDigits: 11, NoRepeat: true}, expectedError: "at most 10 digits can be used without repeats"},
 	}
+
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			changedPassword := addMissingCharacters([]byte(tc.password), len(tc.password), tc.characterSet)
+			// when
+			err := ValidatePasswordPolicy(tc.policy)
 
-			assert.NotEqual(t, tc.password, changedPassword, "Password slice should have been modified")
+			// then
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.expectedError)
 		})
 	}
 }
+
+func countChars(s, chars string) int {
+	count := 0
+	for _, char := range s {
+		if strings.ContainsRune(chars, char) {
+			count++
+		}
+	}
+	return count
+}
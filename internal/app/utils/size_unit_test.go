@@ -0,0 +1,33 @@
+//go:build unit
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSize(t *testing.T) {
+	// given
+	testCases := []struct {
+		name     string
+		bytes    int64
+		expected string
+	}{
+		{"should format bytes", 512, "512 B"},
+		{"should format kibibytes", 1536, "1.5 KiB"},
+		{"should format mebibytes", 100 * 1024 * 1024, "100.0 MiB"},
+		{"should format gibibytes", 3 * 1024 * 1024 * 1024, "3.0 GiB"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			size := FormatSize(tc.bytes)
+
+			// then
+			assert.Equal(t, tc.expected, size)
+		})
+	}
+}
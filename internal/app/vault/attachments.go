@@ -0,0 +1,255 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"yubigo-pass/internal/app/model"
+
+	"github.com/google/uuid"
+)
+
+// MaxAttachmentSize is the default size limit of a single attached file
+const MaxAttachmentSize int64 = 100 * 1024 * 1024
+
+// AttachmentDirName is the name of the blob directory kept next to the DB file
+const AttachmentDirName = "attachments"
+
+// Attachments stores encrypted files attached to the entries of a vault. File contents are
+// streamed through the vault cipher into a blob directory next to the DB, each blob named by
+// the SHA-256 of its ciphertext, while the DB only keeps the encrypted name and the blob reference.
+type Attachments struct {
+	vault Vault
+	dir   string
+	limit int64
+}
+
+// NewAttachments returns new Attachments instance storing blobs in dir
+func NewAttachments(v Vault, dir string) Attachments {
+	return Attachments{
+		vault: v,
+		dir:   dir,
+		limit: MaxAttachmentSize,
+	}
+}
+
+// WithLimit returns a copy of the attachments accepting files of at most limit bytes
+func (a Attachments) WithLimit(limit int64) Attachments {
+	a.limit = limit
+	return a
+}
+
+// Limit returns the maximum size of an attached file in bytes
+func (a Attachments) Limit() int64 {
+	return a.limit
+}
+
+// Record appends an event about an entry to the audit log of the vault the attachments belong to, see Vault.Record
+func (a Attachments) Record(event model.AuditEvent, client model.AuditClient, entry model.Password) error {
+	return a.vault.Record(event, client, entry)
+}
+
+// Add encrypts the content read from src and attaches it to an entry under name.
+// Names are unique per entry, a file larger than the limit is rejected.
+func (a Attachments) Add(entryID, name string, src io.Reader) (model.Attachment, error) {
+	if !a.vault.session.IsAuthenticated() {
+		return model.Attachment{}, errors.New("no active user session")
+	}
+	if err := a.vault.writable(); err != nil {
+		return model.Attachment{}, err
+	}
+	name = filepath.Base(name)
+	if name == "." || name == string(filepath.Separator) {
+		return model.Attachment{}, errors.New("attachment name cannot be empty")
+	}
+	if _, err := a.vault.Entry(entryID); err != nil {
+		return model.Attachment{}, err
+	}
+	existing, err := a.List(entryID)
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	for _, attachment := range existing {
+		if attachment.Name == name {
+			return model.Attachment{}, fmt.Errorf("attachment %s already exists", name)
+		}
+	}
+
+	id := uuid.New().String()
+	blob, size, err := a.writeBlob(id, name, src)
+	if err != nil {
+		return model.Attachment{}, err
+	}
+
+	encryptedName, err := a.vault.cipher.EncryptString(name)
+	if err != nil {
+		_ = os.Remove(a.blobPath(blob))
+		return model.Attachment{}, fmt.Errorf("failed to encrypt attachment name: %w", err)
+	}
+	err = a.vault.store.AddAttachment(model.NewAttachment(id, entryID, encryptedName, size, blob))
+	if err != nil {
+		_ = os.Remove(a.blobPath(blob))
+		return model.Attachment{}, err
+	}
+	return model.NewAttachment(id, entryID, name, size, blob), nil
+}
+
+// writeBlob encrypts src into a temporary file and moves it to its content address,
+// returning the blob name and the plaintext size
+func (a Attachments) writeBlob(id, name string, src io.Reader) (string, int64, error) {
+	err := os.MkdirAll(a.dir, 0700)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(a.dir, "upload-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	hash := sha256.New()
+	// Read one byte past the limit to tell a file of exactly the limit from a larger one
+	size, err := a.vault.cipher.EncryptStream(io.MultiWriter(tmp, hash), io.LimitReader(src, a.limit+1), []byte(id))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to encrypt attachment: %w", err)
+	}
+	if size > a.limit {
+		return "", 0, model.NewAttachmentTooLargeError(name, a.limit)
+	}
+	if err = tmp.Sync(); err != nil {
+		return "", 0, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	blob := hex.EncodeToString(hash.Sum(nil))
+	path := a.blobPath(blob)
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", 0, fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	return blob, size, nil
+}
+
+// List returns the attachments of an entry with their names decrypted, sorted by name
+func (a Attachments) List(entryID string) ([]model.Attachment, error) {
+	stored, err := a.vault.store.GetPasswordAttachments(entryID)
+	if err != nil {
+		return nil, err
+	}
+	attachments := make([]model.Attachment, 0, len(stored))
+	for _, attachment := range stored {
+		attachment.Name, err = a.vault.cipher.DecryptString(attachment.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt attachment name: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+	sort.SliceStable(attachments, func(i, j int) bool {
+		return attachments[i].Name < attachments[j].Name
+	})
+	return attachments, nil
+}
+
+// Find returns the attachment of an entry with the given name
+func (a Attachments) Find(entryID, name string) (model.Attachment, error) {
+	attachments, err := a.List(entryID)
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	for _, attachment := range attachments {
+		if attachment.Name == name {
+			return attachment, nil
+		}
+	}
+	return model.Attachment{}, model.NewAttachmentNotFoundError(name)
+}
+
+// Extract decrypts an attachment into dst. The blob is checked against its content address,
+// and an error is returned after the plaintext was written when the check fails,
+// so callers writing to a file should discard it on error.
+func (a Attachments) Extract(attachment model.Attachment, dst io.Writer) error {
+	file, err := os.Open(a.blobPath(attachment.Blob))
+	if err != nil {
+		return fmt.Errorf("failed to open attachment %s: %w", attachment.Name, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = a.vault.cipher.DecryptStream(dst, io.TeeReader(file, hash), []byte(attachment.ID))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt attachment %s: %w", attachment.Name, err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != attachment.Blob {
+		return fmt.Errorf("attachment %s does not match its checksum", attachment.Name)
+	}
+	return nil
+}
+
+// ExtractToFile decrypts an attachment into a new file at path, readable by the owner only.
+// The plaintext is written to a temporary file first, so a failed extraction leaves nothing behind.
+func (a Attachments) ExtractToFile(attachment model.Attachment, path string, overwrite bool) error {
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return fmt.Errorf("%s already exists", path)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".extract-*")
+	if err != nil {
+		return fmt.Errorf("failed to extract attachment %s: %w", attachment.Name, err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if err = a.Extract(attachment, tmp); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to extract attachment %s: %w", attachment.Name, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Remove deletes an attachment and its blob
+func (a Attachments) Remove(attachment model.Attachment) error {
+	if err := a.vault.writable(); err != nil {
+		return err
+	}
+	err := a.vault.store.DeleteAttachment(attachment.ID)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(a.blobPath(attachment.Blob))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove attachment %s: %w", attachment.Name, err)
+	}
+	return nil
+}
+
+// RemoveBlob deletes the blob of an attachment whose record is already gone,
+// e.g. after the transaction adding it was rolled back
+func (a Attachments) RemoveBlob(attachment model.Attachment) {
+	_ = os.Remove(a.blobPath(attachment.Blob))
+}
+
+// blobPath returns the location of a blob, fanned out by the first two hex digits of its name
+func (a Attachments) blobPath(blob string) string {
+	if len(blob) < 2 {
+		return filepath.Join(a.dir, blob)
+	}
+	return filepath.Join(a.dir, blob[:2], blob)
+}
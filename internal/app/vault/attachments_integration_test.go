@@ -0,0 +1,162 @@
+//go:build integration
+
+package vault
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"yubigo-pass/internal/app/crypto"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAttachments returns attachments of a vault holding a single entry, along with the entry
+func setupAttachments(t *testing.T) (Attachments, model.Password, database.StoreExecutor) {
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	t.Cleanup(func() { test.TeardownTestDB(db) })
+	store := database.NewStore(db)
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := New(store, session)
+
+	entry := model.Password{Title: test.RandomString(), Username: test.RandomString(), Password: test.RandomString()}
+	require.NoError(t, v.AddEntry(entry, nil, "", nil))
+	stored, err := v.FindEntry(entry.Title, entry.Username)
+	require.NoError(t, err)
+
+	return NewAttachments(v, t.TempDir()), stored, store
+}
+
+func TestShouldAttachAndExtractFile(t *testing.T) {
+	// setup
+	attachments, entry, store := setupAttachments(t)
+
+	// given
+	content := make([]byte, 3*crypto.StreamChunkSize+100)
+	_, err := rand.Read(content)
+	require.NoError(t, err)
+
+	// when
+	attachment, err := attachments.Add(entry.ID, "/tmp/recovery-codes.pdf", bytes.NewReader(content))
+	require.NoError(t, err)
+	var extracted bytes.Buffer
+	err = attachments.Extract(attachment, &extracted)
+
+	// then
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(content, extracted.Bytes()))
+	assert.Equal(t, "recovery-codes.pdf", attachment.Name)
+	assert.Equal(t, int64(len(content)), attachment.Size)
+
+	stored, err := store.GetPasswordAttachments(entry.ID)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.NotEqual(t, attachment.Name, stored[0].Name)
+	blob, err := os.ReadFile(attachments.blobPath(attachment.Blob))
+	require.NoError(t, err)
+	assert.NotContains(t, string(blob), string(content[:64]))
+}
+
+func TestShouldListAttachmentsSortedByName(t *testing.T) {
+	// setup
+	attachments, entry, _ := setupAttachments(t)
+
+	// given
+	for _, name := range []string{"license.key", "backup.pdf"} {
+		_, err := attachments.Add(entry.ID, name, bytes.NewReader([]byte(test.RandomString())))
+		require.NoError(t, err)
+	}
+
+	// when
+	listed, err := attachments.List(entry.ID)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, "backup.pdf", listed[0].Name)
+	assert.Equal(t, "license.key", listed[1].Name)
+}
+
+func TestShouldNotAttachFileLargerThanLimit(t *testing.T) {
+	// setup
+	attachments, entry, _ := setupAttachments(t)
+	attachments = attachments.WithLimit(10)
+
+	// given
+	content := []byte("eleven byte")
+
+	// when
+	_, err := attachments.Add(entry.ID, "too-large.bin", bytes.NewReader(content))
+
+	// then
+	assert.EqualError(t, err, model.NewAttachmentTooLargeError("too-large.bin", 10).Error())
+	listed, err := attachments.List(entry.ID)
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+	blobs, err := filepath.Glob(filepath.Join(attachments.dir, "*", "*"))
+	require.NoError(t, err)
+	assert.Empty(t, blobs)
+}
+
+func TestShouldNotAttachFileWithDuplicateName(t *testing.T) {
+	// setup
+	attachments, entry, _ := setupAttachments(t)
+
+	// given
+	_, err := attachments.Add(entry.ID, "license.key", bytes.NewReader([]byte(test.RandomString())))
+	require.NoError(t, err)
+
+	// when
+	_, err = attachments.Add(entry.ID, "license.key", bytes.NewReader([]byte(test.RandomString())))
+
+	// then
+	assert.EqualError(t, err, "attachment license.key already exists")
+}
+
+func TestShouldRemoveAttachment(t *testing.T) {
+	// setup
+	attachments, entry, _ := setupAttachments(t)
+
+	// given
+	attachment, err := attachments.Add(entry.ID, "license.key", bytes.NewReader([]byte(test.RandomString())))
+	require.NoError(t, err)
+
+	// when
+	err = attachments.Remove(attachment)
+
+	// then
+	require.NoError(t, err)
+	_, err = attachments.Find(entry.ID, "license.key")
+	assert.EqualError(t, err, model.NewAttachmentNotFoundError("license.key").Error())
+	_, err = os.Stat(attachments.blobPath(attachment.Blob))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestShouldNotExtractTamperedAttachment(t *testing.T) {
+	// setup
+	attachments, entry, _ := setupAttachments(t)
+
+	// given
+	attachment, err := attachments.Add(entry.ID, "license.key", bytes.NewReader([]byte(test.RandomString())))
+	require.NoError(t, err)
+	path := attachments.blobPath(attachment.Blob)
+	blob, err := os.ReadFile(path)
+	require.NoError(t, err)
+	blob[len(blob)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, blob, 0600))
+
+	// when
+	err = attachments.Extract(attachment, &bytes.Buffer{})
+
+	// then
+	assert.ErrorIs(t, err, crypto.ErrStreamCorrupted)
+}
@@ -0,0 +1,143 @@
+package vault
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/database"
+)
+
+// AuditEntry is a decrypted event of the audit log of a vault. EntryID and Title refer to the entry
+// the event is about, both are empty for events about the whole vault such as exports.
+type AuditEntry struct {
+	Seq     int64
+	Time    time.Time
+	Event   model.AuditEvent
+	Client  model.AuditClient
+	EntryID string
+	Title   string
+}
+
+// AuditFilter narrows the audit log down to events of a kind, through a client, about entries whose
+// title contains Entry regardless of case, or that happened from Since on. Zero values match every event.
+type AuditFilter struct {
+	Event  model.AuditEvent
+	Client model.AuditClient
+	Entry  string
+	Since  time.Time
+}
+
+// matches reports whether an event passes the filter
+func (f AuditFilter) matches(entry AuditEntry) bool {
+	return (f.Event == "" || entry.Event == f.Event) &&
+		(f.Client == "" || entry.Client == f.Client) &&
+		(f.Entry == "" || strings.Contains(strings.ToLower(entry.Title), strings.ToLower(f.Entry))) &&
+		(f.Since.IsZero() || !entry.Time.Before(f.Since))
+}
+
+// auditData is the part of an audit record sealed with the key of the vault
+type auditData struct {
+	Event   model.AuditEvent  `json:"event"`
+	Client  model.AuditClient `json:"client"`
+	EntryID string            `json:"entry_id,omitempty"`
+	Title   string            `json:"title,omitempty"`
+}
+
+// Record appends an event about entry through client to the audit log of the vault. A zero entry
+// records an event about the whole vault. Readers of shared collections record their events too.
+func (v Vault) Record(event model.AuditEvent, client model.AuditClient, entry model.Password) error {
+	if !v.session.IsAuthenticated() {
+		return errors.New("no active user session")
+	}
+	encoded, err := json.Marshal(auditData{Event: event, Client: client, EntryID: entry.ID, Title: entry.Title})
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event: %w", err)
+	}
+	sealed, err := v.cipher.EncryptString(string(encoded))
+	if err != nil {
+		return err
+	}
+	_, err = v.store.AppendAuditRecord(model.AuditRecord{UserID: v.UserID(), CreatedAt: time.Now().UnixMilli(), Data: sealed}, v.auditHash)
+	return err
+}
+
+// auditHash returns the hash of an audit record of the vault, a MAC under its key
+func (v Vault) auditHash(record model.AuditRecord) string {
+	return hex.EncodeToString(v.cipher.MAC(record.ChainData()))
+}
+
+// AuditLog decrypts the audit log of the vault and returns the events passing filter, the latest first.
+// The whole log is verified first, see VerifyAuditLog, and the records of the vault are checked
+// against their hash, returning an AuditChainBrokenError at the first one altered.
+func (v Vault) AuditLog(filter AuditFilter) ([]AuditEntry, error) {
+	if !v.session.IsAuthenticated() {
+		return nil, errors.New("no active user session")
+	}
+	if err := VerifyAuditLog(v.store); err != nil {
+		return nil, err
+	}
+	records, err := v.store.GetUserAuditLog(v.UserID())
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if v.auditHash(record) != record.Hash {
+			return nil, model.NewAuditChainBrokenError(record.Seq)
+		}
+	}
+
+	var entries []AuditEntry
+	for _, record := range slices.Backward(records) {
+		plaintext, err := v.cipher.DecryptString(record.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt audit record %d: %w", record.Seq, err)
+		}
+		var data auditData
+		if err = json.Unmarshal([]byte(plaintext), &data); err != nil {
+			return nil, fmt.Errorf("failed to decode audit record %d: %w", record.Seq, err)
+		}
+		entry := AuditEntry{
+			Seq:     record.Seq,
+			Time:    time.UnixMilli(record.CreatedAt),
+			Event:   data.Event,
+			Client:  data.Client,
+			EntryID: data.EntryID,
+			Title:   data.Title,
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// VerifyAuditLog walks the hash chain of the audit log of every vault in store, returning an
+// AuditChainBrokenError at the first record removed, inserted out of order or cut off the end. It
+// needs no keys, so the log is verified before anybody logs in. Altered records are told by their
+// hash, which only the vault they belong to checks, see AuditLog.
+func VerifyAuditLog(store database.StoreExecutor) error {
+	records, err := store.GetAuditLog()
+	if err != nil {
+		return err
+	}
+	prevHash := ""
+	for i, record := range records {
+		if record.Seq != int64(i)+1 || record.PrevHash != prevHash {
+			return model.NewAuditChainBrokenError(record.Seq)
+		}
+		prevHash = record.Hash
+	}
+	length, err := store.GetAuditLogLength()
+	if err != nil {
+		return err
+	}
+	if length != int64(len(records)) {
+		return model.NewAuditChainBrokenError(int64(len(records)) + 1)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+//go:build integration
+
+package vault
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogShouldReturnRecordedEventsLatestFirst(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	bob := setupUserVault(t, db, "bob")
+	github := model.Password{ID: "github-id", Title: "GitHub"}
+	bank := model.Password{ID: "bank-id", Title: "Bank"}
+	require.NoError(t, alice.Record(model.AuditCreated, model.ClientTUI, github))
+	require.NoError(t, alice.Record(model.AuditRead, model.ClientCLI, github))
+	require.NoError(t, bob.Record(model.AuditRead, model.ClientCLI, bank))
+	require.NoError(t, alice.Record(model.AuditExported, model.ClientCLI, model.Password{}))
+
+	// when
+	events, err := alice.AuditLog(AuditFilter{})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, model.AuditExported, events[0].Event)
+	assert.Empty(t, events[0].EntryID)
+	assert.Equal(t, model.AuditRead, events[1].Event)
+	assert.Equal(t, model.ClientCLI, events[1].Client)
+	assert.Equal(t, "github-id", events[1].EntryID)
+	assert.Equal(t, "GitHub", events[1].Title)
+	assert.Equal(t, model.AuditCreated, events[2].Event)
+	assert.WithinDuration(t, time.Now(), events[2].Time, time.Minute)
+}
+
+func TestAuditLogShouldFilterEvents(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	require.NoError(t, alice.Record(model.AuditRead, model.ClientTUI, model.Password{ID: "1", Title: "GitHub"}))
+	require.NoError(t, alice.Record(model.AuditRead, model.ClientCLI, model.Password{ID: "1", Title: "GitHub"}))
+	require.NoError(t, alice.Record(model.AuditChanged, model.ClientCLI, model.Password{ID: "1", Title: "GitHub"}))
+	require.NoError(t, alice.Record(model.AuditRead, model.ClientCLI, model.Password{ID: "2", Title: "Bank"}))
+
+	// when
+	reads, err := alice.AuditLog(AuditFilter{Event: model.AuditRead, Client: model.ClientCLI, Entry: "git"})
+	require.NoError(t, err)
+	future, err := alice.AuditLog(AuditFilter{Since: time.Now().Add(time.Hour)})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, reads, 1)
+	assert.Equal(t, "GitHub", reads[0].Title)
+	assert.Equal(t, model.ClientCLI, reads[0].Client)
+	assert.Empty(t, future)
+}
+
+func TestAuditLogShouldRecordEventsOfEmergencyContactsInOwnerLog(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	bob := setupUserVault(t, db, "bob")
+	require.NoError(t, alice.AddEntry(model.Password{Title: "Bank", Username: "alice", Password: "hunter2"}, nil, "", nil))
+	require.NoError(t, alice.AddEmergencyContact("bob", 0))
+	_, err := bob.RequestEmergencyAccess(alice.UserID())
+	require.NoError(t, err)
+	require.NoError(t, alice.GrantDueEmergencyAccess())
+	takenOver, err := bob.EmergencyVault(alice.UserID())
+	require.NoError(t, err)
+	entry, err := takenOver.FindEntry("Bank", "alice")
+	require.NoError(t, err)
+
+	// when
+	require.NoError(t, takenOver.Record(model.AuditRead, model.ClientTUI, entry))
+
+	// then
+	events, err := alice.AuditLog(AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "Bank", events[0].Title)
+	bobEvents, err := bob.AuditLog(AuditFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, bobEvents)
+}
+
+func TestVerifyAuditLogShouldDetectTampering(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	for _, title := range []string{"GitHub", "Bank", "Mail"} {
+		require.NoError(t, alice.Record(model.AuditRead, model.ClientCLI, model.Password{Title: title}))
+	}
+	store := database.NewStore(db)
+	intactErr := VerifyAuditLog(store)
+
+	// when
+	_, err := db.Exec(`DROP TRIGGER audit_log_no_delete`)
+	require.NoError(t, err)
+	_, err = db.Exec(`DELETE FROM audit_log WHERE seq = 2`)
+	require.NoError(t, err)
+
+	// then
+	assert.NoError(t, intactErr)
+	err = VerifyAuditLog(store)
+	var brokenErr model.AuditChainBrokenError
+	require.True(t, errors.As(err, &brokenErr))
+	assert.Equal(t, int64(3), brokenErr.Seq)
+}
+
+func TestAuditLogShouldDetectAlteredRecords(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	require.NoError(t, alice.Record(model.AuditRead, model.ClientCLI, model.Password{Title: "GitHub"}))
+	require.NoError(t, alice.Record(model.AuditExported, model.ClientCLI, model.Password{}))
+
+	// when
+	_, err := db.Exec(`DROP TRIGGER audit_log_no_update`)
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE audit_log SET created_at = created_at + 1000 WHERE seq = 1`)
+	require.NoError(t, err)
+
+	// then
+	_, err = alice.AuditLog(AuditFilter{})
+	assert.EqualError(t, err, "audit log was tampered with at record 1")
+}
+
+func TestAuditLogShouldRejectRecordsHashedWithoutVaultKey(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	mallory := setupUserVault(t, db, "mallory")
+	require.NoError(t, alice.Record(model.AuditRead, model.ClientCLI, model.Password{Title: "GitHub"}))
+	forged, err := alice.cipher.EncryptString(`{"event":"read","client":"cli","title":"Bank"}`)
+	require.NoError(t, err)
+
+	// when
+	_, err = database.NewStore(db).AppendAuditRecord(model.AuditRecord{UserID: alice.UserID(), CreatedAt: 1, Data: forged}, mallory.auditHash)
+	require.NoError(t, err)
+
+	// then
+	assert.NoError(t, VerifyAuditLog(database.NewStore(db)))
+	_, err = alice.AuditLog(AuditFilter{})
+	assert.EqualError(t, err, "audit log was tampered with at record 2")
+}
+
+func TestVerifyAuditLogShouldDetectRecordsCutOffTheEnd(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	for _, title := range []string{"GitHub", "Bank", "Mail"} {
+		require.NoError(t, alice.Record(model.AuditRead, model.ClientCLI, model.Password{Title: title}))
+	}
+
+	// when
+	_, err := db.Exec(`DROP TRIGGER audit_log_no_delete`)
+	require.NoError(t, err)
+	_, err = db.Exec(`DELETE FROM audit_log WHERE seq = 3`)
+	require.NoError(t, err)
+
+	// then
+	assert.EqualError(t, VerifyAuditLog(database.NewStore(db)), "audit log was tampered with at record 3")
+}
@@ -0,0 +1,264 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"yubigo-pass/internal/app/crypto"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/utils"
+
+	"github.com/google/uuid"
+)
+
+// ErrReadOnly is returned when changing a collection the member may only read
+var ErrReadOnly = errors.New("you can only read this collection")
+
+// ErrNotAdmin is returned when a member who is not an admin invites another user
+var ErrNotAdmin = errors.New("only admins of the collection can invite members")
+
+// EnsureKeyPair returns the public key of the vault owner, generating their key pair on first use.
+// The private key is stored encrypted with the vault key. Users get invited to collections by sealing
+// the collection key to their public key, so they need a key pair first.
+func (v Vault) EnsureKeyPair() ([]byte, error) {
+	if v.IsCollection() {
+		return nil, errors.New("collections have no key pair")
+	}
+	key, err := v.store.GetUserKey(v.UserID())
+	if err == nil {
+		return key.PublicKey, nil
+	}
+	if !errors.As(err, &model.UserKeyNotFoundError{}) {
+		return nil, err
+	}
+
+	public, private, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	sealed, _, err := v.cipher.Seal(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt private key: %w", err)
+	}
+	if err = v.store.CreateUserKey(model.NewUserKey(v.UserID(), public, sealed)); err != nil {
+		return nil, err
+	}
+	return public, nil
+}
+
+// IsCollection reports whether the vault holds the entries of a shared collection
+func (v Vault) IsCollection() bool {
+	return v.role != ""
+}
+
+// Role returns the role of the member who opened the vault of a collection, empty for the vault of a user
+func (v Vault) Role() model.Role {
+	return v.role
+}
+
+// CreateCollection creates a shared collection with a new random key, making the vault owner its admin
+func (v Vault) CreateCollection(name string) (model.Collection, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return model.Collection{}, errors.New("collection name cannot be empty")
+	}
+	public, err := v.EnsureKeyPair()
+	if err != nil {
+		return model.Collection{}, err
+	}
+
+	key, err := crypto.GenerateAESKey()
+	if err != nil {
+		return model.Collection{}, fmt.Errorf("failed to generate collection key: %w", err)
+	}
+	sealedName, err := crypto.NewCipher(key).EncryptString(name)
+	if err != nil {
+		return model.Collection{}, fmt.Errorf("failed to encrypt collection name: %w", err)
+	}
+	sealedKey, err := crypto.SealToPublicKey(public, key)
+	if err != nil {
+		return model.Collection{}, err
+	}
+
+	collection := model.NewCollection(uuid.New().String(), name)
+	err = v.Transaction(func(tx Vault) error {
+		if err := tx.store.CreateCollection(model.NewCollection(collection.ID, sealedName)); err != nil {
+			return err
+		}
+		return tx.store.AddCollectionMember(model.NewCollectionMember(collection.ID, v.UserID(), model.RoleAdmin, sealedKey))
+	})
+	if err != nil {
+		return model.Collection{}, err
+	}
+	return collection, nil
+}
+
+// Collections returns the memberships of the vault owner with the names of their collections decrypted,
+// sorted by name
+func (v Vault) Collections() ([]model.CollectionMember, error) {
+	memberships, err := v.store.GetUserCollections(v.UserID())
+	if err != nil || len(memberships) == 0 {
+		return memberships, err
+	}
+	public, private, err := v.keyPair()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, membership := range memberships {
+		collectionKey, err := crypto.OpenWithPrivateKey(public, private, membership.SealedKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open collection key: %w", err)
+		}
+		memberships[i].Name, err = crypto.NewCipher(collectionKey).DecryptString(membership.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt collection name: %w", err)
+		}
+	}
+	slices.SortFunc(memberships, func(a, b model.CollectionMember) int { return strings.Compare(a.Name, b.Name) })
+	return memberships, nil
+}
+
+// Collection opens the vault of a collection the vault owner is a member of
+func (v Vault) Collection(collectionID string) (Vault, error) {
+	membership, key, err := v.membership(collectionID)
+	if err != nil {
+		return Vault{}, err
+	}
+	return Vault{
+		store:   v.store,
+		session: utils.NewSession(collectionID, "", ""),
+		cipher:  crypto.NewCipher(key),
+		role:    membership.Role,
+	}, nil
+}
+
+// Members lists the members of a collection the vault owner is a member of, ordered by username
+func (v Vault) Members(collectionID string) ([]model.CollectionMember, error) {
+	if _, _, err := v.membership(collectionID); err != nil {
+		return nil, err
+	}
+	return v.store.GetCollectionMembers(collectionID)
+}
+
+// Invite makes another local user a member of a collection with a role, sealing the collection key
+// to their public key. Only admins of the collection may invite.
+func (v Vault) Invite(collectionID, username string, role model.Role) error {
+	if _, err := model.ParseRole(string(role)); err != nil {
+		return err
+	}
+	membership, key, err := v.membership(collectionID)
+	if err != nil {
+		return err
+	}
+	if !membership.Role.CanAdmin() {
+		return ErrNotAdmin
+	}
+
+	user, err := v.store.GetUser(username)
+	if err != nil {
+		if errors.As(err, &model.UserNotFoundError{}) {
+			return fmt.Errorf("there is no user named %s", username)
+		}
+		return err
+	}
+	userKey, err := v.store.GetUserKey(user.UserID)
+	if err != nil {
+		if errors.As(err, &model.UserKeyNotFoundError{}) {
+			return fmt.Errorf("%s has no key pair yet, they need to log in once before being invited", username)
+		}
+		return err
+	}
+	sealedKey, err := crypto.SealToPublicKey(userKey.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	err = v.store.AddCollectionMember(model.NewCollectionMember(collectionID, user.UserID, role, sealedKey))
+	if errors.As(err, &model.MemberAlreadyExistsError{}) {
+		return fmt.Errorf("%s is already a member of the collection", username)
+	}
+	return err
+}
+
+// MoveToCollection moves an entry of the vault owner into the vault of a collection, re-encrypting it
+// with the collection key. The entry is filed under its first folder in the collection.
+func (v Vault) MoveToCollection(entry model.Password, collection Vault) error {
+	if v.IsCollection() || !collection.IsCollection() {
+		return errors.New("entries can only be moved from the vault of a user into a collection")
+	}
+	if err := collection.writable(); err != nil {
+		return err
+	}
+	attachments, err := v.store.GetPasswordAttachments(entry.ID)
+	if err != nil {
+		return err
+	}
+	if len(attachments) > 0 {
+		return fmt.Errorf("%s has attachments, which cannot be moved into a collection yet", entry.Title)
+	}
+	details, err := v.Reveal(entry)
+	if err != nil {
+		return err
+	}
+	var folder string
+	if len(details.Folders) > 0 {
+		folder = details.Folders[0]
+	}
+
+	return v.Transaction(func(tx Vault) error {
+		if err := tx.store.ClearPasswordPlacement(entry.ID); err != nil {
+			return err
+		}
+		if err := tx.store.SetPasswordOwner(entry, collection.UserID()); err != nil {
+			return err
+		}
+		shared := collection
+		shared.store = tx.store
+		return shared.PutItem(entry.ID, time.Now(), details.Item, details.Fields, folder, details.Tags)
+	})
+}
+
+// membership returns the membership of the vault owner in a collection along with the collection key
+func (v Vault) membership(collectionID string) (model.CollectionMember, []byte, error) {
+	memberships, err := v.store.GetUserCollections(v.UserID())
+	if err != nil {
+		return model.CollectionMember{}, nil, err
+	}
+	i := slices.IndexFunc(memberships, func(m model.CollectionMember) bool { return m.CollectionID == collectionID })
+	if i < 0 {
+		return model.CollectionMember{}, nil, fmt.Errorf("you are not a member of collection %s", collectionID)
+	}
+
+	public, private, err := v.keyPair()
+	if err != nil {
+		return model.CollectionMember{}, nil, err
+	}
+	collectionKey, err := crypto.OpenWithPrivateKey(public, private, memberships[i].SealedKey)
+	if err != nil {
+		return model.CollectionMember{}, nil, fmt.Errorf("failed to open collection key: %w", err)
+	}
+	return memberships[i], collectionKey, nil
+}
+
+// keyPair returns the public and the decrypted private key of the vault owner
+func (v Vault) keyPair() ([]byte, []byte, error) {
+	key, err := v.store.GetUserKey(v.UserID())
+	if err != nil {
+		return nil, nil, err
+	}
+	private, err := v.cipher.Open(key.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+	return key.PublicKey, private, nil
+}
+
+// writable checks the vault may be changed, which members of a collection with the read role may not
+func (v Vault) writable() error {
+	if v.IsCollection() && !v.role.CanWrite() {
+		return ErrReadOnly
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+//go:build integration
+
+package vault
+
+import (
+	"testing"
+	"yubigo-pass/internal/app/crypto"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+	"yubigo-pass/test/usertest"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupUserVault adds a user with a key pair to the test database and opens their vault, the way
+// vaulttest.NewUser does for the tests of other packages
+func setupUserVault(t *testing.T, db *sqlx.DB, username string) Vault {
+	t.Helper()
+	v := New(database.NewStore(db), usertest.Insert(t, db, username, test.RandomString()))
+	_, err := v.EnsureKeyPair()
+	require.NoError(t, err)
+	return v
+}
+
+func TestEnsureKeyPairShouldGenerateKeyPairOnce(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := New(database.NewStore(db), usertest.Insert(t, db, "alice", test.RandomString()))
+
+	// when
+	first, err := alice.EnsureKeyPair()
+	require.NoError(t, err)
+	second, err := alice.EnsureKeyPair()
+
+	// then
+	require.NoError(t, err)
+	assert.Len(t, first, crypto.KeySize)
+	assert.Equal(t, first, second)
+	public, private, err := alice.keyPair()
+	require.NoError(t, err)
+	assert.Equal(t, first, public)
+	assert.Len(t, private, crypto.KeySize)
+}
+
+func TestShouldShareEntriesOfCollectionWithInvitedMembers(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	bob := setupUserVault(t, db, "bob")
+	require.NoError(t, alice.AddEntry(model.Password{Title: "Router", Username: "admin", Password: "hunter2", Notes: "in the hall"},
+		[]model.CustomField{{Type: model.FieldTypeHidden, Name: "PIN", Value: "1234"}}, "Home/Network", []string{"wifi"}))
+	entry, err := alice.FindEntry("Router", "admin")
+	require.NoError(t, err)
+
+	// when
+	collection, err := alice.CreateCollection(" Family ")
+	require.NoError(t, err)
+	require.NoError(t, alice.Invite(collection.ID, "bob", model.RoleWrite))
+	shared, err := alice.Collection(collection.ID)
+	require.NoError(t, err)
+	require.NoError(t, alice.MoveToCollection(entry, shared))
+
+	// then
+	assert.Equal(t, "Family", collection.Name)
+	own, err := alice.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, own)
+
+	collections, err := bob.Collections()
+	require.NoError(t, err)
+	require.Len(t, collections, 1)
+	assert.Equal(t, "Family", collections[0].Name)
+	assert.Equal(t, model.RoleWrite, collections[0].Role)
+	bobShared, err := bob.Collection(collection.ID)
+	require.NoError(t, err)
+	moved, err := bobShared.FindEntry("Router", "admin")
+	require.NoError(t, err)
+	details, err := bobShared.Reveal(moved)
+	require.NoError(t, err)
+	assert.Equal(t, entry.ID, moved.ID)
+	assert.Equal(t, "hunter2", details.Entry.Password)
+	assert.Equal(t, "in the hall", details.Entry.Notes)
+	assert.Equal(t, []model.CustomField{model.NewCustomField(entry.ID, 0, model.FieldTypeHidden, "PIN", "1234")}, details.Fields)
+	assert.Equal(t, []string{"Home/Network"}, details.Folders)
+	assert.Equal(t, []string{"wifi"}, details.Tags)
+
+	members, err := bob.Members(collection.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, "alice", members[0].Username)
+	assert.Equal(t, model.RoleAdmin, members[0].Role)
+	assert.Equal(t, "bob", members[1].Username)
+}
+
+func TestShouldNotLetReadersChangeCollection(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	bob := setupUserVault(t, db, "bob")
+	setupUserVault(t, db, "carol")
+	collection, err := alice.CreateCollection("Family")
+	require.NoError(t, err)
+	require.NoError(t, alice.Invite(collection.ID, "bob", model.RoleRead))
+	require.NoError(t, bob.AddEntry(model.Password{Title: "Bank", Username: "bob", Password: "secret"}, nil, "", nil))
+	entry, err := bob.FindEntry("Bank", "bob")
+	require.NoError(t, err)
+	shared, err := bob.Collection(collection.ID)
+	require.NoError(t, err)
+
+	// when
+	addErr := shared.AddEntry(model.Password{Title: "Mail", Username: "bob", Password: "secret"}, nil, "", nil)
+	moveErr := bob.MoveToCollection(entry, shared)
+	inviteErr := bob.Invite(collection.ID, "carol", model.RoleRead)
+
+	// then
+	assert.ErrorIs(t, addErr, ErrReadOnly)
+	assert.ErrorIs(t, moveErr, ErrReadOnly)
+	assert.ErrorIs(t, inviteErr, ErrNotAdmin)
+	own, err := bob.Entries()
+	require.NoError(t, err)
+	assert.Len(t, own, 1)
+}
+
+func TestInviteShouldRejectUnknownMembersAndUsers(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	bob := setupUserVault(t, db, "bob")
+	usertest.Insert(t, db, "dave", test.RandomString())
+	collection, err := alice.CreateCollection("Family")
+	require.NoError(t, err)
+	require.NoError(t, alice.Invite(collection.ID, "bob", model.RoleRead))
+
+	testCases := []struct {
+		name     string
+		username string
+		role     model.Role
+		expected string
+	}{
+		{name: "unknown user", username: "erin", role: model.RoleRead, expected: "there is no user named erin"},
+		{name: "user without key pair", username: "dave", role: model.RoleRead, expected: "dave has no key pair yet"},
+		{name: "existing member", username: "bob", role: model.RoleWrite, expected: "bob is already a member of the collection"},
+		{name: "unknown role", username: "bob", role: "owner", expected: `unknown role "owner"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			err := alice.Invite(collection.ID, tc.username, tc.role)
+
+			// then
+			assert.ErrorContains(t, err, tc.expected)
+		})
+	}
+
+	// and
+	_, err = setupUserVault(t, db, "frank").Collection(collection.ID)
+	assert.ErrorContains(t, err, "you are not a member of collection")
+	_, err = bob.Members(uuid.New().String())
+	assert.Error(t, err)
+}
+
+func TestMoveToCollectionShouldRollBackOnDuplicate(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	collection, err := alice.CreateCollection("Family")
+	require.NoError(t, err)
+	shared, err := alice.Collection(collection.ID)
+	require.NoError(t, err)
+	require.NoError(t, shared.AddEntry(model.Password{Title: "Router", Username: "admin", Password: "shared"}, nil, "", nil))
+	require.NoError(t, alice.AddEntry(model.Password{Title: "Router", Username: "admin", Password: "own"}, nil, "Home", nil))
+	entry, err := alice.FindEntry("Router", "admin")
+	require.NoError(t, err)
+
+	// when
+	err = alice.MoveToCollection(entry, shared)
+
+	// then
+	assert.ErrorAs(t, err, &model.PasswordAlreadyExistsError{})
+	details, err := alice.Reveal(entry)
+	require.NoError(t, err)
+	assert.Equal(t, "own", details.Entry.Password)
+	assert.Equal(t, []string{"Home"}, details.Folders)
+}
+
+func TestCollectionsShouldHoldEntriesWithForeignKeysEnforced(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	// the pragma holds for a single connection, which must keep the in-memory database
+	db.SetMaxOpenConns(1)
+	_, err := db.Exec("PRAGMA foreign_keys = ON")
+	require.NoError(t, err)
+	alice := setupUserVault(t, db, "alice")
+	collection, err := alice.CreateCollection("Family")
+	require.NoError(t, err)
+	shared, err := alice.Collection(collection.ID)
+	require.NoError(t, err)
+	require.NoError(t, alice.AddEntry(model.Password{Title: "Netflix", Username: "family", Password: "own"}, nil, "Home", []string{"tv"}))
+	entry, err := alice.FindEntry("Netflix", "family")
+	require.NoError(t, err)
+
+	// when
+	addErr := shared.AddEntry(model.Password{Title: "Router", Username: "admin", Password: "shared"}, nil, "Home/Network", []string{"wifi"})
+	moveErr := alice.MoveToCollection(entry, shared)
+
+	// then
+	require.NoError(t, addErr)
+	require.NoError(t, moveErr)
+	entries, err := shared.Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	var violations []struct {
+		Table  string `db:"table"`
+		RowID  int64  `db:"rowid"`
+		Parent string `db:"parent"`
+		FKID   int64  `db:"fkid"`
+	}
+	require.NoError(t, db.Select(&violations, "PRAGMA foreign_key_check"))
+	assert.Empty(t, violations)
+}
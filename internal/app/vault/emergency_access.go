@@ -0,0 +1,213 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"yubigo-pass/internal/app/crypto"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/utils"
+)
+
+// errNotOwnVault is returned when managing emergency access from a vault other than the user's own
+var errNotOwnVault = errors.New("emergency access can only be managed from your own vault")
+
+// AddEmergencyContact designates another local user who may request access to the vault after
+// waiting waitDays. The owner may approve a request earlier or reject it until the waiting period
+// ends. The vault key is sealed to the contact only once access is granted, see GrantDueEmergencyAccess.
+func (v Vault) AddEmergencyContact(username string, waitDays int) error {
+	if err := v.ownVault(); err != nil {
+		return err
+	}
+	if waitDays < 0 {
+		return errors.New("the waiting period cannot be negative")
+	}
+	grantee, err := v.store.GetUser(username)
+	if err != nil {
+		if errors.As(err, &model.UserNotFoundError{}) {
+			return fmt.Errorf("there is no user named %s", username)
+		}
+		return err
+	}
+	if grantee.UserID == v.UserID() {
+		return errors.New("you cannot be your own emergency contact")
+	}
+	if _, err = v.store.GetUserKey(grantee.UserID); err != nil {
+		if errors.As(err, &model.UserKeyNotFoundError{}) {
+			return fmt.Errorf("%s has no key pair yet, they need to log in once before being designated", username)
+		}
+		return err
+	}
+
+	err = v.store.CreateEmergencyAccess(model.NewEmergencyAccess(v.UserID(), grantee.UserID, waitDays))
+	if errors.As(err, &model.EmergencyAccessAlreadyExistsError{}) {
+		return fmt.Errorf("%s is already an emergency contact", username)
+	}
+	return err
+}
+
+// EmergencyContacts returns the emergency access the vault owner gave other users, ordered by username
+func (v Vault) EmergencyContacts() ([]model.EmergencyAccess, error) {
+	if err := v.ownVault(); err != nil {
+		return nil, err
+	}
+	return v.store.GetEmergencyContacts(v.UserID())
+}
+
+// EmergencyRequests returns the emergency access requested by contacts of the vault owner, which is
+// granted once their waiting period ends unless the owner rejects it
+func (v Vault) EmergencyRequests() ([]model.EmergencyAccess, error) {
+	contacts, err := v.EmergencyContacts()
+	if err != nil {
+		return nil, err
+	}
+	var requests []model.EmergencyAccess
+	for _, contact := range contacts {
+		if contact.Status == model.EmergencyRequested {
+			requests = append(requests, contact)
+		}
+	}
+	return requests, nil
+}
+
+// ApproveEmergencyAccess grants the pending request of a contact before their waiting period ends,
+// sealing the vault key to them
+func (v Vault) ApproveEmergencyAccess(granteeID string) error {
+	return v.changeEmergencyAccess(granteeID, func(access model.EmergencyAccess) (model.EmergencyAccess, error) {
+		access, err := access.Approve()
+		if err != nil {
+			return access, err
+		}
+		return v.releaseEmergencyKey(access)
+	})
+}
+
+// GrantDueEmergencyAccess seals the vault key to the contacts whose waiting period passed without the
+// owner rejecting their request. The key is only at hand while the owner has the vault unlocked, so
+// such access opens the next time they log in, see Unlock.
+func (v Vault) GrantDueEmergencyAccess() error {
+	contacts, err := v.EmergencyContacts()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, access := range contacts {
+		if !access.Granted(now) || access.Released() {
+			continue
+		}
+		if access, err = v.releaseEmergencyKey(access); err != nil {
+			return err
+		}
+		if err = v.store.UpdateEmergencyAccess(access); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RejectEmergencyAccess turns down the pending request of a contact, or takes back access granted already
+func (v Vault) RejectEmergencyAccess(granteeID string) error {
+	return v.changeEmergencyAccess(granteeID, model.EmergencyAccess.Reject)
+}
+
+// RemoveEmergencyContact takes back emergency access from a contact for good
+func (v Vault) RemoveEmergencyContact(granteeID string) error {
+	if err := v.ownVault(); err != nil {
+		return err
+	}
+	return v.store.DeleteEmergencyAccess(v.UserID(), granteeID)
+}
+
+// EmergencyGrants returns the emergency access other users gave the vault owner, ordered by username
+func (v Vault) EmergencyGrants() ([]model.EmergencyAccess, error) {
+	if err := v.ownVault(); err != nil {
+		return nil, err
+	}
+	return v.store.GetEmergencyGrants(v.UserID())
+}
+
+// RequestEmergencyAccess asks for access to the vault of another user who designated the vault owner
+// as emergency contact, starting their waiting period
+func (v Vault) RequestEmergencyAccess(ownerID string) (model.EmergencyAccess, error) {
+	if err := v.ownVault(); err != nil {
+		return model.EmergencyAccess{}, err
+	}
+	access, err := v.store.GetEmergencyAccess(ownerID, v.UserID())
+	if err != nil {
+		return model.EmergencyAccess{}, err
+	}
+	access, err = access.Request(time.Now())
+	if err != nil {
+		return model.EmergencyAccess{}, err
+	}
+	return access, v.store.UpdateEmergencyAccess(access)
+}
+
+// EmergencyVault opens the vault of another user who granted the vault owner emergency access, taking
+// it over with the vault key they sealed to the vault owner
+func (v Vault) EmergencyVault(ownerID string) (Vault, error) {
+	if err := v.ownVault(); err != nil {
+		return Vault{}, err
+	}
+	access, err := v.store.GetEmergencyAccess(ownerID, v.UserID())
+	if err != nil {
+		return Vault{}, err
+	}
+	if !access.Granted(time.Now()) {
+		return Vault{}, fmt.Errorf("%s has not granted you access to their vault", access.OwnerName)
+	}
+	if !access.Released() {
+		return Vault{}, fmt.Errorf("%s granted you access to their vault, it opens once they log in again", access.OwnerName)
+	}
+	public, private, err := v.keyPair()
+	if err != nil {
+		return Vault{}, err
+	}
+	vaultKey, err := crypto.OpenWithPrivateKey(public, private, access.SealedKey)
+	if err != nil {
+		return Vault{}, fmt.Errorf("failed to open vault key: %w", err)
+	}
+	return Vault{
+		store:   v.store,
+		session: utils.NewSession(ownerID, "", ""),
+		cipher:  crypto.NewCipher(vaultKey),
+	}, nil
+}
+
+// releaseEmergencyKey seals the vault key to the grantee of emergency access
+func (v Vault) releaseEmergencyKey(access model.EmergencyAccess) (model.EmergencyAccess, error) {
+	granteeKey, err := v.store.GetUserKey(access.GranteeID)
+	if err != nil {
+		return access, err
+	}
+	vaultKey := crypto.DeriveAESKey(v.session.GetPassphrase(), v.session.GetSalt())
+	if access.SealedKey, err = crypto.SealToPublicKey(granteeKey.PublicKey, vaultKey); err != nil {
+		return access, err
+	}
+	return access, nil
+}
+
+// changeEmergencyAccess moves the emergency access of a contact of the vault owner to another state
+func (v Vault) changeEmergencyAccess(granteeID string, transition func(model.EmergencyAccess) (model.EmergencyAccess, error)) error {
+	if err := v.ownVault(); err != nil {
+		return err
+	}
+	access, err := v.store.GetEmergencyAccess(v.UserID(), granteeID)
+	if err != nil {
+		return err
+	}
+	access, err = transition(access)
+	if err != nil {
+		return err
+	}
+	return v.store.UpdateEmergencyAccess(access)
+}
+
+// ownVault checks the vault is the one of a user who unlocked it with their master password, not a
+// collection or a vault opened through emergency access
+func (v Vault) ownVault() error {
+	if v.IsCollection() || v.session.GetPassphrase() == "" {
+		return errNotOwnVault
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+//go:build integration
+
+package vault
+
+import (
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/crypto"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test"
+	"yubigo-pass/test/usertest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmergencyVaultShouldOpenOwnerVaultAfterWaitingPeriod(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	bob := setupUserVault(t, db, "bob")
+	require.NoError(t, alice.AddEntry(model.Password{Title: "Bank", Username: "alice", Password: "hunter2"}, nil, "", nil))
+	require.NoError(t, alice.AddEmergencyContact("bob", 3))
+	_, err := bob.RequestEmergencyAccess(alice.UserID())
+	require.NoError(t, err)
+	_, waitingErr := bob.EmergencyVault(alice.UserID())
+	requestedAt := time.Now().AddDate(0, 0, -3).UnixMilli()
+	_, err = db.Exec(`UPDATE emergency_access SET requested_at = $1`, requestedAt)
+	require.NoError(t, err)
+	_, unreleasedErr := bob.EmergencyVault(alice.UserID())
+
+	// when
+	_, err = Unlock(alice.store, "alice", alice.session.GetPassphrase())
+	require.NoError(t, err)
+	takenOver, err := bob.EmergencyVault(alice.UserID())
+	require.NoError(t, err)
+
+	// then
+	assert.EqualError(t, waitingErr, "alice has not granted you access to their vault")
+	assert.EqualError(t, unreleasedErr, "alice granted you access to their vault, it opens once they log in again")
+	entry, err := takenOver.FindEntry("Bank", "alice")
+	require.NoError(t, err)
+	password, err := takenOver.RevealPassword(entry)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", password)
+}
+
+func TestEmergencyContactShouldHoldNoVaultKeyBeforeAccessIsGranted(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	bob := setupUserVault(t, db, "bob")
+	require.NoError(t, alice.AddEmergencyContact("bob", 3))
+	_, err := bob.RequestEmergencyAccess(alice.UserID())
+	require.NoError(t, err)
+
+	// when
+	err = alice.GrantDueEmergencyAccess()
+
+	// then
+	require.NoError(t, err)
+	var sealedKey []byte
+	require.NoError(t, db.Get(&sealedKey, `SELECT sealed_key FROM emergency_access`))
+	assert.Empty(t, sealedKey)
+	access, err := alice.store.GetEmergencyAccess(alice.UserID(), bob.UserID())
+	require.NoError(t, err)
+	public, private, err := bob.keyPair()
+	require.NoError(t, err)
+	_, err = crypto.OpenWithPrivateKey(public, private, access.SealedKey)
+	assert.Error(t, err)
+}
+
+func TestRejectedEmergencyAccessShouldNotOpenVault(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	bob := setupUserVault(t, db, "bob")
+	require.NoError(t, alice.AddEmergencyContact("bob", 0))
+	_, err := bob.RequestEmergencyAccess(alice.UserID())
+	require.NoError(t, err)
+	requests, err := alice.EmergencyRequests()
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+
+	// when
+	err = alice.RejectEmergencyAccess(bob.UserID())
+
+	// then
+	require.NoError(t, err)
+	_, openErr := bob.EmergencyVault(alice.UserID())
+	assert.EqualError(t, openErr, "alice has not granted you access to their vault")
+	requests, err = alice.EmergencyRequests()
+	require.NoError(t, err)
+	assert.Empty(t, requests)
+}
+
+func TestApprovedEmergencyAccessShouldOpenVaultBeforeWaitingPeriod(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	bob := setupUserVault(t, db, "bob")
+	require.NoError(t, alice.AddEmergencyContact("bob", 30))
+	_, err := bob.RequestEmergencyAccess(alice.UserID())
+	require.NoError(t, err)
+
+	// when
+	err = alice.ApproveEmergencyAccess(bob.UserID())
+
+	// then
+	require.NoError(t, err)
+	_, err = bob.EmergencyVault(alice.UserID())
+	assert.NoError(t, err)
+	grants, err := bob.EmergencyGrants()
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	assert.Equal(t, model.EmergencyApproved, grants[0].Status)
+	assert.Equal(t, "alice", grants[0].OwnerName)
+}
+
+func TestAddEmergencyContactShouldRejectInvalidContacts(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	setupUserVault(t, db, "bob")
+	usertest.Insert(t, db, "carol", test.RandomString())
+	require.NoError(t, alice.AddEmergencyContact("bob", 7))
+
+	testCases := []struct {
+		username string
+		waitDays int
+		err      string
+	}{
+		{username: "dave", waitDays: 7, err: "there is no user named dave"},
+		{username: "alice", waitDays: 7, err: "you cannot be your own emergency contact"},
+		{username: "carol", waitDays: 7, err: "carol has no key pair yet, they need to log in once before being designated"},
+		{username: "bob", waitDays: 7, err: "bob is already an emergency contact"},
+		{username: "bob", waitDays: -1, err: "the waiting period cannot be negative"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.err, func(t *testing.T) {
+			// when
+			err := alice.AddEmergencyContact(tc.username, tc.waitDays)
+
+			// then
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestEmergencyVaultShouldNotManageEmergencyAccess(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	alice := setupUserVault(t, db, "alice")
+	bob := setupUserVault(t, db, "bob")
+	setupUserVault(t, db, "carol")
+	require.NoError(t, alice.AddEmergencyContact("bob", 0))
+	_, err := bob.RequestEmergencyAccess(alice.UserID())
+	require.NoError(t, err)
+	require.NoError(t, alice.GrantDueEmergencyAccess())
+	takenOver, err := bob.EmergencyVault(alice.UserID())
+	require.NoError(t, err)
+
+	// when
+	err = takenOver.AddEmergencyContact("carol", 0)
+
+	// then
+	assert.ErrorIs(t, err, errNotOwnVault)
+}
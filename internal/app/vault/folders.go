@@ -0,0 +1,305 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"yubigo-pass/internal/app/model"
+
+	"github.com/google/uuid"
+)
+
+// FolderSeparator separates folder names in a folder path, e.g. "Work/Servers"
+const FolderSeparator = "/"
+
+// Folder is a folder with its name decrypted
+type Folder struct {
+	ID       string
+	ParentID string
+	Name     string
+}
+
+// Tag is a tag with its name decrypted
+type Tag struct {
+	ID   string
+	Name string
+}
+
+// FolderTree indexes the folders of a vault by id and by parent
+type FolderTree struct {
+	folders  map[string]Folder
+	children map[string][]Folder
+}
+
+// NewFolderTree returns new FolderTree instance
+func NewFolderTree(folders []Folder) FolderTree {
+	t := FolderTree{
+		folders:  make(map[string]Folder, len(folders)),
+		children: make(map[string][]Folder),
+	}
+	for _, f := range folders {
+		t.folders[f.ID] = f
+		t.children[f.ParentID] = append(t.children[f.ParentID], f)
+	}
+	for parentID := range t.children {
+		sort.Slice(t.children[parentID], func(i, j int) bool {
+			return t.children[parentID][i].Name < t.children[parentID][j].Name
+		})
+	}
+	return t
+}
+
+// Get returns the folder with the given id
+func (t FolderTree) Get(id string) (Folder, bool) {
+	f, ok := t.folders[id]
+	return f, ok
+}
+
+// Children returns the direct subfolders of a folder sorted by name, an empty parentID returns top-level folders
+func (t FolderTree) Children(parentID string) []Folder {
+	return t.children[parentID]
+}
+
+// Path returns the full path of a folder, e.g. "Work/Servers"
+func (t FolderTree) Path(id string) string {
+	var names []string
+	seen := make(map[string]bool)
+	for id != "" && !seen[id] {
+		seen[id] = true
+		f, ok := t.folders[id]
+		if !ok {
+			break
+		}
+		names = append([]string{f.Name}, names...)
+		id = f.ParentID
+	}
+	return strings.Join(names, FolderSeparator)
+}
+
+// Find looks a folder up by its full path
+func (t FolderTree) Find(path string) (Folder, bool) {
+	var current Folder
+	parentID := ""
+	parts := SplitFolderPath(path)
+	if len(parts) == 0 {
+		return Folder{}, false
+	}
+	for _, name := range parts {
+		found := false
+		for _, child := range t.children[parentID] {
+			if child.Name == name {
+				current, found = child, true
+				break
+			}
+		}
+		if !found {
+			return Folder{}, false
+		}
+		parentID = current.ID
+	}
+	return current, true
+}
+
+// Walk visits all folders depth-first in name order, passing the nesting depth of each folder
+func (t FolderTree) Walk(fn func(f Folder, depth int)) {
+	t.walk("", 0, fn, make(map[string]bool))
+}
+
+func (t FolderTree) walk(parentID string, depth int, fn func(f Folder, depth int), seen map[string]bool) {
+	for _, child := range t.children[parentID] {
+		if seen[child.ID] {
+			continue
+		}
+		seen[child.ID] = true
+		fn(child, depth)
+		t.walk(child.ID, depth+1, fn, seen)
+	}
+}
+
+// SplitFolderPath splits a folder path into trimmed, non-empty folder names
+func SplitFolderPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(path, FolderSeparator) {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// NormalizeTags trims tag names and drops empty and duplicate ones, keeping the original order
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// ParseTags splits a comma separated list of tags
+func ParseTags(input string) []string {
+	return NormalizeTags(strings.Split(input, ","))
+}
+
+// Folders fetches and decrypts all folders of the vault owner
+func (v Vault) Folders() (FolderTree, error) {
+	stored, err := v.store.GetAllUserFolders(v.UserID())
+	if err != nil {
+		return FolderTree{}, err
+	}
+	folders, err := v.decryptFolders(stored)
+	if err != nil {
+		return FolderTree{}, err
+	}
+	return NewFolderTree(folders), nil
+}
+
+// Tags fetches and decrypts all tags of the vault owner, sorted by name
+func (v Vault) Tags() ([]Tag, error) {
+	stored, err := v.store.GetAllUserTags(v.UserID())
+	if err != nil {
+		return nil, err
+	}
+	tags, err := v.decryptTags(stored)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// EnsureFolder returns the folder at path, creating any missing folders along it
+func (v Vault) EnsureFolder(path string) (Folder, error) {
+	tree, err := v.Folders()
+	if err != nil {
+		return Folder{}, err
+	}
+
+	var current Folder
+	for _, name := range SplitFolderPath(path) {
+		found := false
+		for _, child := range tree.Children(current.ID) {
+			if child.Name == name {
+				current, found = child, true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		encryptedName, err := v.cipher.EncryptString(name)
+		if err != nil {
+			return Folder{}, fmt.Errorf("failed to encrypt folder name: %w", err)
+		}
+		folder := model.NewFolder(uuid.New().String(), v.UserID(), current.ID, encryptedName)
+		err = v.store.CreateFolder(folder)
+		if err != nil {
+			return Folder{}, err
+		}
+		current = Folder{ID: folder.ID, ParentID: folder.ParentID, Name: name}
+	}
+	return current, nil
+}
+
+// EnsureTag returns the tag with the given name, creating it if it does not exist yet
+func (v Vault) EnsureTag(name string) (Tag, error) {
+	tags, err := v.Tags()
+	if err != nil {
+		return Tag{}, err
+	}
+	for _, tag := range tags {
+		if tag.Name == name {
+			return tag, nil
+		}
+	}
+
+	encryptedName, err := v.cipher.EncryptString(name)
+	if err != nil {
+		return Tag{}, fmt.Errorf("failed to encrypt tag name: %w", err)
+	}
+	tag := model.NewTag(uuid.New().String(), v.UserID(), encryptedName)
+	err = v.store.CreateTag(tag)
+	if err != nil {
+		return Tag{}, err
+	}
+	return Tag{ID: tag.ID, Name: name}, nil
+}
+
+// FindTag looks a tag up by its name
+func (v Vault) FindTag(name string) (Tag, bool, error) {
+	tags, err := v.Tags()
+	if err != nil {
+		return Tag{}, false, err
+	}
+	for _, tag := range tags {
+		if tag.Name == name {
+			return tag, true, nil
+		}
+	}
+	return Tag{}, false, nil
+}
+
+// EntriesInFolder fetches the entries filed directly in a folder
+func (v Vault) EntriesInFolder(folderID string) ([]model.Password, error) {
+	return v.store.GetPasswordsByFolder(v.UserID(), folderID)
+}
+
+// EntriesWithTag fetches the entries labelled with a tag
+func (v Vault) EntriesWithTag(tagID string) ([]model.Password, error) {
+	return v.store.GetPasswordsByTag(v.UserID(), tagID)
+}
+
+// EntryFolders fetches and decrypts the folders an entry is filed in
+func (v Vault) EntryFolders(entryID string) ([]Folder, error) {
+	stored, err := v.store.GetPasswordFolders(entryID)
+	if err != nil {
+		return nil, err
+	}
+	return v.decryptFolders(stored)
+}
+
+// EntryTags fetches and decrypts the tags of an entry, sorted by name
+func (v Vault) EntryTags(entryID string) ([]Tag, error) {
+	stored, err := v.store.GetPasswordTags(entryID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := v.decryptTags(stored)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (v Vault) decryptFolders(stored []model.Folder) ([]Folder, error) {
+	folders := make([]Folder, 0, len(stored))
+	for _, f := range stored {
+		name, err := v.cipher.DecryptString(f.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt folder name: %w", err)
+		}
+		folders = append(folders, Folder{ID: f.ID, ParentID: f.ParentID, Name: name})
+	}
+	return folders, nil
+}
+
+func (v Vault) decryptTags(stored []model.Tag) ([]Tag, error) {
+	tags := make([]Tag, 0, len(stored))
+	for _, t := range stored {
+		name, err := v.cipher.DecryptString(t.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt tag name: %w", err)
+		}
+		tags = append(tags, Tag{ID: t.ID, Name: name})
+	}
+	return tags, nil
+}
@@ -0,0 +1,97 @@
+//go:build unit
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testFolderTree() FolderTree {
+	return NewFolderTree([]Folder{
+		{ID: "servers", ParentID: "work", Name: "Servers"},
+		{ID: "work", ParentID: "", Name: "Work"},
+		{ID: "banking", ParentID: "", Name: "Banking"},
+		{ID: "db", ParentID: "servers", Name: "Databases"},
+	})
+}
+
+func TestFolderTreeShouldReturnChildrenSortedByName(t *testing.T) {
+	// given
+	tree := testFolderTree()
+
+	// when
+	children := tree.Children("")
+
+	// then
+	assert.Equal(t, []Folder{
+		{ID: "banking", ParentID: "", Name: "Banking"},
+		{ID: "work", ParentID: "", Name: "Work"},
+	}, children)
+}
+
+func TestFolderTreeShouldBuildPath(t *testing.T) {
+	// given
+	tree := testFolderTree()
+
+	// when
+	path := tree.Path("db")
+
+	// then
+	assert.Equal(t, "Work/Servers/Databases", path)
+}
+
+func TestFolderTreeShouldFindFolderByPath(t *testing.T) {
+	testCases := []struct {
+		name       string
+		path       string
+		expectedID string
+		found      bool
+	}{
+		{name: "top level", path: "Work", expectedID: "work", found: true},
+		{name: "nested", path: "Work/Servers", expectedID: "servers", found: true},
+		{name: "surrounding separators and spaces", path: " /Work/ Servers /Databases/", expectedID: "db", found: true},
+		{name: "missing", path: "Work/Desktops", found: false},
+		{name: "empty", path: "", found: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			tree := testFolderTree()
+
+			// when
+			folder, ok := tree.Find(tc.path)
+
+			// then
+			assert.Equal(t, tc.found, ok)
+			assert.Equal(t, tc.expectedID, folder.ID)
+		})
+	}
+}
+
+func TestFolderTreeShouldWalkDepthFirst(t *testing.T) {
+	// given
+	tree := testFolderTree()
+	var visited []string
+	var depths []int
+
+	// when
+	tree.Walk(func(f Folder, depth int) {
+		visited = append(visited, f.ID)
+		depths = append(depths, depth)
+	})
+
+	// then
+	assert.Equal(t, []string{"banking", "work", "servers", "db"}, visited)
+	assert.Equal(t, []int{0, 0, 1, 2}, depths)
+}
+
+func TestParseTags(t *testing.T) {
+	// when
+	tags := ParseTags(" work, personal,,work , 2fa ")
+
+	// then
+	assert.Equal(t, []string{"work", "personal", "2fa"}, tags)
+}
@@ -0,0 +1,98 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/utils"
+)
+
+// GeneratorProfiles returns the password generator profiles the vault owner saved, ordered by name.
+// The default profile is listed as well until the owner saves one under its name, and it is the
+// active one while the owner has not activated another.
+func (v Vault) GeneratorProfiles() ([]model.GeneratorProfile, error) {
+	if !v.session.IsAuthenticated() {
+		return nil, errors.New("no active user session")
+	}
+	profiles, err := v.store.GetGeneratorProfiles(v.UserID())
+	if err != nil {
+		return nil, err
+	}
+
+	isDefault := func(profile model.GeneratorProfile) bool { return profile.Name == model.DefaultGeneratorProfileName }
+	if !slices.ContainsFunc(profiles, isDefault) {
+		profiles = append(profiles, model.NewGeneratorProfile(v.UserID(), model.DefaultGeneratorProfileName, model.DefaultPasswordPolicy))
+		slices.SortFunc(profiles, func(a, b model.GeneratorProfile) int { return strings.Compare(a.Name, b.Name) })
+	}
+	if !slices.ContainsFunc(profiles, func(profile model.GeneratorProfile) bool { return profile.Active }) {
+		profiles[slices.IndexFunc(profiles, isDefault)].Active = true
+	}
+	return profiles, nil
+}
+
+// ActiveGeneratorProfile returns the profile passwords are generated with, the default one until
+// the owner activates another
+func (v Vault) ActiveGeneratorProfile() (model.GeneratorProfile, error) {
+	profiles, err := v.GeneratorProfiles()
+	if err != nil {
+		return model.GeneratorProfile{}, err
+	}
+	for _, profile := range profiles {
+		if profile.Active {
+			return profile, nil
+		}
+	}
+	return model.DefaultGeneratorProfile(v.UserID()), nil
+}
+
+// SaveGeneratorProfile validates a policy and saves it under name, replacing the policy of a saved
+// profile of the same name
+func (v Vault) SaveGeneratorProfile(name string, policy model.PasswordPolicy) error {
+	if !v.session.IsAuthenticated() {
+		return errors.New("no active user session")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("generator profile name cannot be empty")
+	}
+	if err := utils.ValidatePasswordPolicy(policy); err != nil {
+		return fmt.Errorf("invalid generator profile %s: %w", name, err)
+	}
+	return v.store.SaveGeneratorProfile(model.NewGeneratorProfile(v.UserID(), name, policy))
+}
+
+// ActivateGeneratorProfile makes the profile named name the one passwords are generated with
+func (v Vault) ActivateGeneratorProfile(name string) error {
+	if !v.session.IsAuthenticated() {
+		return errors.New("no active user session")
+	}
+	err := v.store.ActivateGeneratorProfile(v.UserID(), name)
+	if errors.As(err, &model.GeneratorProfileNotFoundError{}) && name == model.DefaultGeneratorProfileName {
+		// the default profile is only saved once it is activated again
+		if err = v.store.SaveGeneratorProfile(model.DefaultGeneratorProfile(v.UserID())); err != nil {
+			return err
+		}
+		err = v.store.ActivateGeneratorProfile(v.UserID(), name)
+	}
+	return err
+}
+
+// DeleteGeneratorProfile removes the profile named name. Deleting the active profile makes
+// the default one active again.
+func (v Vault) DeleteGeneratorProfile(name string) error {
+	if !v.session.IsAuthenticated() {
+		return errors.New("no active user session")
+	}
+	return v.store.DeleteGeneratorProfile(v.UserID(), name)
+}
+
+// GeneratePassword generates a password, or a passphrase, with the active generator profile
+func (v Vault) GeneratePassword() (string, error) {
+	profile, err := v.ActiveGeneratorProfile()
+	if err != nil {
+		return "", err
+	}
+	return utils.GeneratePasswordWithPolicy(profile.PasswordPolicy)
+}
@@ -0,0 +1,95 @@
+//go:build integration
+
+package vault
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldGeneratePasswordsWithDefaultProfileUntilAnotherIsActivated(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	v := setupUserVault(t, db, "alice")
+
+	// when
+	profiles, err := v.GeneratorProfiles()
+	require.NoError(t, err)
+	password, err := v.GeneratePassword()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []model.GeneratorProfile{model.DefaultGeneratorProfile(v.UserID())}, profiles)
+	assert.Len(t, password, model.DefaultPasswordPolicy.Length)
+}
+
+func TestShouldGeneratePasswordsWithActivatedProfile(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	v := setupUserVault(t, db, "alice")
+	pin := model.PasswordPolicy{Length: 6, Digits: true}
+	require.NoError(t, v.SaveGeneratorProfile(" pin ", pin))
+
+	// when
+	require.NoError(t, v.ActivateGeneratorProfile("pin"))
+	active, err := v.ActiveGeneratorProfile()
+	require.NoError(t, err)
+	profiles, err := v.GeneratorProfiles()
+	require.NoError(t, err)
+	password, err := v.GeneratePassword()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "pin", active.Name)
+	assert.Equal(t, pin, active.PasswordPolicy)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, model.DefaultGeneratorProfileName, profiles[0].Name)
+	assert.False(t, profiles[0].Active)
+	assert.Len(t, password, 6)
+	assert.Empty(t, strings.Trim(password, "0123456789"))
+}
+
+func TestShouldFallBackToDefaultProfileWhenActiveOneIsDeleted(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	v := setupUserVault(t, db, "alice")
+	require.NoError(t, v.SaveGeneratorProfile("pin", model.PasswordPolicy{Length: 6, Digits: true}))
+	require.NoError(t, v.ActivateGeneratorProfile("pin"))
+
+	// when
+	require.NoError(t, v.DeleteGeneratorProfile("pin"))
+	active, err := v.ActiveGeneratorProfile()
+	require.NoError(t, err)
+	require.NoError(t, v.ActivateGeneratorProfile(model.DefaultGeneratorProfileName))
+	activatedDefault, err := v.ActiveGeneratorProfile()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, model.DefaultGeneratorProfile(v.UserID()), active)
+	assert.Equal(t, model.DefaultGeneratorProfile(v.UserID()), activatedDefault)
+}
+
+func TestShouldNotSaveInvalidGeneratorProfile(t *testing.T) {
+	// given
+	db := test.NewDB(t)
+	v := setupUserVault(t, db, "alice")
+
+	// when
+	invalidErr := v.SaveGeneratorProfile("short", model.PasswordPolicy{Length: 2, Lower: true})
+	unnamedErr := v.SaveGeneratorProfile(" ", model.DefaultPasswordPolicy)
+	activateErr := v.ActivateGeneratorProfile("short")
+	profiles, err := v.GeneratorProfiles()
+
+	// then
+	require.NoError(t, err)
+	assert.EqualError(t, invalidErr, "invalid generator profile short: password length must be between 4 and 128")
+	assert.EqualError(t, unnamedErr, "generator profile name cannot be empty")
+	assert.True(t, errors.As(activateErr, &model.GeneratorProfileNotFoundError{}))
+	assert.Len(t, profiles, 1)
+}
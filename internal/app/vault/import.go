@@ -0,0 +1,90 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/otp"
+)
+
+// ImportReport tells what an import did with every record it read, naming each by the
+// title and username of its entry. Duplicates lists records clashing with an existing entry.
+type ImportReport struct {
+	Added      []string
+	Updated    []string
+	Skipped    []SkippedRecord
+	Duplicates []model.PasswordAlreadyExistsError
+}
+
+// SkippedRecord is a record an import left out, with the reason why
+type SkippedRecord struct {
+	Name   string
+	Reason string
+}
+
+// entryKey identifies an entry by its title and username, unique per user
+type entryKey struct {
+	title    string
+	username string
+}
+
+// ImportOTPKeys stores one-time password keys, as read from authenticator QR codes, in login
+// entries titled by the issuer and named by the account. A key matching an existing entry
+// without a one-time password is added to it, entries already holding one are left untouched.
+func (v Vault) ImportOTPKeys(keys []otp.Key, folderPath string) (ImportReport, error) {
+	if !v.session.IsAuthenticated() {
+		return ImportReport{}, errors.New("no active user session")
+	}
+	entries, err := v.Entries()
+	if err != nil {
+		return ImportReport{}, err
+	}
+	existing := make(map[entryKey]model.Password, len(entries))
+	for _, entry := range entries {
+		existing[entryKey{entry.Title, entry.Username}] = entry
+	}
+
+	var report ImportReport
+	for _, key := range keys {
+		title, username := key.Issuer, key.Account
+		if title == "" {
+			title = username
+		}
+		if username == "" {
+			username = title
+		}
+		if title == "" {
+			report.Skipped = append(report.Skipped, SkippedRecord{Name: "unnamed account", Reason: "has neither issuer nor account"})
+			continue
+		}
+		name := fmt.Sprintf("%s (%s)", title, username)
+
+		stored, found := existing[entryKey{title, username}]
+		switch {
+		case found && stored.OTP != "":
+			report.Skipped = append(report.Skipped, SkippedRecord{Name: name, Reason: "already has a one-time password"})
+		case found:
+			stored.OTP, err = v.encryptOptional(key.URI())
+			if err != nil {
+				return report, fmt.Errorf("failed to encrypt one-time password: %w", err)
+			}
+			stored.OTPCounter = key.Counter
+			stored.ModifiedAt = time.Now().UnixMilli()
+			if err = v.store.UpdatePassword(stored); err != nil {
+				return report, err
+			}
+			existing[entryKey{title, username}] = stored
+			report.Updated = append(report.Updated, name)
+		default:
+			entry := model.Password{Type: model.ItemTypeLogin, Title: title, Username: username, OTP: key.URI()}
+			if err = v.AddEntry(entry, nil, folderPath, nil); err != nil {
+				return report, err
+			}
+			// Any non-empty value marks the entry as holding a one-time password for later keys
+			existing[entryKey{title, username}] = model.Password{Title: title, Username: username, OTP: entry.OTP}
+			report.Added = append(report.Added, name)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,105 @@
+//go:build integration
+
+package vault
+
+import (
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/otp"
+	"yubigo-pass/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldImportOTPKeysAsNewEntries(t *testing.T) {
+	// setup
+	v := setupUserVault(t, test.NewDB(t), "octocat")
+
+	// given
+	secret, err := otp.DecodeSecret(test.OTPSecret)
+	require.NoError(t, err)
+	keys := []otp.Key{
+		{Type: otp.TypeTOTP, Issuer: "GitHub", Account: "octocat", Secret: secret, Algorithm: otp.AlgorithmSHA1, Digits: 8, Period: otp.DefaultPeriod},
+		{Type: otp.TypeHOTP, Account: "me@bank", Secret: secret, Algorithm: otp.AlgorithmSHA1, Digits: 6, Counter: 1},
+	}
+
+	// when
+	report, err := v.ImportOTPKeys(keys, "2FA")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GitHub (octocat)", "me@bank (me@bank)"}, report.Added)
+	assert.Empty(t, report.Updated)
+	assert.Empty(t, report.Skipped)
+
+	github, err := v.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	assert.Equal(t, model.ItemTypeLogin, github.ItemType())
+	code, err := v.OneTimeCode(github, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "94287082", code.Value)
+	details, err := v.Reveal(github)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2FA"}, details.Folders)
+	assert.NoError(t, details.Item.Validate())
+
+	bank, err := v.FindEntry("me@bank", "")
+	require.NoError(t, err)
+	code, err = v.OneTimeCode(bank, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code.Value)
+}
+
+func TestShouldAddImportedOTPKeyToExistingEntry(t *testing.T) {
+	// setup
+	v := setupUserVault(t, test.NewDB(t), "octocat")
+
+	// given
+	password := test.RandomString()
+	require.NoError(t, v.AddEntry(model.Password{Title: "GitHub", Username: "octocat", Password: password}, nil, "", nil))
+	secret, err := otp.DecodeSecret(test.OTPSecret)
+	require.NoError(t, err)
+	key := otp.Key{Type: otp.TypeTOTP, Issuer: "GitHub", Account: "octocat", Secret: secret, Algorithm: otp.AlgorithmSHA1, Digits: 6, Period: otp.DefaultPeriod}
+
+	// when
+	report, err := v.ImportOTPKeys([]otp.Key{key}, "")
+
+	// then
+	require.NoError(t, err)
+	assert.Empty(t, report.Added)
+	assert.Equal(t, []string{"GitHub (octocat)"}, report.Updated)
+	stored, err := v.FindEntry("GitHub", "octocat")
+	require.NoError(t, err)
+	revealed, err := v.RevealPassword(stored)
+	require.NoError(t, err)
+	assert.Equal(t, password, revealed)
+	_, err = v.OTPKey(stored)
+	assert.NoError(t, err)
+}
+
+func TestShouldSkipImportedOTPKeyOfEntryHoldingOne(t *testing.T) {
+	// setup
+	v := setupUserVault(t, test.NewDB(t), "octocat")
+
+	// given
+	secret, err := otp.DecodeSecret(test.OTPSecret)
+	require.NoError(t, err)
+	key := otp.Key{Type: otp.TypeTOTP, Issuer: "GitHub", Account: "octocat", Secret: secret, Algorithm: otp.AlgorithmSHA1, Digits: 6, Period: otp.DefaultPeriod}
+	unnamed := otp.Key{Type: otp.TypeTOTP, Secret: secret, Algorithm: otp.AlgorithmSHA1, Digits: 6, Period: otp.DefaultPeriod}
+
+	// when
+	report, err := v.ImportOTPKeys([]otp.Key{key, key, unnamed}, "")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GitHub (octocat)"}, report.Added)
+	assert.Equal(t, []SkippedRecord{
+		{Name: "GitHub (octocat)", Reason: "already has a one-time password"},
+		{Name: "unnamed account", Reason: "has neither issuer nor account"},
+	}, report.Skipped)
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,96 @@
+//go:build integration
+
+package vault
+
+import (
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/otp"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+	"yubigo-pass/test/usertest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldGenerateTOTPOfEntry(t *testing.T) {
+	// setup
+	db := test.NewDB(t)
+	v := New(database.NewStore(db), usertest.Insert(t, db, "octocat", test.RandomString()))
+
+	// given
+	uri := "otpauth://totp/GitHub:octocat?secret=" + test.OTPSecret + "&digits=8"
+	entry := model.Password{Title: "github", Username: "octocat", Password: test.RandomString(), OTP: uri}
+	require.NoError(t, v.AddEntry(entry, nil, "", nil))
+	stored, err := v.FindEntry("github", "")
+	require.NoError(t, err)
+
+	// when
+	code, err := v.OneTimeCode(stored, time.Unix(59, 0))
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, otp.Code{Value: "94287082", Remaining: time.Second}, code)
+	raw := test.GetPassword(t, db, v.UserID(), "github", "octocat")
+	assert.NotContains(t, raw.OTP, test.OTPSecret)
+}
+
+func TestShouldAdvanceHOTPCounterOnEveryCode(t *testing.T) {
+	// setup
+	v := setupUserVault(t, test.NewDB(t), "octocat")
+
+	// given
+	uri := "otpauth://hotp/Bank:me?secret=" + test.OTPSecret + "&counter=1"
+	require.NoError(t, v.AddEntry(model.Password{Title: "bank", Username: "me", Password: test.RandomString(), OTP: uri}, nil, "", nil))
+	stored, err := v.FindEntry("bank", "")
+	require.NoError(t, err)
+
+	// when
+	first, err := v.OneTimeCode(stored, time.Now())
+	require.NoError(t, err)
+	second, err := v.OneTimeCode(stored, time.Now())
+	require.NoError(t, err)
+
+	// then
+	assert.Equal(t, "287082", first.Value)
+	assert.Equal(t, "359152", second.Value)
+	stored, err = v.FindEntry("bank", "")
+	require.NoError(t, err)
+	details, err := v.Reveal(stored)
+	require.NoError(t, err)
+	key, err := otp.Parse(details.Entry.OTP)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), key.Counter)
+}
+
+func TestShouldNotGenerateCodeForEntryWithoutOneTimePassword(t *testing.T) {
+	// setup
+	v := setupUserVault(t, test.NewDB(t), "octocat")
+
+	// given
+	require.NoError(t, v.AddEntry(model.Password{Title: "mail", Username: "me", Password: test.RandomString()}, nil, "", nil))
+	stored, err := v.FindEntry("mail", "")
+	require.NoError(t, err)
+
+	// when
+	_, err = v.OneTimeCode(stored, time.Now())
+
+	// then
+	assert.EqualError(t, err, "mail has no one-time password")
+}
+
+func TestShouldNotAddEntryWithInvalidOneTimePassword(t *testing.T) {
+	// setup
+	v := setupUserVault(t, test.NewDB(t), "octocat")
+
+	// given
+	entry := model.Password{Title: "mail", Username: "me", Password: test.RandomString(), OTP: "otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP&digits=12"}
+
+	// when
+	err := v.AddEntry(entry, nil, "", nil)
+
+	// then
+	assert.EqualError(t, err, "one-time password URI must have between 6 and 8 digits")
+}
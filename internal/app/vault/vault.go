@@ -0,0 +1,595 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+	"yubigo-pass/internal/app/crypto"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/otp"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Vault gives an authenticated user access to their entries. It encrypts and decrypts
+// secrets and metadata with a key derived from the session, so callers only deal with plaintext.
+// The vault of a shared collection, see Collection, holds the entries of the collection instead,
+// keyed by the collection key and limited by the role of the member who opened it.
+type Vault struct {
+	store   database.StoreExecutor
+	session utils.Session
+	cipher  crypto.Cipher
+	role    model.Role
+}
+
+// New returns new Vault instance for an authenticated session
+func New(store database.StoreExecutor, session utils.Session) Vault {
+	return Vault{
+		store:   store,
+		session: session,
+		cipher:  crypto.NewCipherFromPassphrase(session.GetPassphrase(), session.GetSalt()),
+	}
+}
+
+// ErrIncorrectCredentials is returned when logging in with an unknown username or a wrong password
+var ErrIncorrectCredentials = errors.New("incorrect username or password")
+
+// Unlock verifies user credentials and returns an authenticated session.
+// Failed attempts are throttled per username, see model.DefaultLoginPolicy: while a username waits
+// out its delay every attempt fails with a model.LoginThrottledError without checking the password.
+// Unknown usernames are throttled the same way, so the delay does not tell whether a user exists.
+// Users logging in for the first time since collections were added get their key pair, see EnsureKeyPair.
+// Contacts whose emergency access came due meanwhile get the vault key, see GrantDueEmergencyAccess.
+func Unlock(store database.StoreExecutor, username, password string) (utils.Session, error) {
+	policy := model.DefaultLoginPolicy
+	usernameHash := hashUsername(username)
+	attempts, err := store.GetLoginAttempts(usernameHash)
+	if err != nil {
+		return utils.NewEmptySession(), fmt.Errorf("login failed: %w", err)
+	}
+	if retryAt := policy.RetryAt(attempts); time.Now().Before(retryAt) {
+		return utils.NewEmptySession(), model.NewLoginThrottledError(retryAt)
+	}
+
+	user, err := store.GetUser(username)
+	if err != nil && !errors.As(err, &model.UserNotFoundError{}) {
+		return utils.NewEmptySession(), fmt.Errorf("login failed: %w", err)
+	}
+	hashedPassword := crypto.HashPasswordWithSalt(password, user.Salt)
+	if err != nil || subtle.ConstantTimeCompare([]byte(hashedPassword), []byte(user.Password)) != 1 {
+		return utils.NewEmptySession(), failLogin(store, policy, attempts)
+	}
+	if attempts.Failures > 0 {
+		if err = store.DeleteLoginAttempts(usernameHash); err != nil {
+			return utils.NewEmptySession(), fmt.Errorf("login failed: %w", err)
+		}
+	}
+	session := utils.NewSession(user.UserID, password, user.Salt)
+
+	_, err = store.GetUserKey(user.UserID)
+	if errors.As(err, &model.UserKeyNotFoundError{}) {
+		_, err = New(store, session).EnsureKeyPair()
+	}
+	if err == nil {
+		err = New(store, session).GrantDueEmergencyAccess()
+	}
+	if err != nil {
+		return utils.NewEmptySession(), fmt.Errorf("login failed: %w", err)
+	}
+	return session, nil
+}
+
+// failLogin counts a failed login and returns ErrIncorrectCredentials, along with a model.LoginThrottledError
+// when the failure makes the next attempt wait
+func failLogin(store database.StoreExecutor, policy model.LoginPolicy, attempts model.LoginAttempts) error {
+	attempts = policy.Fail(attempts, time.Now())
+	if err := store.SaveLoginAttempts(attempts); err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	if retryAt := policy.RetryAt(attempts); time.Now().Before(retryAt) {
+		return fmt.Errorf("%w: %w", ErrIncorrectCredentials, model.NewLoginThrottledError(retryAt))
+	}
+	return ErrIncorrectCredentials
+}
+
+// hashUsername returns the key failed logins under username are counted by
+func hashUsername(username string) string {
+	hash := sha256.Sum256([]byte(username))
+	return hex.EncodeToString(hash[:])
+}
+
+// Transaction runs fn against a vault whose store operations share a single DB transaction,
+// committed when fn succeeds and rolled back when it fails. Stores without transactions run fn as is.
+func (v Vault) Transaction(fn func(tx Vault) error) error {
+	transactor, ok := v.store.(database.Transactor)
+	if !ok {
+		return fn(v)
+	}
+	return transactor.WithTransaction(func(store database.StoreExecutor) error {
+		tx := v
+		tx.store = store
+		return fn(tx)
+	})
+}
+
+// UserID returns the identifier of the vault owner
+func (v Vault) UserID() string {
+	return v.session.GetUserID()
+}
+
+// EntryDetails is an entry with its secret, notes and custom fields decrypted,
+// along with the paths of the folders it is filed in and its tag names.
+// Item holds the same entry laid out by the fields of its type.
+type EntryDetails struct {
+	Entry   model.Password
+	Item    model.Item
+	Fields  []model.CustomField
+	Folders []string
+	Tags    []string
+}
+
+// AddItem validates a typed item and stores it as a new entry, see AddEntry, returning the ID of the entry
+func (v Vault) AddItem(item model.Item, fields []model.CustomField, folderPath string, tags []string) (string, error) {
+	entry, err := itemEntry(item)
+	if err != nil {
+		return "", err
+	}
+	entry.ID = uuid.New().String()
+	if err = v.AddEntry(entry, fields, folderPath, tags); err != nil {
+		return "", err
+	}
+	return entry.ID, nil
+}
+
+// UpdateItem overwrites the stored entry with the values of a typed item and moves it to
+// folderPath and tags, replacing its previous placement. Custom fields are left untouched.
+func (v Vault) UpdateItem(stored model.Password, item model.Item, folderPath string, tags []string) error {
+	if !v.session.IsAuthenticated() {
+		return errors.New("no active user session")
+	}
+	if err := v.writable(); err != nil {
+		return err
+	}
+	entry, err := itemEntry(item)
+	if err != nil {
+		return err
+	}
+
+	entry.ID = stored.ID
+	entry.UserID = v.UserID()
+	entry.ModifiedAt = time.Now().UnixMilli()
+	if err = v.encryptEntry(&entry); err != nil {
+		return err
+	}
+
+	// a failing step must not leave the entry half updated or without its folders and tags
+	return v.Transaction(func(tx Vault) error {
+		err := tx.store.UpdatePassword(entry)
+		if err != nil {
+			return err
+		}
+		err = tx.store.ClearPasswordPlacement(entry.ID)
+		if err != nil {
+			return err
+		}
+		return tx.Organize(entry.ID, folderPath, tags)
+	})
+}
+
+// itemEntry validates an item and lays it out as a plaintext entry with a JSON payload
+func itemEntry(item model.Item) (model.Password, error) {
+	if err := item.Validate(); err != nil {
+		return model.Password{}, err
+	}
+
+	entry, payload := item.Entry()
+	if len(payload) > 0 {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return model.Password{}, fmt.Errorf("failed to encode item: %w", err)
+		}
+		entry.Payload = string(encoded)
+	}
+	return entry, nil
+}
+
+// PutItem stores an item under a known ID and modification time with its custom fields and placement,
+// overwriting the entry holding the ID or adding it when there is none. Synchronization uses it to
+// apply entries changed on other devices, keeping their identity and the time of the change.
+func (v Vault) PutItem(id string, modified time.Time, item model.Item, fields []model.CustomField, folderPath string, tags []string) error {
+	if err := v.writable(); err != nil {
+		return err
+	}
+	entry, err := itemEntry(item)
+	if err != nil {
+		return err
+	}
+	entry.ID = id
+	entry.ModifiedAt = modified.UnixMilli()
+
+	return v.Transaction(func(tx Vault) error {
+		entries, err := tx.Entries()
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(entries, func(e model.Password) bool { return e.ID == id })
+		if i < 0 {
+			return tx.AddEntry(entry, fields, folderPath, tags)
+		}
+		stored := entries[i]
+
+		entry.UserID = tx.UserID()
+		if err = tx.encryptEntry(&entry); err != nil {
+			return err
+		}
+		// keep the furthest HOTP counter, codes generated from the other one must not come back
+		entry.OTPCounter = max(entry.OTPCounter, stored.OTPCounter)
+		if err = tx.store.UpdatePassword(entry); err != nil {
+			return err
+		}
+		if err = tx.SetFields(id, fields); err != nil {
+			return err
+		}
+		if err = tx.store.ClearPasswordPlacement(id); err != nil {
+			return err
+		}
+		return tx.Organize(id, folderPath, tags)
+	})
+}
+
+// DeriveCipher returns a cipher keyed by the passphrase of the vault owner and another salt,
+// for data the owner opens on other devices, whose vaults are keyed by salts of their own
+func (v Vault) DeriveCipher(salt string) (crypto.Cipher, error) {
+	if !v.session.IsAuthenticated() {
+		return crypto.Cipher{}, errors.New("no active user session")
+	}
+	if v.IsCollection() {
+		return crypto.Cipher{}, errors.New("collections have no passphrase to derive keys from")
+	}
+	return crypto.NewCipherFromPassphrase(v.session.GetPassphrase(), salt), nil
+}
+
+// AddEntry encrypts and stores a new entry with its custom fields, filing it under folderPath
+// and labelling it with tags. The entry's Password, Notes and Payload and the fields are expected in plaintext.
+// Entries without a modification time are stamped with the current one.
+func (v Vault) AddEntry(entry model.Password, fields []model.CustomField, folderPath string, tags []string) error {
+	if !v.session.IsAuthenticated() {
+		return errors.New("no active user session")
+	}
+	if err := v.writable(); err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if err := field.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	entry.UserID = v.UserID()
+	if entry.ModifiedAt == 0 {
+		entry.ModifiedAt = time.Now().UnixMilli()
+	}
+	err := v.encryptEntry(&entry)
+	if err != nil {
+		return err
+	}
+
+	// a failing step must not leave the entry without its fields or placement
+	return v.Transaction(func(tx Vault) error {
+		err := tx.store.AddPassword(entry)
+		if err != nil {
+			return err
+		}
+
+		if len(fields) > 0 {
+			err = tx.SetFields(entry.ID, fields)
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Organize(entry.ID, folderPath, tags)
+	})
+}
+
+// encryptEntry seals the password and encrypts the notes, the payload and the one-time password URI
+// of a plaintext entry. The HOTP counter is taken from the URI.
+func (v Vault) encryptEntry(entry *model.Password) error {
+	if entry.OTP != "" {
+		key, err := otp.Parse(entry.OTP)
+		if err != nil {
+			return fmt.Errorf("one-time password URI %w", err)
+		}
+		entry.OTPCounter = key.Counter
+	}
+
+	sealed, nonce, err := v.cipher.Seal([]byte(entry.Password))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt password: %w", err)
+	}
+	entry.Password = string(sealed)
+	entry.Nonce = nonce
+	entry.Type = entry.ItemType()
+
+	entry.Notes, err = v.encryptOptional(entry.Notes)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt notes: %w", err)
+	}
+	entry.Payload, err = v.encryptOptional(entry.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt item: %w", err)
+	}
+	entry.OTP, err = v.encryptOptional(entry.OTP)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt one-time password: %w", err)
+	}
+	return nil
+}
+
+// SetFields encrypts and stores the custom fields of an entry, replacing the existing ones
+func (v Vault) SetFields(entryID string, fields []model.CustomField) error {
+	if err := v.writable(); err != nil {
+		return err
+	}
+	encrypted := make([]model.CustomField, 0, len(fields))
+	for i, field := range fields {
+		if err := field.Validate(); err != nil {
+			return err
+		}
+		name, err := v.cipher.EncryptString(field.Name)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt custom field: %w", err)
+		}
+		value, err := v.cipher.EncryptString(field.Value)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt custom field: %w", err)
+		}
+		encrypted = append(encrypted, model.NewCustomField(entryID, i, field.Type, name, value))
+	}
+	return v.store.SetPasswordFields(entryID, encrypted)
+}
+
+// Reveal decrypts an entry with its custom fields and resolves its folders and tags
+func (v Vault) Reveal(entry model.Password) (EntryDetails, error) {
+	password, err := v.RevealPassword(entry)
+	if err != nil {
+		return EntryDetails{}, err
+	}
+	notes, err := v.decryptOptional(entry.Notes)
+	if err != nil {
+		return EntryDetails{}, fmt.Errorf("failed to decrypt notes: %w", err)
+	}
+	payload, err := v.decryptOptional(entry.Payload)
+	if err != nil {
+		return EntryDetails{}, fmt.Errorf("failed to decrypt item: %w", err)
+	}
+	values := model.ItemValues{}
+	if payload != "" {
+		if err = json.Unmarshal([]byte(payload), &values); err != nil {
+			return EntryDetails{}, fmt.Errorf("failed to decode item: %w", err)
+		}
+	}
+	otpURI, err := v.revealOTP(entry)
+	if err != nil {
+		return EntryDetails{}, err
+	}
+	entry.Password = password
+	entry.Notes = notes
+	entry.Payload = payload
+	entry.OTP = otpURI
+
+	stored, err := v.store.GetPasswordFields(entry.ID)
+	if err != nil {
+		return EntryDetails{}, err
+	}
+	fields := make([]model.CustomField, 0, len(stored))
+	for _, field := range stored {
+		field.Name, err = v.cipher.DecryptString(field.Name)
+		if err != nil {
+			return EntryDetails{}, fmt.Errorf("failed to decrypt custom field: %w", err)
+		}
+		field.Value, err = v.cipher.DecryptString(field.Value)
+		if err != nil {
+			return EntryDetails{}, fmt.Errorf("failed to decrypt custom field: %w", err)
+		}
+		fields = append(fields, field)
+	}
+
+	tree, err := v.Folders()
+	if err != nil {
+		return EntryDetails{}, err
+	}
+	folders, err := v.EntryFolders(entry.ID)
+	if err != nil {
+		return EntryDetails{}, err
+	}
+	folderPaths := make([]string, 0, len(folders))
+	for _, f := range folders {
+		folderPaths = append(folderPaths, tree.Path(f.ID))
+	}
+
+	tags, err := v.EntryTags(entry.ID)
+	if err != nil {
+		return EntryDetails{}, err
+	}
+	tagNames := make([]string, 0, len(tags))
+	for _, t := range tags {
+		tagNames = append(tagNames, t.Name)
+	}
+
+	return EntryDetails{
+		Entry:   entry,
+		Item:    model.ItemFromEntry(entry, values),
+		Fields:  fields,
+		Folders: folderPaths,
+		Tags:    tagNames,
+	}, nil
+}
+
+// Entry fetches a single entry of the vault owner by its ID
+func (v Vault) Entry(id string) (model.Password, error) {
+	entries, err := v.Entries()
+	if err != nil {
+		return model.Password{}, err
+	}
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+	}
+	return model.Password{}, fmt.Errorf("entry %s not found", id)
+}
+
+// FindEntry looks an entry up by title, and by username when several entries share the title
+func (v Vault) FindEntry(title, username string) (model.Password, error) {
+	if username != "" {
+		return v.store.GetPassword(v.UserID(), title, username)
+	}
+
+	entries, err := v.Entries()
+	if err != nil {
+		return model.Password{}, err
+	}
+	var matches []model.Password
+	for _, entry := range entries {
+		if entry.Title == title {
+			matches = append(matches, entry)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return model.Password{}, model.NewPasswordNotFoundError(v.UserID(), title, username)
+	case 1:
+		return matches[0], nil
+	default:
+		return model.Password{}, fmt.Errorf("%d entries titled %s, pick one by username", len(matches), title)
+	}
+}
+
+// encryptOptional encrypts a value, leaving empty values empty so their absence stays visible
+func (v Vault) encryptOptional(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	return v.cipher.EncryptString(plaintext)
+}
+
+// decryptOptional decrypts a value encrypted by encryptOptional
+func (v Vault) decryptOptional(sealed string) (string, error) {
+	if sealed == "" {
+		return "", nil
+	}
+	return v.cipher.DecryptString(sealed)
+}
+
+// Organize files an existing entry under folderPath and labels it with tags,
+// creating missing folders and tags on the way. Empty values are skipped.
+func (v Vault) Organize(entryID, folderPath string, tags []string) error {
+	if err := v.writable(); err != nil {
+		return err
+	}
+	if len(SplitFolderPath(folderPath)) > 0 {
+		folder, err := v.EnsureFolder(folderPath)
+		if err != nil {
+			return err
+		}
+		err = v.store.AddPasswordToFolder(entryID, folder.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range NormalizeTags(tags) {
+		tag, err := v.EnsureTag(name)
+		if err != nil {
+			return err
+		}
+		err = v.store.AddTagToPassword(entryID, tag.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevealPassword decrypts the secret of a stored entry
+func (v Vault) RevealPassword(entry model.Password) (string, error) {
+	plaintext, err := v.cipher.Open([]byte(entry.Password))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt password: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// RevealNotes decrypts the notes of a stored entry
+func (v Vault) RevealNotes(entry model.Password) (string, error) {
+	notes, err := v.decryptOptional(entry.Notes)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt notes: %w", err)
+	}
+	return notes, nil
+}
+
+// OTPKey decrypts the one-time password key of a stored entry, with the current HOTP counter
+func (v Vault) OTPKey(entry model.Password) (otp.Key, error) {
+	uri, err := v.decryptOptional(entry.OTP)
+	if err != nil {
+		return otp.Key{}, fmt.Errorf("failed to decrypt one-time password: %w", err)
+	}
+	if uri == "" {
+		return otp.Key{}, fmt.Errorf("%s has no one-time password", entry.Title)
+	}
+	key, err := otp.Parse(uri)
+	if err != nil {
+		return otp.Key{}, fmt.Errorf("one-time password URI %w", err)
+	}
+	key.Counter = entry.OTPCounter
+	return key, nil
+}
+
+// OneTimeCode generates the current one-time password of a stored entry. HOTP codes
+// are generated from a counter advanced in the store, so every call returns a new code.
+func (v Vault) OneTimeCode(entry model.Password, now time.Time) (otp.Code, error) {
+	key, err := v.OTPKey(entry)
+	if err != nil {
+		return otp.Code{}, err
+	}
+	if key.Type == otp.TypeHOTP {
+		key.Counter, err = v.store.IncrementOTPCounter(entry.ID)
+		if err != nil {
+			return otp.Code{}, err
+		}
+	}
+	return key.Generate(now), nil
+}
+
+// revealOTP decrypts the one-time password URI of a stored entry. HOTP URIs are rewritten with
+// the current counter, so editing the entry keeps it.
+func (v Vault) revealOTP(entry model.Password) (string, error) {
+	if entry.OTP == "" {
+		return "", nil
+	}
+	key, err := v.OTPKey(entry)
+	if err != nil {
+		return "", err
+	}
+	if key.Type == otp.TypeHOTP {
+		return key.URI(), nil
+	}
+	return v.decryptOptional(entry.OTP)
+}
+
+// Entries fetches all entries of the vault owner
+func (v Vault) Entries() ([]model.Password, error) {
+	return v.store.GetAllUserPasswords(v.UserID())
+}
@@ -0,0 +1,558 @@
+//go:build integration
+
+package vault
+
+import (
+	"testing"
+	"time"
+	"yubigo-pass/internal/app/crypto"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+	"yubigo-pass/test/usertest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldUnlockVaultWithCorrectCredentials(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+
+	// given
+	username, password := test.RandomString(), test.RandomString()
+	user := usertest.Insert(t, db, username, password)
+
+	// when
+	session, err := Unlock(store, username, password)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, user.GetUserID(), session.GetUserID())
+	assert.True(t, session.IsAuthenticated())
+}
+
+func TestShouldNotUnlockVaultWithIncorrectCredentials(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+
+	// given
+	username := test.RandomString()
+	usertest.Insert(t, db, username, test.RandomString())
+
+	testCases := []struct {
+		name     string
+		username string
+	}{
+		{name: "wrong password", username: username},
+		{name: "unknown user", username: test.RandomString()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			session, err := Unlock(store, tc.username, test.RandomString())
+
+			// then
+			assert.EqualError(t, err, "incorrect username or password")
+			assert.False(t, session.IsAuthenticated())
+		})
+	}
+}
+
+func TestUnlockShouldThrottleFailedAttempts(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+
+	// given
+	username, password := test.RandomString(), test.RandomString()
+	usertest.Insert(t, db, username, password)
+
+	testCases := []struct {
+		name     string
+		username string
+	}{
+		{name: "existing user", username: username},
+		{name: "unknown user", username: test.RandomString()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for range model.DefaultLoginPolicy.FreeFailures - 1 {
+				_, err = Unlock(store, tc.username, test.RandomString())
+				require.EqualError(t, err, "incorrect username or password")
+			}
+
+			// when
+			_, failedErr := Unlock(store, tc.username, test.RandomString())
+			_, throttledErr := Unlock(store, tc.username, password)
+
+			// then
+			var throttled model.LoginThrottledError
+			require.ErrorAs(t, failedErr, &throttled)
+			assert.ErrorIs(t, failedErr, ErrIncorrectCredentials)
+			assert.WithinDuration(t, time.Now().Add(model.DefaultLoginPolicy.BaseDelay), throttled.RetryAt, time.Second)
+			assert.ErrorAs(t, throttledErr, &model.LoginThrottledError{})
+			assert.NotErrorIs(t, throttledErr, ErrIncorrectCredentials)
+		})
+	}
+}
+
+func TestUnlockShouldClearFailedAttemptsOnSuccess(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+
+	// given
+	username, password := test.RandomString(), test.RandomString()
+	usertest.Insert(t, db, username, password)
+	expired := model.LoginAttempts{
+		UsernameHash: hashUsername(username),
+		Failures:     model.DefaultLoginPolicy.MaxFailures - 1,
+		LastFailure:  time.Now().Add(-model.DefaultLoginPolicy.MaxDelay).UnixMilli(),
+	}
+	require.NoError(t, store.SaveLoginAttempts(expired))
+
+	// when
+	session, err := Unlock(store, username, password)
+
+	// then
+	require.NoError(t, err)
+	assert.True(t, session.IsAuthenticated())
+	attempts, err := store.GetLoginAttempts(expired.UsernameHash)
+	require.NoError(t, err)
+	assert.Zero(t, attempts.Failures)
+}
+
+func TestUnlockShouldLockOutAfterTooManyFailedAttempts(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+
+	// given
+	username, password := test.RandomString(), test.RandomString()
+	usertest.Insert(t, db, username, password)
+	require.NoError(t, store.SaveLoginAttempts(model.LoginAttempts{
+		UsernameHash: hashUsername(username),
+		Failures:     model.DefaultLoginPolicy.MaxFailures - 1,
+		LastFailure:  time.Now().Add(-model.DefaultLoginPolicy.MaxDelay).UnixMilli(),
+	}))
+
+	// when
+	_, err = Unlock(store, username, test.RandomString())
+
+	// then
+	var throttled model.LoginThrottledError
+	require.ErrorAs(t, err, &throttled)
+	assert.WithinDuration(t, time.Now().Add(model.DefaultLoginPolicy.Lockout), throttled.RetryAt, time.Second)
+	_, err = Unlock(store, username, password)
+	assert.ErrorAs(t, err, &model.LoginThrottledError{})
+}
+
+func TestShouldAddEntryWithFolderAndTags(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := New(store, session)
+
+	// given
+	secret := test.RandomString()
+	entry := model.Password{Title: test.RandomString(), Username: test.RandomString(), Password: secret}
+
+	// when
+	err = v.AddEntry(entry, nil, "Work/Servers", []string{"prod", "ssh"})
+
+	// then
+	require.NoError(t, err)
+
+	stored, err := store.GetPassword(session.GetUserID(), entry.Title, entry.Username)
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, stored.Password)
+	revealed, err := v.RevealPassword(stored)
+	require.NoError(t, err)
+	assert.Equal(t, secret, revealed)
+
+	tree, err := v.Folders()
+	require.NoError(t, err)
+	folder, ok := tree.Find("Work/Servers")
+	require.True(t, ok)
+	inFolder, err := v.EntriesInFolder(folder.ID)
+	require.NoError(t, err)
+	require.Len(t, inFolder, 1)
+	assert.Equal(t, stored.ID, inFolder[0].ID)
+
+	tag, ok, err := v.FindTag("ssh")
+	require.NoError(t, err)
+	require.True(t, ok)
+	withTag, err := v.EntriesWithTag(tag.ID)
+	require.NoError(t, err)
+	require.Len(t, withTag, 1)
+	assert.Equal(t, stored.ID, withTag[0].ID)
+
+	tags, err := v.EntryTags(stored.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []Tag{{ID: tags[0].ID, Name: "prod"}, {ID: tags[1].ID, Name: "ssh"}}, tags)
+}
+
+func TestShouldNotKeepHalfWrittenEntryWhenFilingItFails(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := New(store, session)
+
+	// given
+	entry := model.Password{Title: test.RandomString(), Username: test.RandomString(), Password: test.RandomString()}
+	fields := []model.CustomField{{Type: model.FieldTypeText, Name: "Scope", Value: "repo"}}
+	_, err = db.Exec(`DROP TABLE password_tags`)
+	require.NoError(t, err)
+
+	// when
+	err = v.AddEntry(entry, fields, "Work", []string{"prod"})
+
+	// then
+	require.Error(t, err)
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	tree, err := v.Folders()
+	require.NoError(t, err)
+	_, ok := tree.Find("Work")
+	assert.False(t, ok)
+}
+
+func TestShouldStoreFolderAndTagNamesEncrypted(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := New(store, session)
+
+	// when
+	_, err = v.EnsureFolder("Banking")
+	require.NoError(t, err)
+	_, err = v.EnsureTag("finance")
+	require.NoError(t, err)
+
+	// then
+	folders, err := store.GetAllUserFolders(session.GetUserID())
+	require.NoError(t, err)
+	require.Len(t, folders, 1)
+	assert.NotEqual(t, "Banking", folders[0].Name)
+
+	tags, err := store.GetAllUserTags(session.GetUserID())
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.NotEqual(t, "finance", tags[0].Name)
+}
+
+func TestShouldReuseExistingFoldersAndTags(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := New(store, session)
+
+	// given
+	first, err := v.EnsureFolder("Work/Servers")
+	require.NoError(t, err)
+	firstTag, err := v.EnsureTag("prod")
+	require.NoError(t, err)
+
+	// when
+	second, err := v.EnsureFolder("Work/Servers")
+	require.NoError(t, err)
+	secondTag, err := v.EnsureTag("prod")
+	require.NoError(t, err)
+
+	// then
+	assert.Equal(t, first, second)
+	assert.Equal(t, firstTag, secondTag)
+	folders, err := store.GetAllUserFolders(session.GetUserID())
+	require.NoError(t, err)
+	assert.Len(t, folders, 2)
+}
+
+func TestShouldAddAndRevealEntryWithNotesAndCustomFields(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := New(store, session)
+
+	// given
+	notes := "line one\nline two"
+	fields := []model.CustomField{
+		{Type: model.FieldTypeHidden, Name: "PIN", Value: "1234"},
+		{Type: model.FieldTypeBoolean, Name: "MFA", Value: "true"},
+	}
+	entry := model.Password{Title: test.RandomString(), Username: test.RandomString(), Password: test.RandomString(), Notes: notes}
+
+	// when
+	err = v.AddEntry(entry, fields, "Work", []string{"prod"})
+	require.NoError(t, err)
+
+	// then
+	stored, err := store.GetPassword(session.GetUserID(), entry.Title, entry.Username)
+	require.NoError(t, err)
+	assert.NotEqual(t, notes, stored.Notes)
+	storedFields, err := store.GetPasswordFields(stored.ID)
+	require.NoError(t, err)
+	require.Len(t, storedFields, 2)
+	assert.NotEqual(t, "PIN", storedFields[0].Name)
+	assert.NotEqual(t, "1234", storedFields[0].Value)
+
+	details, err := v.Reveal(stored)
+	require.NoError(t, err)
+	assert.Equal(t, entry.Password, details.Entry.Password)
+	assert.Equal(t, notes, details.Entry.Notes)
+	assert.Equal(t, []string{"Work"}, details.Folders)
+	assert.Equal(t, []string{"prod"}, details.Tags)
+	require.Len(t, details.Fields, 2)
+	assert.Equal(t, "PIN", details.Fields[0].Name)
+	assert.Equal(t, "1234", details.Fields[0].Value)
+	assert.Equal(t, model.FieldTypeBoolean, details.Fields[1].Type)
+}
+
+func TestShouldNotAddEntryWithInvalidCustomField(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := New(store, session)
+
+	// given
+	fields := []model.CustomField{{Type: model.FieldTypeBoolean, Name: "MFA", Value: "maybe"}}
+	entry := model.Password{Title: test.RandomString(), Username: test.RandomString(), Password: test.RandomString()}
+
+	// when
+	err = v.AddEntry(entry, fields, "", nil)
+
+	// then
+	assert.EqualError(t, err, "custom field MFA must be true or false")
+	entries, err := v.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestShouldAddAndRevealTypedItem(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := New(store, session)
+
+	// given
+	item := model.Item{
+		Type:  model.ItemTypeCard,
+		Title: "Visa",
+		Values: model.ItemValues{
+			"cardholder": "Jane Doe",
+			"number":     "4111 1111 1111 1111",
+			"expiry":     "09/29",
+			"cvv":        "123",
+		},
+	}
+
+	// when
+	id, err := v.AddItem(item, nil, "Finance", nil)
+	require.NoError(t, err)
+
+	// then
+	stored, err := store.GetPassword(session.GetUserID(), "Visa", "Jane Doe")
+	require.NoError(t, err)
+	assert.Equal(t, id, stored.ID)
+	assert.Equal(t, model.ItemTypeCard, stored.Type)
+	assert.NotContains(t, stored.Payload, "09/29")
+	assert.NotContains(t, stored.Password, "4111")
+
+	details, err := v.Reveal(stored)
+	require.NoError(t, err)
+	assert.Equal(t, item, details.Item)
+	assert.Equal(t, []string{"Finance"}, details.Folders)
+}
+
+func TestShouldNotAddInvalidTypedItem(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	v := New(database.NewStore(db), utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString()))
+
+	// given
+	item := model.Item{
+		Type:   model.ItemTypeCard,
+		Title:  "Visa",
+		Values: model.ItemValues{"cardholder": "Jane Doe", "number": "4111 1111 1111 1112", "expiry": "09/29"},
+	}
+
+	// when
+	_, err = v.AddItem(item, nil, "", nil)
+
+	// then
+	assert.EqualError(t, err, "card number is not valid")
+}
+
+func TestShouldRevealLegacyEntryAsLogin(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := New(store, session)
+
+	// given
+	cipher := crypto.NewCipherFromPassphrase(session.GetPassphrase(), session.GetSalt())
+	sealed, nonce, err := cipher.Seal([]byte("hunter2"))
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO passwords (id, user_id, title, username, password, url, nonce) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		uuid.New().String(), session.GetUserID(), "github", "octocat", string(sealed), "https://github.com", nonce)
+	require.NoError(t, err)
+	stored, err := store.GetPassword(session.GetUserID(), "github", "octocat")
+	require.NoError(t, err)
+
+	// when
+	details, err := v.Reveal(stored)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, model.ItemTypeLogin, details.Item.Type)
+	assert.Equal(t, model.ItemValues{"username": "octocat", "password": "hunter2", "url": "https://github.com", "otp": ""}, details.Item.Values)
+}
+
+func TestShouldUpdateItemAndPlacement(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := New(store, session)
+
+	// given
+	item := model.Item{
+		Type:   model.ItemTypeAPIToken,
+		Title:  "ci",
+		Values: model.ItemValues{"key_id": "bot", "token": "old"},
+	}
+	fields := []model.CustomField{{Type: model.FieldTypeText, Name: "Scope", Value: "repo"}}
+	_, err = v.AddItem(item, fields, "Work", []string{"ci"})
+	require.NoError(t, err)
+	stored, err := store.GetPassword(session.GetUserID(), "ci", "bot")
+	require.NoError(t, err)
+
+	updated := item
+	updated.Values = model.ItemValues{"key_id": "bot", "token": "new", "endpoint": "https://api.example.com", "expires": "2030-01-01"}
+	updated.Notes = "rotated"
+
+	// when
+	err = v.UpdateItem(stored, updated, "Home", []string{"deploy"})
+
+	// then
+	require.NoError(t, err)
+	stored, err = v.Entry(stored.ID)
+	require.NoError(t, err)
+	details, err := v.Reveal(stored)
+	require.NoError(t, err)
+	assert.Equal(t, updated, details.Item)
+	assert.Equal(t, []string{"Home"}, details.Folders)
+	assert.Equal(t, []string{"deploy"}, details.Tags)
+	require.Len(t, details.Fields, 1)
+	assert.Equal(t, "Scope", details.Fields[0].Name)
+}
+
+func TestShouldKeepItemAndPlacementWhenUpdateFails(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	v := New(store, session)
+
+	// given
+	item := model.Item{
+		Type:   model.ItemTypeAPIToken,
+		Title:  "ci",
+		Values: model.ItemValues{"key_id": "bot", "token": "old"},
+	}
+	_, err = v.AddItem(item, nil, "Work", nil)
+	require.NoError(t, err)
+	stored, err := store.GetPassword(session.GetUserID(), "ci", "bot")
+	require.NoError(t, err)
+	_, err = db.Exec(`DROP TABLE password_tags`)
+	require.NoError(t, err)
+
+	updated := item
+	updated.Values = model.ItemValues{"key_id": "bot", "token": "new"}
+
+	// when
+	err = v.UpdateItem(stored, updated, "Home", nil)
+
+	// then
+	require.Error(t, err)
+	_, err = db.Exec(`CREATE TABLE password_tags (password_id TEXT NOT NULL, tag_id TEXT NOT NULL)`)
+	require.NoError(t, err)
+	stored, err = v.Entry(stored.ID)
+	require.NoError(t, err)
+	details, err := v.Reveal(stored)
+	require.NoError(t, err)
+	assert.Equal(t, "old", details.Item.Values["token"])
+	assert.Equal(t, []string{"Work"}, details.Folders)
+}
+
+func TestUnlockShouldGenerateKeyPairOfUser(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	require.NoError(t, err)
+	defer test.TeardownTestDB(db)
+	store := database.NewStore(db)
+
+	// given
+	username, password := test.RandomString(), test.RandomString()
+	user := usertest.Insert(t, db, username, password)
+
+	// when
+	session, err := Unlock(store, username, password)
+	require.NoError(t, err)
+	key, err := store.GetUserKey(user.GetUserID())
+	require.NoError(t, err)
+	_, err = Unlock(store, username, password)
+	require.NoError(t, err)
+
+	// then
+	public, err := New(store, session).EnsureKeyPair()
+	require.NoError(t, err)
+	assert.Equal(t, key.PublicKey, public)
+}
@@ -0,0 +1,377 @@
+// Package backup takes consistent snapshots of the SQLite database while it is open and restores
+// them. Snapshots are written with VACUUM INTO, which reads the database in a single transaction,
+// into a rotation directory keeping the newest few. A snapshot is checked for integrity before it
+// replaces the database file. Attachment blobs live outside the database and are not part of snapshots.
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DirName is the name of the snapshot directory kept next to the database file
+const DirName = "backups"
+
+// Defaults used when the environment does not configure the rotation
+const (
+	DefaultKeep     = 7
+	DefaultInterval = 24 * time.Hour
+)
+
+// Environment variables configuring the rotation
+const (
+	// EnvKeep is the number of snapshots kept
+	EnvKeep = "YUBIGO_PASS_BACKUP_KEEP"
+	// EnvInterval is how often scheduled snapshots are taken, as a Go duration; 0 disables them
+	EnvInterval = "YUBIGO_PASS_BACKUP_INTERVAL"
+)
+
+// Snapshot file names are the prefix, the UTC time taken and the reason, e.g.
+// yubigo-pass-20240102T150405.000Z-scheduled.db
+const (
+	filePrefix = "yubigo-pass-"
+	fileSuffix = ".db"
+	timeLayout = "20060102T150405.000Z"
+)
+
+// Reason tells why a snapshot was taken
+type Reason string
+
+// Reasons snapshots are taken for
+const (
+	ReasonManual    Reason = "manual"
+	ReasonScheduled Reason = "scheduled"
+	ReasonMigration Reason = "pre-migration"
+	ReasonRestore   Reason = "pre-restore"
+)
+
+// Errors returned when a snapshot cannot be restored
+var (
+	ErrCorrupted   = errors.New("backup failed the integrity check")
+	ErrNotDatabase = errors.New("not a yubigo-pass database")
+)
+
+// Execer runs statements against an open database
+type Execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Snapshot is a single backup file of the rotation
+type Snapshot struct {
+	Path   string
+	Taken  time.Time
+	Reason Reason
+}
+
+// Rotation is a directory of snapshots keeping the Keep newest
+type Rotation struct {
+	Dir  string
+	Keep int
+}
+
+// DirFor returns the snapshot directory of the database file
+func DirFor(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), DirName)
+}
+
+// FromEnv returns the rotation of the database file and the interval of scheduled snapshots,
+// using the defaults for settings missing from the environment
+func FromEnv(dbPath string) (Rotation, time.Duration, error) {
+	rotation := Rotation{Dir: DirFor(dbPath), Keep: DefaultKeep}
+	interval := DefaultInterval
+	if value := os.Getenv(EnvKeep); value != "" {
+		var keep int
+		if _, err := fmt.Sscan(value, &keep); err != nil || keep < 1 {
+			return Rotation{}, 0, fmt.Errorf("$%s must be a positive number, got %q", EnvKeep, value)
+		}
+		rotation.Keep = keep
+	}
+	if value := os.Getenv(EnvInterval); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			return Rotation{}, 0, fmt.Errorf("$%s must be a duration such as 12h, got %q", EnvInterval, value)
+		}
+		interval = parsed
+	}
+	return rotation, interval, nil
+}
+
+// Take snapshots the database into the rotation and removes the snapshots past the newest Keep.
+// The snapshot is written under a temporary name first, so an interrupted one is never listed.
+func (r Rotation) Take(db Execer, reason Reason) (Snapshot, error) {
+	if err := os.MkdirAll(r.Dir, 0o700); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	taken := time.Now().UTC().Truncate(time.Millisecond)
+	path := filepath.Join(r.Dir, filePrefix+taken.Format(timeLayout)+"-"+string(reason)+fileSuffix)
+	partial := path + ".partial"
+
+	if _, err := db.Exec("VACUUM INTO ?", partial); err != nil {
+		_ = os.Remove(partial)
+		return Snapshot{}, fmt.Errorf("failed to back up database: %w", err)
+	}
+	if err := os.Chmod(partial, 0o600); err != nil {
+		_ = os.Remove(partial)
+		return Snapshot{}, fmt.Errorf("failed to back up database: %w", err)
+	}
+	if err := os.Rename(partial, path); err != nil {
+		_ = os.Remove(partial)
+		return Snapshot{}, fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	snapshot := Snapshot{Path: path, Taken: taken, Reason: reason}
+	return snapshot, r.prune()
+}
+
+// List returns the snapshots of the rotation, newest first
+func (r Rotation) List() ([]Snapshot, error) {
+	dirEntries, err := os.ReadDir(r.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range dirEntries {
+		if snapshot, ok := parseName(r.Dir, entry.Name()); ok && entry.Type().IsRegular() {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	slices.SortFunc(snapshots, func(a, b Snapshot) int { return b.Taken.Compare(a.Taken) })
+	return snapshots, nil
+}
+
+// Due reports whether the newest snapshot is older than the interval
+func (r Rotation) Due(interval time.Duration) (bool, error) {
+	snapshots, err := r.List()
+	if err != nil {
+		return false, err
+	}
+	return len(snapshots) == 0 || time.Since(snapshots[0].Taken) >= interval, nil
+}
+
+// prune removes the snapshots past the newest Keep
+func (r Rotation) prune() error {
+	snapshots, err := r.List()
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots[min(r.Keep, len(snapshots)):] {
+		if err = os.Remove(snapshot.Path); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseName reads the time and reason of a snapshot from its file name
+func parseName(dir, name string) (Snapshot, bool) {
+	rest, ok := strings.CutPrefix(name, filePrefix)
+	if !ok {
+		return Snapshot{}, false
+	}
+	rest, ok = strings.CutSuffix(rest, fileSuffix)
+	if !ok || len(rest) < len(timeLayout)+2 {
+		return Snapshot{}, false
+	}
+	taken, err := time.Parse(timeLayout, rest[:len(timeLayout)])
+	if err != nil || rest[len(timeLayout)] != '-' {
+		return Snapshot{}, false
+	}
+	return Snapshot{Path: filepath.Join(dir, name), Taken: taken, Reason: Reason(rest[len(timeLayout)+1:])}, true
+}
+
+// Check opens the database file read-only and verifies it passes SQLite's integrity and
+// foreign key checks and holds a yubigo-pass schema that is not halfway through a migration
+func Check(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	db, err := sqlx.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer db.Close()
+
+	var problems []string
+	if err = db.Select(&problems, "PRAGMA integrity_check"); err != nil {
+		if strings.Contains(err.Error(), "not a database") {
+			return ErrNotDatabase
+		}
+		return fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+	if len(problems) != 1 || problems[0] != "ok" {
+		return fmt.Errorf("%w: %s", ErrCorrupted, strings.Join(problems[:min(3, len(problems))], "; "))
+	}
+
+	rows, err := db.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+	violated := rows.Next()
+	_ = rows.Close()
+	if violated {
+		return fmt.Errorf("%w: foreign key violations", ErrCorrupted)
+	}
+
+	var dirty bool
+	if err = db.Get(&dirty, "SELECT dirty FROM schema_migrations"); err != nil {
+		return ErrNotDatabase
+	}
+	if dirty {
+		return fmt.Errorf("%w: taken during a failed migration", ErrCorrupted)
+	}
+	var users int
+	if err = db.Get(&users, "SELECT COUNT(*) FROM users"); err != nil {
+		return ErrNotDatabase
+	}
+	return nil
+}
+
+// Restore checks the snapshot and atomically replaces the database file with a copy of it.
+// The database must be closed; leftover journal files of the replaced database are removed.
+func Restore(snapshotPath, dbPath string) error {
+	if err := Check(snapshotPath); err != nil {
+		return err
+	}
+	staged, err := stage(snapshotPath, dbPath)
+	if err != nil {
+		return err
+	}
+	return swap(staged, dbPath)
+}
+
+// stage copies the snapshot next to the database file, returning the path of the copy
+func stage(snapshotPath, dbPath string) (string, error) {
+	src, err := os.Open(snapshotPath) // #nosec G304 -- path of the backup chosen by the user
+	if err != nil {
+		return "", fmt.Errorf("failed to restore backup: %w", err)
+	}
+	defer src.Close()
+	staged, err := os.CreateTemp(filepath.Dir(dbPath), filepath.Base(dbPath)+".restore-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	_, err = io.Copy(staged, src)
+	if err == nil {
+		err = staged.Sync()
+	}
+	if closeErr := staged.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(staged.Name())
+		return "", fmt.Errorf("failed to restore backup: %w", err)
+	}
+	return staged.Name(), nil
+}
+
+// swap moves the staged copy over the database file
+func swap(staged, dbPath string) error {
+	defer os.Remove(staged)
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to restore backup: %w", err)
+		}
+	}
+	if err := os.Rename(staged, dbPath); err != nil {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+	return nil
+}
+
+// Manager takes scheduled and on-demand snapshots of an open database and restores them
+type Manager struct {
+	db       *sqlx.DB
+	dbPath   string
+	Rotation Rotation
+	// Interval between scheduled snapshots, 0 disables them
+	Interval time.Duration
+}
+
+// NewManager returns new Manager instance for the database opened from dbPath
+func NewManager(db *sqlx.DB, dbPath string, rotation Rotation, interval time.Duration) Manager {
+	return Manager{
+		db:       db,
+		dbPath:   dbPath,
+		Rotation: rotation,
+		Interval: interval,
+	}
+}
+
+// Take snapshots the database now
+func (m Manager) Take(reason Reason) (Snapshot, error) {
+	return m.Rotation.Take(m.db, reason)
+}
+
+// TakeIfDue takes a scheduled snapshot when the newest one is older than the interval
+func (m Manager) TakeIfDue() (Snapshot, bool, error) {
+	if m.Interval <= 0 {
+		return Snapshot{}, false, nil
+	}
+	due, err := m.Rotation.Due(m.Interval)
+	if err != nil || !due {
+		return Snapshot{}, false, err
+	}
+	snapshot, err := m.Take(ReasonScheduled)
+	return snapshot, err == nil, err
+}
+
+// Schedule takes scheduled snapshots until the context is done, checking at least hourly
+// whether one is due. Failures are passed to onError and retried on the next check.
+func (m Manager) Schedule(ctx context.Context, onError func(error)) {
+	if m.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(min(m.Interval, time.Hour))
+	defer ticker.Stop()
+	for {
+		if _, _, err := m.TakeIfDue(); err != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Restore replaces the database with the snapshot after checking it, first taking a snapshot
+// of the current database so the restore can be undone. It closes the database, the manager
+// and every store using it cannot be used afterwards.
+func (m Manager) Restore(snapshotPath string) (Snapshot, error) {
+	if err := Check(snapshotPath); err != nil {
+		return Snapshot{}, err
+	}
+	// staged before the undo snapshot, whose pruning may remove the one restored
+	staged, err := stage(snapshotPath, m.dbPath)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	undo, err := m.Take(ReasonRestore)
+	if err != nil {
+		_ = os.Remove(staged)
+		return Snapshot{}, err
+	}
+	if err = m.db.Close(); err != nil {
+		_ = os.Remove(staged)
+		return Snapshot{}, fmt.Errorf("failed to close database: %w", err)
+	}
+	return undo, swap(staged, m.dbPath)
+}
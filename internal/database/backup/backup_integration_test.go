@@ -0,0 +1,232 @@
+//go:build integration
+
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openDB creates a migrated database file holding a single user
+func openDB(t *testing.T) (*sqlx.DB, string) {
+	t.Helper()
+	_, b, _, _ := runtime.Caller(0)
+	migrationsDir := filepath.Join(filepath.Dir(b), "..", "..", "..", "assets", "migrations")
+	dbPath := filepath.Join(t.TempDir(), "yubigo-pass.db")
+
+	db, err := sqlx.Connect("sqlite3", dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	driver, err := sqlite.WithInstance(db.DB, &sqlite.Config{})
+	require.NoError(t, err)
+	m, err := migrate.NewWithDatabaseInstance("file://"+migrationsDir, "sqlite3", driver)
+	require.NoError(t, err)
+	if err = m.Up(); !errors.Is(err, migrate.ErrNoChange) {
+		require.NoError(t, err)
+	}
+	insertUser(t, db, "alice")
+	return db, dbPath
+}
+
+func insertUser(t *testing.T, db *sqlx.DB, username string) {
+	t.Helper()
+	_, err := db.Exec("INSERT INTO users (id, username, password, salt) VALUES (?, ?, 'hash', 'salt')", username, username)
+	require.NoError(t, err)
+}
+
+func usernames(t *testing.T, dbPath string) []string {
+	t.Helper()
+	db, err := sqlx.Connect("sqlite3", dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+	var names []string
+	require.NoError(t, db.Select(&names, "SELECT username FROM users ORDER BY username"))
+	return names
+}
+
+func TestTakeShouldSnapshotOpenDatabase(t *testing.T) {
+	// given
+	db, dbPath := openDB(t)
+	rotation := Rotation{Dir: DirFor(dbPath), Keep: 3}
+
+	// when
+	snapshot, err := rotation.Take(db, ReasonManual)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, ReasonManual, snapshot.Reason)
+	assert.WithinDuration(t, time.Now(), snapshot.Taken, time.Minute)
+	assert.NoError(t, Check(snapshot.Path))
+	assert.Equal(t, []string{"alice"}, usernames(t, snapshot.Path))
+	info, err := os.Stat(snapshot.Path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	snapshots, err := rotation.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Snapshot{snapshot}, snapshots)
+}
+
+func TestTakeShouldKeepNewestSnapshots(t *testing.T) {
+	// given
+	db, dbPath := openDB(t)
+	rotation := Rotation{Dir: DirFor(dbPath), Keep: 2}
+	var taken []Snapshot
+	for range 4 {
+		snapshot, err := rotation.Take(db, ReasonScheduled)
+		require.NoError(t, err)
+		taken = append(taken, snapshot)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	// when
+	snapshots, err := rotation.List()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []Snapshot{taken[3], taken[2]}, snapshots)
+	assert.NoFileExists(t, taken[0].Path)
+}
+
+func TestListShouldIgnoreOtherFiles(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	for _, name := range []string{"notes.txt", "yubigo-pass-20240102T150405.000Z-manual.db.partial", "yubigo-pass-garbage.db"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "yubigo-pass-20240102T150405.000Z-pre-migration.db"), nil, 0o600))
+
+	// when
+	snapshots, err := Rotation{Dir: dir, Keep: 7}.List()
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []Snapshot{{
+		Path:   filepath.Join(dir, "yubigo-pass-20240102T150405.000Z-pre-migration.db"),
+		Taken:  time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+		Reason: ReasonMigration,
+	}}, snapshots)
+}
+
+func TestTakeIfDueShouldFollowInterval(t *testing.T) {
+	// given
+	db, dbPath := openDB(t)
+	manager := NewManager(db, dbPath, Rotation{Dir: DirFor(dbPath), Keep: 3}, time.Hour)
+
+	// when
+	first, tookFirst, err := manager.TakeIfDue()
+	require.NoError(t, err)
+	_, tookSecond, err := manager.TakeIfDue()
+	require.NoError(t, err)
+
+	// then
+	assert.True(t, tookFirst)
+	assert.Equal(t, ReasonScheduled, first.Reason)
+	assert.False(t, tookSecond)
+}
+
+func TestTakeIfDueShouldDoNothingWhenDisabled(t *testing.T) {
+	// given
+	db, dbPath := openDB(t)
+	manager := NewManager(db, dbPath, Rotation{Dir: DirFor(dbPath), Keep: 3}, 0)
+
+	// when
+	_, took, err := manager.TakeIfDue()
+
+	// then
+	require.NoError(t, err)
+	assert.False(t, took)
+	assert.NoDirExists(t, DirFor(dbPath))
+}
+
+func TestRestoreShouldSwapInSnapshotAndKeepReplacedDatabase(t *testing.T) {
+	// given
+	db, dbPath := openDB(t)
+	manager := NewManager(db, dbPath, Rotation{Dir: DirFor(dbPath), Keep: 1}, 0)
+	snapshot, err := manager.Take(ReasonManual)
+	require.NoError(t, err)
+	insertUser(t, db, "bob")
+
+	// when
+	undo, err := manager.Restore(snapshot.Path)
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, usernames(t, dbPath))
+	assert.Equal(t, ReasonRestore, undo.Reason)
+	assert.Equal(t, []string{"alice", "bob"}, usernames(t, undo.Path))
+	assert.Error(t, db.Ping())
+	matches, err := filepath.Glob(dbPath + ".restore-*")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestRestoreShouldRejectCorruptedBackup(t *testing.T) {
+	// given
+	db, dbPath := openDB(t)
+	snapshot, err := Rotation{Dir: DirFor(dbPath), Keep: 3}.Take(db, ReasonManual)
+	require.NoError(t, err)
+	data, err := os.ReadFile(snapshot.Path)
+	require.NoError(t, err)
+	for i := 4096; i < len(data); i++ {
+		data[i] = 0xff
+	}
+	require.NoError(t, os.WriteFile(snapshot.Path, data, 0o600))
+
+	// when
+	err = Restore(snapshot.Path, dbPath)
+
+	// then
+	assert.ErrorIs(t, err, ErrCorrupted)
+	assert.Equal(t, []string{"alice"}, usernames(t, dbPath))
+}
+
+func TestCheckShouldRejectOtherFiles(t *testing.T) {
+	// given
+	dir := t.TempDir()
+	text := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(text, []byte("not a database at all, just some text that is long enough"), 0o600))
+	other := filepath.Join(dir, "other.db")
+	db, err := sqlx.Connect("sqlite3", other)
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE things (id TEXT)")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// then
+	assert.ErrorIs(t, Check(text), ErrNotDatabase)
+	assert.ErrorIs(t, Check(other), ErrNotDatabase)
+	assert.ErrorIs(t, Check(filepath.Join(dir, "missing.db")), os.ErrNotExist)
+}
+
+func TestFromEnvShouldReadRotationSettings(t *testing.T) {
+	// given
+	t.Setenv(EnvKeep, "3")
+	t.Setenv(EnvInterval, "6h")
+
+	// when
+	rotation, interval, err := FromEnv("/data/yubigo-pass.db")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, Rotation{Dir: filepath.Join("/data", DirName), Keep: 3}, rotation)
+	assert.Equal(t, 6*time.Hour, interval)
+
+	// when
+	t.Setenv(EnvKeep, "0")
+	_, _, err = FromEnv("/data/yubigo-pass.db")
+
+	// then
+	assert.EqualError(t, err, `$YUBIGO_PASS_BACKUP_KEEP must be a positive number, got "0"`)
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"yubigo-pass/internal/database/backup"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
 
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
@@ -25,8 +27,9 @@ const MigrationPath = "file://assets/migrations"
 
 var db *sqlx.DB
 
-// CreateDB Creates DB instance
-func CreateDB(dbFilePath, migrationPath string) (*sqlx.DB, error) {
+// CreateDB Creates DB instance, snapshotting an existing database into the backup rotation
+// before applying pending migrations. A rotation without a directory takes no snapshot.
+func CreateDB(dbFilePath, migrationPath string, backups backup.Rotation) (*sqlx.DB, error) {
 	err := os.MkdirAll(filepath.Dir(dbFilePath), 0750)
 	if err != nil {
 		return nil, fmt.Errorf("error creating directory path: %w", err)
@@ -38,18 +41,19 @@ func CreateDB(dbFilePath, migrationPath string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error creating database instance: %w", err)
 	}
 
-	driver, err := sqlite.WithInstance(db.DB, &sqlite.Config{})
-	if err != nil {
-		CloseDB()
-		return nil, fmt.Errorf("error creating database driver: %w", err)
-	}
-
 	//log.Info("Starting migration")
 
-	m, err := migrate.NewWithDatabaseInstance(migrationPath, "sqlite3", driver)
+	m, err := newMigrate(db, migrationPath)
 	if err != nil {
 		CloseDB()
-		return nil, fmt.Errorf("error creating migration instance: %w", err)
+		return nil, err
+	}
+
+	if backups.Dir != "" {
+		if err = backupBeforeMigration(db, m, migrationPath, backups); err != nil {
+			CloseDB()
+			return nil, err
+		}
 	}
 
 	err = m.Up()
@@ -62,6 +66,80 @@ func CreateDB(dbFilePath, migrationPath string) (*sqlx.DB, error) {
 	return db, nil
 }
 
+// OpenCopy opens a copy of the database file at path, written to copyPath and migrated to the current
+// schema, leaving the file itself untouched. It is meant for reading the vaults of another database,
+// which may have been written by an older version of yubigo-pass. The caller closes the copy and removes it.
+func OpenCopy(path, copyPath, migrationPath string) (*sqlx.DB, error) {
+	if err := backup.Check(path); err != nil {
+		return nil, err
+	}
+	source, err := sqlx.Connect("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", path, err)
+	}
+	_, err = source.Exec("VACUUM INTO ?", copyPath)
+	if closeErr := source.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error copying %s: %w", path, err)
+	}
+
+	copied, err := sqlx.Connect("sqlite3", copyPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening copy of %s: %w", path, err)
+	}
+	m, err := newMigrate(copied, migrationPath)
+	if err == nil {
+		if err = m.Up(); errors.Is(err, migrate.ErrNoChange) {
+			err = nil
+		}
+	}
+	if err != nil {
+		_ = copied.Close()
+		return nil, fmt.Errorf("error migrating copy of %s: %w", path, err)
+	}
+	return copied, nil
+}
+
+// newMigrate returns a migration instance applying the migrations at migrationPath to db
+func newMigrate(db *sqlx.DB, migrationPath string) (*migrate.Migrate, error) {
+	driver, err := sqlite.WithInstance(db.DB, &sqlite.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("error creating database driver: %w", err)
+	}
+	m, err := migrate.NewWithDatabaseInstance(migrationPath, "sqlite3", driver)
+	if err != nil {
+		return nil, fmt.Errorf("error creating migration instance: %w", err)
+	}
+	return m, nil
+}
+
+// backupBeforeMigration snapshots the database when it has a schema and migrations are pending
+func backupBeforeMigration(db *sqlx.DB, m *migrate.Migrate, migrationPath string, backups backup.Rotation) error {
+	version, _, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading schema version: %w", err)
+	}
+
+	migrations, err := source.Open(migrationPath)
+	if err != nil {
+		return fmt.Errorf("error reading migrations: %w", err)
+	}
+	defer migrations.Close()
+	if _, err = migrations.Next(version); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if _, err = backups.Take(db, backup.ReasonMigration); err != nil {
+		return fmt.Errorf("error backing up database before migration: %w", err)
+	}
+	return nil
+}
+
 // CloseDB closes the database connection
 func CloseDB() {
 	if db != nil {
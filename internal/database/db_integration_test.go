@@ -7,8 +7,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/database/backup"
+	"yubigo-pass/test"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCreateDBAndThenCloseConnection(t *testing.T) {
@@ -23,7 +28,7 @@ func TestCreateDBAndThenCloseConnection(t *testing.T) {
 	migrationsDir := filepath.Join("file://", cwd, "../../assets/migrations")
 
 	// when
-	db, err := CreateDB(tempDBFilePath, migrationsDir)
+	db, err := CreateDB(tempDBFilePath, migrationsDir, backup.Rotation{})
 
 	// then
 	assert.Nil(t, err)
@@ -47,7 +52,7 @@ func TestCreateDBShouldFailCreatingDirectory(t *testing.T) {
 	expectedError := fmt.Errorf("error creating database instance: unable to open database file: is a directory")
 
 	// when
-	db, err := CreateDB(incorrectPath, "")
+	db, err := CreateDB(incorrectPath, "", backup.Rotation{})
 
 	// then
 	assert.EqualError(t, err, expectedError.Error())
@@ -63,9 +68,183 @@ func TestCreateDBShouldFailMigration(t *testing.T) {
 	expectedError := fmt.Errorf("error creating migration instance: URL cannot be empty")
 
 	// when
-	db, err := CreateDB(tempDBFilePath, "")
+	db, err := CreateDB(tempDBFilePath, "", backup.Rotation{})
 
 	// then
 	assert.EqualError(t, err, expectedError.Error())
 	assert.Nil(t, db)
 }
+
+func TestCreateDBShouldBackUpBeforePendingMigrations(t *testing.T) {
+	// given
+	tempDir := t.TempDir()
+	tempDBFilePath := filepath.Join(tempDir, "test.db")
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	allMigrations := filepath.Join(cwd, "../../assets/migrations")
+	firstMigrations := t.TempDir()
+	for _, name := range []string{"1_users_table.up.sql", "1_users_table.down.sql"} {
+		content, err := os.ReadFile(filepath.Join(allMigrations, name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(firstMigrations, name), content, 0o600))
+	}
+	backups := backup.Rotation{Dir: backup.DirFor(tempDBFilePath), Keep: 3}
+
+	_, err = CreateDB(tempDBFilePath, "file://"+firstMigrations, backups)
+	require.NoError(t, err)
+	CloseDB()
+
+	// when
+	db, err := CreateDB(tempDBFilePath, "file://"+allMigrations, backups)
+	require.NoError(t, err)
+	CloseDB()
+	_, err = CreateDB(tempDBFilePath, "file://"+allMigrations, backups)
+	require.NoError(t, err)
+	defer CloseDB()
+
+	// then
+	assert.NotNil(t, db)
+	snapshots, err := backups.List()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, backup.ReasonMigration, snapshots[0].Reason)
+	assert.NoError(t, backup.Check(snapshots[0].Path))
+}
+
+func TestOpenCopyShouldMigrateCopyAndLeaveFileUntouched(t *testing.T) {
+	// given
+	tempDir := t.TempDir()
+	otherPath := filepath.Join(tempDir, "other.db")
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	allMigrations := filepath.Join(cwd, "../../assets/migrations")
+	firstMigrations := t.TempDir()
+	for _, name := range []string{"1_users_table.up.sql", "1_users_table.down.sql"} {
+		content, err := os.ReadFile(filepath.Join(allMigrations, name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(firstMigrations, name), content, 0o600))
+	}
+	other, err := CreateDB(otherPath, "file://"+firstMigrations, backup.Rotation{})
+	require.NoError(t, err)
+	_, err = other.Exec("INSERT INTO users (id, username, password, salt) VALUES ('1', 'alice', 'hash', 'salt')")
+	require.NoError(t, err)
+	CloseDB()
+
+	// when
+	copied, err := OpenCopy(otherPath, filepath.Join(tempDir, "copy.db"), "file://"+allMigrations)
+
+	// then
+	require.NoError(t, err)
+	defer copied.Close()
+	var username string
+	require.NoError(t, copied.Get(&username, "SELECT username FROM users"))
+	assert.Equal(t, "alice", username)
+	var count int
+	require.NoError(t, copied.Get(&count, "SELECT COUNT(*) FROM passwords"))
+
+	// and the file itself stays at its schema
+	original, err := sqlx.Connect("sqlite3", otherPath)
+	require.NoError(t, err)
+	defer original.Close()
+	var version int
+	require.NoError(t, original.Get(&version, "SELECT version FROM schema_migrations"))
+	assert.Equal(t, 1, version)
+}
+
+func TestOpenCopyShouldRejectOtherFiles(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("not a database at all, just some text that is long enough"), 0o600))
+
+	// when
+	db, err := OpenCopy(path, filepath.Join(t.TempDir(), "copy.db"), "")
+
+	// then
+	assert.ErrorIs(t, err, backup.ErrNotDatabase)
+	assert.Nil(t, db)
+}
+
+func TestDowngradingTypedItemsShouldFailInsteadOfDeletingThem(t *testing.T) {
+	// given
+	tempDir := t.TempDir()
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	migrationPath := "file://" + filepath.Join(cwd, "../../assets/migrations")
+	db, err := CreateDB(filepath.Join(tempDir, "test.db"), migrationPath, backup.Rotation{})
+	require.NoError(t, err)
+	defer CloseDB()
+	userID := test.RandomString()
+	test.InsertIntoPasswords(t, db, model.Password{ID: "login", UserID: userID, Title: "mail", Username: "alice"})
+	test.InsertIntoPasswords(t, db, model.Password{ID: "card", UserID: userID, Title: "visa", Type: model.ItemTypeCard, Payload: "sealed"})
+	m, err := newMigrate(db, migrationPath)
+	require.NoError(t, err)
+
+	// when
+	err = m.Migrate(4)
+
+	// then
+	assert.ErrorContains(t, err, "cannot downgrade: the vault holds items other than logins, export and delete them first")
+	var count int
+	require.NoError(t, db.Get(&count, `SELECT COUNT(*) FROM passwords WHERE payload = 'sealed'`))
+	assert.Equal(t, 1, count)
+}
+
+func TestDowngradingLoginsOnlyShouldKeepThem(t *testing.T) {
+	// given
+	tempDir := t.TempDir()
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	migrationPath := "file://" + filepath.Join(cwd, "../../assets/migrations")
+	db, err := CreateDB(filepath.Join(tempDir, "test.db"), migrationPath, backup.Rotation{})
+	require.NoError(t, err)
+	defer CloseDB()
+	test.InsertIntoPasswords(t, db, model.Password{ID: "login", UserID: test.RandomString(), Title: "mail", Username: "alice"})
+	m, err := newMigrate(db, migrationPath)
+	require.NoError(t, err)
+
+	// when
+	err = m.Migrate(4)
+
+	// then
+	require.NoError(t, err)
+	var titles []string
+	require.NoError(t, db.Select(&titles, `SELECT title FROM passwords`))
+	assert.Equal(t, []string{"mail"}, titles)
+}
+
+func TestCollectionsMigrationShouldKeepItemsAndDropReferenceToUsers(t *testing.T) {
+	// given
+	tempDir := t.TempDir()
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	migrationPath := "file://" + filepath.Join(cwd, "../../assets/migrations")
+	db, err := CreateDB(filepath.Join(tempDir, "test.db"), migrationPath, backup.Rotation{})
+	require.NoError(t, err)
+	defer CloseDB()
+	m, err := newMigrate(db, migrationPath)
+	require.NoError(t, err)
+	require.NoError(t, m.Migrate(8))
+	userID := test.RandomString()
+	test.InsertIntoPasswords(t, db, model.Password{ID: "router", UserID: userID, Title: "Router", Username: "admin"})
+	test.InsertIntoFolders(t, db, model.Folder{ID: "home", UserID: userID, Name: "Home"})
+	test.InsertIntoTags(t, db, model.Tag{ID: "wifi", UserID: userID, Name: "wifi"})
+	test.LinkPasswordToFolder(t, db, "router", "home")
+	test.LinkPasswordToTag(t, db, "router", "wifi")
+
+	// when
+	err = m.Migrate(9)
+
+	// then
+	require.NoError(t, err)
+	var links int
+	require.NoError(t, db.Get(&links, `SELECT COUNT(*) FROM passwords p
+		JOIN password_folders pf ON pf.password_id = p.id JOIN folders f ON f.id = pf.folder_id
+		JOIN password_tags pt ON pt.password_id = p.id JOIN tags t ON t.id = pt.tag_id
+		WHERE p.user_id = $1 AND f.user_id = $1 AND t.user_id = $1`, userID))
+	assert.Equal(t, 1, links)
+	for _, table := range []string{"passwords", "folders", "tags"} {
+		var references int
+		require.NoError(t, db.Get(&references, `SELECT COUNT(*) FROM pragma_foreign_key_list($1) WHERE "table" = 'users'`, table))
+		assert.Zero(t, references, "%s still references users", table)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 	"yubigo-pass/internal/app/model"
 
 	"github.com/mattn/go-sqlite3"
@@ -14,8 +15,36 @@ import (
 // Store is a DB gateway
 type Store struct {
 	db *sqlx.DB
+	tx *sqlx.Tx
 }
 
+// conn is the part of the sqlx API shared by connections and transactions
+type conn interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRowx(query string, args ...any) *sqlx.Row
+	Select(dest any, query string, args ...any) error
+	Get(dest any, query string, args ...any) error
+}
+
+// transaction is a conn that commits or rolls back its statements as a whole
+type transaction interface {
+	conn
+	Commit() error
+	Rollback() error
+}
+
+// joinedTransaction runs the statements of a single method in the transaction of
+// WithTransaction, leaving commit and rollback to the enclosing call
+type joinedTransaction struct {
+	*sqlx.Tx
+}
+
+// Commit is a no-op, the enclosing transaction commits
+func (joinedTransaction) Commit() error { return nil }
+
+// Rollback is a no-op, the enclosing transaction rolls back when its function fails
+func (joinedTransaction) Rollback() error { return nil }
+
 // NewStore returns new Store instance
 func NewStore(db *sqlx.DB) Store {
 	return Store{
@@ -23,9 +52,45 @@ func NewStore(db *sqlx.DB) Store {
 	}
 }
 
+// WithTransaction runs fn against a store bound to a single transaction, committing when fn
+// succeeds and rolling everything back when it returns an error. Nested calls join the outer transaction.
+func (s Store) WithTransaction(fn func(store StoreExecutor) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err = fn(Store{db: s.db, tx: tx}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// conn returns the transaction of the store when there is one, the DB otherwise
+func (s Store) conn() conn {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// begin starts a transaction for the statements of a single method
+func (s Store) begin() (transaction, error) {
+	if s.tx != nil {
+		return joinedTransaction{s.tx}, nil
+	}
+	return s.db.Beginx()
+}
+
 // CreateUser adds new user in DB
 func (s Store) CreateUser(input model.User) error {
-	tx, err := s.db.Begin()
+	tx, err := s.begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -51,7 +116,7 @@ func (s Store) GetUser(username string) (model.User, error) {
 	query := `SELECT * FROM users where username = $1`
 
 	var user model.User
-	err := s.db.QueryRowx(query, username).StructScan(&user)
+	err := s.conn().QueryRowx(query, username).StructScan(&user)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return model.User{}, model.NewUserNotFoundError(username)
@@ -64,19 +129,24 @@ func (s Store) GetUser(username string) (model.User, error) {
 
 // AddPassword adds a new password in DB
 func (s Store) AddPassword(input model.Password) error {
-	tx, err := s.db.Begin()
+	tx, err := s.begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	query := `INSERT INTO passwords (user_id, title, username, password, url, nonce) VALUES ($1, $2, $3, $4, $5, $6)`
+	query := `INSERT INTO passwords (id, user_id, title, username, password, url, nonce, notes, type, payload, otp, otp_counter, modified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
 
-	_, err = tx.Exec(query, input.UserID, input.Title, input.Username, input.Password, input.Url, input.Nonce)
+	_, err = tx.Exec(query, input.ID, input.UserID, input.Title, input.Username, input.Password, input.Url, input.Nonce,
+		input.Notes, input.ItemType(), input.Payload, input.OTP, input.OTPCounter, input.ModifiedAt)
 	if err != nil {
 		_ = tx.Rollback()
 		var sqliteErr sqlite3.Error
 		if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintPrimaryKey) {
 			return model.NewPasswordAlreadyExistsError(input.UserID, input.Title, input.Username)
 		}
+		if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintUnique) {
+			return model.NewPasswordIDTakenError(input.ID)
+		}
 		return fmt.Errorf("failed to create password: %w", err)
 	}
 
@@ -84,12 +154,75 @@ func (s Store) AddPassword(input model.Password) error {
 	return nil
 }
 
+// UpdatePassword overwrites a password identified by its ID and user ID
+func (s Store) UpdatePassword(input model.Password) error {
+	query := `UPDATE passwords SET title = $1, username = $2, password = $3, url = $4, nonce = $5, notes = $6,
+		type = $7, payload = $8, otp = $9, otp_counter = $10, modified_at = $11 WHERE id = $12 AND user_id = $13`
+
+	result, err := s.conn().Exec(query, input.Title, input.Username, input.Password, input.Url, input.Nonce, input.Notes,
+		input.ItemType(), input.Payload, input.OTP, input.OTPCounter, input.ModifiedAt, input.ID, input.UserID)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintPrimaryKey) {
+			return model.NewPasswordAlreadyExistsError(input.UserID, input.Title, input.Username)
+		}
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if affected == 0 {
+		return model.NewPasswordNotFoundError(input.UserID, input.Title, input.Username)
+	}
+	return nil
+}
+
+// IncrementOTPCounter advances the HOTP counter of a password in a single transaction, so
+// concurrent callers never get the same value, and marks the password modified.
+// It returns the counter value to generate a code with.
+func (s Store) IncrementOTPCounter(passwordID string) (uint64, error) {
+	tx, err := s.begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	result, err := tx.Exec(`UPDATE passwords SET otp_counter = otp_counter + 1, modified_at = $1 WHERE id = $2`,
+		time.Now().UnixMilli(), passwordID)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("failed to increment otp counter: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("failed to increment otp counter: %w", err)
+	}
+	if affected == 0 {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("failed to increment otp counter: password %s not found", passwordID)
+	}
+
+	var counter uint64
+	err = tx.Get(&counter, `SELECT otp_counter FROM passwords WHERE id = $1`, passwordID)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("failed to increment otp counter: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to increment otp counter: %w", err)
+	}
+	return counter - 1, nil
+}
+
 // GetPassword fetches a password by userID, title and username from DB
 func (s Store) GetPassword(userID, title, username string) (model.Password, error) {
 	query := `SELECT * FROM passwords WHERE user_id = $1 AND title = $2 AND username = $3`
 
 	var password model.Password
-	err := s.db.QueryRowx(query, userID, title, username).StructScan(&password)
+	err := s.conn().QueryRowx(query, userID, title, username).StructScan(&password)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return model.Password{}, model.NewPasswordNotFoundError(userID, title, username)
@@ -104,9 +237,624 @@ func (s Store) GetAllUserPasswords(username string) ([]model.Password, error) {
 	query := `SELECT * FROM passwords WHERE user_id = $1`
 
 	var passwords []model.Password
-	err := s.db.Select(&passwords, query, username)
+	err := s.conn().Select(&passwords, query, username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get passwords: %w", err)
 	}
 	return passwords, nil
 }
+
+// CreateFolder adds a new folder in DB
+func (s Store) CreateFolder(input model.Folder) error {
+	query := `INSERT INTO folders (id, user_id, parent_id, name) VALUES ($1, $2, $3, $4)`
+
+	_, err := s.conn().Exec(query, input.ID, input.UserID, input.ParentID, input.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create folder: %w", err)
+	}
+	return nil
+}
+
+// GetAllUserFolders fetches all folders for a user
+func (s Store) GetAllUserFolders(userID string) ([]model.Folder, error) {
+	query := `SELECT * FROM folders WHERE user_id = $1`
+
+	var folders []model.Folder
+	err := s.conn().Select(&folders, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folders: %w", err)
+	}
+	return folders, nil
+}
+
+// CreateTag adds a new tag in DB
+func (s Store) CreateTag(input model.Tag) error {
+	query := `INSERT INTO tags (id, user_id, name) VALUES ($1, $2, $3)`
+
+	_, err := s.conn().Exec(query, input.ID, input.UserID, input.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create tag: %w", err)
+	}
+	return nil
+}
+
+// GetAllUserTags fetches all tags for a user
+func (s Store) GetAllUserTags(userID string) ([]model.Tag, error) {
+	query := `SELECT * FROM tags WHERE user_id = $1`
+
+	var tags []model.Tag
+	err := s.conn().Select(&tags, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	return tags, nil
+}
+
+// AddPasswordToFolder links a password with a folder, linking the same pair twice is a no-op
+func (s Store) AddPasswordToFolder(passwordID, folderID string) error {
+	query := `INSERT OR IGNORE INTO password_folders (password_id, folder_id) VALUES ($1, $2)`
+
+	_, err := s.conn().Exec(query, passwordID, folderID)
+	if err != nil {
+		return fmt.Errorf("failed to add password to folder: %w", err)
+	}
+	return nil
+}
+
+// AddTagToPassword links a password with a tag, linking the same pair twice is a no-op
+func (s Store) AddTagToPassword(passwordID, tagID string) error {
+	query := `INSERT OR IGNORE INTO password_tags (password_id, tag_id) VALUES ($1, $2)`
+
+	_, err := s.conn().Exec(query, passwordID, tagID)
+	if err != nil {
+		return fmt.Errorf("failed to add tag to password: %w", err)
+	}
+	return nil
+}
+
+// GetPasswordsByFolder fetches all user passwords filed in a folder
+func (s Store) GetPasswordsByFolder(userID, folderID string) ([]model.Password, error) {
+	query := `SELECT p.* FROM passwords p
+		JOIN password_folders pf ON pf.password_id = p.id
+		WHERE p.user_id = $1 AND pf.folder_id = $2`
+
+	var passwords []model.Password
+	err := s.conn().Select(&passwords, query, userID, folderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passwords by folder: %w", err)
+	}
+	return passwords, nil
+}
+
+// GetPasswordsByTag fetches all user passwords labelled with a tag
+func (s Store) GetPasswordsByTag(userID, tagID string) ([]model.Password, error) {
+	query := `SELECT p.* FROM passwords p
+		JOIN password_tags pt ON pt.password_id = p.id
+		WHERE p.user_id = $1 AND pt.tag_id = $2`
+
+	var passwords []model.Password
+	err := s.conn().Select(&passwords, query, userID, tagID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passwords by tag: %w", err)
+	}
+	return passwords, nil
+}
+
+// GetPasswordFolders fetches all folders a password is filed in
+func (s Store) GetPasswordFolders(passwordID string) ([]model.Folder, error) {
+	query := `SELECT f.* FROM folders f
+		JOIN password_folders pf ON pf.folder_id = f.id
+		WHERE pf.password_id = $1`
+
+	var folders []model.Folder
+	err := s.conn().Select(&folders, query, passwordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password folders: %w", err)
+	}
+	return folders, nil
+}
+
+// GetPasswordTags fetches all tags of a password
+func (s Store) GetPasswordTags(passwordID string) ([]model.Tag, error) {
+	query := `SELECT t.* FROM tags t
+		JOIN password_tags pt ON pt.tag_id = t.id
+		WHERE pt.password_id = $1`
+
+	var tags []model.Tag
+	err := s.conn().Select(&tags, query, passwordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password tags: %w", err)
+	}
+	return tags, nil
+}
+
+// ClearPasswordPlacement removes a password from all its folders and tags
+func (s Store) ClearPasswordPlacement(passwordID string) error {
+	tx, err := s.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM password_folders WHERE password_id = $1`, passwordID)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to remove password from folders: %w", err)
+	}
+	_, err = tx.Exec(`DELETE FROM password_tags WHERE password_id = $1`, passwordID)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to remove password tags: %w", err)
+	}
+
+	_ = tx.Commit()
+	return nil
+}
+
+// SetPasswordFields replaces all custom fields of a password
+func (s Store) SetPasswordFields(passwordID string, fields []model.CustomField) error {
+	tx, err := s.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM password_fields WHERE password_id = $1`, passwordID)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to delete password fields: %w", err)
+	}
+
+	query := `INSERT INTO password_fields (password_id, position, type, name, value) VALUES ($1, $2, $3, $4, $5)`
+	for i, field := range fields {
+		_, err = tx.Exec(query, passwordID, i, field.Type, field.Name, field.Value)
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("failed to create password field: %w", err)
+		}
+	}
+
+	_ = tx.Commit()
+	return nil
+}
+
+// GetPasswordFields fetches all custom fields of a password ordered by position
+func (s Store) GetPasswordFields(passwordID string) ([]model.CustomField, error) {
+	query := `SELECT * FROM password_fields WHERE password_id = $1 ORDER BY position`
+
+	var fields []model.CustomField
+	err := s.conn().Select(&fields, query, passwordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password fields: %w", err)
+	}
+	return fields, nil
+}
+
+// AddAttachment adds a new attachment of a password in DB
+func (s Store) AddAttachment(input model.Attachment) error {
+	query := `INSERT INTO attachments (id, password_id, name, size, blob) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := s.conn().Exec(query, input.ID, input.PasswordID, input.Name, input.Size, input.Blob)
+	if err != nil {
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+	return nil
+}
+
+// GetPasswordAttachments fetches all attachments of a password in the order they were added
+func (s Store) GetPasswordAttachments(passwordID string) ([]model.Attachment, error) {
+	query := `SELECT id, password_id, name, size, blob FROM attachments WHERE password_id = $1 ORDER BY rowid`
+
+	var attachments []model.Attachment
+	err := s.conn().Select(&attachments, query, passwordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// DeleteAttachment removes an attachment from DB
+func (s Store) DeleteAttachment(id string) error {
+	result, err := s.conn().Exec(`DELETE FROM attachments WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	if affected == 0 {
+		return model.NewAttachmentNotFoundError(id)
+	}
+	return nil
+}
+
+// SetPasswordOwner hands a password over to another owner, a user or a collection
+func (s Store) SetPasswordOwner(input model.Password, ownerID string) error {
+	result, err := s.conn().Exec(`UPDATE passwords SET user_id = $1 WHERE id = $2 AND user_id = $3`, ownerID, input.ID, input.UserID)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintPrimaryKey) {
+			return model.NewPasswordAlreadyExistsError(ownerID, input.Title, input.Username)
+		}
+		return fmt.Errorf("failed to move password: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to move password: %w", err)
+	}
+	if affected == 0 {
+		return model.NewPasswordNotFoundError(input.UserID, input.Title, input.Username)
+	}
+	return nil
+}
+
+// CreateUserKey adds the key pair of a user in DB
+func (s Store) CreateUserKey(input model.UserKey) error {
+	query := `INSERT INTO user_keys (user_id, public_key, private_key) VALUES ($1, $2, $3)`
+
+	_, err := s.conn().Exec(query, input.UserID, input.PublicKey, input.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to create user key: %w", err)
+	}
+	return nil
+}
+
+// GetUserKey fetches the key pair of a user from DB
+func (s Store) GetUserKey(userID string) (model.UserKey, error) {
+	query := `SELECT * FROM user_keys WHERE user_id = $1`
+
+	var key model.UserKey
+	err := s.conn().QueryRowx(query, userID).StructScan(&key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.UserKey{}, model.NewUserKeyNotFoundError(userID)
+		}
+		return model.UserKey{}, fmt.Errorf("failed to get user key: %w", err)
+	}
+	return key, nil
+}
+
+// CreateCollection adds a new collection in DB
+func (s Store) CreateCollection(input model.Collection) error {
+	query := `INSERT INTO collections (id, name) VALUES ($1, $2)`
+
+	_, err := s.conn().Exec(query, input.ID, input.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+	return nil
+}
+
+// AddCollectionMember adds a user to a collection with the collection key sealed to them
+func (s Store) AddCollectionMember(input model.CollectionMember) error {
+	query := `INSERT INTO collection_members (collection_id, user_id, role, sealed_key) VALUES ($1, $2, $3, $4)`
+
+	_, err := s.conn().Exec(query, input.CollectionID, input.UserID, input.Role, input.SealedKey)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintPrimaryKey) {
+			return model.NewMemberAlreadyExistsError(input.CollectionID, input.UserID)
+		}
+		return fmt.Errorf("failed to add collection member: %w", err)
+	}
+	return nil
+}
+
+// GetUserCollections fetches the memberships of a user along with the names of their collections
+func (s Store) GetUserCollections(userID string) ([]model.CollectionMember, error) {
+	query := `SELECT m.collection_id, m.user_id, m.role, m.sealed_key, u.username, c.name FROM collection_members m
+		JOIN collections c ON c.id = m.collection_id
+		JOIN users u ON u.id = m.user_id
+		WHERE m.user_id = $1`
+
+	var members []model.CollectionMember
+	err := s.conn().Select(&members, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user collections: %w", err)
+	}
+	return members, nil
+}
+
+// GetCollectionMembers fetches all members of a collection along with their usernames, ordered by username
+func (s Store) GetCollectionMembers(collectionID string) ([]model.CollectionMember, error) {
+	query := `SELECT m.collection_id, m.user_id, m.role, m.sealed_key, u.username, c.name FROM collection_members m
+		JOIN collections c ON c.id = m.collection_id
+		JOIN users u ON u.id = m.user_id
+		WHERE m.collection_id = $1
+		ORDER BY u.username`
+
+	var members []model.CollectionMember
+	err := s.conn().Select(&members, query, collectionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection members: %w", err)
+	}
+	return members, nil
+}
+
+// emergencyAccessQuery selects emergency access along with the usernames of the owner and the grantee
+const emergencyAccessQuery = `SELECT e.owner_id, e.grantee_id, e.status, e.wait_days, e.requested_at, e.sealed_key,
+		o.username AS owner_name, g.username AS grantee_name FROM emergency_access e
+		JOIN users o ON o.id = e.owner_id
+		JOIN users g ON g.id = e.grantee_id`
+
+// CreateEmergencyAccess adds the emergency access a user gives another one to their vault in DB
+func (s Store) CreateEmergencyAccess(input model.EmergencyAccess) error {
+	query := `INSERT INTO emergency_access (owner_id, grantee_id, status, wait_days, requested_at, sealed_key)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := s.conn().Exec(query, input.OwnerID, input.GranteeID, input.Status, input.WaitDays, input.RequestedAt, input.SealedKey)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && errors.Is(sqliteErr.ExtendedCode, sqlite3.ErrConstraintPrimaryKey) {
+			return model.NewEmergencyAccessAlreadyExistsError(input.OwnerID, input.GranteeID)
+		}
+		return fmt.Errorf("failed to create emergency access: %w", err)
+	}
+	return nil
+}
+
+// GetEmergencyAccess fetches the emergency access a user gave another one from DB
+func (s Store) GetEmergencyAccess(ownerID, granteeID string) (model.EmergencyAccess, error) {
+	query := emergencyAccessQuery + ` WHERE e.owner_id = $1 AND e.grantee_id = $2`
+
+	var access model.EmergencyAccess
+	err := s.conn().QueryRowx(query, ownerID, granteeID).StructScan(&access)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.EmergencyAccess{}, model.NewEmergencyAccessNotFoundError(ownerID, granteeID)
+		}
+		return model.EmergencyAccess{}, fmt.Errorf("failed to get emergency access: %w", err)
+	}
+	return access, nil
+}
+
+// UpdateEmergencyAccess stores the status, the time of the last request and the released key of
+// emergency access in DB
+func (s Store) UpdateEmergencyAccess(input model.EmergencyAccess) error {
+	query := `UPDATE emergency_access SET status = $1, requested_at = $2, sealed_key = $3 WHERE owner_id = $4 AND grantee_id = $5`
+
+	result, err := s.conn().Exec(query, input.Status, input.RequestedAt, input.SealedKey, input.OwnerID, input.GranteeID)
+	if err != nil {
+		return fmt.Errorf("failed to update emergency access: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update emergency access: %w", err)
+	}
+	if affected == 0 {
+		return model.NewEmergencyAccessNotFoundError(input.OwnerID, input.GranteeID)
+	}
+	return nil
+}
+
+// DeleteEmergencyAccess removes the emergency access a user gave another one from DB
+func (s Store) DeleteEmergencyAccess(ownerID, granteeID string) error {
+	result, err := s.conn().Exec(`DELETE FROM emergency_access WHERE owner_id = $1 AND grantee_id = $2`, ownerID, granteeID)
+	if err != nil {
+		return fmt.Errorf("failed to delete emergency access: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete emergency access: %w", err)
+	}
+	if affected == 0 {
+		return model.NewEmergencyAccessNotFoundError(ownerID, granteeID)
+	}
+	return nil
+}
+
+// GetEmergencyContacts fetches the emergency access a user gave others to their vault, ordered by grantee username
+func (s Store) GetEmergencyContacts(ownerID string) ([]model.EmergencyAccess, error) {
+	query := emergencyAccessQuery + ` WHERE e.owner_id = $1 ORDER BY g.username`
+
+	var contacts []model.EmergencyAccess
+	err := s.conn().Select(&contacts, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emergency contacts: %w", err)
+	}
+	return contacts, nil
+}
+
+// GetEmergencyGrants fetches the emergency access others gave a user to their vaults, ordered by owner username
+func (s Store) GetEmergencyGrants(granteeID string) ([]model.EmergencyAccess, error) {
+	query := emergencyAccessQuery + ` WHERE e.grantee_id = $1 ORDER BY o.username`
+
+	var grants []model.EmergencyAccess
+	err := s.conn().Select(&grants, query, granteeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emergency grants: %w", err)
+	}
+	return grants, nil
+}
+
+// auditLogLengthQuery reads how many records were ever appended to the audit log. SQLite keeps the
+// last sequence number it handed out apart from the records, so it tells records cut off the end.
+const auditLogLengthQuery = `SELECT seq FROM sqlite_sequence WHERE name = 'audit_log'`
+
+// AppendAuditRecord chains a record to the last one of the audit log and appends it in DB, returning
+// it with its sequence number and hashes. hash computes the hash of the record once it is chained.
+func (s Store) AppendAuditRecord(input model.AuditRecord, hash func(model.AuditRecord) string) (model.AuditRecord, error) {
+	tx, err := s.begin()
+	if err != nil {
+		return model.AuditRecord{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	input.PrevHash = ""
+	err = tx.Get(&input.PrevHash, `SELECT hash FROM audit_log ORDER BY seq DESC LIMIT 1`)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		_ = tx.Rollback()
+		return model.AuditRecord{}, fmt.Errorf("failed to append audit record: %w", err)
+	}
+	input.Seq = 0
+	err = tx.Get(&input.Seq, auditLogLengthQuery)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		_ = tx.Rollback()
+		return model.AuditRecord{}, fmt.Errorf("failed to append audit record: %w", err)
+	}
+	input.Seq++
+	input.Hash = hash(input)
+
+	query := `INSERT INTO audit_log (seq, user_id, created_at, data, prev_hash, hash) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err = tx.Exec(query, input.Seq, input.UserID, input.CreatedAt, input.Data, input.PrevHash, input.Hash)
+	if err != nil {
+		_ = tx.Rollback()
+		return model.AuditRecord{}, fmt.Errorf("failed to append audit record: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return model.AuditRecord{}, fmt.Errorf("failed to append audit record: %w", err)
+	}
+	return input, nil
+}
+
+// GetAuditLogLength fetches from DB how many records were ever appended to the audit log
+func (s Store) GetAuditLogLength() (int64, error) {
+	var length int64
+	err := s.conn().Get(&length, auditLogLengthQuery)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to get audit log length: %w", err)
+	}
+	return length, nil
+}
+
+// GetAuditLog fetches every record of the audit log from DB in the order they were appended
+func (s Store) GetAuditLog() ([]model.AuditRecord, error) {
+	var records []model.AuditRecord
+	err := s.conn().Select(&records, `SELECT seq, user_id, created_at, data, prev_hash, hash FROM audit_log ORDER BY seq`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	return records, nil
+}
+
+// GetUserAuditLog fetches the audit records of a vault from DB in the order they were appended
+func (s Store) GetUserAuditLog(userID string) ([]model.AuditRecord, error) {
+	query := `SELECT seq, user_id, created_at, data, prev_hash, hash FROM audit_log WHERE user_id = $1 ORDER BY seq`
+
+	var records []model.AuditRecord
+	err := s.conn().Select(&records, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	return records, nil
+}
+
+// GetLoginAttempts fetches the failed logins under a username from DB, none when it has no failures
+func (s Store) GetLoginAttempts(usernameHash string) (model.LoginAttempts, error) {
+	query := `SELECT username_hash, failures, last_failure FROM login_attempts WHERE username_hash = $1`
+
+	attempts := model.LoginAttempts{UsernameHash: usernameHash}
+	err := s.conn().Get(&attempts, query, usernameHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return model.LoginAttempts{}, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// SaveLoginAttempts stores the failed logins under a username in DB, replacing the previous count
+func (s Store) SaveLoginAttempts(input model.LoginAttempts) error {
+	query := `INSERT INTO login_attempts (username_hash, failures, last_failure) VALUES ($1, $2, $3)
+		ON CONFLICT (username_hash) DO UPDATE SET failures = excluded.failures, last_failure = excluded.last_failure`
+
+	_, err := s.conn().Exec(query, input.UsernameHash, input.Failures, input.LastFailure)
+	if err != nil {
+		return fmt.Errorf("failed to save login attempts: %w", err)
+	}
+	return nil
+}
+
+// DeleteLoginAttempts clears the failed logins under a username from DB
+func (s Store) DeleteLoginAttempts(usernameHash string) error {
+	_, err := s.conn().Exec(`DELETE FROM login_attempts WHERE username_hash = $1`, usernameHash)
+	if err != nil {
+		return fmt.Errorf("failed to delete login attempts: %w", err)
+	}
+	return nil
+}
+
+// generatorProfileColumns lists the columns of generator_profiles in the order they are inserted
+const generatorProfileColumns = `user_id, name, active, length, lower, upper, digits, symbols,
+	min_lower, min_upper, min_digits, min_symbols, symbol_set, exclude_ambiguous, no_repeat,
+	mode, words, wordlist, separator, capitalization, append_digit, append_symbol`
+
+// GetGeneratorProfiles fetches the password generator profiles of a user from DB, ordered by name
+func (s Store) GetGeneratorProfiles(userID string) ([]model.GeneratorProfile, error) {
+	query := `SELECT ` + generatorProfileColumns + ` FROM generator_profiles WHERE user_id = $1 ORDER BY name`
+
+	var profiles []model.GeneratorProfile
+	err := s.conn().Select(&profiles, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get generator profiles: %w", err)
+	}
+	return profiles, nil
+}
+
+// SaveGeneratorProfile stores a password generator profile in DB, replacing the policy of a profile
+// of the same name while keeping whether it is active
+func (s Store) SaveGeneratorProfile(input model.GeneratorProfile) error {
+	query := `INSERT INTO generator_profiles (` + generatorProfileColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		ON CONFLICT (user_id, name) DO UPDATE SET length = excluded.length, lower = excluded.lower,
+			upper = excluded.upper, digits = excluded.digits, symbols = excluded.symbols,
+			min_lower = excluded.min_lower, min_upper = excluded.min_upper, min_digits = excluded.min_digits,
+			min_symbols = excluded.min_symbols, symbol_set = excluded.symbol_set,
+			exclude_ambiguous = excluded.exclude_ambiguous, no_repeat = excluded.no_repeat,
+			mode = excluded.mode, words = excluded.words, wordlist = excluded.wordlist,
+			separator = excluded.separator, capitalization = excluded.capitalization,
+			append_digit = excluded.append_digit, append_symbol = excluded.append_symbol`
+
+	_, err := s.conn().Exec(query, input.UserID, input.Name, input.Active, input.Length, input.Lower, input.Upper,
+		input.Digits, input.Symbols, input.MinLower, input.MinUpper, input.MinDigits, input.MinSymbols,
+		input.SymbolSet, input.ExcludeAmbiguous, input.NoRepeat, input.Mode, input.Words, input.Wordlist,
+		input.Separator, input.Capitalization, input.AppendDigit, input.AppendSymbol)
+	if err != nil {
+		return fmt.Errorf("failed to save generator profile: %w", err)
+	}
+	return nil
+}
+
+// ActivateGeneratorProfile makes the password generator profile of a user named name the only active one in DB
+func (s Store) ActivateGeneratorProfile(userID, name string) error {
+	tx, err := s.begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var found bool
+	err = tx.QueryRowx(`SELECT EXISTS (SELECT 1 FROM generator_profiles WHERE user_id = $1 AND name = $2)`, userID, name).Scan(&found)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to activate generator profile: %w", err)
+	}
+	if !found {
+		_ = tx.Rollback()
+		return model.NewGeneratorProfileNotFoundError(userID, name)
+	}
+
+	_, err = tx.Exec(`UPDATE generator_profiles SET active = (name = $1) WHERE user_id = $2`, name, userID)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to activate generator profile: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to activate generator profile: %w", err)
+	}
+	return nil
+}
+
+// DeleteGeneratorProfile removes the password generator profile of a user named name from DB
+func (s Store) DeleteGeneratorProfile(userID, name string) error {
+	result, err := s.conn().Exec(`DELETE FROM generator_profiles WHERE user_id = $1 AND name = $2`, userID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete generator profile: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete generator profile: %w", err)
+	}
+	if affected == 0 {
+		return model.NewGeneratorProfileNotFoundError(userID, name)
+	}
+	return nil
+}
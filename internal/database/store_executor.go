@@ -9,6 +9,53 @@ type StoreExecutor interface {
 	CreateUser(input model.User) error
 	GetUser(username string) (model.User, error)
 	AddPassword(password model.Password) error
+	UpdatePassword(password model.Password) error
+	IncrementOTPCounter(passwordID string) (uint64, error)
 	GetPassword(userID, title, username string) (model.Password, error)
 	GetAllUserPasswords(userID string) ([]model.Password, error)
+	CreateFolder(folder model.Folder) error
+	GetAllUserFolders(userID string) ([]model.Folder, error)
+	CreateTag(tag model.Tag) error
+	GetAllUserTags(userID string) ([]model.Tag, error)
+	AddPasswordToFolder(passwordID, folderID string) error
+	AddTagToPassword(passwordID, tagID string) error
+	GetPasswordsByFolder(userID, folderID string) ([]model.Password, error)
+	GetPasswordsByTag(userID, tagID string) ([]model.Password, error)
+	GetPasswordFolders(passwordID string) ([]model.Folder, error)
+	GetPasswordTags(passwordID string) ([]model.Tag, error)
+	ClearPasswordPlacement(passwordID string) error
+	SetPasswordFields(passwordID string, fields []model.CustomField) error
+	GetPasswordFields(passwordID string) ([]model.CustomField, error)
+	AddAttachment(attachment model.Attachment) error
+	GetPasswordAttachments(passwordID string) ([]model.Attachment, error)
+	DeleteAttachment(id string) error
+	SetPasswordOwner(password model.Password, ownerID string) error
+	CreateUserKey(key model.UserKey) error
+	GetUserKey(userID string) (model.UserKey, error)
+	CreateCollection(collection model.Collection) error
+	AddCollectionMember(member model.CollectionMember) error
+	GetUserCollections(userID string) ([]model.CollectionMember, error)
+	GetCollectionMembers(collectionID string) ([]model.CollectionMember, error)
+	CreateEmergencyAccess(access model.EmergencyAccess) error
+	GetEmergencyAccess(ownerID, granteeID string) (model.EmergencyAccess, error)
+	UpdateEmergencyAccess(access model.EmergencyAccess) error
+	DeleteEmergencyAccess(ownerID, granteeID string) error
+	GetEmergencyContacts(ownerID string) ([]model.EmergencyAccess, error)
+	GetEmergencyGrants(granteeID string) ([]model.EmergencyAccess, error)
+	AppendAuditRecord(record model.AuditRecord, hash func(model.AuditRecord) string) (model.AuditRecord, error)
+	GetAuditLog() ([]model.AuditRecord, error)
+	GetAuditLogLength() (int64, error)
+	GetUserAuditLog(userID string) ([]model.AuditRecord, error)
+	GetLoginAttempts(usernameHash string) (model.LoginAttempts, error)
+	SaveLoginAttempts(attempts model.LoginAttempts) error
+	DeleteLoginAttempts(usernameHash string) error
+	GetGeneratorProfiles(userID string) ([]model.GeneratorProfile, error)
+	SaveGeneratorProfile(profile model.GeneratorProfile) error
+	ActivateGeneratorProfile(userID, name string) error
+	DeleteGeneratorProfile(userID, name string) error
+}
+
+// Transactor is implemented by stores able to run several operations in a single DB transaction
+type Transactor interface {
+	WithTransaction(fn func(store StoreExecutor) error) error
 }
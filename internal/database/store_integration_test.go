@@ -3,6 +3,7 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
@@ -126,12 +127,14 @@ func TestShouldCreatePasswordInDB(t *testing.T) {
 
 	// given
 	input := model.Password{
+		ID:       test.RandomString(),
 		UserID:   test.RandomString(),
 		Title:    test.RandomString(),
 		Username: test.RandomString(),
 		Password: test.RandomString(),
 		Url:      test.RandomString(),
 		Nonce:    []byte(test.RandomString()),
+		Type:     model.ItemTypeLogin,
 	}
 
 	// when
@@ -154,14 +157,17 @@ func TestShouldNotCreatePasswordIfOneWithTheSameIsAlreadyInDB(t *testing.T) {
 
 	// given
 	input := model.Password{
+		ID:       test.RandomString(),
 		UserID:   test.RandomString(),
 		Title:    test.RandomString(),
 		Username: test.RandomString(),
 		Password: test.RandomString(),
 		Url:      test.RandomString(),
 		Nonce:    []byte(test.RandomString()),
+		Type:     model.ItemTypeLogin,
 	}
 	test.InsertIntoPasswords(t, db, input)
+	input.ID = test.RandomString()
 
 	// expected
 	expectedError := model.NewPasswordAlreadyExistsError(input.UserID, input.Title, input.Username)
@@ -173,6 +179,30 @@ func TestShouldNotCreatePasswordIfOneWithTheSameIsAlreadyInDB(t *testing.T) {
 	assert.EqualError(t, err, expectedError.Error())
 }
 
+func TestShouldNotCreatePasswordWithIDTakenByPasswordOfOtherUser(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	taken := model.NewPassword(test.RandomString(), test.RandomString(), test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	test.InsertIntoPasswords(t, db, taken)
+	input := model.NewPassword(taken.ID, test.RandomString(), test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+
+	// expected
+	expectedError := model.NewPasswordIDTakenError(taken.ID)
+
+	// when
+	err = store.AddPassword(input)
+
+	// then
+	assert.EqualError(t, err, expectedError.Error())
+}
+
 func TestShouldGetPasswordFromDB(t *testing.T) {
 	// setup
 	db, err := test.SetupTestDB()
@@ -184,12 +214,14 @@ func TestShouldGetPasswordFromDB(t *testing.T) {
 
 	// given
 	input := model.Password{
+		ID:       test.RandomString(),
 		UserID:   test.RandomString(),
 		Title:    test.RandomString(),
 		Username: test.RandomString(),
 		Password: test.RandomString(),
 		Url:      test.RandomString(),
 		Nonce:    []byte(test.RandomString()),
+		Type:     model.ItemTypeLogin,
 	}
 	test.InsertIntoPasswords(t, db, input)
 
@@ -238,22 +270,26 @@ func TestShouldGetAllPasswordsForUserFromDB(t *testing.T) {
 	// given
 	userID := test.RandomString()
 	input1 := model.Password{
+		ID:       test.RandomString(),
 		UserID:   userID,
 		Title:    test.RandomString(),
 		Username: test.RandomString(),
 		Password: test.RandomString(),
 		Url:      test.RandomString(),
 		Nonce:    []byte(test.RandomString()),
+		Type:     model.ItemTypeLogin,
 	}
 	test.InsertIntoPasswords(t, db, input1)
 
 	input2 := model.Password{
+		ID:       test.RandomString(),
 		UserID:   userID,
 		Title:    test.RandomString(),
 		Username: test.RandomString(),
 		Password: test.RandomString(),
 		Url:      test.RandomString(),
 		Nonce:    []byte(test.RandomString()),
+		Type:     model.ItemTypeLogin,
 	}
 	test.InsertIntoPasswords(t, db, input2)
 
@@ -264,3 +300,828 @@ func TestShouldGetAllPasswordsForUserFromDB(t *testing.T) {
 	assert.NoError(t, err)
 	reflect.DeepEqual([]model.Password{input1, input2}, passwords)
 }
+
+func TestShouldCreateFolderAndGetAllUserFolders(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	userID := test.RandomString()
+	parent := model.NewFolder(test.RandomString(), userID, "", test.RandomString())
+	child := model.NewFolder(test.RandomString(), userID, parent.ID, test.RandomString())
+	test.InsertIntoFolders(t, db, model.NewFolder(test.RandomString(), test.RandomString(), "", test.RandomString()))
+
+	// when
+	err = store.CreateFolder(parent)
+	assert.NoError(t, err)
+	err = store.CreateFolder(child)
+	assert.NoError(t, err)
+	folders, err := store.GetAllUserFolders(userID)
+
+	// then
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []model.Folder{parent, child}, folders)
+}
+
+func TestShouldCreateTagAndGetAllUserTags(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	userID := test.RandomString()
+	tag := model.NewTag(test.RandomString(), userID, test.RandomString())
+	test.InsertIntoTags(t, db, model.NewTag(test.RandomString(), test.RandomString(), test.RandomString()))
+
+	// when
+	err = store.CreateTag(tag)
+	assert.NoError(t, err)
+	tags, err := store.GetAllUserTags(userID)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Tag{tag}, tags)
+}
+
+func TestShouldGetPasswordsByFolder(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	userID := test.RandomString()
+	folder := model.NewFolder(test.RandomString(), userID, "", test.RandomString())
+	test.InsertIntoFolders(t, db, folder)
+	filed := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	unfiled := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	test.InsertIntoPasswords(t, db, filed)
+	test.InsertIntoPasswords(t, db, unfiled)
+
+	// when
+	err = store.AddPasswordToFolder(filed.ID, folder.ID)
+	assert.NoError(t, err)
+	err = store.AddPasswordToFolder(filed.ID, folder.ID)
+	assert.NoError(t, err)
+	passwords, err := store.GetPasswordsByFolder(userID, folder.ID)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Password{filed}, passwords)
+	folders, err := store.GetPasswordFolders(filed.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Folder{folder}, folders)
+}
+
+func TestShouldGetPasswordsByTag(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	userID := test.RandomString()
+	tag := model.NewTag(test.RandomString(), userID, test.RandomString())
+	test.InsertIntoTags(t, db, tag)
+	tagged := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	untagged := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	test.InsertIntoPasswords(t, db, tagged)
+	test.InsertIntoPasswords(t, db, untagged)
+
+	// when
+	err = store.AddTagToPassword(tagged.ID, tag.ID)
+	assert.NoError(t, err)
+	passwords, err := store.GetPasswordsByTag(userID, tag.ID)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Password{tagged}, passwords)
+	tags, err := store.GetPasswordTags(tagged.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Tag{tag}, tags)
+}
+
+func TestShouldSetAndGetPasswordFields(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	passwordID := test.RandomString()
+	initial := []model.CustomField{
+		model.NewCustomField(passwordID, 0, model.FieldTypeText, test.RandomString(), test.RandomString()),
+	}
+	replacement := []model.CustomField{
+		model.NewCustomField(passwordID, 0, model.FieldTypeHidden, test.RandomString(), test.RandomString()),
+		model.NewCustomField(passwordID, 1, model.FieldTypeBoolean, test.RandomString(), test.RandomString()),
+	}
+
+	// when
+	err = store.SetPasswordFields(passwordID, initial)
+	assert.NoError(t, err)
+	err = store.SetPasswordFields(passwordID, replacement)
+	assert.NoError(t, err)
+	fields, err := store.GetPasswordFields(passwordID)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, replacement, fields)
+}
+
+func TestShouldStoreTypedItem(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	input := model.Password{
+		ID:       test.RandomString(),
+		UserID:   test.RandomString(),
+		Title:    test.RandomString(),
+		Username: test.RandomString(),
+		Password: test.RandomString(),
+		Nonce:    []byte(test.RandomString()),
+		Type:     model.ItemTypeCard,
+		Payload:  test.RandomString(),
+	}
+
+	// when
+	err = store.AddPassword(input)
+
+	// then
+	assert.NoError(t, err)
+	password, err := store.GetPassword(input.UserID, input.Title, input.Username)
+	assert.NoError(t, err)
+	assert.Equal(t, input, password)
+}
+
+func TestShouldUpdatePassword(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	input := model.NewPassword(test.RandomString(), test.RandomString(), test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	test.InsertIntoPasswords(t, db, input)
+	updated := input
+	updated.Title = test.RandomString()
+	updated.Password = test.RandomString()
+	updated.Notes = test.RandomString()
+	updated.Type = model.ItemTypeAPIToken
+	updated.Payload = test.RandomString()
+
+	// when
+	err = store.UpdatePassword(updated)
+
+	// then
+	assert.NoError(t, err)
+	password := test.GetPassword(t, db, updated.UserID, updated.Title, updated.Username)
+	assert.Equal(t, updated, password)
+}
+
+func TestShouldNotUpdateMissingPassword(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	input := model.NewPassword(test.RandomString(), test.RandomString(), test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+
+	// expected
+	expectedError := model.NewPasswordNotFoundError(input.UserID, input.Title, input.Username)
+
+	// when
+	err = store.UpdatePassword(input)
+
+	// then
+	assert.EqualError(t, err, expectedError.Error())
+}
+
+func TestShouldNotUpdatePasswordToExistingTitleAndUsername(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	userID := test.RandomString()
+	first := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	second := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	test.InsertIntoPasswords(t, db, first)
+	test.InsertIntoPasswords(t, db, second)
+	second.Title = first.Title
+	second.Username = first.Username
+
+	// expected
+	expectedError := model.NewPasswordAlreadyExistsError(userID, first.Title, first.Username)
+
+	// when
+	err = store.UpdatePassword(second)
+
+	// then
+	assert.EqualError(t, err, expectedError.Error())
+}
+
+func TestShouldClearPasswordPlacement(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	userID := test.RandomString()
+	password := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	folder := model.NewFolder(test.RandomString(), userID, "", test.RandomString())
+	tag := model.NewTag(test.RandomString(), userID, test.RandomString())
+	test.InsertIntoPasswords(t, db, password)
+	test.InsertIntoFolders(t, db, folder)
+	test.InsertIntoTags(t, db, tag)
+	test.LinkPasswordToFolder(t, db, password.ID, folder.ID)
+	test.LinkPasswordToTag(t, db, password.ID, tag.ID)
+
+	// when
+	err = store.ClearPasswordPlacement(password.ID)
+
+	// then
+	assert.NoError(t, err)
+	folders, err := store.GetPasswordFolders(password.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, folders)
+	tags, err := store.GetPasswordTags(password.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, tags)
+}
+
+func TestShouldAddAndGetPasswordAttachments(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	passwordID := test.RandomString()
+	first := model.NewAttachment(test.RandomString(), passwordID, test.RandomString(), 10, test.RandomString())
+	second := model.NewAttachment(test.RandomString(), passwordID, test.RandomString(), 20, test.RandomString())
+	other := model.NewAttachment(test.RandomString(), test.RandomString(), test.RandomString(), 30, test.RandomString())
+
+	// when
+	for _, attachment := range []model.Attachment{first, second, other} {
+		err = store.AddAttachment(attachment)
+		assert.NoError(t, err)
+	}
+	attachments, err := store.GetPasswordAttachments(passwordID)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Attachment{first, second}, attachments)
+}
+
+func TestShouldDeleteAttachment(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	attachment := model.NewAttachment(test.RandomString(), test.RandomString(), test.RandomString(), 10, test.RandomString())
+	err = store.AddAttachment(attachment)
+	assert.NoError(t, err)
+
+	// when
+	err = store.DeleteAttachment(attachment.ID)
+
+	// then
+	assert.NoError(t, err)
+	attachments, err := store.GetPasswordAttachments(attachment.PasswordID)
+	assert.NoError(t, err)
+	assert.Empty(t, attachments)
+}
+
+func TestShouldNotDeleteMissingAttachment(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	id := test.RandomString()
+
+	// when
+	err = store.DeleteAttachment(id)
+
+	// then
+	assert.EqualError(t, err, model.NewAttachmentNotFoundError(id).Error())
+}
+
+func TestShouldIncrementOTPCounter(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	userID := test.RandomString()
+	password := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	password.OTP = test.RandomString()
+	password.OTPCounter = 5
+	test.InsertIntoPasswords(t, db, password)
+
+	// when
+	first, err := store.IncrementOTPCounter(password.ID)
+	assert.NoError(t, err)
+	second, err := store.IncrementOTPCounter(password.ID)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), first)
+	assert.Equal(t, uint64(6), second)
+	stored := test.GetPassword(t, db, userID, password.Title, password.Username)
+	assert.Equal(t, uint64(7), stored.OTPCounter)
+}
+
+func TestShouldNotIncrementOTPCounterOfMissingPassword(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	id := test.RandomString()
+
+	// when
+	_, err = store.IncrementOTPCounter(id)
+
+	// then
+	assert.EqualError(t, err, "failed to increment otp counter: password "+id+" not found")
+}
+
+func TestShouldCommitOperationsOfTransaction(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	userID := test.RandomString()
+	first := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	second := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	folder := model.NewFolder(test.RandomString(), userID, "", test.RandomString())
+
+	// when
+	err = store.WithTransaction(func(tx StoreExecutor) error {
+		if err := tx.AddPassword(first); err != nil {
+			return err
+		}
+		if err := tx.AddPassword(second); err != nil {
+			return err
+		}
+		if err := tx.CreateFolder(folder); err != nil {
+			return err
+		}
+		return tx.AddPasswordToFolder(second.ID, folder.ID)
+	})
+
+	// then
+	assert.NoError(t, err)
+	passwords, err := store.GetAllUserPasswords(userID)
+	assert.NoError(t, err)
+	assert.Len(t, passwords, 2)
+	inFolder, err := store.GetPasswordsByFolder(userID, folder.ID)
+	assert.NoError(t, err)
+	assert.Len(t, inFolder, 1)
+}
+
+func TestShouldRollBackTransactionWhenFunctionFails(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	userID := test.RandomString()
+	password := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	fields := []model.CustomField{{Type: model.FieldTypeText, Name: test.RandomString(), Value: test.RandomString()}}
+	failure := errors.New("import failed")
+
+	// when
+	err = store.WithTransaction(func(tx StoreExecutor) error {
+		if err := tx.AddPassword(password); err != nil {
+			return err
+		}
+		if err := tx.SetPasswordFields(password.ID, fields); err != nil {
+			return err
+		}
+		return failure
+	})
+
+	// then
+	assert.ErrorIs(t, err, failure)
+	passwords, err := store.GetAllUserPasswords(userID)
+	assert.NoError(t, err)
+	assert.Empty(t, passwords)
+	stored, err := store.GetPasswordFields(password.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, stored)
+}
+
+func TestShouldContinueTransactionAfterDuplicatePassword(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	userID := test.RandomString()
+	existing := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	test.InsertIntoPasswords(t, db, existing)
+	duplicate := model.NewPassword(test.RandomString(), userID, existing.Title, existing.Username, test.RandomString(), "", []byte{})
+	added := model.NewPassword(test.RandomString(), userID, test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+
+	// when
+	var duplicateErr error
+	err = store.WithTransaction(func(tx StoreExecutor) error {
+		duplicateErr = tx.AddPassword(duplicate)
+		return tx.AddPassword(added)
+	})
+
+	// then
+	assert.NoError(t, err)
+	assert.EqualError(t, duplicateErr, model.NewPasswordAlreadyExistsError(userID, existing.Title, existing.Username).Error())
+	passwords, err := store.GetAllUserPasswords(userID)
+	assert.NoError(t, err)
+	assert.Len(t, passwords, 2)
+}
+
+func TestShouldSetPasswordOwner(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	input := model.NewPassword(test.RandomString(), test.RandomString(), test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	test.InsertIntoPasswords(t, db, input)
+	collectionID := test.RandomString()
+
+	// when
+	err = store.SetPasswordOwner(input, collectionID)
+
+	// then
+	assert.NoError(t, err)
+	moved := test.GetPassword(t, db, collectionID, input.Title, input.Username)
+	assert.Equal(t, input.ID, moved.ID)
+	passwords, err := store.GetAllUserPasswords(input.UserID)
+	assert.NoError(t, err)
+	assert.Empty(t, passwords)
+}
+
+func TestShouldNotSetPasswordOwnerHoldingSameTitleAndUsername(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	input := model.NewPassword(test.RandomString(), test.RandomString(), test.RandomString(), test.RandomString(), test.RandomString(), "", []byte{})
+	test.InsertIntoPasswords(t, db, input)
+	collectionID := test.RandomString()
+	existing := model.NewPassword(test.RandomString(), collectionID, input.Title, input.Username, test.RandomString(), "", []byte{})
+	test.InsertIntoPasswords(t, db, existing)
+
+	// expected
+	expectedError := model.NewPasswordAlreadyExistsError(collectionID, input.Title, input.Username)
+
+	// when
+	err = store.SetPasswordOwner(input, collectionID)
+
+	// then
+	assert.EqualError(t, err, expectedError.Error())
+}
+
+func TestShouldCreateAndGetUserKey(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	key := model.NewUserKey(test.RandomString(), []byte(test.RandomString()), []byte(test.RandomString()))
+
+	// when
+	err = store.CreateUserKey(key)
+	assert.NoError(t, err)
+	stored, err := store.GetUserKey(key.UserID)
+	_, missingErr := store.GetUserKey(test.RandomString())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, key, stored)
+	assert.True(t, errors.As(missingErr, &model.UserKeyNotFoundError{}))
+}
+
+func TestShouldAddCollectionMembersAndGetCollections(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	alice := model.NewUser(test.RandomString(), "alice", test.RandomString(), test.RandomString())
+	bob := model.NewUser(test.RandomString(), "bob", test.RandomString(), test.RandomString())
+	test.InsertIntoUsers(t, db, alice)
+	test.InsertIntoUsers(t, db, bob)
+	collection := model.NewCollection(test.RandomString(), test.RandomString())
+	admin := model.NewCollectionMember(collection.ID, alice.UserID, model.RoleAdmin, []byte(test.RandomString()))
+	reader := model.NewCollectionMember(collection.ID, bob.UserID, model.RoleRead, []byte(test.RandomString()))
+
+	// when
+	assert.NoError(t, store.CreateCollection(collection))
+	assert.NoError(t, store.AddCollectionMember(admin))
+	assert.NoError(t, store.AddCollectionMember(reader))
+	duplicateErr := store.AddCollectionMember(reader)
+	collections, err := store.GetUserCollections(bob.UserID)
+	assert.NoError(t, err)
+	members, err := store.GetCollectionMembers(collection.ID)
+
+	// then
+	assert.NoError(t, err)
+	assert.EqualError(t, duplicateErr, model.NewMemberAlreadyExistsError(collection.ID, bob.UserID).Error())
+	reader.Username, reader.Name = "bob", collection.Name
+	admin.Username, admin.Name = "alice", collection.Name
+	assert.Equal(t, []model.CollectionMember{reader}, collections)
+	assert.Equal(t, []model.CollectionMember{admin, reader}, members)
+}
+
+func TestShouldCreateUpdateAndGetEmergencyAccess(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	alice := model.NewUser(test.RandomString(), "alice", test.RandomString(), test.RandomString())
+	bob := model.NewUser(test.RandomString(), "bob", test.RandomString(), test.RandomString())
+	carol := model.NewUser(test.RandomString(), "carol", test.RandomString(), test.RandomString())
+	test.InsertIntoUsers(t, db, alice)
+	test.InsertIntoUsers(t, db, bob)
+	test.InsertIntoUsers(t, db, carol)
+	toCarol := model.NewEmergencyAccess(alice.UserID, carol.UserID, 7)
+	toBob := model.NewEmergencyAccess(alice.UserID, bob.UserID, 2)
+
+	// when
+	assert.NoError(t, store.CreateEmergencyAccess(toCarol))
+	assert.NoError(t, store.CreateEmergencyAccess(toBob))
+	duplicateErr := store.CreateEmergencyAccess(toBob)
+	toBob.Status, toBob.RequestedAt, toBob.SealedKey = model.EmergencyApproved, 1234, []byte(test.RandomString())
+	assert.NoError(t, store.UpdateEmergencyAccess(toBob))
+	stored, err := store.GetEmergencyAccess(alice.UserID, bob.UserID)
+	assert.NoError(t, err)
+	contacts, err := store.GetEmergencyContacts(alice.UserID)
+	assert.NoError(t, err)
+	grants, err := store.GetEmergencyGrants(carol.UserID)
+
+	// then
+	assert.NoError(t, err)
+	assert.EqualError(t, duplicateErr, model.NewEmergencyAccessAlreadyExistsError(alice.UserID, bob.UserID).Error())
+	toBob.OwnerName, toBob.GranteeName = "alice", "bob"
+	toCarol.OwnerName, toCarol.GranteeName = "alice", "carol"
+	assert.Equal(t, toBob, stored)
+	assert.Equal(t, []model.EmergencyAccess{toBob, toCarol}, contacts)
+	assert.Equal(t, []model.EmergencyAccess{toCarol}, grants)
+}
+
+func TestShouldDeleteEmergencyAccess(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	alice := model.NewUser(test.RandomString(), "alice", test.RandomString(), test.RandomString())
+	bob := model.NewUser(test.RandomString(), "bob", test.RandomString(), test.RandomString())
+	test.InsertIntoUsers(t, db, alice)
+	test.InsertIntoUsers(t, db, bob)
+	assert.NoError(t, store.CreateEmergencyAccess(model.NewEmergencyAccess(alice.UserID, bob.UserID, 7)))
+
+	// when
+	err = store.DeleteEmergencyAccess(alice.UserID, bob.UserID)
+	_, missingErr := store.GetEmergencyAccess(alice.UserID, bob.UserID)
+	deleteAgainErr := store.DeleteEmergencyAccess(alice.UserID, bob.UserID)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, errors.As(missingErr, &model.EmergencyAccessNotFoundError{}))
+	assert.True(t, errors.As(deleteAgainErr, &model.EmergencyAccessNotFoundError{}))
+}
+
+func TestShouldAppendChainedAuditRecords(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	alice, bob := test.RandomString(), test.RandomString()
+	hash := func(record model.AuditRecord) string { return string(record.ChainData()) }
+
+	// when
+	first, err := store.AppendAuditRecord(model.AuditRecord{UserID: alice, CreatedAt: 1, Data: "first"}, hash)
+	assert.NoError(t, err)
+	second, err := store.AppendAuditRecord(model.AuditRecord{UserID: bob, CreatedAt: 2, Data: "second"}, hash)
+	assert.NoError(t, err)
+	third, err := store.AppendAuditRecord(model.AuditRecord{UserID: alice, CreatedAt: 3, Data: "third"}, hash)
+	assert.NoError(t, err)
+	all, err := store.GetAuditLog()
+	assert.NoError(t, err)
+	length, err := store.GetAuditLogLength()
+	assert.NoError(t, err)
+	ofAlice, err := store.GetUserAuditLog(alice)
+
+	// then
+	assert.NoError(t, err)
+	assert.Empty(t, first.PrevHash)
+	assert.Equal(t, first.Hash, second.PrevHash)
+	assert.Equal(t, second.Hash, third.PrevHash)
+	assert.Equal(t, int64(3), third.Seq)
+	assert.Equal(t, hash(third), third.Hash)
+	assert.Equal(t, int64(3), length)
+	assert.Equal(t, []model.AuditRecord{first, second, third}, all)
+	assert.Equal(t, []model.AuditRecord{first, third}, ofAlice)
+}
+
+func TestShouldNotUpdateOrDeleteAuditRecords(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	record, err := store.AppendAuditRecord(model.AuditRecord{UserID: test.RandomString(), CreatedAt: 1, Data: "sealed"},
+		func(model.AuditRecord) string { return "hash" })
+	assert.NoError(t, err)
+
+	// when
+	_, updateErr := db.Exec(`UPDATE audit_log SET data = 'forged' WHERE seq = $1`, record.Seq)
+	_, deleteErr := db.Exec(`DELETE FROM audit_log WHERE seq = $1`, record.Seq)
+	all, err := store.GetAuditLog()
+
+	// then
+	assert.NoError(t, err)
+	assert.ErrorContains(t, updateErr, "audit log is append-only")
+	assert.ErrorContains(t, deleteErr, "audit log is append-only")
+	assert.Equal(t, []model.AuditRecord{record}, all)
+}
+
+func TestShouldSaveGetAndDeleteLoginAttempts(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	usernameHash := test.RandomString()
+	none, err := store.GetLoginAttempts(usernameHash)
+	assert.NoError(t, err)
+
+	// when
+	assert.NoError(t, store.SaveLoginAttempts(model.LoginAttempts{UsernameHash: usernameHash, Failures: 1, LastFailure: 1}))
+	assert.NoError(t, store.SaveLoginAttempts(model.LoginAttempts{UsernameHash: usernameHash, Failures: 2, LastFailure: 2}))
+	saved, err := store.GetLoginAttempts(usernameHash)
+	assert.NoError(t, err)
+	assert.NoError(t, store.DeleteLoginAttempts(usernameHash))
+	deleted, err := store.GetLoginAttempts(usernameHash)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, model.LoginAttempts{UsernameHash: usernameHash}, none)
+	assert.Equal(t, model.LoginAttempts{UsernameHash: usernameHash, Failures: 2, LastFailure: 2}, saved)
+	assert.Equal(t, model.LoginAttempts{UsernameHash: usernameHash}, deleted)
+}
+
+func TestShouldSaveActivateAndDeleteGeneratorProfiles(t *testing.T) {
+	// setup
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	defer test.TeardownTestDB(db)
+	store := NewStore(db)
+
+	// given
+	userID := test.RandomString()
+	work := model.NewGeneratorProfile(userID, "work", model.PasswordPolicy{Length: 32, Lower: true, MinLower: 2, SymbolSet: "#!", NoRepeat: true})
+	pin := model.NewGeneratorProfile(userID, "pin", model.PasswordPolicy{Length: 6, Digits: true})
+	assert.NoError(t, store.SaveGeneratorProfile(work))
+	assert.NoError(t, store.SaveGeneratorProfile(pin))
+	assert.NoError(t, store.SaveGeneratorProfile(model.NewGeneratorProfile(test.RandomString(), "work", model.DefaultPasswordPolicy)))
+
+	// when
+	assert.NoError(t, store.ActivateGeneratorProfile(userID, "pin"))
+	assert.NoError(t, store.ActivateGeneratorProfile(userID, "work"))
+	work.Length = 40
+	work.Mode, work.Words, work.Wordlist, work.Separator = model.GeneratorModePassphrase, 7, model.WordlistEFFShort, " "
+	work.Capitalization, work.AppendDigit, work.AppendSymbol = model.CapitalizeRandom, true, true
+	assert.NoError(t, store.SaveGeneratorProfile(work))
+	missingErr := store.ActivateGeneratorProfile(userID, "missing")
+	profiles, err := store.GetGeneratorProfiles(userID)
+	assert.NoError(t, err)
+	assert.NoError(t, store.DeleteGeneratorProfile(userID, "pin"))
+	deleteAgainErr := store.DeleteGeneratorProfile(userID, "pin")
+	remaining, err := store.GetGeneratorProfiles(userID)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, errors.As(missingErr, &model.GeneratorProfileNotFoundError{}))
+	assert.True(t, errors.As(deleteAgainErr, &model.GeneratorProfileNotFoundError{}))
+	work.Active = true
+	assert.Equal(t, []model.GeneratorProfile{pin, work}, profiles)
+	assert.Equal(t, []model.GeneratorProfile{work}, remaining)
+}
@@ -0,0 +1,6 @@
+package test
+
+import "yubigo-pass/internal/app/keepass"
+
+// KeePassOptions write KeePass databases with a cheap key derivation for testing purposes
+var KeePassOptions = keepass.Options{Cipher: keepass.CipherAES256, KDF: keepass.KDF{Type: keepass.KDFTypeAES, Rounds: 10}}
@@ -0,0 +1,4 @@
+package test
+
+// OTPSecret is the base32 encoding of the RFC 4226 test secret "12345678901234567890"
+const OTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
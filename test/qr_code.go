@@ -0,0 +1,76 @@
+package test
+
+import (
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const qrCodeSize = 300
+
+// MigrationAccount is an account of a Google Authenticator export for testing purposes
+type MigrationAccount struct {
+	Secret  []byte
+	Name    string
+	Issuer  string
+	HOTP    bool
+	Counter uint64
+}
+
+// MigrationURI encodes accounts as an otpauth-migration:// URI like Google Authenticator exports
+func MigrationURI(accounts ...MigrationAccount) string {
+	var payload []byte
+	for _, account := range accounts {
+		var parameters []byte
+		parameters = protowire.AppendTag(parameters, 1, protowire.BytesType)
+		parameters = protowire.AppendBytes(parameters, account.Secret)
+		parameters = protowire.AppendTag(parameters, 2, protowire.BytesType)
+		parameters = protowire.AppendString(parameters, account.Name)
+		parameters = protowire.AppendTag(parameters, 3, protowire.BytesType)
+		parameters = protowire.AppendString(parameters, account.Issuer)
+		otpType := uint64(2)
+		if account.HOTP {
+			otpType = 1
+		}
+		parameters = protowire.AppendTag(parameters, 6, protowire.VarintType)
+		parameters = protowire.AppendVarint(parameters, otpType)
+		parameters = protowire.AppendTag(parameters, 7, protowire.VarintType)
+		parameters = protowire.AppendVarint(parameters, account.Counter)
+
+		payload = protowire.AppendTag(payload, 1, protowire.BytesType)
+		payload = protowire.AppendBytes(payload, parameters)
+	}
+	return "otpauth-migration://offline?data=" + url.QueryEscape(base64.StdEncoding.EncodeToString(payload))
+}
+
+// WriteQRCode writes a PNG image holding a QR code of every text, side by side, for testing purposes
+func WriteQRCode(t *testing.T, path string, texts ...string) {
+	t.Helper()
+	canvas := image.NewGray(image.Rect(0, 0, qrCodeSize*len(texts), qrCodeSize))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for i, text := range texts {
+		code, err := qrcode.NewQRCodeWriter().Encode(text, gozxing.BarcodeFormat_QR_CODE, qrCodeSize, qrCodeSize, nil)
+		if err != nil {
+			t.Fatalf("Failed to encode QR code: %v", err)
+		}
+		draw.Draw(canvas, code.Bounds().Add(image.Pt(i*qrCodeSize, 0)), code, image.Point{}, draw.Src)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create QR code image: %v", err)
+	}
+	defer file.Close()
+	if err = png.Encode(file, canvas); err != nil {
+		t.Fatalf("Failed to write QR code image: %v", err)
+	}
+}
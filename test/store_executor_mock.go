@@ -35,3 +35,213 @@ func (s StoreExecutorMock) GetPassword(userID, title, username string) (model.Pa
 func (s StoreExecutorMock) GetAllUserPasswords(userID string) ([]model.Password, error) {
 	return []model.Password{}, nil
 }
+
+// CreateFolder mocks StoreExecutor CreateFolder method
+func (s StoreExecutorMock) CreateFolder(folder model.Folder) error {
+	return nil
+}
+
+// GetAllUserFolders mocks StoreExecutor GetAllUserFolders method
+func (s StoreExecutorMock) GetAllUserFolders(userID string) ([]model.Folder, error) {
+	return []model.Folder{}, nil
+}
+
+// CreateTag mocks StoreExecutor CreateTag method
+func (s StoreExecutorMock) CreateTag(tag model.Tag) error {
+	return nil
+}
+
+// GetAllUserTags mocks StoreExecutor GetAllUserTags method
+func (s StoreExecutorMock) GetAllUserTags(userID string) ([]model.Tag, error) {
+	return []model.Tag{}, nil
+}
+
+// AddPasswordToFolder mocks StoreExecutor AddPasswordToFolder method
+func (s StoreExecutorMock) AddPasswordToFolder(passwordID, folderID string) error {
+	return nil
+}
+
+// AddTagToPassword mocks StoreExecutor AddTagToPassword method
+func (s StoreExecutorMock) AddTagToPassword(passwordID, tagID string) error {
+	return nil
+}
+
+// GetPasswordsByFolder mocks StoreExecutor GetPasswordsByFolder method
+func (s StoreExecutorMock) GetPasswordsByFolder(userID, folderID string) ([]model.Password, error) {
+	return []model.Password{}, nil
+}
+
+// GetPasswordsByTag mocks StoreExecutor GetPasswordsByTag method
+func (s StoreExecutorMock) GetPasswordsByTag(userID, tagID string) ([]model.Password, error) {
+	return []model.Password{}, nil
+}
+
+// GetPasswordFolders mocks StoreExecutor GetPasswordFolders method
+func (s StoreExecutorMock) GetPasswordFolders(passwordID string) ([]model.Folder, error) {
+	return []model.Folder{}, nil
+}
+
+// GetPasswordTags mocks StoreExecutor GetPasswordTags method
+func (s StoreExecutorMock) GetPasswordTags(passwordID string) ([]model.Tag, error) {
+	return []model.Tag{}, nil
+}
+
+// SetPasswordFields mocks StoreExecutor SetPasswordFields method
+func (s StoreExecutorMock) SetPasswordFields(passwordID string, fields []model.CustomField) error {
+	return nil
+}
+
+// GetPasswordFields mocks StoreExecutor GetPasswordFields method
+func (s StoreExecutorMock) GetPasswordFields(passwordID string) ([]model.CustomField, error) {
+	return []model.CustomField{}, nil
+}
+
+// UpdatePassword mocks StoreExecutor UpdatePassword method
+func (s StoreExecutorMock) UpdatePassword(input model.Password) error {
+	return nil
+}
+
+// ClearPasswordPlacement mocks StoreExecutor ClearPasswordPlacement method
+func (s StoreExecutorMock) ClearPasswordPlacement(passwordID string) error {
+	return nil
+}
+
+// AddAttachment mocks StoreExecutor AddAttachment method
+func (s StoreExecutorMock) AddAttachment(attachment model.Attachment) error {
+	return nil
+}
+
+// GetPasswordAttachments mocks StoreExecutor GetPasswordAttachments method
+func (s StoreExecutorMock) GetPasswordAttachments(passwordID string) ([]model.Attachment, error) {
+	return []model.Attachment{}, nil
+}
+
+// DeleteAttachment mocks StoreExecutor DeleteAttachment method
+func (s StoreExecutorMock) DeleteAttachment(id string) error {
+	return nil
+}
+
+// IncrementOTPCounter mocks StoreExecutor IncrementOTPCounter method
+func (s StoreExecutorMock) IncrementOTPCounter(passwordID string) (uint64, error) {
+	return 0, nil
+}
+
+// SetPasswordOwner mocks StoreExecutor SetPasswordOwner method
+func (s StoreExecutorMock) SetPasswordOwner(password model.Password, ownerID string) error {
+	return nil
+}
+
+// CreateUserKey mocks StoreExecutor CreateUserKey method
+func (s StoreExecutorMock) CreateUserKey(key model.UserKey) error {
+	return nil
+}
+
+// GetUserKey mocks StoreExecutor GetUserKey method
+func (s StoreExecutorMock) GetUserKey(userID string) (model.UserKey, error) {
+	return model.UserKey{}, nil
+}
+
+// CreateCollection mocks StoreExecutor CreateCollection method
+func (s StoreExecutorMock) CreateCollection(collection model.Collection) error {
+	return nil
+}
+
+// AddCollectionMember mocks StoreExecutor AddCollectionMember method
+func (s StoreExecutorMock) AddCollectionMember(member model.CollectionMember) error {
+	return nil
+}
+
+// GetUserCollections mocks StoreExecutor GetUserCollections method
+func (s StoreExecutorMock) GetUserCollections(userID string) ([]model.CollectionMember, error) {
+	return []model.CollectionMember{}, nil
+}
+
+// GetCollectionMembers mocks StoreExecutor GetCollectionMembers method
+func (s StoreExecutorMock) GetCollectionMembers(collectionID string) ([]model.CollectionMember, error) {
+	return []model.CollectionMember{}, nil
+}
+
+// CreateEmergencyAccess mocks StoreExecutor CreateEmergencyAccess method
+func (s StoreExecutorMock) CreateEmergencyAccess(access model.EmergencyAccess) error {
+	return nil
+}
+
+// GetEmergencyAccess mocks StoreExecutor GetEmergencyAccess method
+func (s StoreExecutorMock) GetEmergencyAccess(ownerID, granteeID string) (model.EmergencyAccess, error) {
+	return model.EmergencyAccess{}, nil
+}
+
+// UpdateEmergencyAccess mocks StoreExecutor UpdateEmergencyAccess method
+func (s StoreExecutorMock) UpdateEmergencyAccess(access model.EmergencyAccess) error {
+	return nil
+}
+
+// DeleteEmergencyAccess mocks StoreExecutor DeleteEmergencyAccess method
+func (s StoreExecutorMock) DeleteEmergencyAccess(ownerID, granteeID string) error {
+	return nil
+}
+
+// GetEmergencyContacts mocks StoreExecutor GetEmergencyContacts method
+func (s StoreExecutorMock) GetEmergencyContacts(ownerID string) ([]model.EmergencyAccess, error) {
+	return []model.EmergencyAccess{}, nil
+}
+
+// GetEmergencyGrants mocks StoreExecutor GetEmergencyGrants method
+func (s StoreExecutorMock) GetEmergencyGrants(granteeID string) ([]model.EmergencyAccess, error) {
+	return []model.EmergencyAccess{}, nil
+}
+
+// AppendAuditRecord mocks StoreExecutor AppendAuditRecord method
+func (s StoreExecutorMock) AppendAuditRecord(record model.AuditRecord, hash func(model.AuditRecord) string) (model.AuditRecord, error) {
+	return record, nil
+}
+
+// GetAuditLog mocks StoreExecutor GetAuditLog method
+func (s StoreExecutorMock) GetAuditLog() ([]model.AuditRecord, error) {
+	return []model.AuditRecord{}, nil
+}
+
+// GetAuditLogLength mocks StoreExecutor GetAuditLogLength method
+func (s StoreExecutorMock) GetAuditLogLength() (int64, error) {
+	return 0, nil
+}
+
+// GetUserAuditLog mocks StoreExecutor GetUserAuditLog method
+func (s StoreExecutorMock) GetUserAuditLog(userID string) ([]model.AuditRecord, error) {
+	return []model.AuditRecord{}, nil
+}
+
+// GetLoginAttempts mocks StoreExecutor GetLoginAttempts method
+func (s StoreExecutorMock) GetLoginAttempts(usernameHash string) (model.LoginAttempts, error) {
+	return model.LoginAttempts{UsernameHash: usernameHash}, nil
+}
+
+// SaveLoginAttempts mocks StoreExecutor SaveLoginAttempts method
+func (s StoreExecutorMock) SaveLoginAttempts(attempts model.LoginAttempts) error {
+	return nil
+}
+
+// DeleteLoginAttempts mocks StoreExecutor DeleteLoginAttempts method
+func (s StoreExecutorMock) DeleteLoginAttempts(usernameHash string) error {
+	return nil
+}
+
+// GetGeneratorProfiles mocks StoreExecutor GetGeneratorProfiles method
+func (s StoreExecutorMock) GetGeneratorProfiles(userID string) ([]model.GeneratorProfile, error) {
+	return nil, nil
+}
+
+// SaveGeneratorProfile mocks StoreExecutor SaveGeneratorProfile method
+func (s StoreExecutorMock) SaveGeneratorProfile(profile model.GeneratorProfile) error {
+	return nil
+}
+
+// ActivateGeneratorProfile mocks StoreExecutor ActivateGeneratorProfile method
+func (s StoreExecutorMock) ActivateGeneratorProfile(userID, name string) error {
+	return nil
+}
+
+// DeleteGeneratorProfile mocks StoreExecutor DeleteGeneratorProfile method
+func (s StoreExecutorMock) DeleteGeneratorProfile(userID, name string) error {
+	return nil
+}
@@ -47,6 +47,17 @@ func SetupTestDB() (*sqlx.DB, error) {
 	return db, nil
 }
 
+// NewDB sets up in-memory database closed at the end of the test
+func NewDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db, err := SetupTestDB()
+	if err != nil {
+		t.Fatalf("failed to set up test database: %s", err)
+	}
+	t.Cleanup(func() { TeardownTestDB(db) })
+	return db
+}
+
 // TeardownTestDB closes in-memory test database
 func TeardownTestDB(db *sqlx.DB) {
 	_ = db.Close()
@@ -64,11 +75,53 @@ func InsertIntoUsers(t *testing.T, db *sqlx.DB, input model.User) {
 
 // InsertIntoPasswords inserts record into passwords table for testing purposes
 func InsertIntoPasswords(t *testing.T, db *sqlx.DB, input model.Password) {
-	query := `INSERT INTO passwords (user_id, title, username, password, url, nonce) VALUES ($1, $2, $3, $4, $5, $6)`
+	query := `INSERT INTO passwords (id, user_id, title, username, password, url, nonce, notes, type, payload, otp, otp_counter)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
 
-	_, err := db.Exec(query, input.UserID, input.Title, input.Username, input.Password, input.Url, input.Nonce)
+	_, err := db.Exec(query, input.ID, input.UserID, input.Title, input.Username, input.Password, input.Url, input.Nonce,
+		input.Notes, input.ItemType(), input.Payload, input.OTP, input.OTPCounter)
 	if err != nil {
-		t.Fatalf("failed to create user: %s", err)
+		t.Fatalf("failed to create password: %s", err)
+	}
+}
+
+// InsertIntoFolders inserts record into folders table for testing purposes
+func InsertIntoFolders(t *testing.T, db *sqlx.DB, input model.Folder) {
+	query := `INSERT INTO folders (id, user_id, parent_id, name) VALUES ($1, $2, $3, $4)`
+
+	_, err := db.Exec(query, input.ID, input.UserID, input.ParentID, input.Name)
+	if err != nil {
+		t.Fatalf("failed to create folder: %s", err)
+	}
+}
+
+// InsertIntoTags inserts record into tags table for testing purposes
+func InsertIntoTags(t *testing.T, db *sqlx.DB, input model.Tag) {
+	query := `INSERT INTO tags (id, user_id, name) VALUES ($1, $2, $3)`
+
+	_, err := db.Exec(query, input.ID, input.UserID, input.Name)
+	if err != nil {
+		t.Fatalf("failed to create tag: %s", err)
+	}
+}
+
+// LinkPasswordToFolder inserts record into password_folders table for testing purposes
+func LinkPasswordToFolder(t *testing.T, db *sqlx.DB, passwordID, folderID string) {
+	query := `INSERT INTO password_folders (password_id, folder_id) VALUES ($1, $2)`
+
+	_, err := db.Exec(query, passwordID, folderID)
+	if err != nil {
+		t.Fatalf("failed to link password to folder: %s", err)
+	}
+}
+
+// LinkPasswordToTag inserts record into password_tags table for testing purposes
+func LinkPasswordToTag(t *testing.T, db *sqlx.DB, passwordID, tagID string) {
+	query := `INSERT INTO password_tags (password_id, tag_id) VALUES ($1, $2)`
+
+	_, err := db.Exec(query, passwordID, tagID)
+	if err != nil {
+		t.Fatalf("failed to link password to tag: %s", err)
 	}
 }
 
@@ -0,0 +1,25 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// BitwardenExport reads an unencrypted export as written by the Bitwarden web vault, holding a
+// login filed in a folder, a secure note, a card, an identity and an item of a type without a
+// counterpart
+func BitwardenExport(t *testing.T) []byte {
+	return readTestData(t, "bitwarden_export.json")
+}
+
+func readTestData(t *testing.T, name string) []byte {
+	t.Helper()
+	_, b, _, _ := runtime.Caller(0)
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(b), "testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+	return data
+}
@@ -0,0 +1,27 @@
+// Package usertest adds users to test databases. It is kept apart from package test, which the
+// tests of the crypto package import themselves, and from package vaulttest, which the tests of
+// the vault package cannot import.
+package usertest
+
+import (
+	"testing"
+	"yubigo-pass/internal/app/crypto"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/test"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Insert adds a user logging in with username and password to the database and returns their session
+func Insert(t *testing.T, db *sqlx.DB, username, password string) utils.Session {
+	t.Helper()
+	salt, err := crypto.NewSalt()
+	if err != nil {
+		t.Fatalf("failed to generate salt: %s", err)
+	}
+	user := model.NewUser(uuid.New().String(), username, crypto.HashPasswordWithSalt(password, salt), salt)
+	test.InsertIntoUsers(t, db, user)
+	return utils.NewSession(user.UserID, password, salt)
+}
@@ -0,0 +1,25 @@
+package vaulttest
+
+import (
+	"testing"
+	"yubigo-pass/internal/app/model"
+	"yubigo-pass/internal/app/vault"
+)
+
+// AssertLastAuditEvent checks the latest event of the audit log of the vault is event about the
+// whole vault, recorded through client
+func AssertLastAuditEvent(t *testing.T, v vault.Vault, event model.AuditEvent, client model.AuditClient) {
+	t.Helper()
+	events, err := v.AuditLog(vault.AuditFilter{})
+	if err != nil {
+		t.Fatalf("failed to read audit log: %s", err)
+	}
+	if len(events) == 0 {
+		t.Fatalf("audit log is empty, expected %s event", event)
+	}
+	last := events[0]
+	if last.Event != event || last.Client != client || last.EntryID != "" {
+		t.Errorf("last audit event is %s through %s about entry %q, expected %s through %s about the vault",
+			last.Event, last.Client, last.EntryID, event, client)
+	}
+}
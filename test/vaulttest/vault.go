@@ -0,0 +1,42 @@
+// Package vaulttest opens vaults on test databases. It is kept apart from package test, which the
+// tests of the vault and database packages import themselves.
+package vaulttest
+
+import (
+	"testing"
+	"yubigo-pass/internal/app/utils"
+	"yubigo-pass/internal/app/vault"
+	"yubigo-pass/internal/database"
+	"yubigo-pass/test"
+	"yubigo-pass/test/usertest"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// New sets up an in-memory database closed at the end of the test and opens the vault of a random
+// user on it
+func New(t *testing.T) vault.Vault {
+	t.Helper()
+	v, _ := NewWithDB(t)
+	return v
+}
+
+// NewWithDB works like New and also returns the database, for tests reaching into it
+func NewWithDB(t *testing.T) (vault.Vault, *sqlx.DB) {
+	t.Helper()
+	db := test.NewDB(t)
+	session := utils.NewSession(uuid.New().String(), test.RandomString(), test.RandomString())
+	return vault.New(database.NewStore(db), session), db
+}
+
+// NewUser adds a user logging in with username and password to the database, along with their key
+// pair, and opens their vault
+func NewUser(t *testing.T, db *sqlx.DB, username, password string) vault.Vault {
+	t.Helper()
+	v := vault.New(database.NewStore(db), usertest.Insert(t, db, username, password))
+	if _, err := v.EnsureKeyPair(); err != nil {
+		t.Fatalf("failed to generate key pair: %s", err)
+	}
+	return v
+}